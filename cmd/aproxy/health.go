@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+// backendPinger checks that PostgreSQL is reachable
+type backendPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// drainState reports whether the proxy is shutting down
+type drainState interface {
+	Draining() bool
+}
+
+// registerHealthHandlers registers the health endpoints
+//
+//	/livez  - the process is up and serving HTTP, never fails on backend trouble
+//	/readyz - the proxy accepts work: not draining and PostgreSQL answers a ping
+//	/health - same as /readyz, kept for existing probes and load balancers
+//
+// Kubernetes restarts a pod whose liveness probe fails, so a brief PostgreSQL outage
+// should only take the pod out of rotation through readiness
+func registerHealthHandlers(mux *http.ServeMux, backend backendPinger, drain drainState) {
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	ready := func(w http.ResponseWriter, r *http.Request) {
+		// Report unready while draining so load balancers stop routing here
+		if drain.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Draining"))
+			return
+		}
+		if err := backend.Ping(r.Context()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("PostgreSQL unhealthy"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}
+	mux.HandleFunc("/readyz", ready)
+	mux.HandleFunc("/health", ready)
+}
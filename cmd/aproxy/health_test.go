@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackend struct{ err error }
+
+func (b *fakeBackend) Ping(ctx context.Context) error { return b.err }
+
+type fakeDrain struct{ draining bool }
+
+func (d *fakeDrain) Draining() bool { return d.draining }
+
+func TestHealthHandlers(t *testing.T) {
+	backend := &fakeBackend{}
+	drain := &fakeDrain{}
+	mux := http.NewServeMux()
+	registerHealthHandlers(mux, backend, drain)
+
+	status := func(path string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, status("/livez"))
+	assert.Equal(t, http.StatusOK, status("/readyz"))
+	assert.Equal(t, http.StatusOK, status("/health"))
+
+	t.Run("Backend outage", func(t *testing.T) {
+		backend.err = errors.New("connection refused")
+		defer func() { backend.err = nil }()
+
+		assert.Equal(t, http.StatusOK, status("/livez"))
+		assert.Equal(t, http.StatusServiceUnavailable, status("/readyz"))
+		assert.Equal(t, http.StatusServiceUnavailable, status("/health"))
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		drain.draining = true
+		defer func() { drain.draining = false }()
+
+		assert.Equal(t, http.StatusOK, status("/livez"))
+		assert.Equal(t, http.StatusServiceUnavailable, status("/readyz"))
+		assert.Equal(t, http.StatusServiceUnavailable, status("/health"))
+	})
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
@@ -55,6 +56,8 @@ func main() {
 		os.Exit(1)
 	}
 	defer logger.Sync()
+	logger.SetSlowQueryThreshold(cfg.Observability.SlowQueryThreshold)
+	logger.SetLongTransactionThreshold(cfg.Observability.LongTransactionThreshold)
 
 	logger.Info("Starting AProxy",
 		zap.String("version", version),
@@ -64,20 +67,10 @@ func main() {
 
 	metrics := observability.NewMetrics()
 
-	pgPool, err := pool.NewPool(&pool.Config{
-		Host:        cfg.Postgres.Host,
-		Port:        cfg.Postgres.Port,
-		Database:    cfg.Postgres.Database,
-		User:        cfg.Postgres.User,
-		Password:    cfg.Postgres.Password,
-		SSLMode:     cfg.Postgres.SSLMode,
-		MaxPoolSize: cfg.Postgres.MaxPoolSize,
-		Mode:        pool.ConnectionMode(cfg.Postgres.ConnectionMode),
-	})
+	pgPool, err := pool.NewPool(newPoolConfig(cfg.Postgres))
 	if err != nil {
 		logger.Fatal("Failed to create PostgreSQL pool", zap.Error(err))
 	}
-	defer pgPool.Close()
 
 	logger.Info("PostgreSQL connection pool initialized",
 		zap.String("host", cfg.Postgres.Host),
@@ -86,8 +79,43 @@ func main() {
 		zap.String("mode", cfg.Postgres.ConnectionMode),
 	)
 
+	// Databases routed to the same backend share one pool
+	routes := make(map[string]*pool.Pool)
+	backends := map[config.PostgresConfig]*pool.Pool{cfg.Postgres: pgPool}
+	for dbName, pgCfg := range cfg.ResolvedRoutes() {
+		p, ok := backends[pgCfg]
+		if !ok {
+			p, err = pool.NewPool(newPoolConfig(pgCfg))
+			if err != nil {
+				logger.Fatal("Failed to create PostgreSQL pool for database route",
+					zap.String("mysql_database", dbName), zap.Error(err))
+			}
+			backends[pgCfg] = p
+		}
+		routes[dbName] = p
+
+		logger.Info("Database route configured",
+			zap.String("mysql_database", dbName),
+			zap.String("host", pgCfg.Host),
+			zap.Int("port", pgCfg.Port),
+			zap.String("database", pgCfg.Database),
+		)
+	}
+
+	pgRouter := pool.NewRouter(pgPool, routes)
+	defer pgRouter.Close()
+
+	for _, p := range pgRouter.Pools() {
+		backend := p.Name()
+		metrics.SetBreakerState(backend, float64(pool.BreakerClosed))
+		p.Breaker().OnStateChange(func(state pool.BreakerState) {
+			metrics.SetBreakerState(backend, float64(state))
+			logger.Warn("PostgreSQL circuit breaker changed state", zap.String("backend", backend), zap.String("state", state.String()))
+		})
+	}
+
 	ctx := context.Background()
-	if err := pgPool.Ping(ctx); err != nil {
+	if err := pgRouter.Ping(ctx); err != nil {
 		logger.Fatal("Failed to ping PostgreSQL", zap.Error(err))
 	}
 	logger.Info("PostgreSQL connection verified")
@@ -102,9 +130,48 @@ func main() {
 	}
 
 	sessionMgr := session.NewManager()
-	rewriter := sqlrewrite.NewRewriter(cfg.SQLRewrite.Enabled)
+	sessionMgr.SetMaxConnectionsPerUser(cfg.Security.MaxConnectionsPerUser)
+	rewriter, err := newRewriter(cfg)
+	if err != nil {
+		logger.Fatal("Invalid SQL rewrite config", zap.Error(err))
+	}
 
-	handler := my.NewHandler(pgPool, sessionMgr, rewriter, metrics, logger, cfg.SQLRewrite.DebugSQL)
+	handler := my.NewHandler(pgRouter, sessionMgr, rewriter, metrics, logger, cfg.SQLRewrite.DebugSQL)
+	handler.SetSerializationRetries(cfg.Server.SerializationRetries)
+	handler.SetServerVersion(cfg.Server.ServerVersion)
+	handler.SetBackendPing(cfg.Server.PingBackend, cfg.Server.PingBackendInterval)
+	handler.SetInsertBatching(cfg.Server.InsertBatchRows, cfg.Server.InsertBatchWindow)
+	handler.SetMaxPacketSize(cfg.Server.MaxPacketSize)
+	handler.SetDryRun(cfg.SQLRewrite.DryRun)
+	handler.SetSafeMode(cfg.SQLRewrite.SafeMode)
+	handler.SetExplainRewrite(cfg.SQLRewrite.ExplainRewrite)
+	handler.SetResultLimit(cfg.Security.MaxResultRows, cfg.Security.MaxResultRowsPerUser, cfg.Security.TruncateResults)
+	handler.SetZeroDates(sqlrewrite.ZeroDatePolicy(cfg.SQLRewrite.ZeroDates), cfg.SQLRewrite.ZeroDatesOnRead)
+	if err := handler.SetCapabilities(cfg.Server.Capabilities); err != nil {
+		logger.Fatal("Invalid server capabilities", zap.Error(err))
+	}
+
+	var auditLogger *observability.AuditLogger
+	if cfg.Observability.AuditLog {
+		auditLogger, err = observability.NewAuditLogger(cfg.Observability.AuditLogPath, cfg.Observability.RedactParameters)
+		if err != nil {
+			logger.Fatal("Failed to create audit logger", zap.Error(err))
+		}
+		defer auditLogger.Sync()
+		handler.SetAuditLogger(auditLogger)
+		logger.Info("Audit log enabled", zap.String("path", cfg.Observability.AuditLogPath))
+	}
+
+	// Handshake and COM_CHANGE_USER authenticate against the same users
+	credentials := cfg.Auth.Credentials()
+	handler.SetCredentials(credentials)
+	inMemoryProvider := server.NewInMemoryProvider()
+	for user, password := range credentials {
+		inMemoryProvider.AddUser(user, password)
+	}
+	credentialProvider := handler.LimitCredentials(inMemoryProvider)
+	handler.SetRequireSSL(cfg.Security.RequireSSLUsers)
+	mysqlServer := server.NewDefaultServer()
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 
@@ -113,33 +180,27 @@ func main() {
 		logger.Info("Starting metrics server", zap.String("addr", metricsAddr))
 
 		http.Handle("/metrics", promhttp.Handler())
-		http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-			if err := pgPool.Ping(r.Context()); err != nil {
-				w.WriteHeader(http.StatusServiceUnavailable)
-				w.Write([]byte("PostgreSQL unhealthy"))
-				return
-			}
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("OK"))
-		})
+		registerHealthHandlers(http.DefaultServeMux, pgRouter, handler)
 
 		if err := http.ListenAndServe(metricsAddr, nil); err != nil {
 			logger.Error("Metrics server error", zap.Error(err))
 		}
 	}()
 
-	go func() {
-		logger.Info("Starting MySQL protocol server", zap.String("addr", addr))
+	logger.Info("Starting MySQL protocol server", zap.String("addr", addr))
 
-		listener, err := net.Listen("tcp", addr)
-		if err != nil {
-			logger.Fatal("Failed to create MySQL listener", zap.Error(err))
-		}
-		defer listener.Close()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Fatal("Failed to create MySQL listener", zap.Error(err))
+	}
 
+	go func() {
 		for {
 			conn, err := listener.Accept()
 			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
 				logger.Error("Failed to accept connection", zap.Error(err))
 				continue
 			}
@@ -152,12 +213,20 @@ func main() {
 					logger.Error("Failed to create connection handler", zap.Error(err))
 					return
 				}
+				defer connHandler.Close()
 
-				mysqlConn, err := server.NewConn(c, "root", "", connHandler)
+				mysqlConn, err := mysqlServer.NewCustomizedConn(connHandler.NetConn(), connHandler.Credentials(credentialProvider), connHandler)
 				if err != nil {
 					logger.Error("Failed to create MySQL connection", zap.Error(err))
 					return
 				}
+				if err := connHandler.SetUser(mysqlConn.GetUser()); err != nil {
+					mysqlConn.WriteValue(err)
+					logger.Warn("Connection rejected", zap.String("user", mysqlConn.GetUser()), zap.Error(err))
+					return
+				}
+				connHandler.SetClientCapabilities(mysqlConn.Capability())
+				connHandler.SetPacketConn(mysqlConn)
 
 				for {
 					if err := mysqlConn.HandleCommand(); err != nil {
@@ -170,16 +239,94 @@ func main() {
 	}()
 
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	sig := <-sigChan
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	var sig os.Signal
+	for sig = range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+
+		// SIGHUP reloads runtime settings without dropping connections
+		reloaded, err := reloadConfig(*configFile, cfg, logger)
+		if err != nil {
+			logger.Error("Failed to reload config", zap.Error(err))
+			continue
+		}
+		cfg = reloaded
+		handler.SetDebugSQL(cfg.SQLRewrite.DebugSQL)
+		handler.SetDryRun(cfg.SQLRewrite.DryRun)
+		handler.SetSafeMode(cfg.SQLRewrite.SafeMode)
+		handler.SetExplainRewrite(cfg.SQLRewrite.ExplainRewrite)
+		if auditLogger != nil {
+			auditLogger.SetRedactParameters(cfg.Observability.RedactParameters)
+		}
+		logger.Info("Config reloaded",
+			zap.String("log_level", cfg.Observability.LogLevel),
+			zap.Duration("slow_query_threshold", cfg.Observability.SlowQueryThreshold),
+			zap.Duration("long_transaction_threshold", cfg.Observability.LongTransactionThreshold),
+			zap.Bool("redact_parameters", cfg.Observability.RedactParameters),
+			zap.Bool("debug_sql", cfg.SQLRewrite.DebugSQL),
+			zap.Bool("dry_run", cfg.SQLRewrite.DryRun),
+			zap.Bool("safe_mode", cfg.SQLRewrite.SafeMode),
+			zap.Bool("explain_rewrite", cfg.SQLRewrite.ExplainRewrite),
+		)
+	}
 
 	logger.Info("Received shutdown signal", zap.String("signal", sig.String()))
 
+	// Stop accepting new connections, then let in-flight queries finish
+	listener.Close()
+
+	logger.Info("Draining in-flight queries",
+		zap.Int("in_flight", handler.InFlightQueries()),
+		zap.Duration("timeout", cfg.Server.ShutdownTimeout),
+	)
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	if err := handler.Drain(drainCtx); err != nil {
+		logger.Warn("Shutdown timeout reached with queries still running",
+			zap.Int("in_flight", handler.InFlightQueries()),
+		)
+	}
+	cancel()
+
 	logger.Info("Closing active sessions")
+	closed := handler.CloseConnections()
 	sessions := sessionMgr.GetAllSessions()
 	for _, sess := range sessions {
 		sessionMgr.RemoveSession(sess.ID)
 	}
+	logger.Info("Client connections closed", zap.Int("count", closed))
 
 	logger.Info("Shutdown complete")
 }
+
+func newPoolConfig(pg config.PostgresConfig) *pool.Config {
+	return &pool.Config{
+		Host:        pg.Host,
+		Port:        pg.Port,
+		Database:    pg.Database,
+		User:        pg.User,
+		Password:    pg.Password,
+		SSLMode:     pg.SSLMode,
+		MaxPoolSize: pg.MaxPoolSize,
+		Mode:        pool.ConnectionMode(pg.ConnectionMode),
+
+		BreakerThreshold: pg.BreakerThreshold,
+		BreakerCoolDown:  pg.BreakerCoolDown,
+	}
+}
+
+// newRewriter creates the SQL rewriter configured by the sql_rewrite section
+func newRewriter(cfg *config.Config) (*sqlrewrite.Rewriter, error) {
+	rewriter := sqlrewrite.NewRewriter(cfg.SQLRewrite.Enabled)
+	rewriter.SetVersionCommentTarget(cfg.SQLRewrite.VersionCommentTarget)
+	rewriter.SetEnumOrderBy(cfg.SQLRewrite.EnumOrderBy)
+	rewriter.SetGreatestLeastNulls(cfg.SQLRewrite.GreatestLeastNulls)
+	rewriter.SetZeroDatePolicy(sqlrewrite.ZeroDatePolicy(cfg.SQLRewrite.ZeroDates))
+	rewriter.SetServerVersion(cfg.Server.ServerVersion)
+	if err := rewriter.SetFunctionMappings(cfg.SQLRewrite.FunctionMappings); err != nil {
+		return nil, err
+	}
+	return rewriter, nil
+}
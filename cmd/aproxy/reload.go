@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"aproxy/internal/config"
+	"aproxy/pkg/observability"
+	"go.uber.org/zap"
+)
+
+// reloadConfig re-reads the config file and applies the hot-reloadable settings
+// to the running logger. It returns the new config so the caller can apply the
+// remaining runtime settings (debug SQL, dry run, explain rewrite) to the handler
+func reloadConfig(path string, current *config.Config, logger *observability.Logger) (*config.Config, error) {
+	next, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	logger.SetLevel(next.Observability.LogLevel)
+	logger.SetRedactParameters(next.Observability.RedactParameters)
+	logger.SetSlowQueryThreshold(next.Observability.SlowQueryThreshold)
+	logger.SetLongTransactionThreshold(next.Observability.LongTransactionThreshold)
+
+	for _, section := range current.IgnoredReloadChanges(next) {
+		logger.Warn("Config change ignored until restart", zap.String("section", section))
+	}
+
+	// Keep non-reloadable settings as they are actually running
+	applied := *current
+	applied.Observability.LogLevel = next.Observability.LogLevel
+	applied.Observability.RedactParameters = next.Observability.RedactParameters
+	applied.Observability.SlowQueryThreshold = next.Observability.SlowQueryThreshold
+	applied.Observability.LongTransactionThreshold = next.Observability.LongTransactionThreshold
+	applied.SQLRewrite.DebugSQL = next.SQLRewrite.DebugSQL
+	applied.SQLRewrite.DryRun = next.SQLRewrite.DryRun
+	applied.SQLRewrite.SafeMode = next.SQLRewrite.SafeMode
+	applied.SQLRewrite.ExplainRewrite = next.SQLRewrite.ExplainRewrite
+	return &applied, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aproxy/internal/config"
+	"aproxy/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReloadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+observability:
+  log_level: "info"
+`), 0o644))
+
+	current, err := config.LoadConfig(path)
+	require.NoError(t, err)
+
+	logger, err := observability.NewLogger(current.Observability.LogLevel, "json", true)
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.InfoLevel, logger.Level())
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: 3307
+observability:
+  log_level: "debug"
+  slow_query_threshold: 250ms
+  long_transaction_threshold: 5s
+sql_rewrite:
+  debug_sql: true
+  dry_run: true
+  safe_mode: true
+  explain_rewrite: true
+`), 0o644))
+
+	applied, err := reloadConfig(path, current, logger)
+	require.NoError(t, err)
+
+	assert.Equal(t, zapcore.DebugLevel, logger.Level())
+	assert.Equal(t, 250*time.Millisecond, logger.SlowQueryThreshold())
+	assert.Equal(t, 5*time.Second, logger.LongTransactionThreshold())
+	assert.True(t, applied.SQLRewrite.DebugSQL)
+	assert.True(t, applied.SQLRewrite.DryRun)
+	assert.True(t, applied.SQLRewrite.SafeMode)
+	assert.True(t, applied.SQLRewrite.ExplainRewrite)
+	// Port changes need a restart
+	assert.Equal(t, 3306, applied.Server.Port)
+
+	next, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"server"}, current.IgnoredReloadChanges(next))
+}
+
+func TestNewRewriterFunctionMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+sql_rewrite:
+  function_mappings:
+    calc_tax: app.calc_tax
+`), 0o644))
+
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"calc_tax": "app.calc_tax"}, cfg.SQLRewrite.FunctionMappings)
+
+	rewriter, err := newRewriter(cfg)
+	require.NoError(t, err)
+	result, err := rewriter.Rewrite("SELECT calc_tax(price) FROM orders")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT APP.CALC_TAX("price") FROM "orders"`, result)
+
+	cfg.SQLRewrite.FunctionMappings = map[string]string{"if": "my_if"}
+	_, err = newRewriter(cfg)
+	assert.ErrorContains(t, err, "conflicts with the built-in IF conversion")
+}
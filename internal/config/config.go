@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -16,6 +17,10 @@ type Config struct {
 	SQLRewrite   SQLRewriteConfig   `yaml:"sql_rewrite"`
 	Observability ObservabilityConfig `yaml:"observability"`
 	SchemaCache  SchemaCacheConfig  `yaml:"schema_cache"`
+
+	// DatabaseRoutes maps a MySQL database name to its own PostgreSQL backend
+	// Unset fields are inherited from the default postgres section
+	DatabaseRoutes map[string]PostgresConfig `yaml:"database_routes"`
 }
 
 type ServerConfig struct {
@@ -25,6 +30,23 @@ type ServerConfig struct {
 	MaxPacketSize  int64         `yaml:"max_packet_size"`
 	ReadTimeout    time.Duration `yaml:"read_timeout"`
 	WriteTimeout   time.Duration `yaml:"write_timeout"`
+	// ShutdownTimeout bounds how long shutdown waits for in-flight queries
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// SerializationRetries retries reads outside transactions that fail with 40001/40P01
+	SerializationRetries int `yaml:"serialization_retries"`
+	// ServerVersion is advertised in the handshake and returned by VERSION()
+	ServerVersion string `yaml:"server_version"`
+	// Capabilities turns handshake capability flags on or off by name, e.g. CLIENT_CONNECT_ATTRS: false
+	Capabilities map[string]bool `yaml:"capabilities"`
+	// PingBackend makes COM_PING fail when the session's PostgreSQL backend does not answer
+	PingBackend bool `yaml:"ping_backend"`
+	// PingBackendInterval skips the backend check when it succeeded less than this long ago
+	PingBackendInterval time.Duration `yaml:"ping_backend_interval"`
+	// InsertBatchRows sends up to this many executions of a prepared single-row INSERT
+	// inside a transaction as one multi-row INSERT, 0 disables batching
+	InsertBatchRows int `yaml:"insert_batch_rows"`
+	// InsertBatchWindow sends batched rows once the first of them is older than this
+	InsertBatchWindow time.Duration `yaml:"insert_batch_window"`
 }
 
 type PostgresConfig struct {
@@ -36,19 +58,43 @@ type PostgresConfig struct {
 	MaxPoolSize    int    `yaml:"max_pool_size"`
 	ConnectionMode string `yaml:"connection_mode"`
 	SSLMode        string `yaml:"ssl_mode"`
+	// BreakerThreshold consecutive connection failures make new queries fail at once
+	// for BreakerCoolDown, then one query tests the backend again. 0 disables the breaker
+	BreakerThreshold int           `yaml:"breaker_threshold"`
+	BreakerCoolDown  time.Duration `yaml:"breaker_cool_down"`
 }
 
 type AuthConfig struct {
 	Mode         string   `yaml:"mode"`
 	AllowedUsers []string `yaml:"allowed_users"`
+	// Users maps MySQL user names to passwords accepted by the proxy
+	Users map[string]string `yaml:"users"`
+}
+
+// Credentials returns the users accepted at handshake and COM_CHANGE_USER,
+// defaulting to root without password when none are configured
+func (a AuthConfig) Credentials() map[string]string {
+	if len(a.Users) == 0 {
+		return map[string]string{"root": ""}
+	}
+	return a.Users
 }
 
 type SecurityConfig struct {
 	RateLimitPerSecond       int      `yaml:"rate_limit_per_second"`
 	MaxConnectionsPerIP      int      `yaml:"max_connections_per_ip"`
+	MaxConnectionsPerUser    int      `yaml:"max_connections_per_user"` // 0 means unlimited
+	// MaxResultRows caps the rows of a result set, 0 means unlimited
+	MaxResultRows int `yaml:"max_result_rows"`
+	// MaxResultRowsPerUser overrides MaxResultRows for individual users
+	MaxResultRowsPerUser map[string]int `yaml:"max_result_rows_per_user"`
+	// TruncateResults returns the first MaxResultRows rows instead of failing the query
+	TruncateResults bool `yaml:"truncate_results"`
 	EnableTLS                bool     `yaml:"enable_tls"`
 	TLSCert                  string   `yaml:"tls_cert"`
 	TLSKey                   string   `yaml:"tls_key"`
+	// RequireSSLUsers may only log in over TLS, other users may connect without it
+	RequireSSLUsers []string `yaml:"require_ssl_users"`
 	DangerousCommandsBlacklist []string `yaml:"dangerous_commands_blacklist"`
 }
 
@@ -56,6 +102,26 @@ type SQLRewriteConfig struct {
 	Enabled     bool   `yaml:"enabled"`
 	CustomRules string `yaml:"custom_rules"`
 	DebugSQL    bool   `yaml:"debug_sql"` // Enable SQL rewrite debugging (prints original and rewritten SQL)
+	// VersionCommentTarget decides which /*!NNNNN ... */ comments are executed
+	VersionCommentTarget int `yaml:"version_comment_target"`
+	// EnumOrderBy sorts ENUM columns (stored as VARCHAR) by declaration order like MySQL
+	EnumOrderBy bool `yaml:"enum_order_by"`
+	// GreatestLeastNulls makes GREATEST and LEAST return NULL for a NULL argument like MySQL,
+	// false keeps PostgreSQL's skipping of NULL arguments
+	GreatestLeastNulls bool `yaml:"greatest_least_nulls"`
+	// DryRun rewrites statements and reports whether they are supported instead of executing them
+	DryRun bool `yaml:"dry_run"`
+	// SafeMode plans every rewritten statement with EXPLAIN before running it, failing it
+	// with a proxy error when PostgreSQL rejects the rewrite
+	SafeMode bool `yaml:"safe_mode"`
+	// FunctionMappings renames MySQL functions to PostgreSQL functions, arguments are passed through
+	FunctionMappings map[string]string `yaml:"function_mappings"`
+	// ZeroDates is what INSERT and UPDATE write for '0000-00-00': null, error or epoch
+	ZeroDates string `yaml:"zero_dates"`
+	// ZeroDatesOnRead reads the value written for zero dates as '0000-00-00' again
+	ZeroDatesOnRead bool `yaml:"zero_dates_on_read"`
+	// ExplainRewrite answers SELECT aproxy_explain_rewrite('<sql>') with the rewrite trace of the statement
+	ExplainRewrite bool `yaml:"explain_rewrite"`
 }
 
 type ObservabilityConfig struct {
@@ -66,6 +132,14 @@ type ObservabilityConfig struct {
 	RedactParameters  bool   `yaml:"redact_parameters"`
 	EnableTracing     bool   `yaml:"enable_tracing"`
 	TracingEndpoint   string `yaml:"tracing_endpoint"`
+	// SlowQueryThreshold logs queries at warn level once exceeded, 0 disables
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	// LongTransactionThreshold logs transactions at warn level once they end after running
+	// longer than this, 0 disables
+	LongTransactionThreshold time.Duration `yaml:"long_transaction_threshold"`
+	// AuditLog writes every executed statement to AuditLogPath as JSON
+	AuditLog     bool   `yaml:"audit_log"`
+	AuditLogPath string `yaml:"audit_log_path"`
 }
 
 type SchemaCacheConfig struct {
@@ -84,6 +158,10 @@ func DefaultConfig() *Config {
 			MaxPacketSize:  16777216,
 			ReadTimeout:    30 * time.Second,
 			WriteTimeout:   30 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			ServerVersion:   "8.0.11",
+			PingBackendInterval: time.Second,
+			InsertBatchWindow:   50 * time.Millisecond,
 		},
 		Postgres: PostgresConfig{
 			Host:           "localhost",
@@ -94,6 +172,8 @@ func DefaultConfig() *Config {
 			MaxPoolSize:    100,
 			ConnectionMode: "session_affinity",
 			SSLMode:        "prefer",
+			BreakerThreshold: 5,
+			BreakerCoolDown:  10 * time.Second,
 		},
 		Auth: AuthConfig{
 			Mode:         "pass_through",
@@ -114,6 +194,10 @@ func DefaultConfig() *Config {
 			Enabled:     true,
 			CustomRules: "",
 			DebugSQL:    false,
+			VersionCommentTarget: 80011,
+			EnumOrderBy:          true,
+			GreatestLeastNulls:   true,
+			ZeroDates:            "null",
 		},
 		Observability: ObservabilityConfig{
 			MetricsPort:      9090,
@@ -123,6 +207,10 @@ func DefaultConfig() *Config {
 			RedactParameters: true,
 			EnableTracing:    false,
 			TracingEndpoint:  "localhost:4318",
+			SlowQueryThreshold: time.Second,
+			LongTransactionThreshold: 10 * time.Second,
+			AuditLog:           false,
+			AuditLogPath:       "logs/audit.log",
 		},
 		SchemaCache: SchemaCacheConfig{
 			Enabled:         true,
@@ -164,6 +252,24 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max_packet_size must be at least 1024 bytes")
 	}
 
+	if c.Server.SerializationRetries < 0 {
+		return fmt.Errorf("serialization_retries must not be negative")
+	}
+
+	if c.Server.PingBackendInterval < 0 {
+		return fmt.Errorf("ping_backend_interval must not be negative")
+	}
+
+	if c.Server.InsertBatchRows < 0 || c.Server.InsertBatchWindow < 0 {
+		return fmt.Errorf("insert_batch_rows and insert_batch_window must not be negative")
+	}
+
+	// Clients parse the leading major.minor.patch to gate features
+	var major, minor, patch int
+	if _, err := fmt.Sscanf(c.Server.ServerVersion, "%d.%d.%d", &major, &minor, &patch); err != nil {
+		return fmt.Errorf("server_version must start with major.minor.patch: %q", c.Server.ServerVersion)
+	}
+
 	if c.Postgres.Host == "" {
 		return fmt.Errorf("postgres host is required")
 	}
@@ -176,10 +282,49 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("postgres max_pool_size must be at least 1")
 	}
 
+	if c.Postgres.BreakerThreshold < 0 {
+		return fmt.Errorf("postgres breaker_threshold must not be negative")
+	}
+	if c.Postgres.BreakerThreshold > 0 && c.Postgres.BreakerCoolDown <= 0 {
+		return fmt.Errorf("postgres breaker_cool_down must be positive when breaker_threshold is set")
+	}
+
+	for dbName, route := range c.ResolvedRoutes() {
+		if route.Port < 1 || route.Port > 65535 {
+			return fmt.Errorf("invalid postgres port for database route %s: %d", dbName, route.Port)
+		}
+		if route.MaxPoolSize < 1 {
+			return fmt.Errorf("max_pool_size for database route %s must be at least 1", dbName)
+		}
+	}
+
+	switch c.SQLRewrite.ZeroDates {
+	case "null", "error", "epoch":
+	default:
+		return fmt.Errorf("invalid zero_dates: %s (must be 'null', 'error' or 'epoch')", c.SQLRewrite.ZeroDates)
+	}
+
 	if c.Auth.Mode != "pass_through" && c.Auth.Mode != "proxy_auth" {
 		return fmt.Errorf("invalid auth mode: %s (must be 'pass_through' or 'proxy_auth')", c.Auth.Mode)
 	}
 
+	if c.Observability.AuditLog && c.Observability.AuditLogPath == "" {
+		return fmt.Errorf("audit_log_path is required when audit_log is true")
+	}
+
+	if c.Security.MaxConnectionsPerUser < 0 {
+		return fmt.Errorf("max_connections_per_user must not be negative")
+	}
+
+	if c.Security.MaxResultRows < 0 {
+		return fmt.Errorf("max_result_rows must not be negative")
+	}
+	for user, maxRows := range c.Security.MaxResultRowsPerUser {
+		if maxRows < 0 {
+			return fmt.Errorf("max_result_rows_per_user for %s must not be negative", user)
+		}
+	}
+
 	if c.Security.EnableTLS {
 		if c.Security.TLSCert == "" || c.Security.TLSKey == "" {
 			return fmt.Errorf("tls_cert and tls_key are required when enable_tls is true")
@@ -188,3 +333,86 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+
+// ResolvedRoutes returns the database routes with unset fields filled in
+// from the default postgres section
+func (c *Config) ResolvedRoutes() map[string]PostgresConfig {
+	routes := make(map[string]PostgresConfig, len(c.DatabaseRoutes))
+	for dbName, route := range c.DatabaseRoutes {
+		def := c.Postgres
+		if route.Host != "" {
+			def.Host = route.Host
+		}
+		if route.Port != 0 {
+			def.Port = route.Port
+		}
+		if route.Database != "" {
+			def.Database = route.Database
+		}
+		if route.User != "" {
+			def.User = route.User
+		}
+		if route.Password != "" {
+			def.Password = route.Password
+		}
+		if route.MaxPoolSize != 0 {
+			def.MaxPoolSize = route.MaxPoolSize
+		}
+		if route.ConnectionMode != "" {
+			def.ConnectionMode = route.ConnectionMode
+		}
+		if route.SSLMode != "" {
+			def.SSLMode = route.SSLMode
+		}
+		if route.BreakerThreshold != 0 {
+			def.BreakerThreshold = route.BreakerThreshold
+		}
+		if route.BreakerCoolDown != 0 {
+			def.BreakerCoolDown = route.BreakerCoolDown
+		}
+		routes[dbName] = def
+	}
+	return routes
+}
+
+// IgnoredReloadChanges lists settings that differ in next but only take effect
+// after a restart. Hot-reloadable settings (log level, slow-query and long-transaction
+// thresholds, parameter redaction, SQL debugging, dry run) are not reported
+func (c *Config) IgnoredReloadChanges(next *Config) []string {
+	var ignored []string
+	if !reflect.DeepEqual(c.Server, next.Server) {
+		ignored = append(ignored, "server")
+	}
+	if c.Postgres != next.Postgres {
+		ignored = append(ignored, "postgres")
+	}
+	if !reflect.DeepEqual(c.DatabaseRoutes, next.DatabaseRoutes) {
+		ignored = append(ignored, "database_routes")
+	}
+	if !reflect.DeepEqual(c.Auth, next.Auth) {
+		ignored = append(ignored, "auth")
+	}
+	if !reflect.DeepEqual(c.Security, next.Security) {
+		ignored = append(ignored, "security")
+	}
+	if c.SQLRewrite.Enabled != next.SQLRewrite.Enabled || c.SQLRewrite.CustomRules != next.SQLRewrite.CustomRules ||
+		c.SQLRewrite.VersionCommentTarget != next.SQLRewrite.VersionCommentTarget ||
+		c.SQLRewrite.EnumOrderBy != next.SQLRewrite.EnumOrderBy || c.SQLRewrite.GreatestLeastNulls != next.SQLRewrite.GreatestLeastNulls ||
+		c.SQLRewrite.ZeroDates != next.SQLRewrite.ZeroDates || c.SQLRewrite.ZeroDatesOnRead != next.SQLRewrite.ZeroDatesOnRead ||
+		!reflect.DeepEqual(c.SQLRewrite.FunctionMappings, next.SQLRewrite.FunctionMappings) {
+		ignored = append(ignored, "sql_rewrite")
+	}
+
+	obs, nextObs := c.Observability, next.Observability
+	if obs.MetricsPort != nextObs.MetricsPort || obs.LogFormat != nextObs.LogFormat ||
+		obs.EnableQueryLog != nextObs.EnableQueryLog || obs.EnableTracing != nextObs.EnableTracing ||
+		obs.TracingEndpoint != nextObs.TracingEndpoint || obs.AuditLog != nextObs.AuditLog ||
+		obs.AuditLogPath != nextObs.AuditLogPath {
+		ignored = append(ignored, "observability")
+	}
+	if c.SchemaCache != next.SchemaCache {
+		ignored = append(ignored, "schema_cache")
+	}
+	return ignored
+}
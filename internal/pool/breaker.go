@@ -0,0 +1,152 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBackendUnavailable is returned without trying the backend while its circuit breaker is open
+var ErrBackendUnavailable = errors.New("PostgreSQL backend unavailable")
+
+// BreakerState is the state of a circuit breaker, its value is what the state metric reports
+type BreakerState int
+
+const (
+	// BreakerClosed lets every connection attempt through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails connection attempts until the cool-down has passed
+	BreakerOpen
+	// BreakerHalfOpen lets one connection attempt through to test the backend
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	}
+	return "closed"
+}
+
+// Breaker stops connection attempts to a backend that keeps failing. After threshold
+// consecutive failures it opens and fails attempts at once for the cool-down, so
+// queries do not queue on a dead or overloaded backend. It then half-opens: one
+// attempt goes through, its success closes the breaker and its failure opens it again.
+// A nil Breaker lets everything through
+type Breaker struct {
+	threshold int
+	coolDown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int       // Consecutive failures while closed
+	openedAt time.Time // When the breaker last opened
+	probing  bool      // The half-open attempt is in flight
+	onChange func(BreakerState)
+}
+
+// NewBreaker creates a breaker opening after threshold consecutive failures for coolDown
+func NewBreaker(threshold int, coolDown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, coolDown: coolDown, now: time.Now}
+}
+
+// OnStateChange sets a function called with the new state on every change
+func (b *Breaker) OnStateChange(fn func(BreakerState)) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = fn
+}
+
+// State returns the current state. An open breaker past its cool-down reports open
+// until the next attempt half-opens it
+func (b *Breaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a connection attempt may go to the backend. Every allowed
+// attempt must be followed by Success, Failure or Release
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.coolDown - b.now().Sub(b.openedAt); wait > 0 {
+			return fmt.Errorf("%w: %d consecutive connection failures, retrying in %s", ErrBackendUnavailable, b.threshold, wait.Round(time.Second))
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: testing whether it recovered", ErrBackendUnavailable)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success records a successful connection attempt and closes the breaker
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	b.setState(BreakerClosed)
+}
+
+// Failure records a failed connection attempt, opening the breaker at the threshold
+// or when the half-open attempt failed
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	b.failures++
+	if b.state == BreakerHalfOpen || b.state == BreakerClosed && b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// Release ends an allowed attempt without recording its outcome, for attempts that
+// did not get to test the backend. A half-open breaker lets the next attempt through
+func (b *Breaker) Release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// setState changes the state, b.mu must be held
+func (b *Breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
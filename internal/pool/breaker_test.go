@@ -0,0 +1,158 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBreaker(threshold int, coolDown time.Duration) (*Breaker, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(threshold, coolDown)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker(t *testing.T) {
+	b, now := newTestBreaker(3, 10*time.Second)
+	var changes []BreakerState
+	b.OnStateChange(func(state BreakerState) { changes = append(changes, state) })
+
+	// Failures must be consecutive
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+	require.NoError(t, b.Allow())
+	b.Success()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	err := b.Allow()
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+	assert.Contains(t, err.Error(), "retrying in 10s")
+
+	// After the cool-down one attempt tests the backend, the others still fail
+	*now = now.Add(10 * time.Second)
+	require.NoError(t, b.Allow())
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrBackendUnavailable)
+
+	// It failed, so the breaker opens for another cool-down
+	b.Failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	*now = now.Add(5 * time.Second)
+	assert.ErrorIs(t, b.Allow(), ErrBackendUnavailable)
+
+	// The backend recovered
+	*now = now.Add(5 * time.Second)
+	require.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, BreakerClosed, b.State())
+	require.NoError(t, b.Allow())
+
+	assert.Equal(t, []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}, changes)
+}
+
+func TestBreaker_Release(t *testing.T) {
+	b, now := newTestBreaker(1, 10*time.Second)
+	require.NoError(t, b.Allow())
+	b.Failure()
+	*now = now.Add(10 * time.Second)
+
+	// The half-open attempt was canceled, the next one tests the backend
+	require.NoError(t, b.Allow())
+	b.Release()
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	require.NoError(t, b.Allow())
+	b.Success()
+
+	// Released attempts do not count as failures
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Allow())
+		b.Release()
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestBreaker_Nil(t *testing.T) {
+	var b *Breaker
+	require.NoError(t, b.Allow())
+	b.Failure()
+	b.Success()
+	b.Release()
+	b.OnStateChange(func(BreakerState) {})
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestPool_BreakerFailsFast(t *testing.T) {
+	// A port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	p := &Pool{
+		config:       &Config{Host: "127.0.0.1", Port: port, Database: "postgres", User: "postgres", SSLMode: "disable"},
+		mode:         ModeSessionAffinity,
+		sessionConns: make(map[string]*pgx.Conn),
+		breaker:      NewBreaker(2, time.Minute),
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := p.AcquireForSession(ctx, "s1")
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrBackendUnavailable))
+	}
+	_, err = p.AcquireForSession(ctx, "s1")
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+	assert.Contains(t, err.Error(), "127.0.0.1")
+	assert.Equal(t, BreakerOpen, p.Breaker().State())
+}
+
+func TestPool_BreakerIgnoresAttemptsNotReachingTheBackend(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+	p := &Pool{
+		config:       &Config{Host: "127.0.0.1", Port: 1, Database: "postgres", User: "postgres", SSLMode: "disable"},
+		mode:         ModeSessionAffinity,
+		sessionConns: make(map[string]*pgx.Conn),
+		breaker:      b,
+	}
+	require.NoError(t, b.Allow())
+	b.Failure()
+	*now = now.Add(time.Minute)
+
+	// A bad connection string fails before the half-open attempt is made
+	p.config.SSLMode = "bogus"
+	_, err := p.AcquireForSession(context.Background(), "s1")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBackendUnavailable))
+	p.config.SSLMode = "disable"
+
+	// The caller gave up, which says nothing about the backend
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.AcquireForSession(ctx, "s1")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBackendUnavailable))
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	// The next attempt still tests the backend, which is down
+	_, err = p.AcquireForSession(context.Background(), "s1")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBackendUnavailable))
+	assert.Equal(t, BreakerOpen, b.State())
+}
@@ -0,0 +1,27 @@
+package pool
+
+import (
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// noticeHandlers routes the NOTICE and WARNING messages of each backend connection
+// to the session using it, pgx only takes one callback per connection config
+var noticeHandlers sync.Map // *pgconn.PgConn -> func(*pgconn.Notice)
+
+// OnNotice sends the notices PostgreSQL raises on conn to fn from now on, nil stops it
+func OnNotice(conn *pgconn.PgConn, fn func(*pgconn.Notice)) {
+	if fn == nil {
+		noticeHandlers.Delete(conn)
+		return
+	}
+	noticeHandlers.Store(conn, fn)
+}
+
+// dispatchNotice is the OnNotice callback of every connection the pools open
+func dispatchNotice(conn *pgconn.PgConn, notice *pgconn.Notice) {
+	if fn, ok := noticeHandlers.Load(conn); ok {
+		fn.(func(*pgconn.Notice))(notice)
+	}
+}
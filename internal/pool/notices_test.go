@@ -0,0 +1,23 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnNotice(t *testing.T) {
+	connA, connB := &pgconn.PgConn{}, &pgconn.PgConn{}
+	var got []string
+	OnNotice(connA, func(n *pgconn.Notice) { got = append(got, n.Message) })
+	defer OnNotice(connA, nil)
+
+	dispatchNotice(connA, &pgconn.Notice{Message: "a"})
+	dispatchNotice(connB, &pgconn.Notice{Message: "b"}) // No session listens on connB
+	assert.Equal(t, []string{"a"}, got)
+
+	OnNotice(connA, nil)
+	dispatchNotice(connA, &pgconn.Notice{Message: "c"})
+	assert.Equal(t, []string{"a"}, got)
+}
@@ -27,15 +27,24 @@ type Config struct {
 	SSLMode     string
 	MaxPoolSize int
 	Mode        ConnectionMode
+	// BreakerThreshold consecutive connection failures open the circuit breaker for
+	// BreakerCoolDown, 0 disables the breaker
+	BreakerThreshold int
+	BreakerCoolDown  time.Duration
 }
 
 type Pool struct {
 	config *Config
 	pool   *pgxpool.Pool
 	mode   ConnectionMode
+	// breaker fails connection attempts at once while the backend keeps failing
+	breaker *Breaker
 
 	sessionConns map[string]*pgx.Conn
-	mu           sync.RWMutex
+	// pooledConns are the connections pooled mode lent to sessions. A session keeps its
+	// connection until it ends, so a transaction never moves to another backend
+	pooledConns map[string]*pgxpool.Conn
+	mu          sync.RWMutex
 }
 
 func NewPool(cfg *Config) (*Pool, error) {
@@ -64,18 +73,11 @@ func NewPool(cfg *Config) (*Pool, error) {
 	// Binary Format (Format=1) causes "busy buffer" errors because BuildSimpleTextResultset
 	// expects Text Format (Format=0) data. Simple Query Protocol always uses Text Format.
 	poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	poolConfig.ConnConfig.OnNotice = dispatchNotice
 
 	// Set PostgreSQL timezone to system local timezone
 	// This ensures LOCALTIMESTAMP and timestamp values match the system timezone
-	poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
-		// Get local timezone offset hours
-		_, offset := time.Now().Zone()
-		offsetHours := offset / 3600
-		// Use numeric timezone like '+08' or '-05'
-		tz := fmt.Sprintf("%+03d", offsetHours)
-		_, err := conn.Exec(ctx, "SET timezone = '"+tz+"'")
-		return err
-	}
+	poolConfig.AfterConnect = setLocalTimezone
 
 	ctx := context.Background()
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
@@ -88,12 +90,27 @@ func NewPool(cfg *Config) (*Pool, error) {
 		return nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
 	}
 
-	return &Pool{
+	p := &Pool{
 		config:       cfg,
 		pool:         pool,
 		mode:         cfg.Mode,
 		sessionConns: make(map[string]*pgx.Conn),
-	}, nil
+		pooledConns:  make(map[string]*pgxpool.Conn),
+	}
+	if cfg.BreakerThreshold > 0 {
+		p.breaker = NewBreaker(cfg.BreakerThreshold, cfg.BreakerCoolDown)
+	}
+	return p, nil
+}
+
+// Name identifies the backend in errors and metrics as host:port/database
+func (p *Pool) Name() string {
+	return fmt.Sprintf("%s:%d/%s", p.config.Host, p.config.Port, p.config.Database)
+}
+
+// Breaker returns the circuit breaker of the backend, nil when disabled
+func (p *Pool) Breaker() *Breaker {
+	return p.breaker
 }
 
 func (p *Pool) AcquireForSession(ctx context.Context, sessionID string) (*pgx.Conn, error) {
@@ -121,17 +138,19 @@ func (p *Pool) AcquireForSession(ctx context.Context, sessionID string) (*pgx.Co
 			return nil, fmt.Errorf("failed to parse connection string: %w", err)
 		}
 		connConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+		connConfig.OnNotice = dispatchNotice
 
+		if err := p.breaker.Allow(); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name(), err)
+		}
 		conn, err := pgx.ConnectConfig(ctx, connConfig)
+		p.recordAttempt(ctx, err)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create dedicated connection: %w", err)
 		}
 
 		// Set timezone to match system local timezone
-		_, offset := time.Now().Zone()
-		offsetHours := offset / 3600
-		tz := fmt.Sprintf("%+03d", offsetHours)
-		if _, err := conn.Exec(ctx, "SET timezone = '"+tz+"'"); err != nil {
+		if err := setLocalTimezone(ctx, conn); err != nil {
 			conn.Close(ctx)
 			return nil, fmt.Errorf("failed to set timezone: %w", err)
 		}
@@ -140,30 +159,105 @@ func (p *Pool) AcquireForSession(ctx context.Context, sessionID string) (*pgx.Co
 		return conn, nil
 	}
 
+	p.mu.RLock()
+	conn, exists := p.pooledConns[sessionID]
+	p.mu.RUnlock()
+	if exists {
+		return conn.Conn(), nil
+	}
+
+	if err := p.breaker.Allow(); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Name(), err)
+	}
 	conn, err := p.pool.Acquire(ctx)
+	p.recordAttempt(ctx, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to acquire connection from pool: %w", err)
 	}
 
+	p.mu.Lock()
+	p.pooledConns[sessionID] = conn
+	p.mu.Unlock()
 	return conn.Conn(), nil
 }
 
+// recordAttempt tells the breaker how an allowed connection attempt ended. Only a
+// backend that could not be reached counts as a failure: an attempt ended by the
+// caller's context, canceled or timed out while waiting for a connection of an
+// exhausted pool, says nothing about the backend and only releases the attempt
+func (p *Pool) recordAttempt(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		p.breaker.Success()
+	case ctx.Err() != nil:
+		p.breaker.Release()
+	default:
+		p.breaker.Failure()
+	}
+}
+
+// ReleaseForSession closes the connection of a session, or resets and returns it to
+// the pool in pooled mode. The connection is taken out of the session maps first, the
+// round trips to the backend run without holding p.mu and other sessions carry on
 func (p *Pool) ReleaseForSession(sessionID string) error {
 	if p.mode == ModeSessionAffinity || p.mode == ModeHybrid {
 		p.mu.Lock()
-		defer p.mu.Unlock()
-
-		if conn, exists := p.sessionConns[sessionID]; exists {
-			ctx := context.Background()
-			err := conn.Close(ctx)
-			delete(p.sessionConns, sessionID)
-			return err
+		conn, exists := p.sessionConns[sessionID]
+		delete(p.sessionConns, sessionID)
+		p.mu.Unlock()
+		if !exists {
+			return nil
 		}
+		ctx, cancel := context.WithTimeout(context.Background(), resetTimeout)
+		defer cancel()
+		OnNotice(conn.PgConn(), nil)
+		return conn.Close(ctx)
 	}
 
+	p.mu.Lock()
+	conn, exists := p.pooledConns[sessionID]
+	delete(p.pooledConns, sessionID)
+	p.mu.Unlock()
+	if exists {
+		ctx, cancel := context.WithTimeout(context.Background(), resetTimeout)
+		defer cancel()
+		OnNotice(conn.Conn().PgConn(), nil)
+		resetSession(ctx, conn.Conn())
+		conn.Release()
+	}
 	return nil
 }
 
+// resetTimeout bounds the reset of a released connection, a backend that does not
+// answer gets the connection closed instead of holding up the session's teardown
+var resetTimeout = 5 * time.Second
+
+// resetSession clears what a session changed on a pooled connection (search_path,
+// settings, temporary tables) before the next session gets it. A connection still in a
+// transaction or failing the reset is closed, the pool then replaces it
+func resetSession(ctx context.Context, conn *pgx.Conn) {
+	if conn.PgConn().TxStatus() != 'I' {
+		conn.Close(ctx)
+		return
+	}
+	if _, err := conn.Exec(ctx, "DISCARD ALL"); err != nil {
+		conn.Close(ctx)
+		return
+	}
+	if err := setLocalTimezone(ctx, conn); err != nil {
+		conn.Close(ctx)
+	}
+}
+
+// setLocalTimezone sets the session timezone to the numeric offset of the system's
+// local timezone, like '+08' or '-05', so LOCALTIMESTAMP and timestamp values match it
+func setLocalTimezone(ctx context.Context, conn *pgx.Conn) error {
+	_, offset := time.Now().Zone()
+	tz := fmt.Sprintf("%+03d", offset/3600)
+	_, err := conn.Exec(ctx, "SET timezone = '"+tz+"'")
+	return err
+}
+
 func (p *Pool) Stat() *pgxpool.Stat {
 	return p.pool.Stat()
 }
@@ -177,6 +271,10 @@ func (p *Pool) Close() {
 		conn.Close(ctx)
 		delete(p.sessionConns, sessionID)
 	}
+	for sessionID, conn := range p.pooledConns {
+		conn.Release()
+		delete(p.pooledConns, sessionID)
+	}
 
 	p.pool.Close()
 }
@@ -0,0 +1,177 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend is a PostgreSQL server that accepts any number of connections and
+// completes every query without rows, recording them
+type fakeBackend struct {
+	mu      sync.Mutex
+	queries []string
+	port    int
+	// stall holds the answer to DISCARD ALL until it is closed, when set
+	stall chan struct{}
+}
+
+func (b *fakeBackend) seen(query string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, q := range b.queries {
+		if q == query {
+			n++
+		}
+	}
+	return n
+}
+
+func newFakeBackend(t *testing.T) *fakeBackend {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	b := &fakeBackend{port: listener.Addr().(*net.TCPAddr).Port}
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(c)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBackend) serve(c net.Conn) {
+	defer c.Close()
+	backend := pgproto3.NewBackend(c, c)
+	if _, err := backend.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+	backend.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if backend.Flush() != nil {
+		return
+	}
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		query, ok := msg.(*pgproto3.Query)
+		if !ok {
+			continue
+		}
+		b.mu.Lock()
+		b.queries = append(b.queries, query.String)
+		b.mu.Unlock()
+		if query.String == "DISCARD ALL" && b.stall != nil {
+			<-b.stall
+		}
+		backend.Send(&pgproto3.EmptyQueryResponse{})
+		backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		if backend.Flush() != nil {
+			return
+		}
+	}
+}
+
+func TestPool_PooledConnectionsReturned(t *testing.T) {
+	backend := newFakeBackend(t)
+	p, err := NewPool(&Config{
+		Host: "127.0.0.1", Port: backend.port, Database: "postgres", User: "postgres",
+		SSLMode: "disable", MaxPoolSize: 1, Mode: ModePooled,
+	})
+	require.NoError(t, err)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A session keeps its connection, its transactions stay on one backend
+	first, err := p.AcquireForSession(ctx, "s1")
+	require.NoError(t, err)
+	again, err := p.AcquireForSession(ctx, "s1")
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	// The only connection is held by s1 until it ends
+	waitCtx, waitCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	_, err = p.AcquireForSession(waitCtx, "s2")
+	waitCancel()
+	require.Error(t, err)
+
+	require.NoError(t, p.ReleaseForSession("s1"))
+	assert.Equal(t, 1, backend.seen("DISCARD ALL"))
+
+	second, err := p.AcquireForSession(ctx, "s2")
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	require.NoError(t, p.ReleaseForSession("s2"))
+}
+
+func TestPool_ReleaseResetsOutsideTheLock(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.stall = make(chan struct{})
+	p, err := NewPool(&Config{
+		Host: "127.0.0.1", Port: backend.port, Database: "postgres", User: "postgres",
+		SSLMode: "disable", MaxPoolSize: 2, Mode: ModePooled,
+	})
+	require.NoError(t, err)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = p.AcquireForSession(ctx, "s1")
+	require.NoError(t, err)
+	released := make(chan error, 1)
+	go func() { released <- p.ReleaseForSession("s1") }()
+	require.Eventually(t, func() bool { return backend.seen("DISCARD ALL") == 1 }, time.Second, time.Millisecond)
+
+	// Another session gets a connection while the reset of s1 waits on the backend
+	_, err = p.AcquireForSession(ctx, "s2")
+	require.NoError(t, err)
+
+	close(backend.stall)
+	require.NoError(t, <-released)
+	require.NoError(t, p.ReleaseForSession("s2"))
+}
+
+func TestPool_ReleaseResetTimesOut(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.stall = make(chan struct{})
+	defer close(backend.stall)
+	p, err := NewPool(&Config{
+		Host: "127.0.0.1", Port: backend.port, Database: "postgres", User: "postgres",
+		SSLMode: "disable", MaxPoolSize: 1, Mode: ModePooled,
+	})
+	require.NoError(t, err)
+	defer p.Close()
+
+	defer func(timeout time.Duration) { resetTimeout = timeout }(resetTimeout)
+	resetTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	first, err := p.AcquireForSession(ctx, "s1")
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, p.ReleaseForSession("s1"))
+	assert.Less(t, time.Since(start), time.Second)
+	// The connection that did not answer is closed, the pool opens another one
+	assert.True(t, first.IsClosed())
+}
@@ -0,0 +1,63 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+)
+
+// Router maps MySQL database names to PostgreSQL backend pools
+// Databases without an explicit route are served by the default pool
+type Router struct {
+	defaultPool *Pool
+	routes      map[string]*Pool
+}
+
+func NewRouter(defaultPool *Pool, routes map[string]*Pool) *Router {
+	if routes == nil {
+		routes = make(map[string]*Pool)
+	}
+	return &Router{
+		defaultPool: defaultPool,
+		routes:      routes,
+	}
+}
+
+// PoolFor returns the pool serving the given MySQL database name
+func (r *Router) PoolFor(database string) *Pool {
+	if p, ok := r.routes[database]; ok {
+		return p
+	}
+	return r.defaultPool
+}
+
+func (r *Router) Default() *Pool {
+	return r.defaultPool
+}
+
+// Pools returns every distinct pool, the default pool first
+func (r *Router) Pools() []*Pool {
+	pools := []*Pool{r.defaultPool}
+	seen := map[*Pool]bool{r.defaultPool: true}
+	for _, p := range r.routes {
+		if !seen[p] {
+			seen[p] = true
+			pools = append(pools, p)
+		}
+	}
+	return pools
+}
+
+func (r *Router) Ping(ctx context.Context) error {
+	for _, p := range r.Pools() {
+		if err := p.Ping(ctx); err != nil {
+			return fmt.Errorf("backend %s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (r *Router) Close() {
+	for _, p := range r.Pools() {
+		p.Close()
+	}
+}
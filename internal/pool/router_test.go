@@ -0,0 +1,40 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_PoolFor(t *testing.T) {
+	defaultPool := &Pool{config: &Config{Database: "postgres"}}
+	tenantA := &Pool{config: &Config{Host: "pg-a", Database: "tenant_a"}}
+	tenantB := &Pool{config: &Config{Host: "pg-b", Database: "tenant_b"}}
+
+	router := NewRouter(defaultPool, map[string]*Pool{
+		"shop_a": tenantA,
+		"shop_b": tenantB,
+	})
+
+	assert.Same(t, tenantA, router.PoolFor("shop_a"))
+	assert.Same(t, tenantB, router.PoolFor("shop_b"))
+	assert.Same(t, defaultPool, router.PoolFor("unmapped"))
+	assert.Same(t, defaultPool, router.PoolFor(""))
+	assert.Same(t, defaultPool, router.Default())
+}
+
+func TestRouter_PoolsDeduplicated(t *testing.T) {
+	defaultPool := &Pool{config: &Config{}}
+	shared := &Pool{config: &Config{}}
+
+	router := NewRouter(defaultPool, map[string]*Pool{
+		"db1": shared,
+		"db2": shared,
+		"db3": defaultPool,
+	})
+
+	pools := router.Pools()
+	assert.Len(t, pools, 2)
+	assert.Same(t, defaultPool, pools[0])
+	assert.Same(t, shared, pools[1])
+}
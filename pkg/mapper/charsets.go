@@ -0,0 +1,110 @@
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// showCharsetRe matches SHOW COLLATION and SHOW {CHARACTER SET | CHARSET} with an
+// optional LIKE clause. WHERE filters and anything after the pattern are left to the
+// unsupported SHOW error
+var showCharsetRe = regexp.MustCompile(`(?is)^\s*SHOW\s+(COLLATION|CHARACTER\s+SET|CHARSET)\b(\s+LIKE\s+(?:'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*"))?\s*;?\s*$`)
+
+// mysqlCollation is one row of SHOW COLLATION
+type mysqlCollation struct {
+	name      string
+	charset   string
+	id        int
+	isDefault bool // The default collation of its character set
+	sortlen   int
+	noPad     bool // The UCA 9.0.0 collations compare trailing spaces
+}
+
+// mysqlCollations lists the collations connectors look up during startup, with the ids
+// MySQL 8.0 assigns them. Connectors pick the collation of the handshake or of
+// SET NAMES ... COLLATE from this list and abort if it is missing. Strings are
+// stored in PostgreSQL's database encoding whatever the client asks for
+var mysqlCollations = []mysqlCollation{
+	{name: "ascii_general_ci", charset: "ascii", id: 11, isDefault: true, sortlen: 1},
+	{name: "ascii_bin", charset: "ascii", id: 65, sortlen: 1},
+	{name: "binary", charset: "binary", id: 63, isDefault: true, sortlen: 1},
+	{name: "latin1_swedish_ci", charset: "latin1", id: 8, isDefault: true, sortlen: 1},
+	{name: "latin1_bin", charset: "latin1", id: 47, sortlen: 1},
+	{name: "latin1_general_ci", charset: "latin1", id: 48, sortlen: 1},
+	{name: "latin1_general_cs", charset: "latin1", id: 49, sortlen: 1},
+	{name: "utf8_general_ci", charset: "utf8", id: 33, isDefault: true, sortlen: 1},
+	{name: "utf8_bin", charset: "utf8", id: 83, sortlen: 1},
+	{name: "utf8_unicode_ci", charset: "utf8", id: 192, sortlen: 8},
+	{name: "utf8mb4_general_ci", charset: "utf8mb4", id: 45, sortlen: 1},
+	{name: "utf8mb4_bin", charset: "utf8mb4", id: 46, sortlen: 1},
+	{name: "utf8mb4_unicode_ci", charset: "utf8mb4", id: 224, sortlen: 8},
+	{name: "utf8mb4_unicode_520_ci", charset: "utf8mb4", id: 246, sortlen: 8},
+	{name: "utf8mb4_0900_ai_ci", charset: "utf8mb4", id: 255, isDefault: true, noPad: true},
+	{name: "utf8mb4_0900_as_cs", charset: "utf8mb4", id: 278, noPad: true},
+	{name: "utf8mb4_0900_bin", charset: "utf8mb4", id: 309, sortlen: 1, noPad: true},
+}
+
+// mysqlCharsets describes the character sets of mysqlCollations for SHOW CHARACTER SET
+var mysqlCharsets = []struct {
+	name        string
+	description string
+	maxlen      int
+}{
+	{"ascii", "US ASCII", 1},
+	{"binary", "Binary pseudo charset", 1},
+	{"latin1", "cp1252 West European", 1},
+	{"utf8", "UTF-8 Unicode", 3},
+	{"utf8mb4", "UTF-8 Unicode", 4},
+}
+
+// showCollationQuery builds the query for SHOW COLLATION [LIKE 'pattern']
+func showCollationQuery(pattern string) string {
+	rows := make([]string, 0, len(mysqlCollations))
+	for _, c := range mysqlCollations {
+		isDefault, padAttribute := "", "PAD SPACE"
+		if c.isDefault {
+			isDefault = "Yes"
+		}
+		if c.noPad {
+			padAttribute = "NO PAD"
+		}
+		rows = append(rows, fmt.Sprintf("('%s', '%s', %d, '%s', 'Yes', %d, '%s')",
+			c.name, c.charset, c.id, isDefault, c.sortlen, padAttribute))
+	}
+
+	query := `
+		SELECT * FROM (VALUES
+			` + strings.Join(rows, ",\n\t\t\t") + `
+		) AS c("Collation", "Charset", "Id", "Default", "Compiled", "Sortlen", "Pad_attribute")`
+	return showLikeFilter(query, "Collation", pattern) + `
+		ORDER BY 1
+	`
+}
+
+// showCharsetQuery builds the query for SHOW CHARACTER SET [LIKE 'pattern']
+func showCharsetQuery(pattern string) string {
+	rows := make([]string, 0, len(mysqlCharsets))
+	for _, cs := range mysqlCharsets {
+		rows = append(rows, fmt.Sprintf("('%s', '%s', '%s', %d)",
+			cs.name, cs.description, defaultCollationOf(cs.name), cs.maxlen))
+	}
+
+	query := `
+		SELECT * FROM (VALUES
+			` + strings.Join(rows, ",\n\t\t\t") + `
+		) AS cs("Charset", "Description", "Default collation", "Maxlen")`
+	return showLikeFilter(query, "Charset", pattern) + `
+		ORDER BY 1
+	`
+}
+
+// defaultCollationOf returns the collation SHOW CHARACTER SET lists as the default of charset
+func defaultCollationOf(charset string) string {
+	for _, c := range mysqlCollations {
+		if c.charset == charset && c.isDefault {
+			return c.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package mapper
+
+import "regexp"
+
+// showEngineStatusRe matches SHOW ENGINE INNODB STATUS
+var showEngineStatusRe = regexp.MustCompile(`(?is)^\s*SHOW\s+ENGINE\s+INNODB\s+STATUS\s*;?\s*$`)
+
+// innodbStatusQuery builds the query for SHOW ENGINE INNODB STATUS. MySQL returns one row
+// of Type, Name and a text report, monitoring tools read a few of its sections. The report
+// here keeps the layout of InnoDB's monitor output and fills it from PostgreSQL: open
+// transactions from pg_stat_activity, marked LOCK WAIT while waiting on a lock, and row
+// counts of the current database from pg_stat_database
+func innodbStatusQuery() string {
+	return `
+		SELECT 'InnoDB' AS "Type", '' AS "Name", concat_ws(E'\n',
+			'',
+			'=====================================',
+			to_char(now(), 'YYYY-MM-DD HH24:MI:SS') || ' 0x0 INNODB MONITOR OUTPUT',
+			'=====================================',
+			'Per second averages calculated from the last 0 seconds',
+			'------------',
+			'TRANSACTIONS',
+			'------------',
+			'Trx id counter ' || txid_snapshot_xmax(txid_current_snapshot()),
+			'History list length 0',
+			'LIST OF TRANSACTIONS FOR EACH SESSION:',
+			(SELECT string_agg(
+				'---TRANSACTION ' || COALESCE(backend_xid::text, '0') ||
+				', ACTIVE ' || floor(extract(epoch FROM now() - xact_start))::bigint || ' sec' ||
+				CASE WHEN wait_event_type = 'Lock' THEN ' LOCK WAIT' ELSE '' END || E'\n' ||
+				'MySQL thread id ' || pid || ', OS thread handle 0, query id 0 ' ||
+				COALESCE(host(client_addr), 'localhost') || ' ' || COALESCE(usename, '') || ' ' || COALESCE(state, ''),
+				E'\n' ORDER BY xact_start)
+			FROM pg_stat_activity
+			WHERE backend_type = 'client backend' AND xact_start IS NOT NULL),
+			'--------------',
+			'ROW OPERATIONS',
+			'--------------',
+			(SELECT count(*) FROM pg_stat_activity
+			WHERE backend_type = 'client backend' AND state = 'active') || ' queries inside InnoDB, 0 queries in queue',
+			(SELECT 'Number of rows inserted ' || tup_inserted || ', updated ' || tup_updated ||
+				', deleted ' || tup_deleted || ', read ' || tup_fetched
+			FROM pg_stat_database WHERE datname = current_database()),
+			'----------------------------',
+			'END OF INNODB MONITOR OUTPUT',
+			'============================',
+			'') AS "Status"
+	`
+}
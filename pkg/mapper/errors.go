@@ -1,9 +1,18 @@
 package mapper
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// lockNowaitMessage is MySQL's message for a NOWAIT locking read that found a row locked
+const lockNowaitMessage = "Statement aborted because lock(s) could not be acquired immediately and NOWAIT is set."
+
 const (
 	ER_DUP_ENTRY                  = 1062
 	ER_NO_REFERENCED_ROW_2        = 1452
@@ -35,6 +44,10 @@ const (
 	ER_OUT_OF_RESOURCES           = 1041
 	ER_SPECIFIC_ACCESS_DENIED     = 1227
 	ER_LOCK_DEADLOCK_DETECTED     = 1213
+	ER_INCORRECT_GLOBAL_LOCAL_VAR = 1238
+	ER_LOCK_NOWAIT                = 3572
+
+	ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION = 1792
 )
 
 type ErrorMapper struct {
@@ -83,6 +96,7 @@ func initSQLStateMapping() map[string]uint16 {
 		"22007": ER_TRUNCATED_WRONG_VALUE,
 		"22008": ER_TRUNCATED_WRONG_VALUE,
 		"23001": ER_NO_DEFAULT_FOR_FIELD,
+		"25006": ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION,
 	}
 }
 
@@ -91,8 +105,17 @@ func (em *ErrorMapper) MapError(pgErr error) (uint16, string) {
 		return 0, ""
 	}
 
-	if pge, ok := pgErr.(*pgconn.PgError); ok {
+	var pge *pgconn.PgError
+	if errors.As(pgErr, &pge) {
 		if mysqlCode, exists := em.sqlStateToMySQL[pge.Code]; exists {
+			if mysqlCode == ER_DATA_TOO_LONG {
+				return mysqlCode, dataTooLongMessage(pge)
+			}
+			// lock_not_available is also raised when lock_timeout expires, only NOWAIT
+			// fails with "could not obtain lock ..."
+			if mysqlCode == ER_LOCK_WAIT_TIMEOUT && strings.HasPrefix(pge.Message, "could not obtain lock") {
+				return ER_LOCK_NOWAIT, lockNowaitMessage
+			}
 			return mysqlCode, pge.Message
 		}
 
@@ -102,9 +125,77 @@ func (em *ErrorMapper) MapError(pgErr error) (uint16, string) {
 	return ER_UNKNOWN_ERROR, pgErr.Error()
 }
 
+// MySQLError maps a PostgreSQL error to the MySQL error sent to the client, with the
+// SQLSTATE MySQL sends for its code. Errors without a MySQL equivalent keep the
+// PostgreSQL SQLSTATE instead of HY000, reduced to its class when it is one only
+// PostgreSQL defines, so clients can still tell a data error from a syntax error
+//
+//	23505 unique_violation             -> 1062, 23000
+//	22021 character_not_in_repertoire  -> 1105, 22021
+//	22P02 invalid_text_representation  -> 1105, 22000
+func (em *ErrorMapper) MySQLError(pgErr error) *mysql.MyError {
+	code, message := em.MapError(pgErr)
+	myErr := mysql.NewError(code, message)
+
+	var pge *pgconn.PgError
+	if code == ER_UNKNOWN_ERROR && errors.As(pgErr, &pge) {
+		myErr.State = sqlStateOf(pge.Code)
+	}
+	return myErr
+}
+
+// sqlStateOf returns the SQLSTATE reported for a PostgreSQL one. Codes with letters
+// in the subclass are PostgreSQL's own and become their class (42P01 -> 42000), as
+// does any code of the internal error class XX
+func sqlStateOf(pgState string) string {
+	if len(pgState) != 5 || strings.HasPrefix(pgState, "XX") {
+		return mysql.DEFAULT_MYSQL_STATE
+	}
+	for _, c := range pgState[2:] {
+		if c < '0' || c > '9' {
+			return pgState[:2] + "000"
+		}
+	}
+	return pgState
+}
+
+// pgColumnRe finds a column name in error details and context,
+// e.g. `column "name"` or `column name:` (COPY context)
+var pgColumnRe = regexp.MustCompile(`column "?([^\s":,]+)"?`)
+
+// dataTooLongMessage formats string_data_right_truncation like MySQL's ER_DATA_TOO_LONG,
+// which names the column. PostgreSQL only reports it in some cases, so fall back
+// to its own message when the column is unknown
+func dataTooLongMessage(pge *pgconn.PgError) string {
+	column := pge.ColumnName
+	if column == "" {
+		for _, text := range []string{pge.Detail, pge.Where} {
+			if m := pgColumnRe.FindStringSubmatch(text); m != nil {
+				column = m[1]
+				break
+			}
+		}
+	}
+
+	if column == "" {
+		return fmt.Sprintf("Data too long: %s", pge.Message)
+	}
+	return fmt.Sprintf("Data too long for column '%s' at row 1", column)
+}
+
 func (em *ErrorMapper) GetMySQLErrorCode(sqlState string) uint16 {
 	if code, exists := em.sqlStateToMySQL[sqlState]; exists {
 		return code
 	}
 	return ER_UNKNOWN_ERROR
 }
+
+// IsSerializationFailure reports whether err is a transient serialization
+// failure (40001) or deadlock (40P01) that can be retried
+func IsSerializationFailure(err error) bool {
+	var pge *pgconn.PgError
+	if !errors.As(err, &pge) {
+		return false
+	}
+	return pge.Code == "40001" || pge.Code == "40P01"
+}
@@ -2,6 +2,7 @@ package mapper
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/jackc/pgx/v5/pgconn"
@@ -68,6 +69,24 @@ func TestErrorMapper_MapError(t *testing.T) {
 			expectedCode: ER_NO_SUCH_TABLE,
 			expectedMsg:  "relation does not exist",
 		},
+		{
+			name: "NOWAIT locking read",
+			pgErr: &pgconn.PgError{
+				Code:    "55P03",
+				Message: `could not obtain lock on row in relation "accounts"`,
+			},
+			expectedCode: ER_LOCK_NOWAIT,
+			expectedMsg:  "Statement aborted because lock(s) could not be acquired immediately and NOWAIT is set.",
+		},
+		{
+			name: "lock_timeout",
+			pgErr: &pgconn.PgError{
+				Code:    "55P03",
+				Message: "canceling statement due to lock timeout",
+			},
+			expectedCode: ER_LOCK_WAIT_TIMEOUT,
+			expectedMsg:  "canceling statement due to lock timeout",
+		},
 		{
 			name:         "generic error",
 			pgErr:        errors.New("some error"),
@@ -87,6 +106,69 @@ func TestErrorMapper_MapError(t *testing.T) {
 	}
 }
 
+func TestErrorMapper_MapError_DataTooLong(t *testing.T) {
+	em := NewErrorMapper()
+
+	tests := []struct {
+		name        string
+		pgErr       *pgconn.PgError
+		expectedMsg string
+	}{
+		{
+			name:        "column field",
+			pgErr:       &pgconn.PgError{Code: "22001", Message: "value too long for type character varying(5)", ColumnName: "name"},
+			expectedMsg: "Data too long for column 'name' at row 1",
+		},
+		{
+			name:        "column in context",
+			pgErr:       &pgconn.PgError{Code: "22001", Message: "value too long for type character varying(5)", Where: "COPY users, line 1, column name: \"abcdefgh\""},
+			expectedMsg: "Data too long for column 'name' at row 1",
+		},
+		{
+			name:        "column unknown",
+			pgErr:       &pgconn.PgError{Code: "22001", Message: "value too long for type character varying(5)"},
+			expectedMsg: "Data too long: value too long for type character varying(5)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, msg := em.MapError(tt.pgErr)
+			assert.Equal(t, uint16(ER_DATA_TOO_LONG), code)
+			assert.Equal(t, tt.expectedMsg, msg)
+		})
+	}
+}
+
+func TestErrorMapper_MySQLError(t *testing.T) {
+	em := NewErrorMapper()
+
+	tests := []struct {
+		name  string
+		pgErr error
+		code  uint16
+		state string
+	}{
+		{"unique violation", &pgconn.PgError{Code: "23505", Message: "duplicate key"}, ER_DUP_ENTRY, "23000"},
+		{"wrapped", fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"}), ER_DUP_ENTRY, "23000"},
+		{"undefined table", &pgconn.PgError{Code: "42P01"}, ER_NO_SUCH_TABLE, "42S02"},
+		{"MySQL sends HY000 for its code", &pgconn.PgError{Code: "55P03", Message: "canceling statement due to lock timeout"}, ER_LOCK_WAIT_TIMEOUT, "HY000"},
+		{"read-only transaction", &pgconn.PgError{Code: "25006"}, ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION, "25006"},
+		{"standard state without MySQL code", &pgconn.PgError{Code: "22021"}, ER_UNKNOWN_ERROR, "22021"},
+		{"PostgreSQL-only state", &pgconn.PgError{Code: "22P02"}, ER_UNKNOWN_ERROR, "22000"},
+		{"internal error", &pgconn.PgError{Code: "XX000"}, ER_UNKNOWN_ERROR, "HY000"},
+		{"not from PostgreSQL", errors.New("connection reset"), ER_UNKNOWN_ERROR, "HY000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			myErr := em.MySQLError(tt.pgErr)
+			assert.Equal(t, tt.code, myErr.Code)
+			assert.Equal(t, tt.state, myErr.State)
+		})
+	}
+}
+
 func BenchmarkErrorMapper_MapError(b *testing.B) {
 	em := NewErrorMapper()
 	pgErr := &pgconn.PgError{
@@ -99,3 +181,12 @@ func BenchmarkErrorMapper_MapError(b *testing.B) {
 		em.MapError(pgErr)
 	}
 }
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, IsSerializationFailure(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, IsSerializationFailure(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, IsSerializationFailure(fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40001"})))
+	assert.False(t, IsSerializationFailure(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsSerializationFailure(errors.New("40001")))
+	assert.False(t, IsSerializationFailure(nil))
+}
@@ -0,0 +1,249 @@
+package mapper
+
+import (
+	"bytes"
+	"io"
+)
+
+// LoadDataFormat is the field and line layout of LOAD DATA input
+type LoadDataFormat struct {
+	FieldsTerminated string
+	FieldsEnclosed   string // At most one character
+	FieldsEscaped    string // At most one character
+	LinesStarting    string
+	LinesTerminated  string
+	IgnoreLines      uint64
+}
+
+// LoadDataConverter converts LOAD DATA input to PostgreSQL COPY text format as
+// it streams in. Input may be split anywhere, incomplete records are buffered
+// until the next Write or Close
+type LoadDataConverter struct {
+	format LoadDataFormat
+	w      io.Writer
+	buf    []byte
+	skip   uint64
+}
+
+type loadDataField struct {
+	value []byte
+	null  bool
+}
+
+func NewLoadDataConverter(format LoadDataFormat, w io.Writer) *LoadDataConverter {
+	return &LoadDataConverter{format: format, w: w, skip: format.IgnoreLines}
+}
+
+func (c *LoadDataConverter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	if err := c.convert(false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close converts the last record, which needs no line terminator
+func (c *LoadDataConverter) Close() error {
+	return c.convert(true)
+}
+
+func (c *LoadDataConverter) convert(final bool) error {
+	var out []byte
+	pos := 0
+	for pos < len(c.buf) {
+		fields, n, ok := c.parseRecord(c.buf[pos:], final)
+		if !ok {
+			break
+		}
+		pos += n
+		if fields == nil {
+			continue
+		}
+		if c.skip > 0 {
+			c.skip--
+			continue
+		}
+		out = appendCopyRow(out, fields)
+	}
+	c.buf = c.buf[:copy(c.buf, c.buf[pos:])]
+
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := c.w.Write(out)
+	return err
+}
+
+// parseRecord parses one record from the start of data and returns its fields and
+// length. ok is false when data ends before the record does and more input may follow.
+// fields is nil for input without LINES STARTING BY prefix, which MySQL skips
+func (c *LoadDataConverter) parseRecord(data []byte, final bool) (fields []loadDataField, n int, ok bool) {
+	fieldTerm := []byte(c.format.FieldsTerminated)
+	lineTerm := []byte(c.format.LinesTerminated)
+	enclosed := c.format.FieldsEnclosed != ""
+	escaped := c.format.FieldsEscaped != ""
+	var enc, esc byte
+	if enclosed {
+		enc = c.format.FieldsEnclosed[0]
+	}
+	if escaped {
+		esc = c.format.FieldsEscaped[0]
+	}
+
+	// A separator may be split across writes
+	needMore := func(rest []byte) bool {
+		return !final && (isPartialPrefix(rest, fieldTerm) || isPartialPrefix(rest, lineTerm))
+	}
+
+	i := 0
+	if prefix := c.format.LinesStarting; prefix != "" {
+		idx := bytes.Index(data, []byte(prefix))
+		if idx < 0 {
+			if final {
+				return nil, len(data), true
+			}
+			return nil, 0, false
+		}
+		i = idx + len(prefix)
+	}
+
+	for {
+		var field loadDataField
+		if enclosed && i < len(data) && data[i] == enc {
+			i++
+			closed := false
+			for i < len(data) && !closed {
+				switch ch := data[i]; {
+				case escaped && ch == esc:
+					if i+1 >= len(data) {
+						if !final {
+							return nil, 0, false
+						}
+						field.value = append(field.value, ch)
+						i++
+						continue
+					}
+					field.value = append(field.value, unescapeLoadData(data[i+1]))
+					i += 2
+				case ch == enc:
+					rest := data[i+1:]
+					switch {
+					case len(rest) > 0 && rest[0] == enc:
+						// Doubled enclosure character
+						field.value = append(field.value, enc)
+						i += 2
+					case bytes.HasPrefix(rest, fieldTerm) || bytes.HasPrefix(rest, lineTerm) || (final && len(rest) == 0):
+						i++
+						closed = true
+					case len(rest) == 0 || needMore(rest):
+						return nil, 0, false
+					default:
+						field.value = append(field.value, ch)
+						i++
+					}
+				default:
+					field.value = append(field.value, ch)
+					i++
+				}
+			}
+			if !closed && !final {
+				return nil, 0, false
+			}
+		} else {
+			start := i
+			for i < len(data) {
+				rest := data[i:]
+				if bytes.HasPrefix(rest, fieldTerm) || bytes.HasPrefix(rest, lineTerm) {
+					break
+				}
+				if needMore(rest) {
+					return nil, 0, false
+				}
+				if escaped && data[i] == esc {
+					if i+1 >= len(data) {
+						if !final {
+							return nil, 0, false
+						}
+						field.value = append(field.value, esc)
+						i++
+						continue
+					}
+					field.value = append(field.value, unescapeLoadData(data[i+1]))
+					i += 2
+					continue
+				}
+				field.value = append(field.value, data[i])
+				i++
+			}
+			if i >= len(data) && !final {
+				return nil, 0, false
+			}
+
+			// \N is NULL, and so is a bare NULL word when fields can be enclosed
+			raw := string(data[start:i])
+			field.null = (escaped && raw == string([]byte{esc, 'N'})) || (enclosed && raw == "NULL")
+		}
+		fields = append(fields, field)
+
+		switch rest := data[i:]; {
+		case bytes.HasPrefix(rest, fieldTerm):
+			i += len(fieldTerm)
+		case bytes.HasPrefix(rest, lineTerm):
+			return fields, i + len(lineTerm), true
+		default:
+			// End of input
+			return fields, i, true
+		}
+	}
+}
+
+func isPartialPrefix(rest, sep []byte) bool {
+	return len(rest) < len(sep) && bytes.HasPrefix(sep, rest)
+}
+
+// unescapeLoadData resolves the character following the escape character
+func unescapeLoadData(ch byte) byte {
+	switch ch {
+	case '0':
+		return 0
+	case 'b':
+		return '\b'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'Z':
+		return 0x1a
+	}
+	return ch
+}
+
+// appendCopyRow appends fields as a COPY text format line
+func appendCopyRow(out []byte, fields []loadDataField) []byte {
+	for i, field := range fields {
+		if i > 0 {
+			out = append(out, '\t')
+		}
+		if field.null {
+			out = append(out, `\N`...)
+			continue
+		}
+		for _, ch := range field.value {
+			switch ch {
+			case '\\':
+				out = append(out, `\\`...)
+			case '\n':
+				out = append(out, `\n`...)
+			case '\r':
+				out = append(out, `\r`...)
+			case '\t':
+				out = append(out, `\t`...)
+			default:
+				out = append(out, ch)
+			}
+		}
+	}
+	return append(out, '\n')
+}
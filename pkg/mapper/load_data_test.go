@@ -0,0 +1,117 @@
+package mapper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tsvFormat() LoadDataFormat {
+	return LoadDataFormat{FieldsTerminated: "\t", FieldsEscaped: "\\", LinesTerminated: "\n"}
+}
+
+func csvFormat() LoadDataFormat {
+	return LoadDataFormat{FieldsTerminated: ",", FieldsEnclosed: `"`, FieldsEscaped: "\\", LinesTerminated: "\n"}
+}
+
+func TestLoadDataConverter(t *testing.T) {
+	crlf := csvFormat()
+	crlf.LinesTerminated = "\r\n"
+	header := csvFormat()
+	header.IgnoreLines = 1
+	prefixed := tsvFormat()
+	prefixed.LinesStarting = "xxx"
+	noEscape := csvFormat()
+	noEscape.FieldsEscaped = ""
+
+	tests := []struct {
+		name     string
+		format   LoadDataFormat
+		input    string
+		expected string
+	}{
+		{
+			name:     "Defaults",
+			format:   tsvFormat(),
+			input:    "1\talice\n2\tbob\n",
+			expected: "1\talice\n2\tbob\n",
+		},
+		{
+			name:     "Last line without terminator",
+			format:   tsvFormat(),
+			input:    "1\talice\n2\tbob",
+			expected: "1\talice\n2\tbob\n",
+		},
+		{
+			name:     "Escapes and NULL",
+			format:   tsvFormat(),
+			input:    "1\ta\\tb\\nc\\\\d\n2\t\\N\n3\tN\n",
+			expected: "1\ta\\tb\\nc\\\\d\n2\t\\N\n3\tN\n",
+		},
+		{
+			name:     "Enclosed fields",
+			format:   csvFormat(),
+			input:    "1,\"a, b\",\"say \"\"hi\"\"\"\n2,\"multi\nline\",plain\n",
+			expected: "1\ta, b\tsay \"hi\"\n2\tmulti\\nline\tplain\n",
+		},
+		{
+			name:     "NULL word with enclosure, quoted NULL is a string",
+			format:   csvFormat(),
+			input:    "1,NULL,\"NULL\"\n",
+			expected: "1\t\\N\tNULL\n",
+		},
+		{
+			name:     "Tabs in CSV values are escaped for COPY",
+			format:   csvFormat(),
+			input:    "1,\"a\tb\"\n",
+			expected: "1\ta\\tb\n",
+		},
+		{
+			name:     "CRLF lines",
+			format:   crlf,
+			input:    "1,a\r\n2,b\r\n",
+			expected: "1\ta\n2\tb\n",
+		},
+		{
+			name:     "Ignore header line",
+			format:   header,
+			input:    "id,name\n1,a\n",
+			expected: "1\ta\n",
+		},
+		{
+			name:     "Lines starting by",
+			format:   prefixed,
+			input:    "xxx1\ta\nskipped\nyyyxxx2\tb\n",
+			expected: "1\ta\n2\tb\n",
+		},
+		{
+			name:     "Backslash is literal without escape character",
+			format:   noEscape,
+			input:    "1,C:\\dir\n",
+			expected: "1\tC:\\\\dir\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := NewLoadDataConverter(tt.format, &out)
+			_, err := c.Write([]byte(tt.input))
+			require.NoError(t, err)
+			require.NoError(t, c.Close())
+			assert.Equal(t, tt.expected, out.String())
+
+			// Packets split the file at arbitrary points
+			var split bytes.Buffer
+			c = NewLoadDataConverter(tt.format, &split)
+			for i := 0; i < len(tt.input); i++ {
+				_, err := c.Write([]byte{tt.input[i]})
+				require.NoError(t, err)
+			}
+			require.NoError(t, c.Close())
+			assert.Equal(t, tt.expected, split.String())
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// showMasterStatusRe matches SHOW MASTER STATUS and its MySQL 8.2 name SHOW BINARY LOG STATUS
+	showMasterStatusRe = regexp.MustCompile(`(?is)^\s*SHOW\s+(?:MASTER|BINARY\s+LOG)\s+STATUS\s*;?\s*$`)
+	// showSlaveStatusRe matches SHOW {SLAVE | REPLICA} STATUS [FOR CHANNEL name]
+	showSlaveStatusRe = regexp.MustCompile(`(?is)^\s*SHOW\s+(SLAVE|REPLICA)\s+STATUS(?:\s+FOR\s+CHANNEL\s+\S+)?\s*;?\s*$`)
+)
+
+// masterStatusColumns are the columns of SHOW MASTER STATUS
+var masterStatusColumns = []string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}
+
+// slaveStatusColumns are the columns of SHOW SLAVE STATUS in MySQL 8.0. SHOW REPLICA
+// STATUS names them with Replica and Source instead of Slave and Master
+var slaveStatusColumns = []string{
+	"Slave_IO_State", "Master_Host", "Master_User", "Master_Port", "Connect_Retry",
+	"Master_Log_File", "Read_Master_Log_Pos", "Relay_Log_File", "Relay_Log_Pos", "Relay_Master_Log_File",
+	"Slave_IO_Running", "Slave_SQL_Running", "Replicate_Do_DB", "Replicate_Ignore_DB", "Replicate_Do_Table",
+	"Replicate_Ignore_Table", "Replicate_Wild_Do_Table", "Replicate_Wild_Ignore_Table", "Last_Errno", "Last_Error",
+	"Skip_Counter", "Exec_Master_Log_Pos", "Relay_Log_Space", "Until_Condition", "Until_Log_File",
+	"Until_Log_Pos", "Master_SSL_Allowed", "Master_SSL_CA_File", "Master_SSL_CA_Path", "Master_SSL_Cert",
+	"Master_SSL_Cipher", "Master_SSL_Key", "Seconds_Behind_Master", "Master_SSL_Verify_Server_Cert", "Last_IO_Errno",
+	"Last_IO_Error", "Last_SQL_Errno", "Last_SQL_Error", "Replicate_Ignore_Server_Ids", "Master_Server_Id",
+	"Master_UUID", "Master_Info_File", "SQL_Delay", "SQL_Remaining_Delay", "Slave_SQL_Running_State",
+	"Master_Retry_Count", "Master_Bind", "Last_IO_Error_Timestamp", "Last_SQL_Error_Timestamp", "Master_SSL_Crl",
+	"Master_SSL_Crlpath", "Retrieved_Gtid_Set", "Executed_Gtid_Set", "Auto_Position", "Replicate_Rewrite_DB",
+	"Channel_Name", "Master_TLS_Version", "Master_public_key_path", "Get_master_public_key", "Network_Namespace",
+}
+
+// replicationIntColumns are the numeric columns of both statements
+var replicationIntColumns = map[string]bool{
+	"Position": true, "Master_Port": true, "Connect_Retry": true, "Read_Master_Log_Pos": true,
+	"Relay_Log_Pos": true, "Last_Errno": true, "Skip_Counter": true, "Exec_Master_Log_Pos": true,
+	"Relay_Log_Space": true, "Until_Log_Pos": true, "Seconds_Behind_Master": true, "Last_IO_Errno": true,
+	"Last_SQL_Errno": true, "Master_Server_Id": true, "SQL_Delay": true, "SQL_Remaining_Delay": true,
+	"Master_Retry_Count": true, "Auto_Position": true, "Get_master_public_key": true,
+}
+
+// masterStatusQuery builds the query for SHOW MASTER STATUS. The proxy writes no binary
+// log, MySQL then returns no row, which replication-aware tools take as binary logging
+// being off
+func masterStatusQuery() string {
+	return emptyReplicationQuery(masterStatusColumns, nil)
+}
+
+// slaveStatusQuery builds the query for SHOW SLAVE STATUS and SHOW REPLICA STATUS. The
+// proxy is no MySQL replica, MySQL then returns no row. PostgreSQL's own streaming
+// replication has no binary log positions to report in these columns
+func slaveStatusQuery(replica bool) string {
+	if !replica {
+		return emptyReplicationQuery(slaveStatusColumns, nil)
+	}
+	rename := strings.NewReplacer("Slave", "Replica", "Master", "Source", "master", "Source")
+	return emptyReplicationQuery(slaveStatusColumns, rename)
+}
+
+// emptyReplicationQuery selects no row with the given columns, renamed by rename if set
+func emptyReplicationQuery(columns []string, rename *strings.Replacer) string {
+	fields := make([]string, 0, len(columns))
+	for _, column := range columns {
+		tp := "text"
+		if replicationIntColumns[column] {
+			tp = "bigint"
+		}
+		if rename != nil {
+			column = rename.Replace(column)
+		}
+		fields = append(fields, fmt.Sprintf(`NULL::%s AS "%s"`, tp, column))
+	}
+	return `
+		SELECT ` + strings.Join(fields, ",\n\t\t\t") + `
+		WHERE false
+	`
+}
@@ -0,0 +1,321 @@
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SetScope is the scope a SET assignment targets
+type SetScope int
+
+const (
+	ScopeSession SetScope = iota
+	ScopeGlobal
+	ScopePersist
+	ScopePersistOnly
+	ScopeUser // @name user variable
+)
+
+// setScopeKeywords maps SET scope keywords and @@scope. prefixes to their scope
+var setScopeKeywords = map[string]SetScope{
+	"session":      ScopeSession,
+	"local":        ScopeSession,
+	"global":       ScopeGlobal,
+	"persist":      ScopePersist,
+	"persist_only": ScopePersistOnly,
+}
+
+// readOnlyVariables are system variables MySQL refuses to SET in any scope
+var readOnlyVariables = map[string]bool{
+	"basedir":                 true,
+	"character_set_system":    true,
+	"datadir":                 true,
+	"have_openssl":            true,
+	"have_ssl":                true,
+	"hostname":                true,
+	"innodb_version":          true,
+	"license":                 true,
+	"log_bin":                 true,
+	"lower_case_table_names":  true,
+	"pid_file":                true,
+	"port":                    true,
+	"protocol_version":        true,
+	"server_uuid":             true,
+	"socket":                  true,
+	"version":                 true,
+	"version_comment":         true,
+	"version_compile_machine": true,
+	"version_compile_os":      true,
+}
+
+// ReadOnlyVariableError reports an attempt to SET a read-only system variable
+type ReadOnlyVariableError struct {
+	Name string
+}
+
+func (e *ReadOnlyVariableError) Error() string {
+	return fmt.Sprintf("Variable '%s' is a read only variable", e.Name)
+}
+
+// parseSetTarget splits the left-hand side of a SET assignment into its scope and variable name
+//
+//	GLOBAL max_connections    -> ScopeGlobal, max_connections
+//	@@persist.sql_mode        -> ScopePersist, sql_mode
+//	@@autocommit              -> ScopeSession, autocommit
+//	@total                    -> ScopeUser, @total
+func parseSetTarget(target string) (SetScope, string) {
+	target = strings.TrimSpace(target)
+
+	if fields := strings.Fields(target); len(fields) == 2 {
+		if scope, ok := setScopeKeywords[strings.ToLower(fields[0])]; ok {
+			return scope, strings.Trim(fields[1], "`")
+		}
+	}
+
+	if name, ok := strings.CutPrefix(target, "@@"); ok {
+		if prefix, rest, found := strings.Cut(name, "."); found {
+			if scope, ok := setScopeKeywords[strings.ToLower(prefix)]; ok {
+				return scope, strings.Trim(rest, "`")
+			}
+		}
+		return ScopeSession, strings.Trim(name, "`")
+	}
+
+	if strings.HasPrefix(target, "@") {
+		return ScopeUser, target
+	}
+	return ScopeSession, strings.Trim(target, "`")
+}
+
+// SetAssignment is one assignment of a SET statement
+type SetAssignment struct {
+	Scope SetScope
+	Name  string // System variable name, or @name for user variables
+	Value string // Unquoted value as written
+}
+
+// ParseSetStatement splits a SET statement into its assignments, in order
+//
+//	SET autocommit = 0, @@session.time_zone = '+00:00', @x := 1, NAMES utf8mb4
+//
+// SET NAMES and SET CHARACTER SET expand to the character_set_* variables they
+// change. Read-only variables are rejected in every scope, so a statement
+// naming one applies nothing
+func ParseSetStatement(sql string) ([]SetAssignment, error) {
+	body := strings.TrimSpace(sql)
+	if len(body) < 4 || !strings.EqualFold(body[:4], "SET ") {
+		return nil, fmt.Errorf("not a SET command: %s", sql)
+	}
+
+	var assignments []SetAssignment
+	for _, item := range splitTopLevel(body[4:], ',') {
+		item = strings.TrimSpace(item)
+		if charset, ok := parseSetCharset(item); ok {
+			assignments = append(assignments, charset...)
+			continue
+		}
+
+		target, value, ok := cutAssignment(item)
+		if !ok {
+			return nil, fmt.Errorf("invalid SET syntax: %s", sql)
+		}
+		scope, name := parseSetTarget(target)
+		if name == "" {
+			return nil, fmt.Errorf("invalid SET syntax: %s", sql)
+		}
+		if scope != ScopeUser && readOnlyVariables[strings.ToLower(name)] {
+			return nil, &ReadOnlyVariableError{Name: name}
+		}
+		assignments = append(assignments, SetAssignment{Scope: scope, Name: name, Value: unquoteSetValue(value)})
+	}
+	return assignments, nil
+}
+
+// parseSetCharset expands SET NAMES cs [COLLATE coll] and SET CHARACTER SET cs
+func parseSetCharset(item string) ([]SetAssignment, bool) {
+	if strings.Contains(item, "=") {
+		return nil, false // An assignment to a variable named names or charset
+	}
+
+	fields := strings.Fields(item)
+	var charset, collation string
+	var variables []string
+	switch {
+	case len(fields) >= 2 && strings.EqualFold(fields[0], "NAMES"):
+		charset = fields[1]
+		variables = []string{"character_set_client", "character_set_connection", "character_set_results"}
+		collation = defaultCollation(unquoteSetValue(charset))
+		if len(fields) == 4 && strings.EqualFold(fields[2], "COLLATE") {
+			collation = fields[3]
+		}
+	case len(fields) == 3 && strings.EqualFold(fields[0], "CHARACTER") && strings.EqualFold(fields[1], "SET"):
+		charset = fields[2]
+		variables = []string{"character_set_client", "character_set_results"}
+	case len(fields) == 2 && strings.EqualFold(fields[0], "CHARSET"):
+		charset = fields[1]
+		variables = []string{"character_set_client", "character_set_results"}
+	default:
+		return nil, false
+	}
+
+	assignments := make([]SetAssignment, 0, len(variables)+1)
+	for _, name := range variables {
+		assignments = append(assignments, SetAssignment{Scope: ScopeSession, Name: name, Value: unquoteSetValue(charset)})
+	}
+	if collation != "" {
+		assignments = append(assignments, SetAssignment{Scope: ScopeSession, Name: "collation_connection", Value: unquoteSetValue(collation)})
+	}
+	return assignments, true
+}
+
+// defaultCollation is the collation_connection SET NAMES selects without COLLATE. Only
+// the binary character set compares case-sensitively by default
+func defaultCollation(charset string) string {
+	if strings.EqualFold(charset, "binary") {
+		return "binary"
+	}
+	return strings.ToLower(charset) + "_general_ci"
+}
+
+// cutAssignment splits "target = value" (or :=) at the first = outside quotes
+func cutAssignment(item string) (string, string, bool) {
+	parts := splitTopLevel(item, '=')
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	target := strings.TrimSuffix(strings.TrimSpace(parts[0]), ":")
+	value := strings.TrimSpace(item[len(parts[0])+1:])
+	if target == "" || value == "" {
+		return "", "", false
+	}
+	return target, value, true
+}
+
+// splitTopLevel splits s at sep outside quotes and parentheses
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquoteSetValue strips the quotes around a string literal, other values are kept as written
+func unquoteSetValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return value
+	}
+	quote := value[0]
+	if (quote != '\'' && quote != '"') || value[len(value)-1] != quote {
+		return value
+	}
+	inner := value[1 : len(value)-1]
+	inner = strings.ReplaceAll(inner, string([]byte{quote, quote}), string(quote))
+	return strings.ReplaceAll(inner, `\`+string(quote), string(quote))
+}
+
+// TransactionCharacteristics are the isolation level and access mode a transaction
+// is started with, empty when not given. Both are spelled as in PostgreSQL, which
+// takes the same levels (READ UNCOMMITTED runs as READ COMMITTED)
+type TransactionCharacteristics struct {
+	Isolation  string // READ UNCOMMITTED, READ COMMITTED, REPEATABLE READ or SERIALIZABLE
+	AccessMode string // READ ONLY or READ WRITE
+}
+
+// Modes returns the characteristics as PostgreSQL transaction modes
+//
+//	{REPEATABLE READ, READ ONLY} -> "ISOLATION LEVEL REPEATABLE READ, READ ONLY"
+func (c TransactionCharacteristics) Modes() string {
+	var modes []string
+	if c.Isolation != "" {
+		modes = append(modes, "ISOLATION LEVEL "+c.Isolation)
+	}
+	if c.AccessMode != "" {
+		modes = append(modes, c.AccessMode)
+	}
+	return strings.Join(modes, ", ")
+}
+
+// SetTransaction is a SET [GLOBAL | SESSION] TRANSACTION statement. Without a scope
+// keyword it only applies to the next transaction
+type SetTransaction struct {
+	TransactionCharacteristics
+	Scope SetScope
+	Next  bool
+}
+
+var setTransactionRe = regexp.MustCompile(`(?is)^SET\s+(?:(GLOBAL|SESSION|LOCAL)\s+)?TRANSACTION\s+(.+)$`)
+
+var isolationLevels = map[string]bool{
+	"READ UNCOMMITTED": true,
+	"READ COMMITTED":   true,
+	"REPEATABLE READ":  true,
+	"SERIALIZABLE":     true,
+}
+
+// ParseSetTransaction parses SET TRANSACTION, it reports false for other SET statements
+//
+//	SET SESSION TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY
+//	SET TRANSACTION READ WRITE
+func ParseSetTransaction(sql string) (*SetTransaction, bool, error) {
+	m := setTransactionRe.FindStringSubmatch(strings.TrimSpace(sql))
+	if m == nil {
+		return nil, false, nil
+	}
+	stmt := &SetTransaction{Scope: ScopeSession, Next: m[1] == ""}
+	if m[1] != "" {
+		stmt.Scope = setScopeKeywords[strings.ToLower(m[1])]
+	}
+	for _, item := range strings.Split(m[2], ",") {
+		words := strings.ToUpper(strings.Join(strings.Fields(item), " "))
+		if words == "READ ONLY" || words == "READ WRITE" {
+			stmt.AccessMode = words
+		} else if level, ok := strings.CutPrefix(words, "ISOLATION LEVEL "); ok && isolationLevels[level] {
+			stmt.Isolation = level
+		} else {
+			return nil, true, fmt.Errorf("invalid SET TRANSACTION syntax: %s", sql)
+		}
+	}
+	return stmt, true, nil
+}
+
+// StartTransactionAccessMode returns the access mode START TRANSACTION asks for,
+// empty when it names none
+//
+//	START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY -> READ ONLY
+func StartTransactionAccessMode(sql string) string {
+	upper := strings.ToUpper(strings.Join(strings.Fields(sql), " "))
+	rest, ok := strings.CutPrefix(upper, "START TRANSACTION ")
+	if !ok {
+		return ""
+	}
+	mode := ""
+	for _, item := range strings.Split(rest, ",") {
+		switch item = strings.TrimSpace(item); item {
+		case "READ ONLY", "READ WRITE":
+			mode = item
+		}
+	}
+	return mode
+}
@@ -0,0 +1,175 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		scope  SetScope
+		name   string
+	}{
+		{"autocommit", ScopeSession, "autocommit"},
+		{"@@autocommit", ScopeSession, "autocommit"},
+		{"@@session.sql_mode", ScopeSession, "sql_mode"},
+		{"SESSION sql_mode", ScopeSession, "sql_mode"},
+		{"LOCAL sql_mode", ScopeSession, "sql_mode"},
+		{"GLOBAL max_connections", ScopeGlobal, "max_connections"},
+		{"@@GLOBAL.max_connections", ScopeGlobal, "max_connections"},
+		{"PERSIST sql_mode", ScopePersist, "sql_mode"},
+		{"@@persist.sql_mode", ScopePersist, "sql_mode"},
+		{"persist_only `innodb_buffer_pool_size`", ScopePersistOnly, "innodb_buffer_pool_size"},
+		{"@total", ScopeUser, "@total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			scope, name := parseSetTarget(tt.target)
+			assert.Equal(t, tt.scope, scope)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
+
+func TestParseSetStatement_Scopes(t *testing.T) {
+	t.Run("GLOBAL and PERSIST keep their scope", func(t *testing.T) {
+		tests := []struct {
+			sql   string
+			scope SetScope
+		}{
+			{"SET GLOBAL max_connections = 500", ScopeGlobal},
+			{"SET @@global.sql_mode = 'STRICT_TRANS_TABLES'", ScopeGlobal},
+			{"SET PERSIST sql_mode = ''", ScopePersist},
+			{"SET PERSIST_ONLY innodb_buffer_pool_size = 1073741824", ScopePersistOnly},
+		}
+		for _, tt := range tests {
+			assignments, err := ParseSetStatement(tt.sql)
+			require.NoError(t, err, tt.sql)
+			require.Len(t, assignments, 1, tt.sql)
+			assert.Equal(t, tt.scope, assignments[0].Scope, tt.sql)
+		}
+	})
+
+	t.Run("Read-only variables are rejected", func(t *testing.T) {
+		for _, sql := range []string{
+			"SET GLOBAL version = '9.0'",
+			"SET PERSIST port = 3307",
+			"SET @@session.hostname = 'x'",
+			"SET autocommit = 0, GLOBAL version = '9.0'",
+		} {
+			_, err := ParseSetStatement(sql)
+			var readOnly *ReadOnlyVariableError
+			require.ErrorAs(t, err, &readOnly, sql)
+			assert.Contains(t, err.Error(), "is a read only variable")
+		}
+	})
+}
+
+func TestParseSetStatement_MultipleAssignments(t *testing.T) {
+	assignments, err := ParseSetStatement(
+		"SET autocommit=0, time_zone='+00:00', @x=1, @@session.sql_mode = 'ANSI,NO_ZERO_DATE', NAMES utf8mb4 COLLATE utf8mb4_unicode_ci, GLOBAL max_connections = 10, @y := (SELECT 1, 2)")
+	require.NoError(t, err)
+
+	assert.Equal(t, []SetAssignment{
+		{Scope: ScopeSession, Name: "autocommit", Value: "0"},
+		{Scope: ScopeSession, Name: "time_zone", Value: "+00:00"},
+		{Scope: ScopeUser, Name: "@x", Value: "1"},
+		{Scope: ScopeSession, Name: "sql_mode", Value: "ANSI,NO_ZERO_DATE"},
+		{Scope: ScopeSession, Name: "character_set_client", Value: "utf8mb4"},
+		{Scope: ScopeSession, Name: "character_set_connection", Value: "utf8mb4"},
+		{Scope: ScopeSession, Name: "character_set_results", Value: "utf8mb4"},
+		{Scope: ScopeSession, Name: "collation_connection", Value: "utf8mb4_unicode_ci"},
+		{Scope: ScopeGlobal, Name: "max_connections", Value: "10"},
+		{Scope: ScopeUser, Name: "@y", Value: "(SELECT 1, 2)"},
+	}, assignments)
+}
+
+func TestParseSetStatement_NamesCollation(t *testing.T) {
+	tests := []struct {
+		sql       string
+		collation string
+	}{
+		{"SET NAMES utf8mb4", "utf8mb4_general_ci"},
+		{"SET NAMES 'binary'", "binary"},
+		{"SET NAMES utf8mb4 COLLATE utf8mb4_bin", "utf8mb4_bin"},
+	}
+	for _, tt := range tests {
+		assignments, err := ParseSetStatement(tt.sql)
+		require.NoError(t, err, tt.sql)
+		last := assignments[len(assignments)-1]
+		assert.Equal(t, SetAssignment{Scope: ScopeSession, Name: "collation_connection", Value: tt.collation}, last, tt.sql)
+	}
+}
+
+func TestParseSetStatement_Values(t *testing.T) {
+	tests := []struct {
+		sql   string
+		value string
+	}{
+		{"SET @s = 'it''s'", "it's"},
+		{`SET @s = 'a\'b'`, "a'b"},
+		{`SET @s = "x=1, y=2"`, "x=1, y=2"},
+		{"SET @s = ON", "ON"},
+	}
+	for _, tt := range tests {
+		assignments, err := ParseSetStatement(tt.sql)
+		require.NoError(t, err, tt.sql)
+		require.Len(t, assignments, 1, tt.sql)
+		assert.Equal(t, tt.value, assignments[0].Value, tt.sql)
+	}
+
+	for _, sql := range []string{"SET", "SET x", "SET x =", "SET a = 1,"} {
+		_, err := ParseSetStatement(sql)
+		assert.Error(t, err, sql)
+	}
+}
+
+func TestParseSetTransaction(t *testing.T) {
+	tests := []struct {
+		sql        string
+		scope      SetScope
+		next       bool
+		isolation  string
+		accessMode string
+	}{
+		{"SET SESSION TRANSACTION READ ONLY", ScopeSession, false, "", "READ ONLY"},
+		{"set local transaction read  write", ScopeSession, false, "", "READ WRITE"},
+		{"SET TRANSACTION READ ONLY", ScopeSession, true, "", "READ ONLY"},
+		{"SET GLOBAL TRANSACTION ISOLATION LEVEL SERIALIZABLE", ScopeGlobal, false, "SERIALIZABLE", ""},
+		{"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY", ScopeSession, false, "REPEATABLE READ", "READ ONLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			stmt, ok, err := ParseSetTransaction(tt.sql)
+			require.True(t, ok)
+			require.NoError(t, err)
+			assert.Equal(t, tt.scope, stmt.Scope)
+			assert.Equal(t, tt.next, stmt.Next)
+			assert.Equal(t, tt.isolation, stmt.Isolation)
+			assert.Equal(t, tt.accessMode, stmt.AccessMode)
+		})
+	}
+
+	stmt, _, _ := ParseSetTransaction("SET SESSION TRANSACTION ISOLATION LEVEL READ COMMITTED, READ ONLY")
+	assert.Equal(t, "ISOLATION LEVEL READ COMMITTED, READ ONLY", stmt.Modes())
+
+	_, ok, err := ParseSetTransaction("SET SESSION TRANSACTION READ SOMETIMES")
+	assert.True(t, ok)
+	assert.Error(t, err)
+
+	// A variable, not the statement
+	_, ok, _ = ParseSetTransaction("SET transaction_read_only = 1")
+	assert.False(t, ok)
+}
+
+func TestStartTransactionAccessMode(t *testing.T) {
+	assert.Equal(t, "READ ONLY", StartTransactionAccessMode("START TRANSACTION READ ONLY"))
+	assert.Equal(t, "READ WRITE", StartTransactionAccessMode("start transaction with consistent snapshot,  read write"))
+	assert.Empty(t, StartTransactionAccessMode("START TRANSACTION"))
+	assert.Empty(t, StartTransactionAccessMode("BEGIN WORK"))
+}
@@ -3,17 +3,41 @@ package mapper
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	_ "github.com/pingcap/tidb/pkg/parser/test_driver"
 )
 
-type ShowEmulator struct{}
+type ShowEmulator struct {
+	// declaredTypes returns the MySQL types of the columns of a table that PostgreSQL
+	// stores as another type, keyed by lower-case column name
+	declaredTypes func(table string) map[string]string
+}
 
 func NewShowEmulator() *ShowEmulator {
 	return &ShowEmulator{}
 }
 
+// SetDeclaredTypes sets where SHOW COLUMNS and DESCRIBE look up the declared type of
+// columns, such as the enum('a','b') of an ENUM stored as VARCHAR
+func (se *ShowEmulator) SetDeclaredTypes(declaredTypes func(table string) map[string]string) {
+	se.declaredTypes = declaredTypes
+}
+
+// declared returns the declared column types of a table, nil when unknown
+func (se *ShowEmulator) declared(table string) map[string]string {
+	if se.declaredTypes == nil {
+		return nil
+	}
+	return se.declaredTypes(table)
+}
+
 func (se *ShowEmulator) HandleShowCommand(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
 	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
 
@@ -21,7 +45,7 @@ func (se *ShowEmulator) HandleShowCommand(ctx context.Context, conn *pgx.Conn, s
 		return se.showDatabases(ctx, conn)
 	}
 
-	if strings.HasPrefix(upperSQL, "SHOW TABLES") {
+	if showTablesRe.MatchString(upperSQL) {
 		return se.showTables(ctx, conn, sql)
 	}
 
@@ -37,20 +61,38 @@ func (se *ShowEmulator) HandleShowCommand(ctx context.Context, conn *pgx.Conn, s
 		return se.showCreateTable(ctx, conn, sql)
 	}
 
-	if strings.HasPrefix(upperSQL, "SHOW INDEX") {
-		return se.showIndex(ctx, conn, sql)
+	if m := showCreateDatabaseRe.FindStringSubmatch(sql); m != nil {
+		return se.showCreateDatabase(ctx, conn, strings.Trim(m[2], "`\""), m[1] != "")
 	}
 
-	if strings.HasPrefix(upperSQL, "SHOW STATUS") {
-		return se.showStatus(ctx, conn)
+	if showIndexRe.MatchString(upperSQL) {
+		return se.showIndex(ctx, conn, sql)
 	}
 
-	if strings.HasPrefix(upperSQL, "SHOW VARIABLES") {
+	if m := showScopedRe.FindStringSubmatch(upperSQL); m != nil {
+		if m[2] == "STATUS" {
+			return se.showStatus(ctx, conn, sql)
+		}
 		return se.showVariables(ctx, conn, sql)
 	}
 
-	if strings.HasPrefix(upperSQL, "SHOW WARNINGS") {
-		return se.showWarnings(ctx, conn)
+	if m := showCharsetRe.FindStringSubmatch(sql); m != nil {
+		if strings.EqualFold(m[1], "COLLATION") {
+			return conn.Query(ctx, showCollationQuery(showLikePattern(m[2])))
+		}
+		return conn.Query(ctx, showCharsetQuery(showLikePattern(m[2])))
+	}
+
+	if showMasterStatusRe.MatchString(sql) {
+		return conn.Query(ctx, masterStatusQuery())
+	}
+
+	if m := showSlaveStatusRe.FindStringSubmatch(sql); m != nil {
+		return conn.Query(ctx, slaveStatusQuery(strings.EqualFold(m[1], "REPLICA")))
+	}
+
+	if showEngineStatusRe.MatchString(sql) {
+		return conn.Query(ctx, innodbStatusQuery())
 	}
 
 	return nil, fmt.Errorf("unsupported SHOW command: %s", sql)
@@ -67,60 +109,165 @@ func (se *ShowEmulator) showDatabases(ctx context.Context, conn *pgx.Conn) (pgx.
 }
 
 func (se *ShowEmulator) showTables(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
-	var schemaName string
+	query, err := showTablesQuery(sql)
+	if err != nil {
+		return nil, err
+	}
+	return conn.Query(ctx, query)
+}
 
-	upperSQL := strings.ToUpper(sql)
-	if strings.Contains(upperSQL, "FROM") || strings.Contains(upperSQL, "IN") {
-		parts := strings.Fields(sql)
-		for i, part := range parts {
-			if (strings.ToUpper(part) == "FROM" || strings.ToUpper(part) == "IN") && i+1 < len(parts) {
-				schemaName = strings.Trim(parts[i+1], "`\"';")
-				break
-			}
-		}
+var (
+	showTablesRe       = regexp.MustCompile(`(?i)^SHOW\s+(FULL\s+)?TABLES\b`)
+	showTablesFilterRe = regexp.MustCompile(`(?i)\b(?:LIKE|WHERE)\b`)
+	showTablesLikeRe   = regexp.MustCompile(`(?is)\bLIKE\s+'((?:[^'\\]|\\.|'')*)'`)
+	showTablesWhereRe  = regexp.MustCompile(`(?is)\bWHERE\s+(.+?)\s*;?\s*$`)
+	showTablesSchemaRe = regexp.MustCompile("(?i)\\b(?:FROM|IN)\\s+([`\"]?[\\w$]+[`\"]?)")
+	tablesInColumnRe   = regexp.MustCompile(`(?i)^tables(?:_in_[\w$]+)?$`)
+)
+
+// showTablesQuery builds the query for SHOW [FULL] TABLES [{FROM | IN} db] [LIKE 'pattern' | WHERE expr]
+// MySQL LIKE wildcards (%, _ and \ escapes) mean the same in PostgreSQL, the pattern
+// is matched with \ as its escape character, see likeLiteral. Views are listed as in
+// MySQL, FULL adds the Table_type column telling them from tables (BASE TABLE or VIEW)
+func showTablesQuery(sql string) (string, error) {
+	full := false
+	if m := showTablesRe.FindStringSubmatch(strings.TrimSpace(sql)); m != nil {
+		full = m[1] != ""
 	}
 
-	var query string
-	if schemaName != "" {
+	// Only look for FROM/IN before the filter, WHERE expressions may contain IN and LIKE
+	head, filter := sql, ""
+	if loc := showTablesFilterRe.FindStringIndex(sql); loc != nil {
+		head, filter = sql[:loc[0]], sql[loc[0]:]
+	}
+
+	schemaFilter := "current_schema()"
+	column := "Tables"
+	if m := showTablesSchemaRe.FindStringSubmatch(head); m != nil {
+		schemaName := strings.Trim(m[1], "`\"")
+		schemaFilter = quoteLiteral(schemaName)
+		column = "Tables_in_" + schemaName
+	}
+
+	columns := fmt.Sprintf(`table_name AS "%s"`, column)
+	if full {
+		columns += `, table_type AS "Table_type"`
+	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM information_schema.tables
+		WHERE table_schema = %s AND table_type IN ('BASE TABLE', 'VIEW')`, columns, schemaFilter)
+
+	isLike := strings.HasPrefix(strings.ToUpper(filter), "LIKE")
+	if m := showTablesLikeRe.FindStringSubmatch(filter); isLike && m != nil {
+		query += fmt.Sprintf(`
+		  AND table_name LIKE %s`, likeLiteral(unescapeString(m[1], '\'')))
+	} else if m := showTablesWhereRe.FindStringSubmatch(filter); !isLike && m != nil {
+		where, err := showTablesWhere(m[1], column, full)
+		if err != nil {
+			return "", err
+		}
 		query = fmt.Sprintf(`
-			SELECT table_name AS "Tables_in_%s"
-			FROM information_schema.tables
-			WHERE table_schema = '%s' AND table_type = 'BASE TABLE'
-			ORDER BY table_name
-		`, schemaName, schemaName)
-	} else {
-		query = `
-			SELECT table_name AS "Tables"
-			FROM information_schema.tables
-			WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
-			ORDER BY table_name
-		`
+		SELECT * FROM (%s
+		) AS tables WHERE %s`, query, where)
 	}
 
-	return conn.Query(ctx, query)
+	return query + `
+		ORDER BY 1
+	`, nil
+}
+
+// showTablesWhere parses the expression of SHOW TABLES WHERE expr and restores it for
+// PostgreSQL. The expression refers to the result columns (Tables_in_db, Table_type),
+// which need quoting in PostgreSQL to keep their mixed case. Anything that is not a
+// single expression is rejected rather than pasted into the query
+func showTablesWhere(expr, column string, full bool) (string, error) {
+	stmt, err := parser.New().ParseOneStmt("SELECT 1 FROM t WHERE ("+expr+")", "", "")
+	if err != nil {
+		return "", fmt.Errorf("invalid SHOW TABLES WHERE expression: %w", err)
+	}
+	where := stmt.(*ast.SelectStmt).Where
+	where.Accept(&showColumnRenamer{column: column, full: full})
+
+	var b strings.Builder
+	flags := format.RestoreStringSingleQuotes | format.RestoreKeyWordUppercase |
+		format.RestoreNameDoubleQuotes | format.RestoreStringWithoutCharset
+	if err := where.Restore(format.NewRestoreCtx(flags, &b)); err != nil {
+		return "", fmt.Errorf("invalid SHOW TABLES WHERE expression: %w", err)
+	}
+	return b.String(), nil
+}
+
+// showColumnRenamer renames references to the SHOW TABLES result columns
+type showColumnRenamer struct {
+	column string
+	full   bool
+}
+
+func (r *showColumnRenamer) Enter(n ast.Node) (ast.Node, bool) {
+	col, ok := n.(*ast.ColumnNameExpr)
+	if !ok || col.Name.Table.L != "" {
+		return n, false
+	}
+	switch {
+	case tablesInColumnRe.MatchString(col.Name.Name.O):
+		col.Name.Name = ast.NewCIStr(r.column)
+	case r.full && col.Name.Name.L == "table_type":
+		col.Name.Name = ast.NewCIStr("Table_type")
+	}
+	return n, false
+}
+
+func (r *showColumnRenamer) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
 }
 
 func (se *ShowEmulator) showColumns(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
-	tableName := se.extractTableName(sql)
+	schemaName, tableName := se.extractTableRef(sql)
 	if tableName == "" {
 		return nil, fmt.Errorf("table name not found in: %s", sql)
 	}
 
-	query := fmt.Sprintf(`
+	return conn.Query(ctx, showColumnsQuery(schemaName, tableName, se.declared(tableName)))
+}
+
+func showColumnsQuery(schemaName, tableName string, declared map[string]string) string {
+	return fmt.Sprintf(`
 		SELECT
 			column_name AS "Field",
-			data_type AS "Type",
+			%s AS "Type",
 			is_nullable AS "Null",
 			column_default AS "Default",
 			'' AS "Key",
 			'' AS "Extra"
 		FROM information_schema.columns
-		WHERE table_schema = current_schema()
-		  AND table_name = '%s'
+		WHERE table_schema = %s
+		  AND table_name = %s
 		ORDER BY ordinal_position
-	`, tableName)
+	`, columnTypeExpr(declared), schemaPredicate(schemaName), quoteLiteral(tableName))
+}
 
-	return conn.Query(ctx, query)
+// columnTypeExpr returns the Type column of SHOW COLUMNS and DESCRIBE. Columns with a
+// declared type report it, ENUM and SET are VARCHAR in PostgreSQL
+//
+//	CASE lower(column_name) WHEN 'status' THEN 'enum(''new'',''paid'')' ELSE data_type END
+func columnTypeExpr(declared map[string]string) string {
+	if len(declared) == 0 {
+		return "data_type"
+	}
+	columns := make([]string, 0, len(declared))
+	for column := range declared {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var b strings.Builder
+	b.WriteString("CASE lower(column_name)")
+	for _, column := range columns {
+		fmt.Fprintf(&b, " WHEN %s THEN %s", quoteLiteral(column), quoteLiteral(declared[column]))
+	}
+	b.WriteString(" ELSE data_type END")
+	return b.String()
 }
 
 func (se *ShowEmulator) describe(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
@@ -129,18 +276,23 @@ func (se *ShowEmulator) describe(ctx context.Context, conn *pgx.Conn, sql string
 		return nil, fmt.Errorf("invalid DESCRIBE command: %s", sql)
 	}
 
-	tableName := strings.Trim(parts[1], "`\"';")
+	schemaName, tableName := splitQualifiedName(strings.TrimRight(parts[1], ";"))
 
-	query := fmt.Sprintf(`
+	return conn.Query(ctx, describeQuery(schemaName, tableName, se.declared(tableName)))
+}
+
+func describeQuery(schemaName, tableName string, declared map[string]string) string {
+	schema := schemaPredicate(schemaName)
+	return fmt.Sprintf(`
 		SELECT
 			column_name AS "Field",
-			data_type AS "Type",
+			%s AS "Type",
 			is_nullable AS "Null",
 			column_default AS "Default",
 			CASE
 				WHEN EXISTS (
 					SELECT 1 FROM information_schema.key_column_usage kcu
-					WHERE kcu.table_schema = current_schema()
+					WHERE kcu.table_schema = %s
 					  AND kcu.table_name = c.table_name
 					  AND kcu.column_name = c.column_name
 				) THEN 'PRI'
@@ -151,12 +303,10 @@ func (se *ShowEmulator) describe(ctx context.Context, conn *pgx.Conn, sql string
 				ELSE ''
 			END AS "Extra"
 		FROM information_schema.columns c
-		WHERE c.table_schema = current_schema()
-		  AND c.table_name = '%s'
+		WHERE c.table_schema = %s
+		  AND c.table_name = %s
 		ORDER BY c.ordinal_position
-	`, tableName)
-
-	return conn.Query(ctx, query)
+	`, columnTypeExpr(declared), schema, schema, quoteLiteral(tableName))
 }
 
 func (se *ShowEmulator) showCreateTable(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
@@ -176,68 +326,206 @@ func (se *ShowEmulator) showCreateTable(ctx context.Context, conn *pgx.Conn, sql
 	return conn.Query(ctx, query)
 }
 
+// showCreateDatabaseRe matches SHOW CREATE {DATABASE | SCHEMA} [IF NOT EXISTS] db
+var showCreateDatabaseRe = regexp.MustCompile("(?is)^\\s*SHOW\\s+CREATE\\s+(?:DATABASE|SCHEMA)\\s+(IF\\s+NOT\\s+EXISTS\\s+)?(`[^`]+`|\"[^\"]+\"|[^\\s;]+)")
+
+// UnknownDatabaseError reports a database (PostgreSQL schema) that does not exist
+type UnknownDatabaseError struct {
+	Name string
+}
+
+func (e *UnknownDatabaseError) Error() string {
+	return fmt.Sprintf("Unknown database '%s'", e.Name)
+}
+
+// showCreateDatabase emulates SHOW CREATE DATABASE. Databases are PostgreSQL schemas,
+// an unknown schema is an unknown database as in MySQL
+func (se *ShowEmulator) showCreateDatabase(ctx context.Context, conn *pgx.Conn, name string, ifNotExists bool) (pgx.Rows, error) {
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = %s)", quoteLiteral(name))
+	if err := conn.QueryRow(ctx, query).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &UnknownDatabaseError{Name: name}
+	}
+	return conn.Query(ctx, showCreateDatabaseQuery(name, ifNotExists))
+}
+
+// showCreateDatabaseQuery returns the schema as a MySQL database with the proxy's
+// character set, in the form mysqldump writes it
+func showCreateDatabaseQuery(name string, ifNotExists bool) string {
+	create := "CREATE DATABASE "
+	if ifNotExists {
+		create += "/*!32312 IF NOT EXISTS*/ "
+	}
+	create += "`" + strings.ReplaceAll(name, "`", "``") + "` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci */"
+
+	return fmt.Sprintf(`
+		SELECT
+			nspname AS "Database",
+			%s AS "Create Database"
+		FROM pg_namespace
+		WHERE nspname = %s
+	`, quoteLiteral(create), quoteLiteral(name))
+}
+
 func (se *ShowEmulator) showIndex(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
-	tableName := se.extractTableName(sql)
+	schemaName, tableName := se.extractTableRef(sql)
 	if tableName == "" {
 		return nil, fmt.Errorf("table name not found in: %s", sql)
 	}
 
-	query := fmt.Sprintf(`
+	return conn.Query(ctx, showIndexQuery(schemaName, tableName))
+}
+
+// showIndexQuery lists one row per index column, in index then Seq_in_index order
+// The primary key is named PRIMARY and listed first. Cardinality is estimated from the
+// planner statistics: pg_stats.n_distinct when the table was analyzed (negative values
+// are a fraction of the row count), pg_class.reltuples otherwise. INCLUDE columns are
+// not part of the key and are left out
+func showIndexQuery(schemaName, tableName string) string {
+	return fmt.Sprintf(`
 		SELECT
-			'%s' AS "Table",
-			0 AS "Non_unique",
-			i.indexname AS "Key_name",
-			1 AS "Seq_in_index",
-			'' AS "Column_name",
+			t.relname AS "Table",
+			CASE WHEN ix.indisunique THEN 0 ELSE 1 END AS "Non_unique",
+			CASE WHEN ix.indisprimary THEN 'PRIMARY' ELSE ic.relname END AS "Key_name",
+			k.seq AS "Seq_in_index",
+			a.attname AS "Column_name",
 			'A' AS "Collation",
-			0 AS "Cardinality",
+			(CASE
+				WHEN s.n_distinct IS NULL THEN GREATEST(t.reltuples, 0)
+				WHEN s.n_distinct < 0 THEN -s.n_distinct * GREATEST(t.reltuples, 0)
+				ELSE s.n_distinct
+			END)::bigint AS "Cardinality",
 			NULL AS "Sub_part",
 			NULL AS "Packed",
-			'YES' AS "Null",
-			'BTREE' AS "Index_type",
+			CASE WHEN a.attnotnull THEN '' ELSE 'YES' END AS "Null",
+			upper(am.amname) AS "Index_type",
 			'' AS "Comment"
-		FROM pg_indexes i
-		WHERE i.schemaname = current_schema()
-		  AND i.tablename = '%s'
-	`, tableName, tableName)
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_class ic ON ic.oid = ix.indexrelid
+		JOIN pg_am am ON am.oid = ic.relam
+		CROSS JOIN LATERAL unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, seq)
+		LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		LEFT JOIN pg_stats s ON s.schemaname = n.nspname AND s.tablename = t.relname AND s.attname = a.attname
+		WHERE n.nspname = %s
+		  AND t.relname = %s
+		  AND k.seq <= ix.indnkeyatts
+		ORDER BY ix.indisprimary DESC, ix.indexrelid, k.seq
+	`, schemaPredicate(schemaName), quoteLiteral(tableName))
+}
 
-	return conn.Query(ctx, query)
+// showIndexRe matches SHOW [EXTENDED] {INDEX | INDEXES | KEYS}, which are synonyms
+var showIndexRe = regexp.MustCompile(`(?i)^SHOW\s+(?:EXTENDED\s+)?(?:INDEX|INDEXES|KEYS)\s`)
+
+var (
+	showScopedRe = regexp.MustCompile(`(?is)^SHOW\s+(?:(GLOBAL|SESSION|LOCAL)\s+)?(VARIABLES|STATUS)\b`)
+	showLikeRe   = regexp.MustCompile(`(?is)\bLIKE\s+(?:'((?:[^'\\]|\\.|'')*)'|"((?:[^"\\]|\\.|"")*)")`)
+)
+
+// parseShowScope splits SHOW [GLOBAL | SESSION | LOCAL] {VARIABLES | STATUS} [LIKE 'pattern']
+// into its scope and LIKE pattern. Without a scope keyword MySQL shows session values
+func parseShowScope(sql string) (SetScope, string) {
+	sql = strings.TrimSpace(sql)
+	m := showScopedRe.FindStringSubmatch(sql)
+	if m == nil {
+		return ScopeSession, ""
+	}
+
+	scope := ScopeSession
+	if strings.EqualFold(m[1], "GLOBAL") {
+		scope = ScopeGlobal
+	}
+
+	return scope, showLikePattern(sql[len(m[0]):])
 }
 
-func (se *ShowEmulator) showStatus(ctx context.Context, conn *pgx.Conn) (pgx.Rows, error) {
-	query := `
-		SELECT 'Uptime' AS "Variable_name", '0' AS "Value"
+// showLikePattern returns the pattern of a LIKE 'pattern' clause in the rest of a
+// SHOW statement, or "" without one
+func showLikePattern(rest string) string {
+	like := showLikeRe.FindStringSubmatch(rest)
+	switch {
+	case like == nil:
+		return ""
+	case strings.HasSuffix(like[0], "'"):
+		return unescapeString(like[1], '\'')
+	default:
+		return unescapeString(like[2], '"')
+	}
+}
+
+// showLikeFilter wraps a SHOW query in a filter on its name column. MySQL
+// matches variable, character set and collation names case-insensitively
+func showLikeFilter(query, column, pattern string) string {
+	if pattern == "" {
+		return query
+	}
+	return fmt.Sprintf(`
+		SELECT * FROM (%s
+		) AS vars WHERE "%s" ILIKE %s`, query, column, likeLiteral(pattern))
+}
+
+func (se *ShowEmulator) showStatus(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
+	return conn.Query(ctx, showStatusQuery(sql))
+}
+
+// showStatusQuery builds the query for SHOW [GLOBAL | SESSION] STATUS [LIKE 'pattern']
+// Counters come from PostgreSQL's statistics views. PostgreSQL keeps no per-session
+// statement count, so the session scope reports Questions as 0
+func showStatusQuery(sql string) string {
+	scope, pattern := parseShowScope(sql)
+
+	questions := "'0'"
+	if scope == ScopeGlobal {
+		questions = "(SELECT COALESCE(sum(xact_commit + xact_rollback), 0) FROM pg_stat_database)::text"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT 'Uptime' AS "Variable_name",
+			floor(extract(epoch FROM now() - pg_postmaster_start_time()))::bigint::text AS "Value"
 		UNION ALL
-		SELECT 'Threads_connected', '1'
+		SELECT 'Threads_connected',
+			(SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend')::text
 		UNION ALL
-		SELECT 'Questions', '0'
+		SELECT 'Threads_running',
+			(SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend' AND state = 'active')::text
 		UNION ALL
-		SELECT 'Slow_queries', '0'
+		SELECT 'Questions', %s
+		UNION ALL
+		SELECT 'Slow_queries', '0'`, questions)
+
+	return showLikeFilter(query, "Variable_name", pattern) + `
+		ORDER BY 1
 	`
-	return conn.Query(ctx, query)
 }
 
 func (se *ShowEmulator) showVariables(ctx context.Context, conn *pgx.Conn, sql string) (pgx.Rows, error) {
-	upperSQL := strings.ToUpper(sql)
-
-	if strings.Contains(upperSQL, "LIKE") {
-		parts := strings.Split(sql, "LIKE")
-		if len(parts) > 1 {
-			pattern := strings.TrimSpace(parts[1])
-			pattern = strings.Trim(pattern, "'\"")
-			pattern = strings.ReplaceAll(pattern, "%", "%%")
-
-			query := fmt.Sprintf(`
-				SELECT name AS "Variable_name", setting AS "Value"
-				FROM pg_settings
-				WHERE name LIKE '%s'
-				ORDER BY name
-			`, pattern)
-			return conn.Query(ctx, query)
+	return conn.Query(ctx, showVariablesQuery(sql))
+}
+
+// showVariablesQuery builds the query for SHOW [GLOBAL | SESSION] VARIABLES [LIKE 'pattern']
+// A pattern is matched against pg_settings, where the session scope shows the current
+// setting and the global scope the value new sessions start with
+func showVariablesQuery(sql string) string {
+	scope, pattern := parseShowScope(sql)
+
+	if pattern != "" {
+		value := "setting"
+		if scope == ScopeGlobal {
+			value = "reset_val"
 		}
+		return fmt.Sprintf(`
+		SELECT name AS "Variable_name", %s AS "Value"
+		FROM pg_settings
+		WHERE name ILIKE %s
+		ORDER BY name
+	`, value, likeLiteral(pattern))
 	}
 
-	query := `
+	return `
 		SELECT
 			'version' AS "Variable_name",
 			version() AS "Value"
@@ -260,73 +548,136 @@ func (se *ShowEmulator) showVariables(ctx context.Context, conn *pgx.Conn, sql s
 		UNION ALL
 		SELECT 'sql_mode', 'TRADITIONAL'
 	`
-	return conn.Query(ctx, query)
 }
 
-func (se *ShowEmulator) showWarnings(ctx context.Context, conn *pgx.Conn) (pgx.Rows, error) {
-	query := `
-		SELECT
-			'Warning' AS "Level",
-			0 AS "Code",
-			'' AS "Message"
-		LIMIT 0
-	`
-	return conn.Query(ctx, query)
+func (se *ShowEmulator) extractTableName(sql string) string {
+	_, tableName := se.extractTableRef(sql)
+	return tableName
 }
 
-func (se *ShowEmulator) extractTableName(sql string) string {
-	upperSQL := strings.ToUpper(sql)
-
-	keywords := []string{"FROM", "IN", "COLUMNS", "FIELDS", "INDEX"}
-	for _, keyword := range keywords {
-		if idx := strings.Index(upperSQL, keyword); idx != -1 {
-			remaining := sql[idx+len(keyword):]
-			parts := strings.Fields(remaining)
-			if len(parts) > 0 {
-				tableName := strings.Trim(parts[0], "`\"';")
-				return tableName
+// extractTableRef returns the optional schema and the table of a SHOW COLUMNS/INDEX
+// statement. Both MySQL forms are accepted:
+//
+//	SHOW COLUMNS FROM db.tbl / `db`.`tbl`
+//	SHOW COLUMNS FROM tbl FROM db / IN db
+func (se *ShowEmulator) extractTableRef(sql string) (string, string) {
+	parts := strings.Fields(strings.TrimRight(strings.TrimSpace(sql), ";"))
+
+	// The table follows the first FROM or IN, a second one names the database
+	tableIdx := -1
+	for i, part := range parts {
+		if (strings.EqualFold(part, "FROM") || strings.EqualFold(part, "IN")) && i+1 < len(parts) {
+			tableIdx = i + 1
+			break
+		}
+	}
+	for _, keyword := range []string{"COLUMNS", "FIELDS", "INDEX", "INDEXES", "KEYS"} {
+		if tableIdx != -1 {
+			break
+		}
+		for i, part := range parts {
+			if strings.EqualFold(part, keyword) && i+1 < len(parts) {
+				tableIdx = i + 1
+				break
 			}
 		}
 	}
+	if tableIdx == -1 {
+		if len(parts) < 2 {
+			return "", ""
+		}
+		tableIdx = len(parts) - 1
+	}
 
-	parts := strings.Fields(sql)
-	if len(parts) >= 2 {
-		return strings.Trim(parts[len(parts)-1], "`\"';")
+	schemaName, tableName := splitQualifiedName(parts[tableIdx])
+
+	// Trailing FROM db / IN db overrides the qualifier, as in MySQL
+	if tableIdx+2 < len(parts) {
+		keyword := strings.ToUpper(parts[tableIdx+1])
+		if keyword == "FROM" || keyword == "IN" {
+			schemaName = strings.Trim(parts[tableIdx+2], "`\"'")
+		}
 	}
 
-	return ""
+	return schemaName, tableName
 }
 
-func (se *ShowEmulator) HandleSetCommand(ctx context.Context, sql string, sessionVars map[string]interface{}) error {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-
-	if !strings.HasPrefix(upperSQL, "SET ") {
-		return fmt.Errorf("not a SET command: %s", sql)
+// splitQualifiedName splits db.tbl, `db`.`tbl` or "db"."tbl" into schema and table
+// A dot inside quotes belongs to the name
+func splitQualifiedName(ref string) (string, string) {
+	var names []string
+	var current strings.Builder
+	var quote byte
+
+	for i := 0; i < len(ref); i++ {
+		c := ref[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteByte(c)
+		case c == '`' || c == '"':
+			quote = c
+		case c == '.':
+			names = append(names, current.String())
+			current.Reset()
+		case c == '\'' || c == ';':
+		default:
+			current.WriteByte(c)
+		}
 	}
+	names = append(names, current.String())
 
-	assignment := strings.TrimPrefix(sql, "SET ")
-	assignment = strings.TrimPrefix(assignment, "set ")
+	if len(names) >= 2 {
+		return names[len(names)-2], names[len(names)-1]
+	}
+	return "", names[0]
+}
 
-	parts := strings.SplitN(assignment, "=", 2)
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid SET syntax: %s", sql)
+// schemaPredicate returns the schema to filter on, the current schema when unqualified
+func schemaPredicate(schemaName string) string {
+	if schemaName == "" {
+		return "current_schema()"
 	}
+	return quoteLiteral(schemaName)
+}
 
-	varName := strings.TrimSpace(parts[0])
-	varValue := strings.TrimSpace(parts[1])
-	varValue = strings.Trim(varValue, "'\"")
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
 
-	varName = strings.TrimPrefix(varName, "@@")
-	varName = strings.TrimPrefix(varName, "SESSION.")
-	varName = strings.TrimPrefix(varName, "session.")
+// likeLiteral returns a LIKE pattern as a PostgreSQL literal with \ as the escape
+// character, as MySQL matches it
+//
+//	log\_2024% -> 'log\_2024%' ESCAPE '\'
+func likeLiteral(pattern string) string {
+	return quoteLiteral(pattern) + ` ESCAPE '\'`
+}
 
-	if strings.HasPrefix(varName, "@") {
-		sessionVars[varName] = varValue
-		return nil
+// unescapeString returns the value of the body of a MySQL string literal quoted with
+// quote. Backslash escapes are resolved except \% and \_, which MySQL keeps for LIKE
+func unescapeString(body string, quote byte) string {
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\\' && i+1 < len(body):
+			i++
+			switch body[i] {
+			case '%', '_':
+				b.WriteByte('\\')
+				b.WriteByte(body[i])
+			default:
+				b.WriteByte(unescapeLoadData(body[i]))
+			}
+		case c == quote && i+1 < len(body) && body[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		default:
+			b.WriteByte(c)
+		}
 	}
-
-	sessionVars[varName] = varValue
-	return nil
+	return b.String()
 }
 
 func (se *ShowEmulator) HandleUseCommand(ctx context.Context, conn *pgx.Conn, sql string) error {
@@ -0,0 +1,370 @@
+package mapper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowEmulator_ExtractTableRef(t *testing.T) {
+	se := NewShowEmulator()
+
+	tests := []struct {
+		name   string
+		sql    string
+		schema string
+		table  string
+	}{
+		{"unqualified", "SHOW COLUMNS FROM users", "", "users"},
+		{"qualified", "SHOW COLUMNS FROM shop.users", "shop", "users"},
+		{"backtick qualified", "SHOW COLUMNS FROM `shop`.`users`;", "shop", "users"},
+		{"FROM db form", "SHOW COLUMNS FROM users FROM shop", "shop", "users"},
+		{"IN db form", "SHOW FIELDS IN users IN `shop`", "shop", "users"},
+		{"dot inside backticks", "SHOW COLUMNS FROM `odd.name`", "", "odd.name"},
+		{"index qualified", "SHOW INDEX FROM shop.users", "shop", "users"},
+		{"index IN table FROM db", "SHOW INDEX IN users FROM shop", "shop", "users"},
+		{"INDEXES spelling", "SHOW INDEXES FROM users IN shop", "shop", "users"},
+		{"KEYS alias", "SHOW KEYS FROM `users`", "", "users"},
+		{"columns IN table FROM db", "SHOW COLUMNS IN users FROM shop", "shop", "users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, table := se.extractTableRef(tt.sql)
+			assert.Equal(t, tt.schema, schema)
+			assert.Equal(t, tt.table, table)
+		})
+	}
+}
+
+func TestShowEmulator_DescribeNonCurrentSchema(t *testing.T) {
+	schema, table := splitQualifiedName("`reporting`.`orders`")
+	assert.Equal(t, "reporting", schema)
+	assert.Equal(t, "orders", table)
+
+	query := describeQuery(schema, table, nil)
+	assert.Contains(t, query, "c.table_schema = 'reporting'")
+	assert.Contains(t, query, "kcu.table_schema = 'reporting'")
+	assert.Contains(t, query, "c.table_name = 'orders'")
+	assert.NotContains(t, query, "current_schema()")
+
+	// Unqualified names still use the current schema
+	schema, table = splitQualifiedName("orders")
+	query = describeQuery(schema, table, nil)
+	assert.Contains(t, query, "c.table_schema = current_schema()")
+
+	query = showColumnsQuery("reporting", "it's", nil)
+	assert.Contains(t, query, "table_schema = 'reporting'")
+	assert.Contains(t, query, "table_name = 'it''s'")
+}
+
+func TestShowColumnsDeclaredTypes(t *testing.T) {
+	declared := map[string]string{"status": "enum('new','it''s')", "tags": "set('a','b')"}
+	expr := `CASE lower(column_name) WHEN 'status' THEN 'enum(''new'',''it''''s'')' WHEN 'tags' THEN 'set(''a'',''b'')' ELSE data_type END`
+
+	assert.Contains(t, showColumnsQuery("", "orders", declared), expr+` AS "Type"`)
+	assert.Contains(t, describeQuery("", "orders", declared), expr+` AS "Type"`)
+	assert.Contains(t, showColumnsQuery("", "orders", nil), `data_type AS "Type"`)
+}
+
+func TestShowTablesQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "All tables",
+			sql:      "SHOW TABLES",
+			contains: []string{`AS "Tables"`, "table_schema = current_schema()"},
+			excludes: []string{"LIKE"},
+		},
+		{
+			name:     "Prefix pattern",
+			sql:      "SHOW TABLES LIKE 'order%'",
+			contains: []string{`AND table_name LIKE 'order%' ESCAPE '\'`},
+		},
+		{
+			name:     "Single-char wildcard and escape",
+			sql:      `SHOW TABLES LIKE 'log\_2024_0_'`,
+			contains: []string{`AND table_name LIKE 'log\_2024_0_' ESCAPE '\'`},
+		},
+		{
+			name:     "Escaped backslash and quotes",
+			sql:      `SHOW TABLES LIKE 'a\\b\'c''d'`,
+			contains: []string{`AND table_name LIKE 'a\b''c''d' ESCAPE '\'`},
+		},
+		{
+			name:     "Backslash before the closing quote",
+			sql:      `SHOW TABLES LIKE 'x\\'; DROP TABLE users; --'`,
+			contains: []string{`AND table_name LIKE 'x\' ESCAPE '\'`},
+			excludes: []string{"DROP"},
+		},
+		{
+			name:     "Schema and pattern",
+			sql:      "SHOW TABLES FROM `shop` LIKE 'user%'",
+			contains: []string{`AS "Tables_in_shop"`, "table_schema = 'shop'", `AND table_name LIKE 'user%' ESCAPE '\'`},
+		},
+		{
+			name:     "WHERE expression",
+			sql:      "SHOW TABLES IN shop WHERE Tables_in_shop IN ('users', 'orders')",
+			contains: []string{"table_schema = 'shop'", `) AS tables WHERE ("Tables_in_shop" IN ('users','orders'))`},
+		},
+		{
+			name:     "WHERE with backticks",
+			sql:      "SHOW TABLES WHERE `Tables_in_test` LIKE 'a%'",
+			contains: []string{`WHERE ("Tables" LIKE 'a%')`},
+		},
+		{
+			name:     "WHERE string is requoted",
+			sql:      `SHOW TABLES WHERE Tables = 'it\'s'`,
+			contains: []string{`WHERE ("Tables"='it''s')`},
+		},
+		{
+			name:     "Views are listed",
+			sql:      "SHOW TABLES",
+			contains: []string{"table_type IN ('BASE TABLE', 'VIEW')"},
+			excludes: []string{"Table_type"},
+		},
+		{
+			name:     "FULL",
+			sql:      "show full tables from shop like 'user%'",
+			contains: []string{`AS "Tables_in_shop", table_type AS "Table_type"`, "table_schema = 'shop'", `AND table_name LIKE 'user%' ESCAPE '\'`},
+		},
+		{
+			name:     "FULL with WHERE on the type",
+			sql:      "SHOW FULL TABLES WHERE Table_type = 'VIEW'",
+			contains: []string{`AS "Table_type"`, `) AS tables WHERE ("Table_type"='VIEW')`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := showTablesQuery(tt.sql)
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, query, s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, query, s)
+			}
+		})
+	}
+}
+
+func TestShowTablesWhereRejectsStatements(t *testing.T) {
+	for _, sql := range []string{
+		"SHOW TABLES WHERE 1=1) AS t; DROP TABLE users; --",
+		"SHOW TABLES WHERE Tables = 'a' UNION SELECT usename FROM pg_user",
+		"SHOW TABLES WHERE Tables = 'a' LIMIT 1",
+		"SHOW FULL TABLES WHERE Table_type = 'VIEW') AS t; DROP TABLE users; --",
+	} {
+		_, err := showTablesQuery(sql)
+		assert.Error(t, err, sql)
+	}
+}
+
+func TestParseShowScope(t *testing.T) {
+	tests := []struct {
+		sql     string
+		scope   SetScope
+		pattern string
+	}{
+		{"SHOW VARIABLES", ScopeSession, ""},
+		{"SHOW GLOBAL VARIABLES", ScopeGlobal, ""},
+		{"show session variables like 'max%'", ScopeSession, "max%"},
+		{"SHOW LOCAL STATUS LIKE 'Threads%'", ScopeSession, "Threads%"},
+		{"SHOW GLOBAL STATUS LIKE 'Uptime';", ScopeGlobal, "Uptime"},
+		{`SHOW GLOBAL VARIABLES LIKE "it's%"`, ScopeGlobal, "it's%"},
+		{`SHOW VARIABLES LIKE 'sql\_mode'`, ScopeSession, `sql\_mode`},
+		{`SHOW VARIABLES LIKE 'a\'b''c\\'`, ScopeSession, `a'b'c\`},
+		{`SHOW VARIABLES LIKE "say ""hi"""`, ScopeSession, `say "hi"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			scope, pattern := parseShowScope(tt.sql)
+			assert.Equal(t, tt.scope, scope)
+			assert.Equal(t, tt.pattern, pattern)
+		})
+	}
+}
+
+func TestShowScopedQueries(t *testing.T) {
+	query := showVariablesQuery("SHOW GLOBAL VARIABLES LIKE 'max_connections'")
+	assert.Contains(t, query, `reset_val AS "Value"`)
+	assert.Contains(t, query, `WHERE name ILIKE 'max_connections' ESCAPE '\'`)
+
+	query = showVariablesQuery("SHOW SESSION VARIABLES LIKE 'work%'")
+	assert.Contains(t, query, `setting AS "Value"`)
+	assert.Contains(t, query, `WHERE name ILIKE 'work%' ESCAPE '\'`)
+
+	query = showStatusQuery("SHOW GLOBAL STATUS LIKE 'Questions'")
+	assert.Contains(t, query, "FROM pg_stat_database")
+	assert.Contains(t, query, `WHERE "Variable_name" ILIKE 'Questions' ESCAPE '\'`)
+
+	// A backslash before the closing quote cannot end the literal early
+	query = showVariablesQuery(`SHOW VARIABLES LIKE 'x\\'; DROP TABLE users; --'`)
+	assert.Contains(t, query, `WHERE name ILIKE 'x\' ESCAPE '\'`)
+	assert.NotContains(t, query, "DROP")
+	query = showStatusQuery(`SHOW STATUS LIKE "it's"`)
+	assert.Contains(t, query, `WHERE "Variable_name" ILIKE 'it''s' ESCAPE '\'`)
+
+	query = showStatusQuery("SHOW SESSION STATUS")
+	assert.Contains(t, query, "SELECT 'Questions', '0'")
+	assert.NotContains(t, query, "ILIKE")
+}
+
+func TestShowIndexQuery(t *testing.T) {
+	query := showIndexQuery("", "orders")
+	assert.Contains(t, query, "n.nspname = current_schema()")
+	assert.Contains(t, query, "t.relname = 'orders'")
+	assert.Contains(t, query, `CASE WHEN ix.indisprimary THEN 'PRIMARY' ELSE ic.relname END AS "Key_name"`)
+	assert.Contains(t, query, "ORDER BY ix.indisprimary DESC, ix.indexrelid, k.seq")
+
+	query = showIndexQuery("reporting", "it's")
+	assert.Contains(t, query, "n.nspname = 'reporting'")
+	assert.Contains(t, query, "t.relname = 'it''s'")
+}
+
+func TestShowIndexAliases(t *testing.T) {
+	for _, sql := range []string{
+		"SHOW INDEX FROM users",
+		"SHOW INDEXES FROM users",
+		"show keys in users",
+		"SHOW EXTENDED KEYS FROM users",
+	} {
+		assert.True(t, showIndexRe.MatchString(sql), sql)
+	}
+	assert.False(t, showIndexRe.MatchString("SHOW INDEX_STATISTICS"))
+	assert.False(t, showIndexRe.MatchString("SHOW TABLES"))
+}
+
+func TestShowCreateDatabase(t *testing.T) {
+	tests := []struct {
+		sql         string
+		name        string
+		ifNotExists bool
+	}{
+		{"SHOW CREATE DATABASE shop", "shop", false},
+		{"show create schema `my shop`;", "`my shop`", false},
+		{"SHOW CREATE DATABASE IF NOT EXISTS shop", "shop", true},
+	}
+	for _, tt := range tests {
+		m := showCreateDatabaseRe.FindStringSubmatch(tt.sql)
+		require.NotNil(t, m, tt.sql)
+		assert.Equal(t, tt.name, m[2], tt.sql)
+		assert.Equal(t, tt.ifNotExists, m[1] != "", tt.sql)
+	}
+
+	query := showCreateDatabaseQuery("shop", false)
+	assert.Contains(t, query, "'CREATE DATABASE `shop` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci */' AS \"Create Database\"")
+	assert.Contains(t, query, "WHERE nspname = 'shop'")
+
+	query = showCreateDatabaseQuery("it's", true)
+	assert.Contains(t, query, "'CREATE DATABASE /*!32312 IF NOT EXISTS*/ `it''s`")
+	assert.Contains(t, query, "WHERE nspname = 'it''s'")
+
+	assert.EqualError(t, &UnknownDatabaseError{Name: "nope"}, "Unknown database 'nope'")
+}
+
+func TestShowCollation(t *testing.T) {
+	m := showCharsetRe.FindStringSubmatch("SHOW COLLATION")
+	require.NotNil(t, m)
+	query := showCollationQuery(showLikePattern(m[2]))
+	for _, row := range []string{
+		"('utf8mb4_general_ci', 'utf8mb4', 45, '', 'Yes', 1, 'PAD SPACE')",
+		"('utf8mb4_unicode_ci', 'utf8mb4', 224, '', 'Yes', 8, 'PAD SPACE')",
+		"('utf8mb4_0900_ai_ci', 'utf8mb4', 255, 'Yes', 'Yes', 0, 'NO PAD')",
+		"('latin1_swedish_ci', 'latin1', 8, 'Yes', 'Yes', 1, 'PAD SPACE')",
+	} {
+		assert.Contains(t, query, row)
+	}
+	assert.NotContains(t, query, "ILIKE")
+
+	m = showCharsetRe.FindStringSubmatch("show collation like 'utf8mb4%';")
+	require.NotNil(t, m)
+	assert.Contains(t, showCollationQuery(showLikePattern(m[2])), `WHERE "Collation" ILIKE 'utf8mb4%' ESCAPE '\'`)
+
+	m = showCharsetRe.FindStringSubmatch("SHOW CHARACTER SET LIKE 'latin1'")
+	require.NotNil(t, m)
+	query = showCharsetQuery(showLikePattern(m[2]))
+	assert.Contains(t, query, "('utf8mb4', 'UTF-8 Unicode', 'utf8mb4_0900_ai_ci', 4)")
+	assert.Contains(t, query, `WHERE "Charset" ILIKE 'latin1' ESCAPE '\'`)
+
+	assert.NotNil(t, showCharsetRe.FindStringSubmatch("SHOW CHARSET"))
+	assert.Nil(t, showCharsetRe.FindStringSubmatch("SHOW COLLATION WHERE Charset = 'utf8mb4'"))
+	assert.Nil(t, showCharsetRe.FindStringSubmatch("SHOW COLLATIONS"))
+
+	// The pattern is a literal, nothing may follow it
+	assert.Nil(t, showCharsetRe.FindStringSubmatch("SHOW COLLATION LIKE 'utf8%' OR 1=1"))
+	m = showCharsetRe.FindStringSubmatch(`SHOW CHARSET LIKE 'x\\'; DROP TABLE users; --'`)
+	assert.Nil(t, m)
+	m = showCharsetRe.FindStringSubmatch(`SHOW COLLATION LIKE "it's\_%"`)
+	require.NotNil(t, m)
+	assert.Contains(t, showCollationQuery(showLikePattern(m[2])), `WHERE "Collation" ILIKE 'it''s\_%' ESCAPE '\'`)
+}
+
+// TestConnectorStartup follows a connector picking its collation from SHOW COLLATION
+// and switching to it with SET NAMES
+func TestConnectorStartup(t *testing.T) {
+	query := showCollationQuery("")
+	for _, collation := range []string{"utf8mb4_general_ci", "utf8mb4_unicode_ci", "utf8mb4_0900_ai_ci", "latin1_swedish_ci"} {
+		require.Contains(t, query, "('"+collation+"',")
+
+		charset := strings.SplitN(collation, "_", 2)[0]
+		assignments, err := ParseSetStatement("SET NAMES " + charset + " COLLATE '" + collation + "'")
+		require.NoError(t, err)
+		assert.Contains(t, assignments, SetAssignment{Scope: ScopeSession, Name: "character_set_client", Value: charset})
+		assert.Contains(t, assignments, SetAssignment{Scope: ScopeSession, Name: "collation_connection", Value: collation})
+	}
+}
+
+func TestShowEngineInnodbStatus(t *testing.T) {
+	for _, sql := range []string{"SHOW ENGINE INNODB STATUS", "show engine innodb status;", "SHOW  ENGINE\tInnoDB  STATUS"} {
+		assert.True(t, showEngineStatusRe.MatchString(sql), sql)
+	}
+	assert.False(t, showEngineStatusRe.MatchString("SHOW ENGINE INNODB MUTEX"))
+	assert.False(t, showEngineStatusRe.MatchString("SHOW ENGINES"))
+
+	query := innodbStatusQuery()
+	assert.Contains(t, query, `SELECT 'InnoDB' AS "Type", '' AS "Name", concat_ws(`)
+	assert.Contains(t, query, `) AS "Status"`)
+	for _, section := range []string{"INNODB MONITOR OUTPUT", "TRANSACTIONS", "ROW OPERATIONS", "END OF INNODB MONITOR OUTPUT"} {
+		assert.Contains(t, query, section)
+	}
+}
+
+func TestShowReplicationStatus(t *testing.T) {
+	for _, sql := range []string{"SHOW MASTER STATUS", "show binary log status;"} {
+		assert.True(t, showMasterStatusRe.MatchString(sql), sql)
+	}
+	assert.False(t, showMasterStatusRe.MatchString("SHOW MASTER LOGS"))
+
+	query := masterStatusQuery()
+	assert.Contains(t, query, `NULL::text AS "File",`)
+	assert.Contains(t, query, `NULL::bigint AS "Position",`)
+	assert.Contains(t, query, `NULL::text AS "Executed_Gtid_Set"`)
+	assert.Equal(t, len(masterStatusColumns), strings.Count(query, " AS "))
+	assert.Contains(t, query, "WHERE false")
+
+	m := showSlaveStatusRe.FindStringSubmatch("SHOW SLAVE STATUS")
+	require.NotNil(t, m)
+	query = slaveStatusQuery(strings.EqualFold(m[1], "REPLICA"))
+	assert.Equal(t, 60, strings.Count(query, " AS "))
+	for _, column := range []string{`text AS "Slave_IO_State"`, `bigint AS "Seconds_Behind_Master"`, `text AS "Master_Host"`, `text AS "Channel_Name"`} {
+		assert.Contains(t, query, column)
+	}
+
+	m = showSlaveStatusRe.FindStringSubmatch("show replica status for channel 'ch1'")
+	require.NotNil(t, m)
+	query = slaveStatusQuery(strings.EqualFold(m[1], "REPLICA"))
+	for _, column := range []string{`"Replica_IO_State"`, `bigint AS "Seconds_Behind_Source"`, `"Source_Host"`, `"Exec_Source_Log_Pos"`, `"Get_Source_public_key"`, `"Relay_Log_File"`} {
+		assert.Contains(t, query, column)
+	}
+	assert.NotContains(t, query, "Slave")
+	assert.NotContains(t, query, "Master")
+}
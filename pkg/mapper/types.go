@@ -2,6 +2,9 @@ package mapper
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"time"
 
 	"github.com/go-mysql-org/go-mysql/mysql"
@@ -147,14 +150,9 @@ func (tm *TypeMapper) ConvertValue(value interface{}, targetType byte) (interfac
 		if !numeric.Valid {
 			return nil, nil
 		}
-		// Convert Numeric to float64
-		float64Val, err := numeric.Float64Value()
-		if err == nil && float64Val.Valid {
-			value = float64Val.Float64
-		} else {
-			// Fallback to string representation
-			value = numeric.Int.String()
-		}
+		// Every digit is kept (BIGINT UNSIGNED stored as NUMERIC(20,0), DECIMAL(30,10)),
+		// float64 only holds 53 bits
+		value = NumericString(numeric)
 	}
 
 	// Handle pgtype.Timestamp
@@ -200,7 +198,7 @@ func (tm *TypeMapper) convertToInt(value interface{}) (interface{}, error) {
 	case int64:
 		return v, nil
 	case uint:
-		return int64(v), nil
+		return tm.convertToInt(uint64(v))
 	case uint8:
 		return int64(v), nil
 	case uint16:
@@ -208,6 +206,10 @@ func (tm *TypeMapper) convertToInt(value interface{}) (interface{}, error) {
 	case uint32:
 		return int64(v), nil
 	case uint64:
+		// BIGINT UNSIGNED values above the int64 range must not wrap around
+		if v > math.MaxInt64 {
+			return v, nil
+		}
 		return int64(v), nil
 	case float32:
 		return int64(v), nil
@@ -216,6 +218,12 @@ func (tm *TypeMapper) convertToInt(value interface{}) (interface{}, error) {
 	case string:
 		var i int64
 		_, err := fmt.Sscanf(v, "%d", &i)
+		if err != nil {
+			var u uint64
+			if _, uerr := fmt.Sscanf(v, "%d", &u); uerr == nil {
+				return u, nil
+			}
+		}
 		return i, err
 	default:
 		return value, nil
@@ -257,8 +265,36 @@ func (tm *TypeMapper) convertToFloat(value interface{}) (interface{}, error) {
 	}
 }
 
+// convertToDecimal returns the decimal text of a value. DECIMAL is sent to clients as
+// text in both protocols, going through float64 would round it
 func (tm *TypeMapper) convertToDecimal(value interface{}) (interface{}, error) {
-	return tm.convertToFloat(value)
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []byte:
+		return tm.convertToDecimal(string(v))
+	case string:
+		if _, ok := new(big.Float).SetString(v); !ok {
+			return nil, fmt.Errorf("invalid decimal value: %q", v)
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+// NumericString formats a PostgreSQL NUMERIC exactly, with the scale it was returned
+// with: 12345678901234567890.1234567890 stays as is where a float64 would round it
+func NumericString(numeric pgtype.Numeric) string {
+	text, err := numeric.Value() // PostgreSQL's own text format, NaN and Infinity included
+	if s, ok := text.(string); ok && err == nil {
+		return s
+	}
+	return numeric.Int.String()
 }
 
 func (tm *TypeMapper) convertToString(value interface{}) (interface{}, error) {
@@ -1,9 +1,11 @@
 package mapper
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -326,6 +328,12 @@ func TestTypeMapper_ConvertValue_Integers(t *testing.T) {
 		// BIGINT conversions
 		{"int to bigint", 9223372036854775807, MYSQL_TYPE_LONGLONG, int64(9223372036854775807), false},
 		{"string to bigint", "9223372036854775807", MYSQL_TYPE_LONGLONG, int64(9223372036854775807), false},
+
+		// BIGINT UNSIGNED above the int64 range
+		{"uint64 to bigint unsigned", uint64(18446744073709551615), MYSQL_TYPE_LONGLONG, uint64(18446744073709551615), false},
+		{"string to bigint unsigned", "18446744073709551615", MYSQL_TYPE_LONGLONG, uint64(18446744073709551615), false},
+		{"numeric to bigint unsigned", pgtype.Numeric{Int: new(big.Int).SetUint64(18446744073709551615), Valid: true}, MYSQL_TYPE_LONGLONG, uint64(18446744073709551615), false},
+		{"out of range string", "18446744073709551616", MYSQL_TYPE_LONGLONG, nil, true},
 	}
 
 	for _, tt := range tests {
@@ -361,8 +369,9 @@ func TestTypeMapper_ConvertValue_FloatingPoint(t *testing.T) {
 		{"float to double", 3.14, MYSQL_TYPE_DOUBLE, float64(3.14)},
 
 		// DECIMAL conversions
-		{"int to decimal", 42, MYSQL_TYPE_NEWDECIMAL, float64(42)},
-		{"string to decimal", "123.45", MYSQL_TYPE_NEWDECIMAL, float64(123.45)},
+		{"int to decimal", 42, MYSQL_TYPE_NEWDECIMAL, "42"},
+		{"string to decimal", "123.45", MYSQL_TYPE_NEWDECIMAL, "123.45"},
+		{"float to decimal", 0.1, MYSQL_TYPE_NEWDECIMAL, "0.1"},
 	}
 
 	for _, tt := range tests {
@@ -374,6 +383,31 @@ func TestTypeMapper_ConvertValue_FloatingPoint(t *testing.T) {
 	}
 }
 
+func TestTypeMapper_ConvertValue_DecimalExact(t *testing.T) {
+	tm := NewTypeMapper()
+
+	// DECIMAL(30,10) as PostgreSQL returns it, float64 keeps only 15-17 digits of it
+	const exact = "12345678901234567890.1234567890"
+	var numeric pgtype.Numeric
+	assert.NoError(t, numeric.Scan(exact))
+	f, err := numeric.Float64Value()
+	assert.NoError(t, err)
+	assert.NotEqual(t, exact, big.NewFloat(f.Float64).Text('f', 10))
+
+	result, err := tm.ConvertValue(numeric, MYSQL_TYPE_NEWDECIMAL)
+	assert.NoError(t, err)
+	assert.Equal(t, exact, result)
+	assert.Equal(t, exact, NumericString(numeric))
+
+	assert.NoError(t, numeric.Scan("-0.0000000001"))
+	assert.Equal(t, "-0.0000000001", NumericString(numeric))
+	assert.NoError(t, numeric.Scan("NaN"))
+	assert.Equal(t, "NaN", NumericString(numeric))
+
+	_, err = tm.ConvertValue("12.3x", MYSQL_TYPE_NEWDECIMAL)
+	assert.Error(t, err)
+}
+
 func TestTypeMapper_ConvertValue_Strings(t *testing.T) {
 	tm := NewTypeMapper()
 
@@ -0,0 +1,74 @@
+package observability
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AuditLogger writes one JSON record per executed statement to its own sink,
+// independent of the operational log level and format
+type AuditLogger struct {
+	logger       *zap.Logger
+	redactParams atomic.Bool
+}
+
+func NewAuditLogger(path string, redactParams bool) (*AuditLogger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("audit log path is required")
+	}
+
+	config := zap.NewProductionConfig()
+	config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	config.Encoding = "json"
+	config.OutputPaths = []string{path}
+	config.ErrorOutputPaths = []string{"stderr"}
+	config.Sampling = nil // Every statement must be recorded
+	config.DisableCaller = true
+	config.DisableStacktrace = true
+	config.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	config.EncoderConfig.TimeKey = "timestamp"
+	config.EncoderConfig.MessageKey = "event"
+
+	logger, err := config.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	a := &AuditLogger{logger: logger}
+	a.redactParams.Store(redactParams)
+	return a, nil
+}
+
+func (a *AuditLogger) SetRedactParameters(redact bool) {
+	a.redactParams.Store(redact)
+}
+
+// LogStatement records a statement with its outcome
+func (a *AuditLogger) LogStatement(sessionID, user, clientIP, database, query string, duration time.Duration, err error) {
+	if a.redactParams.Load() {
+		query = redactQuery(query)
+	}
+
+	fields := []zap.Field{
+		zap.String("session_id", sessionID),
+		zap.String("user", user),
+		zap.String("client_ip", clientIP),
+		zap.String("database", database),
+		zap.String("statement", query),
+		zap.Float64("duration_seconds", duration.Seconds()),
+		zap.Bool("success", err == nil),
+	}
+	if err != nil {
+		fields = append(fields, zap.String("error", err.Error()))
+	}
+
+	a.logger.Info("statement", fields...)
+}
+
+func (a *AuditLogger) Sync() error {
+	return a.logger.Sync()
+}
@@ -0,0 +1,66 @@
+package observability
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditEntries(t *testing.T, path string) []map[string]interface{} {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAuditLogger_LogStatement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewAuditLogger(path, false)
+	require.NoError(t, err)
+
+	audit.LogStatement("s1", "alice", "10.0.0.1", "shop", "SELECT * FROM orders WHERE id = 1", 5*time.Millisecond, nil)
+	audit.LogStatement("s1", "alice", "10.0.0.1", "shop", "DROP TABLE orders", time.Millisecond, errors.New("permission denied"))
+	require.NoError(t, audit.Sync())
+
+	entries := readAuditEntries(t, path)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "statement", entries[0]["event"])
+	assert.Equal(t, "alice", entries[0]["user"])
+	assert.Equal(t, "10.0.0.1", entries[0]["client_ip"])
+	assert.Equal(t, "shop", entries[0]["database"])
+	assert.Equal(t, "SELECT * FROM orders WHERE id = 1", entries[0]["statement"])
+	assert.Equal(t, true, entries[0]["success"])
+	assert.NotEmpty(t, entries[0]["timestamp"])
+
+	assert.Equal(t, false, entries[1]["success"])
+	assert.Equal(t, "permission denied", entries[1]["error"])
+}
+
+func TestAuditLogger_Redaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewAuditLogger(path, true)
+	require.NoError(t, err)
+
+	audit.LogStatement("s1", "bob", "10.0.0.2", "", "UPDATE users SET password = 'hunter2'", time.Millisecond, nil)
+	require.NoError(t, audit.Sync())
+
+	entries := readAuditEntries(t, path)
+	require.Len(t, entries, 1)
+	assert.NotContains(t, entries[0]["statement"], "hunter2")
+}
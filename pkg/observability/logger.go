@@ -1,29 +1,40 @@
 package observability
 
 import (
+	"sync/atomic"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type Logger struct {
 	*zap.Logger
-	redactParams bool
+	level        zap.AtomicLevel
+	redactParams atomic.Bool
+	slowQuery    atomic.Int64 // Slow-query threshold in nanoseconds, 0 disables
+	slowQueries  atomic.Int64 // Queries logged as slow
+	longTx       atomic.Int64 // Long-transaction threshold in nanoseconds, 0 disables
+	longTxs      atomic.Int64 // Transactions logged as long
 }
 
-func NewLogger(level string, format string, redactParams bool) (*Logger, error) {
-	var zapLevel zapcore.Level
+func parseLevel(level string) zapcore.Level {
 	switch level {
 	case "debug":
-		zapLevel = zapcore.DebugLevel
+		return zapcore.DebugLevel
 	case "info":
-		zapLevel = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	case "warn":
-		zapLevel = zapcore.WarnLevel
+		return zapcore.WarnLevel
 	case "error":
-		zapLevel = zapcore.ErrorLevel
+		return zapcore.ErrorLevel
 	default:
-		zapLevel = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	}
+}
+
+func NewLogger(level string, format string, redactParams bool) (*Logger, error) {
+	zapLevel := parseLevel(level)
 
 	var config zap.Config
 	if format == "json" {
@@ -40,15 +51,57 @@ func NewLogger(level string, format string, redactParams bool) (*Logger, error)
 		return nil, err
 	}
 
-	return &Logger{
-		Logger:       logger,
-		redactParams: redactParams,
-	}, nil
+	l := &Logger{
+		Logger: logger,
+		level:  config.Level,
+	}
+	l.redactParams.Store(redactParams)
+	return l, nil
+}
+
+// The setters below are safe to call on a running logger (config reload)
+
+func (l *Logger) SetLevel(level string) {
+	l.level.SetLevel(parseLevel(level))
+}
+
+func (l *Logger) Level() zapcore.Level {
+	return l.level.Level()
+}
+
+func (l *Logger) SetRedactParameters(redact bool) {
+	l.redactParams.Store(redact)
+}
+
+func (l *Logger) SetSlowQueryThreshold(threshold time.Duration) {
+	l.slowQuery.Store(int64(threshold))
+}
+
+func (l *Logger) SlowQueryThreshold() time.Duration {
+	return time.Duration(l.slowQuery.Load())
+}
+
+// SlowQueries returns the number of queries that exceeded the slow-query threshold
+func (l *Logger) SlowQueries() int64 {
+	return l.slowQueries.Load()
+}
+
+func (l *Logger) SetLongTransactionThreshold(threshold time.Duration) {
+	l.longTx.Store(int64(threshold))
+}
+
+func (l *Logger) LongTransactionThreshold() time.Duration {
+	return time.Duration(l.longTx.Load())
+}
+
+// LongTransactions returns the number of transactions that exceeded the long-transaction threshold
+func (l *Logger) LongTransactions() int64 {
+	return l.longTxs.Load()
 }
 
 func (l *Logger) LogQuery(sessionID, user, clientIP, query string, duration float64, rowsAffected int64, err error) {
-	if l.redactParams {
-		query = l.redactQuery(query)
+	if l.redactParams.Load() {
+		query = redactQuery(query)
 	}
 
 	fields := []zap.Field{
@@ -60,14 +113,35 @@ func (l *Logger) LogQuery(sessionID, user, clientIP, query string, duration floa
 		zap.Int64("rows_affected", rowsAffected),
 	}
 
+	threshold := l.SlowQueryThreshold()
 	if err != nil {
 		fields = append(fields, zap.Error(err))
 		l.Error("query_error", fields...)
+	} else if threshold > 0 && duration >= threshold.Seconds() {
+		l.slowQueries.Add(1)
+		l.Warn("slow_query", fields...)
 	} else {
 		l.Info("query_executed", fields...)
 	}
 }
 
+// LogTransaction warns about a transaction that ended with result after running longer
+// than the long-transaction threshold, it kept its PostgreSQL connection all along
+func (l *Logger) LogTransaction(sessionID, user, clientIP, result string, duration time.Duration) {
+	threshold := l.LongTransactionThreshold()
+	if threshold <= 0 || duration < threshold {
+		return
+	}
+	l.longTxs.Add(1)
+	l.Warn("long_transaction",
+		zap.String("session_id", sessionID),
+		zap.String("user", user),
+		zap.String("client_ip", clientIP),
+		zap.String("result", result),
+		zap.Float64("duration_seconds", duration.Seconds()),
+	)
+}
+
 func (l *Logger) LogConnection(sessionID, user, clientIP string, connected bool) {
 	if connected {
 		l.Info("client_connected",
@@ -94,7 +168,7 @@ func (l *Logger) LogError(sessionID, user, clientIP, errorType string, err error
 	)
 }
 
-func (l *Logger) redactQuery(query string) string {
+func redactQuery(query string) string {
 	if len(query) > 100 {
 		return query[:100] + "... [REDACTED]"
 	}
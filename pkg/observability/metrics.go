@@ -3,18 +3,24 @@ package observability
 import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
 )
 
 type Metrics struct {
-	ActiveConnections prometheus.Gauge
-	TotalQueries      prometheus.Counter
-	QueryDuration     prometheus.Histogram
-	ErrorsTotal       *prometheus.CounterVec
-	PGPoolSize        prometheus.Gauge
-	BytesIn           prometheus.Counter
-	BytesOut          prometheus.Counter
-	PreparedStmts     prometheus.Gauge
-	TransactionsTotal *prometheus.CounterVec
+	ActiveConnections   prometheus.Gauge
+	TotalQueries        prometheus.Counter
+	QueryDuration       prometheus.Histogram
+	ErrorsTotal         *prometheus.CounterVec
+	PGPoolSize          prometheus.Gauge
+	BytesIn             prometheus.Counter
+	BytesOut            prometheus.Counter
+	PreparedStmts       prometheus.Gauge
+	TransactionsTotal   *prometheus.CounterVec
+	TransactionsStarted prometheus.Counter
+	TransactionDuration prometheus.Histogram
+	RewriteFailures     *prometheus.CounterVec
+	ParamMismatches     prometheus.Counter
+	BreakerState        *prometheus.GaugeVec
 }
 
 func NewMetrics() *Metrics {
@@ -56,6 +62,27 @@ func NewMetrics() *Metrics {
 			Name: "mysql_pg_proxy_transactions_total",
 			Help: "Total number of transactions by result",
 		}, []string{"result"}),
+		TransactionsStarted: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "mysql_pg_proxy_transactions_started_total",
+			Help: "Total number of transactions started",
+		}),
+		TransactionDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "mysql_pg_proxy_transaction_duration_seconds",
+			Help:    "Transaction duration from start to commit or rollback in seconds",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 18),
+		}),
+		RewriteFailures: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "mysql_pg_proxy_rewrite_failures_total",
+			Help: "Total number of statements that could not be rewritten by reason and feature",
+		}, []string{"reason", "feature"}),
+		ParamMismatches: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "mysql_pg_proxy_param_count_mismatches_total",
+			Help: "Total number of prepared statements whose rewrite changed the number of parameters",
+		}),
+		BreakerState: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mysql_pg_proxy_backend_breaker_state",
+			Help: "Circuit breaker state of each PostgreSQL backend: 0 closed, 1 open, 2 half-open",
+		}, []string{"backend"}),
 	}
 }
 
@@ -71,6 +98,15 @@ func (m *Metrics) IncTotalQueries() {
 	m.TotalQueries.Inc()
 }
 
+// QueryCount returns the number of queries processed so far
+func (m *Metrics) QueryCount() uint64 {
+	var metric dto.Metric
+	if err := m.TotalQueries.Write(&metric); err != nil {
+		return 0
+	}
+	return uint64(metric.GetCounter().GetValue())
+}
+
 func (m *Metrics) ObserveQueryDuration(seconds float64) {
 	m.QueryDuration.Observe(seconds)
 }
@@ -98,3 +134,23 @@ func (m *Metrics) SetPreparedStmts(count float64) {
 func (m *Metrics) IncTransactions(result string) {
 	m.TransactionsTotal.WithLabelValues(result).Inc()
 }
+
+func (m *Metrics) IncTransactionsStarted() {
+	m.TransactionsStarted.Inc()
+}
+
+func (m *Metrics) ObserveTransactionDuration(seconds float64) {
+	m.TransactionDuration.Observe(seconds)
+}
+
+func (m *Metrics) IncRewriteFailures(reason, feature string) {
+	m.RewriteFailures.WithLabelValues(reason, feature).Inc()
+}
+
+func (m *Metrics) IncParamMismatches() {
+	m.ParamMismatches.Inc()
+}
+
+func (m *Metrics) SetBreakerState(backend string, state float64) {
+	m.BreakerState.WithLabelValues(backend).Set(state)
+}
@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// byteaOID is the PostgreSQL BYTEA type, sent to MySQL clients as a BLOB
+const byteaOID = 17
+
+// blobColumnLength is the column length MySQL reports for LONGBLOB, BYTEA holds up to 1GB
+const blobColumnLength = 1<<32 - 1
+
+// buildBinaryResultset builds a binary protocol result set. go-mysql takes each column's
+// type from the first row and encodes all rows by it, so a NULL there would send the
+// strings and blobs of later rows without their length prefix. A leading row holding the
+// first non-NULL value of each column fixes the types and is dropped again
+func buildBinaryResultset(names []string, values [][]interface{}) (*mysql.Resultset, error) {
+	sample := make([]interface{}, len(names))
+	for _, row := range values {
+		for i, v := range row {
+			if sample[i] == nil {
+				sample[i] = v
+			}
+		}
+	}
+
+	resultset, err := mysql.BuildSimpleBinaryResultset(names, append([][]interface{}{sample}, values...))
+	if err != nil {
+		return nil, err
+	}
+	resultset.RowDatas = resultset.RowDatas[1:]
+	return resultset, nil
+}
+
+// setBlobField marks a result column as a binary BLOB, so clients return its bytes as is
+// rather than decoding them as UTF-8 text
+func setBlobField(field *mysql.Field) {
+	field.Type = mysql.MYSQL_TYPE_BLOB
+	field.Charset = 63
+	field.Flag = mysql.BINARY_FLAG | mysql.BLOB_FLAG
+	field.ColumnLength = blobColumnLength
+}
+
+// stmtArg converts a prepared statement parameter for PostgreSQL. go-mysql delivers
+// every string parameter as []byte. Text is passed on as a string; bytes that are not
+// valid text (a NUL or invalid UTF-8) can only be BLOB data and stay []byte, which pgx
+// sends as a BYTEA literal instead of letting PostgreSQL reject the string
+func stmtArg(v []byte) interface{} {
+	if utf8.Valid(v) && bytes.IndexByte(v, 0) < 0 {
+		return string(v)
+	}
+	return v
+}
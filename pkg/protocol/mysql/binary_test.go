@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// valueRows yields fixed rows of values for the given columns
+type valueRows struct {
+	fields []pgconn.FieldDescription
+	rows   [][]any
+	next   int
+}
+
+func (r *valueRows) Close()                                       {}
+func (r *valueRows) Err() error                                   { return nil }
+func (r *valueRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *valueRows) FieldDescriptions() []pgconn.FieldDescription { return r.fields }
+func (r *valueRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+func (r *valueRows) Scan(dest ...any) error { return nil }
+func (r *valueRows) Values() ([]any, error) { return r.rows[r.next-1], nil }
+func (r *valueRows) RawValues() [][]byte    { return nil }
+func (r *valueRows) Conn() *pgx.Conn        { return nil }
+
+func TestByteaResult(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+
+	blob := []byte{0x00, 0xff, 0x80, '\'', '\\', 0x01}
+	newRows := func() *valueRows {
+		return &valueRows{
+			fields: []pgconn.FieldDescription{{Name: "data", DataTypeOID: byteaOID}},
+			rows:   [][]any{{nil}, {blob}},
+		}
+	}
+
+	t.Run("Binary protocol", func(t *testing.T) {
+		result, err := ch.buildMySQLResult(newRows(), true)
+		require.NoError(t, err)
+
+		field := result.Resultset.Fields[0]
+		assert.Equal(t, uint8(mysql.MYSQL_TYPE_BLOB), field.Type)
+		assert.Equal(t, uint16(63), field.Charset)
+
+		require.Len(t, result.Resultset.RowDatas, 2)
+		// Header byte and null bitmap, then the length-prefixed bytes
+		row := []byte(result.Resultset.RowDatas[1])
+		assert.Equal(t, append([]byte{byte(len(blob))}, blob...), row[2:])
+	})
+
+	t.Run("Text protocol", func(t *testing.T) {
+		result, err := ch.buildMySQLResult(newRows(), false)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint8(mysql.MYSQL_TYPE_BLOB), result.Resultset.Fields[0].Type)
+		require.Len(t, result.Resultset.RowDatas, 2)
+		assert.Equal(t, append([]byte{byte(len(blob))}, blob...), []byte(result.Resultset.RowDatas[1]))
+	})
+}
+
+func TestStmtArg(t *testing.T) {
+	assert.Equal(t, "2024-01-01 10:00:00", stmtArg([]byte("2024-01-01 10:00:00")))
+	assert.Equal(t, "héllo", stmtArg([]byte("héllo")))
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, stmtArg([]byte{0x89, 'P', 'N', 'G'}))
+	assert.Equal(t, []byte{'a', 0x00, 'b'}, stmtArg([]byte{'a', 0x00, 'b'}))
+}
@@ -0,0 +1,213 @@
+package mysql
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"net"
+
+	"aproxy/pkg/session"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"go.uber.org/zap"
+)
+
+// handshakeConn records the scramble sent in the server's initial handshake
+// COM_CHANGE_USER auth responses are computed against that same scramble,
+// which go-mysql keeps private, so it is captured off the wire here.
+// The advertised version and capabilities are rewritten on the way out, and
+// queued session state is attached to the next OK packet.
+// Commands answered with a bare string (COM_STATISTICS) replace that OK packet
+type handshakeConn struct {
+	net.Conn
+	opts         handshakeOptions
+	salt         []byte
+	seen         bool
+	sessionState []byte // Session state info for the OK packet answering the current command
+	reply        []byte // Payload sent instead of the OK packet answering the current command
+	limit        packetLimit
+	tooLarge     func() // Called when the client sends a packet over the limit
+}
+
+func (c *handshakeConn) Write(p []byte) (int, error) {
+	if !c.seen {
+		c.seen = true
+		c.salt = parseHandshakeSalt(p)
+
+		// The caller only knows about its own packet, report its length on success
+		if _, err := c.Conn.Write(rewriteHandshake(p, c.opts)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if c.reply != nil {
+		reply := c.reply
+		c.reply = nil
+		if _, err := c.Conn.Write(replacePayload(p, reply)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if c.sessionState != nil {
+		state := c.sessionState
+		c.sessionState = nil
+		if _, err := c.Conn.Write(appendSessionState(p, state)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return c.Conn.Write(p)
+}
+
+// parseHandshakeSalt extracts auth-plugin-data from a Handshake V10 packet
+func parseHandshakeSalt(packet []byte) []byte {
+	// 4 byte packet header, protocol version 10
+	if len(packet) < 5 || packet[4] != 10 {
+		return nil
+	}
+	data := packet[5:]
+
+	// server version[NUL], connection id[4]
+	end := bytes.IndexByte(data, 0)
+	if end < 0 || len(data) < end+1+4+8 {
+		return nil
+	}
+	data = data[end+1+4:]
+	salt := append([]byte{}, data[:8]...)
+
+	// part 1[8], filler[1], capability[2], charset[1], status[2], capability[2], auth data len[1], reserved[10]
+	data = data[8+1+2+1+2+2:]
+	if len(data) < 1+10 {
+		return salt
+	}
+	saltLen := int(data[0])
+	data = data[1+10:]
+
+	// part 2 is max(13, len-8) bytes including the trailing NUL
+	part2 := saltLen - 8 - 1
+	if part2 < 12 {
+		part2 = 12
+	}
+	if len(data) < part2 {
+		return salt
+	}
+	return append(salt, data[:part2]...)
+}
+
+// changeUserRequest is the payload of COM_CHANGE_USER
+type changeUserRequest struct {
+	User         string
+	AuthResponse []byte
+	Database     string
+	AuthPlugin   string
+}
+
+func parseChangeUser(data []byte) (*changeUserRequest, error) {
+	req := &changeUserRequest{AuthPlugin: mysql.AUTH_NATIVE_PASSWORD}
+
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return nil, fmt.Errorf("malformed COM_CHANGE_USER: missing user")
+	}
+	req.User = string(data[:end])
+	data = data[end+1:]
+
+	// CLIENT_SECURE_CONNECTION: length-prefixed auth response
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return nil, fmt.Errorf("malformed COM_CHANGE_USER: bad auth response")
+	}
+	req.AuthResponse = data[1 : 1+int(data[0])]
+	data = data[1+int(data[0]):]
+
+	end = bytes.IndexByte(data, 0)
+	if end < 0 {
+		req.Database = string(data)
+		return req, nil
+	}
+	req.Database = string(data[:end])
+	data = data[end+1:]
+
+	// charset[2], then auth plugin name[NUL] (CLIENT_PLUGIN_AUTH)
+	if len(data) > 2 {
+		data = data[2:]
+		if end = bytes.IndexByte(data, 0); end > 0 {
+			req.AuthPlugin = string(data[:end])
+		}
+	}
+	return req, nil
+}
+
+// checkAuthResponse verifies a scrambled password against the configured one
+func checkAuthResponse(plugin string, salt, authResponse []byte, password string) bool {
+	if password == "" {
+		return len(authResponse) == 0
+	}
+	if len(salt) == 0 {
+		return false
+	}
+
+	var expected []byte
+	switch plugin {
+	case mysql.AUTH_NATIVE_PASSWORD:
+		expected = mysql.CalcPassword(salt, []byte(password))
+	case mysql.AUTH_CACHING_SHA2_PASSWORD:
+		expected = mysql.CalcCachingSha2Password(salt, password)
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(expected, authResponse) == 1
+}
+
+// handleChangeUser re-authenticates the connection as another user and starts
+// a fresh session: the PostgreSQL connection is released (dropping its session
+// state and any open transaction) and session variables are cleared
+func (ch *ConnectionHandler) handleChangeUser(data []byte) error {
+	req, err := parseChangeUser(data)
+	if err != nil {
+		return mysql.NewError(mysql.ER_MALFORMED_PACKET, err.Error())
+	}
+
+	password, ok := ch.handler.credentials[req.User]
+	if !ok || !checkAuthResponse(req.AuthPlugin, ch.conn.salt, req.AuthResponse, password) {
+		usingPassword := mysql.MySQLErrName[mysql.ER_NO]
+		if len(req.AuthResponse) > 0 {
+			usingPassword = mysql.MySQLErrName[mysql.ER_YES]
+		}
+		ch.handler.metrics.IncErrors("auth")
+		return mysql.NewDefaultError(mysql.ER_ACCESS_DENIED_ERROR, req.User, ch.session.ClientAddr, usingPassword)
+	}
+
+	if err := ch.checkSSL(req.User); err != nil {
+		return err
+	}
+	if err := ch.switchCountedUser(req.User); err != nil {
+		return err
+	}
+
+	ch.discardInserts()
+	if ch.session.InTransaction {
+		ch.endTransaction(txRolledBack)
+	}
+	if ch.pgConn != nil {
+		ch.pgPool.ReleaseForSession(ch.session.ID)
+		ch.pgConn = nil
+	}
+	// The new session starts without a database, on the default backend
+	ch.pgPool = ch.handler.pgRouter.Default()
+	ch.handler.sessionMgr.RemoveSession(ch.session.ID)
+
+	previousUser := ch.session.User
+	ch.session = session.NewSession(req.User, "", ch.session.ClientAddr)
+	ch.handler.sessionMgr.AddSession(ch.session)
+
+	ch.handler.logger.Info("User changed",
+		zap.String("session_id", ch.session.ID),
+		zap.String("previous_user", previousUser),
+		zap.String("user", req.User),
+		zap.String("database", req.Database),
+	)
+
+	if req.Database != "" {
+		return ch.UseDB(req.Database)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package mysql
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"aproxy/internal/pool"
+	"aproxy/pkg/observability"
+	"aproxy/pkg/session"
+	"aproxy/pkg/sqlrewrite"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Metrics register with the global Prometheus registry, so only once per test binary
+var testMetrics = sync.OnceValue(observability.NewMetrics)
+
+func newTestHandler(t testing.TB) *Handler {
+	logger, err := observability.NewLogger("error", "json", true)
+	require.NoError(t, err)
+	return NewHandler(pool.NewRouter(nil, nil), session.NewManager(), sqlrewrite.NewRewriter(true), testMetrics(), logger, false)
+}
+
+// buildHandshake mirrors go-mysql's Handshake V10 packet layout
+func buildHandshake(salt []byte) []byte {
+	data := []byte{0, 0, 0, 0, 10}
+	data = append(data, "8.0.11"...)
+	data = append(data, 0, 1, 0, 0, 0)
+	data = append(data, salt[:8]...)
+	data = append(data, 0, 0xff, 0xff, 33, 2, 0, 0xff, 0xff, byte(len(salt)+1))
+	data = append(data, make([]byte, 10)...)
+	data = append(data, salt[8:]...)
+	data = append(data, 0)
+	data = append(data, mysql.AUTH_NATIVE_PASSWORD...)
+	return append(data, 0)
+}
+
+func buildChangeUser(user string, authResponse []byte, database string) []byte {
+	data := append([]byte(user), 0)
+	data = append(data, byte(len(authResponse)))
+	data = append(data, authResponse...)
+	data = append(data, database...)
+	data = append(data, 0, 33, 0)
+	data = append(data, mysql.AUTH_NATIVE_PASSWORD...)
+	return append(data, 0)
+}
+
+func TestParseHandshakeSalt(t *testing.T) {
+	salt := []byte("abcdefghijklmnopqrst")
+	assert.Equal(t, salt, parseHandshakeSalt(buildHandshake(salt)))
+	assert.Nil(t, parseHandshakeSalt([]byte{1, 0, 0, 0, 0xff}))
+}
+
+func TestParseChangeUser(t *testing.T) {
+	req, err := parseChangeUser(buildChangeUser("alice", []byte{1, 2, 3}, "shop"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", req.User)
+	assert.Equal(t, []byte{1, 2, 3}, req.AuthResponse)
+	assert.Equal(t, "shop", req.Database)
+	assert.Equal(t, mysql.AUTH_NATIVE_PASSWORD, req.AuthPlugin)
+
+	_, err = parseChangeUser([]byte("no-terminator"))
+	assert.Error(t, err)
+}
+
+func TestChangeUser(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetCredentials(map[string]string{
+		"root":  "",
+		"alice": "secret",
+	})
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	salt := []byte("abcdefghijklmnopqrst")
+	ch.conn.salt = salt
+	require.NoError(t, ch.SetUser("root"))
+	ch.session.SetUserVar("v", 1)
+	oldID := ch.session.ID
+
+	t.Run("Bad password rejected", func(t *testing.T) {
+		err := ch.HandleOtherCommand(mysql.COM_CHANGE_USER,
+			buildChangeUser("alice", mysql.CalcPassword(salt, []byte("wrong")), ""))
+		var myErr *mysql.MyError
+		require.ErrorAs(t, err, &myErr)
+		assert.Equal(t, uint16(mysql.ER_ACCESS_DENIED_ERROR), myErr.Code)
+		assert.Equal(t, "root", ch.session.User)
+	})
+
+	t.Run("Unknown user rejected", func(t *testing.T) {
+		err := ch.HandleOtherCommand(mysql.COM_CHANGE_USER, buildChangeUser("mallory", nil, ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("Switch to new user", func(t *testing.T) {
+		err := ch.HandleOtherCommand(mysql.COM_CHANGE_USER,
+			buildChangeUser("alice", mysql.CalcPassword(salt, []byte("secret")), "shop"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "alice", ch.session.User)
+		assert.Equal(t, "shop", ch.session.Database)
+		assert.NotEqual(t, oldID, ch.session.ID)
+
+		// Session state is reset
+		_, ok := ch.session.GetUserVar("v")
+		assert.False(t, ok)
+		_, ok = h.sessionMgr.GetSession(oldID)
+		assert.False(t, ok)
+		_, ok = h.sessionMgr.GetSession(ch.session.ID)
+		assert.True(t, ok)
+	})
+}
+
+func TestChangeUserResetsBackend(t *testing.T) {
+	defaultPool, shopPool := &pool.Pool{}, &pool.Pool{}
+	logger, err := observability.NewLogger("error", "json", true)
+	require.NoError(t, err)
+	router := pool.NewRouter(defaultPool, map[string]*pool.Pool{"shop": shopPool})
+	h := NewHandler(router, session.NewManager(), sqlrewrite.NewRewriter(true), testMetrics(), logger, false)
+	h.SetCredentials(map[string]string{"root": ""})
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	defer ch.Close()
+	require.NoError(t, ch.SetUser("root"))
+
+	require.NoError(t, ch.UseDB("shop"))
+	assert.Same(t, shopPool, ch.pgPool)
+
+	// Without a database the session is no longer on the routed backend
+	require.NoError(t, ch.HandleOtherCommand(mysql.COM_CHANGE_USER, buildChangeUser("root", nil, "")))
+	assert.Same(t, defaultPool, ch.pgPool)
+	assert.Empty(t, ch.session.Database)
+
+	require.NoError(t, ch.HandleOtherCommand(mysql.COM_CHANGE_USER, buildChangeUser("root", nil, "shop")))
+	assert.Same(t, shopPool, ch.pgPool)
+}
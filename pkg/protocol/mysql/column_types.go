@@ -0,0 +1,27 @@
+package mysql
+
+import (
+	"aproxy/pkg/schema"
+	"aproxy/pkg/sqlrewrite"
+)
+
+// loadTableColumns reads the columns and unique keys of the tables query refers to that
+// were created outside the proxy, through the schema cache, and hands them to the
+// rewriter. Their literals, string comparisons and ON DUPLICATE KEY UPDATE are then
+// rewritten as for the tables created through the proxy
+func (ch *ConnectionHandler) loadTableColumns(query string) {
+	cache := schema.GetGlobalCache()
+	for _, table := range ch.handler.rewriter.TablesToLoad(query, ch.session.Variables()) {
+		info := cache.GetTableColumns(ch.pgConn, ch.session.Database, table)
+		columns := make([]sqlrewrite.TableColumn, len(info.Columns))
+		for i, col := range info.Columns {
+			columns[i] = sqlrewrite.TableColumn{
+				Name:          col.Name,
+				DataType:      col.DataType,
+				Collation:     col.Collation,
+				AutoIncrement: col.AutoIncrement,
+			}
+		}
+		ch.handler.rewriter.LoadTable(table, columns, info.UniqueKeys, info.LastRefreshed)
+	}
+}
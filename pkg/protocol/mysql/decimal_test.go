@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDecimalBackend attaches a fake PostgreSQL backend to ch that answers every query
+// with one NUMERIC(30,10) column holding value
+func newDecimalBackend(t *testing.T, ch *ConnectionHandler, value string) {
+	column := &pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{{Name: []byte("amount"),
+		DataTypeOID: pgtype.NumericOID, DataTypeSize: -1, TypeModifier: 30<<16 | 10 + 4}}}
+	newFakePGBackend(t, ch, nil, func(_ *fakePGBackend, backend *pgproto3.Backend, msg pgproto3.FrontendMessage) {
+		switch msg.(type) {
+		case *pgproto3.Query:
+			backend.Send(column)
+			backend.Send(&pgproto3.DataRow{Values: [][]byte{[]byte(value)}})
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		case *pgproto3.Parse:
+			backend.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: []uint32{pgtype.NumericOID}})
+			backend.Send(column)
+		case *pgproto3.Sync:
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		}
+	})
+}
+
+func TestDecimalResultsExact(t *testing.T) {
+	// 20 integer and 10 fractional digits, a float64 would round it to 1.2345678901234567e+19
+	const exact = "12345678901234567890.1234567890"
+	const query = "SELECT amount + 0.0000000001 FROM accounts WHERE amount = ?"
+
+	t.Run("Text protocol", func(t *testing.T) {
+		h := newTestHandler(t)
+		ch := newTestConnection(t, h)
+		newDecimalBackend(t, ch, exact)
+
+		result, err := ch.HandleQuery("SELECT amount + 0.0000000001 FROM accounts WHERE amount = " + exact)
+		require.NoError(t, err)
+		require.Len(t, result.Resultset.RowDatas, 1)
+		assert.Equal(t, byte(mysql.MYSQL_TYPE_NEWDECIMAL), result.Resultset.Fields[0].Type)
+		assert.Equal(t, uint8(10), result.Resultset.Fields[0].Decimal)
+		values, err := result.Resultset.RowDatas[0].ParseText(result.Resultset.Fields, nil)
+		require.NoError(t, err)
+		assert.Equal(t, exact, string(values[0].AsString()))
+	})
+
+	t.Run("Binary protocol", func(t *testing.T) {
+		h := newTestHandler(t)
+		ch := newTestConnection(t, h)
+		newDecimalBackend(t, ch, exact)
+
+		_, _, id, err := ch.HandleStmtPrepare(query)
+		require.NoError(t, err)
+		result, err := ch.HandleStmtExecute(id, query, []interface{}{[]byte(exact)})
+		require.NoError(t, err)
+		require.Len(t, result.Resultset.RowDatas, 1)
+		assert.Equal(t, byte(mysql.MYSQL_TYPE_NEWDECIMAL), result.Resultset.Fields[0].Type)
+		values, err := result.Resultset.RowDatas[0].ParseBinary(result.Resultset.Fields, nil)
+		require.NoError(t, err)
+		assert.Equal(t, exact, string(values[0].AsString()))
+	})
+}
@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// commentDirective is a proxy switch written as a leading /*aproxy:name*/ comment
+type commentDirective int
+
+const (
+	directiveNone commentDirective = iota
+	directiveOnce                  // /*aproxy:name*/ <statement>
+	directiveOn                    // /*aproxy:name=on*/
+	directiveOff                   // /*aproxy:name=off*/
+)
+
+// Directive names
+const (
+	directiveDryRun   = "dry_run"   // Report statements instead of executing them
+	directiveDebug    = "debug"     // Log original and rewritten SQL
+	directiveRaw      = "raw"       // Send PostgreSQL SQL as written, see rawDirective
+	directiveSafeMode = "safe_mode" // Validate rewritten statements with EXPLAIN, see validateRewrite
+)
+
+// parseDirective recognizes a leading /*aproxy:name*/ comment. It has to be looked
+// at before StripComments, which drops regular comments
+//
+//	/*aproxy:dry_run*/ SELECT ...  -> directiveOnce, "SELECT ..."
+//	/*aproxy:dry_run=on*/          -> directiveOn for the rest of the session
+//	/*aproxy:dry_run=off*/         -> directiveOff
+func parseDirective(query, name string) (commentDirective, string) {
+	prefix := "/*aproxy:" + name
+	trimmed := strings.TrimSpace(query)
+	if len(trimmed) < len(prefix) || !strings.EqualFold(trimmed[:len(prefix)], prefix) {
+		return directiveNone, query
+	}
+	end := strings.Index(trimmed, "*/")
+	if end < 0 {
+		return directiveNone, query
+	}
+	option := strings.ToLower(strings.Join(strings.Fields(trimmed[len(prefix):end]), ""))
+	rest := strings.TrimSpace(trimmed[end+2:])
+
+	switch option {
+	case "":
+		return directiveOnce, rest
+	case "=on", "=1":
+		return directiveOn, rest
+	case "=off", "=0":
+		return directiveOff, rest
+	}
+	return directiveNone, query
+}
+
+// applyDirectives strips the leading debug and dry-run directives (in this order) off
+// query, updating the session switches they set. It reports whether the statement is
+// run dry and whether its rewrite is logged, globally, for the session or just for it
+func (ch *ConnectionHandler) applyDirectives(query string) (string, bool, bool) {
+	query, debug := ch.applyDebugDirective(query)
+	dryRun, query := parseDirective(query, directiveDryRun)
+	if dryRun == directiveOn || dryRun == directiveOff {
+		ch.session.DryRun = dryRun == directiveOn
+	}
+	return query, dryRun == directiveOnce || ch.session.DryRun || ch.handler.dryRun.Load(), debug
+}
+
+// applyDebugDirective strips a leading debug directive off query, updating the session
+// switch it sets, and reports whether the statement's rewrite is logged. Prepared
+// statements take it as well, their rewrite is logged when they are prepared
+func (ch *ConnectionHandler) applyDebugDirective(query string) (string, bool) {
+	debug, query := parseDirective(query, directiveDebug)
+	if debug == directiveOn || debug == directiveOff {
+		ch.session.DebugSQL = debug == directiveOn
+	}
+	return query, debug == directiveOnce || ch.session.DebugSQL || ch.handler.debugSQL.Load()
+}
+
+// logRewrite logs a statement before and after rewriting
+func (ch *ConnectionHandler) logRewrite(query, rewrittenSQL string) {
+	ch.handler.logger.Info("SQL Debug",
+		zap.String("session_id", ch.session.ID),
+		zap.String("mysql", query),
+		zap.String("pg", rewrittenSQL),
+		zap.Bool("rewritten", query != rewrittenSQL))
+}
@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		query     string
+		name      string
+		directive commentDirective
+		rest      string
+	}{
+		{"SELECT 1", directiveDryRun, directiveNone, "SELECT 1"},
+		{"/* comment */ SELECT 1", directiveDryRun, directiveNone, "/* comment */ SELECT 1"},
+		{"/*aproxy:dry_run*/ SELECT 1", directiveDryRun, directiveOnce, "SELECT 1"},
+		{"  /*APROXY:DRY_RUN*/DELETE FROM t", directiveDryRun, directiveOnce, "DELETE FROM t"},
+		{"/*aproxy:dry_run=on*/", directiveDryRun, directiveOn, ""},
+		{"/*aproxy:dry_run = off */", directiveDryRun, directiveOff, ""},
+		{"/*aproxy:dry_run=maybe*/ SELECT 1", directiveDryRun, directiveNone, "/*aproxy:dry_run=maybe*/ SELECT 1"},
+		{"/*aproxy:debug*/ SELECT 1", directiveDebug, directiveOnce, "SELECT 1"},
+		{"/*aproxy:debug=1*/", directiveDebug, directiveOn, ""},
+		{"/*aproxy:debug*/ SELECT 1", directiveDryRun, directiveNone, "/*aproxy:debug*/ SELECT 1"},
+	}
+	for _, tt := range tests {
+		directive, rest := parseDirective(tt.query, tt.name)
+		assert.Equal(t, tt.directive, directive, tt.query)
+		assert.Equal(t, tt.rest, rest, tt.query)
+	}
+}
+
+func TestDebugDirective(t *testing.T) {
+	h := newTestHandler(t)
+	core, logs := observer.New(zapcore.InfoLevel)
+	h.logger.Logger = zap.New(core)
+	ch := newTestConnection(t, h)
+	newRecordingBackend(t, ch, 0)
+
+	// logged returns the statements logged since the last call, as "mysql -> pg"
+	logged := func() []string {
+		var statements []string
+		for _, entry := range logs.TakeAll() {
+			if entry.Message == "SQL Debug" {
+				fields := entry.ContextMap()
+				statements = append(statements, fmt.Sprint(fields["mysql"], " -> ", fields["pg"]))
+			}
+		}
+		return statements
+	}
+	query := func(sql string) {
+		_, err := ch.HandleQuery(sql)
+		require.NoError(t, err)
+	}
+	prepare := func(sql string) {
+		_, _, _, err := ch.HandleStmtPrepare(sql)
+		require.NoError(t, err)
+	}
+
+	query("UPDATE t SET a = 1")
+	assert.Empty(t, logged())
+	query("/*aproxy:debug*/ UPDATE t SET a = 2")
+	assert.Equal(t, []string{`UPDATE t SET a = 2 -> UPDATE "t" SET "a"=2`}, logged(), "a single statement")
+	query("UPDATE t SET a = 3")
+	assert.Empty(t, logged())
+
+	// Prepared statements log their rewrite when prepared
+	prepare("/*aproxy:debug*/ SELECT a FROM t WHERE b = ?")
+	assert.Equal(t, []string{`SELECT a FROM t WHERE b = ? -> SELECT "a" FROM "t" WHERE "b"=$1`}, logged())
+	prepare("SELECT a FROM t WHERE b = ?")
+	assert.Empty(t, logged())
+
+	// The session switch applies to both
+	query("/*aproxy:debug=on*/")
+	assert.True(t, ch.session.DebugSQL)
+	query("UPDATE t SET a = 4")
+	prepare("DELETE FROM t WHERE a = ?")
+	assert.Equal(t, []string{
+		`UPDATE t SET a = 4 -> UPDATE "t" SET "a"=4`,
+		`DELETE FROM t WHERE a = ? -> DELETE FROM "t" WHERE "a"=$1`,
+	}, logged())
+
+	// Other sessions are not affected
+	assert.False(t, newTestConnection(t, h).session.DebugSQL)
+
+	query("/*aproxy:debug=off*/")
+	query("UPDATE t SET a = 5")
+	prepare("DELETE FROM t WHERE a = ?")
+	assert.Empty(t, logged())
+}
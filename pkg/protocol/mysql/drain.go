@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"context"
+	"sync"
+)
+
+// drainTracker counts in-flight queries so shutdown can wait for them to finish
+type drainTracker struct {
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{} // Closed once draining and no query is in flight
+}
+
+func newDrainTracker() *drainTracker {
+	return &drainTracker{idle: make(chan struct{})}
+}
+
+// begin marks a query as in flight
+func (d *drainTracker) begin() {
+	d.mu.Lock()
+	d.inFlight++
+	d.mu.Unlock()
+}
+
+// done marks an in-flight query as finished
+func (d *drainTracker) done() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inFlight--
+	if d.draining && d.inFlight == 0 {
+		d.closeIdle()
+	}
+}
+
+// drain switches to draining and blocks until in-flight queries finish or ctx expires
+func (d *drainTracker) drain(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.inFlight == 0 {
+			d.closeIdle()
+		}
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *drainTracker) isDraining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+func (d *drainTracker) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// closeIdle must be called with mu held
+func (d *drainTracker) closeIdle() {
+	select {
+	case <-d.idle:
+	default:
+		close(d.idle)
+	}
+}
@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainTracker_InFlightQueryCompletes(t *testing.T) {
+	d := newDrainTracker()
+
+	// A query is running when shutdown starts
+	d.begin()
+	queryDone := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(queryDone)
+		d.done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := d.drain(ctx)
+	require.NoError(t, err)
+	assert.True(t, d.isDraining())
+
+	select {
+	case <-queryDone:
+	default:
+		t.Fatal("drain returned before the in-flight query finished")
+	}
+	assert.Equal(t, 0, d.count())
+}
+
+func TestDrainTracker_Timeout(t *testing.T) {
+	d := newDrainTracker()
+	d.begin()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := d.drain(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, d.count())
+}
+
+func TestDrainTracker_Idle(t *testing.T) {
+	d := newDrainTracker()
+	assert.False(t, d.isDraining())
+
+	err := d.drain(context.Background())
+	require.NoError(t, err)
+	assert.True(t, d.isDraining())
+
+	// Draining twice is harmless
+	require.NoError(t, d.drain(context.Background()))
+}
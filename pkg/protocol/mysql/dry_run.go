@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"strings"
+
+	"aproxy/pkg/sqlrewrite"
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// dryRunStatement runs query through the rewrite pipeline without executing it
+// and reports one {statement, supported, warning} row. A raw statement is not
+// rewritten and always reported as supported
+func (ch *ConnectionHandler) dryRunStatement(query string, raw bool) (*mysql.Result, error) {
+	supported, warnings := true, []string(nil)
+	if !raw {
+		supported, warnings = ch.checkStatement(query)
+	}
+
+	supportedValue := int64(0)
+	if supported {
+		supportedValue = 1
+	}
+	resultset, err := mysql.BuildSimpleResultset(
+		[]string{"statement", "supported", "warning"},
+		[][]interface{}{{query, supportedValue, strings.Join(warnings, "; ")}},
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &mysql.Result{Status: 0, Resultset: resultset}, nil
+}
+
+// checkStatement reports whether query would be executed, along with the
+// unsupported-feature findings and rewrite errors for it
+func (ch *ConnectionHandler) checkStatement(query string) (bool, []string) {
+	rewriter := ch.handler.rewriter
+
+	// Handled by the proxy itself without rewriting
+	if rewriter.IsShowStatement(query) || rewriter.IsSetStatement(query) || rewriter.IsUseStatement(query) ||
+		rewriter.IsBeginStatement(query) || rewriter.IsCommitStatement(query) || rewriter.IsRollbackStatement(query) {
+		return true, nil
+	}
+
+	if sqlrewrite.IsLoadDataStatement(query) {
+		ld, err := sqlrewrite.ParseLoadData(query)
+		if err != nil {
+			return false, []string{err.Error()}
+		}
+		if !ld.Local {
+			return false, []string{"LOAD DATA INFILE: server-side files are not read, use LOAD DATA LOCAL INFILE"}
+		}
+		return true, nil
+	}
+
+	query, _, err := sqlrewrite.SplitSelectInto(query)
+	if err != nil {
+		return false, []string{err.Error()}
+	}
+
+	supported := true
+	var warnings []string
+	for _, feature := range rewriter.DetectUnsupported(query) {
+		if feature.Severity == "error" {
+			supported = false
+		}
+		warnings = append(warnings, feature.Feature+": "+feature.Suggestion)
+	}
+	if _, err := rewriter.RewriteStatement(query, ch.session.Variables()); err != nil {
+		supported = false
+		warnings = append(warnings, err.Error())
+	}
+	return supported, warnings
+}
@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+
+	// No PostgreSQL backend is needed, nothing is executed
+	result, err := ch.HandleQuery("/*aproxy:dry_run=on*/")
+	require.NoError(t, err)
+	assert.Nil(t, result.Resultset)
+	assert.True(t, ch.session.DryRun)
+
+	tests := []struct {
+		query     string
+		supported int64
+		warning   string
+	}{
+		{"SELECT id, name FROM users WHERE id = 1", 1, ""},
+		{"INSERT INTO users (name) VALUES ('a');", 1, ""},
+		{"SET NAMES utf8mb4", 1, ""},
+		{"BEGIN", 1, ""},
+		{"SELECT GET_LOCK('x', 10)", 0, "GET_LOCK()"},
+		{"SELECT FROM", 0, "failed to parse SQL"},
+		{"SELECT a INTO OUTFILE '/tmp/x' FROM t", 0, "INTO OUTFILE"},
+		{"LOAD DATA INFILE '/etc/passwd' INTO TABLE t", 0, "server-side files"},
+		{"LOCK TABLES t READ", 1, "LOCK TABLES"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result, err := ch.HandleQuery(tt.query)
+			require.NoError(t, err)
+			require.NotNil(t, result.Resultset)
+			var names []string
+			for _, field := range result.Fields {
+				names = append(names, string(field.Name))
+			}
+			assert.Equal(t, []string{"statement", "supported", "warning"}, names)
+			statement, supported, warning := dryRunReport(t, result)
+			assert.Equal(t, tt.supported, supported)
+			assert.NotEmpty(t, statement)
+			if tt.warning == "" {
+				assert.Empty(t, warning)
+			} else {
+				assert.Contains(t, warning, tt.warning)
+			}
+		})
+	}
+
+	_, err = ch.HandleQuery("/*aproxy:dry_run=off*/")
+	require.NoError(t, err)
+	assert.False(t, ch.session.DryRun)
+
+	t.Run("Single statement", func(t *testing.T) {
+		result, err := ch.HandleQuery("/*aproxy:dry_run*/ DELETE FROM users")
+		require.NoError(t, err)
+		statement, supported, _ := dryRunReport(t, result)
+		assert.Equal(t, "DELETE FROM users", statement)
+		assert.Equal(t, int64(1), supported)
+		assert.False(t, ch.session.DryRun)
+	})
+
+	t.Run("Config flag", func(t *testing.T) {
+		h.SetDryRun(true)
+		defer h.SetDryRun(false)
+		result, err := ch.HandleQuery("UPDATE users SET name = 'b'")
+		require.NoError(t, err)
+		require.NotNil(t, result.Resultset)
+	})
+}
+
+// dryRunReport decodes the single {statement, supported, warning} row
+func dryRunReport(t *testing.T, result *mysql.Result) (string, int64, string) {
+	require.Len(t, result.RowDatas, 1)
+	values, err := result.RowDatas[0].ParseText(result.Fields, nil)
+	require.NoError(t, err)
+	return string(values[0].AsString()), values[1].AsInt64(), string(values[2].AsString())
+}
@@ -0,0 +1,28 @@
+package mysql
+
+import (
+	"aproxy/pkg/sqlrewrite"
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// SetExplainRewrite toggles SELECT aproxy_explain_rewrite('<sql>') at runtime, which shows
+// clients without access to the proxy's logs how a statement is rewritten
+func (h *Handler) SetExplainRewrite(enabled bool) {
+	h.explainRewrite.Store(enabled)
+}
+
+// explainRewrite runs sql through the rewrite pipeline without executing it and
+// reports one {step, detail} row per step, see sqlrewrite.Rewriter.ExplainRewrite
+func (ch *ConnectionHandler) explainRewrite(sql string) (*mysql.Result, error) {
+	sql = sqlrewrite.TrimStatement(ch.handler.rewriter.StripComments(sql))
+
+	var rows [][]interface{}
+	for _, step := range ch.handler.rewriter.ExplainRewrite(sql, ch.session.Variables()) {
+		rows = append(rows, []interface{}{step.Step, step.Detail})
+	}
+	resultset, err := mysql.BuildSimpleResultset([]string{"step", "detail"}, rows, false)
+	if err != nil {
+		return nil, err
+	}
+	return &mysql.Result{Status: 0, Resultset: resultset}, nil
+}
@@ -0,0 +1,241 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"aproxy/pkg/sqlrewrite"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Columns of MySQL's traditional EXPLAIN
+var explainColumns = []string{
+	"id", "select_type", "table", "partitions", "type", "possible_keys",
+	"key", "key_len", "ref", "rows", "filtered", "Extra",
+}
+
+// explainStatement answers EXPLAIN from the PostgreSQL plan of the explained statement.
+// args bind its placeholders when it was prepared. An EXPLAIN of a statement with ? sent
+// as a query has no values for them: PostgreSQL 16 plans it with GENERIC_PLAN, older
+// versions get dummy literals of the parameter types instead
+func (ch *ConnectionHandler) explainStatement(ctx context.Context, explain *sqlrewrite.Explain, binary bool, args []interface{}) (*mysql.Result, error) {
+	sql := explain.SQL(false)
+	if explain.Params > 0 && len(args) == 0 {
+		switch {
+		case explain.Analyze:
+			return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "EXPLAIN ANALYZE needs values for the placeholders of the statement")
+		case pgMajorVersion(ch.pgConn) >= 16:
+			sql = explain.SQL(true)
+		default:
+			literals, err := ch.placeholderLiterals(ctx, sql)
+			if err != nil {
+				return nil, ch.handler.errorMapper.MySQLError(err)
+			}
+			sql = explain.Bind(literals).SQL(false)
+		}
+	}
+
+	lines, err := ch.queryPlan(ctx, sql, args)
+	if err != nil {
+		return nil, ch.handler.errorMapper.MySQLError(err)
+	}
+
+	// JSON is a single row with the whole plan, text one row per line
+	plan := strings.Join(lines, "\n")
+	names, values := []string{"EXPLAIN"}, [][]interface{}{{plan}}
+	if explain.Format == sqlrewrite.ExplainTraditional {
+		names = explainColumns
+		if values, err = traditionalPlan([]byte(plan)); err != nil {
+			return nil, err
+		}
+	}
+	resultset, err := mysql.BuildSimpleResultset(names, values, binary)
+	if err != nil {
+		return nil, err
+	}
+	return &mysql.Result{Status: 0, Resultset: resultset}, nil
+}
+
+// queryPlan runs an EXPLAIN and returns its lines. Without args it is sent as is, pgx
+// would refuse the unbound placeholders of a generic plan
+func (ch *ConnectionHandler) queryPlan(ctx context.Context, sql string, args []interface{}) ([]string, error) {
+	var lines []string
+	if len(args) == 0 {
+		results, err := ch.pgConn.PgConn().Exec(ctx, sql).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range results[0].Rows {
+			lines = append(lines, string(row[0]))
+		}
+		return lines, nil
+	}
+
+	rows, err := ch.pgConn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		lines = append(lines, string(rows.RawValues()[0]))
+	}
+	return lines, rows.Err()
+}
+
+// pgMajorVersion returns the major version of the PostgreSQL server conn is connected to,
+// 0 when unknown
+func pgMajorVersion(conn *pgx.Conn) int {
+	version := conn.PgConn().ParameterStatus("server_version")
+	end := 0
+	for end < len(version) && version[end] >= '0' && version[end] <= '9' {
+		end++
+	}
+	major, _ := strconv.Atoi(version[:end])
+	return major
+}
+
+// placeholderLiterals returns a literal of the type PostgreSQL infers for each placeholder
+// of sql. The literals only stand in for values when planning, NULL is avoided where
+// possible since comparisons with it are folded away
+func (ch *ConnectionHandler) placeholderLiterals(ctx context.Context, sql string) ([]string, error) {
+	desc, err := ch.pgConn.Prepare(ctx, "", sql)
+	if err != nil {
+		return nil, err
+	}
+	literals := make([]string, len(desc.ParamOIDs))
+	for i, oid := range desc.ParamOIDs {
+		switch oid {
+		case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.NumericOID, pgtype.Float4OID, pgtype.Float8OID:
+			literals[i] = "0"
+		case pgtype.BoolOID:
+			literals[i] = "false"
+		case pgtype.TextOID, pgtype.VarcharOID, pgtype.BPCharOID, pgtype.NameOID:
+			literals[i] = "''"
+		case pgtype.DateOID:
+			literals[i] = "'1970-01-01'::date"
+		case pgtype.TimestampOID:
+			literals[i] = "'1970-01-01 00:00:00'::timestamp"
+		case pgtype.TimestamptzOID:
+			literals[i] = "'1970-01-01 00:00:00'::timestamptz"
+		case pgtype.TimeOID:
+			literals[i] = "'00:00:00'::time"
+		default:
+			literals[i] = "NULL"
+		}
+	}
+	return literals, nil
+}
+
+// planNode is a node of PostgreSQL's EXPLAIN (FORMAT JSON) output
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	Operation    string     `json:"Operation"`
+	Parent       string     `json:"Parent Relationship"`
+	RelationName string     `json:"Relation Name"`
+	Alias        string     `json:"Alias"`
+	IndexName    string     `json:"Index Name"`
+	IndexCond    string     `json:"Index Cond"`
+	Filter       string     `json:"Filter"`
+	PlanRows     float64    `json:"Plan Rows"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// traditionalPlan builds the rows of MySQL's traditional EXPLAIN, one per table read,
+// from a JSON plan. Subplans are numbered as SUBQUERY, the scans of an UPDATE or DELETE
+// take its statement type and a plan without tables is reported as such
+//
+//	Seq Scan on t, Filter -> 1 SIMPLE t ALL ... Using where
+func traditionalPlan(plan []byte) ([][]interface{}, error) {
+	var plans []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &plans); err != nil {
+		return nil, err
+	}
+
+	var rows [][]interface{}
+	id := 1
+	var walk func(node planNode, nodeID int64, selectType string)
+	walk = func(node planNode, nodeID int64, selectType string) {
+		if node.Parent == "SubPlan" || node.Parent == "InitPlan" {
+			id++
+			nodeID, selectType = int64(id), "SUBQUERY"
+		}
+		if node.NodeType == "ModifyTable" {
+			selectType = strings.ToUpper(node.Operation)
+			if node.Operation == "Insert" {
+				rows = append(rows, explainRow(nodeID, selectType, node, "ALL", "", nil))
+			}
+		}
+
+		switch node.NodeType {
+		case "Seq Scan":
+			rows = append(rows, explainRow(nodeID, selectType, node, "ALL", "", node.PlanRows))
+			return
+		case "Index Scan", "Index Only Scan":
+			rows = append(rows, explainRow(nodeID, selectType, node, indexAccess(node.IndexCond), node.IndexName, node.PlanRows))
+			return
+		case "Bitmap Heap Scan":
+			index := ""
+			if len(node.Plans) > 0 {
+				index = node.Plans[0].IndexName
+			}
+			rows = append(rows, explainRow(nodeID, selectType, node, "range", index, node.PlanRows))
+			return
+		}
+		for _, child := range node.Plans {
+			walk(child, nodeID, selectType)
+		}
+	}
+	for _, p := range plans {
+		walk(p.Plan, 1, "SIMPLE")
+	}
+
+	if len(rows) == 0 {
+		return [][]interface{}{{int64(1), "SIMPLE", nil, nil, nil, nil, nil, nil, nil, nil, nil, "No tables used"}}, nil
+	}
+	return rows, nil
+}
+
+// indexAccess maps an index condition to MySQL's access type, ranges for inequalities
+// and ref for lookups of a value
+func indexAccess(cond string) string {
+	if cond == "" {
+		return "index"
+	}
+	if strings.ContainsAny(cond, "<>") {
+		return "range"
+	}
+	return "ref"
+}
+
+func explainRow(id int64, selectType string, node planNode, access, index string, planRows interface{}) []interface{} {
+	table := node.Alias
+	if table == "" {
+		table = node.RelationName
+	}
+	var key, rows interface{}
+	if index != "" {
+		key = index
+	}
+	if planRows != nil {
+		rows = int64(planRows.(float64))
+	}
+
+	var extra []string
+	if node.Filter != "" {
+		extra = append(extra, "Using where")
+	}
+	if node.NodeType == "Index Only Scan" {
+		extra = append(extra, "Using index")
+	}
+	var extraValue interface{}
+	if len(extra) > 0 {
+		extraValue = strings.Join(extra, "; ")
+	}
+	return []interface{}{id, selectType, table, nil, access, key, key, nil, nil, rows, "100.00", extraValue}
+}
@@ -0,0 +1,118 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const indexPlan = `[{"Plan": {"Node Type": "Index Scan", "Relation Name": "t", "Alias": "t",
+	"Index Name": "t_pkey", "Index Cond": "(id = $1)", "Plan Rows": 1}}]`
+
+// newPlanBackend attaches a fake PostgreSQL backend of version to ch that answers every
+// query with plan and describes statements with an int4 and a text parameter
+func newPlanBackend(t *testing.T, ch *ConnectionHandler, version, plan string) *fakePGBackend {
+	planColumn := &pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+		{Name: []byte("QUERY PLAN"), DataTypeOID: pgtype.JSONOID, DataTypeSize: -1}}}
+	params := map[string]string{"server_version": version}
+	return newFakePGBackend(t, ch, params, func(b *fakePGBackend, backend *pgproto3.Backend, msg pgproto3.FrontendMessage) {
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			b.record(msg.String)
+			backend.Send(planColumn)
+			backend.Send(&pgproto3.DataRow{Values: [][]byte{[]byte(plan)}})
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("EXPLAIN")})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		case *pgproto3.Parse:
+			b.record("PARSE " + msg.Query)
+			backend.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: []uint32{pgtype.Int4OID, pgtype.TextOID}})
+			backend.Send(planColumn)
+		case *pgproto3.Sync:
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		}
+	})
+}
+
+func TestExplainParameterizedSelect(t *testing.T) {
+	const query = "EXPLAIN SELECT * FROM t WHERE id = ? AND name = ?"
+
+	explain := func(t *testing.T, version string) ([]string, []interface{}) {
+		h := newTestHandler(t)
+		ch := newTestConnection(t, h)
+		backend := newPlanBackend(t, ch, version, indexPlan)
+
+		result, err := ch.HandleQuery(query)
+		require.NoError(t, err)
+		require.NotNil(t, result.Resultset)
+		require.Len(t, result.Resultset.RowDatas, 1)
+		values, err := result.Resultset.RowDatas[0].ParseText(result.Resultset.Fields, nil)
+		require.NoError(t, err)
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = v.Value()
+		}
+		return backend.take(), row
+	}
+
+	t.Run("GENERIC_PLAN on PostgreSQL 16", func(t *testing.T) {
+		queries, row := explain(t, "16.2")
+		assert.Equal(t, []string{`EXPLAIN (GENERIC_PLAN, FORMAT JSON) SELECT * FROM "t" WHERE "id"=$1 AND "name"=$2`}, queries)
+		assert.Equal(t, []interface{}{int64(1), []byte("SIMPLE"), []byte("t"), nil, []byte("ref"), []byte("t_pkey"),
+			[]byte("t_pkey"), nil, nil, int64(1), []byte("100.00"), nil}, row)
+	})
+
+	t.Run("Dummy literals before 16", func(t *testing.T) {
+		queries, row := explain(t, "15.6 (Debian 15.6-1)")
+		assert.Equal(t, []string{
+			`PARSE EXPLAIN (FORMAT JSON) SELECT * FROM "t" WHERE "id"=$1 AND "name"=$2`,
+			`EXPLAIN (FORMAT JSON) SELECT * FROM "t" WHERE "id"=0 AND "name"=''`,
+		}, queries)
+		assert.Equal(t, []byte("t_pkey"), row[6])
+	})
+
+	t.Run("Prepared", func(t *testing.T) {
+		h := newTestHandler(t)
+		ch := newTestConnection(t, h)
+		backend := newPlanBackend(t, ch, "16.2", indexPlan)
+
+		_, columns, id, err := ch.HandleStmtPrepare(query)
+		require.NoError(t, err)
+		assert.Equal(t, 1, columns)
+		result, err := ch.HandleStmtExecute(id, query, []interface{}{int64(7), []byte("ann")})
+		require.NoError(t, err)
+		require.Len(t, result.Resultset.RowDatas, 1)
+		assert.Equal(t, []string{`EXPLAIN (FORMAT JSON) SELECT * FROM "t" WHERE "id"='7' AND "name"='ann'`}, backend.take())
+	})
+}
+
+func TestTraditionalPlan(t *testing.T) {
+	plan := `[{"Plan": {"Node Type": "Nested Loop", "Plans": [
+		{"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "orders", "Alias": "o",
+			"Filter": "(total > 10)", "Plan Rows": 200},
+		{"Node Type": "Bitmap Heap Scan", "Parent Relationship": "Inner", "Relation Name": "users", "Alias": "u", "Plan Rows": 5,
+			"Plans": [{"Node Type": "Bitmap Index Scan", "Index Name": "users_age_idx", "Plan Rows": 5}]},
+		{"Node Type": "Index Only Scan", "Parent Relationship": "SubPlan", "Relation Name": "items", "Alias": "items",
+			"Index Name": "items_pkey", "Index Cond": "(id > 3)", "Filter": "(qty = 1)", "Plan Rows": 3}
+	]}}]`
+	rows, err := traditionalPlan([]byte(plan))
+	require.NoError(t, err)
+	assert.Equal(t, [][]interface{}{
+		{int64(1), "SIMPLE", "o", nil, "ALL", nil, nil, nil, nil, int64(200), "100.00", "Using where"},
+		{int64(1), "SIMPLE", "u", nil, "range", "users_age_idx", "users_age_idx", nil, nil, int64(5), "100.00", nil},
+		{int64(2), "SUBQUERY", "items", nil, "range", "items_pkey", "items_pkey", nil, nil, int64(3), "100.00", "Using where; Using index"},
+	}, rows)
+
+	rows, err = traditionalPlan([]byte(`[{"Plan": {"Node Type": "ModifyTable", "Operation": "Update", "Relation Name": "t", "Alias": "t",
+		"Plans": [{"Node Type": "Seq Scan", "Relation Name": "t", "Alias": "t", "Plan Rows": 10}]}}]`))
+	require.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{int64(1), "UPDATE", "t", nil, "ALL", nil, nil, nil, nil, int64(10), "100.00", nil}}, rows)
+
+	rows, err = traditionalPlan([]byte(`[{"Plan": {"Node Type": "Result", "Plan Rows": 1}}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "No tables used", rows[0][11])
+}
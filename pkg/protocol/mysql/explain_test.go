@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainRewrite(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+	h.SetExplainRewrite(true)
+
+	// No PostgreSQL backend is needed, nothing is executed
+	result, err := ch.HandleQuery("SELECT aproxy_explain_rewrite('SELECT IFNULL(name, ''x'') FROM users')")
+	require.NoError(t, err)
+	require.NotNil(t, result.Resultset)
+
+	var steps [][2]string
+	for _, row := range result.Resultset.RowDatas {
+		values, err := row.ParseText(result.Resultset.Fields, nil)
+		require.NoError(t, err)
+		steps = append(steps, [2]string{string(values[0].AsString()), string(values[1].AsString())})
+	}
+	require.Len(t, steps, 4)
+	assert.Equal(t, [2]string{"original", "SELECT IFNULL(name, 'x') FROM users"}, steps[0])
+	assert.Equal(t, [2]string{"statement_type", "SELECT"}, steps[1])
+	assert.Equal(t, [2]string{"function", "IFNULL() -> COALESCE()"}, steps[2])
+	assert.Equal(t, "rewritten", steps[3][0])
+	assert.Contains(t, steps[3][1], "COALESCE")
+}
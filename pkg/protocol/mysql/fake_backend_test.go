@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePGBackend is a fake PostgreSQL backend serving the connection of one handler.
+// The tests decide what it answers, the queries they record are returned by take
+type fakePGBackend struct {
+	mu       sync.Mutex
+	queries  []string
+	listener net.Listener
+	server   net.Conn
+}
+
+// fakePGHandler answers a message the fake backend received, what it sends is flushed
+// once it returns. Messages it does not answer are ignored
+type fakePGHandler func(b *fakePGBackend, backend *pgproto3.Backend, msg pgproto3.FrontendMessage)
+
+// newFakePGBackend attaches a connection to a fake backend answering with handle to ch,
+// using the simple protocol like the proxy's pools. params are reported at startup
+// besides the settings pgx needs to interpolate simple protocol arguments
+func newFakePGBackend(t testing.TB, ch *ConnectionHandler, params map[string]string, handle fakePGHandler) *fakePGBackend {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &fakePGBackend{listener: listener}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- c
+		defer c.Close()
+
+		backend := pgproto3.NewBackend(c, c)
+		if _, err := backend.ReceiveStartupMessage(); err != nil {
+			return
+		}
+		backend.Send(&pgproto3.AuthenticationOk{})
+		backend.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+		backend.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+		for name, value := range params {
+			backend.Send(&pgproto3.ParameterStatus{Name: name, Value: value})
+		}
+		backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		if backend.Flush() != nil {
+			return
+		}
+		for {
+			msg, err := backend.Receive()
+			if err != nil {
+				return
+			}
+			handle(b, backend, msg)
+			if backend.Flush() != nil {
+				return
+			}
+		}
+	}()
+
+	conn, err := pgx.Connect(context.Background(),
+		fmt.Sprintf("postgres://test@%s/test?sslmode=disable&default_query_exec_mode=simple_protocol", listener.Addr()))
+	require.NoError(t, err)
+	b.server = <-accepted
+	ch.attachPGConn(conn)
+	t.Cleanup(func() {
+		// The test handler has no pool to release the connection to
+		ch.pgConn = nil
+		conn.Close(context.Background())
+		b.stop()
+	})
+	return b
+}
+
+// stop takes the backend down, breaking the handler's connection
+func (b *fakePGBackend) stop() {
+	b.listener.Close()
+	b.server.Close()
+}
+
+// record remembers a query for take
+func (b *fakePGBackend) record(query string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queries = append(b.queries, query)
+}
+
+// take returns the recorded queries and forgets them
+func (b *fakePGBackend) take() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	queries := b.queries
+	b.queries = nil
+	return queries
+}
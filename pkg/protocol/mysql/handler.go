@@ -2,9 +2,12 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"aproxy/internal/pool"
@@ -21,27 +24,46 @@ import (
 )
 
 type Handler struct {
-	pgPool       *pool.Pool
-	sessionMgr   *session.Manager
-	rewriter     *sqlrewrite.Rewriter
-	typeMapper   *mapper.TypeMapper
-	errorMapper  *mapper.ErrorMapper
-	showEmulator *mapper.ShowEmulator
-	metrics      *observability.Metrics
-	logger       *observability.Logger
-	debugSQL     bool
+	pgRouter       *pool.Router
+	sessionMgr     *session.Manager
+	rewriter       *sqlrewrite.Rewriter
+	typeMapper     *mapper.TypeMapper
+	errorMapper    *mapper.ErrorMapper
+	showEmulator   *mapper.ShowEmulator
+	metrics        *observability.Metrics
+	logger         *observability.Logger
+	debugSQL       atomic.Bool
+	dryRun         atomic.Bool
+	safeMode       atomic.Bool
+	explainRewrite atomic.Bool
+
+	serializationRetries int
+	credentials          map[string]string // user -> password, checked on COM_CHANGE_USER
+	requireSSL           map[string]bool   // Users that may only log in over TLS
+	auditLogger          *observability.AuditLogger
+	handshake            handshakeOptions
+	resultLimit          resultLimit
+	backendPing          backendPing
+	zeroDates            zeroDates
+	insertBatching       insertBatching
+	maxPacketSize        int64
+
+	startTime time.Time
+	drain     *drainTracker
+	connsMu   sync.Mutex
+	conns     map[*ConnectionHandler]struct{}
 }
 
 func NewHandler(
-	pgPool *pool.Pool,
+	pgRouter *pool.Router,
 	sessionMgr *session.Manager,
 	rewriter *sqlrewrite.Rewriter,
 	metrics *observability.Metrics,
 	logger *observability.Logger,
 	debugSQL bool,
 ) *Handler {
-	return &Handler{
-		pgPool:       pgPool,
+	h := &Handler{
+		pgRouter:     pgRouter,
 		sessionMgr:   sessionMgr,
 		rewriter:     rewriter,
 		typeMapper:   mapper.NewTypeMapper(),
@@ -49,11 +71,52 @@ func NewHandler(
 		showEmulator: mapper.NewShowEmulator(),
 		metrics:      metrics,
 		logger:       logger,
-		debugSQL:     debugSQL,
+		startTime:    time.Now(),
+		drain:        newDrainTracker(),
+		conns:        make(map[*ConnectionHandler]struct{}),
+		handshake:    handshakeOptions{capabilities: defaultCapabilities},
 	}
+	if rewriter != nil {
+		h.showEmulator.SetDeclaredTypes(rewriter.DeclaredColumnTypes)
+	}
+	h.debugSQL.Store(debugSQL)
+	return h
+}
+
+// SetCredentials sets the users a connection may switch to with COM_CHANGE_USER
+// It must match the credentials used for the initial handshake
+func (h *Handler) SetCredentials(credentials map[string]string) {
+	h.credentials = credentials
+}
+
+// SetAuditLogger enables the audit log of executed statements
+func (h *Handler) SetAuditLogger(auditLogger *observability.AuditLogger) {
+	h.auditLogger = auditLogger
+}
+
+// SetSerializationRetries sets how many times a read outside an explicit
+// transaction is retried after a serialization failure or deadlock (0 disables)
+func (h *Handler) SetSerializationRetries(retries int) {
+	h.serializationRetries = retries
 }
 
-func (h *Handler) NewConnection(conn net.Conn) (server.Handler, error) {
+// SetDebugSQL toggles logging of original and rewritten SQL at runtime
+func (h *Handler) SetDebugSQL(enabled bool) {
+	h.debugSQL.Store(enabled)
+}
+
+// SetDryRun toggles dry-run mode for all sessions at runtime
+func (h *Handler) SetDryRun(enabled bool) {
+	h.dryRun.Store(enabled)
+}
+
+// SetSafeMode toggles validating rewritten statements before they run for all
+// sessions at runtime, see validateRewrite
+func (h *Handler) SetSafeMode(enabled bool) {
+	h.safeMode.Store(enabled)
+}
+
+func (h *Handler) NewConnection(conn net.Conn) (*ConnectionHandler, error) {
 	remoteAddr := conn.RemoteAddr().String()
 	host, _, _ := net.SplitHostPort(remoteAddr)
 
@@ -61,47 +124,179 @@ func (h *Handler) NewConnection(conn net.Conn) (server.Handler, error) {
 	h.sessionMgr.AddSession(sess)
 	h.metrics.IncActiveConnections()
 
-	return &ConnectionHandler{
+	ch := &ConnectionHandler{
 		handler: h,
 		session: sess,
-		conn:    conn,
-	}, nil
+		conn:    &handshakeConn{Conn: conn, opts: h.handshake, limit: packetLimit{max: h.maxPacketSize}},
+		pgPool:  h.pgRouter.Default(),
+	}
+	ch.conn.tooLarge = ch.packetTooLarge
+
+	h.connsMu.Lock()
+	h.conns[ch] = struct{}{}
+	h.connsMu.Unlock()
+
+	return ch, nil
+}
+
+// Drain marks the handler as draining and waits until in-flight queries finish
+// or ctx expires. New connections must be refused by the caller (closing the listener)
+func (h *Handler) Drain(ctx context.Context) error {
+	return h.drain.drain(ctx)
+}
+
+// Draining reports whether shutdown has started
+func (h *Handler) Draining() bool {
+	return h.drain.isDraining()
+}
+
+// InFlightQueries returns the number of queries currently executing
+func (h *Handler) InFlightQueries() int {
+	return h.drain.count()
+}
+
+// CloseConnections closes every client connection still open
+// The connection goroutines then fail their next read and release their sessions
+func (h *Handler) CloseConnections() int {
+	h.connsMu.Lock()
+	conns := make([]*ConnectionHandler, 0, len(h.conns))
+	for ch := range h.conns {
+		conns = append(conns, ch)
+	}
+	h.connsMu.Unlock()
+
+	for _, ch := range conns {
+		ch.conn.Close()
+	}
+	return len(conns)
 }
 
 type ConnectionHandler struct {
 	handler *Handler
 	session *session.Session
-	conn    net.Conn
+	conn    *handshakeConn
+	pgPool  *pool.Pool // Backend serving the current database
 	pgConn  *pgx.Conn
+
+	countedUser        string    // User holding a slot in the per-user connection limit
+	clientCapabilities uint32    // Flags from the client's handshake response
+	backendVerified    time.Time // Last time COM_PING found the backend alive
+	packetConn         packetConn
+	closeOnce          sync.Once
+	warnings           diagnostics // Notices of the last statement, see SHOW WARNINGS
+	pending            insertBatch // Executed INSERTs not sent yet, see insertBatching
+}
+
+var _ server.Handler = (*ConnectionHandler)(nil)
+
+// NetConn returns the client connection the MySQL server side must be created on
+func (ch *ConnectionHandler) NetConn() net.Conn {
+	return ch.conn
+}
+
+// SetUser records the authenticated user and counts the connection against the
+// per-user limit, returning ER_USER_LIMIT_REACHED when the user is already at it
+func (ch *ConnectionHandler) SetUser(user string) error {
+	if err := ch.switchCountedUser(user); err != nil {
+		return err
+	}
+	ch.session.User = user
+	return nil
 }
 
 func (ch *ConnectionHandler) UseDB(dbName string) error {
+	if err := ch.routeDatabase(dbName); err != nil {
+		return err
+	}
 	ch.session.Database = dbName
 
 	if ch.pgConn != nil {
+		// Batched INSERTs name their table relative to the current schema
+		if err := ch.flushInserts(); err != nil {
+			return err
+		}
 		ctx := context.Background()
-		_, err := ch.pgConn.Exec(ctx, fmt.Sprintf("SET search_path TO %s", dbName))
-		return err
+		if _, err := ch.pgConn.Exec(ctx, fmt.Sprintf("SET search_path TO %s", dbName)); err != nil {
+			return err
+		}
 	}
 
+	ch.trackSchema(dbName)
 	return nil
 }
 
 func (ch *ConnectionHandler) HandleQuery(query string) (*mysql.Result, error) {
+	ch.handler.drain.begin()
+	defer ch.handler.drain.done()
+
+	startTime := time.Now()
+	result, err := ch.handleQuery(query)
+	ch.audit(query, startTime, err)
+	ch.warnings.report(result)
+	return result, err
+}
+
+func (ch *ConnectionHandler) handleQuery(query string) (*mysql.Result, error) {
 	startTime := time.Now()
 	ch.handler.metrics.IncTotalQueries()
 
+	// The payload is the command byte followed by the query
+	if err := ch.checkPacketSize(1 + len(query)); err != nil {
+		return nil, err
+	}
+
+	query, dryRun, debugSQL := ch.applyDirectives(query)
+	query, safeMode := ch.applySafeMode(query)
+	query, raw := rawDirective(query)
+
+	// Resolve /*!NNNNN ... */ version comments and drop trailing semicolons before classifying the statement
+	// Comments in raw statements are PostgreSQL's
+	if raw {
+		query = sqlrewrite.TrimStatement(query)
+	} else {
+		query = sqlrewrite.TrimStatement(ch.handler.rewriter.StripComments(query))
+	}
+	if query == "" {
+		return &mysql.Result{Status: 0}, nil
+	}
+
+	// Every other statement starts with no warnings, as in MySQL
+	if showWarningsRe.MatchString(query) {
+		return ch.showWarnings(query)
+	}
+	ch.warnings.reset()
+
+	// Rows held back by insert batching go out before anything else, a rollback drops them
+	if ch.handler.rewriter.IsRollbackStatement(query) {
+		ch.discardInserts()
+	} else if err := ch.flushInserts(); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return ch.dryRunStatement(query, raw)
+	}
+
+	if ch.handler.explainRewrite.Load() && !raw {
+		if sql, ok := sqlrewrite.ParseExplainRewrite(query); ok {
+			return ch.explainRewrite(sql)
+		}
+	}
+
 	ctx := context.Background()
 
 	if ch.pgConn == nil {
-		conn, err := ch.handler.pgPool.AcquireForSession(ctx, ch.session.ID)
+		conn, err := ch.pgPool.AcquireForSession(ctx, ch.session.ID)
 		if err != nil {
 			ch.handler.metrics.IncErrors("connection")
 			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "connection", err)
 			return nil, err
 		}
-		ch.pgConn = conn
-		ch.session.SetPGConn(conn)
+		ch.attachPGConn(conn)
+	}
+
+	if raw {
+		return ch.handleRawQuery(ctx, query, startTime, debugSQL)
 	}
 
 	if ch.handler.rewriter.IsShowStatement(query) {
@@ -118,35 +313,61 @@ func (ch *ConnectionHandler) HandleQuery(query string) (*mysql.Result, error) {
 
 	// Handle transaction control statements
 	if ch.handler.rewriter.IsBeginStatement(query) {
+		wasInTransaction := ch.session.InTransaction
+		// BEGIN inside a transaction keeps it, READ ONLY applies to a new one only
+		if !wasInTransaction {
+			ch.session.SetNextTransaction("", mapper.StartTransactionAccessMode(query))
+		}
 		if err := ch.session.BeginTransaction(); err != nil {
 			ch.handler.metrics.IncErrors("transaction")
 			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "begin_transaction", err)
 			return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
 		}
+		ch.observeTransaction(wasInTransaction, "")
+		ch.trackTransactionState()
 		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, nil)
 		return &mysql.Result{Status: 0}, nil
 	}
 
 	if ch.handler.rewriter.IsCommitStatement(query) {
+		wasInTransaction := ch.session.InTransaction
 		if err := ch.session.CommitTransaction(); err != nil {
 			ch.handler.metrics.IncErrors("transaction")
 			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "commit_transaction", err)
 			return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
 		}
+		ch.observeTransaction(wasInTransaction, txCommitted)
+		ch.trackTransactionState()
 		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, nil)
 		return &mysql.Result{Status: 0}, nil
 	}
 
 	if ch.handler.rewriter.IsRollbackStatement(query) {
+		wasInTransaction := ch.session.InTransaction
 		if err := ch.session.RollbackTransaction(); err != nil {
 			ch.handler.metrics.IncErrors("transaction")
 			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "rollback_transaction", err)
 			return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
 		}
+		ch.observeTransaction(wasInTransaction, txRolledBack)
+		ch.trackTransactionState()
 		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, nil)
 		return &mysql.Result{Status: 0}, nil
 	}
 
+	if sqlrewrite.IsLoadDataStatement(query) {
+		return ch.handleLoadData(ctx, query, startTime)
+	}
+
+	// PostgreSQL has no user variables: INTO @var is split off here and filled by the proxy
+	query, intoVars, err := sqlrewrite.SplitSelectInto(query)
+	if err != nil {
+		ch.recordRewriteFailure(err)
+		return nil, mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, err.Error())
+	}
+
+	ch.loadTableColumns(query)
+
 	// Detect unsupported MySQL features before rewriting
 	unsupportedFeatures := ch.handler.rewriter.DetectUnsupported(query)
 	if len(unsupportedFeatures) > 0 {
@@ -174,68 +395,66 @@ func (ch *ConnectionHandler) HandleQuery(query string) (*mysql.Result, error) {
 		}
 	}
 
-	rewrittenSQL, err := ch.handler.rewriter.Rewrite(query)
+	stmt, err := ch.handler.rewriter.RewriteStatement(query, ch.session.Variables())
 	if err != nil {
-		ch.handler.metrics.IncErrors("rewrite")
-		return nil, err
+		ch.recordRewriteFailure(err)
+		return nil, rewriteFailureError(err)
 	}
+	rewrittenSQL := stmt.SQL
 
 	// Debug SQL logging if enabled
-	if ch.handler.debugSQL {
-		wasRewritten := query != rewrittenSQL
-		ch.handler.logger.Info("SQL Debug",
-			zap.String("mysql", query),
-			zap.String("pg", rewrittenSQL),
-			zap.Bool("rewritten", wasRewritten))
+	if debugSQL {
+		ch.logRewrite(query, rewrittenSQL)
+	}
+
+	if safeMode {
+		if err := ch.validateRewrite(ctx, query, stmt.Type, rewrittenSQL, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(intoVars) > 0 {
+		return ch.selectIntoUserVars(ctx, query, rewrittenSQL, intoVars, startTime)
+	}
+
+	if stmt.Explain != nil {
+		result, err := ch.explainStatement(ctx, stmt.Explain, false, nil)
+		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
+		return result, err
 	}
 
 	// Check if this is a DDL statement (CREATE, DROP, ALTER, etc.) or DML with no result set
+	// The type comes from the AST, so WITH ... DELETE is a write and RETURNING makes it a query
 	upperQuery := strings.ToUpper(strings.TrimSpace(query))
-	isDDL := strings.HasPrefix(upperQuery, "CREATE") ||
-		strings.HasPrefix(upperQuery, "DROP") ||
-		strings.HasPrefix(upperQuery, "ALTER") ||
-		strings.HasPrefix(upperQuery, "TRUNCATE") ||
-		strings.HasPrefix(upperQuery, "DELETE") ||
-		strings.HasPrefix(upperQuery, "UPDATE") ||
-		strings.HasPrefix(upperQuery, "INSERT")
+	isDDL := stmt.Type.IsWrite() && !stmt.Returning
 
 	if isDDL {
 		var lastInsertID uint64
 		var rowsAffected int64
 
 		// Special handling for INSERT to get last insert ID
-		if strings.HasPrefix(upperQuery, "INSERT") {
+		if stmt.Type == sqlrewrite.StatementInsert {
 			// Check if this table has an AUTO_INCREMENT column
 			tableName := extractInsertTableName(query)
 			autoIncrColumn := ch.session.GetAutoIncrementColumn(tableName)
 
-			if autoIncrColumn != "" && !strings.Contains(strings.ToUpper(rewrittenSQL), "RETURNING") {
+			if autoIncrColumn != "" {
 				// Table has AUTO_INCREMENT, use RETURNING to get the inserted ID
 				returningSQL := rewrittenSQL + " RETURNING " + autoIncrColumn
 				rows, err := ch.pgConn.Query(ctx, returningSQL)
 				if err != nil {
 					ch.handler.metrics.IncErrors("query")
-					errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
 					ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
-					return nil, mysql.NewError(errorCode, errorMsg)
-				}
-				defer rows.Close()
-				// Get the returned ID
-				if rows.Next() {
-					var id int64
-					if err := rows.Scan(&id); err == nil {
-						lastInsertID = uint64(id)
-					}
+					return nil, ch.handler.errorMapper.MySQLError(err)
 				}
-				rowsAffected = 1 // INSERT with RETURNING always affects 1 row if successful
+				lastInsertID, rowsAffected = insertedIDs(rows, stmt.FirstGeneratedRow)
 			} else {
-				// Table doesn't have AUTO_INCREMENT or already has RETURNING, just execute
+				// Table doesn't have AUTO_INCREMENT, just execute
 				cmdTag, err := ch.pgConn.Exec(ctx, rewrittenSQL)
 				if err != nil {
 					ch.handler.metrics.IncErrors("query")
-					errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
 					ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
-					return nil, mysql.NewError(errorCode, errorMsg)
+					return nil, ch.handler.errorMapper.MySQLError(err)
 				}
 				rowsAffected = cmdTag.RowsAffected()
 			}
@@ -244,9 +463,8 @@ func (ch *ConnectionHandler) HandleQuery(query string) (*mysql.Result, error) {
 			cmdTag, err := ch.pgConn.Exec(ctx, rewrittenSQL)
 			if err != nil {
 				ch.handler.metrics.IncErrors("query")
-				errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
 				ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
-				return nil, mysql.NewError(errorCode, errorMsg)
+				return nil, ch.handler.errorMapper.MySQLError(err)
 			}
 			rowsAffected = cmdTag.RowsAffected()
 
@@ -269,9 +487,8 @@ func (ch *ConnectionHandler) HandleQuery(query string) (*mysql.Result, error) {
 					schema.GetGlobalCache().InvalidateTable(ch.session.Database, tableName)
 				}
 			} else if strings.HasPrefix(upperQuery, "DROP TABLE") {
-				// DROP TABLE removes the table
-				tableName := extractDropTableName(query)
-				if tableName != "" {
+				// DROP TABLE removes the tables
+				for _, tableName := range extractDropTableNames(query) {
 					schema.GetGlobalCache().InvalidateTable(ch.session.Database, tableName)
 				}
 			}
@@ -291,20 +508,16 @@ func (ch *ConnectionHandler) HandleQuery(query string) (*mysql.Result, error) {
 	}
 
 	// Use Query for SELECT statements
-	rows, err := ch.pgConn.Query(ctx, rewrittenSQL)
+	// Use Text Protocol for regular queries
+	result, converting, err := ch.queryResult(ctx, upperQuery, rewrittenSQL, false)
 	if err != nil {
+		if converting {
+			ch.handler.metrics.IncErrors("result_conversion")
+			return nil, err
+		}
 		ch.handler.metrics.IncErrors("query")
-		errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
 		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
-		return nil, mysql.NewError(errorCode, errorMsg)
-	}
-	defer rows.Close()
-
-	// Use Text Protocol for regular queries
-	result, err := ch.buildMySQLResult(rows, false)
-	if err != nil {
-		ch.handler.metrics.IncErrors("result_conversion")
-		return nil, err
+		return nil, ch.handler.errorMapper.MySQLError(err)
 	}
 
 	duration := time.Since(startTime).Seconds()
@@ -315,6 +528,17 @@ func (ch *ConnectionHandler) HandleQuery(query string) (*mysql.Result, error) {
 		// Use RowDatas length, not Values, because BuildSimpleResultset doesn't populate Values
 		rowCount = int64(len(result.Resultset.RowDatas))
 	}
+	for _, counter := range stmt.Counters {
+		// Counters of derived tables and UNION members number their own rows
+		numbered := rowCount
+		if counter.CountSQL != "" {
+			if err := ch.pgConn.QueryRow(ctx, counter.CountSQL).Scan(&numbered); err != nil {
+				ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "row_counter", err)
+				continue
+			}
+		}
+		ch.session.SetUserVar(counter.Name, counter.After(numbered))
+	}
 	ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, duration, rowCount, nil)
 
 	return result, nil
@@ -325,14 +549,13 @@ func (ch *ConnectionHandler) HandleFieldList(table string, wildcard string) ([]*
 
 	// Ensure we have a PostgreSQL connection
 	if ch.pgConn == nil {
-		conn, err := ch.handler.pgPool.AcquireForSession(ctx, ch.session.ID)
+		conn, err := ch.pgPool.AcquireForSession(ctx, ch.session.ID)
 		if err != nil {
 			ch.handler.metrics.IncErrors("connection")
 			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "connection", err)
 			return nil, err
 		}
-		ch.pgConn = conn
-		ch.session.SetPGConn(conn)
+		ch.attachPGConn(conn)
 	}
 
 	query := fmt.Sprintf(`
@@ -371,23 +594,53 @@ func (ch *ConnectionHandler) HandleFieldList(table string, wildcard string) ([]*
 }
 
 func (ch *ConnectionHandler) HandleStmtPrepare(query string) (int, int, interface{}, error) {
+	if err := ch.checkPacketSize(1 + len(query)); err != nil {
+		return 0, 0, nil, err
+	}
 	ctx := context.Background()
+	query, debugSQL := ch.applyDebugDirective(query)
+	query, safeMode := ch.applySafeMode(query)
+	query, raw := rawDirective(query)
+	if raw {
+		query = sqlrewrite.TrimStatement(query)
+	} else {
+		query = sqlrewrite.TrimStatement(ch.handler.rewriter.StripComments(query))
+	}
 
 	// Ensure we have a PostgreSQL connection
 	if ch.pgConn == nil {
-		conn, err := ch.handler.pgPool.AcquireForSession(ctx, ch.session.ID)
+		conn, err := ch.pgPool.AcquireForSession(ctx, ch.session.ID)
 		if err != nil {
 			ch.handler.metrics.IncErrors("connection")
 			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "connection", err)
 			return 0, 0, nil, err
 		}
-		ch.pgConn = conn
-		ch.session.SetPGConn(conn)
+		ch.attachPGConn(conn)
 	}
 
-	rewrittenSQL, paramCount, err := ch.handler.rewriter.RewritePrepared(query)
-	if err != nil {
-		return 0, 0, nil, err
+	var rewritten *sqlrewrite.Statement
+	var paramCount int
+	if raw {
+		rewritten, paramCount = sqlrewrite.RawPrepared(query)
+	} else {
+		var err error
+		ch.loadTableColumns(query)
+		rewritten, paramCount, err = ch.handler.rewriter.RewritePreparedStatement(query, ch.session.Variables())
+		if err != nil {
+			ch.recordRewriteFailure(err)
+			return 0, 0, nil, rewriteFailureError(err)
+		}
+	}
+	rewrittenSQL := rewritten.SQL
+
+	if debugSQL {
+		ch.logRewrite(query, rewrittenSQL)
+	}
+
+	if !raw && safeMode {
+		if err := ch.validateRewrite(ctx, query, rewritten.Type, rewrittenSQL, paramCount); err != nil {
+			return 0, 0, nil, err
+		}
 	}
 
 	stmtID := uint32(ch.session.GetPreparedStatementCount() + 1)
@@ -404,18 +657,25 @@ func (ch *ConnectionHandler) HandleStmtPrepare(query string) (int, int, interfac
 		strings.HasPrefix(trimmedUpper, "SHOW") ||
 		strings.HasPrefix(trimmedUpper, "EXPLAIN") ||
 		strings.HasPrefix(trimmedUpper, "DESCRIBE") ||
-		strings.HasPrefix(trimmedUpper, "DESC") {
+		strings.HasPrefix(trimmedUpper, "DESC") ||
+		rewritten.Returning {
 		// Use 1 as a placeholder - go-mysql will send placeholder column metadata
 		// The actual columns will be sent during EXECUTE
 		columnCount = 1
 	}
 
 	stmt := &session.PreparedStatement{
-		ID:          stmtID,
-		SQL:         rewrittenSQL,
-		OriginalSQL: query,
-		PGName:      "", // Not using named prepared statements
-		ParamCount:  paramCount,
+		ID:                stmtID,
+		SQL:               rewrittenSQL,
+		OriginalSQL:       query,
+		PGName:            "", // Not using named prepared statements
+		ParamCount:        paramCount,
+		Type:              rewritten.Type,
+		Returning:         rewritten.Returning,
+		FirstGeneratedRow: rewritten.FirstGeneratedRow,
+		SingleRowInsert:   rewritten.SingleRowInsert,
+		Explain:           rewritten.Explain,
+		SelectParams:      rewritten.SelectParams,
 	}
 
 	ch.session.AddPreparedStatement(stmt)
@@ -424,6 +684,17 @@ func (ch *ConnectionHandler) HandleStmtPrepare(query string) (int, int, interfac
 }
 
 func (ch *ConnectionHandler) HandleStmtExecute(data interface{}, query string, args []interface{}) (*mysql.Result, error) {
+	ch.handler.drain.begin()
+	defer ch.handler.drain.done()
+
+	startTime := time.Now()
+	result, err := ch.handleStmtExecute(data, args)
+	ch.audit(query, startTime, err)
+	ch.warnings.report(result)
+	return result, err
+}
+
+func (ch *ConnectionHandler) handleStmtExecute(data interface{}, args []interface{}) (*mysql.Result, error) {
 	stmtID, ok := data.(uint32)
 	if !ok {
 		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, "invalid statement ID type")
@@ -434,25 +705,24 @@ func (ch *ConnectionHandler) HandleStmtExecute(data interface{}, query string, a
 		return nil, mysql.NewError(mysql.ER_UNKNOWN_STMT_HANDLER, "Unknown prepared statement")
 	}
 
+	ch.warnings.reset()
+
 	ctx := context.Background()
 
 	// Ensure we have a PostgreSQL connection
 	if ch.pgConn == nil {
-		conn, err := ch.handler.pgPool.AcquireForSession(ctx, ch.session.ID)
+		conn, err := ch.pgPool.AcquireForSession(ctx, ch.session.ID)
 		if err != nil {
 			ch.handler.metrics.IncErrors("connection")
 			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "connection", err)
 			return nil, err
 		}
-		ch.pgConn = conn
-		ch.session.SetPGConn(conn)
+		ch.attachPGConn(conn)
 	}
 
 	// Check if this is a DML statement that doesn't return rows
-	upperQuery := strings.ToUpper(strings.TrimSpace(stmt.OriginalSQL))
-	isDML := strings.HasPrefix(upperQuery, "INSERT") ||
-		strings.HasPrefix(upperQuery, "UPDATE") ||
-		strings.HasPrefix(upperQuery, "DELETE")
+	// The type comes from the AST, so WITH ... DELETE is a write and RETURNING makes it a query
+	isDML := stmt.Type.IsWrite() && !stmt.Returning
 
 	// Convert MySQL-encoded parameters to PostgreSQL-compatible format
 	// MySQL client may send time.Time as binary-encoded bytes, but PostgreSQL expects strings
@@ -464,14 +734,22 @@ func (ch *ConnectionHandler) HandleStmtExecute(data interface{}, query string, a
 			// Use format compatible with PostgreSQL's timestamp/date parsing
 			convertedArgs[i] = v.Format("2006-01-02 15:04:05")
 		case []byte:
-			// MySQL might send dates/timestamps as byte arrays
-			// Try to convert to string for PostgreSQL
-			convertedArgs[i] = string(v)
+			// MySQL sends strings, dates and blobs as byte arrays, see stmtArg
+			convertedArgs[i] = stmtArg(v)
 		default:
 			convertedArgs[i] = arg
 		}
 	}
 
+	if stmt.Explain != nil {
+		return ch.explainStatement(ctx, stmt.Explain, true, convertedArgs)
+	}
+
+	// Consecutive single-row INSERTs in a transaction may be sent as one, see insertBatching
+	if result, batched, err := ch.batchInsert(stmt, convertedArgs); batched || err != nil {
+		return result, err
+	}
+
 	startTime := time.Now()
 
 	if isDML {
@@ -479,34 +757,24 @@ func (ch *ConnectionHandler) HandleStmtExecute(data interface{}, query string, a
 		var rowsAffected int64
 
 		// Special handling for INSERT to get last insert ID
-		if strings.HasPrefix(upperQuery, "INSERT") {
+		if stmt.Type == sqlrewrite.StatementInsert {
 			// Check if this table has an AUTO_INCREMENT column
 			tableName := extractInsertTableName(stmt.OriginalSQL)
 			autoIncrColumn := ch.session.GetAutoIncrementColumn(tableName)
 
-			if autoIncrColumn != "" && !strings.Contains(strings.ToUpper(stmt.SQL), "RETURNING") {
+			if autoIncrColumn != "" {
 				// Table has AUTO_INCREMENT, use RETURNING to get the inserted ID
 				returningSQL := stmt.SQL + " RETURNING " + autoIncrColumn
 				rows, err := ch.pgConn.Query(ctx, returningSQL, convertedArgs...)
 				if err != nil {
-					errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
-					return nil, mysql.NewError(errorCode, errorMsg)
-				}
-				defer rows.Close()
-				// Get the returned ID
-				if rows.Next() {
-					var id int64
-					if err := rows.Scan(&id); err == nil {
-						lastInsertID = uint64(id)
-					}
+					return nil, ch.handler.errorMapper.MySQLError(err)
 				}
-				rowsAffected = 1 // INSERT with RETURNING always affects 1 row if successful
+				lastInsertID, rowsAffected = insertedIDs(rows, stmt.FirstGeneratedRow)
 			} else {
-				// Table doesn't have AUTO_INCREMENT or already has RETURNING, just execute
+				// Table doesn't have AUTO_INCREMENT, just execute
 				cmdTag, err := ch.pgConn.Exec(ctx, stmt.SQL, convertedArgs...)
 				if err != nil {
-					errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
-					return nil, mysql.NewError(errorCode, errorMsg)
+					return nil, ch.handler.errorMapper.MySQLError(err)
 				}
 				rowsAffected = cmdTag.RowsAffected()
 			}
@@ -514,8 +782,7 @@ func (ch *ConnectionHandler) HandleStmtExecute(data interface{}, query string, a
 			// Execute non-INSERT DML statements normally
 			cmdTag, err := ch.pgConn.Exec(ctx, stmt.SQL, convertedArgs...)
 			if err != nil {
-				errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
-				return nil, mysql.NewError(errorCode, errorMsg)
+				return nil, ch.handler.errorMapper.MySQLError(err)
 			}
 			rowsAffected = cmdTag.RowsAffected()
 		}
@@ -533,17 +800,15 @@ func (ch *ConnectionHandler) HandleStmtExecute(data interface{}, query string, a
 	}
 
 	// Use Query for SELECT statements
-	rows, err := ch.pgConn.Query(ctx, stmt.SQL, convertedArgs...)
-	if err != nil {
-		errorCode, errorMsg := ch.handler.errorMapper.MapError(err)
-		return nil, mysql.NewError(errorCode, errorMsg)
-	}
-	defer rows.Close()
-
 	// CRITICAL: Use Binary Protocol for PreparedStatement results
-	result, err := ch.buildMySQLResult(rows, true)
+	// SELECT ? returns the type of the bound value, as in MySQL
+	sql := sqlrewrite.CastSelectParams(stmt.SQL, stmt.SelectParams, args)
+	result, converting, err := ch.queryResult(ctx, strings.ToUpper(strings.TrimSpace(stmt.OriginalSQL)), sql, true, convertedArgs...)
 	if err != nil {
-		return nil, err
+		if converting {
+			return nil, err
+		}
+		return nil, ch.handler.errorMapper.MySQLError(err)
 	}
 
 	duration := time.Since(startTime).Seconds()
@@ -581,9 +846,14 @@ func (ch *ConnectionHandler) HandleStmtClose(data interface{}) error {
 func (ch *ConnectionHandler) HandleOtherCommand(cmd byte, data []byte) error {
 	switch cmd {
 	case mysql.COM_PING:
-		return nil
+		return ch.handlePing()
 	case mysql.COM_INIT_DB:
 		return ch.UseDB(string(data))
+	case mysql.COM_CHANGE_USER:
+		return ch.handleChangeUser(data)
+	case mysql.COM_STATISTICS:
+		ch.conn.reply = []byte(ch.handler.statistics())
+		return nil
 	case mysql.COM_QUIT:
 		return ch.Close()
 	default:
@@ -591,17 +861,66 @@ func (ch *ConnectionHandler) HandleOtherCommand(cmd byte, data []byte) error {
 	}
 }
 
+// Close releases the session; safe to call more than once (COM_QUIT, connection
+// teardown and shutdown may all close the same connection)
 func (ch *ConnectionHandler) Close() error {
-	ch.handler.metrics.DecActiveConnections()
-	ch.handler.sessionMgr.RemoveSession(ch.session.ID)
+	ch.closeOnce.Do(func() {
+		ch.handler.metrics.DecActiveConnections()
+		if ch.session.InTransaction {
+			// Closing the session rolls the transaction back
+			ch.endTransaction(txRolledBack)
+		}
+		ch.handler.sessionMgr.RemoveSession(ch.session.ID)
 
-	if ch.pgConn != nil {
-		ch.handler.pgPool.ReleaseForSession(ch.session.ID)
-	}
+		ch.handler.connsMu.Lock()
+		delete(ch.handler.conns, ch)
+		ch.handler.connsMu.Unlock()
+
+		if ch.pgConn != nil {
+			pool.OnNotice(ch.pgConn.PgConn(), nil)
+			ch.pgPool.ReleaseForSession(ch.session.ID)
+		}
+		if ch.countedUser != "" {
+			ch.handler.sessionMgr.ReleaseUser(ch.countedUser)
+		}
+	})
 
 	return nil
 }
 
+// queryResult runs a statement returning rows and builds the MySQL result set
+// Reads outside an explicit transaction are retried on serialization failures,
+// inside a transaction PostgreSQL has already aborted it so the error is surfaced.
+// converting reports whether err came from building the result set
+func (ch *ConnectionHandler) queryResult(ctx context.Context, upperQuery, sql string, binary bool, args ...interface{}) (*mysql.Result, bool, error) {
+	retries := 0
+	if strings.HasPrefix(upperQuery, "SELECT") && !ch.session.InTransaction {
+		retries = ch.handler.serializationRetries
+	}
+
+	var result *mysql.Result
+	converting := false
+	err := retrySerializationFailure(retries, func() error {
+		converting = false
+		rows, err := ch.pgConn.Query(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		result, err = ch.buildMySQLResult(rows, binary)
+		converting = err != nil
+		return err
+	}, func(attempt int, err error) {
+		ch.handler.logger.Warn("Retrying read after serialization failure",
+			zap.String("session_id", ch.session.ID),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+	})
+	return result, converting, err
+}
+
 func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysql.Result, error) {
 	fieldDescs := rows.FieldDescriptions()
 
@@ -615,7 +934,15 @@ func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysq
 	// BuildSimpleResultset expects native types (int, float64, string, []byte, nil)
 	values := make([][]interface{}, 0)
 	rowNum := 0
+	maxRows := ch.maxResultRows()
 	for rows.Next() {
+		if maxRows > 0 && rowNum >= maxRows {
+			if err := ch.exceedResultLimit(maxRows); err != nil {
+				return nil, err
+			}
+			break
+		}
+
 		rowValues, err := rows.Values()
 		if err != nil {
 			return nil, err
@@ -623,6 +950,10 @@ func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysq
 
 		row := make([]interface{}, len(rowValues))
 		for i, v := range rowValues {
+			if zero, ok := ch.handler.zeroDates.restore(fieldDescs[i].DataTypeOID, v); ok {
+				row[i] = zero
+				continue
+			}
 			if v == nil {
 				row[i] = nil
 				continue
@@ -683,15 +1014,8 @@ func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysq
 				if !val.Valid {
 					row[i] = nil
 				} else {
-					// Convert using MarshalJSON which returns proper decimal string
-					// e.g., {Int: 9999, Exp: -2} -> "99.99"
-					if jsonBytes, err := val.MarshalJSON(); err == nil {
-						// MarshalJSON returns string representation of the number
-						row[i] = string(jsonBytes)
-					} else {
-						// Fallback: use Int.String()
-						row[i] = val.Int.String()
-					}
+					// Exact decimal text, e.g. {Int: 9999, Exp: -2} -> "99.99", never a float
+					row[i] = mapper.NumericString(val)
 				}
 			case pgtype.Time:
 				// Convert pgtype.Time to MySQL TIME format "HH:MM:SS"
@@ -728,10 +1052,23 @@ func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysq
 		return nil, err
 	}
 
-	// Use BuildSimpleResultset with binary parameter
+	// BIGINT UNSIGNED (NUMERIC(20,0)) is sent as unsigned longlong rather than a decimal
+	unsignedCols := make(map[int]bool)
+	for i, fd := range fieldDescs {
+		if isBigintUnsignedColumn(fd) && toUnsignedColumn(values, i) {
+			unsignedCols[i] = true
+		}
+	}
+
 	// binary=true: Binary Protocol (for PreparedStatements)
 	// binary=false: Text Protocol (for regular queries)
-	resultset, err := mysql.BuildSimpleResultset(names, values, binary)
+	var resultset *mysql.Resultset
+	var err error
+	if binary {
+		resultset, err = buildBinaryResultset(names, values)
+	} else {
+		resultset, err = mysql.BuildSimpleTextResultset(names, values)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -755,6 +1092,14 @@ func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysq
 		// Populate FieldNames map
 		resultset.FieldNames[string(fd.Name)] = i
 
+		if unsignedCols[i] {
+			resultset.Fields[i].Type = mysql.MYSQL_TYPE_LONGLONG
+			resultset.Fields[i].Charset = 63
+			resultset.Fields[i].Flag = mysql.BINARY_FLAG | mysql.UNSIGNED_FLAG
+			resultset.Fields[i].ColumnLength = 20
+			continue
+		}
+
 		// Override field types based on PostgreSQL OID
 		switch fd.DataTypeOID {
 		case 1700: // NUMERIC/DECIMAL
@@ -804,6 +1149,11 @@ func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysq
 			// DO NOT override to 63 (binary) - that prevents MySQL client from parsing date strings
 			resultset.Fields[i].ColumnLength = 10 // "YYYY-MM-DD"
 
+		case byteaOID:
+			// Bytes are sent unchanged in both protocols, the BLOB type keeps clients
+			// from decoding them as text
+			setBlobField(resultset.Fields[i])
+
 		case 1083, 1266: // TIME, TIMETZ
 			// CRITICAL FIX: Must set MYSQL_TYPE_TIME for proper TIME parsing
 			// Text protocol can send time as strings with TIME field type
@@ -826,6 +1176,10 @@ func (ch *ConnectionHandler) buildMySQLResult(rows pgx.Rows, binary bool) (*mysq
 func (ch *ConnectionHandler) handleShowCommand(ctx context.Context, query string) (*mysql.Result, error) {
 	rows, err := ch.handler.showEmulator.HandleShowCommand(ctx, ch.pgConn, query)
 	if err != nil {
+		var unknownDB *mapper.UnknownDatabaseError
+		if errors.As(err, &unknownDB) {
+			return nil, mysql.NewError(mapper.ER_BAD_DB_ERROR, unknownDB.Error())
+		}
 		return nil, err
 	}
 	defer rows.Close()
@@ -835,33 +1189,51 @@ func (ch *ConnectionHandler) handleShowCommand(ctx context.Context, query string
 }
 
 func (ch *ConnectionHandler) handleSetCommand(ctx context.Context, query string) (*mysql.Result, error) {
-	sessionVars := make(map[string]interface{})
+	if tx, ok, err := mapper.ParseSetTransaction(query); ok {
+		if err != nil {
+			return nil, err
+		}
+		return ch.setTransaction(ctx, tx)
+	}
 
-	err := ch.handler.showEmulator.HandleSetCommand(ctx, query, sessionVars)
+	assignments, err := mapper.ParseSetStatement(query)
 	if err != nil {
+		var readOnly *mapper.ReadOnlyVariableError
+		if errors.As(err, &readOnly) {
+			return nil, mysql.NewError(mapper.ER_INCORRECT_GLOBAL_LOCAL_VAR, readOnly.Error())
+		}
 		return nil, err
 	}
 
-	// Handle AUTOCOMMIT specially to manage transaction state
-	for k, v := range sessionVars {
-		if strings.ToLower(k) == "autocommit" {
-			autocommit := false
-			switch val := v.(type) {
-			case string:
-				autocommit = (strings.ToUpper(val) == "ON" || val == "1" || strings.ToUpper(val) == "TRUE")
-			case int:
-				autocommit = (val != 0)
-			case bool:
-				autocommit = val
-			}
+	for _, assignment := range assignments {
+		switch assignment.Scope {
+		case mapper.ScopeGlobal, mapper.ScopePersist, mapper.ScopePersistOnly:
+			// Server-wide settings belong to the PostgreSQL configuration, accept them without effect
+			continue
+		case mapper.ScopeUser:
+			ch.session.SetUserVar(strings.ToLower(strings.TrimPrefix(assignment.Name, "@")), assignment.Value)
+			continue
+		}
 
+		// Handle AUTOCOMMIT specially to manage transaction state
+		if strings.EqualFold(assignment.Name, "autocommit") {
+			value := strings.ToUpper(assignment.Value)
+			autocommit := value == "ON" || value == "1" || value == "TRUE"
+
+			wasInTransaction := ch.session.InTransaction
 			if err := ch.session.SetAutocommit(autocommit); err != nil {
 				ch.handler.metrics.IncErrors("transaction")
 				ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "set_autocommit", err)
 				return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
 			}
+			ch.trackAutocommit(autocommit)
+			// autocommit = 1 commits the open transaction
+			ch.observeTransaction(wasInTransaction, txCommitted)
+			if ch.session.InTransaction != wasInTransaction {
+				ch.trackTransactionState()
+			}
 		}
-		ch.session.SetSessionVar(k, v)
+		ch.session.SetSessionVar(assignment.Name, assignment.Value)
 	}
 
 	result := &mysql.Result{
@@ -873,10 +1245,29 @@ func (ch *ConnectionHandler) handleSetCommand(ctx context.Context, query string)
 }
 
 func (ch *ConnectionHandler) handleUseCommand(ctx context.Context, query string) (*mysql.Result, error) {
+	if parts := strings.Fields(query); len(parts) >= 2 {
+		dbName := strings.Trim(parts[1], "`\"';")
+		if err := ch.routeDatabase(dbName); err != nil {
+			return nil, err
+		}
+		ch.session.Database = dbName
+	}
+
+	if ch.pgConn == nil {
+		conn, err := ch.pgPool.AcquireForSession(ctx, ch.session.ID)
+		if err != nil {
+			ch.handler.metrics.IncErrors("connection")
+			ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "connection", err)
+			return nil, err
+		}
+		ch.attachPGConn(conn)
+	}
+
 	err := ch.handler.showEmulator.HandleUseCommand(ctx, ch.pgConn, query)
 	if err != nil {
 		return nil, err
 	}
+	ch.trackSchema(ch.session.Database)
 
 	result := &mysql.Result{
 		Status:       0,
@@ -886,6 +1277,85 @@ func (ch *ConnectionHandler) handleUseCommand(ctx context.Context, query string)
 	return result, nil
 }
 
+// recordRewriteFailure counts a rewrite failure, labeled by reason and feature
+func (ch *ConnectionHandler) recordRewriteFailure(err error) {
+	ch.handler.metrics.IncErrors("rewrite")
+	reason, feature := sqlrewrite.RewriteFailureLabels(err)
+	ch.handler.metrics.IncRewriteFailures(reason, feature)
+
+	var countErr *sqlrewrite.ParamCountError
+	if errors.As(err, &countErr) {
+		ch.handler.metrics.IncParamMismatches()
+		ch.handler.logger.Warn("Prepared statement parameter count mismatch",
+			zap.String("session_id", ch.session.ID),
+			zap.Int("placeholders", countErr.Placeholders),
+			zap.Int("rewritten", countErr.Rewritten),
+		)
+	}
+}
+
+// rewriteFailureError reports statements using a known unsupported feature as
+// ER_NOT_SUPPORTED_YET and rewrites that changed the number of placeholders as
+// ER_WRONG_ARGUMENTS, other rewrite failures are returned as they are
+func rewriteFailureError(err error) error {
+	var rerr *sqlrewrite.RewriteError
+	if errors.As(err, &rerr) && rerr.Reason == sqlrewrite.ReasonUnsupported {
+		return mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, err.Error())
+	}
+	var countErr *sqlrewrite.ParamCountError
+	if errors.As(err, &countErr) {
+		return mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "Incorrect arguments to mysqld_stmt_prepare: "+err.Error())
+	}
+	return err
+}
+
+// audit records an executed statement in the audit log when enabled
+func (ch *ConnectionHandler) audit(query string, startTime time.Time, err error) {
+	if ch.handler.auditLogger == nil {
+		return
+	}
+	ch.handler.auditLogger.LogStatement(ch.session.ID, ch.session.User, ch.session.ClientAddr,
+		ch.session.Database, query, time.Since(startTime), err)
+}
+
+// routeDatabase switches the connection to the backend pool mapped to dbName
+// The current PostgreSQL connection is released and a new one is acquired lazily
+func (ch *ConnectionHandler) routeDatabase(dbName string) error {
+	target := ch.handler.pgRouter.PoolFor(dbName)
+	if target == ch.pgPool {
+		return nil
+	}
+
+	if ch.session.InTransaction {
+		return mysql.NewError(mysql.ER_UNKNOWN_ERROR, "cannot switch to a database on another backend inside a transaction")
+	}
+
+	if ch.pgConn != nil {
+		ch.pgPool.ReleaseForSession(ch.session.ID)
+		ch.pgConn = nil
+		ch.session.SetPGConn(nil)
+	}
+	ch.pgPool = target
+	return nil
+}
+
+// insertedIDs reads the ids an INSERT ... RETURNING returns, one per row. The last insert
+// id is the first generated one, as in MySQL, where explicit ids before it do not count
+func insertedIDs(rows pgx.Rows, firstGenerated int) (uint64, int64) {
+	defer rows.Close()
+
+	var lastInsertID uint64
+	var count int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil && (count == int64(firstGenerated) || lastInsertID == 0) {
+			lastInsertID = uint64(id)
+		}
+		count++
+	}
+	return lastInsertID, count
+}
+
 // extractInsertTableName extracts the table name from an INSERT statement
 func extractInsertTableName(sql string) string {
 	upper := strings.ToUpper(sql)
@@ -1007,12 +1477,12 @@ func extractAlterTableName(sql string) string {
 	return strings.Trim(parts[0], "`\"")
 }
 
-// extractDropTableName extracts the table name from a DROP TABLE statement
-func extractDropTableName(sql string) string {
+// extractDropTableNames extracts the table names from a DROP TABLE statement
+func extractDropTableNames(sql string) []string {
 	upper := strings.ToUpper(sql)
 	idx := strings.Index(upper, "DROP TABLE")
 	if idx == -1 {
-		return ""
+		return nil
 	}
 
 	// Skip "DROP TABLE "
@@ -1024,14 +1494,16 @@ func extractDropTableName(sql string) string {
 		sql = strings.TrimSpace(sql[9:])
 	}
 
-	// Remove quotes if present
-	sql = strings.Trim(sql, "`\"")
-
-	// Get the first word (table name)
-	parts := strings.Fields(sql)
-	if len(parts) == 0 {
-		return ""
+	// Tables are separated by commas, RESTRICT or CASCADE may follow the last one
+	var names []string
+	for _, part := range strings.Split(sql, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if name := strings.Trim(fields[0], "`\""); name != "" {
+			names = append(names, name)
+		}
 	}
-
-	return strings.Trim(parts[0], "`\"")
+	return names
 }
@@ -0,0 +1,142 @@
+package mysql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// defaultCapabilities are the flags go-mysql advertises in the initial handshake,
+// plus CLIENT_LOCAL_FILES for LOAD DATA LOCAL INFILE handled by the proxy
+const defaultCapabilities = mysql.CLIENT_LONG_PASSWORD | mysql.CLIENT_LONG_FLAG | mysql.CLIENT_CONNECT_WITH_DB |
+	mysql.CLIENT_PROTOCOL_41 | mysql.CLIENT_TRANSACTIONS | mysql.CLIENT_SECURE_CONNECTION | mysql.CLIENT_PLUGIN_AUTH |
+	mysql.CLIENT_SSL | mysql.CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA | mysql.CLIENT_CONNECT_ATTRS | mysql.CLIENT_LOCAL_FILES
+
+// optionalCapabilities are implemented by the proxy but only advertised when configured.
+// CLIENT_SESSION_TRACK changes the OK packet layout, which not every client parses
+// the way go-mysql writes it, so it is opt-in
+const optionalCapabilities = mysql.CLIENT_SESSION_TRACK
+
+// requiredCapabilities cannot be turned off, go-mysql only speaks protocol 4.1 with plugin auth
+const requiredCapabilities = mysql.CLIENT_PROTOCOL_41 | mysql.CLIENT_SECURE_CONNECTION | mysql.CLIENT_PLUGIN_AUTH
+
+// capabilityFlags maps the names accepted in server.capabilities to flags
+var capabilityFlags = map[string]uint32{
+	"CLIENT_LONG_PASSWORD":                  mysql.CLIENT_LONG_PASSWORD,
+	"CLIENT_FOUND_ROWS":                     mysql.CLIENT_FOUND_ROWS,
+	"CLIENT_LONG_FLAG":                      mysql.CLIENT_LONG_FLAG,
+	"CLIENT_CONNECT_WITH_DB":                mysql.CLIENT_CONNECT_WITH_DB,
+	"CLIENT_NO_SCHEMA":                      mysql.CLIENT_NO_SCHEMA,
+	"CLIENT_COMPRESS":                       mysql.CLIENT_COMPRESS,
+	"CLIENT_ODBC":                           mysql.CLIENT_ODBC,
+	"CLIENT_LOCAL_FILES":                    mysql.CLIENT_LOCAL_FILES,
+	"CLIENT_IGNORE_SPACE":                   mysql.CLIENT_IGNORE_SPACE,
+	"CLIENT_PROTOCOL_41":                    mysql.CLIENT_PROTOCOL_41,
+	"CLIENT_INTERACTIVE":                    mysql.CLIENT_INTERACTIVE,
+	"CLIENT_SSL":                            mysql.CLIENT_SSL,
+	"CLIENT_IGNORE_SIGPIPE":                 mysql.CLIENT_IGNORE_SIGPIPE,
+	"CLIENT_TRANSACTIONS":                   mysql.CLIENT_TRANSACTIONS,
+	"CLIENT_RESERVED":                       mysql.CLIENT_RESERVED,
+	"CLIENT_SECURE_CONNECTION":              mysql.CLIENT_SECURE_CONNECTION,
+	"CLIENT_MULTI_STATEMENTS":               mysql.CLIENT_MULTI_STATEMENTS,
+	"CLIENT_MULTI_RESULTS":                  mysql.CLIENT_MULTI_RESULTS,
+	"CLIENT_PS_MULTI_RESULTS":               mysql.CLIENT_PS_MULTI_RESULTS,
+	"CLIENT_PLUGIN_AUTH":                    mysql.CLIENT_PLUGIN_AUTH,
+	"CLIENT_CONNECT_ATTRS":                  mysql.CLIENT_CONNECT_ATTRS,
+	"CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA": mysql.CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA,
+	"CLIENT_CAN_HANDLE_EXPIRED_PASSWORDS":   mysql.CLIENT_CAN_HANDLE_EXPIRED_PASSWORDS,
+	"CLIENT_SESSION_TRACK":                  mysql.CLIENT_SESSION_TRACK,
+	"CLIENT_DEPRECATE_EOF":                  mysql.CLIENT_DEPRECATE_EOF,
+}
+
+// handshakeOptions customizes the initial handshake go-mysql sends
+type handshakeOptions struct {
+	serverVersion string // Replaces go-mysql's version string when set
+	capabilities  uint32 // Advertised capability flags
+}
+
+// parseCapabilities applies name -> enabled overrides to the default flags.
+// Only flags the protocol layer implements can be advertised: go-mysql always
+// terminates result sets with EOF packets, so e.g. CLIENT_DEPRECATE_EOF can only stay off
+func parseCapabilities(overrides map[string]bool) (uint32, error) {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	capabilities := uint32(defaultCapabilities)
+	for _, name := range names {
+		flag, ok := capabilityFlags[strings.ToUpper(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown capability flag: %s", name)
+		}
+		if overrides[name] {
+			if flag&(defaultCapabilities|optionalCapabilities) == 0 {
+				return 0, fmt.Errorf("capability flag %s is not supported by the proxy", name)
+			}
+			capabilities |= flag
+			continue
+		}
+		if flag&requiredCapabilities != 0 {
+			return 0, fmt.Errorf("capability flag %s is required and cannot be disabled", name)
+		}
+		capabilities &^= flag
+	}
+	return capabilities, nil
+}
+
+// rewriteHandshake applies opts to a Handshake V10 packet, header included.
+// Packets that don't parse are returned unchanged
+func rewriteHandshake(packet []byte, opts handshakeOptions) []byte {
+	// 4 byte packet header, protocol version 10
+	if len(packet) < 5 || packet[4] != 10 {
+		return packet
+	}
+	end := bytes.IndexByte(packet[5:], 0)
+	// server version[NUL], connection id[4], salt part 1[8], filler[1], capability[2], charset[1], status[2], capability[2]
+	if end < 0 || len(packet) < 5+end+1+4+8+1+2+1+2+2 {
+		return packet
+	}
+
+	version := packet[5 : 5+end]
+	if opts.serverVersion != "" {
+		version = []byte(opts.serverVersion)
+	}
+	rest := append([]byte{}, packet[5+end:]...)
+
+	capLow := 1 + 4 + 8 + 1
+	capHigh := capLow + 2 + 1 + 2
+	binary.LittleEndian.PutUint16(rest[capLow:], uint16(opts.capabilities))
+	binary.LittleEndian.PutUint16(rest[capHigh:], uint16(opts.capabilities>>16))
+
+	out := make([]byte, 0, 5+len(version)+len(rest))
+	out = append(out, packet[:5]...)
+	out = append(out, version...)
+	out = append(out, rest...)
+
+	// Payload length is 3 bytes little endian, the sequence id is kept
+	length := len(out) - 4
+	out[0], out[1], out[2] = byte(length), byte(length>>8), byte(length>>16)
+	return out
+}
+
+// SetServerVersion sets the version string advertised in the handshake
+func (h *Handler) SetServerVersion(version string) {
+	h.handshake.serverVersion = version
+}
+
+// SetCapabilities overrides capability flags advertised in the handshake,
+// keyed by flag name (e.g. CLIENT_CONNECT_ATTRS: false)
+func (h *Handler) SetCapabilities(overrides map[string]bool) error {
+	capabilities, err := parseCapabilities(overrides)
+	if err != nil {
+		return err
+	}
+	h.handshake.capabilities = capabilities
+	return nil
+}
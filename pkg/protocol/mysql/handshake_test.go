@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handshakeFields extracts the server version and capability flags from a Handshake V10 packet
+func handshakeFields(t *testing.T, packet []byte) (string, uint32) {
+	length := int(packet[0]) | int(packet[1])<<8 | int(packet[2])<<16
+	require.Equal(t, len(packet)-4, length, "payload length in header")
+
+	data := packet[5:]
+	end := 0
+	for data[end] != 0 {
+		end++
+	}
+	rest := data[end+1+4+8+1:]
+	low := binary.LittleEndian.Uint16(rest)
+	high := binary.LittleEndian.Uint16(rest[2+1+2:])
+	return string(data[:end]), uint32(low) | uint32(high)<<16
+}
+
+func setHandshakeHeader(packet []byte) []byte {
+	length := len(packet) - 4
+	packet[0], packet[1], packet[2] = byte(length), byte(length>>8), byte(length>>16)
+	return packet
+}
+
+func TestParseCapabilities(t *testing.T) {
+	caps, err := parseCapabilities(nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(defaultCapabilities), caps)
+
+	caps, err = parseCapabilities(map[string]bool{"CLIENT_CONNECT_ATTRS": false, "client_ssl": false, "CLIENT_TRANSACTIONS": true})
+	require.NoError(t, err)
+	assert.Zero(t, caps&mysql.CLIENT_CONNECT_ATTRS)
+	assert.Zero(t, caps&mysql.CLIENT_SSL)
+	assert.NotZero(t, caps&mysql.CLIENT_TRANSACTIONS)
+
+	// Turning off an unsupported flag is a no-op
+	caps, err = parseCapabilities(map[string]bool{"CLIENT_DEPRECATE_EOF": false})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(defaultCapabilities), caps)
+
+	_, err = parseCapabilities(map[string]bool{"CLIENT_DEPRECATE_EOF": true})
+	assert.ErrorContains(t, err, "not supported")
+	_, err = parseCapabilities(map[string]bool{"CLIENT_PROTOCOL_41": false})
+	assert.ErrorContains(t, err, "required")
+	_, err = parseCapabilities(map[string]bool{"CLIENT_BOGUS": true})
+	assert.ErrorContains(t, err, "unknown")
+}
+
+func TestRewriteHandshake(t *testing.T) {
+	salt := []byte("abcdefghijklmnopqrst")
+	packet := setHandshakeHeader(buildHandshake(salt))
+
+	t.Run("Defaults keep the packet", func(t *testing.T) {
+		out := rewriteHandshake(packet, handshakeOptions{capabilities: 0xffffffff})
+		assert.Equal(t, packet, out)
+	})
+
+	t.Run("Version and capabilities", func(t *testing.T) {
+		caps := uint32(defaultCapabilities &^ mysql.CLIENT_CONNECT_ATTRS)
+		out := rewriteHandshake(packet, handshakeOptions{serverVersion: "5.7.44-aproxy", capabilities: caps})
+
+		version, gotCaps := handshakeFields(t, out)
+		assert.Equal(t, "5.7.44-aproxy", version)
+		assert.Equal(t, caps, gotCaps)
+		assert.Equal(t, salt, parseHandshakeSalt(out))
+	})
+
+	t.Run("Not a handshake", func(t *testing.T) {
+		ok := []byte{7, 0, 0, 2, 0, 0, 0, 2, 0, 0, 0}
+		assert.Equal(t, ok, rewriteHandshake(ok, handshakeOptions{serverVersion: "5.7.44"}))
+	})
+}
+
+func TestHandshakeConn(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetServerVersion("5.7.44-aproxy")
+	require.NoError(t, h.SetCapabilities(map[string]bool{"CLIENT_CONNECT_ATTRS": false}))
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	salt := []byte("abcdefghijklmnopqrst")
+	packet := setHandshakeHeader(buildHandshake(salt))
+	go func() {
+		n, err := ch.conn.Write(packet)
+		assert.NoError(t, err)
+		assert.Equal(t, len(packet), n)
+	}()
+
+	header := make([]byte, 4)
+	_, err = io.ReadFull(client, header)
+	require.NoError(t, err)
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	_, err = io.ReadFull(client, payload)
+	require.NoError(t, err)
+
+	version, caps := handshakeFields(t, append(header, payload...))
+	assert.Equal(t, "5.7.44-aproxy", version)
+	assert.Zero(t, caps&mysql.CLIENT_CONNECT_ATTRS)
+	assert.Equal(t, salt, ch.conn.salt)
+}
@@ -0,0 +1,133 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"aproxy/pkg/session"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// maxPGParams is the most parameters PostgreSQL accepts in one statement
+const maxPGParams = 65535
+
+// insertBatching coalesces consecutive executions of one prepared single-row INSERT
+// inside a transaction into a multi-row INSERT, saving a round trip per row for
+// drivers that loop stmt.Exec
+//
+// Each execution is answered with 1 affected row right away, which is what a plain
+// INSERT ... VALUES of one row reports, and the rows are sent when the batch is full,
+// the window is over or any other statement arrives. Rows only become visible at
+// COMMIT anyway, and as PostgreSQL aborts the transaction on any error, the only
+// difference is that a failing row is reported by the statement that sent it
+// (the next INSERT, the COMMIT, ...). Tables with an AUTO_INCREMENT column are never
+// batched, every execution has to return its own last insert id
+type insertBatching struct {
+	rows   int           // Rows per multi-row INSERT, below 2 disables batching
+	window time.Duration // Rows executed longer ago than this are sent first, 0 waits for a full batch
+}
+
+// SetInsertBatching sets how many executions of a prepared INSERT inside a transaction
+// are sent as one multi-row INSERT (0 disables) and how long they may be held back
+func (h *Handler) SetInsertBatching(rows int, window time.Duration) {
+	h.insertBatching = insertBatching{rows: rows, window: window}
+}
+
+// insertBatch holds the rows of executed INSERTs not sent to PostgreSQL yet
+type insertBatch struct {
+	stmt    *session.PreparedStatement
+	args    []interface{} // Parameters of all rows, ParamCount per row
+	rows    int
+	started time.Time // When the first row was executed
+
+	// Multi-row INSERT last built for stmt, full batches reuse it
+	sql     string
+	sqlRows int
+}
+
+// batchInsert adds an execution of stmt to the pending batch when it can be batched,
+// answering it as a single-row INSERT. Any other execution sends the pending rows
+// first and reports false
+func (ch *ConnectionHandler) batchInsert(stmt *session.PreparedStatement, args []interface{}) (*mysql.Result, bool, error) {
+	if !ch.batchable(stmt) {
+		return nil, false, ch.flushInserts()
+	}
+
+	b := &ch.pending
+	if b.rows > 0 && (b.stmt != stmt || ch.handler.insertBatching.window > 0 && time.Since(b.started) > ch.handler.insertBatching.window) {
+		if err := ch.flushInserts(); err != nil {
+			return nil, true, err
+		}
+	}
+	if b.stmt != stmt {
+		*b = insertBatch{stmt: stmt}
+	}
+	if b.rows == 0 {
+		b.started = time.Now()
+	}
+	b.args = append(b.args, args...)
+	b.rows++
+
+	limit := ch.handler.insertBatching.rows
+	if stmt.ParamCount > 0 && limit*stmt.ParamCount > maxPGParams {
+		limit = maxPGParams / stmt.ParamCount
+	}
+	if b.rows >= limit {
+		if err := ch.flushInserts(); err != nil {
+			return nil, true, err
+		}
+	}
+	return &mysql.Result{Status: 0, AffectedRows: 1}, true, nil
+}
+
+// batchable reports whether an execution of stmt may wait in the pending batch
+func (ch *ConnectionHandler) batchable(stmt *session.PreparedStatement) bool {
+	return ch.handler.insertBatching.rows > 1 &&
+		ch.session.InTransaction &&
+		stmt.SingleRowInsert && !stmt.Returning &&
+		ch.session.GetAutoIncrementColumn(extractInsertTableName(stmt.OriginalSQL)) == ""
+}
+
+// flushInserts sends the pending rows as one INSERT. An error fails the statement
+// that caused the flush
+func (ch *ConnectionHandler) flushInserts() error {
+	b := &ch.pending
+	if b.rows == 0 {
+		return nil
+	}
+	args, rows := b.args, b.rows
+	b.args, b.rows = b.args[:0], 0
+
+	sql := b.stmt.SQL
+	if rows > 1 {
+		if b.sqlRows != rows {
+			multiRow, err := ch.handler.rewriter.RewriteInsertRows(b.stmt.OriginalSQL, rows, ch.session.Variables())
+			if err != nil {
+				return err
+			}
+			b.sql, b.sqlRows = multiRow, rows
+		}
+		sql = b.sql
+	}
+
+	startTime := time.Now()
+	cmdTag, err := ch.pgConn.Exec(context.Background(), sql, args...)
+	if err != nil {
+		ch.handler.metrics.IncErrors("query")
+		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr,
+			b.stmt.OriginalSQL, time.Since(startTime).Seconds(), 0, err)
+		return ch.handler.errorMapper.MySQLError(err)
+	}
+
+	duration := time.Since(startTime).Seconds()
+	ch.handler.metrics.ObserveQueryDuration(duration)
+	ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr,
+		b.stmt.OriginalSQL, duration, cmdTag.RowsAffected(), nil)
+	return nil
+}
+
+// discardInserts drops the pending rows, for ROLLBACK and when the session ends
+func (ch *ConnectionHandler) discardInserts() {
+	ch.pending.args, ch.pending.rows = ch.pending.args[:0], 0
+}
@@ -0,0 +1,194 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecordingBackend attaches a fake PostgreSQL backend to ch that records the simple
+// protocol queries it receives, taking latency to answer each as a network round trip
+// would. AUTO_INCREMENT lookups are answered for serial_t and not recorded
+func newRecordingBackend(t testing.TB, ch *ConnectionHandler, latency time.Duration) *fakePGBackend {
+	autoIncrement := map[string]string{"serial_t": "id"} // Table -> AUTO_INCREMENT column
+	return newFakePGBackend(t, ch, nil, func(b *fakePGBackend, backend *pgproto3.Backend, msg pgproto3.FrontendMessage) {
+		query, ok := msg.(*pgproto3.Query)
+		if !ok {
+			return
+		}
+		if strings.Contains(query.String, "information_schema.columns") {
+			for table, column := range autoIncrement {
+				if strings.Contains(query.String, "'"+table+"'") {
+					backend.Send(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+						{Name: []byte("column_name"), DataTypeOID: pgtype.TextOID, DataTypeSize: -1}}})
+					backend.Send(&pgproto3.DataRow{Values: [][]byte{[]byte(column)}})
+				}
+			}
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'T'})
+			return
+		}
+
+		b.record(query.String)
+		time.Sleep(latency)
+		backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("INSERT 0 1")})
+		backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'T'})
+	})
+}
+
+// prepareInsert prepares query on ch and returns the statement id
+func prepareInsert(t testing.TB, ch *ConnectionHandler, query string) uint32 {
+	_, _, id, err := ch.HandleStmtPrepare(query)
+	require.NoError(t, err)
+	return id.(uint32)
+}
+
+func TestInsertBatching(t *testing.T) {
+	const insert = "INSERT INTO t (a, b) VALUES (?, ?)"
+
+	setup := func(t *testing.T, rows int, window time.Duration) (*ConnectionHandler, *fakePGBackend, uint32) {
+		h := newTestHandler(t)
+		h.SetInsertBatching(rows, window)
+		ch := newTestConnection(t, h)
+		backend := newRecordingBackend(t, ch, 0)
+		_, err := ch.HandleQuery("BEGIN")
+		require.NoError(t, err)
+		backend.take()
+		return ch, backend, prepareInsert(t, ch, insert)
+	}
+	execute := func(t *testing.T, ch *ConnectionHandler, id uint32, a int64, b string) {
+		result, err := ch.HandleStmtExecute(id, insert, []interface{}{a, []byte(b)})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), result.AffectedRows)
+		assert.Equal(t, uint64(0), result.InsertId)
+	}
+
+	t.Run("Full batches and COMMIT", func(t *testing.T) {
+		ch, backend, id := setup(t, 3, 0)
+
+		execute(t, ch, id, 1, "x")
+		execute(t, ch, id, 2, "y")
+		assert.Empty(t, backend.take(), "rows wait for the batch to fill")
+		execute(t, ch, id, 3, "z")
+		assert.Equal(t, []string{`INSERT INTO "t" ("a","b") VALUES ('1','x'),('2','y'),('3','z')`}, backend.take())
+
+		execute(t, ch, id, 4, "w")
+		_, err := ch.HandleQuery("COMMIT")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`INSERT INTO "t" ("a","b") VALUES ('4','w')`, "COMMIT"}, backend.take())
+	})
+
+	t.Run("ROLLBACK drops pending rows", func(t *testing.T) {
+		ch, backend, id := setup(t, 3, 0)
+
+		execute(t, ch, id, 1, "x")
+		execute(t, ch, id, 2, "y")
+		_, err := ch.HandleQuery("ROLLBACK")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ROLLBACK"}, backend.take())
+	})
+
+	t.Run("Other statements send pending rows first", func(t *testing.T) {
+		ch, backend, id := setup(t, 3, 0)
+		other := prepareInsert(t, ch, "INSERT INTO t (b) VALUES (?)")
+
+		execute(t, ch, id, 1, "x")
+		execute(t, ch, id, 2, "y")
+		_, err := ch.HandleStmtExecute(other, "INSERT INTO t (b) VALUES (?)", []interface{}{[]byte("z")})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`INSERT INTO "t" ("a","b") VALUES ('1','x'),('2','y')`}, backend.take())
+
+		_, err = ch.HandleQuery("UPDATE t SET a = 0")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`INSERT INTO "t" ("b") VALUES ('z')`, `UPDATE "t" SET "a"=0`}, backend.take())
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		ch, backend, id := setup(t, 3, time.Nanosecond)
+
+		execute(t, ch, id, 1, "x")
+		time.Sleep(time.Millisecond)
+		execute(t, ch, id, 2, "y")
+		assert.Equal(t, []string{`INSERT INTO "t" ("a","b") VALUES ('1','x')`}, backend.take())
+	})
+
+	t.Run("Not batched", func(t *testing.T) {
+		ch, backend, _ := setup(t, 3, 0)
+
+		// Every execution reports its own AUTO_INCREMENT id
+		serial := prepareInsert(t, ch, "INSERT INTO serial_t (a) VALUES (?)")
+		_, err := ch.HandleStmtExecute(serial, "INSERT INTO serial_t (a) VALUES (?)", []interface{}{int64(1)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`INSERT INTO "serial_t" ("a") VALUES ('1') RETURNING id`}, backend.take())
+
+		// Affected rows of INSERT IGNORE depend on the data
+		ignore := prepareInsert(t, ch, "INSERT IGNORE INTO u (a) VALUES (?)")
+		_, err = ch.HandleStmtExecute(ignore, "INSERT IGNORE INTO u (a) VALUES (?)", []interface{}{int64(1)})
+		require.NoError(t, err)
+		assert.Len(t, backend.take(), 1)
+
+		// Outside a transaction
+		_, err = ch.HandleQuery("COMMIT")
+		require.NoError(t, err)
+		backend.take()
+		plain := prepareInsert(t, ch, "INSERT INTO u (a) VALUES (?)")
+		_, err = ch.HandleStmtExecute(plain, "INSERT INTO u (a) VALUES (?)", []interface{}{int64(1)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`INSERT INTO "u" ("a") VALUES ('1')`}, backend.take())
+	})
+}
+
+func TestStmtExecuteWriteStatements(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+	backend := newRecordingBackend(t, ch, 0)
+
+	// The statement type comes from the AST, a leading WITH does not make it a query
+	const query = "WITH old AS (SELECT a FROM u WHERE a < ?) DELETE FROM u WHERE a IN (SELECT a FROM old)"
+	id := prepareInsert(t, ch, query)
+	result, err := ch.HandleStmtExecute(id, query, []interface{}{int64(5)})
+	require.NoError(t, err)
+	assert.Nil(t, result.Resultset)
+	assert.Equal(t, uint64(1), result.AffectedRows)
+	assert.Len(t, backend.take(), 1)
+}
+
+// BenchmarkInsertBatching executes 10k single-row INSERTs in a transaction against
+// a backend sleeping 50µs on every round trip, with and without batching
+func BenchmarkInsertBatching(b *testing.B) {
+	const insert = "INSERT INTO t (a, b) VALUES (?, ?)"
+	const rows = 10000
+
+	for _, batch := range []int{0, 100, 1000} {
+		b.Run(fmt.Sprintf("batch=%d", batch), func(b *testing.B) {
+			h := newTestHandler(b)
+			h.SetInsertBatching(batch, 0)
+			ch := newTestConnection(b, h)
+			newRecordingBackend(b, ch, 50*time.Microsecond)
+			id := prepareInsert(b, ch, insert)
+			args := []interface{}{int64(1), []byte("row")}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ch.HandleQuery("BEGIN"); err != nil {
+					b.Fatal(err)
+				}
+				for j := 0; j < rows; j++ {
+					if _, err := ch.HandleStmtExecute(id, insert, args); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if _, err := ch.HandleQuery("COMMIT"); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(rows*b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
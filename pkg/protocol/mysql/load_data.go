@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"aproxy/pkg/mapper"
+	"aproxy/pkg/sqlrewrite"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// packetConn is the part of the go-mysql connection LOAD DATA LOCAL INFILE needs:
+// the file request and its content are packets within the current command
+type packetConn interface {
+	WritePacket(data []byte) error
+	ReadPacket() ([]byte, error)
+}
+
+// SetPacketConn sets the MySQL connection LOAD DATA LOCAL INFILE reads client files over
+func (ch *ConnectionHandler) SetPacketConn(conn packetConn) {
+	ch.packetConn = conn
+}
+
+// handleLoadData runs LOAD DATA LOCAL INFILE: the client is asked for the file
+// and its content is converted on the fly into COPY ... FROM STDIN.
+// Server-side files are never read
+func (ch *ConnectionHandler) handleLoadData(ctx context.Context, query string, startTime time.Time) (*mysql.Result, error) {
+	ld, err := sqlrewrite.ParseLoadData(query)
+	if err != nil {
+		ch.recordRewriteFailure(err)
+		return nil, mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, err.Error())
+	}
+	if !ld.Local {
+		return nil, mysql.NewError(mysql.ER_NOT_ALLOWED_COMMAND,
+			"LOAD DATA INFILE would read a file on the proxy host and is not allowed, use LOAD DATA LOCAL INFILE")
+	}
+	if ch.packetConn == nil || ch.clientCapabilities&mysql.CLIENT_LOCAL_FILES == 0 {
+		return nil, mysql.NewError(mysql.ER_NOT_ALLOWED_COMMAND,
+			"LOAD DATA LOCAL INFILE requires local infile support to be enabled on the client")
+	}
+
+	request := append(make([]byte, 4), mysql.LocalInFile_HEADER)
+	request = append(request, ld.Path...)
+	if err := ch.packetConn.WritePacket(request); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	type copyResult struct {
+		tag pgconn.CommandTag
+		err error
+	}
+	done := make(chan copyResult, 1)
+	go func() {
+		tag, err := ch.pgConn.PgConn().CopyFrom(ctx, pr, ld.CopySQL())
+		// Unblock the converter if COPY stops before the input ends
+		pr.CloseWithError(err)
+		done <- copyResult{tag, err}
+	}()
+
+	converter := mapper.NewLoadDataConverter(mapper.LoadDataFormat{
+		FieldsTerminated: ld.FieldsTerminated,
+		FieldsEnclosed:   ld.FieldsEnclosed,
+		FieldsEscaped:    ld.FieldsEscaped,
+		LinesStarting:    ld.LinesStarting,
+		LinesTerminated:  ld.LinesTerminated,
+		IgnoreLines:      ld.IgnoreLines,
+	}, pw)
+
+	// The client sends the file and then an empty packet. It is read to the end
+	// even after a failure so the connection stays in sync
+	var convErr error
+	for {
+		data, err := ch.packetConn.ReadPacket()
+		if err != nil {
+			pw.CloseWithError(err)
+			<-done
+			return nil, err
+		}
+		if len(data) == 0 {
+			break
+		}
+		if convErr == nil {
+			_, convErr = converter.Write(data)
+		}
+	}
+	if convErr == nil {
+		convErr = converter.Close()
+	}
+	pw.CloseWithError(convErr)
+
+	result := <-done
+	if err := result.err; err != nil || convErr != nil {
+		if err == nil {
+			err = convErr
+		}
+		ch.handler.metrics.IncErrors("query")
+		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
+		return nil, ch.handler.errorMapper.MySQLError(err)
+	}
+
+	rowsAffected := result.tag.RowsAffected()
+	ch.handler.metrics.ObserveQueryDuration(time.Since(startTime).Seconds())
+	ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), rowsAffected, nil)
+	return &mysql.Result{AffectedRows: uint64(rowsAffected)}, nil
+}
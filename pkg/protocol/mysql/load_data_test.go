@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePacketConn records the file request instead of talking to a client
+type fakePacketConn struct {
+	written [][]byte
+}
+
+func (c *fakePacketConn) WritePacket(data []byte) error {
+	c.written = append(c.written, data)
+	return nil
+}
+
+func (c *fakePacketConn) ReadPacket() ([]byte, error) {
+	return nil, nil
+}
+
+func TestLoadDataRejected(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+	conn := &fakePacketConn{}
+	ch.SetPacketConn(conn)
+	ch.SetClientCapabilities(mysql.CLIENT_PROTOCOL_41 | mysql.CLIENT_LOCAL_FILES)
+
+	tests := []struct {
+		name string
+		sql  string
+		code uint16
+	}{
+		{"Server-side file", "LOAD DATA INFILE '/etc/passwd' INTO TABLE t", mysql.ER_NOT_ALLOWED_COMMAND},
+		{"REPLACE", "LOAD DATA LOCAL INFILE 'x.csv' REPLACE INTO TABLE t", mysql.ER_NOT_SUPPORTED_YET},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ch.handleLoadData(context.Background(), tt.sql, time.Now())
+			var myErr *mysql.MyError
+			require.ErrorAs(t, err, &myErr)
+			assert.Equal(t, tt.code, myErr.Code)
+		})
+	}
+
+	t.Run("Client without local infile", func(t *testing.T) {
+		ch.SetClientCapabilities(mysql.CLIENT_PROTOCOL_41)
+		_, err := ch.handleLoadData(context.Background(), "LOAD DATA LOCAL INFILE 'x.csv' INTO TABLE t", time.Now())
+		var myErr *mysql.MyError
+		require.ErrorAs(t, err, &myErr)
+		assert.Equal(t, uint16(mysql.ER_NOT_ALLOWED_COMMAND), myErr.Code)
+	})
+
+	// No file was requested from the client
+	assert.Empty(t, conn.written)
+}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"aproxy/pkg/sqlrewrite"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordRewriteFailure(t *testing.T) {
+	h := newTestHandler(t)
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	counter := h.metrics.RewriteFailures.WithLabelValues("parse_error", "SELECT")
+	before := testutil.ToFloat64(counter)
+
+	_, err = h.rewriter.Rewrite("SELECT FROM")
+	require.Error(t, err)
+	ch.recordRewriteFailure(err)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	assert.Equal(t, float64(0), testutil.ToFloat64(h.metrics.RewriteFailures.WithLabelValues("unsupported", "SELECT")))
+}
+
+func TestRewriteFailureError(t *testing.T) {
+	h := newTestHandler(t)
+
+	_, err := h.rewriter.Rewrite("CREATE TRIGGER t_bi BEFORE INSERT ON t FOR EACH ROW SET NEW.x = 1")
+	require.Error(t, err)
+	var myErr *mysql.MyError
+	require.ErrorAs(t, rewriteFailureError(err), &myErr)
+	assert.Equal(t, uint16(mysql.ER_NOT_SUPPORTED_YET), myErr.Code)
+	assert.Contains(t, myErr.Message, "CREATE TRIGGER")
+
+	// Parse errors keep their own message
+	_, err = h.rewriter.Rewrite("SELECT FROM")
+	require.Error(t, err)
+	assert.Equal(t, err, rewriteFailureError(err))
+}
+
+func TestParamCountMismatch(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+
+	// A rewrite numbering a different number of placeholders than the client binds
+	err := &sqlrewrite.RewriteError{
+		Reason:  sqlrewrite.ReasonGenerate,
+		Feature: "SELECT",
+		Err:     &sqlrewrite.ParamCountError{Placeholders: 2, Rewritten: 4},
+	}
+
+	before := testutil.ToFloat64(h.metrics.ParamMismatches)
+	ch.recordRewriteFailure(err)
+	assert.Equal(t, before+1, testutil.ToFloat64(h.metrics.ParamMismatches))
+
+	var myErr *mysql.MyError
+	require.ErrorAs(t, rewriteFailureError(err), &myErr)
+	assert.Equal(t, uint16(mysql.ER_WRONG_ARGUMENTS), myErr.Code)
+	assert.Contains(t, myErr.Message, "rewritten statement has 4 placeholders, the original has 2")
+
+	// Other rewrite failures are not counted
+	ch.recordRewriteFailure(&sqlrewrite.RewriteError{Reason: sqlrewrite.ReasonParse, Feature: "SELECT", Err: fmt.Errorf("syntax")})
+	assert.Equal(t, before+1, testutil.ToFloat64(h.metrics.ParamMismatches))
+}
@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"errors"
+	"io"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"go.uber.org/zap"
+)
+
+// maxPayloadLen is the largest payload of one packet on the wire, longer payloads are
+// split into chunks of this size followed by a shorter one (possibly empty)
+const maxPayloadLen = 1<<24 - 1
+
+// sslRequestLen is the payload length of an SSLRequest. A HandshakeResponse41 always
+// carries a user name after the same 32 bytes, so it is longer
+const sslRequestLen = 32
+
+var errPacketTooLarge = errors.New("packet bigger than max_allowed_packet")
+
+// SetMaxPacketSize sets the largest packet payload accepted from clients, what
+// @@max_allowed_packet is on a MySQL server (0 disables the limit)
+//
+// Outbound packets need no limit here, go-mysql already splits payloads of 16MB and
+// more into chunks as the protocol requires
+func (h *Handler) SetMaxPacketSize(size int64) {
+	h.maxPacketSize = size
+	h.rewriter.SetMaxAllowedPacket(size)
+}
+
+// packetLimit follows the packet headers of the client stream and stops at the first
+// packet over max bytes, before go-mysql reads (and allocates) its payload. Chunks of
+// a split packet add up. Once the client asks for TLS only ciphertext follows, the
+// commands are then checked when they arrive, see checkPacketSize. The first packet
+// is always looked at, it tells whether the client uses TLS
+type packetLimit struct {
+	max    int64
+	tls    bool    // The client switched to TLS
+	first  bool    // The first client packet has been seen
+	header [4]byte // Header being read
+	n      int     // Header bytes read
+	left   int     // Payload bytes of the current chunk still to come
+	size   int64   // Payload bytes of the current packet so far
+}
+
+// scan follows the packets in data, the next bytes of the stream. It reports the
+// sequence id of the chunk making a packet too large
+func (l *packetLimit) scan(data []byte) (byte, bool) {
+	for !l.tls && len(data) > 0 && (l.max > 0 || !l.first) {
+		if l.left > 0 {
+			k := min(l.left, len(data))
+			l.left -= k
+			data = data[k:]
+			continue
+		}
+
+		k := copy(l.header[l.n:], data)
+		l.n += k
+		data = data[k:]
+		if l.n < len(l.header) {
+			break
+		}
+		l.n = 0
+
+		length := int(l.header[0]) | int(l.header[1])<<8 | int(l.header[2])<<16
+		if !l.first {
+			l.first = true
+			l.tls = length == sslRequestLen
+		}
+		l.size += int64(length)
+		l.left = length
+		if l.max > 0 && l.size > l.max {
+			l.left -= min(l.left, len(data))
+			return l.header[3], true
+		}
+		if length < maxPayloadLen {
+			l.size = 0
+		}
+	}
+	return 0, false
+}
+
+// Read rejects a packet over the limit like MySQL does: ER_NET_PACKET_TOO_LARGE is
+// sent and the connection fails. The rest of the chunk is skipped first, a client
+// still writing it would otherwise fail with a broken pipe before reading the error
+func (c *handshakeConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if seq, tooLarge := c.limit.scan(p[:n]); tooLarge {
+		if c.tooLarge != nil {
+			c.tooLarge()
+		}
+		if _, err := io.CopyN(io.Discard, c.Conn, int64(c.limit.left)); err == nil {
+			c.Conn.Write(errPacket(seq+1, mysql.NewDefaultError(mysql.ER_NET_PACKET_TOO_LARGE)))
+		}
+		return 0, errPacketTooLarge
+	}
+	return n, err
+}
+
+// errPacket builds an ERR packet with its header
+func errPacket(seq byte, e *mysql.MyError) []byte {
+	payload := []byte{mysql.ERR_HEADER, byte(e.Code), byte(e.Code >> 8), '#'}
+	payload = append(payload, e.State...)
+	payload = append(payload, e.Message...)
+	return append([]byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}, payload...)
+}
+
+// packetTooLarge records a connection closed for sending a packet over the limit
+func (ch *ConnectionHandler) packetTooLarge() {
+	ch.handler.metrics.IncErrors("packet_too_large")
+	ch.handler.logger.Warn("Packet bigger than max_allowed_packet",
+		zap.String("session_id", ch.session.ID),
+		zap.String("user", ch.session.User),
+		zap.Int64("max_allowed_packet", ch.handler.maxPacketSize),
+	)
+}
+
+// checkPacketSize rejects a command of size payload bytes over the limit. It only
+// matters on TLS connections, packetLimit stops larger packets on the others. The
+// connection stays usable as the packet has been read anyway
+func (ch *ConnectionHandler) checkPacketSize(size int) error {
+	if ch.handler.maxPacketSize > 0 && int64(size) > ch.handler.maxPacketSize {
+		ch.packetTooLarge()
+		return mysql.NewDefaultError(mysql.ER_NET_PACKET_TOO_LARGE)
+	}
+	return nil
+}
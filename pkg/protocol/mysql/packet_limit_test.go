@@ -0,0 +1,135 @@
+package mysql
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/client"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requirePacketTooLarge(t *testing.T, err error) {
+	var myErr *mysql.MyError
+	require.ErrorAs(t, err, &myErr)
+	assert.Equal(t, uint16(mysql.ER_NET_PACKET_TOO_LARGE), myErr.Code)
+	assert.Equal(t, "08S01", myErr.State)
+}
+
+func TestMaxPacketSize(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetMaxPacketSize(1024)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	provider := server.NewInMemoryProvider()
+	provider.AddUser("app", "secret")
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		ch, err := h.NewConnection(c)
+		if err != nil {
+			return
+		}
+		defer ch.Close()
+		mysqlConn, err := server.NewDefaultServer().NewCustomizedConn(ch.NetConn(), provider, ch)
+		if err != nil {
+			return
+		}
+		for mysqlConn.HandleCommand() == nil {
+		}
+	}()
+
+	conn, err := client.Connect(listener.Addr().String(), "app", "secret", "")
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.Ping())
+
+	_, err = conn.Execute("SELECT '" + strings.Repeat("x", 4096) + "'")
+	requirePacketTooLarge(t, err)
+
+	// MySQL closes the connection as well
+	assert.Error(t, conn.Ping())
+}
+
+func TestPacketLimitScan(t *testing.T) {
+	header := func(length int, seq byte) []byte {
+		return []byte{byte(length), byte(length >> 8), byte(length >> 16), seq}
+	}
+
+	t.Run("Packets under the limit", func(t *testing.T) {
+		l := packetLimit{max: 100}
+		data := append(header(100, 0), make([]byte, 100)...)
+		data = append(data, header(5, 0)...)
+		// Headers split across reads are put together
+		for _, b := range data {
+			_, tooLarge := l.scan([]byte{b})
+			require.False(t, tooLarge)
+		}
+	})
+
+	t.Run("Packet over the limit", func(t *testing.T) {
+		l := packetLimit{max: 100}
+		_, tooLarge := l.scan(append(header(5, 0), make([]byte, 5)...))
+		require.False(t, tooLarge)
+		seq, tooLarge := l.scan(append(header(101, 0), make([]byte, 10)...))
+		require.True(t, tooLarge)
+		assert.Equal(t, byte(0), seq)
+		assert.Equal(t, 91, l.left, "the rest of the chunk to skip")
+	})
+
+	t.Run("Chunks of a split packet add up", func(t *testing.T) {
+		l := packetLimit{max: maxPayloadLen + 10}
+		_, tooLarge := l.scan(header(maxPayloadLen, 0))
+		require.False(t, tooLarge)
+		_, tooLarge = l.scan(make([]byte, maxPayloadLen))
+		require.False(t, tooLarge)
+		seq, tooLarge := l.scan(header(11, 1))
+		require.True(t, tooLarge)
+		assert.Equal(t, byte(1), seq)
+	})
+
+	t.Run("Not followed through TLS", func(t *testing.T) {
+		l := packetLimit{max: 100}
+		_, tooLarge := l.scan(append(header(sslRequestLen, 1), make([]byte, sslRequestLen)...))
+		require.False(t, tooLarge)
+		_, tooLarge = l.scan(header(1000, 2))
+		assert.False(t, tooLarge)
+	})
+
+	t.Run("TLS seen without a limit", func(t *testing.T) {
+		l := packetLimit{}
+		l.scan(header(sslRequestLen, 1))
+		assert.True(t, l.tls)
+
+		l = packetLimit{}
+		l.scan(append(header(100, 1), make([]byte, 100)...))
+		assert.False(t, l.tls)
+	})
+}
+
+func TestMaxPacketSizeCommands(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetMaxPacketSize(1024)
+	ch := newTestConnection(t, h)
+
+	// Commands read on TLS connections are checked when handled
+	query := "SELECT '" + strings.Repeat("x", 1024) + "'"
+	_, err := ch.HandleQuery(query)
+	requirePacketTooLarge(t, err)
+	_, _, _, err = ch.HandleStmtPrepare(query)
+	requirePacketTooLarge(t, err)
+
+	// Connectors size their packets after @@max_allowed_packet
+	stmt, err := h.rewriter.Rewrite("SELECT @@max_allowed_packet")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT 1024 AS "@@max_allowed_packet"`, stmt)
+}
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// pingTimeout bounds the backend check of COM_PING, a hung backend must not hang the keepalive
+const pingTimeout = 5 * time.Second
+
+// backendPing configures COM_PING. By default a ping only shows the proxy is up; with
+// the backend check a client's keepalive also notices that PostgreSQL went away
+type backendPing struct {
+	enabled  bool
+	interval time.Duration // Skip the check when it succeeded this recently
+}
+
+// SetBackendPing makes COM_PING check the session's PostgreSQL backend. A check that
+// succeeded less than interval ago answers the next pings without a round trip
+func (h *Handler) SetBackendPing(enabled bool, interval time.Duration) {
+	h.backendPing = backendPing{enabled: enabled, interval: interval}
+}
+
+// handlePing answers COM_PING, pinging the session's backend connection when the
+// backend check is on, or the pool when the session has not acquired one yet
+func (ch *ConnectionHandler) handlePing() error {
+	ping := ch.handler.backendPing
+	if !ping.enabled || time.Since(ch.backendVerified) < ping.interval {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	var err error
+	if ch.pgConn != nil {
+		err = ch.pgConn.Ping(ctx)
+	} else {
+		err = ch.pgPool.Ping(ctx)
+	}
+	if err != nil {
+		ch.backendVerified = time.Time{}
+		ch.handler.metrics.IncErrors("connection")
+		ch.handler.logger.LogError(ch.session.ID, ch.session.User, ch.session.ClientAddr, "ping", err)
+		return mysql.NewError(mysql.ER_UNKNOWN_ERROR, fmt.Sprintf("PostgreSQL backend unavailable: %v", err))
+	}
+
+	ch.backendVerified = time.Now()
+	return nil
+}
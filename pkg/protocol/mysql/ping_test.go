@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend gives ch a PostgreSQL connection to a fake backend answering queries with
+// an empty result until stop is called, which takes the backend down
+func fakeBackend(t *testing.T, ch *ConnectionHandler) (stop func()) {
+	b := newFakePGBackend(t, ch, nil, func(_ *fakePGBackend, backend *pgproto3.Backend, msg pgproto3.FrontendMessage) {
+		if _, ok := msg.(*pgproto3.Query); ok {
+			backend.Send(&pgproto3.EmptyQueryResponse{})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		}
+	})
+	return b.stop
+}
+
+func requirePingError(t *testing.T, err error) {
+	var myErr *mysql.MyError
+	require.ErrorAs(t, err, &myErr)
+	assert.Equal(t, uint16(mysql.ER_UNKNOWN_ERROR), myErr.Code)
+	assert.Contains(t, myErr.Message, "PostgreSQL backend unavailable")
+}
+
+func TestPingBackendDown(t *testing.T) {
+	t.Run("Backend check off", func(t *testing.T) {
+		h := newTestHandler(t)
+		ch := newTestConnection(t, h)
+		stop := fakeBackend(t, ch)
+
+		stop()
+		assert.NoError(t, ch.HandleOtherCommand(mysql.COM_PING, nil), "ping only reflects the proxy")
+	})
+
+	t.Run("Backend check on", func(t *testing.T) {
+		h := newTestHandler(t)
+		h.SetBackendPing(true, 0)
+		ch := newTestConnection(t, h)
+		stop := fakeBackend(t, ch)
+
+		require.NoError(t, ch.HandleOtherCommand(mysql.COM_PING, nil))
+
+		stop()
+		requirePingError(t, ch.HandleOtherCommand(mysql.COM_PING, nil))
+	})
+
+	t.Run("Recently verified", func(t *testing.T) {
+		h := newTestHandler(t)
+		h.SetBackendPing(true, time.Hour)
+		ch := newTestConnection(t, h)
+		stop := fakeBackend(t, ch)
+
+		require.NoError(t, ch.HandleOtherCommand(mysql.COM_PING, nil))
+
+		// Within the interval the ping is answered without asking the backend
+		stop()
+		assert.NoError(t, ch.HandleOtherCommand(mysql.COM_PING, nil))
+
+		ch.backendVerified = time.Now().Add(-2 * time.Hour)
+		requirePingError(t, ch.HandleOtherCommand(mysql.COM_PING, nil))
+	})
+}
@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"aproxy/pkg/schema"
+	"aproxy/pkg/sqlrewrite"
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// rawDirective strips a leading /*aproxy:raw*/ off query and reports whether the
+// statement is PostgreSQL SQL to send as written. It applies to one statement only
+//
+//	/*aproxy:raw*/ SELECT '{"a":1}'::jsonb ->> 'a'
+func rawDirective(query string) (string, bool) {
+	raw, rest := parseDirective(query, directiveRaw)
+	if raw != directiveOnce {
+		return query, false
+	}
+	return rest, true
+}
+
+// handleRawQuery runs a /*aproxy:raw*/ statement on PostgreSQL without rewriting
+// it. The proxy does not follow what it does: BEGIN, SET or USE sent this way are
+// not seen by the session, and INSERT reports no last insert id (RETURNING does)
+func (ch *ConnectionHandler) handleRawQuery(ctx context.Context, query string, startTime time.Time, debugSQL bool) (*mysql.Result, error) {
+	stmt := sqlrewrite.RawStatement(query)
+	if debugSQL {
+		ch.logRewrite(query, stmt.SQL)
+	}
+
+	var result *mysql.Result
+	var rowCount int64
+	if stmt.Type.IsWrite() && !stmt.Returning {
+		cmdTag, err := ch.pgConn.Exec(ctx, stmt.SQL)
+		if err != nil {
+			ch.handler.metrics.IncErrors("query")
+			ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
+			return nil, ch.handler.errorMapper.MySQLError(err)
+		}
+		if stmt.Type == sqlrewrite.StatementDDL {
+			// The statement is not parsed for the table it changes
+			schema.GetGlobalCache().InvalidateAll()
+		}
+		rowCount = cmdTag.RowsAffected()
+		result = &mysql.Result{Status: 0, AffectedRows: uint64(rowCount)}
+	} else {
+		var converting bool
+		var err error
+		result, converting, err = ch.queryResult(ctx, strings.ToUpper(stmt.SQL), stmt.SQL, false)
+		if err != nil {
+			if converting {
+				ch.handler.metrics.IncErrors("result_conversion")
+				return nil, err
+			}
+			ch.handler.metrics.IncErrors("query")
+			ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
+			return nil, ch.handler.errorMapper.MySQLError(err)
+		}
+		if result.Resultset != nil {
+			rowCount = int64(len(result.Resultset.RowDatas))
+		}
+	}
+
+	duration := time.Since(startTime).Seconds()
+	ch.handler.metrics.ObserveQueryDuration(duration)
+	ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, duration, rowCount, nil)
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawDirective(t *testing.T) {
+	query, raw := rawDirective("/*aproxy:raw*/ SELECT '{\"a\":1}'::jsonb ? 'a'")
+	assert.True(t, raw)
+	assert.Equal(t, "SELECT '{\"a\":1}'::jsonb ? 'a'", query)
+
+	// A single statement only
+	for _, query := range []string{"SELECT 1", "/*aproxy:raw=on*/ SELECT 1", "/* raw */ SELECT 1"} {
+		rest, raw := rawDirective(query)
+		assert.False(t, raw, query)
+		assert.Equal(t, query, rest)
+	}
+}
+
+func TestRawDryRun(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+
+	// Dollar quoting and :: casts fail to parse as MySQL
+	pg := "SELECT $$a;b$$::text AS s, now()::date"
+	result, err := ch.HandleQuery("/*aproxy:dry_run*/ " + pg)
+	require.NoError(t, err)
+	_, supported, _ := dryRunReport(t, result)
+	assert.Equal(t, int64(0), supported)
+
+	// The raw path does not rewrite it, nor strip its comments
+	pg += " /* pg */"
+	result, err = ch.HandleQuery("/*aproxy:dry_run*/ /*aproxy:raw*/ " + pg + ";")
+	require.NoError(t, err)
+	statement, supported, warning := dryRunReport(t, result)
+	assert.Equal(t, pg, statement)
+	assert.Equal(t, int64(1), supported)
+	assert.Empty(t, warning)
+}
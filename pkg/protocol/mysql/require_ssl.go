@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/server"
+)
+
+// SetRequireSSL sets the users that may only log in over TLS, what REQUIRE SSL is
+// on a MySQL account. Other users may still connect without it
+func (h *Handler) SetRequireSSL(users []string) {
+	h.requireSSL = make(map[string]bool, len(users))
+	for _, user := range users {
+		h.requireSSL[user] = true
+	}
+}
+
+// TLS reports whether the client switched the connection to TLS during the handshake
+func (ch *ConnectionHandler) TLS() bool {
+	return ch.conn.limit.tls
+}
+
+// checkSSL rejects user on a connection without TLS when the user requires SSL
+func (ch *ConnectionHandler) checkSSL(user string) error {
+	if !ch.handler.requireSSL[user] || ch.TLS() {
+		return nil
+	}
+	ch.handler.metrics.IncErrors("auth")
+	return mysql.NewError(mysql.ER_ACCESS_DENIED_ERROR,
+		fmt.Sprintf("Access denied for user '%s'@'%s' (SSL connection required)", user, ch.session.ClientAddr))
+}
+
+// sslCredentialProvider rejects users requiring SSL during the handshake of a
+// connection without TLS, before their password is checked. The client has sent
+// its SSLRequest by the time the user name arrives
+type sslCredentialProvider struct {
+	server.CredentialProvider
+	conn *ConnectionHandler
+}
+
+// Credentials wraps p to enforce the SSL requirement of users on this connection
+func (ch *ConnectionHandler) Credentials(p server.CredentialProvider) server.CredentialProvider {
+	return &sslCredentialProvider{CredentialProvider: p, conn: ch}
+}
+
+func (p *sslCredentialProvider) GetCredential(username string) (string, bool, error) {
+	if err := p.conn.checkSSL(username); err != nil {
+		return "", false, err
+	}
+	return p.CredentialProvider.GetCredential(username)
+}
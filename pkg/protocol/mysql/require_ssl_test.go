@@ -0,0 +1,104 @@
+package mysql
+
+import (
+	"net"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/client"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireSSLError(t *testing.T, err error) {
+	var myErr *mysql.MyError
+	require.ErrorAs(t, err, &myErr)
+	assert.Equal(t, uint16(mysql.ER_ACCESS_DENIED_ERROR), myErr.Code)
+	assert.Equal(t, "28000", myErr.State)
+	assert.Contains(t, myErr.Message, "SSL connection required")
+}
+
+func TestRequireSSL(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetRequireSSL([]string{"secure"})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	provider := server.NewInMemoryProvider()
+	provider.AddUser("secure", "secret")
+	provider.AddUser("app", "secret")
+	mysqlServer := server.NewDefaultServer()
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				ch, err := h.NewConnection(c)
+				if err != nil {
+					return
+				}
+				defer ch.Close()
+				mysqlConn, err := mysqlServer.NewCustomizedConn(ch.NetConn(), ch.Credentials(provider), ch)
+				if err != nil {
+					return
+				}
+				for mysqlConn.HandleCommand() == nil {
+				}
+			}()
+		}
+	}()
+	addr := listener.Addr().String()
+	useSSL := func(c *client.Conn) error {
+		c.UseSSL(true)
+		return nil
+	}
+
+	t.Run("Without TLS", func(t *testing.T) {
+		_, err := client.Connect(addr, "secure", "secret", "")
+		requireSSLError(t, err)
+	})
+
+	t.Run("With TLS", func(t *testing.T) {
+		conn, err := client.Connect(addr, "secure", "secret", "", useSSL)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.NoError(t, conn.Ping())
+	})
+
+	t.Run("Other users need no TLS", func(t *testing.T) {
+		conn, err := client.Connect(addr, "app", "secret", "")
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.NoError(t, conn.Ping())
+	})
+
+	t.Run("Wrong password over TLS", func(t *testing.T) {
+		_, err := client.Connect(addr, "secure", "wrong", "", useSSL)
+		var myErr *mysql.MyError
+		require.ErrorAs(t, err, &myErr)
+		assert.Equal(t, uint16(mysql.ER_ACCESS_DENIED_ERROR), myErr.Code)
+		assert.NotContains(t, myErr.Message, "SSL")
+	})
+}
+
+func TestRequireSSLChangeUser(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetCredentials(map[string]string{"root": "", "secure": ""})
+	h.SetRequireSSL([]string{"secure"})
+
+	ch := newTestConnection(t, h)
+	require.NoError(t, ch.SetUser("root"))
+	err := ch.HandleOtherCommand(mysql.COM_CHANGE_USER, buildChangeUser("secure", nil, ""))
+	requireSSLError(t, err)
+	assert.Equal(t, "root", ch.session.User)
+
+	ch.conn.limit.tls = true
+	require.NoError(t, ch.HandleOtherCommand(mysql.COM_CHANGE_USER, buildChangeUser("secure", nil, "")))
+	assert.Equal(t, "secure", ch.session.User)
+}
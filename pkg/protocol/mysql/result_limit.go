@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"go.uber.org/zap"
+)
+
+// resultLimit caps the rows a single result set may return
+type resultLimit struct {
+	maxRows  int            // 0 means unlimited
+	perUser  map[string]int // Overrides maxRows for individual users
+	truncate bool           // Return the first maxRows rows instead of an error
+}
+
+// SetResultLimit caps the rows of a result set, 0 means unlimited. perUser overrides
+// the cap for individual users. With truncate, the rows past the cap are dropped
+// and a warning is logged instead of failing the query
+func (h *Handler) SetResultLimit(maxRows int, perUser map[string]int, truncate bool) {
+	h.resultLimit = resultLimit{maxRows: maxRows, perUser: perUser, truncate: truncate}
+}
+
+// maxResultRows returns the row cap for the session's user, 0 means unlimited
+func (ch *ConnectionHandler) maxResultRows() int {
+	limit := ch.handler.resultLimit
+	if maxRows, ok := limit.perUser[ch.session.User]; ok {
+		return maxRows
+	}
+	return limit.maxRows
+}
+
+// exceedResultLimit is called when a result set produces a row past the cap. The
+// rows are checked as they are read, so the remaining rows are never buffered
+func (ch *ConnectionHandler) exceedResultLimit(maxRows int) error {
+	ch.handler.metrics.IncErrors("result_limit")
+	if !ch.handler.resultLimit.truncate {
+		return mysql.NewError(mysql.ER_TOO_BIG_SELECT,
+			fmt.Sprintf("Result set exceeds the limit of %d rows for user '%s'", maxRows, ch.session.User))
+	}
+	ch.handler.logger.Warn("Result set truncated",
+		zap.String("session_id", ch.session.ID),
+		zap.String("user", ch.session.User),
+		zap.Int("max_rows", maxRows),
+	)
+	return nil
+}
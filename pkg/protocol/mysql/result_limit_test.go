@@ -0,0 +1,78 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRows yields n single-column integer rows and counts how many were read
+type fakeRows struct {
+	n, read int
+}
+
+func (r *fakeRows) Close()                        {}
+func (r *fakeRows) Err() error                    { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	return []pgconn.FieldDescription{{Name: "id", DataTypeOID: pgtype.Int8OID}}
+}
+func (r *fakeRows) Next() bool {
+	if r.read >= r.n {
+		return false
+	}
+	r.read++
+	return true
+}
+func (r *fakeRows) Scan(dest ...any) error { return nil }
+func (r *fakeRows) Values() ([]any, error) { return []any{int64(r.read)}, nil }
+func (r *fakeRows) RawValues() [][]byte    { return nil }
+func (r *fakeRows) Conn() *pgx.Conn        { return nil }
+
+func TestResultLimit(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetResultLimit(3, map[string]int{"etl": 0, "report": 5}, false)
+
+	ch := newTestConnection(t, h)
+	require.NoError(t, ch.SetUser("app"))
+
+	t.Run("Within the limit", func(t *testing.T) {
+		result, err := ch.buildMySQLResult(&fakeRows{n: 3}, false)
+		require.NoError(t, err)
+		assert.Len(t, result.Resultset.RowDatas, 3)
+	})
+
+	t.Run("Exceeding the limit is rejected", func(t *testing.T) {
+		rows := &fakeRows{n: 1000}
+		_, err := ch.buildMySQLResult(rows, false)
+		var myErr *mysql.MyError
+		require.ErrorAs(t, err, &myErr)
+		assert.Equal(t, uint16(mysql.ER_TOO_BIG_SELECT), myErr.Code)
+		assert.Equal(t, 4, rows.read, "rows past the limit must not be read")
+	})
+
+	t.Run("Per-user overrides", func(t *testing.T) {
+		require.NoError(t, ch.SetUser("report"))
+		result, err := ch.buildMySQLResult(&fakeRows{n: 5}, false)
+		require.NoError(t, err)
+		assert.Len(t, result.Resultset.RowDatas, 5)
+
+		require.NoError(t, ch.SetUser("etl"))
+		result, err = ch.buildMySQLResult(&fakeRows{n: 1000}, false)
+		require.NoError(t, err)
+		assert.Len(t, result.Resultset.RowDatas, 1000)
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		h.SetResultLimit(3, nil, true)
+		require.NoError(t, ch.SetUser("app"))
+		result, err := ch.buildMySQLResult(&fakeRows{n: 10}, true)
+		require.NoError(t, err)
+		assert.Len(t, result.Resultset.RowDatas, 3)
+	})
+}
@@ -0,0 +1,28 @@
+package mysql
+
+import (
+	"time"
+
+	"aproxy/pkg/mapper"
+)
+
+// serializationRetryBackoff is the pause before the first retry, doubled per attempt
+var serializationRetryBackoff = 10 * time.Millisecond
+
+// retrySerializationFailure runs fn and re-runs it up to retries more times while
+// it fails with a serialization failure or deadlock. Callers must only use it for
+// statements that are safe to repeat (reads outside an explicit transaction)
+func retrySerializationFailure(retries int, fn func() error, onRetry func(attempt int, err error)) error {
+	backoff := serializationRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt > retries || !mapper.IsSerializationFailure(err) {
+			return err
+		}
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
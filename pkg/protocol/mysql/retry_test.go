@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrySerializationFailure_SucceedsOnRetry(t *testing.T) {
+	serializationRetryBackoff = 0
+
+	calls := 0
+	retried := 0
+	err := retrySerializationFailure(3, func() error {
+		calls++
+		if calls == 1 {
+			return &pgconn.PgError{Code: "40001", Message: "could not serialize access due to concurrent update"}
+		}
+		return nil
+	}, func(attempt int, err error) {
+		retried++
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, retried)
+}
+
+func TestRetrySerializationFailure_GivesUp(t *testing.T) {
+	serializationRetryBackoff = 0
+
+	calls := 0
+	err := retrySerializationFailure(2, func() error {
+		calls++
+		return &pgconn.PgError{Code: "40P01", Message: "deadlock detected"}
+	}, nil)
+
+	var pge *pgconn.PgError
+	assert.True(t, errors.As(err, &pge))
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetrySerializationFailure_OtherErrorsNotRetried(t *testing.T) {
+	calls := 0
+	err := retrySerializationFailure(3, func() error {
+		calls++
+		return &pgconn.PgError{Code: "42P01", Message: "relation does not exist"}
+	}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetrySerializationFailure_Disabled(t *testing.T) {
+	calls := 0
+	err := retrySerializationFailure(0, func() error {
+		calls++
+		return &pgconn.PgError{Code: "40001"}
+	}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"context"
+
+	"aproxy/pkg/sqlrewrite"
+	"go.uber.org/zap"
+)
+
+// applySafeMode strips a leading safe-mode directive off query, updating the session
+// switch it sets, and reports whether the statement is validated before it runs.
+// Prepared statements take it as well, they are validated when they are prepared
+func (ch *ConnectionHandler) applySafeMode(query string) (string, bool) {
+	safeMode, query := parseDirective(query, directiveSafeMode)
+	if safeMode == directiveOn || safeMode == directiveOff {
+		ch.session.SafeMode = safeMode == directiveOn
+	}
+	return query, safeMode == directiveOnce || ch.session.SafeMode || ch.handler.safeMode.Load()
+}
+
+// validateRewrite plans a rewritten statement with EXPLAIN, which does not execute it,
+// so a conversion PostgreSQL rejects fails before any row or table is touched. A
+// prepared statement with placeholders has no values yet and is only parsed and
+// analyzed. DDL has no plan and runs unchecked.
+//
+// Inside a transaction a rejected statement aborts it, as running it would have
+func (ch *ConnectionHandler) validateRewrite(ctx context.Context, query string, stmtType sqlrewrite.StatementType, sql string, params int) error {
+	if !stmtType.CanExplain() {
+		return nil
+	}
+
+	var err error
+	if params > 0 {
+		_, err = ch.pgConn.PgConn().Prepare(ctx, "", "EXPLAIN "+sql, nil)
+	} else {
+		_, err = ch.pgConn.PgConn().Exec(ctx, "EXPLAIN "+sql).ReadAll()
+	}
+	if err == nil {
+		return nil
+	}
+
+	ch.recordRewriteFailure(sqlrewrite.NewValidationError(query, err))
+	ch.handler.logger.Warn("Rewritten statement failed validation",
+		zap.String("session_id", ch.session.ID),
+		zap.String("mysql", query),
+		zap.String("pg", sql),
+		zap.Error(err))
+	myErr := ch.handler.errorMapper.MySQLError(err)
+	myErr.Message = "aproxy safe mode: rewritten statement failed validation: " + myErr.Message
+	return myErr
+}
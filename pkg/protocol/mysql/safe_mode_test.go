@@ -0,0 +1,136 @@
+package mysql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newValidatingBackend attaches a fake PostgreSQL backend to ch that rejects every
+// statement calling missing_fn, the way PostgreSQL rejects an unknown function when
+// planning or parsing
+func newValidatingBackend(t *testing.T, ch *ConnectionHandler) *fakePGBackend {
+	missingFn := &pgproto3.ErrorResponse{Severity: "ERROR", Code: "42883", Message: "function missing_fn(numeric) does not exist"}
+	failed := false
+	return newFakePGBackend(t, ch, nil, func(b *fakePGBackend, backend *pgproto3.Backend, msg pgproto3.FrontendMessage) {
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			// Column lookups of the tables a statement uses are not recorded, see loadTableColumns
+			if !strings.Contains(msg.String, "information_schema.columns") {
+				b.record(msg.String)
+			}
+			switch {
+			case strings.Contains(msg.String, "MISSING_FN"):
+				backend.Send(missingFn)
+			case strings.HasPrefix(msg.String, "EXPLAIN"):
+				backend.Send(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+					{Name: []byte("QUERY PLAN"), DataTypeOID: pgtype.TextOID, DataTypeSize: -1}}})
+				backend.Send(&pgproto3.DataRow{Values: [][]byte{[]byte("Seq Scan on orders")}})
+				backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("EXPLAIN")})
+			default:
+				backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("UPDATE 1")})
+			}
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		case *pgproto3.Parse:
+			b.record("PARSE " + msg.Query)
+			if failed = strings.Contains(msg.Query, "MISSING_FN"); failed {
+				backend.Send(missingFn)
+			} else {
+				backend.Send(&pgproto3.ParseComplete{})
+			}
+		case *pgproto3.Describe:
+			if !failed {
+				backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: []uint32{pgtype.Int4OID}})
+				backend.Send(&pgproto3.NoData{})
+			}
+		case *pgproto3.Sync:
+			failed = false
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		}
+	})
+}
+
+func requireValidationError(t *testing.T, err error) {
+	var myErr *mysql.MyError
+	require.ErrorAs(t, err, &myErr)
+	// The code is the one PostgreSQL's error maps to
+	assert.Equal(t, uint16(mysql.ER_PARSE_ERROR), myErr.Code)
+	assert.Contains(t, myErr.Message, "aproxy safe mode: rewritten statement failed validation")
+	assert.Contains(t, myErr.Message, "missing_fn")
+}
+
+func TestSafeMode(t *testing.T) {
+	h := newTestHandler(t)
+	// A mapping to a function PostgreSQL does not have stands in for a broken rewrite
+	require.NoError(t, h.rewriter.SetFunctionMappings(map[string]string{"calc_tax": "missing_fn"}))
+	ch := newTestConnection(t, h)
+	backend := newValidatingBackend(t, ch)
+
+	t.Run("Off by default", func(t *testing.T) {
+		_, err := ch.HandleQuery("UPDATE orders SET total = total + 1 WHERE id = 1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`UPDATE "orders" SET "total"="total"+1 WHERE "id"=1`}, backend.take())
+	})
+
+	t.Run("Broken rewrite caught before it runs", func(t *testing.T) {
+		_, err := ch.HandleQuery("/*aproxy:safe_mode*/ UPDATE orders SET total = calc_tax(total) WHERE id = 1")
+		requireValidationError(t, err)
+		// Only the EXPLAIN reached PostgreSQL
+		assert.Equal(t, []string{`EXPLAIN UPDATE "orders" SET "total"=MISSING_FN("total") WHERE "id"=1`}, backend.take())
+	})
+
+	t.Run("Valid statement runs after its plan", func(t *testing.T) {
+		_, err := ch.HandleQuery("/*aproxy:safe_mode*/ UPDATE orders SET total = total + 1 WHERE id = 1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`EXPLAIN UPDATE "orders" SET "total"="total"+1 WHERE "id"=1`,
+			`UPDATE "orders" SET "total"="total"+1 WHERE "id"=1`,
+		}, backend.take())
+	})
+
+	t.Run("Session switch", func(t *testing.T) {
+		_, err := ch.HandleQuery("/*aproxy:safe_mode=on*/")
+		require.NoError(t, err)
+		assert.True(t, ch.session.SafeMode)
+
+		_, err = ch.HandleQuery("UPDATE orders SET total = calc_tax(total)")
+		requireValidationError(t, err)
+
+		// Prepared statements are analyzed when prepared, their values are not bound yet
+		_, _, _, err = ch.HandleStmtPrepare("SELECT calc_tax(total) FROM orders WHERE id = ?")
+		requireValidationError(t, err)
+		assert.Equal(t, []string{
+			`EXPLAIN UPDATE "orders" SET "total"=MISSING_FN("total")`,
+			`PARSE EXPLAIN SELECT MISSING_FN("total") FROM "orders" WHERE "id"=$1`,
+		}, backend.take())
+
+		// DDL has no plan
+		_, err = ch.HandleQuery("CREATE TABLE t (id INT)")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`CREATE TABLE "t" ("id" INT)`}, backend.take())
+
+		_, err = ch.HandleQuery("/*aproxy:safe_mode=off*/")
+		require.NoError(t, err)
+		_, err = ch.HandleQuery("UPDATE orders SET total = calc_tax(total)")
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "safe mode")
+		backend.take()
+	})
+
+	t.Run("Prepared statement", func(t *testing.T) {
+		_, _, _, err := ch.HandleStmtPrepare("/*aproxy:safe_mode*/ SELECT calc_tax(total) FROM orders WHERE id = ?")
+		requireValidationError(t, err)
+		assert.Equal(t, []string{`PARSE EXPLAIN SELECT MISSING_FN("total") FROM "orders" WHERE "id"=$1`}, backend.take())
+		assert.False(t, ch.session.SafeMode, "the directive applies to the statement only")
+
+		// Without the directive the statement is prepared as it is
+		_, _, _, err = ch.HandleStmtPrepare("SELECT calc_tax(total) FROM orders WHERE id = ?")
+		require.NoError(t, err)
+		assert.Empty(t, backend.take())
+	})
+}
@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// selectIntoUserVars runs the SELECT part of SELECT ... INTO @a, @b and stores the
+// single result row in the session's user variables. Like MySQL, more than one row
+// is an error and an empty result leaves the variables unchanged
+func (ch *ConnectionHandler) selectIntoUserVars(ctx context.Context, query, rewrittenSQL string, vars []string, startTime time.Time) (*mysql.Result, error) {
+	rows, err := ch.pgConn.Query(ctx, rewrittenSQL)
+	if err != nil {
+		ch.handler.metrics.IncErrors("query")
+		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
+		return nil, ch.handler.errorMapper.MySQLError(err)
+	}
+	defer rows.Close()
+
+	if len(rows.FieldDescriptions()) != len(vars) {
+		return nil, mysql.NewError(mysql.ER_WRONG_NUMBER_OF_COLUMNS_IN_SELECT,
+			"The used SELECT statements have a different number of columns")
+	}
+
+	var values []interface{}
+	rowCount := int64(0)
+	for rows.Next() {
+		rowCount++
+		if rowCount > 1 {
+			return nil, mysql.NewError(mysql.ER_TOO_MANY_ROWS, "Result consisted of more than one row")
+		}
+		if values, err = rows.Values(); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		ch.handler.metrics.IncErrors("query")
+		ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, time.Since(startTime).Seconds(), 0, err)
+		return nil, ch.handler.errorMapper.MySQLError(err)
+	}
+
+	if values != nil {
+		for i, name := range vars {
+			ch.session.SetUserVar(name, userVarValue(values[i]))
+		}
+	}
+
+	duration := time.Since(startTime).Seconds()
+	ch.handler.metrics.ObserveQueryDuration(duration)
+	ch.handler.logger.LogQuery(ch.session.ID, ch.session.User, ch.session.ClientAddr, query, duration, rowCount, nil)
+
+	return &mysql.Result{Status: 0, AffectedRows: uint64(rowCount)}, nil
+}
+
+// userVarValue converts a PostgreSQL result value to one of the types user variables
+// hold (int64, uint64, float64, string or nil), so it can later be written back as a literal
+func userVarValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, int64, uint64, float64, string:
+		return val
+	case int:
+		return int64(val)
+	case int8:
+		return int64(val)
+	case int16:
+		return int64(val)
+	case int32:
+		return int64(val)
+	case uint32:
+		return uint64(val)
+	case float32:
+		return float64(val)
+	case bool:
+		if val {
+			return int64(1)
+		}
+		return int64(0)
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05.999999")
+	case driver.Valuer:
+		// pgtype values such as Numeric render themselves
+		dv, err := val.Value()
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return userVarValue(dv)
+	default:
+		return fmt.Sprint(v)
+	}
+}
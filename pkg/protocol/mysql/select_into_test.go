@@ -0,0 +1,20 @@
+package mysql
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserVarValue(t *testing.T) {
+	assert.Equal(t, int64(5), userVarValue(int32(5)))
+	assert.Equal(t, int64(1), userVarValue(true))
+	assert.Equal(t, float64(1.5), userVarValue(float32(1.5)))
+	assert.Equal(t, "abc", userVarValue([]byte("abc")))
+	assert.Nil(t, userVarValue(nil))
+	assert.Equal(t, "2024-01-02 03:04:05", userVarValue(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(t, "12.34", userVarValue(pgtype.Numeric{Int: big.NewInt(1234), Exp: -2, Valid: true}))
+}
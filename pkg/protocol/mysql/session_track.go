@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// Session state information types carried in OK packets (CLIENT_SESSION_TRACK)
+const (
+	sessionTrackSystemVariables  = 0x00
+	sessionTrackSchema           = 0x01
+	sessionTrackTransactionState = 0x05
+)
+
+// Transaction state strings: explicit transaction, implicit (autocommit=0), none
+const (
+	txStateExplicit = "T_______"
+	txStateImplicit = "I_______"
+	txStateNone     = "________"
+)
+
+// SetClientCapabilities records the capability flags the client sent in its handshake response
+func (ch *ConnectionHandler) SetClientCapabilities(capabilities uint32) {
+	ch.clientCapabilities = capabilities
+}
+
+// tracksSessionState reports whether CLIENT_SESSION_TRACK was negotiated
+func (ch *ConnectionHandler) tracksSessionState() bool {
+	return ch.clientCapabilities&ch.handler.handshake.capabilities&mysql.CLIENT_SESSION_TRACK != 0
+}
+
+// trackSessionState queues a session state change for the OK packet that answers
+// the current command. go-mysql cannot attach it, so handshakeConn adds it on the wire
+func (ch *ConnectionHandler) trackSessionState(stateType byte, fields ...string) {
+	if !ch.tracksSessionState() {
+		return
+	}
+	var data []byte
+	for _, field := range fields {
+		data = append(data, mysql.PutLengthEncodedString([]byte(field))...)
+	}
+	ch.conn.sessionState = append(ch.conn.sessionState, stateType)
+	ch.conn.sessionState = append(ch.conn.sessionState, mysql.PutLengthEncodedString(data)...)
+}
+
+func (ch *ConnectionHandler) trackSchema(dbName string) {
+	ch.trackSessionState(sessionTrackSchema, dbName)
+}
+
+func (ch *ConnectionHandler) trackAutocommit(autocommit bool) {
+	value := "OFF"
+	if autocommit {
+		value = "ON"
+	}
+	ch.trackSessionState(sessionTrackSystemVariables, "autocommit", value)
+}
+
+// trackTransactionState reports whether a transaction is open after the current command
+func (ch *ConnectionHandler) trackTransactionState() {
+	state := txStateNone
+	if ch.session.InTransaction {
+		state = txStateExplicit
+		if !ch.session.Autocommit {
+			state = txStateImplicit
+		}
+	}
+	ch.trackSessionState(sessionTrackTransactionState, state)
+}
+
+// appendSessionState adds session state info to an OK packet, header included.
+// go-mysql writes header, affected rows, insert id, status and warnings, with
+// CLIENT_SESSION_TRACK the info string becomes length-encoded and the state follows it.
+// Packets that are not OK packets are returned unchanged
+func appendSessionState(packet []byte, state []byte) []byte {
+	if len(packet) < 5 || packet[4] != mysql.OK_HEADER {
+		return packet
+	}
+	pos := 5
+	for i := 0; i < 2; i++ {
+		_, _, n := mysql.LengthEncodedInt(packet[pos:])
+		pos += n
+	}
+	if pos+4 > len(packet) {
+		return packet
+	}
+
+	out := append([]byte{}, packet[:pos+4]...)
+	status := uint16(out[pos]) | uint16(out[pos+1])<<8 | mysql.SERVER_SESSION_STATE_CHANGED
+	out[pos], out[pos+1] = byte(status), byte(status>>8)
+	out = append(out, mysql.PutLengthEncodedString(packet[pos+4:])...)
+	out = append(out, mysql.PutLengthEncodedString(state)...)
+
+	length := len(out) - 4
+	out[0], out[1], out[2] = byte(length), byte(length>>8), byte(length>>16)
+	return out
+}
@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// okPacket mirrors go-mysql's writeOK with CLIENT_PROTOCOL_41
+func okPacket() []byte {
+	return setHandshakeHeader([]byte{0, 0, 0, 1, mysql.OK_HEADER, 0, 0, byte(mysql.SERVER_STATUS_AUTOCOMMIT), 0, 0, 0})
+}
+
+// sessionStateEntries decodes the session state info of an OK packet into type -> fields
+func sessionStateEntries(t *testing.T, packet []byte) map[byte][]string {
+	payload := packet[4:]
+	require.Equal(t, mysql.OK_HEADER, payload[0])
+	pos := 1 + 1 + 1
+	status := uint16(payload[pos]) | uint16(payload[pos+1])<<8
+	pos += 4
+	if status&mysql.SERVER_SESSION_STATE_CHANGED == 0 {
+		return nil
+	}
+
+	info, _, n, err := mysql.LengthEncodedString(payload[pos:])
+	require.NoError(t, err)
+	assert.Empty(t, info)
+	pos += n
+	state, _, _, err := mysql.LengthEncodedString(payload[pos:])
+	require.NoError(t, err)
+
+	entries := make(map[byte][]string)
+	for len(state) > 0 {
+		stateType := state[0]
+		data, _, n, err := mysql.LengthEncodedString(state[1:])
+		require.NoError(t, err)
+		state = state[1+n:]
+		for len(data) > 0 {
+			field, _, n, err := mysql.LengthEncodedString(data)
+			require.NoError(t, err)
+			entries[stateType] = append(entries[stateType], string(field))
+			data = data[n:]
+		}
+	}
+	return entries
+}
+
+func TestAppendSessionState(t *testing.T) {
+	state := append([]byte{sessionTrackSchema}, mysql.PutLengthEncodedString(mysql.PutLengthEncodedString([]byte("shop")))...)
+	out := appendSessionState(okPacket(), state)
+
+	assert.Equal(t, map[byte][]string{sessionTrackSchema: {"shop"}}, sessionStateEntries(t, out))
+	// Other status flags are kept
+	assert.Equal(t, byte(mysql.SERVER_STATUS_AUTOCOMMIT), out[7])
+
+	errPacket := setHandshakeHeader([]byte{0, 0, 0, 1, mysql.ERR_HEADER, 0x28, 0x04})
+	assert.Equal(t, errPacket, appendSessionState(errPacket, state))
+}
+
+func TestSessionTrackSchema(t *testing.T) {
+	h := newTestHandler(t)
+	require.NoError(t, h.SetCapabilities(map[string]bool{"CLIENT_SESSION_TRACK": true}))
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	defer ch.Close()
+	ch.conn.seen = true
+
+	// writeOK sends the OK packet for the command the way go-mysql does and reads it back
+	writeOK := func() []byte {
+		go ch.conn.Write(okPacket())
+		header := make([]byte, 4)
+		_, err := io.ReadFull(client, header)
+		require.NoError(t, err)
+		payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+		_, err = io.ReadFull(client, payload)
+		require.NoError(t, err)
+		return append(header, payload...)
+	}
+
+	t.Run("Not negotiated", func(t *testing.T) {
+		require.NoError(t, ch.UseDB("shop"))
+		assert.Nil(t, sessionStateEntries(t, writeOK()))
+	})
+
+	ch.SetClientCapabilities(mysql.CLIENT_PROTOCOL_41 | mysql.CLIENT_SESSION_TRACK)
+
+	t.Run("USE reports the schema", func(t *testing.T) {
+		require.NoError(t, ch.UseDB("shop"))
+		assert.Equal(t, []string{"shop"}, sessionStateEntries(t, writeOK())[sessionTrackSchema])
+
+		require.NoError(t, ch.UseDB("inventory"))
+		assert.Equal(t, []string{"inventory"}, sessionStateEntries(t, writeOK())[sessionTrackSchema])
+	})
+
+	t.Run("State is sent once", func(t *testing.T) {
+		assert.Nil(t, sessionStateEntries(t, writeOK()))
+	})
+
+	t.Run("Autocommit", func(t *testing.T) {
+		_, err := ch.handleSetCommand(t.Context(), "SET autocommit = 0")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"autocommit", "OFF"}, sessionStateEntries(t, writeOK())[sessionTrackSystemVariables])
+	})
+}
@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSetGlobalAndPersist(t *testing.T) {
+	h := newTestHandler(t)
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	for _, query := range []string{"SET GLOBAL sql_mode = 'ANSI'", "SET PERSIST sql_mode = 'ANSI'"} {
+		result, err := ch.handleSetCommand(t.Context(), query)
+		require.NoError(t, err, query)
+		assert.NotNil(t, result)
+	}
+
+	// Server-wide assignments must not leak into the session
+	_, ok := ch.session.GetSessionVar("sql_mode")
+	assert.False(t, ok)
+	_, ok = ch.session.GetSessionVar("GLOBAL sql_mode")
+	assert.False(t, ok)
+
+	_, err = ch.handleSetCommand(t.Context(), "SET GLOBAL version = '9.0.0'")
+	var myErr *mysql.MyError
+	require.True(t, errors.As(err, &myErr), "expected a MySQL error, got %v", err)
+	assert.Equal(t, uint16(mysql.ER_INCORRECT_GLOBAL_LOCAL_VAR), myErr.Code)
+	assert.Equal(t, "Variable 'version' is a read only variable", myErr.Message)
+}
+
+func TestHandleSetMultipleAssignments(t *testing.T) {
+	h := newTestHandler(t)
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	defer ch.Close()
+
+	_, err = ch.handleSetCommand(t.Context(),
+		"SET autocommit=0, time_zone='+00:00', @x=1, @@session.sql_mode = 'ANSI', NAMES utf8mb4, GLOBAL max_connections = 10")
+	require.NoError(t, err)
+
+	assert.False(t, ch.session.Autocommit)
+	value, _ := ch.session.GetSessionVar("time_zone")
+	assert.Equal(t, "+00:00", value)
+	value, _ = ch.session.GetSessionVar("sql_mode")
+	assert.Equal(t, "ANSI", value)
+	value, _ = ch.session.GetSessionVar("character_set_client")
+	assert.Equal(t, "utf8mb4", value)
+	value, _ = ch.session.GetUserVar("x")
+	assert.Equal(t, "1", value)
+	_, ok := ch.session.GetSessionVar("max_connections")
+	assert.False(t, ok)
+}
+
+func TestSelectSessionSystemVariables(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+
+	_, err := ch.handleSetCommand(t.Context(), "SET autocommit=0, time_zone='+00:00', sql_safe_updates=ON, wait_timeout=600")
+	require.NoError(t, err)
+
+	stmt, err := h.rewriter.RewriteStatement(
+		"SELECT @@autocommit, @@session.time_zone AS tz, @@sql_safe_updates, @@wait_timeout, @@global.time_zone",
+		ch.session.Variables())
+	require.NoError(t, err)
+	assert.Equal(t,
+		`SELECT 0 AS "@@autocommit",'+00:00' AS "tz",1 AS "@@sql_safe_updates",600 AS "@@wait_timeout",'SYSTEM' AS "@@global.time_zone"`,
+		stmt.SQL)
+}
@@ -0,0 +1,33 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+)
+
+// statistics builds the COM_STATISTICS status line in the format mysqladmin status prints
+func (h *Handler) statistics() string {
+	uptime := time.Since(h.startTime)
+	questions := h.metrics.QueryCount()
+
+	h.connsMu.Lock()
+	threads := len(h.conns)
+	h.connsMu.Unlock()
+
+	qps := 0.0
+	if uptime > 0 {
+		qps = float64(questions) / uptime.Seconds()
+	}
+	return fmt.Sprintf("Uptime: %d  Threads: %d  Questions: %d  Slow queries: %d  Queries per second avg: %.3f",
+		int64(uptime.Seconds()), threads, questions, h.logger.SlowQueries(), qps)
+}
+
+// replacePayload keeps the sequence id of packet and swaps its payload
+func replacePayload(packet, payload []byte) []byte {
+	if len(packet) < 4 {
+		return packet
+	}
+	out := make([]byte, 4, 4+len(payload))
+	out[0], out[1], out[2], out[3] = byte(len(payload)), byte(len(payload)>>8), byte(len(payload)>>16), packet[3]
+	return append(out, payload...)
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"net"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/client"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatistics(t *testing.T) {
+	h := newTestHandler(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	provider := server.NewInMemoryProvider()
+	provider.AddUser("app", "secret")
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		ch, err := h.NewConnection(c)
+		if err != nil {
+			return
+		}
+		defer ch.Close()
+		mysqlConn, err := server.NewDefaultServer().NewCustomizedConn(ch.NetConn(), provider, ch)
+		if err != nil {
+			return
+		}
+		for mysqlConn.HandleCommand() == nil {
+		}
+	}()
+
+	conn, err := client.Connect(listener.Addr().String(), "app", "secret", "")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.ResetSequence()
+	require.NoError(t, conn.WritePacket([]byte{0, 0, 0, 0, mysql.COM_STATISTICS}))
+	data, err := conn.ReadPacket()
+	require.NoError(t, err)
+	assert.Regexp(t, `^Uptime: \d+  Threads: 1  Questions: \d+  Slow queries: \d+  Queries per second avg: \d+\.\d{3}$`, string(data))
+
+	// The status line replaces the OK packet, the next command gets its own response
+	require.NoError(t, conn.Ping())
+}
@@ -0,0 +1,31 @@
+package mysql
+
+import "time"
+
+// Results of ended transactions in mysql_pg_proxy_transactions_total
+const (
+	txCommitted  = "committed"
+	txRolledBack = "rolled_back"
+)
+
+// observeTransaction updates the transaction metrics after a command that can start or
+// end a transaction, wasInTransaction is the state before it and result how an ended
+// transaction ended
+func (ch *ConnectionHandler) observeTransaction(wasInTransaction bool, result string) {
+	switch {
+	case !wasInTransaction && ch.session.InTransaction:
+		ch.handler.metrics.IncTransactionsStarted()
+	case wasInTransaction && !ch.session.InTransaction:
+		ch.endTransaction(result)
+	}
+}
+
+// endTransaction counts the session's transaction as ended with result and records its
+// duration, transactions past the long-transaction threshold are logged since they held
+// a PostgreSQL connection all along
+func (ch *ConnectionHandler) endTransaction(result string) {
+	duration := time.Since(ch.session.TxStartedAt)
+	ch.handler.metrics.IncTransactions(result)
+	ch.handler.metrics.ObserveTransactionDuration(duration.Seconds())
+	ch.handler.logger.LogTransaction(ch.session.ID, ch.session.User, ch.session.ClientAddr, result, duration)
+}
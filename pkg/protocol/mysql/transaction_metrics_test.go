@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transactionDurationCount(t *testing.T, h *Handler) uint64 {
+	var metric dto.Metric
+	require.NoError(t, h.metrics.TransactionDuration.Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestTransactionMetrics(t *testing.T) {
+	h := newTestHandler(t)
+	h.logger.SetLongTransactionThreshold(time.Second)
+	ch := newTestConnection(t, h)
+
+	committed := h.metrics.TransactionsTotal.WithLabelValues(txCommitted)
+	rolledBack := h.metrics.TransactionsTotal.WithLabelValues(txRolledBack)
+	started := testutil.ToFloat64(h.metrics.TransactionsStarted)
+	commits, rollbacks := testutil.ToFloat64(committed), testutil.ToFloat64(rolledBack)
+	durations := transactionDurationCount(t, h)
+
+	// BEGIN, as the session leaves it without a PostgreSQL connection
+	ch.session.InTransaction = true
+	ch.session.TxStartedAt = time.Now().Add(-2 * time.Second)
+	ch.observeTransaction(false, "")
+	assert.Equal(t, started+1, testutil.ToFloat64(h.metrics.TransactionsStarted))
+	assert.Equal(t, durations, transactionDurationCount(t, h))
+
+	// BEGIN inside the transaction starts nothing
+	ch.observeTransaction(true, "")
+	assert.Equal(t, started+1, testutil.ToFloat64(h.metrics.TransactionsStarted))
+
+	// COMMIT
+	ch.session.InTransaction = false
+	ch.observeTransaction(true, txCommitted)
+	assert.Equal(t, commits+1, testutil.ToFloat64(committed))
+	assert.Equal(t, rollbacks, testutil.ToFloat64(rolledBack))
+	assert.Equal(t, durations+1, transactionDurationCount(t, h))
+	assert.Equal(t, int64(1), h.logger.LongTransactions())
+
+	// COMMIT outside a transaction
+	ch.observeTransaction(false, txCommitted)
+	assert.Equal(t, commits+1, testutil.ToFloat64(committed))
+
+	// Disconnecting inside a transaction rolls it back
+	ch.session.InTransaction = true
+	ch.session.TxStartedAt = time.Now()
+	require.NoError(t, ch.Close())
+	assert.Equal(t, rollbacks+1, testutil.ToFloat64(rolledBack))
+	assert.Equal(t, durations+2, transactionDurationCount(t, h))
+	assert.Equal(t, int64(1), h.logger.LongTransactions())
+}
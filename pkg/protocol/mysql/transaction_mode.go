@@ -0,0 +1,45 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+
+	"aproxy/pkg/mapper"
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// setTransaction applies SET [GLOBAL | SESSION] TRANSACTION. The session scope becomes
+// the PostgreSQL session's default, which covers autocommitted statements too, and is
+// reported by @@transaction_isolation and @@transaction_read_only. Without a scope the
+// characteristics are kept for the next BEGIN, START TRANSACTION or SET autocommit = 0
+func (ch *ConnectionHandler) setTransaction(ctx context.Context, tx *mapper.SetTransaction) (*mysql.Result, error) {
+	switch {
+	case tx.Scope != mapper.ScopeSession:
+		// Server-wide settings belong to the PostgreSQL configuration, accepted without effect
+
+	case tx.Next:
+		if ch.session.InTransaction {
+			return nil, mysql.NewDefaultError(mysql.ER_CANT_CHANGE_TX_CHARACTERISTICS)
+		}
+		ch.session.SetNextTransaction(tx.Isolation, tx.AccessMode)
+
+	default:
+		if _, err := ch.pgConn.Exec(ctx, "SET SESSION CHARACTERISTICS AS TRANSACTION "+tx.Modes()); err != nil {
+			return nil, ch.handler.errorMapper.MySQLError(err)
+		}
+		if tx.Isolation != "" {
+			level := strings.ReplaceAll(tx.Isolation, " ", "-")
+			ch.session.SetSessionVar("transaction_isolation", level)
+			ch.session.SetSessionVar("tx_isolation", level)
+		}
+		if tx.AccessMode != "" {
+			readOnly := "OFF"
+			if tx.AccessMode == "READ ONLY" {
+				readOnly = "ON"
+			}
+			ch.session.SetSessionVar("transaction_read_only", readOnly)
+			ch.session.SetSessionVar("tx_read_only", readOnly)
+		}
+	}
+	return &mysql.Result{Status: 0}, nil
+}
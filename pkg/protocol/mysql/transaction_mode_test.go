@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"aproxy/pkg/mapper"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTransactionNext(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+
+	tx, _, err := mapper.ParseSetTransaction("SET TRANSACTION READ ONLY")
+	require.NoError(t, err)
+	_, err = ch.setTransaction(context.Background(), tx)
+	require.NoError(t, err)
+
+	// Not while a transaction is running
+	ch.session.InTransaction = true
+	_, err = ch.setTransaction(context.Background(), tx)
+	var myErr *mysql.MyError
+	require.ErrorAs(t, err, &myErr)
+	assert.Equal(t, uint16(mysql.ER_CANT_CHANGE_TX_CHARACTERISTICS), myErr.Code)
+	assert.Equal(t, "25001", myErr.State)
+
+	// GLOBAL is accepted without effect
+	tx, _, err = mapper.ParseSetTransaction("SET GLOBAL TRANSACTION READ ONLY")
+	require.NoError(t, err)
+	_, err = ch.setTransaction(context.Background(), tx)
+	require.NoError(t, err)
+	_, set := ch.session.GetSessionVar("transaction_read_only")
+	assert.False(t, set)
+}
@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// bigintUnsignedTypmod is the type modifier of NUMERIC(20,0): ((precision << 16) | scale) + 4
+const bigintUnsignedTypmod = (20 << 16) + 4
+
+// isBigintUnsignedColumn reports whether a result column is NUMERIC(20,0),
+// the type CREATE TABLE maps BIGINT UNSIGNED to
+func isBigintUnsignedColumn(fd pgconn.FieldDescription) bool {
+	return fd.DataTypeOID == 1700 && fd.TypeModifier == bigintUnsignedTypmod
+}
+
+// toUnsignedColumn converts the decimal strings in column col to uint64, so they are
+// sent as unsigned longlong instead of going through int64 and wrapping above 2^63.
+// The column is left as is, and false returned, when a value does not fit in uint64
+// (e.g. a negative number in a column declared DECIMAL(20,0))
+func toUnsignedColumn(values [][]interface{}, col int) bool {
+	for _, row := range values {
+		if s, ok := row[col].(string); ok {
+			if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+				return false
+			}
+		}
+	}
+
+	for _, row := range values {
+		if s, ok := row[col].(string); ok {
+			row[col], _ = strconv.ParseUint(s, 10, 64)
+		}
+	}
+	return true
+}
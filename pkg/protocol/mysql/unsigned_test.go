@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBigintUnsignedColumn(t *testing.T) {
+	assert.True(t, isBigintUnsignedColumn(pgconn.FieldDescription{DataTypeOID: 1700, TypeModifier: bigintUnsignedTypmod}))
+	assert.False(t, isBigintUnsignedColumn(pgconn.FieldDescription{DataTypeOID: 1700, TypeModifier: (10 << 16) + 2 + 4}))
+	assert.False(t, isBigintUnsignedColumn(pgconn.FieldDescription{DataTypeOID: 1700, TypeModifier: -1}))
+	assert.False(t, isBigintUnsignedColumn(pgconn.FieldDescription{DataTypeOID: 20}))
+}
+
+func TestToUnsignedColumn(t *testing.T) {
+	values := [][]interface{}{
+		{"18446744073709551615", "a"},
+		{nil, "b"},
+		{"0", "c"},
+	}
+	assert.True(t, toUnsignedColumn(values, 0))
+	assert.Equal(t, uint64(18446744073709551615), values[0][0])
+	assert.Nil(t, values[1][0])
+	assert.Equal(t, uint64(0), values[2][0])
+}
+
+func TestToUnsignedColumn_OutOfRange(t *testing.T) {
+	values := [][]interface{}{
+		{"5"},
+		{"-1"},
+	}
+	assert.False(t, toUnsignedColumn(values, 0))
+	assert.Equal(t, "5", values[0][0], "column must be left untouched")
+}
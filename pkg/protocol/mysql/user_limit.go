@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/server"
+)
+
+// userLimitError is MySQL's error for exceeding max_user_connections
+func (h *Handler) userLimitError(user string) error {
+	return mysql.NewDefaultError(mysql.ER_USER_LIMIT_REACHED, user, "max_user_connections", h.sessionMgr.MaxConnectionsPerUser())
+}
+
+// switchCountedUser moves this connection's slot in the per-user limit to user
+func (ch *ConnectionHandler) switchCountedUser(user string) error {
+	if user == ch.countedUser {
+		return nil
+	}
+	if err := ch.handler.sessionMgr.AcquireUser(user); err != nil {
+		ch.handler.metrics.IncErrors("user_limit")
+		return ch.handler.userLimitError(user)
+	}
+	if ch.countedUser != "" {
+		ch.handler.sessionMgr.ReleaseUser(ch.countedUser)
+	}
+	ch.countedUser = user
+	return nil
+}
+
+// limitedCredentialProvider rejects users at their connection limit during the
+// handshake, so the client gets ER_USER_LIMIT_REACHED instead of a login followed
+// by a dropped connection. SetUser still enforces the limit for racing logins
+type limitedCredentialProvider struct {
+	server.CredentialProvider
+	handler *Handler
+}
+
+// LimitCredentials wraps p to enforce the per-user connection limit at login
+func (h *Handler) LimitCredentials(p server.CredentialProvider) server.CredentialProvider {
+	return &limitedCredentialProvider{CredentialProvider: p, handler: h}
+}
+
+func (p *limitedCredentialProvider) GetCredential(username string) (string, bool, error) {
+	password, found, err := p.CredentialProvider.GetCredential(username)
+	if err != nil || !found {
+		return password, found, err
+	}
+	if p.handler.sessionMgr.UserLimitReached(username) {
+		p.handler.metrics.IncErrors("user_limit")
+		return "", false, p.handler.userLimitError(username)
+	}
+	return password, found, nil
+}
@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"net"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConnection(t testing.TB, h *Handler) *ConnectionHandler {
+	client, serverSide := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	ch, err := h.NewConnection(serverSide)
+	require.NoError(t, err)
+	t.Cleanup(func() { ch.Close() })
+	return ch
+}
+
+func requireUserLimitError(t *testing.T, err error) {
+	var myErr *mysql.MyError
+	require.ErrorAs(t, err, &myErr)
+	assert.Equal(t, uint16(mysql.ER_USER_LIMIT_REACHED), myErr.Code)
+}
+
+func TestUserConnectionLimit(t *testing.T) {
+	h := newTestHandler(t)
+	h.sessionMgr.SetMaxConnectionsPerUser(2)
+
+	first := newTestConnection(t, h)
+	require.NoError(t, first.SetUser("alice"))
+	require.NoError(t, newTestConnection(t, h).SetUser("alice"))
+
+	// Other users have their own budget
+	require.NoError(t, newTestConnection(t, h).SetUser("bob"))
+
+	excess := newTestConnection(t, h)
+	requireUserLimitError(t, excess.SetUser("alice"))
+	assert.Equal(t, 2, h.sessionMgr.UserConnections("alice"))
+
+	// Closing a connection frees its slot, and only once
+	first.Close()
+	first.Close()
+	assert.Equal(t, 1, h.sessionMgr.UserConnections("alice"))
+	require.NoError(t, excess.SetUser("alice"))
+	assert.Equal(t, 2, h.sessionMgr.UserConnections("alice"))
+}
+
+func TestUserConnectionLimit_Unlimited(t *testing.T) {
+	h := newTestHandler(t)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, newTestConnection(t, h).SetUser("alice"))
+	}
+	assert.Equal(t, 5, h.sessionMgr.UserConnections("alice"))
+}
+
+func TestUserConnectionLimit_ChangeUser(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetCredentials(map[string]string{"root": "", "alice": ""})
+	h.sessionMgr.SetMaxConnectionsPerUser(1)
+
+	require.NoError(t, newTestConnection(t, h).SetUser("alice"))
+
+	ch := newTestConnection(t, h)
+	require.NoError(t, ch.SetUser("root"))
+	err := ch.HandleOtherCommand(mysql.COM_CHANGE_USER, buildChangeUser("alice", nil, ""))
+	requireUserLimitError(t, err)
+	assert.Equal(t, "root", ch.session.User)
+	assert.Equal(t, 1, h.sessionMgr.UserConnections("root"))
+}
+
+func TestLimitCredentials(t *testing.T) {
+	h := newTestHandler(t)
+	h.sessionMgr.SetMaxConnectionsPerUser(1)
+	inMemory := server.NewInMemoryProvider()
+	inMemory.AddUser("alice", "secret")
+	provider := h.LimitCredentials(inMemory)
+
+	password, found, err := provider.GetCredential("alice")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "secret", password)
+
+	require.NoError(t, newTestConnection(t, h).SetUser("alice"))
+	_, found, err = provider.GetCredential("alice")
+	requireUserLimitError(t, err)
+	assert.False(t, found)
+
+	// Unknown users still fail authentication normally
+	_, found, err = provider.GetCredential("mallory")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
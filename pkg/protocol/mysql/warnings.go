@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"regexp"
+	"sync"
+
+	"aproxy/internal/pool"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// maxWarnings is MySQL's default max_error_count, further notices are dropped
+const maxWarnings = 64
+
+var showWarningsRe = regexp.MustCompile(`(?is)^SHOW\s+(COUNT\s*\(\s*\*\s*\)\s+)?WARNINGS\b`)
+
+// warning is one row of SHOW WARNINGS
+type warning struct {
+	level   string // Note or Warning
+	code    uint16
+	message string
+}
+
+// diagnostics collects the NOTICE and WARNING messages PostgreSQL raises while the
+// current statement runs, MySQL clients read them with SHOW WARNINGS
+type diagnostics struct {
+	mu       sync.Mutex
+	warnings []warning
+}
+
+func (d *diagnostics) add(w warning) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.warnings) < maxWarnings {
+		d.warnings = append(d.warnings, w)
+	}
+}
+
+func (d *diagnostics) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.warnings = nil
+}
+
+// report sets the number of warnings in the OK packet of result, clients only
+// ask for SHOW WARNINGS when it is not zero
+func (d *diagnostics) report(result *mysql.Result) {
+	if result == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result.Warnings = uint16(len(d.warnings))
+}
+
+func (d *diagnostics) list() []warning {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]warning(nil), d.warnings...)
+}
+
+// attachPGConn makes conn the session's backend connection and collects its notices
+func (ch *ConnectionHandler) attachPGConn(conn *pgx.Conn) {
+	ch.pgConn = conn
+	ch.session.SetPGConn(conn)
+	pool.OnNotice(conn.PgConn(), ch.addNotice)
+}
+
+// addNotice records a PostgreSQL notice as a MySQL warning. WARNING keeps its level,
+// the informational severities (NOTICE, INFO, ...) become notes
+func (ch *ConnectionHandler) addNotice(notice *pgconn.Notice) {
+	level := "Note"
+	if notice.Severity == "WARNING" {
+		level = "Warning"
+	}
+	ch.warnings.add(warning{
+		level:   level,
+		code:    ch.handler.errorMapper.GetMySQLErrorCode(notice.Code),
+		message: notice.Message,
+	})
+}
+
+// showWarnings answers SHOW WARNINGS and SHOW COUNT(*) WARNINGS with the notices of
+// the previous statement
+func (ch *ConnectionHandler) showWarnings(query string) (*mysql.Result, error) {
+	warnings := ch.warnings.list()
+
+	var resultset *mysql.Resultset
+	var err error
+	if m := showWarningsRe.FindStringSubmatch(query); m != nil && m[1] != "" {
+		resultset, err = mysql.BuildSimpleResultset([]string{"@@session.warning_count"},
+			[][]interface{}{{int64(len(warnings))}}, false)
+	} else {
+		rows := make([][]interface{}, 0, len(warnings))
+		for _, w := range warnings {
+			rows = append(rows, []interface{}{w.level, int64(w.code), w.message})
+		}
+		resultset, err = mysql.BuildSimpleResultset([]string{"Level", "Code", "Message"}, rows, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mysql.Result{Status: 0, Resultset: resultset}, nil
+}
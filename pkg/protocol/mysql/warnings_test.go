@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"testing"
+
+	"aproxy/pkg/mapper"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowWarnings(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+
+	// Delivered by pgx while DROP TABLE IF EXISTS missing runs
+	ch.addNotice(&pgconn.Notice{Severity: "NOTICE", Code: "00000", Message: `table "missing" does not exist, skipping`})
+	ch.addNotice(&pgconn.Notice{Severity: "WARNING", Code: "22001", Message: "value too long"})
+
+	result, err := ch.HandleQuery("SHOW WARNINGS")
+	require.NoError(t, err)
+	require.Len(t, result.Resultset.RowDatas, 2)
+	assert.Equal(t, uint16(2), result.Warnings)
+
+	values, err := result.Resultset.RowDatas[0].ParseText(result.Resultset.Fields, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Note", string(values[0].AsString()))
+	assert.Equal(t, int64(mapper.ER_UNKNOWN_ERROR), values[1].AsInt64())
+	assert.Equal(t, `table "missing" does not exist, skipping`, string(values[2].AsString()))
+
+	values, err = result.Resultset.RowDatas[1].ParseText(result.Resultset.Fields, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Warning", string(values[0].AsString()))
+	assert.Equal(t, int64(mapper.ER_DATA_TOO_LONG), values[1].AsInt64())
+
+	result, err = ch.HandleQuery("show count(*) warnings")
+	require.NoError(t, err)
+	values, err = result.Resultset.RowDatas[0].ParseText(result.Resultset.Fields, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), values[0].AsInt64())
+
+	// The next statement starts without warnings
+	_, err = ch.HandleQuery("/*aproxy:dry_run*/ SELECT 1")
+	require.NoError(t, err)
+	result, err = ch.HandleQuery("SHOW WARNINGS")
+	require.NoError(t, err)
+	assert.Empty(t, result.Resultset.RowDatas)
+	assert.Equal(t, uint16(0), result.Warnings)
+}
+
+func TestWarningsCapped(t *testing.T) {
+	var d diagnostics
+	for i := 0; i < maxWarnings+10; i++ {
+		d.add(warning{level: "Note", code: mysql.ER_UNKNOWN_ERROR, message: "x"})
+	}
+	assert.Len(t, d.list(), maxWarnings)
+}
+
+func TestDropTableWithoutWarnings(t *testing.T) {
+	h := newTestHandler(t)
+	ch := newTestConnection(t, h)
+	backend := newRecordingBackend(t, ch, 0)
+
+	// No trigger function was created for the table, none is dropped: PostgreSQL
+	// would answer DROP FUNCTION IF EXISTS with a notice, reported as a warning
+	result, err := ch.HandleQuery("DROP TABLE plain")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`DROP TABLE "plain"`}, backend.take())
+	assert.Equal(t, uint16(0), result.Warnings)
+}
@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"time"
+
+	"aproxy/pkg/sqlrewrite"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// zeroDates maps the values the rewriter stores for MySQL's zero dates back to
+// '0000-00-00' in result sets, see sqlrewrite.ZeroDatePolicy
+type zeroDates struct {
+	policy sqlrewrite.ZeroDatePolicy
+	onRead bool
+}
+
+// SetZeroDates sets how zero dates were written. With onRead, date and timestamp
+// columns holding that value read as '0000-00-00' (00:00:00) again. Under the null
+// policy this applies to every NULL in those columns
+func (h *Handler) SetZeroDates(policy sqlrewrite.ZeroDatePolicy, onRead bool) {
+	h.zeroDates = zeroDates{policy: policy, onRead: onRead}
+}
+
+// restore returns the zero date a column value of the given type stands for
+func (z zeroDates) restore(oid uint32, value interface{}) (string, bool) {
+	if !z.onRead {
+		return "", false
+	}
+	var zero string
+	switch oid {
+	case pgtype.DateOID:
+		zero = "0000-00-00"
+	case pgtype.TimestampOID:
+		zero = "0000-00-00 00:00:00"
+	default:
+		return "", false
+	}
+
+	switch z.policy {
+	case sqlrewrite.ZeroDateNull:
+		return zero, value == nil
+	case sqlrewrite.ZeroDateEpoch:
+		t, ok := value.(time.Time)
+		return zero, ok && t.Equal(time.Unix(0, 0))
+	}
+	return "", false
+}
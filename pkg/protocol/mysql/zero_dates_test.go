@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"aproxy/pkg/sqlrewrite"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dateRows yields rows of a DATE, a TIMESTAMP and a TEXT column
+type dateRows struct {
+	rows [][]any
+	read int
+}
+
+func (r *dateRows) Close()                        {}
+func (r *dateRows) Err() error                    { return nil }
+func (r *dateRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+func (r *dateRows) FieldDescriptions() []pgconn.FieldDescription {
+	return []pgconn.FieldDescription{
+		{Name: "d", DataTypeOID: pgtype.DateOID},
+		{Name: "ts", DataTypeOID: pgtype.TimestampOID},
+		{Name: "note", DataTypeOID: pgtype.TextOID},
+	}
+}
+func (r *dateRows) Next() bool {
+	r.read++
+	return r.read <= len(r.rows)
+}
+func (r *dateRows) Scan(dest ...any) error { return nil }
+func (r *dateRows) Values() ([]any, error) { return r.rows[r.read-1], nil }
+func (r *dateRows) RawValues() [][]byte    { return nil }
+func (r *dateRows) Conn() *pgx.Conn        { return nil }
+
+func TestZeroDatesOnRead(t *testing.T) {
+	epoch := time.Unix(0, 0).UTC()
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		policy   sqlrewrite.ZeroDatePolicy
+		onRead   bool
+		row      []any
+		expected []string
+	}{
+		{
+			name:     "NULL reads as the zero date",
+			policy:   sqlrewrite.ZeroDateNull,
+			onRead:   true,
+			row:      []any{nil, nil, nil},
+			expected: []string{"0000-00-00", "0000-00-00 00:00:00", ""},
+		},
+		{
+			name:     "Epoch reads as the zero date",
+			policy:   sqlrewrite.ZeroDateEpoch,
+			onRead:   true,
+			row:      []any{epoch, epoch, nil},
+			expected: []string{"0000-00-00", "0000-00-00 00:00:00", ""},
+		},
+		{
+			name:     "Other dates are kept",
+			policy:   sqlrewrite.ZeroDateEpoch,
+			onRead:   true,
+			row:      []any{day, nil, "x"},
+			expected: []string{"2024-01-02 00:00:00", "", "x"},
+		},
+		{
+			name:     "Disabled",
+			policy:   sqlrewrite.ZeroDateNull,
+			row:      []any{nil, nil, nil},
+			expected: []string{"", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			h.SetZeroDates(tt.policy, tt.onRead)
+			ch := newTestConnection(t, h)
+
+			result, err := ch.buildMySQLResult(&dateRows{rows: [][]any{tt.row}}, false)
+			require.NoError(t, err)
+			require.Len(t, result.Resultset.RowDatas, 1)
+			values, err := result.Resultset.RowDatas[0].ParseText(result.Resultset.Fields, nil)
+			require.NoError(t, err)
+			for i, expected := range tt.expected {
+				assert.Equal(t, expected, string(values[i].AsString()), "column %d", i)
+			}
+		})
+	}
+}
@@ -50,11 +50,29 @@ type TableInfo struct {
 	TTL            time.Duration
 }
 
+// ColumnInfo describes a column as information_schema.columns does
+type ColumnInfo struct {
+	Name          string
+	DataType      string // data_type, e.g. "character varying"
+	Collation     string // collation_name, empty for the database default
+	AutoIncrement bool   // SERIAL or IDENTITY
+}
+
+// TableColumns contains the columns of a table in declaration order and its unique keys,
+// the primary key first. A table that does not exist has no columns
+type TableColumns struct {
+	Columns       []ColumnInfo
+	UniqueKeys    [][]string
+	LastRefreshed time.Time // When this info was last queried
+	TTL           time.Duration
+}
+
 // Cache is a global schema cache shared across all sessions
 type Cache struct {
-	tables *syncMapTyped[string, *TableInfo] // Type-safe map[string]*TableInfo
-	ttl    time.Duration
-	mu     sync.RWMutex
+	tables  *syncMapTyped[string, *TableInfo]    // Type-safe map[string]*TableInfo
+	columns *syncMapTyped[string, *TableColumns] // Same keys as tables
+	ttl     time.Duration
+	mu      sync.RWMutex
 }
 
 var (
@@ -67,8 +85,9 @@ var (
 func InitGlobalCache(ttl time.Duration) *Cache {
 	once.Do(func() {
 		GlobalCache = &Cache{
-			tables: &syncMapTyped[string, *TableInfo]{},
-			ttl:    ttl,
+			tables:  &syncMapTyped[string, *TableInfo]{},
+			columns: &syncMapTyped[string, *TableColumns]{},
+			ttl:     ttl,
 		}
 	})
 	return GlobalCache
@@ -145,12 +164,107 @@ func (c *Cache) queryAutoIncrementColumn(conn *pgx.Conn, tableName string) strin
 	return columnName
 }
 
+// GetTableColumns returns the columns and unique keys of a table
+// It uses cached data if available and not expired, otherwise queries PostgreSQL
+// The cache key format is "database.table" as for GetAutoIncrementColumn
+func (c *Cache) GetTableColumns(conn *pgx.Conn, database, tableName string) *TableColumns {
+	cacheKey := database + "." + tableName
+
+	if info, ok := c.columns.Load(cacheKey); ok {
+		if time.Since(info.LastRefreshed) < info.TTL {
+			return info
+		}
+	}
+
+	info, err := c.queryTableColumns(conn, strings.ToLower(tableName))
+	if err != nil {
+		// Not cached, the next statement tries again
+		return &TableColumns{}
+	}
+	info.LastRefreshed = time.Now()
+	info.TTL = c.ttl
+	c.columns.Store(cacheKey, info)
+	return info
+}
+
+// queryTableColumns queries information_schema for the columns of a table and pg_index
+// for its unique keys. Partial and expression indexes cannot be an ON CONFLICT target
+// and are left out
+func (c *Cache) queryTableColumns(conn *pgx.Conn, tableName string) (*TableColumns, error) {
+	if conn == nil {
+		return nil, pgx.ErrNoRows
+	}
+
+	ctx := context.Background()
+
+	query := `
+		SELECT column_name, data_type, COALESCE(collation_name, ''),
+		       COALESCE(column_default, '') LIKE 'nextval(%' OR is_identity = 'YES'
+		FROM information_schema.columns
+		WHERE table_name = $1
+		  AND table_schema = current_schema()
+		ORDER BY ordinal_position
+	`
+	rows, err := conn.Query(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	info := &TableColumns{}
+	for rows.Next() {
+		var col ColumnInfo
+		if err := rows.Scan(&col.Name, &col.DataType, &col.Collation, &col.AutoIncrement); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		info.Columns = append(info.Columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(info.Columns) == 0 {
+		return info, nil
+	}
+
+	keyQuery := `
+		SELECT i.indexrelid, a.attname
+		FROM pg_index i
+		JOIN LATERAL unnest(i.indkey) WITH ORDINALITY AS k(attnum, n) ON true
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum
+		WHERE i.indrelid = to_regclass(quote_ident($1))
+		  AND i.indisunique
+		  AND i.indpred IS NULL
+		  AND i.indexprs IS NULL
+		ORDER BY NOT i.indisprimary, i.indexrelid, k.n
+	`
+	rows, err = conn.Query(ctx, keyQuery, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var current uint32
+	for rows.Next() {
+		var index uint32
+		var column string
+		if err := rows.Scan(&index, &column); err != nil {
+			return nil, err
+		}
+		if len(info.UniqueKeys) == 0 || index != current {
+			info.UniqueKeys = append(info.UniqueKeys, nil)
+			current = index
+		}
+		last := len(info.UniqueKeys) - 1
+		info.UniqueKeys[last] = append(info.UniqueKeys[last], column)
+	}
+	return info, rows.Err()
+}
+
 // InvalidateTable removes a table from the cache
 // This should be called when a DDL statement modifies the table
 // The key format is "database.table"
 func (c *Cache) InvalidateTable(database, tableName string) {
 	cacheKey := database + "." + tableName
 	c.tables.Delete(cacheKey)
+	c.columns.Delete(cacheKey)
 }
 
 // InvalidateAll clears the entire cache
@@ -159,6 +273,10 @@ func (c *Cache) InvalidateAll() {
 		c.tables.Delete(key)
 		return true
 	})
+	c.columns.Range(func(key string, value *TableColumns) bool {
+		c.columns.Delete(key)
+		return true
+	})
 }
 
 // RefreshTable forces a refresh of table schema information
@@ -216,6 +334,13 @@ func (c *Cache) refreshExpiredEntries(conn *pgx.Conn) {
 		return true
 	})
 
+	c.columns.Range(func(cacheKey string, info *TableColumns) bool {
+		if now.Sub(info.LastRefreshed) >= info.TTL {
+			c.columns.Delete(cacheKey)
+		}
+		return true
+	})
+
 	// Refresh expired tables
 	// Note: We can't refresh without knowing the database context
 	// So we just invalidate expired entries and let them refresh on next access
@@ -2,14 +2,18 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"aproxy/pkg/mapper"
 	"aproxy/pkg/schema"
+	"aproxy/pkg/sqlrewrite"
 )
 
 type Session struct {
@@ -19,10 +23,14 @@ type Session struct {
 	Charset       string
 	Autocommit    bool
 	InTransaction bool
+	TxStartedAt   time.Time // Start of the current or last transaction
 	LastInsertID  uint64
 	CreatedAt     time.Time
 	LastActiveAt  time.Time
 	ClientAddr    string
+	DryRun        bool // Report statements instead of executing them
+	DebugSQL      bool // Log original and rewritten SQL of this session's statements
+	SafeMode      bool // Validate rewritten statements with EXPLAIN before running them
 
 	sessionVars   map[string]interface{}
 	userVars      map[string]interface{}
@@ -31,6 +39,9 @@ type Session struct {
 	// Track tables with AUTO_INCREMENT: map[tableName]columnName
 	autoIncrementTables map[string]string
 
+	// Characteristics of the next transaction only (SET TRANSACTION without SESSION)
+	nextTransaction mapper.TransactionCharacteristics
+
 	pgConn *pgx.Conn
 	mu     sync.RWMutex
 }
@@ -45,17 +56,78 @@ type PreparedStatement struct {
 	ColumnCount   int
 	ColumnTypes   []int
 	ColumnNames   []string
+	Type          sqlrewrite.StatementType // From the parsed AST, see sqlrewrite.Statement
+	Returning     bool // DML with an explicit RETURNING clause, executed as a query
+	FirstGeneratedRow int // INSERT row whose AUTO_INCREMENT id is reported as the last insert id
+	SingleRowInsert   bool // Plain INSERT ... VALUES of one row, may be batched
+	Explain           *sqlrewrite.Explain // EXPLAIN, answered from the plan of the explained statement
+	SelectParams      []int               // Placeholders that are whole select-list columns, cast to the bound type
 }
 
 type Manager struct {
 	sessions map[string]*Session
 	mu       sync.RWMutex
+
+	maxPerUser int            // Concurrent connections allowed per user, 0 means unlimited
+	userConns  map[string]int // Authenticated connections per user
 }
 
+// ErrUserLimitReached is returned when a user already has the maximum number of connections
+var ErrUserLimitReached = errors.New("user connection limit reached")
+
 func NewManager() *Manager {
 	return &Manager{
-		sessions: make(map[string]*Session),
+		sessions:  make(map[string]*Session),
+		userConns: make(map[string]int),
+	}
+}
+
+// SetMaxConnectionsPerUser limits concurrent connections per user, 0 disables the limit
+func (m *Manager) SetMaxConnectionsPerUser(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxPerUser = n
+}
+
+func (m *Manager) MaxConnectionsPerUser() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.maxPerUser
+}
+
+// AcquireUser counts a connection for user, failing with ErrUserLimitReached
+// when the user is already at the limit. Every successful call needs a ReleaseUser
+func (m *Manager) AcquireUser(user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.maxPerUser > 0 && m.userConns[user] >= m.maxPerUser {
+		return ErrUserLimitReached
 	}
+	m.userConns[user]++
+	return nil
+}
+
+func (m *Manager) ReleaseUser(user string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.userConns[user] <= 1 {
+		delete(m.userConns, user)
+		return
+	}
+	m.userConns[user]--
+}
+
+// UserLimitReached reports whether user cannot open another connection
+func (m *Manager) UserLimitReached(user string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.maxPerUser > 0 && m.userConns[user] >= m.maxPerUser
+}
+
+func (m *Manager) UserConnections(user string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.userConns[user]
 }
 
 func NewSession(user, database, clientAddr string) *Session {
@@ -154,6 +226,44 @@ func (s *Session) GetUserVar(key string) (interface{}, bool) {
 	return val, ok
 }
 
+// Variables returns a copy of the user variables (@name) set in this session, plus
+// the system variables SET in it under their @@name. Integer and ON/OFF values read
+// back as numbers, as MySQL reports them
+func (s *Session) Variables() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	vars := make(map[string]interface{}, len(s.userVars)+len(s.sessionVars)+1)
+	for k, v := range s.userVars {
+		vars[k] = v
+	}
+	for k, v := range s.sessionVars {
+		vars["@@"+strings.ToLower(k)] = systemVarValue(v)
+	}
+	vars["@@autocommit"] = int64(0)
+	if s.Autocommit {
+		vars["@@autocommit"] = int64(1)
+	}
+	return vars
+}
+
+// systemVarValue converts a SET value to the type MySQL reports for the variable
+func systemVarValue(v interface{}) interface{} {
+	value, ok := v.(string)
+	if !ok {
+		return v
+	}
+	switch strings.ToUpper(value) {
+	case "ON", "TRUE":
+		return int64(1)
+	case "OFF", "FALSE":
+		return int64(0)
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n
+	}
+	return value
+}
+
 func (s *Session) AddPreparedStatement(stmt *PreparedStatement) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -219,16 +329,41 @@ func (s *Session) SetAutocommit(autocommit bool) error {
 		}
 		s.InTransaction = false
 	} else if !autocommit && !s.InTransaction {
-		_, err := s.pgConn.Exec(ctx, "BEGIN")
+		_, err := s.pgConn.Exec(ctx, s.beginStatement())
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction: %w", err)
 		}
 		s.InTransaction = true
+		s.TxStartedAt = time.Now()
 	}
 
 	return nil
 }
 
+// SetNextTransaction sets the isolation level and access mode the next transaction
+// begins with, in PostgreSQL syntax. Empty values keep what was set before
+func (s *Session) SetNextTransaction(isolation, accessMode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if isolation != "" {
+		s.nextTransaction.Isolation = isolation
+	}
+	if accessMode != "" {
+		s.nextTransaction.AccessMode = accessMode
+	}
+}
+
+// beginStatement returns the BEGIN starting the next transaction and clears the
+// characteristics set for it, the caller holds s.mu
+func (s *Session) beginStatement() string {
+	modes := s.nextTransaction.Modes()
+	s.nextTransaction = mapper.TransactionCharacteristics{}
+	if modes == "" {
+		return "BEGIN"
+	}
+	return "BEGIN " + modes
+}
+
 func (s *Session) BeginTransaction() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -242,12 +377,13 @@ func (s *Session) BeginTransaction() error {
 	}
 
 	ctx := context.Background()
-	_, err := s.pgConn.Exec(ctx, "BEGIN")
+	_, err := s.pgConn.Exec(ctx, s.beginStatement())
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	s.InTransaction = true
+	s.TxStartedAt = time.Now()
 	return nil
 }
 
@@ -3,15 +3,18 @@ package sqlrewrite
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
 )
 
 // ASTRewriter AST-based SQL rewriter
 // This is the main class that integrates TypeMapper, ASTVisitor and PGGenerator
 type ASTRewriter struct {
+	parserMu  sync.Mutex // The parser is shared by all sessions and not safe for concurrent use
 	parser    *parser.Parser
-	visitor   *ASTVisitor
+	visitor   *ASTVisitor // Configuration and registries, statements are rewritten by a copy, see forStatement
 	generator *PGGenerator
 	enabled   bool
 }
@@ -29,53 +32,147 @@ func NewASTRewriter() *ASTRewriter {
 // Rewrite rewrites MySQL SQL to PostgreSQL SQL
 // This is the main public API
 func (r *ASTRewriter) Rewrite(sql string) (string, error) {
+	return r.RewriteWithUserVars(sql, nil)
+}
+
+// RewriteWithUserVars rewrites MySQL SQL to PostgreSQL SQL, replacing @name
+// references with the given session user variables
+func (r *ASTRewriter) RewriteWithUserVars(sql string, userVars map[string]interface{}) (string, error) {
+	stmt, err := r.rewrite(sql, userVars)
+	if err != nil {
+		return "", err
+	}
+	return stmt.SQL, nil
+}
+
+// rewrite rewrites sql and reports the type of the parsed statement
+func (r *ASTRewriter) rewrite(sql string, userVars map[string]interface{}) (*Statement, error) {
 	if !r.enabled {
-		return sql, nil
+		return &Statement{SQL: sql, Type: statementTypeOfKeyword(sql)}, nil
 	}
 
 	// Step 1: Parse MySQL SQL to AST
-	stmts, _, err := r.parser.Parse(sql, "", "")
+	r.parserMu.Lock()
+	r.parser.SetSQLMode(parserSQLMode(userVars))
+	normalized := normalizeShareLock(normalizeLimitAll(sql))
+	stmts, _, err := r.parser.Parse(normalized, "", "")
+	if err != nil {
+		// DEFAULT (expression) only parses as a function call, see wrapDefaultExprs
+		if wrapped := wrapDefaultExprs(normalized); wrapped != normalized {
+			stmts, _, err = r.parser.Parse(wrapped, "", "")
+		} else if split := splitTruncateTables(normalized); split != normalized {
+			stmts, _, err = r.parser.Parse(split, "", "")
+		}
+	}
+	// The parser reuses the slice it returns
+	stmts = append([]ast.StmtNode(nil), stmts...)
+	r.parserMu.Unlock()
 	if err != nil {
-		return "", fmt.Errorf("failed to parse SQL: %w", err)
+		return nil, &RewriteError{Reason: ReasonParse, Feature: statementKeyword(sql), Err: fmt.Errorf("failed to parse SQL: %w", err)}
 	}
 
 	if len(stmts) == 0 {
-		return "", fmt.Errorf("no statements found in SQL")
+		return nil, &RewriteError{Reason: ReasonParse, Feature: "other", Err: fmt.Errorf("no statements found in SQL")}
 	}
 
 	// Currently only handles single statement
 	stmt := stmts[0]
+	if truncate, ok := truncateOf(stmts); ok {
+		stmt = truncate
+	}
 
-	// Step 2: Traverse and transform AST
-	// Reset visitor state
-	r.visitor.ResetPlaceholders()
+	// EXPLAIN is put around the rewritten statement, see Explain
+	explain, explaining := explainOf(stmt)
+	if explaining {
+		stmt = explain.Stmt
+	}
 
-	// Use visitor to traverse and transform AST
-	stmt.Accept(r.visitor)
+	// Step 2: Traverse and transform AST
+	placeholders := countParamMarkers(stmt)
+	visitor := r.visitor.forStatement(userVars)
+	visitor.divisionErrors = divisionByZeroErrors(stmt, userVars)
+	visitor.exprSubqueries = exprSubqueries(stmt)
+
+	// Use visitor to traverse and transform AST, statements PostgreSQL spells
+	// differently come back as a PostgreSQL-only node
+	if node, _ := stmt.Accept(visitor); node != nil {
+		stmt = node.(ast.StmtNode)
+	}
 
-	if err := r.visitor.GetError(); err != nil {
-		return "", fmt.Errorf("AST transformation failed: %w", err)
+	if err := visitor.GetError(); err != nil {
+		if rerr, ok := err.(*RewriteError); ok {
+			return nil, rerr
+		}
+		return nil, &RewriteError{Reason: ReasonTransform, Feature: statementKeyword(sql), Err: fmt.Errorf("AST transformation failed: %w", err)}
 	}
 
 	// Step 3: Generate PostgreSQL SQL from transformed AST
 	pgSQL, paramCount, err := r.generator.GenerateWithPlaceholders(stmt)
 	if err != nil {
-		return "", fmt.Errorf("SQL generation failed: %w", err)
+		return nil, &RewriteError{Reason: ReasonGenerate, Feature: statementKeyword(sql), Err: fmt.Errorf("SQL generation failed: %w", err)}
+	}
+	if paramCount != placeholders {
+		return nil, &RewriteError{Reason: ReasonGenerate, Feature: statementKeyword(sql), Err: &ParamCountError{Placeholders: placeholders, Rewritten: paramCount}}
+	}
+	if err := r.countRowCounters(stmt, visitor); err != nil {
+		return nil, &RewriteError{Reason: ReasonGenerate, Feature: statementKeyword(sql), Err: fmt.Errorf("SQL generation failed: %w", err)}
 	}
 
 	// Step 4: Post-processing
 	pgSQLBeforePost := pgSQL
 	pgSQL = r.generator.PostProcess(pgSQL)
+	pgSQL = expandInfoSchemaViews(pgSQL)
+	if explaining {
+		e := &Explain{Query: pgSQL, Format: explainFormat(explain), Analyze: explain.Analyze, Params: paramCount}
+		return &Statement{SQL: e.SQL(false), Type: StatementOther, Explain: e}, nil
+	}
+	if visitor.trailingSQL != "" {
+		pgSQL += "; " + visitor.trailingSQL
+	}
 
 	// DEBUG: Log post-process changes
 	if pgSQL != pgSQLBeforePost {
 		fmt.Fprintf(os.Stderr, "PostProcess changed SQL: %q -> %q\n", pgSQLBeforePost, pgSQL)
 	}
 
-	// Record placeholder count (for debugging)
-	_ = paramCount
+	return &Statement{
+		SQL:               pgSQL,
+		Type:              statementTypeOf(stmt),
+		FirstGeneratedRow: visitor.firstGenerated,
+		SingleRowInsert:   singleRowInsert(stmt) && visitor.trailingSQL == "",
+		Counters:          visitor.counters,
+		SelectParams:      selectParamNumbers(visitor.selectParams, visitor.paramOffsets),
+	}, nil
+}
 
-	return pgSQL, nil
+// tablesToLoad parses sql and returns the tables a query or DML statement reads or
+// writes that were not created through the proxy, see ColumnTypeRegistry.NeedsLoad.
+// Statements that do not parse have none, rewrite reports the error
+func (r *ASTRewriter) tablesToLoad(sql string, userVars map[string]interface{}) []string {
+	if !r.enabled {
+		return nil
+	}
+	r.parserMu.Lock()
+	r.parser.SetSQLMode(parserSQLMode(userVars))
+	stmts, _, err := r.parser.Parse(normalizeShareLock(normalizeLimitAll(sql)), "", "")
+	var stmt ast.StmtNode
+	if err == nil && len(stmts) > 0 {
+		stmt = stmts[0]
+	}
+	r.parserMu.Unlock()
+
+	switch stmt.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt, *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt:
+	default:
+		return nil
+	}
+	var tables []string
+	for _, table := range referencedTables(stmt) {
+		if r.visitor.columnTypes.NeedsLoad(table) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
 }
 
 // RewriteBatch rewrites multiple SQL statements in batch
@@ -1,6 +1,8 @@
 package sqlrewrite
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -272,6 +274,844 @@ func TestASTRewriter_ErrorHandling(t *testing.T) {
 	}
 }
 
+func TestASTRewriter_AnyValue(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "ANY_VALUE in SELECT list",
+			mysql:    "SELECT dept, ANY_VALUE(name) FROM emp GROUP BY dept",
+			expected: `SELECT "dept",MIN("name") FROM "emp" GROUP BY "dept"`,
+		},
+		{
+			name:     "ANY_VALUE in HAVING",
+			mysql:    "SELECT dept FROM emp GROUP BY dept HAVING ANY_VALUE(salary) > 10",
+			expected: `SELECT "dept" FROM "emp" GROUP BY "dept" HAVING MIN("salary")>10`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestASTRewriter_IntervalArithmetic(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Relative interval in WHERE",
+			mysql:    "SELECT id FROM t WHERE created_at > NOW() - INTERVAL 7 DAY",
+			expected: `SELECT "id" FROM "t" WHERE "created_at">(CURRENT_TIMESTAMP-INTERVAL '7 DAY')`,
+		},
+		{
+			name:     "Fractional unit",
+			mysql:    "SELECT NOW() + INTERVAL 1.5 HOUR",
+			expected: `SELECT (CURRENT_TIMESTAMP+INTERVAL '1.5 HOUR') AS "NOW() + INTERVAL 1.5 HOUR"`,
+		},
+		{
+			name:     "Compound unit",
+			mysql:    "SELECT d - INTERVAL '1:30' HOUR_MINUTE FROM t",
+			expected: `SELECT ("d"-CAST('1:30' AS INTERVAL HOUR TO MINUTE)) AS "d - INTERVAL '1:30' HOUR_MINUTE" FROM "t"`,
+		},
+		{
+			name:     "Interval on the left",
+			mysql:    "SELECT INTERVAL 1 QUARTER + d FROM t",
+			expected: `SELECT ("d"+(1) * INTERVAL '3 MONTH') AS "INTERVAL 1 QUARTER + d" FROM "t"`,
+		},
+		{
+			name:     "Placeholder value",
+			mysql:    "SELECT id FROM t WHERE d > NOW() - INTERVAL ? DAY",
+			expected: `SELECT "id" FROM "t" WHERE "d">(CURRENT_TIMESTAMP-($1) * INTERVAL '1 DAY')`,
+		},
+		{
+			name:     "DATE_ADD function form",
+			mysql:    "SELECT DATE_ADD(d, INTERVAL 1 MONTH) FROM t",
+			expected: `SELECT ("d"+INTERVAL '1 MONTH') AS "DATE_ADD(d, INTERVAL 1 MONTH)" FROM "t"`,
+		},
+		{
+			name:     "Alias in the select list",
+			mysql:    "SELECT created_at + INTERVAL 1 HOUR AS next, id FROM t",
+			expected: `SELECT ("created_at"+INTERVAL '1 HOUR') AS "next","id" FROM "t"`,
+		},
+		{
+			name:     "GROUP BY and ORDER BY",
+			mysql:    "SELECT DATE(d + INTERVAL 1 DAY) AS day, COUNT(*) FROM t GROUP BY DATE(d + INTERVAL 1 DAY) ORDER BY MAX(d) - INTERVAL 2 MINUTE DESC",
+			expected: `SELECT DATE(("d"+INTERVAL '1 DAY')) AS "day",COUNT(1) FROM "t" GROUP BY DATE(("d"+INTERVAL '1 DAY')) ORDER BY (MAX("d")-INTERVAL '2 MINUTE') DESC`,
+		},
+		{
+			name:     "Ordering by the alias",
+			mysql:    "SELECT DATE_SUB(d, INTERVAL ? DAY) AS due FROM t GROUP BY due ORDER BY due",
+			expected: `SELECT ("d"-($1) * INTERVAL '1 DAY') AS "due" FROM "t" GROUP BY "due" ORDER BY "due"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestASTRewriter_UserVariables(t *testing.T) {
+	rewriter := NewASTRewriter()
+	vars := map[string]interface{}{"c": int64(3), "name": "o'k"}
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Bare variable is named after itself",
+			mysql:    "SELECT @c",
+			expected: `SELECT 3 AS "@c"`,
+		},
+		{
+			name:     "Case-insensitive name in expression",
+			mysql:    "SELECT @C + 1 AS n",
+			expected: `SELECT 3+1 AS "n"`,
+		},
+		{
+			name:     "String value",
+			mysql:    "SELECT @name",
+			expected: `SELECT 'o''k' AS "@name"`,
+		},
+		{
+			name:     "Unset variable is NULL",
+			mysql:    "SELECT @missing",
+			expected: `SELECT NULL AS "@missing"`,
+		},
+		{
+			name:     "Variable in WHERE",
+			mysql:    "SELECT * FROM t WHERE id = @c",
+			expected: `SELECT * FROM "t" WHERE "id"=3`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.RewriteWithUserVars(tt.mysql, vars)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("Prepared statement", func(t *testing.T) {
+		stmt, paramCount, err := NewRewriter(true).RewritePreparedStatement("SELECT * FROM t WHERE id = ? AND n > @c", vars)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM "t" WHERE "id"=$1 AND "n">3`, stmt.SQL)
+		assert.Equal(t, 1, paramCount)
+	})
+
+	t.Run("Concurrent sessions keep their own variables", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sessionVars := map[string]interface{}{"c": int64(i)}
+				for range 50 {
+					result, err := rewriter.RewriteWithUserVars("SELECT @c", sessionVars)
+					assert.NoError(t, err)
+					assert.Equal(t, fmt.Sprintf(`SELECT %d AS "@c"`, i), result)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestASTRewriter_SystemVariables(t *testing.T) {
+	rewriter := NewASTRewriter()
+	vars := map[string]interface{}{"@@time_zone": "+00:00", "@@autocommit": int64(0)}
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Several variables at once",
+			mysql:    "SELECT @@version, @@autocommit, @@session.time_zone",
+			expected: `SELECT '8.0.11' AS "@@version",0 AS "@@autocommit",'+00:00' AS "@@session.time_zone"`,
+		},
+		{
+			name:     "Alias",
+			mysql:    "SELECT @@max_allowed_packet AS packet, @@SESSION.sql_mode mode",
+			expected: `SELECT 67108864 AS "packet",'TRADITIONAL' AS "mode"`,
+		},
+		{
+			name:     "Global scope reads the default",
+			mysql:    "SELECT @@GLOBAL.time_zone, @@global.autocommit",
+			expected: `SELECT 'SYSTEM' AS "@@GLOBAL.time_zone",1 AS "@@global.autocommit"`,
+		},
+		{
+			name:     "Variable in an expression",
+			mysql:    "SELECT CONCAT('tz=', @@time_zone) AS tz",
+			expected: `SELECT CONCAT('tz=', '+00:00') AS "tz"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.RewriteWithUserVars(tt.mysql, vars)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("Unknown variable", func(t *testing.T) {
+		_, err := rewriter.RewriteWithUserVars("SELECT @@no_such_variable", vars)
+		assert.ErrorContains(t, err, "Unknown system variable 'no_such_variable'")
+
+		result, err := rewriter.RewriteWithUserVars("SELECT @@autocommit", vars)
+		require.NoError(t, err, "a failed rewrite must not affect the next one")
+		assert.Equal(t, `SELECT 0 AS "@@autocommit"`, result)
+	})
+}
+
+func TestASTRewriter_LogicalOperators(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "&& and || in WHERE",
+			mysql:    "SELECT * FROM users WHERE age > 18 && status = 'active' || role = 'admin'",
+			expected: `SELECT * FROM "users" WHERE "age">18 AND "status"='active' OR "role"='admin'`,
+		},
+		{
+			name:     "Grouped || in WHERE",
+			mysql:    "SELECT * FROM users WHERE (age < 18 || age > 65) && status = 'active'",
+			expected: `SELECT * FROM "users" WHERE ("age"<18 OR "age">65) AND "status"='active'`,
+		},
+		{
+			name:     "!= and <>",
+			mysql:    "SELECT * FROM users WHERE status != 'banned' AND role <> 'guest'",
+			expected: `SELECT * FROM "users" WHERE "status"!='banned' AND "role"!='guest'`,
+		},
+		{
+			name:     "Operators inside strings are untouched",
+			mysql:    "SELECT * FROM users WHERE name = 'a && b || c' || note <> '!x'",
+			expected: `SELECT * FROM "users" WHERE "name"='a && b || c' OR "note"!='!x'`,
+		},
+		{
+			name:     "! becomes NOT",
+			mysql:    "SELECT * FROM users WHERE !active && !(age > 18)",
+			expected: `SELECT * FROM "users" WHERE NOT "active" AND NOT ("age">18)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("PIPES_AS_CONCAT", func(t *testing.T) {
+		for _, mode := range []string{"PIPES_AS_CONCAT", "ansi", "STRICT_TRANS_TABLES,PIPES_AS_CONCAT"} {
+			result, err := rewriter.RewriteWithUserVars("SELECT first_name || ' ' || last_name FROM users WHERE id = 1 || id = 2",
+				map[string]interface{}{"@@sql_mode": mode})
+			require.NoError(t, err, mode)
+			assert.Equal(t, `SELECT CONCAT(CONCAT("first_name", ' '), "last_name") FROM "users" WHERE "id"=CONCAT(1, "id")=2`, result, mode)
+		}
+
+		// The mode belongs to the session, the next statement parses || as OR again
+		result, err := rewriter.Rewrite("SELECT * FROM users WHERE id = 1 || id = 2")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM "users" WHERE "id"=1 OR "id"=2`, result)
+	})
+}
+
+func TestASTRewriter_Rollup(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Single column",
+			mysql:    "SELECT category, SUM(amount) FROM sales GROUP BY category WITH ROLLUP",
+			expected: `SELECT "category",SUM("amount") FROM "sales" GROUP BY ROLLUP("category")`,
+		},
+		{
+			name:     "Multiple columns",
+			mysql:    "SELECT region, category, SUM(amount) FROM sales GROUP BY region, category WITH ROLLUP",
+			expected: `SELECT "region","category",SUM("amount") FROM "sales" GROUP BY ROLLUP("region", "category")`,
+		},
+		{
+			name:     "With HAVING, ORDER BY and placeholders",
+			mysql:    "SELECT region, COUNT(*) AS n FROM sales WHERE amount > ? GROUP BY region WITH ROLLUP HAVING COUNT(*) > ? ORDER BY region",
+			expected: `SELECT "region",COUNT(1) AS "n" FROM "sales" WHERE "amount">$1 GROUP BY ROLLUP("region") HAVING COUNT(1)>$2 ORDER BY "region"`,
+		},
+		{
+			name:     "GROUPING()",
+			mysql:    "SELECT region, GROUPING(region) FROM sales GROUP BY region WITH ROLLUP",
+			expected: `SELECT "region",GROUPING("region") FROM "sales" GROUP BY ROLLUP("region")`,
+		},
+		{
+			name:     "Plain GROUP BY is untouched",
+			mysql:    "SELECT region, SUM(amount) FROM sales GROUP BY region",
+			expected: `SELECT "region",SUM("amount") FROM "sales" GROUP BY "region"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestASTRewriter_DivisionByZero(t *testing.T) {
+	rewriter := NewASTRewriter()
+	lenient := map[string]interface{}{"@@sql_mode": "ANSI"}
+
+	tests := []struct {
+		name     string
+		mysql    string
+		vars     map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "SELECT yields NULL",
+			mysql:    "SELECT a / b, a DIV b, a % b, MOD(a, b) FROM t",
+			expected: `SELECT "a"/NULLIF("b", 0),DIV("a", NULLIF("b", 0)),"a"%NULLIF("b", 0),"a"%NULLIF("b", 0) FROM "t"`,
+		},
+		{
+			name:     "Non-zero literal divisors are kept",
+			mysql:    "SELECT a / 2, a DIV 3, a MOD 0.5, a / 0 FROM t",
+			expected: `SELECT "a"/2,DIV("a", 3),"a"%0.5,"a"/NULLIF(0, 0) FROM "t"`,
+		},
+		{
+			name:     "Placeholder divisor",
+			mysql:    "SELECT a / ? FROM t WHERE b > ?",
+			expected: `SELECT "a"/NULLIF($1, 0.0) FROM "t" WHERE "b">$2`,
+		},
+		{
+			name:     "Strict INSERT and UPDATE fail",
+			mysql:    "UPDATE t SET a = a / b, c = c DIV 2",
+			expected: `UPDATE "t" SET "a"="a"/"b", "c"=DIV("c", 2)`,
+		},
+		{
+			name:     "Lenient sql_mode yields NULL",
+			mysql:    "INSERT INTO t (a) VALUES (1 / ?)",
+			vars:     lenient,
+			expected: `INSERT INTO "t" ("a") VALUES (1/NULLIF($1, 0.0))`,
+		},
+		{
+			name:     "ERROR_FOR_DIVISION_BY_ZERO without strict mode",
+			mysql:    "UPDATE t SET a = a % b",
+			vars:     map[string]interface{}{"@@sql_mode": "ERROR_FOR_DIVISION_BY_ZERO"},
+			expected: `UPDATE "t" SET "a"="a"%NULLIF("b", 0)`,
+		},
+		{
+			name:     "Strict sql_mode",
+			mysql:    "INSERT INTO t (a) VALUES (1 / ?)",
+			vars:     map[string]interface{}{"@@sql_mode": "STRICT_TRANS_TABLES,ERROR_FOR_DIVISION_BY_ZERO"},
+			expected: `INSERT INTO "t" ("a") VALUES (1/$1)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.RewriteWithUserVars(tt.mysql, tt.vars)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestASTRewriter_IfNull(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Nested",
+			mysql:    "SELECT IFNULL(IFNULL(a, b), c), IFNULL(a, IFNULL(b, IFNULL(c, 0))) FROM t",
+			expected: `SELECT COALESCE(COALESCE("a", "b"), "c"),COALESCE("a", COALESCE("b", COALESCE("c", 0))) FROM "t"`,
+		},
+		{
+			name:     "Inside aggregates",
+			mysql:    "SELECT SUM(IFNULL(price, 0)), COUNT(DISTINCT IFNULL(a, 0)), MAX(IFNULL(IFNULL(a, b), 0)) FROM t",
+			expected: `SELECT SUM(COALESCE("price", 0)),COUNT(DISTINCT COALESCE("a", 0)),MAX(COALESCE(COALESCE("a", "b"), 0)) FROM "t"`,
+		},
+		{
+			name:     "Inside other functions",
+			mysql:    "SELECT CONCAT(IFNULL(a, ''), UPPER(IFNULL(b, ''))), IF(IFNULL(a, 0) > 0, IFNULL(b, 1), 2) FROM t",
+			expected: `SELECT CONCAT(COALESCE("a", ''), UPPER(COALESCE("b", ''))),CASE WHEN COALESCE("a", 0)>0 THEN COALESCE("b", 1) ELSE 2 END FROM "t"`,
+		},
+		{
+			name:     "UPDATE and WHERE",
+			mysql:    "UPDATE t SET a = IFNULL(IFNULL(b, ?), 0) WHERE IFNULL(x, 0) > ?",
+			expected: `UPDATE "t" SET "a"=COALESCE(COALESCE("b", $1), 0) WHERE COALESCE("x", 0)>$2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	// MySQL refuses IFNULL with other than two arguments, COALESCE would take them
+	_, err := rewriter.Rewrite("SELECT IFNULL(a, b, c) FROM t")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "IFNULL function requires 2 arguments, got 3")
+}
+
+func TestASTRewriter_LockingReads(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		mysql    string
+		expected string
+	}{
+		{"SELECT * FROM t WHERE id = 1 FOR UPDATE NOWAIT", `SELECT * FROM "t" WHERE "id"=1 FOR UPDATE NOWAIT`},
+		{"SELECT * FROM t WHERE id = 1 FOR UPDATE SKIP LOCKED", `SELECT * FROM "t" WHERE "id"=1 FOR UPDATE SKIP LOCKED`},
+		{
+			"SELECT * FROM t JOIN u ON t.id = u.tid WHERE t.id = ? FOR UPDATE OF t, u NOWAIT",
+			`SELECT * FROM "t" JOIN "u" ON "t"."id"="u"."tid" WHERE "t"."id"=$1 FOR UPDATE OF "t", "u" NOWAIT`,
+		},
+		{"SELECT * FROM t AS x WHERE id = 1 FOR UPDATE OF x", `SELECT * FROM "t" AS "x" WHERE "id"=1 FOR UPDATE OF "x"`},
+		{"SELECT * FROM t WHERE id = 1 FOR SHARE OF t NOWAIT", `SELECT * FROM "t" WHERE "id"=1 FOR SHARE OF "t" NOWAIT`},
+		{"SELECT * FROM t WHERE id = 1 FOR SHARE SKIP LOCKED", `SELECT * FROM "t" WHERE "id"=1 FOR SHARE SKIP LOCKED`},
+		{"SELECT * FROM t WHERE id = 1 LOCK IN SHARE MODE", `SELECT * FROM "t" WHERE "id"=1 FOR SHARE`},
+		{"SELECT * FROM t WHERE id = 1 lock in share\n mode NOWAIT", `SELECT * FROM "t" WHERE "id"=1 FOR SHARE NOWAIT`},
+		{"SELECT * FROM t WHERE id = 1 LOCK IN SHARE MODE SKIP LOCKED", `SELECT * FROM "t" WHERE "id"=1 FOR SHARE SKIP LOCKED`},
+		{
+			"SELECT * FROM t JOIN (SELECT id FROM u LOCK IN SHARE MODE) AS s ON t.id = s.id FOR UPDATE",
+			`SELECT * FROM "t" JOIN (SELECT "id" FROM "u" FOR SHARE) AS "s" ON "t"."id"="s"."id" FOR UPDATE`,
+		},
+		{
+			"SELECT * FROM t WHERE id IN (SELECT tid FROM u FOR UPDATE NOWAIT) LOCK IN SHARE MODE",
+			`SELECT * FROM "t" WHERE "id" IN (SELECT "tid" FROM "u" FOR UPDATE NOWAIT) FOR SHARE`,
+		},
+		{
+			"(SELECT id FROM t FOR UPDATE) UNION ALL (SELECT id FROM u LOCK IN SHARE MODE)",
+			`(SELECT "id" FROM "t" FOR UPDATE) UNION ALL (SELECT "id" FROM "u" FOR SHARE)`,
+		},
+		{"SELECT * FROM t WHERE note = 'LOCK IN SHARE MODE'", `SELECT * FROM "t" WHERE "note"='LOCK IN SHARE MODE'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mysql, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestASTRewriter_UpdateOrderLimit(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Top-N rows by an ordering column",
+			mysql:    "UPDATE jobs SET state = 'run' WHERE state = 'new' ORDER BY created LIMIT 10",
+			expected: `UPDATE "jobs" SET "state"='run' WHERE "ctid" IN (SELECT "ctid" FROM "jobs" WHERE "state"='new' ORDER BY "created" LIMIT 10 FOR UPDATE)`,
+		},
+		{
+			name:     "Placeholders keep their order",
+			mysql:    "UPDATE jobs SET state = ? WHERE state = ? ORDER BY created DESC, id LIMIT ?",
+			expected: `UPDATE "jobs" SET "state"=$1 WHERE "ctid" IN (SELECT "ctid" FROM "jobs" WHERE "state"=$2 ORDER BY "created" DESC,"id" LIMIT $3 FOR UPDATE)`,
+		},
+		{
+			name:     "LIMIT without ORDER BY",
+			mysql:    "UPDATE jobs SET state = 'run' LIMIT 1",
+			expected: `UPDATE "jobs" SET "state"='run' WHERE "ctid" IN (SELECT "ctid" FROM "jobs" LIMIT 1 FOR UPDATE)`,
+		},
+		{
+			name:     "ORDER BY without LIMIT is dropped",
+			mysql:    "UPDATE jobs SET n = n + 1 ORDER BY id DESC",
+			expected: `UPDATE "jobs" SET "n"="n"+1`,
+		},
+		{
+			name:     "LIMIT in a subquery is untouched",
+			mysql:    "UPDATE jobs SET n = 0 WHERE id IN (SELECT id FROM done ORDER BY id LIMIT 3)",
+			expected: `UPDATE "jobs" SET "n"=0 WHERE "id" IN (SELECT "id" FROM "done" ORDER BY "id" LIMIT 3)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("ORDER BY without LIMIT is reported", func(t *testing.T) {
+		detector := NewUnsupportedDetector()
+		features := detector.Detect("UPDATE jobs SET n = n + 1 ORDER BY id DESC")
+		require.Len(t, features, 1)
+		assert.Equal(t, "warning", features[0].Severity)
+		assert.Empty(t, detector.Detect("UPDATE jobs SET n = n + 1 ORDER BY id DESC LIMIT 5"))
+	})
+}
+
+func TestASTRewriter_Version(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	result, err := rewriter.Rewrite("SELECT VERSION()")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT '8.0.11' AS "VERSION()"`, result)
+
+	rewriter.SetServerVersion("5.7.44-aproxy")
+	result, err = rewriter.Rewrite("SELECT version() AS v, CONCAT('v', VERSION())")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT '5.7.44-aproxy' AS "v",CONCAT('v', '5.7.44-aproxy')`, result)
+}
+
+func TestASTRewriter_RowConstructorIn(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	tests := []struct {
+		name       string
+		mysql      string
+		expected   string
+		paramCount int
+	}{
+		{
+			name:     "Literal rows",
+			mysql:    "SELECT * FROM t WHERE (a,b) IN ((1,2),(3,4))",
+			expected: `SELECT * FROM "t" WHERE ROW("a","b") IN (ROW(1,2),ROW(3,4))`,
+		},
+		{
+			name:     "NOT IN with string values",
+			mysql:    "SELECT * FROM t WHERE (a, b) NOT IN ((1, 'x'))",
+			expected: `SELECT * FROM "t" WHERE ROW("a","b") NOT IN (ROW(1,'x'))`,
+		},
+		{
+			name:       "Placeholders",
+			mysql:      "SELECT * FROM t WHERE (a,b) IN ((?,?),(?,?))",
+			expected:   `SELECT * FROM "t" WHERE ROW("a","b") IN (ROW($1,$2),ROW($3,$4))`,
+			paramCount: 4,
+		},
+		{
+			name:       "Placeholders before and after the row list",
+			mysql:      "SELECT * FROM t WHERE c = ? AND (a,b) IN ((?,?)) AND d > ?",
+			expected:   `SELECT * FROM "t" WHERE "c"=$1 AND ROW("a","b") IN (ROW($2,$3)) AND "d">$4`,
+			paramCount: 4,
+		},
+		{
+			name:     "Subquery",
+			mysql:    "SELECT * FROM t WHERE (a,b) IN (SELECT x, y FROM u)",
+			expected: `SELECT * FROM "t" WHERE ROW("a","b") IN (SELECT "x","y" FROM "u")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, paramCount, err := rewriter.RewritePrepared(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.paramCount, paramCount)
+		})
+	}
+}
+
+func TestASTRewriter_EnumOrderBy(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	_, err := rewriter.Rewrite("CREATE TABLE orders (id INT PRIMARY KEY, status ENUM('new','paid','shipped'))")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Declaration order",
+			mysql:    "SELECT id FROM orders ORDER BY status",
+			expected: `SELECT "id" FROM "orders" ORDER BY CASE "status" WHEN 'new' THEN 1 WHEN 'paid' THEN 2 WHEN 'shipped' THEN 3 ELSE 0 END`,
+		},
+		{
+			name:     "Qualified by alias",
+			mysql:    "SELECT id FROM orders o ORDER BY o.status DESC, id",
+			expected: `SELECT "id" FROM "orders" AS "o" ORDER BY CASE "o"."status" WHEN 'new' THEN 1 WHEN 'paid' THEN 2 WHEN 'shipped' THEN 3 ELSE 0 END DESC,"id"`,
+		},
+		{
+			name:     "DISTINCT keeps the column",
+			mysql:    "SELECT DISTINCT status FROM orders ORDER BY status",
+			expected: `SELECT DISTINCT "status" FROM "orders" ORDER BY "status"`,
+		},
+		{
+			name:     "Ordinal position",
+			mysql:    "SELECT id, status FROM orders ORDER BY 2 DESC",
+			expected: `SELECT "id","status" FROM "orders" ORDER BY CASE "status" WHEN 'new' THEN 1 WHEN 'paid' THEN 2 WHEN 'shipped' THEN 3 ELSE 0 END DESC`,
+		},
+		{
+			name:     "Ordinal position after a wildcard",
+			mysql:    "SELECT *, status FROM orders ORDER BY 2",
+			expected: `SELECT *,"status" FROM "orders" ORDER BY 2`,
+		},
+		{
+			name:     "Select alias is not the ENUM column",
+			mysql:    "SELECT UPPER(status) AS status FROM orders ORDER BY status",
+			expected: `SELECT UPPER("status") AS "status" FROM "orders" ORDER BY "status"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("Forgotten after DROP TABLE", func(t *testing.T) {
+		_, err := rewriter.Rewrite("DROP TABLE orders")
+		require.NoError(t, err)
+		result, err := rewriter.Rewrite("SELECT id FROM orders ORDER BY status")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "id" FROM "orders" ORDER BY "status"`, result)
+	})
+}
+
+func TestRewriter_DeclaredColumnTypes(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	result, err := rewriter.Rewrite("CREATE TABLE items (id INT, Status ENUM('new','it''s'), tags SET('a','bc'))")
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE TABLE "items" ("id" INT,"Status" VARCHAR(50),"tags" VARCHAR(4))`, result)
+	assert.Equal(t, map[string]string{"status": "enum('new','it''s')", "tags": "set('a','bc')"},
+		rewriter.DeclaredColumnTypes("ITEMS"))
+
+	_, err = rewriter.Rewrite("DROP TABLE items")
+	require.NoError(t, err)
+	assert.Empty(t, rewriter.DeclaredColumnTypes("items"))
+}
+
+func TestASTRewriter_OrdinalPositions(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "GROUP BY and ORDER BY positions",
+			mysql:    "SELECT status, COUNT(*) FROM orders GROUP BY 1 ORDER BY 2 DESC, 1",
+			expected: `SELECT "status",COUNT(1) FROM "orders" GROUP BY 1 ORDER BY 2 DESC,1`,
+		},
+		{
+			name:     "Rewritten function keeps its position",
+			mysql:    "SELECT DATE_FORMAT(created_at, '%Y-%m'), COUNT(*) FROM orders GROUP BY 1 ORDER BY 1",
+			expected: `SELECT TO_CHAR("created_at", '%Y-%m'),COUNT(1) FROM "orders" GROUP BY 1 ORDER BY 1`,
+		},
+		{
+			name:     "IF becomes CASE in place",
+			mysql:    "SELECT IF(amount > 100, 'large', 'small'), status, SUM(amount) FROM orders GROUP BY 1, 2 ORDER BY 3 DESC",
+			expected: `SELECT CASE WHEN "amount">100 THEN 'large' ELSE 'small' END,"status",SUM("amount") FROM "orders" GROUP BY 1,2 ORDER BY 3 DESC`,
+		},
+		{
+			name:     "Interval arithmetic",
+			mysql:    "SELECT DATE_ADD(created_at, INTERVAL 1 DAY) AS due, COUNT(*) FROM orders GROUP BY 1 ORDER BY 1",
+			expected: `SELECT ("created_at"+INTERVAL '1 DAY') AS "due",COUNT(1) FROM "orders" GROUP BY 1 ORDER BY 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestASTRewriter_BooleanLiterals(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	_, err := rewriter.Rewrite("CREATE TABLE flags (id INT PRIMARY KEY, active TINYINT(1), deleted TINYINT, name VARCHAR(50))")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Compared with TRUE and FALSE",
+			mysql:    "SELECT id FROM flags WHERE active = TRUE AND deleted <> FALSE",
+			expected: `SELECT "id" FROM "flags" WHERE "active"=1 AND "deleted"!=0`,
+		},
+		{
+			name:     "Literal on the left",
+			mysql:    "SELECT id FROM flags f WHERE FALSE = f.active",
+			expected: `SELECT "id" FROM "flags" AS "f" WHERE 0="f"."active"`,
+		},
+		{
+			name:     "IS TRUE and IS FALSE",
+			mysql:    "SELECT id FROM flags WHERE active IS TRUE AND deleted IS FALSE",
+			expected: `SELECT "id" FROM "flags" WHERE "active"!=0 AND "deleted"=0`,
+		},
+		{
+			name:     "IS NOT keeps NULL rows",
+			mysql:    "SELECT id FROM flags WHERE deleted IS NOT TRUE",
+			expected: `SELECT "id" FROM "flags" WHERE ("deleted"!=0) IS NOT TRUE`,
+		},
+		{
+			name:     "IS UNKNOWN is IS NULL",
+			mysql:    "SELECT id FROM flags WHERE active IS UNKNOWN OR (deleted > 0) IS NOT UNKNOWN",
+			expected: `SELECT "id" FROM "flags" WHERE "active" IS NULL OR ("deleted">0) IS NOT NULL`,
+		},
+		{
+			name:     "IS UNKNOWN in CASE",
+			mysql:    "SELECT CASE WHEN (active = deleted) IS UNKNOWN THEN 'unknown' WHEN active IS TRUE THEN 'on' ELSE 'off' END FROM flags",
+			expected: `SELECT CASE WHEN ("active"="deleted") IS NULL THEN 'unknown' WHEN "active"!=0 THEN 'on' ELSE 'off' END FROM "flags"`,
+		},
+		{
+			name:     "UPDATE assignment and WHERE",
+			mysql:    "UPDATE flags SET active = FALSE WHERE deleted = TRUE",
+			expected: `UPDATE "flags" SET "active"=0 WHERE "deleted"=1`,
+		},
+		{
+			name:     "Subquery resolves its own tables",
+			mysql:    "SELECT * FROM accounts WHERE enabled = TRUE AND id IN (SELECT id FROM flags WHERE active = TRUE)",
+			expected: `SELECT * FROM "accounts" WHERE "enabled"=TRUE AND "id" IN (SELECT "id" FROM "flags" WHERE "active"=1)`,
+		},
+		{
+			name:     "Non-integer columns are untouched",
+			mysql:    "SELECT id FROM flags WHERE name = TRUE",
+			expected: `SELECT "id" FROM "flags" WHERE "name"=TRUE`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("Forgotten after DROP TABLE", func(t *testing.T) {
+		_, err := rewriter.Rewrite("DROP TABLE flags")
+		require.NoError(t, err)
+		result, err := rewriter.Rewrite("SELECT id FROM flags WHERE active IS TRUE")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "id" FROM "flags" WHERE "active" IS TRUE`, result)
+	})
+}
+
+func TestASTRewriter_NullSafeEqual(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "WHERE",
+			mysql:    "SELECT id FROM users WHERE manager_id <=> NULL",
+			expected: `SELECT "id" FROM "users" WHERE "manager_id" IS NOT DISTINCT FROM NULL`,
+		},
+		{
+			name:     "JOIN condition",
+			mysql:    "SELECT a.id FROM a JOIN b ON a.code <=> b.code",
+			expected: `SELECT "a"."id" FROM "a" JOIN "b" ON "a"."code" IS NOT DISTINCT FROM "b"."code"`,
+		},
+		{
+			name:     "Negation",
+			mysql:    "SELECT id FROM users WHERE NOT (manager_id <=> ?) AND !(team <=> 'ops')",
+			expected: `SELECT "id" FROM "users" WHERE "manager_id" IS DISTINCT FROM $1 AND "team" IS DISTINCT FROM 'ops'`,
+		},
+		{
+			name:     "Operands binding looser than IS",
+			mysql:    "SELECT a + 1 <=> b, (a = 1) <=> (b = 1) FROM t",
+			expected: `SELECT ("a"+1) IS NOT DISTINCT FROM "b",("a"=1) IS NOT DISTINCT FROM ("b"=1) FROM "t"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestASTRewriter_CharsetIntroducers(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "National string literals",
+			mysql:    "SELECT N'abc', n'x' FROM t WHERE name = N'Zoë'",
+			expected: `SELECT 'abc','x' FROM "t" WHERE "name"='Zoë'`,
+		},
+		{
+			name:     "Introducers in function arguments",
+			mysql:    "SELECT CONCAT(_latin1'a', _utf8mb4'b', N'c') FROM t",
+			expected: `SELECT CONCAT('a', 'b', 'c') FROM "t"`,
+		},
+		{
+			name:     "Identifiers with charset-like names",
+			mysql:    "SELECT `_utf8col`, name_utf8 FROM t WHERE `_utf8col` = _utf8'x' AND name_utf8 = 'a_utf8'",
+			expected: `SELECT "_utf8col","name_utf8" FROM "t" WHERE "_utf8col"='x' AND "name_utf8"='a_utf8'`,
+		},
+		{
+			name:     "INSERT",
+			mysql:    "INSERT INTO t (_utf8col) VALUES (N'x'), (_binary'y')",
+			expected: `INSERT INTO "t" ("_utf8col") VALUES ('x'),('y')`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 // Benchmarks
 func BenchmarkASTRewriter_SimpleSelect(b *testing.B) {
 	rewriter := NewASTRewriter()
@@ -4,10 +4,13 @@ package sqlrewrite
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/charset"
 	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
 	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
 )
 
@@ -18,6 +21,31 @@ type ASTVisitor struct {
 	typeMapper       *TypeMapper
 	placeholderIndex int // Placeholder index ($1, $2, ...)
 	functionMap      map[string]string
+	userVars         map[string]interface{} // Session variables substituted for @name and @@name references
+	enums            *EnumRegistry          // ENUM declarations captured from CREATE TABLE
+	enumOrderBy      bool                   // Rewrite ORDER BY on ENUM columns to declaration order
+	columnTypes      *ColumnTypeRegistry    // Column types captured from CREATE TABLE
+	firstGenerated   int                    // Row of the last INSERT whose AUTO_INCREMENT value is generated first
+	trailingSQL      string                 // Statements to run after the rewritten one, such as triggers
+	counters         []UserVarCounter       // User variables numbering rows, see rewriteRowCounters
+	paramOffsets     []int                  // Positions of the statement's placeholders
+	selectParams     []int                  // Positions of placeholders that are whole select-list columns
+	serverVersion    string                 // Returned by VERSION() instead of the PostgreSQL version
+	maxAllowedPacket int64                  // Returned by @@max_allowed_packet when set
+	divisionErrors   bool                   // Division by zero fails the statement, see divisionByZeroErrors
+	zeroDates        ZeroDatePolicy         // What INSERT and UPDATE write for '0000-00-00'
+	nullGreatest     bool                   // GREATEST and LEAST return NULL for a NULL argument, see guardGreatestLeast
+	trace            *[]RewriteStep         // Transformations recorded for ExplainRewrite, nil unless tracing
+
+	// Declared collations of the statement's column references, "" for columns not
+	// holding strings, see collationVisitor
+	columnCollations map[*ast.ColumnNameExpr]string
+	// String literals written to or compared with BYTEA columns, see rewriteNulString
+	binaryLiterals map[*driver.ValueExpr]bool
+	// SELECT each of counters numbers the rows of, and the SELECTs in subquery
+	// expressions, whose rows are not counted, see rewriteRowCounters
+	counterScopes  []*ast.SelectStmt
+	exprSubqueries map[*ast.SelectStmt]bool
 }
 
 // NewASTVisitor creates a new AST visitor
@@ -26,6 +54,12 @@ func NewASTVisitor() *ASTVisitor {
 		typeMapper:       NewTypeMapper(),
 		placeholderIndex: 0,
 		functionMap:      createFunctionMap(),
+		enums:            NewEnumRegistry(),
+		enumOrderBy:      true,
+		nullGreatest:     true,
+		columnTypes:      NewColumnTypeRegistry(),
+		serverVersion:    DefaultServerVersion,
+		zeroDates:        ZeroDateNull,
 	}
 }
 
@@ -62,7 +96,8 @@ func createFunctionMap() map[string]string {
 		"ltrim":             "LTRIM",
 		"rtrim":             "RTRIM",
 		"replace":           "REPLACE",
-		"locate":            "POSITION",
+		"locate":            "", // Requires special handling for case-insensitive search
+		"position":          "", // Requires special handling for case-insensitive search
 		"instr":             "", // Requires special handling
 		"find_in_set":       "", // Requires special handling
 
@@ -85,6 +120,7 @@ func createFunctionMap() map[string]string {
 		"max":               "MAX",
 		"min":               "MIN",
 		"group_concat":      "STRING_AGG", // Requires special handling for parameter order
+		"any_value":         "MIN",        // Deterministic pick; PostgreSQL has no ANY_VALUE before 16
 
 		// Conditional functions
 		"if":                "", // Needs conversion to CASE WHEN
@@ -104,6 +140,54 @@ func createFunctionMap() map[string]string {
 	}
 }
 
+// specialFunctions are rewritten by dedicated handlers rather than renamed,
+// custom function mappings must not override them
+var specialFunctions = map[string]bool{
+	"adddate":        true,
+	"date_add":       true,
+	"date_sub":       true,
+	"greatest":       true,
+	"group_concat":   true,
+	"if":             true,
+	"instr":          true,
+	"least":          true,
+	"locate":         true,
+	"position":       true,
+	"regexp_instr":   true,
+	"regexp_like":    true,
+	"regexp_replace": true,
+	"regexp_substr":  true,
+	"subdate":        true,
+	"unix_timestamp": true,
+	"uuid":           true,
+	"version":        true,
+}
+
+// functionNamePattern matches a function name, optionally qualified by a schema
+var functionNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// AddFunctionMappings merges custom MySQL -> PostgreSQL function renames into the
+// function map. Arguments are passed through unchanged. Built-in renames can be
+// overridden, functions with a dedicated handler cannot
+func (v *ASTVisitor) AddFunctionMappings(mappings map[string]string) error {
+	for mysqlFunc, pgFunc := range mappings {
+		name := strings.ToLower(mysqlFunc)
+		if specialFunctions[name] {
+			return fmt.Errorf("function mapping %s conflicts with the built-in %s conversion", mysqlFunc, strings.ToUpper(name))
+		}
+		if !functionNamePattern.MatchString(mysqlFunc) || strings.Contains(mysqlFunc, ".") {
+			return fmt.Errorf("invalid MySQL function name in mapping: %q", mysqlFunc)
+		}
+		if !functionNamePattern.MatchString(pgFunc) {
+			return fmt.Errorf("invalid PostgreSQL function name for %s: %q", mysqlFunc, pgFunc)
+		}
+	}
+	for mysqlFunc, pgFunc := range mappings {
+		v.functionMap[strings.ToLower(mysqlFunc)] = pgFunc
+	}
+	return nil
+}
+
 // Enter implements ast.Visitor interface - called when entering a node
 func (v *ASTVisitor) Enter(n ast.Node) (node ast.Node, skipChildren bool) {
 	if v.err != nil {
@@ -123,47 +207,236 @@ func (v *ASTVisitor) Enter(n ast.Node) (node ast.Node, skipChildren bool) {
 	case *ast.SelectStmt:
 		return v.visitSelect(node)
 
-	case *ast.Limit:
-		return v.visitLimit(node)
-
 	case *ast.MatchAgainst:
 		return v.visitMatchAgainst(node)
 
 	case *ast.CreateTableStmt:
 		return v.visitCreateTable(node)
+
+	case *ast.AlterTableStmt:
+		for _, spec := range node.Specs {
+			for _, col := range spec.NewColumns {
+				rewriteColumnCollation(col, declaredCollation(nil, col))
+			}
+		}
+
+	case *ast.DropTableStmt:
+		if node.TemporaryKeyword != ast.TemporaryNone {
+			// PostgreSQL has no DROP TEMPORARY TABLE, the tables are looked up in the
+			// session's temporary schema so a permanent table of the same name survives
+			node.TemporaryKeyword = ast.TemporaryNone
+			for _, table := range node.Tables {
+				table.Schema = ast.NewCIStr("pg_temp")
+			}
+		} else if !node.IsView {
+			for _, table := range node.Tables {
+				v.enums.DropTable(table.Name.L)
+				v.columnTypes.DropTable(table.Name.L)
+			}
+			v.trailingSQL = v.dropOnUpdateFunctions(node.Tables)
+		}
+
+	case *ast.UpdateStmt:
+		v.rewriteColumnLiterals(node, node.TableRefs)
+		for _, assignment := range node.List {
+			assignment.Expr = v.rewriteZeroDates(assignment.Expr)
+		}
+
+	case *ast.DeleteStmt:
+		v.rewriteColumnLiterals(node, node.TableRefs)
+
+	case *ast.InsertStmt:
+		// The parser fills the column list and a single row for INSERT ... SET a=1, b=2,
+		// restored without the flag it is the VALUES form PostgreSQL accepts
+		node.Setlist = false
+		v.rewriteAutoIncrementValues(node)
+		v.markBinaryValues(node)
+		for _, row := range node.Lists {
+			for i, expr := range row {
+				row[i] = v.rewriteZeroDates(expr)
+			}
+		}
+		for _, assignment := range node.OnDuplicate {
+			assignment.Expr = v.rewriteZeroDates(assignment.Expr)
+		}
+
+	case *ast.SelectField:
+		return v.visitSelectField(node)
+
+	case *ast.PatternLikeOrIlikeExpr:
+		v.visitLike(node)
+
+	case *ast.CaseExpr:
+		v.visitCase(node)
+
+	case *ast.BinaryOperationExpr:
+		v.visitComparison(node)
+
+	case *ast.PatternInExpr:
+		v.visitIn(node)
 	}
 
 	return n, false
 }
 
 // Leave implements ast.Visitor interface - called when leaving a node
+// Rewrites that replace a node with a different node type must happen here:
+// the parent assigns whatever Leave returns, while Enter's result is type-asserted
+// back to the original node type
 func (v *ASTVisitor) Leave(n ast.Node) (node ast.Node, ok bool) {
-	return n, v.err == nil
+	if v.err != nil {
+		return n, false
+	}
+	if v.trace != nil {
+		from := restoreTrace(n) // Before the replacement reuses parts of n
+		defer func() {
+			if node != n && node != nil {
+				v.traceStep("rewrite", from+" -> "+restoreTrace(node))
+			}
+		}()
+	}
+
+	switch node := n.(type) {
+	case *ast.FuncCallExpr:
+		switch node.FnName.L {
+		case "date_add", "date_sub", "adddate", "subdate":
+			return v.transformDateAddSub(node), v.err == nil
+		case "if":
+			return v.transformIF(node), v.err == nil
+		case "greatest", "least":
+			return v.guardGreatestLeast(node), true
+		case "regexp_like":
+			return v.transformRegexpLike(node), v.err == nil
+		case "regexp_replace", "regexp_substr", "regexp_instr":
+			return v.transformRegexpFunc(node), v.err == nil
+		case "version":
+			if len(node.Args) == 0 {
+				return ast.NewValueExpr(v.serverVersion, "", ""), true
+			}
+		case "uuid":
+			// MySQL returns the UUID as a string
+			if len(node.Args) == 0 {
+				return &pgCastExpr{ExprNode: &ast.FuncCallExpr{FnName: ast.NewCIStr("GEN_RANDOM_UUID")}, Type: "TEXT"}, true
+			}
+		case defaultExprMarker:
+			return unwrapDefaultExpr(node), true
+		}
+
+	case *ast.AggregateFuncExpr:
+		return rewriteMultiArgAggregate(node), true
+
+	case *driver.ValueExpr:
+		return v.rewriteNulString(node), v.err == nil
+
+	case *ast.VariableExpr:
+		if isUserVariableRef(node) {
+			return v.substituteUserVar(node), true
+		}
+		if isSystemVariableRef(node) {
+			return v.substituteSystemVar(node), v.err == nil
+		}
+		if !node.IsSystem && node.Value != nil {
+			v.err = fmt.Errorf("assigning @%s inside an expression is not supported, except for numbering rows with SELECT @%s := @%s + 1; use ROW_NUMBER() OVER (...)", node.Name, node.Name, node.Name)
+			return node, false
+		}
+
+	case *ast.Limit:
+		return v.visitLimit(node)
+
+	case *ast.PatternRegexpExpr:
+		return v.transformRegexpOperator(node), true
+
+	case *ast.ValuesExpr:
+		return excludedColumn(node), true
+
+	case *ast.InsertStmt:
+		if len(node.OnDuplicate) > 0 {
+			return v.rewriteOnDuplicateKeyUpdate(node), true
+		}
+
+	case *ast.UpdateStmt:
+		if node.Order != nil || node.Limit != nil {
+			return rewriteUpdateOrderLimit(node), true
+		}
+
+	case *ast.CreateViewStmt:
+		return &pgCreateViewStmt{CreateViewStmt: node}, true
+
+	case *ast.SetCollationExpr:
+		// PostgreSQL knows no MySQL collation names, the case sensitivity they
+		// select was applied when entering the comparison
+		return node.Expr, true
+
+	case *ast.FuncCastExpr:
+		if isBinaryCast(node) {
+			return rewriteBinaryCast(node), true
+		}
+		if cast, ok := rewriteTemporalCast(node); ok {
+			return cast, true
+		}
+
+	case *ast.BinaryOperationExpr:
+		// a <=> b -> a IS NOT DISTINCT FROM b
+		if node.Op == opcode.NullEQ {
+			return &pgDistinctExpr{ExprNode: node.L, R: node.R}, true
+		}
+		if isDivisionOp(node.Op) {
+			return v.rewriteDivision(node), true
+		}
+
+	case *ast.UnaryOperationExpr:
+		// NOT (a <=> b) -> a IS DISTINCT FROM b
+		if distinct, ok := unwrapParentheses(node.V).(*pgDistinctExpr); ok && (node.Op == opcode.Not || node.Op == opcode.Not2) {
+			distinct.Not = !distinct.Not
+			return distinct, true
+		}
+		// !a -> NOT a, PostgreSQL has no prefix ! operator
+		if node.Op == opcode.Not2 {
+			node.Op = opcode.Not
+		}
+	}
+
+	return n, true
 }
 
 // visitFuncCall handles function calls
 func (v *ASTVisitor) visitFuncCall(node *ast.FuncCallExpr) (ast.Node, bool) {
 	funcName := strings.ToLower(node.FnName.L)
 
+	// COALESCE takes any number of arguments, MySQL's IFNULL exactly two
+	if funcName == "ifnull" && len(node.Args) != 2 {
+		v.err = fmt.Errorf("IFNULL function requires 2 arguments, got %d", len(node.Args))
+		return node, false
+	}
+
 	// Look up function mapping
 	if pgFunc, exists := v.functionMap[funcName]; exists {
 		if pgFunc != "" {
 			// Simple function name replacement
+			if !strings.EqualFold(pgFunc, funcName) {
+				v.traceStep("function", strings.ToUpper(funcName)+"() -> "+pgFunc+"()")
+			}
 			node.FnName = ast.NewCIStr(pgFunc)
 			return node, false
 		}
 
 		// Functions requiring special handling
+		var result ast.Node
+		var skipChildren bool
 		switch funcName {
-		case "if":
-			return v.transformIF(node)
-		case "date_add", "date_sub":
-			return v.transformDateAddSub(node)
 		case "group_concat":
-			return v.transformGroupConcat(node)
+			result, skipChildren = v.transformGroupConcat(node)
 		case "unix_timestamp":
-			return v.transformUnixTimestamp(node)
+			result, skipChildren = v.transformUnixTimestamp(node)
+		case "locate", "position", "instr":
+			result, skipChildren = v.transformStringSearch(node)
+		default:
+			return node, false
+		}
+		if call, ok := result.(*ast.FuncCallExpr); ok && call.FnName.L != funcName {
+			v.traceStep("function", strings.ToUpper(funcName)+"() -> "+call.FnName.O+"()")
 		}
+		return result, skipChildren
 	}
 
 	return node, false
@@ -174,6 +447,7 @@ func (v *ASTVisitor) visitParamMarker(node *driver.ParamMarkerExpr) (ast.Node, b
 	// Placeholder index starts from 1
 	v.placeholderIndex++
 	node.Order = v.placeholderIndex
+	v.paramOffsets = append(v.paramOffsets, node.Offset)
 	return node, false
 }
 
@@ -195,24 +469,36 @@ func (v *ASTVisitor) visitColumnDef(node *ast.ColumnDef) (ast.Node, bool) {
 func (v *ASTVisitor) visitSelect(node *ast.SelectStmt) (ast.Node, bool) {
 	// Handle SELECT-specific PostgreSQL conversions
 	// For example: MySQL's LIMIT offset, count → PostgreSQL's LIMIT count OFFSET offset
+	v.rewriteRowCounters(node)
+	v.rewriteColumnLiterals(node, node.From)
+	if v.enumOrderBy {
+		v.rewriteEnumOrderBy(node)
+	}
+	v.rewriteInformationSchema(node)
+	v.rewriteRollup(node)
 	return node, false
 }
 
-// visitLimit handles LIMIT clause
-func (v *ASTVisitor) visitLimit(node *ast.Limit) (ast.Node, bool) {
-	// MySQL: LIMIT offset, count
-	// PostgreSQL: LIMIT count OFFSET offset
-
-	// If there's an Offset, ensure correct conversion
-	// TiDB Parser can already parse correctly, just need to confirm here
-	return node, false
+// rewriteRollup moves the grouping columns of WITH ROLLUP into a ROLLUP() grouping set
+// MySQL: SELECT a, b, SUM(c) FROM t GROUP BY a, b WITH ROLLUP
+// PostgreSQL: SELECT "a","b",SUM("c") FROM "t" GROUP BY ROLLUP("a", "b")
+func (v *ASTVisitor) rewriteRollup(node *ast.SelectStmt) {
+	if node.GroupBy == nil || !node.GroupBy.Rollup || len(node.GroupBy.Items) == 0 {
+		return
+	}
+	rollup := &pgRollupExpr{ExprNode: node.GroupBy.Items[0].Expr}
+	for _, item := range node.GroupBy.Items[1:] {
+		rollup.Rest = append(rollup.Rest, item.Expr)
+	}
+	node.GroupBy.Items = []*ast.ByItem{{Expr: rollup}}
+	node.GroupBy.Rollup = false
 }
 
 // transformIF converts IF(condition, true_val, false_val) to CASE WHEN
-func (v *ASTVisitor) transformIF(node *ast.FuncCallExpr) (ast.Node, bool) {
+func (v *ASTVisitor) transformIF(node *ast.FuncCallExpr) ast.Node {
 	if len(node.Args) != 3 {
 		v.err = fmt.Errorf("IF function requires 3 arguments, got %d", len(node.Args))
-		return node, true
+		return node
 	}
 
 	// Build CASE WHEN condition THEN true_val ELSE false_val END
@@ -226,16 +512,37 @@ func (v *ASTVisitor) transformIF(node *ast.FuncCallExpr) (ast.Node, bool) {
 		ElseClause: node.Args[2], // false_val
 	}
 
-	return caseExpr, false
+	return caseExpr
 }
 
-// transformDateAddSub converts DATE_ADD/DATE_SUB
+// transformDateAddSub converts DATE_ADD/DATE_SUB and interval arithmetic
+// TiDB parses "expr + INTERVAL n unit" and "expr - INTERVAL n unit" into DATE_ADD/DATE_SUB,
+// so this covers both the function form and standalone interval arithmetic
 // MySQL: DATE_ADD(date, INTERVAL expr unit)
-// PostgreSQL: date + INTERVAL 'expr unit'
-func (v *ASTVisitor) transformDateAddSub(node *ast.FuncCallExpr) (ast.Node, bool) {
-	// This requires special expression building, keep as-is for now
-	// In actual use, recommend users to use PostgreSQL syntax directly
-	return node, false
+// PostgreSQL: (date + INTERVAL 'expr unit')
+func (v *ASTVisitor) transformDateAddSub(node *ast.FuncCallExpr) ast.Node {
+	// ADDDATE(date, days) / SUBDATE(date, days) shorthand has no unit argument
+	if len(node.Args) != 3 {
+		return node
+	}
+
+	unit, ok := node.Args[2].(*ast.TimeUnitExpr)
+	if !ok {
+		return node
+	}
+
+	op := opcode.Plus
+	if node.FnName.L == "date_sub" || node.FnName.L == "subdate" {
+		op = opcode.Minus
+	}
+
+	return &ast.ParenthesesExpr{
+		Expr: &ast.BinaryOperationExpr{
+			Op: op,
+			L:  node.Args[0],
+			R:  &pgIntervalExpr{ExprNode: node.Args[1], Unit: unit.Unit},
+		},
+	}
 }
 
 // transformGroupConcat converts GROUP_CONCAT
@@ -269,9 +576,97 @@ func (v *ASTVisitor) GetPlaceholderCount() int {
 	return v.placeholderIndex
 }
 
+// SetEnumOrderBy enables or disables sorting ENUM columns by declaration order
+func (v *ASTVisitor) SetEnumOrderBy(enabled bool) {
+	v.enumOrderBy = enabled
+}
+
+// SetServerVersion sets the version string VERSION() returns
+func (v *ASTVisitor) SetServerVersion(version string) {
+	v.serverVersion = version
+}
+
+// SetMaxAllowedPacket sets the value @@max_allowed_packet reads, 0 keeps the default
+func (v *ASTVisitor) SetMaxAllowedPacket(size int64) {
+	v.maxAllowedPacket = size
+}
+
 // ResetPlaceholders resets the placeholder counter
 func (v *ASTVisitor) ResetPlaceholders() {
 	v.placeholderIndex = 0
+	v.paramOffsets = nil
+	v.selectParams = nil
+}
+
+// forStatement returns the visitor rewriting one statement with the given session
+// variables. Configuration and registries are shared with v, the state of the
+// statement (variables, placeholders, errors) belongs to the copy, so sessions
+// rewriting at the same time do not see each other's variables
+func (v *ASTVisitor) forStatement(userVars map[string]interface{}) *ASTVisitor {
+	stmt := *v
+	stmt.ResetPlaceholders()
+	stmt.err = nil
+	stmt.firstGenerated = 0
+	stmt.trailingSQL = ""
+	stmt.counters = nil
+	stmt.counterScopes = nil
+	stmt.exprSubqueries = nil
+	stmt.columnCollations = nil
+	stmt.binaryLiterals = nil
+	stmt.SetUserVars(userVars)
+	return &stmt
+}
+
+// SetUserVars sets the session variables that @name references resolve to. Keys with
+// the @@ prefix are system variables the session has SET, read by @@name references
+func (v *ASTVisitor) SetUserVars(vars map[string]interface{}) {
+	v.userVars = vars
+}
+
+// isUserVariableRef reports whether node reads a user variable (@name),
+// as opposed to a system variable or an assignment (@name := expr)
+func isUserVariableRef(node *ast.VariableExpr) bool {
+	return !node.IsSystem && node.Value == nil
+}
+
+// visitSelectField names a bare "SELECT @name", "SELECT @@name" or "SELECT VERSION()" column
+// after the expression, like MySQL does, since all are replaced by a literal. Date
+// arithmetic is named after it as well, PostgreSQL would name "x + INTERVAL 1 HOUR" ?column?
+// A bare placeholder is recorded to be cast to its bound type, see CastSelectParams
+func (v *ASTVisitor) visitSelectField(node *ast.SelectField) (ast.Node, bool) {
+	if param, ok := node.Expr.(*driver.ParamMarkerExpr); ok {
+		v.selectParams = append(v.selectParams, param.Offset)
+	}
+	if node.AsName.O != "" {
+		return node, false
+	}
+	switch expr := node.Expr.(type) {
+	case *ast.VariableExpr:
+		if isUserVariableRef(expr) {
+			node.AsName = ast.NewCIStr("@" + expr.Name)
+		} else if isSystemVariableRef(expr) {
+			node.AsName = ast.NewCIStr(node.Text())
+		}
+	case *ast.FuncCallExpr:
+		switch expr.FnName.L {
+		case "version", "uuid":
+			if len(expr.Args) == 0 {
+				node.AsName = ast.NewCIStr(node.Text())
+			}
+		case "date_add", "date_sub", "adddate", "subdate":
+			if text := node.Text(); text != "" {
+				node.AsName = ast.NewCIStr(text)
+			}
+		}
+	}
+	return node, false
+}
+
+// substituteUserVar replaces @name with the session value, PostgreSQL has no user variables
+// Unset variables are NULL, as in MySQL
+func (v *ASTVisitor) substituteUserVar(node *ast.VariableExpr) ast.ExprNode {
+	value := v.userVars[strings.ToLower(node.Name)]
+	return ast.NewValueExpr(value, "", "")
 }
 
 // visitMatchAgainst handles MATCH...AGAINST full-text search expressions
@@ -310,12 +705,60 @@ func (v *ASTVisitor) visitCreateTable(node *ast.CreateTableStmt) (ast.Node, bool
 	}
 
 	node.Constraints = filteredConstraints
+	v.rewriteCreateTableSelect(node)
+	v.rewriteOnUpdateColumns(node)
 
 	// Convert column types at AST level
 	// This ensures we only modify actual type definitions, not column names
-	for _, col := range node.Cols {
+	v.enums.DropTable(node.Table.Name.L)
+	v.columnTypes.DropTable(node.Table.Name.L)
+	// ON DUPLICATE KEY UPDATE conflicts on one of the keys, see rewriteOnDuplicateKeyUpdate
+	v.columnTypes.RegisterKeys(node.Table.Name.L, uniqueKeys(node))
+	columns := make([]string, len(node.Cols))
+	for i, col := range node.Cols {
+		columns[i] = col.Name.Name.L
+		// ENUM becomes VARCHAR, remember the declaration order for ORDER BY
+		if col.Tp != nil && col.Tp.GetType() == mysql.TypeEnum {
+			v.enums.Register(node.Table.Name.L, col.Name.Name.L, col.Tp.GetElems())
+			v.enums.RegisterDeclaration(node.Table.Name.L, col.Name.Name.L, enumDeclaration("enum", col.Tp.GetElems()))
+		}
+		// SET becomes VARCHAR as well, SHOW COLUMNS reports the declaration
+		if col.Tp != nil && col.Tp.GetType() == mysql.TypeSet {
+			v.enums.RegisterDeclaration(node.Table.Name.L, col.Name.Name.L, enumDeclaration("set", col.Tp.GetElems()))
+		}
+		// Literals compared with the column are adapted to its type, see rewriteColumnLiterals
+		if col.Tp != nil {
+			v.columnTypes.Register(node.Table.Name.L, col.Name.Name.L, col.Tp.GetType())
+		}
+		// String literals with NUL can only be written to BYTEA, see rewriteNulString
+		if col.Tp != nil && col.Tp.GetCharset() == charset.CharsetBin {
+			v.columnTypes.RegisterBinary(node.Table.Name.L, col.Name.Name.L)
+		}
+		// LIKE and string searches on the column follow its collation, see collationVisitor
+		collation := declaredCollation(node.Options, col)
+		if collation != "" {
+			v.columnTypes.RegisterCollation(node.Table.Name.L, col.Name.Name.L, collation)
+		}
+		// NULL inserted into the column becomes DEFAULT, see rewriteAutoIncrementValues
+		for _, opt := range col.Options {
+			if opt.Tp == ast.ColumnOptionAutoIncrement {
+				v.columnTypes.RegisterAutoIncrement(node.Table.Name.L, col.Name.Name.L, i)
+			}
+		}
 		v.convertColumnType(col)
+		rewriteColumnCollation(col, collation)
+	}
+	v.columnTypes.RegisterColumns(node.Table.Name.L, columns)
+
+	// The table's character set and collation only provide column defaults, which were
+	// taken into account above, see rewriteColumnCollation
+	options := node.Options[:0]
+	for _, opt := range node.Options {
+		if opt.Tp != ast.TableOptionCharset && opt.Tp != ast.TableOptionCollate {
+			options = append(options, opt)
+		}
 	}
+	node.Options = options
 
 	return node, false
 }
@@ -354,6 +797,16 @@ func (v *ASTVisitor) convertColumnType(col *ast.ColumnDef) {
 		// Clear enum elements
 		tp.SetElems(nil)
 
+	case mysql.TypeSet:
+		// SET -> VARCHAR long enough for all members, stored comma-separated as in MySQL
+		length := 0
+		for _, elem := range tp.GetElems() {
+			length += len(elem) + 1
+		}
+		tp.SetType(mysql.TypeVarchar)
+		tp.SetFlen(max(length-1, 1))
+		tp.SetElems(nil)
+
 	case mysql.TypeTinyBlob:
 		// TINYBLOB -> BYTEA (PostgreSQL binary type)
 		tp.SetType(mysql.TypeBlob)
@@ -0,0 +1,71 @@
+package sqlrewrite
+
+import (
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// rewriteAutoIncrementValues converts NULL to DEFAULT where an INSERT writes the
+// AUTO_INCREMENT column. MySQL generates the next value for NULL, PostgreSQL's SERIAL
+// only for DEFAULT. Explicit ids are kept, in every row of a multi-row VALUES
+// MySQL: INSERT INTO t VALUES (NULL,'a'),(DEFAULT,'b'),(5,'c')
+// PostgreSQL: INSERT INTO "t" VALUES (DEFAULT,'a'),(DEFAULT,'b'),(5,'c')
+//
+// The column position comes from the column list, or from CREATE TABLE when there is
+// none. For tables created outside the proxy the leading value is taken to be the id
+func (v *ASTVisitor) rewriteAutoIncrementValues(node *ast.InsertStmt) {
+	v.firstGenerated = 0
+	if node.Select != nil {
+		return
+	}
+	table := insertTableName(node)
+
+	position := 0
+	if column, declared, ok := v.columnTypes.AutoIncrement(table); ok {
+		position = declared
+		if len(node.Columns) > 0 {
+			position = -1 // Not listed, every row is generated
+			for i, col := range node.Columns {
+				if col.Name.L == column {
+					position = i
+				}
+			}
+		}
+	} else if v.columnTypes.HasTable(table) {
+		return // No AUTO_INCREMENT column, NULL means NULL
+	}
+	if position < 0 {
+		return
+	}
+
+	v.firstGenerated = -1
+	for i, row := range node.Lists {
+		if position >= len(row) {
+			continue
+		}
+		switch value := row[position].(type) {
+		case *driver.ValueExpr:
+			if value.Kind() != driver.KindNull {
+				continue
+			}
+			row[position] = &ast.DefaultExpr{}
+		case *ast.DefaultExpr:
+		default:
+			continue
+		}
+		if v.firstGenerated < 0 {
+			v.firstGenerated = i
+		}
+	}
+	if v.firstGenerated < 0 {
+		v.firstGenerated = 0 // Only explicit ids, MySQL reports the first
+	}
+}
+
+// insertTableName returns the name of the table an INSERT writes to
+func insertTableName(node *ast.InsertStmt) string {
+	if table := insertTable(node); table != nil {
+		return table.Name.L
+	}
+	return ""
+}
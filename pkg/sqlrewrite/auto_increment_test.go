@@ -0,0 +1,74 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoIncrementValues(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE items (name VARCHAR(20), id INT AUTO_INCREMENT PRIMARY KEY, note VARCHAR(20))")
+	require.NoError(t, err)
+	_, err = rewriter.Rewrite("CREATE TABLE tags (label VARCHAR(20), parent INT)")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		mysql          string
+		expected       string
+		firstGenerated int
+	}{
+		{
+			name:           "Mixed NULL, DEFAULT and explicit ids",
+			mysql:          "INSERT INTO items VALUES ('a', 5, NULL), ('b', NULL, 'x'), ('c', DEFAULT, NULL)",
+			expected:       `INSERT INTO "items" VALUES ('a',5,NULL),('b',DEFAULT,'x'),('c',DEFAULT,NULL)`,
+			firstGenerated: 1,
+		},
+		{
+			name:           "Position from the column list",
+			mysql:          "INSERT INTO items (id, name) VALUES (7, 'a'), (NULL, 'b')",
+			expected:       `INSERT INTO "items" ("id","name") VALUES (7,'a'),(DEFAULT,'b')`,
+			firstGenerated: 1,
+		},
+		{
+			name:     "Column not listed",
+			mysql:    "INSERT INTO items (name, note) VALUES (NULL, 'a'), ('b', NULL)",
+			expected: `INSERT INTO "items" ("name","note") VALUES (NULL,'a'),('b',NULL)`,
+		},
+		{
+			name:     "Only explicit ids",
+			mysql:    "INSERT INTO items (id, name) VALUES (3, 'a'), (4, 'b')",
+			expected: `INSERT INTO "items" ("id","name") VALUES (3,'a'),(4,'b')`,
+		},
+		{
+			name:     "Known table without AUTO_INCREMENT keeps NULL",
+			mysql:    "INSERT INTO tags VALUES (NULL, NULL)",
+			expected: `INSERT INTO "tags" VALUES (NULL,NULL)`,
+		},
+		{
+			name:           "Unknown table, leading NULL is the id",
+			mysql:          "INSERT INTO users VALUES (1, 'a'), (NULL, 'b'), (NULL, NULL)",
+			expected:       `INSERT INTO "users" VALUES (1,'a'),(DEFAULT,'b'),(DEFAULT,NULL)`,
+			firstGenerated: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := rewriter.rewrite(tt.mysql, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stmt.SQL)
+			assert.Equal(t, tt.firstGenerated, stmt.FirstGeneratedRow)
+		})
+	}
+
+	t.Run("Forgotten after DROP TABLE", func(t *testing.T) {
+		_, err := rewriter.Rewrite("DROP TABLE tags")
+		require.NoError(t, err)
+		result, err := rewriter.Rewrite("INSERT INTO tags VALUES (NULL, NULL)")
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "tags" VALUES (DEFAULT,NULL)`, result)
+	})
+}
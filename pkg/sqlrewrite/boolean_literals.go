@@ -0,0 +1,90 @@
+package sqlrewrite
+
+import (
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// isIntegerType reports whether a MySQL column type is stored as a PostgreSQL integer
+func isIntegerType(tp byte) bool {
+	switch tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		return true
+	}
+	return false
+}
+
+// booleanLiteralVisitor turns boolean tests of known integer columns into integer comparisons.
+// MySQL has no boolean type, BOOLEAN and TINYINT(1) become SMALLINT in PostgreSQL, which
+// refuses to compare them with TRUE and FALSE
+// MySQL: WHERE active = TRUE AND deleted IS FALSE
+// PostgreSQL: WHERE "active"=1 AND "deleted"=0
+// UPDATE assignments of TRUE/FALSE to integer columns become 1/0 as well
+type booleanLiteralVisitor struct {
+	*columnScope
+}
+
+// Leave implements ast.Visitor interface
+func (v *booleanLiteralVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	switch node := n.(type) {
+	case *ast.BinaryOperationExpr:
+		if !isComparisonOp(node.Op) {
+			break
+		}
+		if value, ok := booleanLiteral(node.R); ok && v.isIntegerColumn(node.L) {
+			node.R = value
+		} else if value, ok := booleanLiteral(node.L); ok && v.isIntegerColumn(node.R) {
+			node.L = value
+		}
+
+	case *ast.Assignment:
+		// UPDATE ... SET active = TRUE
+		if value, ok := booleanLiteral(node.Expr); ok && v.isIntegerColumn(&ast.ColumnNameExpr{Name: node.Column}) {
+			node.Expr = value
+		}
+
+	case *ast.IsTruthExpr:
+		if !v.isIntegerColumn(node.Expr) {
+			break
+		}
+		// col IS TRUE -> col <> 0, col IS FALSE -> col = 0
+		op := opcode.NE
+		if node.True == 0 {
+			op = opcode.EQ
+		}
+		cmp := &ast.BinaryOperationExpr{Op: op, L: node.Expr, R: ast.NewValueExpr(int64(0), "", "")}
+		if !node.Not {
+			return cmp, true
+		}
+		// IS NOT TRUE also holds for NULL, keep the truth test around the comparison
+		node.Expr = &ast.ParenthesesExpr{Expr: cmp}
+		node.True = 1
+	}
+	return n, true
+}
+
+// isIntegerColumn reports whether expr is a known integer column
+func (v *booleanLiteralVisitor) isIntegerColumn(expr ast.ExprNode) bool {
+	tp, ok := v.columnType(expr)
+	return ok && isIntegerType(tp)
+}
+
+// booleanLiteral returns the integer replacing a TRUE or FALSE literal
+func booleanLiteral(expr ast.ExprNode) (ast.ExprNode, bool) {
+	value, ok := expr.(*driver.ValueExpr)
+	if !ok || !mysql.HasIsBooleanFlag(value.Type.GetFlag()) {
+		return nil, false
+	}
+	return ast.NewValueExpr(value.GetInt64(), "", ""), true
+}
+
+// isComparisonOp reports whether op compares its operands
+func isComparisonOp(op opcode.Op) bool {
+	switch op {
+	case opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE, opcode.NullEQ:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,346 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+	"github.com/pingcap/tidb/pkg/parser/types"
+)
+
+// caseInsensitive reports whether MySQL compares the given string operands ignoring
+// case. As in MySQL an explicit COLLATE or BINARY on an operand decides first, then the
+// declared collation of a column operand, then the session's @@collation_connection,
+// which is a _ci collation unless SET otherwise
+func (v *ASTVisitor) caseInsensitive(operands ...ast.ExprNode) bool {
+	for _, operand := range operands {
+		switch expr := unwrapParentheses(operand).(type) {
+		case *ast.SetCollationExpr:
+			return isCaseInsensitiveCollation(expr.Collate)
+		case *ast.FuncCastExpr:
+			if isBinaryCast(expr) {
+				return false
+			}
+		}
+	}
+	for _, operand := range operands {
+		if col, ok := unwrapParentheses(operand).(*ast.ColumnNameExpr); ok {
+			if collation, ok := v.columnCollations[col]; ok && collation != connectionCollation {
+				return isCaseInsensitiveCollation(collation)
+			}
+		}
+	}
+
+	collation, ok := v.userVars[systemVarPrefix+"collation_connection"].(string)
+	if !ok {
+		collation = systemVarDefaults["collation_connection"].(string)
+	}
+	return isCaseInsensitiveCollation(collation)
+}
+
+// connectionCollation is recorded for the string columns declared without a collation,
+// they compare with @@collation_connection
+const connectionCollation = "@@collation_connection"
+
+// collationVisitor records the declared collations of the column references in a
+// statement, for caseInsensitive when the visitor reaches their comparisons, and ""
+// for the columns known not to hold strings
+type collationVisitor struct {
+	*columnScope
+	visitor *ASTVisitor
+}
+
+// Leave implements ast.Visitor interface
+func (c *collationVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	col, ok := n.(*ast.ColumnNameExpr)
+	if !ok {
+		return n, true
+	}
+	table, column, ok := c.columnTable(col)
+	if !ok {
+		return n, true
+	}
+	collation, ok := c.columns.Collation(table, column)
+	if !ok {
+		tp, known := c.columns.Type(table, column)
+		if !known {
+			return n, true
+		}
+		// Numbers and dates compare without a collation, recorded as ""
+		collation = ""
+		if isStringType(tp) {
+			collation = connectionCollation
+		}
+	}
+	if c.visitor.columnCollations == nil {
+		c.visitor.columnCollations = make(map[*ast.ColumnNameExpr]string)
+	}
+	c.visitor.columnCollations[col] = collation
+	return n, true
+}
+
+// declaredCollation returns the collation a column definition gives a column, if any:
+// its COLLATE option, a binary type or the BINARY attribute, or the table's default
+//
+//	name VARCHAR(20) COLLATE utf8mb4_bin   -> utf8mb4_bin
+//	name VARCHAR(20) BINARY, VARBINARY(20) -> binary
+func declaredCollation(tableOptions []*ast.TableOption, col *ast.ColumnDef) string {
+	if col.Tp == nil || !(types.IsTypeChar(col.Tp.GetType()) || types.IsTypeBlob(col.Tp.GetType())) {
+		return ""
+	}
+	for _, opt := range col.Options {
+		if opt.Tp == ast.ColumnOptionCollate {
+			return opt.StrValue
+		}
+	}
+	if col.Tp.GetCollate() != "" {
+		return col.Tp.GetCollate()
+	}
+	if mysql.HasBinaryFlag(col.Tp.GetFlag()) {
+		return "binary"
+	}
+	for _, opt := range tableOptions {
+		if opt.Tp == ast.TableOptionCollate {
+			return opt.StrValue
+		}
+	}
+	return ""
+}
+
+// rewriteColumnCollation replaces the character set and collation of a string column,
+// declared with the given collation, by the PostgreSQL collation ordering its values
+// like the MySQL one. PostgreSQL keeps every string in the database encoding, so the
+// character set is dropped, as are the _ci collations, whose case-insensitive
+// comparisons are rewritten where the column is used, see caseInsensitive
+// MySQL: name VARCHAR(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin, code CHAR(3) BINARY
+// PostgreSQL: "name" VARCHAR(20) COLLATE "C","code" CHAR(3) COLLATE "C"
+func rewriteColumnCollation(col *ast.ColumnDef, collation string) {
+	// VARBINARY and BLOB become bytes, which take no collation
+	if col.Tp == nil || !(types.IsTypeChar(col.Tp.GetType()) || types.IsTypeBlob(col.Tp.GetType())) ||
+		col.Tp.GetCharset() == charset.CharsetBin {
+		return
+	}
+
+	options := make([]*ast.ColumnOption, 0, len(col.Options)+1)
+	if pgCollation := postgresCollation(collation); pgCollation != "" {
+		options = append(options, &ast.ColumnOption{Tp: ast.ColumnOptionCollate, StrValue: quoteIdent(pgCollation)})
+	}
+	for _, opt := range col.Options {
+		if opt.Tp != ast.ColumnOptionCollate {
+			options = append(options, opt)
+		}
+	}
+	col.Options = options
+	col.Tp.DelFlag(mysql.BinaryFlag)
+	col.Tp.SetCharset("")
+	col.Tp.SetCollate("")
+}
+
+// postgresCollation returns the PostgreSQL collation sorting like a MySQL collation,
+// "C" for the binary ones, which compare bytes. Other collations have no equivalent
+// every PostgreSQL server provides and map to "", the database default
+func postgresCollation(name string) string {
+	name = strings.ToLower(name)
+	if name == charset.CollationBin || strings.HasSuffix(name, "_bin") {
+		return "C"
+	}
+	return ""
+}
+
+// isCaseInsensitiveCollation reports whether a MySQL collation ignores case, as the
+// _ci ones do. _cs, _bin and binary compare case-sensitively
+func isCaseInsensitiveCollation(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), "_ci")
+}
+
+// isStringType reports whether tp is a character, text, blob, ENUM or SET type
+func isStringType(tp byte) bool {
+	return types.IsTypeChar(tp) || types.IsTypeBlob(tp) || tp == mysql.TypeEnum || tp == mysql.TypeSet
+}
+
+// isBinaryCast reports whether node is the BINARY operator or a CAST / CONVERT to BINARY.
+// Casts to numbers and dates have the binary charset as well
+func isBinaryCast(node *ast.FuncCastExpr) bool {
+	if node.FunctionType == ast.CastBinaryOperator {
+		return true
+	}
+	tp := node.Tp.GetType()
+	return (tp == mysql.TypeVarString || tp == mysql.TypeString) && node.Tp.GetCharset() == charset.CharsetBin
+}
+
+// rewriteBinaryCast makes the operand of BINARY compare byte by byte. PostgreSQL has
+// no binary strings comparable with text, the "C" collation compares and sorts the
+// bytes of a string as MySQL does for its binary strings. Numbers take no collation
+// MySQL: WHERE BINARY name = 'Alice' ORDER BY CAST(name AS BINARY)
+// PostgreSQL: WHERE "name" COLLATE "C"='Alice' ORDER BY "name" COLLATE "C"
+func rewriteBinaryCast(node *ast.FuncCastExpr) ast.ExprNode {
+	if value, ok := unwrapParentheses(node.Expr).(*driver.ValueExpr); ok && value.Kind() != driver.KindString {
+		return node.Expr
+	}
+	return &pgCollateExpr{ExprNode: node.Expr, Collation: "C"}
+}
+
+// transformStringSearch converts the substring search functions, lowering both operands
+// under a case-insensitive collation since PostgreSQL always searches case-sensitively
+// MySQL: LOCATE('world', s), POSITION('world' IN s), INSTR(s, 'world')
+// PostgreSQL: POSITION(LOWER('world') IN LOWER("s")), STRPOS(LOWER("s"), LOWER('world'))
+func (v *ASTVisitor) transformStringSearch(node *ast.FuncCallExpr) (ast.Node, bool) {
+	if node.FnName.L == "locate" && len(node.Args) == 3 {
+		return v.transformLocateFrom(node), true
+	}
+	if node.FnName.L == "instr" {
+		node.FnName = ast.NewCIStr("STRPOS")
+	} else {
+		node.FnName = ast.NewCIStr("POSITION")
+	}
+	if len(node.Args) >= 2 && v.caseInsensitive(node.Args...) {
+		node.Args[0] = lowerExpr(node.Args[0])
+		node.Args[1] = lowerExpr(node.Args[1])
+	}
+	return node, false
+}
+
+// transformLocateFrom converts LOCATE with a start position, PostgreSQL's POSITION and
+// STRPOS search the whole string. The search runs in the string from pos on and the
+// match is numbered from the start of the string. The arguments are visited here, the
+// CASE replacing the call is not. pos is used three times, a ? placeholder there
+// cannot be repeated and is not supported
+// MySQL: LOCATE('b', s, 3)
+// PostgreSQL: CASE WHEN 3<1 THEN 0 ELSE COALESCE(NULLIF(STRPOS(LOWER(SUBSTRING("s", 3)), LOWER('b')), 0)+3-1, 0) END
+func (v *ASTVisitor) transformLocateFrom(node *ast.FuncCallExpr) ast.Node {
+	sub, str, pos := node.Args[0], node.Args[1], node.Args[2]
+	if containsParamMarker(pos) {
+		v.err = &RewriteError{
+			Reason:  ReasonUnsupported,
+			Feature: "LOCATE()",
+			Err:     fmt.Errorf("LOCATE() with a ? placeholder as the start position is not supported"),
+		}
+		return node
+	}
+	for i, arg := range node.Args {
+		if visited, ok := arg.Accept(v); ok {
+			node.Args[i] = visited.(ast.ExprNode)
+		}
+	}
+	sub, str, pos = node.Args[0], node.Args[1], node.Args[2]
+	switch pos.(type) {
+	case *ast.ColumnNameExpr, *driver.ValueExpr, *ast.FuncCallExpr, *ast.ParenthesesExpr:
+	default:
+		pos = &ast.ParenthesesExpr{Expr: pos}
+	}
+
+	call := func(name string, args ...ast.ExprNode) ast.ExprNode {
+		return &ast.FuncCallExpr{FnName: ast.NewCIStr(name), Args: args}
+	}
+	rest := call("SUBSTRING", str, pos)
+	if v.caseInsensitive(sub, str) {
+		sub = lowerExpr(sub)
+		rest = lowerExpr(rest)
+	}
+	zero := func() ast.ExprNode { return ast.NewValueExpr(int64(0), "", "") }
+	match := call("NULLIF", call("STRPOS", rest, sub), zero())
+	found := &ast.BinaryOperationExpr{
+		Op: opcode.Minus,
+		L:  &ast.BinaryOperationExpr{Op: opcode.Plus, L: match, R: pos},
+		R:  ast.NewValueExpr(int64(1), "", ""),
+	}
+	v.traceStep("function", "LOCATE() -> STRPOS()")
+	return &ast.CaseExpr{
+		WhenClauses: []*ast.WhenClause{{
+			Expr:   &ast.BinaryOperationExpr{Op: opcode.LT, L: pos, R: ast.NewValueExpr(int64(1), "", "")},
+			Result: zero(),
+		}},
+		ElseClause: call("COALESCE", found, zero()),
+	}
+}
+
+// visitLike turns LIKE into ILIKE under a case-insensitive collation
+// MySQL: name LIKE 'john%'
+// PostgreSQL: "name" ILIKE 'john%'
+func (v *ASTVisitor) visitLike(node *ast.PatternLikeOrIlikeExpr) {
+	if node.IsLike && v.caseInsensitive(node.Expr, node.Pattern) {
+		node.IsLike = false
+	}
+}
+
+// visitComparison lowers both operands of a comparison under a case-insensitive
+// collation, PostgreSQL compares text case-sensitively. See foldsCase for the
+// comparisons that are lowered
+// MySQL: WHERE name = 'Alice'
+// PostgreSQL: WHERE LOWER("name")=LOWER('Alice')
+func (v *ASTVisitor) visitComparison(node *ast.BinaryOperationExpr) {
+	if !isComparisonOp(node.Op) || !v.foldsCase([]ast.ExprNode{node.L, node.R}) {
+		return
+	}
+	node.L = lowerExpr(node.L)
+	node.R = lowerExpr(node.R)
+}
+
+// visitIn lowers the operand and the values of IN (...) like visitComparison
+// MySQL: WHERE status IN ('Active', ?)
+// PostgreSQL: WHERE LOWER("status") IN (LOWER('Active'),LOWER($1))
+func (v *ASTVisitor) visitIn(node *ast.PatternInExpr) {
+	if node.Sel != nil || !v.foldsCase(append([]ast.ExprNode{node.Expr}, node.List...)) {
+		return
+	}
+	node.Expr = lowerExpr(node.Expr)
+	for i, value := range node.List {
+		node.List[i] = lowerExpr(value)
+	}
+}
+
+// visitCase lowers the operand and the WHEN values of a simple CASE like
+// visitComparison, MySQL compares them as with =
+// MySQL: CASE status WHEN 'active' THEN 1 WHEN ? THEN 2 END
+// PostgreSQL: CASE LOWER("status") WHEN LOWER('active') THEN 1 WHEN LOWER($1) THEN 2 END
+func (v *ASTVisitor) visitCase(node *ast.CaseExpr) {
+	if node.Value == nil {
+		return
+	}
+	operands := []ast.ExprNode{node.Value}
+	for _, when := range node.WhenClauses {
+		operands = append(operands, when.Expr)
+	}
+	if !v.foldsCase(operands) {
+		return
+	}
+	node.Value = lowerExpr(node.Value)
+	for _, when := range node.WhenClauses {
+		when.Expr = lowerExpr(when.Expr)
+	}
+}
+
+// foldsCase reports whether operands compared with each other are strings compared
+// ignoring case. They are strings when one is a column known to hold strings or has a
+// COLLATE, and none is a number or a column of another type. A literal alone does not
+// tell, '2024-01-01' may be compared with a date
+func (v *ASTVisitor) foldsCase(operands []ast.ExprNode) bool {
+	strs := false
+	for _, operand := range operands {
+		switch expr := unwrapParentheses(operand).(type) {
+		case *driver.ValueExpr:
+			if kind := expr.Kind(); kind != driver.KindString && kind != driver.KindNull {
+				return false
+			}
+		case *ast.SetCollationExpr:
+			strs = true
+		case *ast.ColumnNameExpr:
+			if collation, ok := v.columnCollations[expr]; ok {
+				if collation == "" {
+					return false
+				}
+				strs = true
+			}
+		}
+	}
+	return strs && v.caseInsensitive(operands...)
+}
+
+// lowerExpr wraps expr in LOWER()
+func lowerExpr(expr ast.ExprNode) ast.ExprNode {
+	return &ast.FuncCallExpr{FnName: ast.NewCIStr("LOWER"), Args: []ast.ExprNode{expr}}
+}
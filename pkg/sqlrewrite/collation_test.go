@@ -0,0 +1,292 @@
+package sqlrewrite
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteCaseInsensitiveSearch(t *testing.T) {
+	rewriter := NewASTRewriter()
+	binary := map[string]interface{}{"@@collation_connection": "utf8mb4_bin"}
+
+	tests := []struct {
+		name     string
+		mysql    string
+		vars     map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "LOCATE and POSITION lower both operands",
+			mysql:    "SELECT LOCATE('World', title), POSITION(? IN title) FROM posts",
+			expected: `SELECT POSITION(LOWER('World') IN LOWER("title")),POSITION(LOWER($1) IN LOWER("title")) FROM "posts"`,
+		},
+		{
+			name:  "LOCATE with a start position searches from it",
+			mysql: "SELECT LOCATE('World', title, 3), LOCATE('o', title, id + 1) FROM posts",
+			expected: `SELECT CASE WHEN 3<1 THEN 0 ELSE COALESCE(NULLIF(STRPOS(LOWER(SUBSTRING("title", 3)), LOWER('World')), 0)+3-1, 0) END,` +
+				`CASE WHEN ("id"+1)<1 THEN 0 ELSE COALESCE(NULLIF(STRPOS(LOWER(SUBSTRING("title", ("id"+1))), LOWER('o')), 0)+("id"+1)-1, 0) END FROM "posts"`,
+		},
+		{
+			name:     "LOCATE with a start position under a case-sensitive collation",
+			mysql:    "SELECT LOCATE(?, title, 2) FROM posts",
+			vars:     binary,
+			expected: `SELECT CASE WHEN 2<1 THEN 0 ELSE COALESCE(NULLIF(STRPOS(SUBSTRING("title", 2), $1), 0)+2-1, 0) END FROM "posts"`,
+		},
+		{
+			name:     "INSTR keeps its argument order",
+			mysql:    "SELECT id FROM posts WHERE INSTR(title, 'MySQL') > 0",
+			expected: `SELECT "id" FROM "posts" WHERE STRPOS(LOWER("title"), LOWER('MySQL'))>0`,
+		},
+		{
+			name:     "LIKE becomes ILIKE",
+			mysql:    "SELECT id FROM users WHERE name LIKE 'John%' AND email NOT LIKE ?",
+			expected: `SELECT "id" FROM "users" WHERE "name" ILIKE 'John%' AND "email" NOT ILIKE $1`,
+		},
+		{
+			name:     "Case-sensitive session collation",
+			mysql:    "SELECT LOCATE('World', title) FROM posts WHERE title LIKE 'Hello%'",
+			vars:     binary,
+			expected: `SELECT POSITION('World' IN "title") FROM "posts" WHERE "title" LIKE 'Hello%'`,
+		},
+		{
+			name:     "Explicit case-sensitive collation",
+			mysql:    "SELECT id FROM users WHERE name COLLATE utf8mb4_bin LIKE 'John%' AND INSTR(name, 'Jo' COLLATE utf8mb4_0900_as_cs) > 0",
+			expected: `SELECT "id" FROM "users" WHERE "name" LIKE 'John%' AND STRPOS("name", 'Jo')>0`,
+		},
+		{
+			name:     "Explicit case-insensitive collation",
+			mysql:    "SELECT id FROM users WHERE name LIKE 'John%' COLLATE utf8mb4_general_ci",
+			vars:     binary,
+			expected: `SELECT "id" FROM "users" WHERE "name" ILIKE 'John%'`,
+		},
+		{
+			name:     "BINARY",
+			mysql:    "SELECT id FROM users WHERE name LIKE BINARY 'John%'",
+			expected: `SELECT "id" FROM "users" WHERE "name" LIKE 'John%' COLLATE "C"`,
+		},
+		{
+			name:     "CAST AS BINARY",
+			mysql:    "SELECT id FROM users WHERE CAST(name AS BINARY) LIKE 'John%' AND INSTR(CONVERT(name, BINARY), ?) > 0",
+			expected: `SELECT "id" FROM "users" WHERE "name" COLLATE "C" LIKE 'John%' AND STRPOS("name" COLLATE "C", $1)>0`,
+		},
+		{
+			name:     "Simple CASE on numbers",
+			mysql:    "SELECT CASE level WHEN 1 THEN 'low' WHEN ? THEN 'high' END FROM users",
+			expected: `SELECT CASE "level" WHEN 1 THEN 'low' WHEN $1 THEN 'high' END FROM "users"`,
+		},
+		{
+			name:     "Comparisons with an explicit collation",
+			mysql:    "SELECT id FROM users WHERE status COLLATE utf8mb4_general_ci = 'Active' AND CASE status COLLATE utf8mb4_bin WHEN 'Active' THEN 1 END",
+			vars:     binary,
+			expected: `SELECT "id" FROM "users" WHERE LOWER("status")=LOWER('Active') AND CASE "status" WHEN 'Active' THEN 1 END`,
+		},
+		{
+			name:     "A literal alone may be a date",
+			mysql:    "SELECT CASE created_at WHEN '2024-01-01' THEN 1 END FROM users WHERE created_at = '2024-01-01'",
+			expected: `SELECT CASE "created_at" WHEN '2024-01-01' THEN 1 END FROM "users" WHERE "created_at"='2024-01-01'`,
+		},
+		{
+			name:     "COLLATE elsewhere is dropped",
+			mysql:    "SELECT name FROM users ORDER BY name COLLATE utf8mb4_unicode_ci",
+			expected: `SELECT "name" FROM "users" ORDER BY "name"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.RewriteWithUserVars(tt.mysql, tt.vars)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRewriteColumnCollation(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite(`CREATE TABLE accounts (
+		id INT PRIMARY KEY,
+		name VARCHAR(50),
+		code VARCHAR(20) COLLATE utf8mb4_bin,
+		token VARBINARY(32),
+		tag VARCHAR(10) BINARY,
+		label VARCHAR(10) COLLATE utf8mb4_general_ci
+	)`)
+	require.NoError(t, err)
+	_, err = rewriter.Rewrite("CREATE TABLE codes (code VARCHAR(20)) COLLATE=utf8mb4_0900_as_cs")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		vars     map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "Columns without a collation follow the session",
+			mysql:    "SELECT id FROM accounts WHERE name LIKE 'al%'",
+			expected: `SELECT "id" FROM "accounts" WHERE "name" ILIKE 'al%'`,
+		},
+		{
+			name:     "Case-sensitive column collations keep LIKE",
+			mysql:    "SELECT a.id FROM accounts a WHERE a.code LIKE 'AB%' AND token LIKE 'x%' AND tag NOT LIKE 'y%'",
+			expected: `SELECT "a"."id" FROM "accounts" AS "a" WHERE "a"."code" LIKE 'AB%' AND "token" LIKE 'x%' AND "tag" NOT LIKE 'y%'`,
+		},
+		{
+			name:     "Table default collation",
+			mysql:    "SELECT code FROM codes WHERE LOCATE('ab', code) > 0",
+			expected: `SELECT "code" FROM "codes" WHERE POSITION('ab' IN "code")>0`,
+		},
+		{
+			name:     "Column collation wins over the session",
+			mysql:    "SELECT id FROM accounts WHERE code LIKE 'AB%'",
+			vars:     map[string]interface{}{"@@collation_connection": "utf8mb4_general_ci"},
+			expected: `SELECT "id" FROM "accounts" WHERE "code" LIKE 'AB%'`,
+		},
+		{
+			name:     "Explicit COLLATE wins over the column",
+			mysql:    "SELECT id FROM accounts WHERE code LIKE 'ab%' COLLATE utf8mb4_general_ci",
+			expected: `SELECT "id" FROM "accounts" WHERE "code" ILIKE 'ab%'`,
+		},
+		{
+			name:     "Simple CASE lowers the operand and the WHEN values",
+			mysql:    "SELECT CASE name WHEN 'Alice' THEN 1 WHEN ? THEN 2 ELSE 0 END FROM accounts",
+			expected: `SELECT CASE LOWER("name") WHEN LOWER('Alice') THEN 1 WHEN LOWER($1) THEN 2 ELSE 0 END FROM "accounts"`,
+		},
+		{
+			name:     "Comparisons lower both operands",
+			mysql:    "SELECT id FROM accounts a WHERE a.name = 'Alice' AND ? <> label AND name IN ('bob', ?) AND IF(name = 'x', 1, 0)",
+			expected: `SELECT "id" FROM "accounts" AS "a" WHERE LOWER("a"."name")=LOWER('Alice') AND LOWER($1)!=LOWER("label") AND LOWER("name") IN (LOWER('bob'),LOWER($2)) AND CASE WHEN LOWER("name")=LOWER('x') THEN 1 ELSE 0 END`,
+		},
+		{
+			name:     "Case-sensitive and non-string columns are compared as they are",
+			mysql:    "SELECT id FROM accounts WHERE code = 'AB' AND tag IN ('x') AND id = '1' AND name = id AND CASE id WHEN '1' THEN 1 END",
+			expected: `SELECT "id" FROM "accounts" WHERE "code"='AB' AND "tag" IN ('x') AND "id"=1 AND "name"="id" AND CASE "id" WHEN '1' THEN 1 END`,
+		},
+		{
+			name:     "Column collation wins over a case-sensitive session",
+			mysql:    "SELECT CASE label WHEN ? THEN 1 END, CASE code WHEN ? THEN 1 END FROM accounts WHERE name = ?",
+			vars:     map[string]interface{}{"@@collation_connection": "utf8mb4_bin"},
+			expected: `SELECT CASE LOWER("label") WHEN LOWER($1) THEN 1 END,CASE "code" WHEN $2 THEN 1 END FROM "accounts" WHERE "name"=$3`,
+		},
+		{
+			name:     "ESCAPE is kept",
+			mysql:    "SELECT id FROM accounts WHERE name LIKE '50|%%' ESCAPE '|' AND code LIKE '10!_%' ESCAPE '!'",
+			expected: `SELECT "id" FROM "accounts" WHERE "name" ILIKE '50|%%' ESCAPE '|' AND "code" LIKE '10!_%' ESCAPE '!'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.RewriteWithUserVars(tt.mysql, tt.vars)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestLocateStartPositionPlaceholder(t *testing.T) {
+	// The start position is repeated in the rewrite, a placeholder would take two values
+	_, err := NewASTRewriter().Rewrite("SELECT LOCATE('a', title, ?) FROM posts")
+	var rerr *RewriteError
+	require.True(t, errors.As(err, &rerr))
+	assert.Equal(t, ReasonUnsupported, rerr.Reason)
+	assert.Equal(t, "LOCATE()", rerr.Feature)
+}
+
+func TestRewriteColumnCollationDDL(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name: "Character sets are dropped, binary collations sort bytewise",
+			mysql: "CREATE TABLE people (name VARCHAR(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci, " +
+				"code VARCHAR(10) NOT NULL COLLATE utf8mb4_bin DEFAULT 'x', bio TEXT CHARSET latin1, tag CHAR(3) BINARY)",
+			expected: `CREATE TABLE "people" ("name" VARCHAR(100),"code" VARCHAR(10) COLLATE "C" NOT NULL DEFAULT 'x',"bio" TEXT,"tag" CHAR(3) COLLATE "C")`,
+		},
+		{
+			name:     "Table defaults apply to the columns",
+			mysql:    "CREATE TABLE codes (code VARCHAR(20), n INT) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+			expected: `CREATE TABLE "codes" ("code" VARCHAR(20) COLLATE "C","n" INT)`,
+		},
+		{
+			name:     "Case-insensitive table default",
+			mysql:    "CREATE TABLE notes (body VARCHAR(20)) DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci",
+			expected: `CREATE TABLE "notes" ("body" VARCHAR(20))`,
+		},
+		{
+			name:     "Binary strings take no collation",
+			mysql:    "CREATE TABLE blobs (token VARBINARY(32), data BLOB)",
+			expected: `CREATE TABLE "blobs" ("token" VARBINARY(32),"data" BYTEA)`,
+		},
+		{
+			name:     "ALTER TABLE column definitions",
+			mysql:    "ALTER TABLE people ADD COLUMN nick VARCHAR(10) CHARACTER SET ascii COLLATE ascii_bin NOT NULL",
+			expected: `ALTER TABLE "people" ADD COLUMN "nick" VARCHAR(10) COLLATE "C" NOT NULL`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, strings.TrimSpace(result))
+		})
+	}
+}
+
+func TestRewriteBinaryComparison(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "BINARY operator",
+			mysql:    "SELECT id FROM users WHERE BINARY name = 'Alice'",
+			expected: `SELECT "id" FROM "users" WHERE "name" COLLATE "C"='Alice'`,
+		},
+		{
+			name:     "BINARY on the literal",
+			mysql:    "SELECT id FROM users WHERE name = BINARY 'Alice'",
+			expected: `SELECT "id" FROM "users" WHERE "name"='Alice' COLLATE "C"`,
+		},
+		{
+			name:     "CAST and CONVERT",
+			mysql:    "SELECT id FROM users WHERE CAST(name AS BINARY) = ? OR CONVERT(name, BINARY) > 'a'",
+			expected: `SELECT "id" FROM "users" WHERE "name" COLLATE "C"=$1 OR "name" COLLATE "C">'a'`,
+		},
+		{
+			name:     "Byte order",
+			mysql:    "SELECT name FROM users ORDER BY BINARY name",
+			expected: `SELECT "name" FROM "users" ORDER BY "name" COLLATE "C"`,
+		},
+		{
+			name:     "Compound operand",
+			mysql:    "SELECT id FROM users WHERE BINARY CONCAT(first, last) = 'AB' AND CAST(name AS BINARY) IN ('A', 'b')",
+			expected: `SELECT "id" FROM "users" WHERE CONCAT("first", "last") COLLATE "C"='AB' AND "name" COLLATE "C" IN ('A','b')`,
+		},
+		{
+			name:     "Numbers take no collation",
+			mysql:    "SELECT id FROM users WHERE age = BINARY 30",
+			expected: `SELECT "id" FROM "users" WHERE "age"=30`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
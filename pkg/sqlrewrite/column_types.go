@@ -0,0 +1,415 @@
+package sqlrewrite
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+)
+
+// ColumnTypeRegistry remembers the MySQL column types seen in CREATE TABLE, for rewrites
+// that depend on the type of a column compared with a literal. Tables created outside
+// the proxy are read from information_schema before they are queried, see LoadTable
+type ColumnTypeRegistry struct {
+	mu            sync.RWMutex
+	tables        map[string]map[string]byte     // table -> column -> MySQL type
+	collations    map[string]map[string]string   // table -> column -> declared collation
+	keys          map[string][][]string          // table -> PRIMARY KEY and UNIQUE columns
+	autoIncrement map[string]autoIncrementColumn // table -> AUTO_INCREMENT column
+	columns       map[string][]string            // table -> columns in declaration order
+	binary        map[string]map[string]bool     // table -> columns stored as BYTEA
+	loaded        map[string]time.Time           // table -> when it was read from the database
+	onUpdate      map[string]bool                // schema-qualified tables with an ON UPDATE trigger
+}
+
+// autoIncrementColumn is the AUTO_INCREMENT column of a table and its declaration position
+type autoIncrementColumn struct {
+	name     string
+	position int
+}
+
+// NewColumnTypeRegistry creates an empty registry
+func NewColumnTypeRegistry() *ColumnTypeRegistry {
+	return &ColumnTypeRegistry{
+		tables:        make(map[string]map[string]byte),
+		collations:    make(map[string]map[string]string),
+		keys:          make(map[string][][]string),
+		autoIncrement: make(map[string]autoIncrementColumn),
+		columns:       make(map[string][]string),
+		binary:        make(map[string]map[string]bool),
+		loaded:        make(map[string]time.Time),
+		onUpdate:      make(map[string]bool),
+	}
+}
+
+// Register records the type of a column
+func (r *ColumnTypeRegistry) Register(table, column string, tp byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	table = strings.ToLower(table)
+	if r.tables[table] == nil {
+		r.tables[table] = make(map[string]byte)
+	}
+	r.tables[table][strings.ToLower(column)] = tp
+}
+
+// Type returns the MySQL type of a known column
+func (r *ColumnTypeRegistry) Type(table, column string) (byte, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tp, ok := r.tables[strings.ToLower(table)][strings.ToLower(column)]
+	return tp, ok
+}
+
+// RegisterCollation records the collation a column was declared with
+func (r *ColumnTypeRegistry) RegisterCollation(table, column, collation string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	table = strings.ToLower(table)
+	if r.collations[table] == nil {
+		r.collations[table] = make(map[string]string)
+	}
+	r.collations[table][strings.ToLower(column)] = collation
+}
+
+// Collation returns the declared collation of a known column
+func (r *ColumnTypeRegistry) Collation(table, column string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	collation, ok := r.collations[strings.ToLower(table)][strings.ToLower(column)]
+	return collation, ok
+}
+
+// RegisterKeys records the unique keys of a table, the primary key first
+func (r *ColumnTypeRegistry) RegisterKeys(table string, keys [][]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[strings.ToLower(table)] = keys
+}
+
+// Keys returns the unique keys of a table, the primary key first
+func (r *ColumnTypeRegistry) Keys(table string) [][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[strings.ToLower(table)]
+}
+
+// RegisterAutoIncrement records the AUTO_INCREMENT column of a table, position is its
+// index in the CREATE TABLE column list
+func (r *ColumnTypeRegistry) RegisterAutoIncrement(table, column string, position int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.autoIncrement[strings.ToLower(table)] = autoIncrementColumn{name: strings.ToLower(column), position: position}
+}
+
+// AutoIncrement returns the AUTO_INCREMENT column of a table and its declaration position
+func (r *ColumnTypeRegistry) AutoIncrement(table string) (string, int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	col, ok := r.autoIncrement[strings.ToLower(table)]
+	return col.name, col.position, ok
+}
+
+// RegisterColumns records the columns of a table in declaration order
+func (r *ColumnTypeRegistry) RegisterColumns(table string, columns []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.columns[strings.ToLower(table)] = columns
+}
+
+// Columns returns the columns of a table in declaration order, nil when unknown
+func (r *ColumnTypeRegistry) Columns(table string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.columns[strings.ToLower(table)]
+}
+
+// RegisterBinary records a column of a binary type (BLOB, BINARY, VARBINARY), which
+// PostgreSQL stores as BYTEA
+func (r *ColumnTypeRegistry) RegisterBinary(table, column string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	table = strings.ToLower(table)
+	if r.binary[table] == nil {
+		r.binary[table] = make(map[string]bool)
+	}
+	r.binary[table][strings.ToLower(column)] = true
+}
+
+// IsBinary reports whether a known column has a binary type
+func (r *ColumnTypeRegistry) IsBinary(table, column string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.binary[strings.ToLower(table)][strings.ToLower(column)]
+}
+
+// RegisterOnUpdateTrigger records a table the proxy created an ON UPDATE trigger for,
+// schema is "" for an unqualified table
+func (r *ColumnTypeRegistry) RegisterOnUpdateTrigger(schema, table string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onUpdate[onUpdateKey(schema, table)] = true
+}
+
+// DropOnUpdateTrigger forgets the ON UPDATE trigger of a table and reports whether the
+// proxy created one
+func (r *ColumnTypeRegistry) DropOnUpdateTrigger(schema, table string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := onUpdateKey(schema, table)
+	ok := r.onUpdate[key]
+	delete(r.onUpdate, key)
+	return ok
+}
+
+// onUpdateKey is the registry key of a possibly schema-qualified table
+func onUpdateKey(schema, table string) string {
+	return strings.ToLower(schema) + "." + strings.ToLower(table)
+}
+
+// HasTable reports whether the columns of a table are known
+func (r *ColumnTypeRegistry) HasTable(table string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.tables[strings.ToLower(table)]
+	return ok
+}
+
+// DropTable forgets the columns of a table
+func (r *ColumnTypeRegistry) DropTable(table string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropTable(strings.ToLower(table))
+}
+
+// dropTable forgets the columns of a lower-case table name, r.mu is held
+func (r *ColumnTypeRegistry) dropTable(table string) {
+	delete(r.tables, table)
+	delete(r.collations, table)
+	delete(r.keys, table)
+	delete(r.autoIncrement, table)
+	delete(r.columns, table)
+	delete(r.binary, table)
+	delete(r.loaded, table)
+}
+
+// TableColumn is a column of a table created outside the proxy, as information_schema
+// describes it
+type TableColumn struct {
+	Name          string
+	DataType      string // data_type, e.g. "character varying" or "timestamp without time zone"
+	Collation     string // collation_name, "" for the database default
+	AutoIncrement bool   // SERIAL or IDENTITY
+}
+
+// postgresColumnTypes are the MySQL types the PostgreSQL column types are read as, keyed
+// by information_schema data_type. Columns of other types, such as BOOLEAN or UUID, are
+// recorded as mysql.TypeUnspecified: known, but no rewrite applies to them
+var postgresColumnTypes = map[string]byte{
+	"smallint":                    mysql.TypeShort,
+	"integer":                     mysql.TypeLong,
+	"bigint":                      mysql.TypeLonglong,
+	"numeric":                     mysql.TypeNewDecimal,
+	"real":                        mysql.TypeFloat,
+	"double precision":            mysql.TypeDouble,
+	"date":                        mysql.TypeDate,
+	"timestamp without time zone": mysql.TypeDatetime,
+	"timestamp with time zone":    mysql.TypeTimestamp,
+	"time without time zone":      mysql.TypeDuration,
+	"character varying":           mysql.TypeVarchar,
+	"character":                   mysql.TypeString,
+	"text":                        mysql.TypeBlob,
+	"bytea":                       mysql.TypeBlob,
+	"json":                        mysql.TypeJSON,
+	"jsonb":                       mysql.TypeJSON,
+}
+
+// LoadTable records the columns and unique keys, the primary key first, of a table read
+// from the database at readAt. A table already read at readAt is kept, as are the tables
+// created through the proxy, whose CREATE TABLE tells more: ENUM declarations and MySQL
+// collations are not kept by PostgreSQL. A table with no columns no longer exists
+//
+// Columns with the "C" or "POSIX" collation compare like utf8mb4_bin, BYTEA columns
+// like binary ones. The others compare with @@collation_connection
+func (r *ColumnTypeRegistry) LoadTable(table string, columns []TableColumn, keys [][]string, readAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	table = strings.ToLower(table)
+	if _, ok := r.tables[table]; ok {
+		if at, loaded := r.loaded[table]; !loaded || at.Equal(readAt) {
+			return
+		}
+	}
+	r.dropTable(table)
+	if len(columns) == 0 {
+		return
+	}
+
+	types := make(map[string]byte, len(columns))
+	collations := make(map[string]string)
+	binary := make(map[string]bool)
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		name := strings.ToLower(col.Name)
+		names[i] = name
+		types[name] = postgresColumnTypes[col.DataType]
+		switch {
+		case col.DataType == "bytea":
+			binary[name] = true
+			collations[name] = "binary"
+		case col.Collation == "C" || col.Collation == "POSIX":
+			collations[name] = "utf8mb4_bin"
+		}
+		if col.AutoIncrement {
+			if _, ok := r.autoIncrement[table]; !ok {
+				r.autoIncrement[table] = autoIncrementColumn{name: name, position: i}
+			}
+		}
+	}
+	lowered := make([][]string, len(keys))
+	for i, key := range keys {
+		lowered[i] = make([]string, len(key))
+		for j, column := range key {
+			lowered[i][j] = strings.ToLower(column)
+		}
+	}
+
+	r.tables[table] = types
+	r.collations[table] = collations
+	r.binary[table] = binary
+	r.columns[table] = names
+	r.keys[table] = lowered
+	r.loaded[table] = readAt
+}
+
+// NeedsLoad reports whether a table should be read from the database: it was not
+// created through the proxy
+func (r *ColumnTypeRegistry) NeedsLoad(table string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	table = strings.ToLower(table)
+	_, known := r.tables[table]
+	_, loaded := r.loaded[table]
+	return !known || loaded
+}
+
+// referencedTables returns the tables a statement names, common table expressions and
+// the system schemas left out
+func referencedTables(stmt ast.StmtNode) []string {
+	collector := &tableCollector{ctes: make(map[string]bool), seen: make(map[string]bool)}
+	stmt.Accept(collector)
+	tables := collector.tables[:0]
+	for _, table := range collector.tables {
+		if !collector.ctes[table] {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// tableCollector collects the table names of a statement for referencedTables
+type tableCollector struct {
+	tables []string
+	seen   map[string]bool
+	ctes   map[string]bool
+}
+
+// Enter implements ast.Visitor interface
+func (c *tableCollector) Enter(n ast.Node) (ast.Node, bool) {
+	switch node := n.(type) {
+	case *ast.WithClause:
+		for _, cte := range node.CTEs {
+			c.ctes[cte.Name.L] = true
+		}
+	case *ast.TableName:
+		switch node.Schema.L {
+		case "information_schema", "performance_schema", "mysql", "sys", "pg_catalog":
+			return n, true
+		}
+		if !c.seen[node.Name.L] {
+			c.seen[node.Name.L] = true
+			c.tables = append(c.tables, node.Name.L)
+		}
+	}
+	return n, false
+}
+
+// Leave implements ast.Visitor interface
+func (c *tableCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// rewriteColumnLiterals adapts literals compared with known columns to the PostgreSQL
+// column types. Subqueries are rewritten against their own FROM clause when the
+// visitor enters them
+func (v *ASTVisitor) rewriteColumnLiterals(node ast.Node, from *ast.TableRefsClause) {
+	if from == nil || from.TableRefs == nil {
+		return
+	}
+	scope := &columnScope{
+		root:    node,
+		tables:  enumSourceTables(from.TableRefs),
+		columns: v.columnTypes,
+	}
+	node.Accept(&booleanLiteralVisitor{columnScope: scope})
+	node.Accept(&temporalLiteralVisitor{columnScope: scope})
+	node.Accept(&numericLiteralVisitor{columnScope: scope})
+	node.Accept(&mixedArgumentVisitor{columnScope: scope})
+	node.Accept(&collationVisitor{columnScope: scope, visitor: v})
+	node.Accept(&binaryLiteralVisitor{columnScope: scope, visitor: v})
+}
+
+// columnScope resolves column references against the FROM tables of one statement
+type columnScope struct {
+	root    ast.Node
+	tables  map[string]string // alias or name -> table
+	columns *ColumnTypeRegistry
+}
+
+// Enter implements ast.Visitor interface, nested queries have their own scope
+func (s *columnScope) Enter(n ast.Node) (ast.Node, bool) {
+	switch n.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		return n, n != s.root
+	}
+	return n, false
+}
+
+// columnType returns the MySQL type of a column reference
+func (s *columnScope) columnType(expr ast.ExprNode) (byte, bool) {
+	table, column, ok := s.columnTable(expr)
+	if !ok {
+		return 0, false
+	}
+	return s.columns.Type(table, column)
+}
+
+// columnTable resolves a column reference to its table. Unqualified columns must be
+// known in exactly one table
+func (s *columnScope) columnTable(expr ast.ExprNode) (string, string, bool) {
+	col, ok := expr.(*ast.ColumnNameExpr)
+	if !ok {
+		return "", "", false
+	}
+	name := col.Name
+	if name.Table.L != "" {
+		table, ok := s.tables[name.Table.L]
+		return table, name.Name.L, ok
+	}
+
+	var found string
+	count := 0
+	for _, table := range s.tables {
+		if _, ok := s.columns.Type(table, name.Name.L); ok {
+			found = table
+			count++
+		}
+	}
+	return found, name.Name.L, count == 1
+}
@@ -0,0 +1,156 @@
+package sqlrewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTable(t *testing.T) {
+	rewriter := NewASTRewriter()
+	readAt := time.Now()
+	rewriter.visitor.columnTypes.LoadTable("accounts", []TableColumn{
+		{Name: "id", DataType: "integer", AutoIncrement: true},
+		{Name: "email", DataType: "character varying"},
+		{Name: "code", DataType: "character varying", Collation: "C"},
+		{Name: "created_at", DataType: "timestamp without time zone"},
+		{Name: "active", DataType: "boolean"},
+		{Name: "avatar", DataType: "bytea"},
+	}, [][]string{{"id"}, {"email"}}, readAt)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Temporal literal",
+			mysql:    "SELECT id FROM accounts WHERE created_at >= '2024-01-01'",
+			expected: `SELECT "id" FROM "accounts" WHERE "created_at">=CAST('2024-01-01' AS TIMESTAMP)`,
+		},
+		{
+			name:     "Default collation compares case-insensitively",
+			mysql:    "SELECT id FROM accounts WHERE email = 'Bob@Example.com'",
+			expected: `SELECT "id" FROM "accounts" WHERE LOWER("email")=LOWER('Bob@Example.com')`,
+		},
+		{
+			name:     "C collation compares case-sensitively",
+			mysql:    "SELECT id FROM accounts WHERE code = 'Ab'",
+			expected: `SELECT "id" FROM "accounts" WHERE "code"='Ab'`,
+		},
+		{
+			name:     "Other types are left alone",
+			mysql:    "SELECT id FROM accounts WHERE active = TRUE",
+			expected: `SELECT "id" FROM "accounts" WHERE "active"=TRUE`,
+		},
+		{
+			name:     "NUL written to a BYTEA column",
+			mysql:    "INSERT INTO accounts (avatar) VALUES ('a\\0b')",
+			expected: `INSERT INTO "accounts" ("avatar") VALUES (CAST('\x610062' AS BYTEA))`,
+		},
+		{
+			name:     "AUTO_INCREMENT column",
+			mysql:    "INSERT INTO accounts (id, email) VALUES (NULL, 'a@example.com')",
+			expected: `INSERT INTO "accounts" ("id","email") VALUES (DEFAULT,'a@example.com')`,
+		},
+		{
+			name:     "ON DUPLICATE KEY UPDATE conflicts on the unique key written",
+			mysql:    "INSERT INTO accounts (email, code) VALUES ('a@example.com', 'x') ON DUPLICATE KEY UPDATE code = VALUES(code)",
+			expected: `INSERT INTO "accounts" ("email","code") VALUES ('a@example.com','x') ON CONFLICT ("email") DO UPDATE SET "code"="excluded"."code"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestLoadTable_Refresh(t *testing.T) {
+	registry := NewColumnTypeRegistry()
+	readAt := time.Now()
+	registry.LoadTable("t", []TableColumn{{Name: "a", DataType: "integer"}}, nil, readAt)
+
+	// Read at the same time, the table is kept
+	registry.LoadTable("t", []TableColumn{{Name: "a", DataType: "text"}}, nil, readAt)
+	tp, ok := registry.Type("t", "a")
+	require.True(t, ok)
+	assert.Equal(t, postgresColumnTypes["integer"], tp)
+
+	// Read again, the table is replaced
+	registry.LoadTable("t", []TableColumn{{Name: "b", DataType: "text"}}, nil, readAt.Add(time.Second))
+	_, ok = registry.Type("t", "a")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"b"}, registry.Columns("t"))
+	assert.True(t, registry.NeedsLoad("t"))
+
+	// Gone from the database
+	registry.LoadTable("t", nil, nil, readAt.Add(2*time.Second))
+	assert.False(t, registry.HasTable("t"))
+}
+
+func TestLoadTable_ProxyTablesWin(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE items (id INT, name VARCHAR(20) COLLATE utf8mb4_bin)")
+	require.NoError(t, err)
+	assert.False(t, rewriter.visitor.columnTypes.NeedsLoad("items"))
+
+	rewriter.visitor.columnTypes.LoadTable("items", []TableColumn{{Name: "name", DataType: "text"}}, nil, time.Now())
+	collation, ok := rewriter.visitor.columnTypes.Collation("items", "name")
+	require.True(t, ok)
+	assert.Equal(t, "utf8mb4_bin", collation)
+}
+
+func TestTablesToLoad(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE items (id INT)")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected []string
+	}{
+		{
+			name:     "Joins and subqueries",
+			mysql:    "SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id WHERE o.id IN (SELECT order_id FROM order_lines)",
+			expected: []string{"orders", "customers", "order_lines"},
+		},
+		{
+			name:     "Tables created through the proxy are known",
+			mysql:    "SELECT * FROM items JOIN orders ON orders.item_id = items.id",
+			expected: []string{"orders"},
+		},
+		{
+			name:     "CTE names and system schemas",
+			mysql:    "WITH recent AS (SELECT * FROM orders) SELECT * FROM recent, information_schema.tables",
+			expected: []string{"orders"},
+		},
+		{
+			name:     "DML",
+			mysql:    "UPDATE orders SET total = 0 WHERE id = 1",
+			expected: []string{"orders"},
+		},
+		{
+			name:     "DDL",
+			mysql:    "CREATE TABLE orders (id INT)",
+			expected: nil,
+		},
+		{
+			name:     "Parse error",
+			mysql:    "SELECT FROM",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rewriter.tablesToLoad(tt.mysql, nil))
+		})
+	}
+}
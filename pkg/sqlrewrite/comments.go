@@ -0,0 +1,114 @@
+package sqlrewrite
+
+import (
+	"strings"
+)
+
+// DefaultVersionCommentTarget matches the server version advertised to clients (8.0.11)
+const DefaultVersionCommentTarget = 80011
+
+// StripComments preprocesses MySQL comment syntax before statement classification
+//
+//	/*!40101 SET NAMES utf8 */  -> SET NAMES utf8   (40101 <= target, executed)
+//	/*!99999 SET x = 1 */       -> dropped           (newer than target)
+//	/*! SET x = 1 */            -> SET x = 1         (no version, always executed)
+//	/* regular comment */       -> dropped
+//	/*+ optimizer hint */       -> kept, the parser handles hints
+//	SELECT 1 \c                 -> ""                (mysql client: cancel statement)
+//
+// Quoted strings and identifiers are copied untouched
+func StripComments(sql string, target int) string {
+	var sb strings.Builder
+	sb.Grow(len(sql))
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(sql, i)
+			sb.WriteString(sql[i:end])
+			i = end - 1
+
+		case c == '\\' && i+1 < len(sql) && sql[i+1] == 'c' && strings.TrimSpace(sql[i+2:]) == "":
+			// \c clears the statement in the mysql client
+			return ""
+
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				// Unterminated comment, let the parser report it
+				sb.WriteString(sql[i:])
+				return strings.TrimSpace(sb.String())
+			}
+			end += i + 2
+			body := sql[i+2 : end]
+
+			switch {
+			case strings.HasPrefix(body, "+"):
+				sb.WriteString(sql[i : end+2])
+			case strings.HasPrefix(body, "!"):
+				version, inner := splitVersionComment(body[1:])
+				if version <= target {
+					// Version comments may nest regular comments and quotes
+					sb.WriteString(" " + StripComments(inner, target) + " ")
+				} else {
+					sb.WriteByte(' ')
+				}
+			default:
+				sb.WriteByte(' ')
+			}
+			i = end + 1
+
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// TrimStatement removes surrounding whitespace and trailing semicolons, which some
+// clients send with COM_QUERY. Exact-match classification ("COMMIT", "BEGIN") and
+// PostgreSQL-side appends such as RETURNING rely on the statement ending cleanly
+func TrimStatement(sql string) string {
+	return strings.TrimRight(strings.TrimSpace(sql), "; \t\r\n")
+}
+
+// splitVersionComment splits "40101 SET ..." into (40101, " SET ...")
+// A comment without version digits is always executed
+func splitVersionComment(body string) (int, string) {
+	n := 0
+	for n < len(body) && n < 6 && body[n] >= '0' && body[n] <= '9' {
+		n++
+	}
+	// MySQL versions are 5 digits (6 for 10.x and later)
+	if n < 5 {
+		return 0, body
+	}
+	version := 0
+	for _, d := range body[:n] {
+		version = version*10 + int(d-'0')
+	}
+	return version, body[n:]
+}
+
+// skipQuoted returns the index just past the quoted section starting at start
+func skipQuoted(sql string, start int) int {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			// Doubled quote is an escaped quote
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
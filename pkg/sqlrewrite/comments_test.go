@@ -0,0 +1,95 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name:     "Dump-style version comment executed",
+			sql:      "/*!40101 SET NAMES utf8mb4 */",
+			expected: "SET NAMES utf8mb4",
+		},
+		{
+			name:     "Version newer than target dropped",
+			sql:      "SELECT 1 /*!90000 + 1 */",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "Inline version comment",
+			sql:      "CREATE TABLE t (id INT) /*!50100 COMMENT 'x' */",
+			expected: "CREATE TABLE t (id INT)  COMMENT 'x'",
+		},
+		{
+			name:     "Version comment without version",
+			sql:      "/*! SET x = 1 */",
+			expected: "SET x = 1",
+		},
+		{
+			name:     "Regular comment stripped",
+			sql:      "/* app=web */ SELECT 1",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "Optimizer hint kept",
+			sql:      "SELECT /*+ MAX_EXECUTION_TIME(1000) */ 1",
+			expected: "SELECT /*+ MAX_EXECUTION_TIME(1000) */ 1",
+		},
+		{
+			name:     "Comment markers inside strings untouched",
+			sql:      "SELECT '/*!40101 x */', \"a\\\"/* b */\"",
+			expected: "SELECT '/*!40101 x */', \"a\\\"/* b */\"",
+		},
+		{
+			name:     "Client cancel",
+			sql:      "SELECT 1 \\c",
+			expected: "",
+		},
+		{
+			name:     "Unterminated comment passed through",
+			sql:      "SELECT 1 /* oops",
+			expected: "SELECT 1 /* oops",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, StripComments(tt.sql, DefaultVersionCommentTarget))
+		})
+	}
+}
+
+func TestStripComments_Target(t *testing.T) {
+	sql := "/*!50708 SET @x = 1 */"
+	assert.Equal(t, "SET @x = 1", StripComments(sql, 80011))
+	assert.Equal(t, "", StripComments(sql, 50700))
+}
+
+func TestTrimStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{"trailing semicolon", "COMMIT;", "COMMIT"},
+		{"surrounding whitespace", "\n\t  SELECT 1  \r\n", "SELECT 1"},
+		{"semicolon and whitespace", "  INSERT INTO t VALUES (1) ; \n", "INSERT INTO t VALUES (1)"},
+		{"repeated semicolons", "SELECT 1;;  ;", "SELECT 1"},
+		{"only semicolons", " ; ", ""},
+		{"semicolon inside literal kept", "SELECT 'a;'", "SELECT 'a;'"},
+		{"inner semicolon kept", "SELECT 1; SELECT 2;", "SELECT 1; SELECT 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TrimStatement(tt.sql))
+		})
+	}
+}
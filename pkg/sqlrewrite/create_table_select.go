@@ -0,0 +1,53 @@
+package sqlrewrite
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// rewriteCreateTableSelect prepares CREATE TABLE ... [AS] SELECT for PostgreSQL's CREATE TABLE AS
+// The SELECT part goes through the normal rewrite as the visitor walks into it.
+//
+// IGNORE and REPLACE only matter when the new table declares keys that the selected rows
+// violate. PostgreSQL's CREATE TABLE AS has no keys to violate, so both fall back to a
+// plain CREATE TABLE AS; the unsupported-feature detector logs a warning for them
+func (v *ASTVisitor) rewriteCreateTableSelect(node *ast.CreateTableStmt) {
+	if node.Select == nil {
+		return
+	}
+	node.OnDuplicate = ast.OnDuplicateKeyHandlingError
+
+	// PostgreSQL names unaliased expressions ?column? (or after the function), and refuses
+	// to create two columns with the same name. MySQL names them after the expression text
+	var first ast.Node = node.Select
+	for {
+		setOpr, ok := first.(*ast.SetOprStmt)
+		if !ok || setOpr.SelectList == nil || len(setOpr.SelectList.Selects) == 0 {
+			break
+		}
+		first = setOpr.SelectList.Selects[0]
+	}
+	sel, ok := first.(*ast.SelectStmt)
+	if !ok || sel.Fields == nil {
+		return
+	}
+	for _, field := range sel.Fields.Fields {
+		if field.WildCard != nil || field.AsName.O != "" {
+			continue
+		}
+		switch expr := field.Expr.(type) {
+		case *ast.ColumnNameExpr:
+			continue
+		case ast.ValueExpr:
+			// A string literal names its column after the string, without quotes
+			if value, ok := expr.GetValue().(string); ok {
+				field.AsName = ast.NewCIStr(value)
+				continue
+			}
+		}
+		if text := strings.TrimSpace(field.Text()); text != "" {
+			field.AsName = ast.NewCIStr(text)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteCreateTableSelect(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "AS SELECT",
+			mysql:    "CREATE TABLE archive AS SELECT id, name FROM users WHERE id > 10",
+			expected: `CREATE TABLE "archive" AS SELECT "id","name" FROM "users" WHERE "id">10`,
+		},
+		{
+			name:     "SELECT without AS",
+			mysql:    "CREATE TABLE IF NOT EXISTS archive SELECT id FROM users LIMIT 5",
+			expected: `CREATE TABLE IF NOT EXISTS "archive" AS SELECT "id" FROM "users" LIMIT 5`,
+		},
+		{
+			name:     "SELECT is rewritten",
+			mysql:    "CREATE TABLE archive AS SELECT id, IF(active, 'yes', 'no') AS state FROM users",
+			expected: `CREATE TABLE "archive" AS SELECT "id",CASE WHEN "active" THEN 'yes' ELSE 'no' END AS "state" FROM "users"`,
+		},
+		{
+			name:     "Expressions are named like MySQL",
+			mysql:    "CREATE TABLE totals AS SELECT user_id, COUNT(*), SUM(amount) + 1, 'paid' FROM orders GROUP BY user_id",
+			expected: `CREATE TABLE "totals" AS SELECT "user_id",COUNT(1) AS "COUNT(*)",SUM("amount")+1 AS "SUM(amount) + 1",'paid' AS "paid" FROM "orders" GROUP BY "user_id"`,
+		},
+		{
+			name:     "UNION names columns after the first SELECT",
+			mysql:    "CREATE TABLE ids AS SELECT id + 0 FROM a UNION SELECT id FROM b",
+			expected: `CREATE TABLE "ids" AS SELECT "id"+0 AS "id + 0" FROM "a" UNION SELECT "id" FROM "b"`,
+		},
+		{
+			name:     "IGNORE falls back to a plain CREATE TABLE AS",
+			mysql:    "CREATE TABLE archive IGNORE SELECT id FROM users",
+			expected: `CREATE TABLE "archive" AS SELECT "id" FROM "users"`,
+		},
+		{
+			name:     "REPLACE falls back to a plain CREATE TABLE AS",
+			mysql:    "CREATE TABLE archive REPLACE AS SELECT id FROM users",
+			expected: `CREATE TABLE "archive" AS SELECT "id" FROM "users"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("IGNORE and REPLACE are reported", func(t *testing.T) {
+		detector := NewUnsupportedDetector()
+		for _, sql := range []string{
+			"CREATE TABLE archive IGNORE SELECT id FROM users",
+			"CREATE TABLE archive REPLACE AS SELECT id FROM users",
+		} {
+			features := detector.Detect(sql)
+			require.Len(t, features, 1, sql)
+			assert.Equal(t, "warning", features[0].Severity)
+		}
+		assert.Empty(t, detector.Detect("CREATE TABLE archive AS SELECT id FROM users"))
+	})
+}
@@ -0,0 +1,80 @@
+package sqlrewrite
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// defaultExprMarker stands in for the parentheses of a column's DEFAULT (expression)
+const defaultExprMarker = "aproxy_default_expr"
+
+// wrapDefaultExprs makes the DEFAULT (expression) of CREATE and ALTER TABLE parseable.
+// MySQL 8 takes any expression there, TiDB's parser only literals and function calls,
+// so the expression becomes the argument of a call of defaultExprMarker. Leave turns
+// the call back into parentheses once the expression is rewritten
+//
+//	DEFAULT (NOW() + INTERVAL 1 DAY) -> DEFAULT (aproxy_default_expr(NOW() + INTERVAL 1 DAY))
+func wrapDefaultExprs(sql string) string {
+	if keyword := statementKeyword(sql); keyword != "CREATE" && keyword != "ALTER" {
+		return sql
+	}
+
+	var sb strings.Builder
+	last := 0
+	for i := 0; i < len(sql); i++ {
+		if c := sql[i]; c == '\'' || c == '"' || c == '`' {
+			i = skipQuoted(sql, i) - 1
+			continue
+		}
+		end, ok := matchKeywords(sql, i, "DEFAULT")
+		if !ok {
+			continue
+		}
+		open := skipSpaces(sql, end)
+		if open == len(sql) || sql[open] != '(' {
+			continue
+		}
+		closing := closingParen(sql, open)
+		if closing < 0 {
+			return sql
+		}
+		sb.WriteString(sql[last : open+1])
+		sb.WriteString(defaultExprMarker + "(")
+		sb.WriteString(sql[open+1 : closing])
+		sb.WriteString(")")
+		last = closing
+		i = closing
+	}
+	if last == 0 {
+		return sql
+	}
+	sb.WriteString(sql[last:])
+	return sb.String()
+}
+
+// closingParen returns the position of the parenthesis closing the one at open, -1
+// when it is not closed
+func closingParen(sql string, open int) int {
+	depth := 0
+	for i := open; i < len(sql); i++ {
+		switch c := sql[i]; c {
+		case '\'', '"', '`':
+			i = skipQuoted(sql, i) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unwrapDefaultExpr turns the call of defaultExprMarker back into the parenthesized
+// expression, which PostgreSQL takes as a column default as well
+func unwrapDefaultExpr(node *ast.FuncCallExpr) ast.ExprNode {
+	return &ast.ParenthesesExpr{Expr: unwrapParentheses(node.Args[0])}
+}
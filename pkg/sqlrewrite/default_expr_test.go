@@ -0,0 +1,78 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultExpressions(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "UUID()",
+			mysql:    "CREATE TABLE tokens (id CHAR(36) DEFAULT (UUID()), name VARCHAR(20))",
+			expected: `CREATE TABLE "tokens" ("id" CHAR(36) DEFAULT CAST(GEN_RANDOM_UUID() AS TEXT),"name" VARCHAR(20))`,
+		},
+		{
+			name:     "Bare CURRENT_TIMESTAMP",
+			mysql:    "CREATE TABLE events (at DATETIME DEFAULT CURRENT_TIMESTAMP)",
+			expected: `CREATE TABLE "events" ("at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP)`,
+		},
+		{
+			name:     "Parenthesized CURRENT_TIMESTAMP",
+			mysql:    "CREATE TABLE events (at DATETIME DEFAULT (CURRENT_TIMESTAMP))",
+			expected: `CREATE TABLE "events" ("at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP)`,
+		},
+		{
+			name:     "Functions are converted",
+			mysql:    "CREATE TABLE events (day DATE DEFAULT (CURDATE()), label VARCHAR(10) DEFAULT (IFNULL(NULL, 'x')))",
+			expected: `CREATE TABLE "events" ("day" DATE DEFAULT (CURRENT_DATE),"label" VARCHAR(10) DEFAULT (COALESCE(NULL, 'x')))`,
+		},
+		{
+			name:     "Arithmetic",
+			mysql:    "CREATE TABLE events (qty INT DEFAULT (1 + 2) NOT NULL, expires DATETIME DEFAULT (NOW() + INTERVAL 1 DAY))",
+			expected: `CREATE TABLE "events" ("qty" INT DEFAULT (1+2) NOT NULL,"expires" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP+INTERVAL '1 DAY'))`,
+		},
+		{
+			name:     "DATE_ADD with a quoted parenthesis",
+			mysql:    "CREATE TABLE events (expires DATETIME DEFAULT (DATE_ADD(NOW(), INTERVAL 7 DAY)), note VARCHAR(10) DEFAULT ('(none'))",
+			expected: `CREATE TABLE "events" ("expires" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP+INTERVAL '7 DAY'),"note" VARCHAR(10) DEFAULT ('(none'))`,
+		},
+		{
+			name:     "ALTER TABLE ADD COLUMN",
+			mysql:    "ALTER TABLE events ADD COLUMN retries INT DEFAULT (2 * 3)",
+			expected: `ALTER TABLE "events" ADD COLUMN "retries" INT DEFAULT (2*3)`,
+		},
+		{
+			name:     "ALTER COLUMN SET DEFAULT",
+			mysql:    "ALTER TABLE events ALTER COLUMN retries SET DEFAULT (1 + 1)",
+			expected: `ALTER TABLE "events" ALTER COLUMN "retries" SET DEFAULT (1+1)`,
+		},
+		{
+			name:     "Inserting a row that uses the default",
+			mysql:    "INSERT INTO tokens (id, name) VALUES (DEFAULT, 'a')",
+			expected: `INSERT INTO "tokens" ("id","name") VALUES (DEFAULT,'a')`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestUUID(t *testing.T) {
+	result, err := NewASTRewriter().Rewrite("SELECT UUID(), UUID() AS id")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT CAST(GEN_RANDOM_UUID() AS TEXT) AS "UUID()",CAST(GEN_RANDOM_UUID() AS TEXT) AS "id"`, result)
+}
@@ -0,0 +1,56 @@
+package sqlrewrite
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+)
+
+// rewriteMultiArgAggregate folds the arguments of COUNT(DISTINCT a, b) and
+// GROUP_CONCAT(a, b) into one, PostgreSQL's aggregates take a single expression.
+// MySQL skips rows where any of the arguments is NULL, so the folded argument is
+// NULL for them and the aggregate skips them as well
+// MySQL: COUNT(DISTINCT a, b)
+// PostgreSQL: COUNT(DISTINCT CASE WHEN "a" IS NOT NULL AND "b" IS NOT NULL THEN ROW("a","b") END)
+// MySQL: GROUP_CONCAT(DISTINCT first, ' ', last)
+// PostgreSQL: string_agg(DISTINCT CASE WHEN "first" IS NOT NULL AND "last" IS NOT NULL THEN CONCAT("first", ' ', "last") END, ',')
+//
+// GROUP_CONCAT(DISTINCT ...) then compares the concatenated values, MySQL compares
+// the arguments one by one ('a','bc' and 'ab','c' both read 'abc')
+func rewriteMultiArgAggregate(node *ast.AggregateFuncExpr) *ast.AggregateFuncExpr {
+	switch strings.ToLower(node.F) {
+	case ast.AggFuncCount:
+		if node.Distinct && len(node.Args) > 1 {
+			node.Args = []ast.ExprNode{nullIfAnyNull(node.Args, &ast.RowExpr{Values: node.Args})}
+		}
+	case ast.AggFuncGroupConcat:
+		// The last argument is the separator
+		if args := node.Args[:len(node.Args)-1]; len(args) > 1 {
+			concat := &ast.FuncCallExpr{FnName: ast.NewCIStr("CONCAT"), Args: args}
+			node.Args = []ast.ExprNode{nullIfAnyNull(args, concat), node.Args[len(node.Args)-1]}
+		}
+	}
+	return node
+}
+
+// nullIfAnyNull builds CASE WHEN <every arg IS NOT NULL> THEN result END. Literals
+// other than NULL need no check. Arguments with a placeholder are not checked either
+// as a placeholder cannot appear twice, rows are then not skipped for a NULL bound to it
+func nullIfAnyNull(args []ast.ExprNode, result ast.ExprNode) ast.ExprNode {
+	var cond ast.ExprNode
+	for _, arg := range args {
+		if value, ok := arg.(ast.ValueExpr); ok && value.GetValue() != nil || containsParamMarker(arg) {
+			continue
+		}
+		var notNull ast.ExprNode = &ast.IsNullExpr{Expr: arg, Not: true}
+		if cond != nil {
+			notNull = &ast.BinaryOperationExpr{Op: opcode.LogicAnd, L: cond, R: notNull}
+		}
+		cond = notNull
+	}
+	if cond == nil {
+		return result
+	}
+	return &ast.CaseExpr{WhenClauses: []*ast.WhenClause{{Expr: cond, Result: result}}}
+}
@@ -0,0 +1,52 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteMultiArgAggregates(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "COUNT DISTINCT of several columns",
+			mysql:    "SELECT COUNT(DISTINCT a, b) FROM t",
+			expected: `SELECT COUNT(DISTINCT CASE WHEN "a" IS NOT NULL AND "b" IS NOT NULL THEN ROW("a","b") END) FROM "t"`,
+		},
+		{
+			name:     "COUNT DISTINCT of expressions and placeholders",
+			mysql:    "SELECT d, COUNT(DISTINCT a + 1, ?) FROM t GROUP BY d",
+			expected: `SELECT "d",COUNT(DISTINCT CASE WHEN "a"+1 IS NOT NULL THEN ROW("a"+1,$1) END) FROM "t" GROUP BY "d"`,
+		},
+		{
+			name:     "Single argument aggregates are unchanged",
+			mysql:    "SELECT COUNT(DISTINCT a), SUM(DISTINCT b), COUNT(*) FROM t",
+			expected: `SELECT COUNT(DISTINCT "a"),SUM(DISTINCT "b"),COUNT(1) FROM "t"`,
+		},
+		{
+			name:     "GROUP_CONCAT of several arguments",
+			mysql:    "SELECT GROUP_CONCAT(DISTINCT first, ' ', last SEPARATOR '; ') FROM t",
+			expected: `SELECT string_agg(DISTINCT CASE WHEN "first" IS NOT NULL AND "last" IS NOT NULL THEN CONCAT("first", ' ', "last") END, '; ') FROM "t"`,
+		},
+		{
+			name:     "GROUP_CONCAT of one argument",
+			mysql:    "SELECT GROUP_CONCAT(DISTINCT a) FROM t",
+			expected: `SELECT string_agg(DISTINCT "a", ',') FROM "t"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
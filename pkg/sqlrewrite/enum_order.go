@@ -0,0 +1,223 @@
+package sqlrewrite
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// EnumRegistry remembers ENUM declarations seen in CREATE TABLE. ENUM columns become
+// VARCHAR in PostgreSQL, which sorts them alphabetically, while MySQL sorts them by
+// declaration order. Declarations live in memory only: tables created before the
+// proxy started keep sorting as plain strings. The declared types of ENUM and SET
+// columns are kept as well, for SHOW COLUMNS
+type EnumRegistry struct {
+	mu           sync.RWMutex
+	tables       map[string]map[string][]string // table -> column -> values in declaration order
+	declarations map[string]map[string]string   // table -> column -> enum('a','b') or set('a','b')
+}
+
+// NewEnumRegistry creates an empty registry
+func NewEnumRegistry() *EnumRegistry {
+	return &EnumRegistry{
+		tables:       make(map[string]map[string][]string),
+		declarations: make(map[string]map[string]string),
+	}
+}
+
+// Register records the declared values of an ENUM column
+func (r *EnumRegistry) Register(table, column string, values []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	table = strings.ToLower(table)
+	if r.tables[table] == nil {
+		r.tables[table] = make(map[string][]string)
+	}
+	r.tables[table][strings.ToLower(column)] = append([]string(nil), values...)
+}
+
+// Values returns the declared values of an ENUM column, nil if it is not known
+func (r *EnumRegistry) Values(table, column string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tables[strings.ToLower(table)][strings.ToLower(column)]
+}
+
+// RegisterDeclaration records the MySQL type an ENUM or SET column was declared with
+func (r *EnumRegistry) RegisterDeclaration(table, column, declaration string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	table = strings.ToLower(table)
+	if r.declarations[table] == nil {
+		r.declarations[table] = make(map[string]string)
+	}
+	r.declarations[table][strings.ToLower(column)] = declaration
+}
+
+// Declarations returns the declared types of the ENUM and SET columns of a table,
+// keyed by lower-case column name
+func (r *EnumRegistry) Declarations(table string) map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	declarations := make(map[string]string, len(r.declarations[strings.ToLower(table)]))
+	for column, declaration := range r.declarations[strings.ToLower(table)] {
+		declarations[column] = declaration
+	}
+	return declarations
+}
+
+// DropTable forgets the ENUM columns of a table
+func (r *EnumRegistry) DropTable(table string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tables, strings.ToLower(table))
+	delete(r.declarations, strings.ToLower(table))
+}
+
+// enumDeclaration spells an ENUM or SET type the way SHOW COLUMNS does
+//
+//	ENUM('a','it''s') -> enum('a','it''s')
+func enumDeclaration(kind string, values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	return kind + "(" + strings.Join(quoted, ",") + ")"
+}
+
+// rewriteEnumOrderBy replaces ORDER BY on known ENUM columns with their declaration index
+// MySQL: ORDER BY status
+// PostgreSQL: ORDER BY CASE "status" WHEN 'new' THEN 1 WHEN 'paid' THEN 2 ELSE 0 END
+// Unknown values sort first like MySQL's empty-string error value. The stored values
+// are the declared ones, the CASE compares them exactly: it is built after the column
+// collations are recorded, on a new column reference that visitCase does not lower
+func (v *ASTVisitor) rewriteEnumOrderBy(node *ast.SelectStmt) {
+	// With DISTINCT, PostgreSQL requires ORDER BY expressions to appear in the select list
+	if node.OrderBy == nil || node.Distinct || node.From == nil {
+		return
+	}
+
+	tables := enumSourceTables(node.From.TableRefs)
+	for _, item := range node.OrderBy.Items {
+		col, ok := item.Expr.(*ast.ColumnNameExpr)
+		if ok && isSelectAlias(node, col.Name) {
+			continue
+		}
+		if !ok {
+			// ORDER BY 2 sorts by the second select-list column
+			if col, ok = selectFieldColumn(node, item.Expr); !ok {
+				continue
+			}
+		}
+
+		values := v.enumValues(tables, col.Name)
+		if values == nil {
+			continue
+		}
+
+		caseExpr := &ast.CaseExpr{
+			Value:      &ast.ColumnNameExpr{Name: col.Name},
+			ElseClause: ast.NewValueExpr(int64(0), "", ""),
+		}
+		for i, value := range values {
+			caseExpr.WhenClauses = append(caseExpr.WhenClauses, &ast.WhenClause{
+				Expr:   ast.NewValueExpr(value, "", ""),
+				Result: ast.NewValueExpr(int64(i+1), "", ""),
+			})
+		}
+		item.Expr = caseExpr
+	}
+}
+
+// selectFieldColumn resolves an ORDER BY position to the column at that place in the select list
+func selectFieldColumn(node *ast.SelectStmt, expr ast.ExprNode) (*ast.ColumnNameExpr, bool) {
+	pos, ok := expr.(*ast.PositionExpr)
+	if !ok || pos.P != nil || node.Fields == nil {
+		return nil, false
+	}
+	position := pos.N
+	if position < 1 || position > len(node.Fields.Fields) {
+		return nil, false
+	}
+	// A * before the position expands to an unknown number of columns
+	for _, field := range node.Fields.Fields[:position] {
+		if field.WildCard != nil {
+			return nil, false
+		}
+	}
+	col, ok := node.Fields.Fields[position-1].Expr.(*ast.ColumnNameExpr)
+	if !ok {
+		return nil, false
+	}
+	return &ast.ColumnNameExpr{Name: col.Name}, true
+}
+
+// isSelectAlias reports whether an unqualified ORDER BY column refers to a select-list alias
+func isSelectAlias(node *ast.SelectStmt, name *ast.ColumnName) bool {
+	if name.Table.L != "" || node.Fields == nil {
+		return false
+	}
+	for _, field := range node.Fields.Fields {
+		if field.AsName.L == name.Name.L {
+			return true
+		}
+	}
+	return false
+}
+
+// enumValues resolves a column reference against the FROM tables. Unqualified
+// columns must match an ENUM column in exactly one table
+func (v *ASTVisitor) enumValues(tables map[string]string, name *ast.ColumnName) []string {
+	if name.Table.L != "" {
+		table, ok := tables[name.Table.L]
+		if !ok {
+			return nil
+		}
+		return v.enums.Values(table, name.Name.L)
+	}
+
+	var found []string
+	for _, table := range tables {
+		if values := v.enums.Values(table, name.Name.L); values != nil {
+			if found != nil {
+				return nil // Ambiguous, leave it to PostgreSQL
+			}
+			found = values
+		}
+	}
+	return found
+}
+
+// enumSourceTables maps the names a SELECT can qualify columns with (alias or
+// table name) to the underlying table names
+func enumSourceTables(node ast.ResultSetNode) map[string]string {
+	tables := make(map[string]string)
+	var walk func(ast.ResultSetNode)
+	walk = func(n ast.ResultSetNode) {
+		switch n := n.(type) {
+		case *ast.Join:
+			if n.Left != nil {
+				walk(n.Left)
+			}
+			if n.Right != nil {
+				walk(n.Right)
+			}
+		case *ast.TableSource:
+			name, ok := n.Source.(*ast.TableName)
+			if !ok {
+				return
+			}
+			if n.AsName.L != "" {
+				tables[n.AsName.L] = name.Name.L
+			} else {
+				tables[name.Name.L] = name.Name.L
+			}
+		}
+	}
+	walk(node)
+	return tables
+}
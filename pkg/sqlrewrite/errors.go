@@ -0,0 +1,84 @@
+package sqlrewrite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Rewrite failure reasons, used as metric labels
+const (
+	ReasonParse       = "parse_error"
+	ReasonUnsupported = "unsupported"
+	ReasonTransform   = "transform_error"
+	ReasonGenerate    = "generate_error"
+	ReasonValidation  = "validation_error" // PostgreSQL could not plan the rewritten statement
+)
+
+// RewriteError describes why a statement could not be rewritten
+// Feature is a bounded label: a detector feature name (e.g. "GET_LOCK()")
+// or the statement keyword (e.g. "SELECT"), never free-form SQL
+type RewriteError struct {
+	Reason  string
+	Feature string
+	Err     error
+}
+
+func (e *RewriteError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RewriteError) Unwrap() error {
+	return e.Err
+}
+
+// ParamCountError reports a rewrite that changed the number of placeholders, the
+// values a client binds to the MySQL ? would not line up with the PostgreSQL $n
+type ParamCountError struct {
+	Placeholders int // ? of the MySQL statement
+	Rewritten    int // $n of the PostgreSQL statement
+}
+
+func (e *ParamCountError) Error() string {
+	return fmt.Sprintf("rewritten statement has %d placeholders, the original has %d", e.Rewritten, e.Placeholders)
+}
+
+// NewValidationError reports a rewritten statement that PostgreSQL rejected when
+// validating it, sql is the original statement
+func NewValidationError(sql string, err error) *RewriteError {
+	return &RewriteError{Reason: ReasonValidation, Feature: statementKeyword(sql), Err: err}
+}
+
+// RewriteFailureLabels returns the (reason, feature) labels for a rewrite error
+func RewriteFailureLabels(err error) (string, string) {
+	var rerr *RewriteError
+	if !errors.As(err, &rerr) {
+		return ReasonGenerate, "other"
+	}
+	feature := rerr.Feature
+	if feature == "" {
+		feature = "other"
+	}
+	return rerr.Reason, feature
+}
+
+// statementKeywords bounds the feature label for parse and generator errors
+var statementKeywords = map[string]bool{
+	"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"SET": true, "SHOW": true, "WITH": true, "CALL": true, "LOAD": true,
+	"LOCK": true, "UNLOCK": true, "GRANT": true, "REVOKE": true, "EXPLAIN": true,
+}
+
+// statementKeyword returns the leading keyword of sql, or "other"
+func statementKeyword(sql string) string {
+	fields := strings.Fields(strings.TrimLeft(sql, "( \t\n"))
+	if len(fields) == 0 {
+		return "other"
+	}
+	keyword := strings.ToUpper(strings.TrimRight(fields[0], "(;"))
+	if statementKeywords[keyword] {
+		return keyword
+	}
+	return "other"
+}
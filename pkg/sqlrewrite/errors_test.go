@@ -0,0 +1,56 @@
+package sqlrewrite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteFailureLabels(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	tests := []struct {
+		name    string
+		sql     string
+		reason  string
+		feature string
+	}{
+		{
+			name:    "Parse error",
+			sql:     "SELECT FROM",
+			reason:  ReasonParse,
+			feature: "SELECT",
+		},
+		{
+			name:    "Parse error with unknown keyword",
+			sql:     "FROBNICATE t",
+			reason:  ReasonParse,
+			feature: "other",
+		},
+		{
+			name:    "Unsupported statement",
+			sql:     "LOAD DATA INFILE '/tmp/x' INTO TABLE t FIELDS TERMINATED BY ',,' x",
+			reason:  ReasonUnsupported,
+			feature: "LOAD DATA INFILE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rewriter.Rewrite(tt.sql)
+			require.Error(t, err)
+
+			reason, feature := RewriteFailureLabels(err)
+			assert.Equal(t, tt.reason, reason)
+			assert.Equal(t, tt.feature, feature)
+		})
+	}
+}
+
+func TestRewriteFailureLabels_UntypedError(t *testing.T) {
+	reason, feature := RewriteFailureLabels(errors.New("boom"))
+	assert.Equal(t, ReasonGenerate, reason)
+	assert.Equal(t, "other", feature)
+}
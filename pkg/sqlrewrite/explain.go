@@ -0,0 +1,114 @@
+package sqlrewrite
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// ExplainFormat is the output an EXPLAIN asks for
+type ExplainFormat int
+
+const (
+	ExplainTraditional ExplainFormat = iota // EXPLAIN and FORMAT=TRADITIONAL, a row per table
+	ExplainJSON                             // FORMAT=JSON
+	ExplainTree                             // FORMAT='TREE' and EXPLAIN ANALYZE
+)
+
+// Explain is an EXPLAIN statement, the caller answers it in MySQL's shape from the
+// PostgreSQL plan of the explained statement
+type Explain struct {
+	Query   string // PostgreSQL SQL of the explained statement
+	Format  ExplainFormat
+	Analyze bool // Runs the statement, EXPLAIN ANALYZE
+	Params  int  // Placeholders of Query, $1 to $n
+}
+
+// SQL returns the PostgreSQL EXPLAIN of the statement. The traditional and JSON formats
+// are built from PostgreSQL's JSON plan, the tree format is its text plan. A generic
+// plan leaves the parameters unbound, PostgreSQL 16 has EXPLAIN (GENERIC_PLAN) for it
+//
+//	EXPLAIN SELECT * FROM t WHERE id = ? -> EXPLAIN (FORMAT JSON) SELECT * FROM "t" WHERE "id"=$1
+func (e *Explain) SQL(generic bool) string {
+	var options []string
+	if e.Analyze {
+		options = append(options, "ANALYZE")
+	}
+	if generic {
+		options = append(options, "GENERIC_PLAN")
+	}
+	if e.Format != ExplainTree {
+		options = append(options, "FORMAT JSON")
+	}
+	if len(options) == 0 {
+		return "EXPLAIN " + e.Query
+	}
+	return "EXPLAIN (" + strings.Join(options, ", ") + ") " + e.Query
+}
+
+// explainOf returns the EXPLAIN of a statement explaining another one. EXPLAIN t, the
+// DESCRIBE of a table, and EXPLAIN FOR CONNECTION are left to the statement itself
+func explainOf(stmt ast.StmtNode) (*ast.ExplainStmt, bool) {
+	explain, ok := stmt.(*ast.ExplainStmt)
+	if !ok {
+		return nil, false
+	}
+	if _, describe := explain.Stmt.(*ast.ShowStmt); describe {
+		return nil, false
+	}
+	return explain, true
+}
+
+// explainFormat maps the FORMAT of MySQL's EXPLAIN. ANALYZE only has the tree format
+func explainFormat(node *ast.ExplainStmt) ExplainFormat {
+	switch format := strings.ToLower(node.Format); {
+	case node.Analyze || format == "tree":
+		return ExplainTree
+	case format == "json":
+		return ExplainJSON
+	}
+	return ExplainTraditional
+}
+
+// Bind returns the EXPLAIN with literals, one per placeholder, in place of $1 to $n. Before
+// PostgreSQL 16 a plan cannot leave parameters unbound, the literals stand in for them
+//
+//	SELECT * FROM "t" WHERE "id"=$1, [0] -> SELECT * FROM "t" WHERE "id"=0
+func (e *Explain) Bind(literals []string) *Explain {
+	bound := *e
+	bound.Query = bindPlaceholders(e.Query, literals)
+	bound.Params = 0
+	return &bound
+}
+
+// bindPlaceholders replaces the $n placeholders of sql outside quotes with literals[n-1]
+func bindPlaceholders(sql string, literals []string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+	quote := byte(0)
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case quote != 0:
+			// A doubled quote is an escaped one and closes and reopens at once
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '$' && (i == 0 || !isIdentChar(sql[i-1])):
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(sql[i+1 : j]); err == nil && n >= 1 && n <= len(literals) {
+				b.WriteString(literals[n-1])
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
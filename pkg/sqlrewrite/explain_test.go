@@ -0,0 +1,81 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+		generic  string
+		format   ExplainFormat
+		params   int
+	}{
+		{
+			name:     "Parameterized SELECT",
+			mysql:    "EXPLAIN SELECT * FROM t WHERE id = ? AND name = ?",
+			expected: `EXPLAIN (FORMAT JSON) SELECT * FROM "t" WHERE "id"=$1 AND "name"=$2`,
+			generic:  `EXPLAIN (GENERIC_PLAN, FORMAT JSON) SELECT * FROM "t" WHERE "id"=$1 AND "name"=$2`,
+			params:   2,
+		},
+		{
+			name:     "FORMAT=JSON",
+			mysql:    "EXPLAIN FORMAT=JSON SELECT * FROM t WHERE id = 1",
+			expected: `EXPLAIN (FORMAT JSON) SELECT * FROM "t" WHERE "id"=1`,
+			generic:  `EXPLAIN (GENERIC_PLAN, FORMAT JSON) SELECT * FROM "t" WHERE "id"=1`,
+			format:   ExplainJSON,
+		},
+		{
+			name:     "FORMAT='TREE'",
+			mysql:    "EXPLAIN FORMAT='TREE' UPDATE t SET a = ? WHERE id = ?",
+			expected: `EXPLAIN UPDATE "t" SET "a"=$1 WHERE "id"=$2`,
+			generic:  `EXPLAIN (GENERIC_PLAN) UPDATE "t" SET "a"=$1 WHERE "id"=$2`,
+			format:   ExplainTree,
+			params:   2,
+		},
+		{
+			name:     "ANALYZE",
+			mysql:    "EXPLAIN ANALYZE SELECT * FROM t",
+			expected: `EXPLAIN (ANALYZE) SELECT * FROM "t"`,
+			generic:  `EXPLAIN (ANALYZE, GENERIC_PLAN) SELECT * FROM "t"`,
+			format:   ExplainTree,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := rewriter.RewriteStatement(tt.mysql, nil)
+			require.NoError(t, err)
+			require.NotNil(t, stmt.Explain)
+			assert.Equal(t, tt.expected, stmt.SQL)
+			assert.Equal(t, tt.generic, stmt.Explain.SQL(true))
+			assert.Equal(t, tt.format, stmt.Explain.Format)
+			assert.Equal(t, tt.params, stmt.Explain.Params)
+
+			prepared, params, err := rewriter.RewritePreparedStatement(tt.mysql, nil)
+			require.NoError(t, err)
+			require.NotNil(t, prepared.Explain)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+
+	// The DESCRIBE of a table is not a plan
+	stmt, err := rewriter.RewriteStatement("EXPLAIN t", nil)
+	require.NoError(t, err)
+	assert.Nil(t, stmt.Explain)
+}
+
+func TestExplainBind(t *testing.T) {
+	explain := &Explain{Query: `SELECT '$1', "$2" FROM "t" WHERE "id"=$1 AND "name"=$2 AND "n"=$10`, Params: 2}
+	bound := explain.Bind([]string{"0", "''"})
+	assert.Equal(t, `SELECT '$1', "$2" FROM "t" WHERE "id"=0 AND "name"='' AND "n"=$10`, bound.Query)
+	assert.Equal(t, 0, bound.Params)
+	assert.Equal(t, 2, explain.Params)
+}
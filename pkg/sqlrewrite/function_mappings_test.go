@@ -0,0 +1,37 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddFunctionMappings(t *testing.T) {
+	rewriter := NewASTRewriter()
+	require.NoError(t, rewriter.visitor.AddFunctionMappings(map[string]string{
+		"CALC_TAX": "app.calc_tax",
+		"ifnull":   "my_ifnull",
+	}))
+
+	result, err := rewriter.Rewrite("SELECT calc_tax(price, 0.2), IFNULL(name, '') FROM orders")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT APP.CALC_TAX("price", 0.2),MY_IFNULL("name", '') FROM "orders"`, result)
+
+	t.Run("Special handlers cannot be overridden", func(t *testing.T) {
+		err := rewriter.visitor.AddFunctionMappings(map[string]string{"DATE_ADD": "my_date_add"})
+		assert.ErrorContains(t, err, "conflicts with the built-in DATE_ADD conversion")
+		err = rewriter.visitor.AddFunctionMappings(map[string]string{"greatest": "my_greatest"})
+		assert.ErrorContains(t, err, "conflicts with the built-in GREATEST conversion")
+		err = rewriter.visitor.AddFunctionMappings(map[string]string{"LEAST": "my_least"})
+		assert.ErrorContains(t, err, "conflicts with the built-in LEAST conversion")
+		err = rewriter.visitor.AddFunctionMappings(map[string]string{"uuid": "uuid_generate_v4"})
+		assert.ErrorContains(t, err, "conflicts with the built-in UUID conversion")
+	})
+
+	t.Run("Invalid names", func(t *testing.T) {
+		assert.Error(t, rewriter.visitor.AddFunctionMappings(map[string]string{"app.calc": "calc"}))
+		assert.Error(t, rewriter.visitor.AddFunctionMappings(map[string]string{"calc": "calc(1)"}))
+		assert.Error(t, rewriter.visitor.AddFunctionMappings(map[string]string{"": "calc"}))
+	})
+}
@@ -0,0 +1,23 @@
+package sqlrewrite
+
+import (
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// SetGreatestLeastNulls sets whether GREATEST and LEAST return NULL when an argument
+// is NULL like MySQL, rather than skipping NULL arguments like PostgreSQL
+func (v *ASTVisitor) SetGreatestLeastNulls(enabled bool) {
+	v.nullGreatest = enabled
+}
+
+// guardGreatestLeast makes GREATEST and LEAST return NULL when an argument is NULL,
+// see nullIfAnyNull. Arguments are evaluated twice, which only matters for volatile
+// functions such as RAND()
+//
+//	GREATEST(a, b, 0) -> CASE WHEN a IS NOT NULL AND b IS NOT NULL THEN GREATEST(a, b, 0) END
+func (v *ASTVisitor) guardGreatestLeast(node *ast.FuncCallExpr) ast.ExprNode {
+	if !v.nullGreatest {
+		return node
+	}
+	return nullIfAnyNull(node.Args, node)
+}
@@ -0,0 +1,60 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGreatestLeastNulls(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Columns are checked, literals are not",
+			mysql:    "SELECT GREATEST(a, b, 0) FROM t",
+			expected: `SELECT CASE WHEN "a" IS NOT NULL AND "b" IS NOT NULL THEN GREATEST("a", "b", 0) END FROM "t"`,
+		},
+		{
+			name:     "NULL argument",
+			mysql:    "SELECT LEAST(1, NULL)",
+			expected: `SELECT CASE WHEN NULL IS NOT NULL THEN LEAST(1, NULL) END`,
+		},
+		{
+			name:     "Only literals",
+			mysql:    "SELECT GREATEST(1, 2.5)",
+			expected: `SELECT GREATEST(1, 2.5)`,
+		},
+		{
+			name:     "Placeholders keep their number",
+			mysql:    "SELECT id FROM t WHERE LEAST(a, ?) > ?",
+			expected: `SELECT "id" FROM "t" WHERE CASE WHEN "a" IS NOT NULL THEN LEAST("a", $1) END>$2`,
+		},
+		{
+			name:     "Rewritten arguments",
+			mysql:    "SELECT GREATEST(IFNULL(a, 0), b) AS m FROM t",
+			expected: `SELECT CASE WHEN COALESCE("a", 0) IS NOT NULL AND "b" IS NOT NULL THEN GREATEST(COALESCE("a", 0), "b") END AS "m" FROM "t"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("PostgreSQL semantics", func(t *testing.T) {
+		rewriter := NewASTRewriter()
+		rewriter.visitor.SetGreatestLeastNulls(false)
+		result, err := rewriter.Rewrite("SELECT GREATEST(a, NULL), LEAST(b, 1) FROM t")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT GREATEST("a", NULL),LEAST("b", 1) FROM "t"`, result)
+	})
+}
@@ -0,0 +1,313 @@
+package sqlrewrite
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+)
+
+// infoSchemaView emulates a MySQL information_schema table on top of the
+// PostgreSQL catalogs. PostgreSQL's information_schema uses lowercase column
+// names and lacks the MySQL-only columns (COLUMN_TYPE, COLUMN_KEY, ENGINE, ...),
+// and has no STATISTICS table at all
+type infoSchemaView struct {
+	name    string          // MySQL table name, which is also the default alias
+	columns map[string]bool // Lowercase MySQL column names
+	query   string          // PostgreSQL query producing the MySQL-named columns
+}
+
+func newInfoSchemaView(name, query string, columns ...string) *infoSchemaView {
+	view := &infoSchemaView{name: name, columns: make(map[string]bool), query: query}
+	for _, column := range columns {
+		view.columns[strings.ToLower(column)] = true
+	}
+	return view
+}
+
+// infoSchemaDataType maps PostgreSQL data types back to the MySQL names DDL was written with
+const infoSchemaDataType = `CASE c.data_type
+				WHEN 'integer' THEN 'int'
+				WHEN 'character varying' THEN 'varchar'
+				WHEN 'character' THEN 'char'
+				WHEN 'numeric' THEN 'decimal'
+				WHEN 'real' THEN 'float'
+				WHEN 'double precision' THEN 'double'
+				WHEN 'timestamp without time zone' THEN 'datetime'
+				WHEN 'timestamp with time zone' THEN 'timestamp'
+				WHEN 'time without time zone' THEN 'time'
+				WHEN 'boolean' THEN 'tinyint'
+				WHEN 'bytea' THEN 'blob'
+				WHEN 'jsonb' THEN 'json'
+				ELSE CAST(c.data_type AS TEXT)
+			END`
+
+// infoSchemaKeyConstraint tests whether a column is part of a constraint of the given type
+func infoSchemaKeyConstraint(constraintType string) string {
+	return `EXISTS (
+				SELECT 1 FROM information_schema.key_column_usage k
+				JOIN information_schema.table_constraints tc
+				  ON tc.constraint_schema = k.constraint_schema AND tc.constraint_name = k.constraint_name
+				WHERE k.table_schema = c.table_schema AND k.table_name = c.table_name
+				  AND k.column_name = c.column_name AND tc.constraint_type = '` + constraintType + `'
+			)`
+}
+
+var infoSchemaViews = map[string]*infoSchemaView{
+	"columns": newInfoSchemaView("COLUMNS", `
+		SELECT
+			CAST('def' AS TEXT) AS "TABLE_CATALOG",
+			CAST(c.table_schema AS TEXT) AS "TABLE_SCHEMA",
+			CAST(c.table_name AS TEXT) AS "TABLE_NAME",
+			CAST(c.column_name AS TEXT) AS "COLUMN_NAME",
+			CAST(c.ordinal_position AS BIGINT) AS "ORDINAL_POSITION",
+			CASE WHEN c.column_default LIKE 'nextval(%' THEN NULL ELSE CAST(c.column_default AS TEXT) END AS "COLUMN_DEFAULT",
+			CAST(c.is_nullable AS TEXT) AS "IS_NULLABLE",
+			d.data_type AS "DATA_TYPE",
+			CAST(c.character_maximum_length AS BIGINT) AS "CHARACTER_MAXIMUM_LENGTH",
+			CAST(c.character_octet_length AS BIGINT) AS "CHARACTER_OCTET_LENGTH",
+			CAST(c.numeric_precision AS BIGINT) AS "NUMERIC_PRECISION",
+			CAST(c.numeric_scale AS BIGINT) AS "NUMERIC_SCALE",
+			CAST(c.datetime_precision AS BIGINT) AS "DATETIME_PRECISION",
+			CASE WHEN c.character_octet_length IS NOT NULL THEN 'utf8mb4' END AS "CHARACTER_SET_NAME",
+			CASE WHEN c.character_octet_length IS NOT NULL THEN 'utf8mb4_0900_ai_ci' END AS "COLLATION_NAME",
+			CASE
+				WHEN c.character_maximum_length IS NOT NULL THEN d.data_type || '(' || c.character_maximum_length || ')'
+				WHEN d.data_type = 'decimal' AND c.numeric_precision IS NOT NULL THEN 'decimal(' || c.numeric_precision || ',' || c.numeric_scale || ')'
+				ELSE d.data_type
+			END AS "COLUMN_TYPE",
+			CASE
+				WHEN `+infoSchemaKeyConstraint("PRIMARY KEY")+` THEN 'PRI'
+				WHEN `+infoSchemaKeyConstraint("UNIQUE")+` THEN 'UNI'
+				ELSE ''
+			END AS "COLUMN_KEY",
+			CASE WHEN c.column_default LIKE 'nextval(%' OR c.is_identity = 'YES' THEN 'auto_increment' ELSE '' END AS "EXTRA",
+			CAST('select,insert,update,references' AS TEXT) AS "PRIVILEGES",
+			COALESCE(pg_catalog.col_description(a.attrelid, a.attnum), '') AS "COLUMN_COMMENT",
+			COALESCE(CAST(c.generation_expression AS TEXT), '') AS "GENERATION_EXPRESSION",
+			CAST(NULL AS BIGINT) AS "SRS_ID"
+		FROM information_schema.columns c
+		CROSS JOIN LATERAL (SELECT `+infoSchemaDataType+` AS data_type) d
+		LEFT JOIN pg_catalog.pg_namespace n ON n.nspname = c.table_schema
+		LEFT JOIN pg_catalog.pg_class t ON t.relnamespace = n.oid AND t.relname = c.table_name
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attname = c.column_name`,
+		"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "COLUMN_NAME", "ORDINAL_POSITION",
+		"COLUMN_DEFAULT", "IS_NULLABLE", "DATA_TYPE", "CHARACTER_MAXIMUM_LENGTH",
+		"CHARACTER_OCTET_LENGTH", "NUMERIC_PRECISION", "NUMERIC_SCALE", "DATETIME_PRECISION",
+		"CHARACTER_SET_NAME", "COLLATION_NAME", "COLUMN_TYPE", "COLUMN_KEY", "EXTRA",
+		"PRIVILEGES", "COLUMN_COMMENT", "GENERATION_EXPRESSION", "SRS_ID"),
+
+	"tables": newInfoSchemaView("TABLES", `
+		SELECT
+			CAST('def' AS TEXT) AS "TABLE_CATALOG",
+			CAST(it.table_schema AS TEXT) AS "TABLE_SCHEMA",
+			CAST(it.table_name AS TEXT) AS "TABLE_NAME",
+			CASE
+				WHEN it.table_schema IN ('pg_catalog', 'information_schema') THEN 'SYSTEM VIEW'
+				ELSE CAST(it.table_type AS TEXT)
+			END AS "TABLE_TYPE",
+			CASE WHEN it.table_type = 'BASE TABLE' THEN 'InnoDB' END AS "ENGINE",
+			CAST(10 AS BIGINT) AS "VERSION",
+			CAST('Dynamic' AS TEXT) AS "ROW_FORMAT",
+			CAST(GREATEST(t.reltuples, 0) AS BIGINT) AS "TABLE_ROWS",
+			CAST(0 AS BIGINT) AS "AVG_ROW_LENGTH",
+			pg_catalog.pg_relation_size(t.oid) AS "DATA_LENGTH",
+			CAST(0 AS BIGINT) AS "MAX_DATA_LENGTH",
+			pg_catalog.pg_indexes_size(t.oid) AS "INDEX_LENGTH",
+			CAST(0 AS BIGINT) AS "DATA_FREE",
+			CAST(NULL AS BIGINT) AS "AUTO_INCREMENT",
+			CAST(NULL AS TIMESTAMP) AS "CREATE_TIME",
+			CAST(NULL AS TIMESTAMP) AS "UPDATE_TIME",
+			CAST(NULL AS TIMESTAMP) AS "CHECK_TIME",
+			CAST('utf8mb4_0900_ai_ci' AS TEXT) AS "TABLE_COLLATION",
+			CAST(NULL AS BIGINT) AS "CHECKSUM",
+			CAST('' AS TEXT) AS "CREATE_OPTIONS",
+			COALESCE(pg_catalog.obj_description(t.oid, 'pg_class'), '') AS "TABLE_COMMENT"
+		FROM information_schema.tables it
+		LEFT JOIN pg_catalog.pg_namespace n ON n.nspname = it.table_schema
+		LEFT JOIN pg_catalog.pg_class t ON t.relnamespace = n.oid AND t.relname = it.table_name`,
+		"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE", "ENGINE", "VERSION",
+		"ROW_FORMAT", "TABLE_ROWS", "AVG_ROW_LENGTH", "DATA_LENGTH", "MAX_DATA_LENGTH",
+		"INDEX_LENGTH", "DATA_FREE", "AUTO_INCREMENT", "CREATE_TIME", "UPDATE_TIME",
+		"CHECK_TIME", "TABLE_COLLATION", "CHECKSUM", "CREATE_OPTIONS", "TABLE_COMMENT"),
+
+	"statistics": newInfoSchemaView("STATISTICS", `
+		SELECT
+			CAST('def' AS TEXT) AS "TABLE_CATALOG",
+			CAST(n.nspname AS TEXT) AS "TABLE_SCHEMA",
+			CAST(t.relname AS TEXT) AS "TABLE_NAME",
+			CASE WHEN ix.indisunique THEN 0 ELSE 1 END AS "NON_UNIQUE",
+			CAST(n.nspname AS TEXT) AS "INDEX_SCHEMA",
+			CASE WHEN ix.indisprimary THEN 'PRIMARY' ELSE CAST(i.relname AS TEXT) END AS "INDEX_NAME",
+			k.ord AS "SEQ_IN_INDEX",
+			CAST(a.attname AS TEXT) AS "COLUMN_NAME",
+			CAST('A' AS TEXT) AS "COLLATION",
+			CAST(GREATEST(t.reltuples, 0) AS BIGINT) AS "CARDINALITY",
+			CAST(NULL AS BIGINT) AS "SUB_PART",
+			CAST(NULL AS TEXT) AS "PACKED",
+			CASE WHEN a.attnotnull THEN '' ELSE 'YES' END AS "NULLABLE",
+			UPPER(CAST(am.amname AS TEXT)) AS "INDEX_TYPE",
+			CAST('' AS TEXT) AS "COMMENT",
+			COALESCE(pg_catalog.obj_description(i.oid, 'pg_class'), '') AS "INDEX_COMMENT",
+			CAST('YES' AS TEXT) AS "IS_VISIBLE",
+			CASE WHEN k.attnum = 0 THEN pg_catalog.pg_get_indexdef(i.oid, CAST(k.ord AS INT), true) END AS "EXPRESSION"
+		FROM pg_catalog.pg_index ix
+		JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_catalog.pg_class t ON t.oid = ix.indrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_catalog.pg_am am ON am.oid = i.relam
+		CROSS JOIN LATERAL unnest(CAST(ix.indkey AS INT2[])) WITH ORDINALITY AS k(attnum, ord)
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE k.ord <= ix.indnkeyatts`,
+		"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "NON_UNIQUE", "INDEX_SCHEMA",
+		"INDEX_NAME", "SEQ_IN_INDEX", "COLUMN_NAME", "COLLATION", "CARDINALITY", "SUB_PART",
+		"PACKED", "NULLABLE", "INDEX_TYPE", "COMMENT", "INDEX_COMMENT", "IS_VISIBLE", "EXPRESSION"),
+}
+
+// infoSchemaMarker stands in for an emulating query until post-processing is
+// done, so the type-name replacements there leave its literals alone
+func infoSchemaMarker(view *infoSchemaView) string {
+	return "/*aproxy:information_schema." + view.name + "*/"
+}
+
+// expandInfoSchemaViews replaces the markers left by pgInfoSchemaTable with the emulating queries
+func expandInfoSchemaViews(sql string) string {
+	if !strings.Contains(sql, "/*aproxy:information_schema.") {
+		return sql
+	}
+	for _, view := range infoSchemaViews {
+		sql = strings.ReplaceAll(sql, infoSchemaMarker(view), view.query+"\n\t")
+	}
+	return sql
+}
+
+// pgInfoSchemaTable replaces information_schema.<table> in a FROM clause with
+// the emulating query, restored as a derived table
+type pgInfoSchemaTable struct {
+	*ast.TableName
+	view *infoSchemaView
+}
+
+// Restore implements ast.Node interface
+func (n *pgInfoSchemaTable) Restore(ctx *format.RestoreCtx) error {
+	ctx.WritePlain("(" + infoSchemaMarker(n.view) + ")")
+	return nil
+}
+
+// Accept implements ast.Node interface, the emulating query has nothing to visit
+func (n *pgInfoSchemaTable) Accept(v ast.Visitor) (ast.Node, bool) {
+	return n, true
+}
+
+// rewriteInformationSchema swaps emulated information_schema tables in the FROM
+// clause for their PostgreSQL queries. Column references to them are switched to
+// the uppercase names the emulation produces, as MySQL 8 matches them case-insensitively.
+// Unqualified columns are taken to be theirs only when the FROM clause has no other
+// tables, and only in this SELECT: nested queries have their own FROM clause
+//
+//	SELECT column_name FROM information_schema.columns WHERE table_name = 't'
+//	-> SELECT "COLUMN_NAME" FROM (SELECT ... FROM information_schema.columns c ...) AS "COLUMNS" WHERE "TABLE_NAME" = 't'
+func (v *ASTVisitor) rewriteInformationSchema(node *ast.SelectStmt) {
+	if node.From == nil {
+		return
+	}
+
+	sources := make(map[string]*infoSchemaView) // Lowercase qualifier -> view
+	others := false                             // Other tables or derived tables in FROM
+	var walk func(ast.ResultSetNode)
+	walk = func(n ast.ResultSetNode) {
+		switch n := n.(type) {
+		case *ast.Join:
+			if n.Left != nil {
+				walk(n.Left)
+			}
+			if n.Right != nil {
+				walk(n.Right)
+			}
+		case *ast.TableSource:
+			name, ok := n.Source.(*ast.TableName)
+			if !ok || name.Schema.L != "information_schema" {
+				others = true
+				return
+			}
+			view, ok := infoSchemaViews[name.Name.L]
+			if !ok {
+				others = true
+				return
+			}
+			if n.AsName.L == "" {
+				n.AsName = ast.NewCIStr(view.name)
+			}
+			n.Source = &pgInfoSchemaTable{TableName: name, view: view}
+			sources[n.AsName.L] = view
+		}
+	}
+	walk(node.From.TableRefs)
+	if len(sources) == 0 {
+		return
+	}
+
+	node.Accept(&infoSchemaColumnVisitor{root: node, sources: sources, unqualified: !others})
+}
+
+// infoSchemaColumnVisitor renames column references to emulated information_schema tables
+type infoSchemaColumnVisitor struct {
+	root        ast.Node
+	sources     map[string]*infoSchemaView
+	unqualified bool // Unqualified columns of the root SELECT belong to the views
+	nested      int  // Depth in nested queries, whose unqualified columns are their own
+}
+
+// Enter implements ast.Visitor interface
+func (v *infoSchemaColumnVisitor) Enter(n ast.Node) (ast.Node, bool) {
+	switch n.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		if n != v.root {
+			v.nested++
+		}
+		return n, false
+	}
+	col, ok := n.(*ast.ColumnName)
+	if !ok {
+		return n, false
+	}
+
+	if col.Table.L != "" {
+		view, ok := v.sources[col.Table.L]
+		if !ok || (col.Schema.L != "" && col.Schema.L != "information_schema") {
+			return n, true
+		}
+		if !view.columns[col.Name.L] {
+			return n, true
+		}
+		// information_schema.COLUMNS.x refers to the derived table, which has no schema
+		col.Schema = ast.NewCIStr("")
+		if col.Table.L == strings.ToLower(view.name) {
+			col.Table = ast.NewCIStr(view.name)
+		}
+		col.Name = ast.NewCIStr(strings.ToUpper(col.Name.O))
+		return n, true
+	}
+
+	if !v.unqualified || v.nested > 0 {
+		return n, true
+	}
+	for _, view := range v.sources {
+		if view.columns[col.Name.L] {
+			col.Name = ast.NewCIStr(strings.ToUpper(col.Name.O))
+			break
+		}
+	}
+	return n, true
+}
+
+// Leave implements ast.Visitor interface
+func (v *infoSchemaColumnVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	switch n.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		if n != v.root {
+			v.nested--
+		}
+	}
+	return n, true
+}
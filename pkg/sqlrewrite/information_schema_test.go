@@ -0,0 +1,93 @@
+package sqlrewrite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteInformationSchema(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	// outer strips the emulating query to compare the statement around it
+	outer := func(sql string, view *infoSchemaView) string {
+		require.Contains(t, sql, view.query)
+		return strings.Replace(sql, view.query+"\n\t", "...", 1)
+	}
+
+	tests := []struct {
+		name     string
+		mysql    string
+		view     *infoSchemaView
+		expected string
+	}{
+		{
+			name:     "COLUMNS for a table",
+			mysql:    "SELECT column_name, DATA_TYPE, column_key FROM information_schema.COLUMNS WHERE table_schema = 'shop' AND table_name = 'users' ORDER BY ordinal_position",
+			view:     infoSchemaViews["columns"],
+			expected: `SELECT "COLUMN_NAME","DATA_TYPE","COLUMN_KEY" FROM (...) AS "COLUMNS" WHERE "TABLE_SCHEMA"='shop' AND "TABLE_NAME"='users' ORDER BY "ORDINAL_POSITION"`,
+		},
+		{
+			name:     "Alias and qualified columns",
+			mysql:    "SELECT c.column_name FROM INFORMATION_SCHEMA.columns AS c WHERE c.table_name = 'users'",
+			view:     infoSchemaViews["columns"],
+			expected: `SELECT "c"."COLUMN_NAME" FROM (...) AS "c" WHERE "c"."TABLE_NAME"='users'`,
+		},
+		{
+			name:     "Columns qualified by the table name",
+			mysql:    "SELECT tables.table_name FROM information_schema.tables WHERE information_schema.tables.table_type = 'BASE TABLE'",
+			view:     infoSchemaViews["tables"],
+			expected: `SELECT "TABLES"."TABLE_NAME" FROM (...) AS "TABLES" WHERE "TABLES"."TABLE_TYPE"='BASE TABLE'`,
+		},
+		{
+			name:     "STATISTICS",
+			mysql:    "SELECT index_name, seq_in_index, column_name FROM information_schema.statistics WHERE table_name = 'users' AND non_unique = 0",
+			view:     infoSchemaViews["statistics"],
+			expected: `SELECT "INDEX_NAME","SEQ_IN_INDEX","COLUMN_NAME" FROM (...) AS "STATISTICS" WHERE "TABLE_NAME"='users' AND "NON_UNIQUE"=0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, outer(result, tt.view))
+		})
+	}
+
+	t.Run("Type names are not post-processed", func(t *testing.T) {
+		result, err := rewriter.Rewrite("SELECT * FROM information_schema.columns")
+		require.NoError(t, err)
+		assert.Contains(t, result, "THEN 'double'")
+		assert.Contains(t, result, "THEN 'json'")
+	})
+
+	t.Run("Other information_schema tables pass through", func(t *testing.T) {
+		result, err := rewriter.Rewrite("SELECT constraint_name FROM information_schema.table_constraints")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "constraint_name" FROM "information_schema"."table_constraints"`, result)
+	})
+
+	t.Run("User tables keep their column names", func(t *testing.T) {
+		result, err := rewriter.Rewrite("SELECT u.table_name FROM users u JOIN information_schema.tables t ON t.table_name = u.table_name")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "u"."table_name" FROM "users" AS "u" JOIN (...) AS "t" ON "t"."TABLE_NAME"="u"."table_name"`,
+			outer(result, infoSchemaViews["tables"]))
+	})
+
+	t.Run("Unqualified columns next to other tables are kept", func(t *testing.T) {
+		result, err := rewriter.Rewrite("SELECT table_name, t.table_type FROM audit_log JOIN information_schema.tables t ON t.table_name = audit_log.name")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "table_name","t"."TABLE_TYPE" FROM "audit_log" JOIN (...) AS "t" ON "t"."TABLE_NAME"="audit_log"."name"`,
+			outer(result, infoSchemaViews["tables"]))
+	})
+
+	t.Run("Subqueries resolve their own columns", func(t *testing.T) {
+		result, err := rewriter.Rewrite("SELECT table_name FROM information_schema.tables WHERE table_name IN (SELECT table_name FROM audit_log) AND EXISTS (SELECT 1 FROM audit_log a WHERE a.name = tables.table_name)")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "TABLE_NAME" FROM (...) AS "TABLES" WHERE "TABLE_NAME" IN (SELECT "table_name" FROM "audit_log") AND EXISTS (SELECT 1 FROM "audit_log" AS "a" WHERE "a"."name"="TABLES"."TABLE_NAME")`,
+			outer(result, infoSchemaViews["tables"]))
+	})
+}
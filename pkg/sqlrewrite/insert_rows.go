@@ -0,0 +1,55 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+)
+
+// singleRowInsert reports whether stmt is a plain INSERT ... VALUES of one row. Such
+// an INSERT always affects exactly one row, so several of them can be sent as one
+// multi-row INSERT without changing what each reports, see RewriteInsertRows
+func singleRowInsert(stmt ast.StmtNode) bool {
+	insert, ok := stmt.(*ast.InsertStmt)
+	return ok && !insert.IsReplace && !insert.IgnoreErr && insert.Select == nil &&
+		len(insert.OnDuplicate) == 0 && len(insert.Lists) == 1
+}
+
+// RewriteInsertRows rewrites a prepared single-row INSERT repeated rows times, every
+// row with its own placeholders, numbered row after row. @name references resolve
+// against userVars as in RewritePreparedStatement
+//
+//	INSERT INTO t (a, b) VALUES (?, ?), 3 -> INSERT INTO "t" ("a","b") VALUES ($1,$2),($3,$4),($5,$6)
+func (r *Rewriter) RewriteInsertRows(sql string, rows int, userVars map[string]interface{}) (string, error) {
+	r.astRewriter.parserMu.Lock()
+	stmts, _, err := r.astRewriter.parser.Parse(sql, "", "")
+	stmts = append([]ast.StmtNode(nil), stmts...)
+	r.astRewriter.parserMu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	if len(stmts) != 1 || !singleRowInsert(stmts[0]) {
+		return "", fmt.Errorf("not a single-row INSERT: %s", sql)
+	}
+
+	insert := stmts[0].(*ast.InsertStmt)
+	insert.Setlist = false // INSERT ... SET restores a single row only
+	row := insert.Lists[0]
+	insert.Lists = make([][]ast.ExprNode, rows)
+	for i := range insert.Lists {
+		insert.Lists[i] = row
+	}
+
+	// Parsing the restored statement again gives every row its own placeholder nodes
+	var sb strings.Builder
+	if err := insert.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return "", fmt.Errorf("failed to restore SQL: %w", err)
+	}
+	stmt, _, err := r.RewritePreparedStatement(sb.String(), userVars)
+	if err != nil {
+		return "", err
+	}
+	return stmt.SQL, nil
+}
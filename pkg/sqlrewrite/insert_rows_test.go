@@ -0,0 +1,99 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteInsertRows(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	sql, err := rewriter.RewriteInsertRows("INSERT INTO t (a, b) VALUES (?, ?)", 3, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "t" ("a","b") VALUES ($1,$2),($3,$4),($5,$6)`, sql)
+
+	t.Run("Rows are rewritten like the statement", func(t *testing.T) {
+		sql, err := rewriter.RewriteInsertRows("INSERT INTO `logs` (msg, at) VALUES (?, NOW())", 2, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "logs" ("msg","at") VALUES ($1,CURRENT_TIMESTAMP),($2,CURRENT_TIMESTAMP)`, sql)
+	})
+
+	t.Run("INSERT ... SET", func(t *testing.T) {
+		sql, err := rewriter.RewriteInsertRows("INSERT INTO t SET a = ?, b = ?", 2, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "t" ("a","b") VALUES ($1,$2),($3,$4)`, sql)
+	})
+
+	t.Run("Only single-row INSERTs", func(t *testing.T) {
+		for _, sql := range []string{
+			"INSERT INTO t (a) VALUES (?), (?)",
+			"INSERT IGNORE INTO t (a) VALUES (?)",
+			"INSERT INTO t (a) VALUES (?) ON DUPLICATE KEY UPDATE a = VALUES(a)",
+			"REPLACE INTO t (a) VALUES (?)",
+			"INSERT INTO t (a) SELECT ?",
+			"UPDATE t SET a = ?",
+		} {
+			_, err := rewriter.RewriteInsertRows(sql, 2, nil)
+			assert.Error(t, err, sql)
+		}
+	})
+
+	t.Run("SingleRowInsert", func(t *testing.T) {
+		for sql, single := range map[string]bool{
+			"INSERT INTO t (a) VALUES (?)":              true,
+			"INSERT INTO t VALUES (1, 'x')":             true,
+			"INSERT INTO t (a) VALUES (?), (?)":         false,
+			"INSERT IGNORE INTO t (a) VALUES (?)":       false,
+			"INSERT INTO t SET a = ?":                   true,
+			"INSERT INTO t (a) VALUES (?) RETURNING id": true,
+			"SELECT 1": false,
+			"INSERT INTO t (a) VALUES (?) ON DUPLICATE KEY UPDATE a = 1": false,
+		} {
+			stmt, _, err := rewriter.RewritePreparedStatement(sql, nil)
+			require.NoError(t, err, sql)
+			assert.Equal(t, single, stmt.SingleRowInsert, sql)
+		}
+	})
+}
+
+func TestInsertSet(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE items (id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(20), qty INT)")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Assignments become a column list and a row",
+			mysql:    "INSERT INTO items SET name = 'a', qty = 2",
+			expected: `INSERT INTO "items" ("name","qty") VALUES ('a',2)`,
+		},
+		{
+			name:     "Values are rewritten",
+			mysql:    "INSERT INTO items SET name = IFNULL(NULL, 'x'), qty = ?",
+			expected: `INSERT INTO "items" ("name","qty") VALUES (COALESCE(NULL, 'x'),$1)`,
+		},
+		{
+			name:     "NULL and DEFAULT ids are generated",
+			mysql:    "INSERT INTO items SET id = NULL, name = 'a', qty = DEFAULT",
+			expected: `INSERT INTO "items" ("id","name","qty") VALUES (DEFAULT,'a',DEFAULT)`,
+		},
+		{
+			name:     "ON DUPLICATE KEY UPDATE",
+			mysql:    "INSERT INTO items SET id = 1, qty = 1 ON DUPLICATE KEY UPDATE qty = qty + VALUES(qty)",
+			expected: `INSERT INTO "items" ("id","qty") VALUES (1,1) ON CONFLICT ("id") DO UPDATE SET "qty"="items"."qty"+"excluded"."qty"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package sqlrewrite
+
+import (
+	"math"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// limitOffsetFirstMarker precedes the count of "LIMIT ?, ?" in generated SQL. The
+// placeholders are numbered in the order the client binds them, offset first, while
+// PostgreSQL needs the count first. convertPlaceholders numbers the pair accordingly
+const limitOffsetFirstMarker = "/*aproxy:limit_offset_first*/"
+
+// mysqlLimitAll is the row count MySQL documents for "all rows up to the end",
+// PostgreSQL's BIGINT limit cannot hold it
+const mysqlLimitAll = "18446744073709551615"
+
+// visitLimit moves a LIMIT offset after the count
+// MySQL: LIMIT offset, count
+// PostgreSQL: LIMIT count OFFSET offset
+// LIMIT count OFFSET offset parses to the same node and renders unchanged
+func (v *ASTVisitor) visitLimit(node *ast.Limit) (ast.Node, bool) {
+	if node.Count == nil || (node.Offset == nil && !isLimitAll(node.Count)) {
+		return node, true
+	}
+
+	limit := &pgLimitExpr{ExprNode: node.Count, Offset: node.Offset}
+	count, countIsParam := node.Count.(*driver.ParamMarkerExpr)
+	offset, offsetIsParam := node.Offset.(*driver.ParamMarkerExpr)
+	limit.offsetFirst = countIsParam && offsetIsParam && offset.Offset < count.Offset
+
+	node.Count, node.Offset = limit, nil
+	return node, true
+}
+
+// isLimitAll reports whether a LIMIT count is too large for PostgreSQL and means all rows
+func isLimitAll(expr ast.ExprNode) bool {
+	value, ok := expr.(*driver.ValueExpr)
+	return ok && value.Kind() == driver.KindUint64 && value.GetUint64() > math.MaxInt64
+}
+
+// normalizeLimitAll turns PostgreSQL's LIMIT ALL, which the MySQL parser rejects, into
+// MySQL's all-rows count. The AST renders that count as LIMIT ALL again
+func normalizeLimitAll(sql string) string {
+	upper := strings.ToUpper(sql)
+	if !strings.Contains(upper, "ALL") {
+		return sql
+	}
+
+	var sb strings.Builder
+	var quote byte
+	last := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			continue
+		}
+
+		if !strings.HasPrefix(upper[i:], "LIMIT") || (i > 0 && isIdentChar(sql[i-1])) {
+			continue
+		}
+		j := i + len("LIMIT")
+		k := j
+		for k < len(sql) && (sql[k] == ' ' || sql[k] == '\t' || sql[k] == '\n' || sql[k] == '\r') {
+			k++
+		}
+		if k == j || !strings.HasPrefix(upper[k:], "ALL") || (k+3 < len(sql) && isIdentChar(sql[k+3])) {
+			continue
+		}
+		sb.WriteString(sql[last:k])
+		sb.WriteString(mysqlLimitAll)
+		last = k + 3
+		i = last - 1
+	}
+	if last == 0 {
+		return sql
+	}
+	sb.WriteString(sql[last:])
+	return sb.String()
+}
+
+// isIdentChar reports whether c can be part of an unquoted identifier
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,107 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteLimit(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Comma form",
+			mysql:    "SELECT * FROM t LIMIT 5, 10",
+			expected: `SELECT * FROM "t" LIMIT 10 OFFSET 5`,
+		},
+		{
+			name:     "Native OFFSET form",
+			mysql:    "SELECT * FROM t LIMIT 10 OFFSET 5",
+			expected: `SELECT * FROM "t" LIMIT 10 OFFSET 5`,
+		},
+		{
+			name:     "LIMIT ALL",
+			mysql:    "SELECT * FROM t ORDER BY id LIMIT ALL",
+			expected: `SELECT * FROM "t" ORDER BY "id" LIMIT ALL`,
+		},
+		{
+			name:     "MySQL all-rows count",
+			mysql:    "SELECT * FROM t LIMIT 5, 18446744073709551615",
+			expected: `SELECT * FROM "t" LIMIT ALL OFFSET 5`,
+		},
+		{
+			name:     "Native form inside, comma form outside",
+			mysql:    "SELECT * FROM (SELECT * FROM t ORDER BY id LIMIT 10 OFFSET 5) AS x LIMIT 2, 3",
+			expected: `SELECT * FROM (SELECT * FROM "t" ORDER BY "id" LIMIT 10 OFFSET 5) AS "x" LIMIT 3 OFFSET 2`,
+		},
+		{
+			name:     "Comma form inside, native form outside",
+			mysql:    "SELECT * FROM t WHERE id IN (SELECT id FROM u LIMIT 1, 2) LIMIT 4 OFFSET 1",
+			expected: `SELECT * FROM "t" WHERE "id" IN (SELECT "id" FROM "u" LIMIT 2 OFFSET 1) LIMIT 4 OFFSET 1`,
+		},
+		{
+			name:     "LIMIT ALL next to a comma form",
+			mysql:    "SELECT * FROM (SELECT * FROM t LIMIT ALL OFFSET 3) AS x LIMIT 1, 2",
+			expected: `SELECT * FROM (SELECT * FROM "t" LIMIT ALL OFFSET 3) AS "x" LIMIT 2 OFFSET 1`,
+		},
+		{
+			name:     "Correlated scalar subquery in the select list",
+			mysql:    "SELECT id, (SELECT total FROM orders o WHERE o.user_id = u.id ORDER BY created_at DESC LIMIT 1) AS last_total FROM users u",
+			expected: `SELECT "id",(SELECT "total" FROM "orders" AS "o" WHERE "o"."user_id"="u"."id" ORDER BY "created_at" DESC LIMIT 1) AS "last_total" FROM "users" AS "u"`,
+		},
+		{
+			name:     "Comma form in a correlated scalar subquery",
+			mysql:    "SELECT id, (SELECT total FROM orders o WHERE o.user_id = u.id ORDER BY total LIMIT 1, 1) FROM users u LIMIT 2, 5",
+			expected: `SELECT "id",(SELECT "total" FROM "orders" AS "o" WHERE "o"."user_id"="u"."id" ORDER BY "total" LIMIT 1 OFFSET 1) FROM "users" AS "u" LIMIT 5 OFFSET 2`,
+		},
+		{
+			name:     "Scalar subquery compared in WHERE",
+			mysql:    "SELECT * FROM users u WHERE u.score > (SELECT score FROM users v WHERE v.team = u.team ORDER BY score LIMIT 1)",
+			expected: `SELECT * FROM "users" AS "u" WHERE "u"."score">(SELECT "score" FROM "users" AS "v" WHERE "v"."team"="u"."team" ORDER BY "score" LIMIT 1)`,
+		},
+		{
+			name:     "UNION",
+			mysql:    "(SELECT a FROM t LIMIT 1, 2) UNION (SELECT a FROM u LIMIT 3 OFFSET 4) LIMIT 5, 6",
+			expected: `(SELECT "a" FROM "t" LIMIT 2 OFFSET 1) UNION (SELECT "a" FROM "u" LIMIT 3 OFFSET 4) LIMIT 6 OFFSET 5`,
+		},
+		{
+			name:     "String literals are left alone",
+			mysql:    "SELECT * FROM t WHERE note = 'LIMIT 5, 10' OR note = 'limit all' LIMIT 3",
+			expected: `SELECT * FROM "t" WHERE "note"='LIMIT 5, 10' OR "note"='limit all' LIMIT 3`,
+		},
+		{
+			name:     "Placeholders in the comma form keep their binding order",
+			mysql:    "SELECT * FROM t WHERE a = ? LIMIT ?, ?",
+			expected: `SELECT * FROM "t" WHERE "a"=$1 LIMIT $3 OFFSET $2`,
+		},
+		{
+			name:     "Placeholders in the native form",
+			mysql:    "SELECT * FROM t WHERE a = ? LIMIT ? OFFSET ?",
+			expected: `SELECT * FROM "t" WHERE "a"=$1 LIMIT $2 OFFSET $3`,
+		},
+		{
+			name:     "Placeholders in a scalar subquery",
+			mysql:    "SELECT id, (SELECT total FROM orders o WHERE o.user_id = u.id LIMIT ?, ?) FROM users u WHERE id > ?",
+			expected: `SELECT "id",(SELECT "total" FROM "orders" AS "o" WHERE "o"."user_id"="u"."id" LIMIT $2 OFFSET $1) FROM "users" AS "u" WHERE "id">$3`,
+		},
+		{
+			name:     "Placeholders after a comma form",
+			mysql:    "SELECT * FROM (SELECT * FROM t LIMIT ?, ?) AS x WHERE b = ? LIMIT ?",
+			expected: `SELECT * FROM (SELECT * FROM "t" LIMIT $2 OFFSET $1) AS "x" WHERE "b"=$3 LIMIT $4`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// LoadData is a parsed LOAD DATA statement, with MySQL's defaults filled in
+type LoadData struct {
+	Local   bool
+	Path    string
+	Schema  string
+	Table   string
+	Columns []string // Empty means all columns in table order
+
+	FieldsTerminated string
+	FieldsEnclosed   string
+	FieldsEscaped    string
+	LinesStarting    string
+	LinesTerminated  string
+	IgnoreLines      uint64
+}
+
+// IsLoadDataStatement reports whether sql is a LOAD DATA statement
+func IsLoadDataStatement(sql string) bool {
+	fields := strings.Fields(strings.ToUpper(sql))
+	return len(fields) >= 2 && fields[0] == "LOAD" && fields[1] == "DATA"
+}
+
+// ParseLoadData parses a LOAD DATA statement. Options COPY cannot express
+// (REPLACE, column assignments, user variables in the column list, FORMAT,
+// fixed-width fields) are rejected as unsupported
+func ParseLoadData(sql string) (*LoadData, error) {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	if err != nil {
+		return nil, &RewriteError{Reason: ReasonParse, Feature: "LOAD DATA INFILE", Err: fmt.Errorf("failed to parse SQL: %w", err)}
+	}
+	node, ok := stmt.(*ast.LoadDataStmt)
+	if !ok {
+		return nil, fmt.Errorf("not a LOAD DATA statement: %s", sql)
+	}
+
+	unsupported := func(format string, args ...interface{}) error {
+		return &RewriteError{Reason: ReasonUnsupported, Feature: "LOAD DATA INFILE", Err: fmt.Errorf(format, args...)}
+	}
+	switch {
+	case node.OnDuplicate == ast.OnDuplicateKeyHandlingReplace:
+		return nil, unsupported("LOAD DATA ... REPLACE is not supported")
+	case len(node.ColumnAssignments) > 0:
+		return nil, unsupported("LOAD DATA ... SET is not supported")
+	case node.Format != nil:
+		return nil, unsupported("LOAD DATA ... FORMAT is not supported")
+	}
+
+	ld := &LoadData{
+		Local:            node.FileLocRef == ast.FileLocClient,
+		Path:             node.Path,
+		Schema:           node.Table.Schema.O,
+		Table:            node.Table.Name.O,
+		FieldsTerminated: "\t",
+		FieldsEscaped:    "\\",
+		LinesTerminated:  "\n",
+	}
+	for _, c := range node.ColumnsAndUserVars {
+		if c.ColumnName == nil {
+			return nil, unsupported("user variables in the LOAD DATA column list are not supported")
+		}
+		ld.Columns = append(ld.Columns, c.ColumnName.Name.O)
+	}
+	if f := node.FieldsInfo; f != nil {
+		if f.Terminated != nil {
+			ld.FieldsTerminated = *f.Terminated
+		}
+		if f.Enclosed != nil {
+			ld.FieldsEnclosed = *f.Enclosed
+		}
+		if f.Escaped != nil {
+			ld.FieldsEscaped = *f.Escaped
+		}
+		if f.DefinedNullBy != nil {
+			return nil, unsupported("LOAD DATA ... DEFINED NULL BY is not supported")
+		}
+	}
+	if l := node.LinesInfo; l != nil {
+		if l.Starting != nil {
+			ld.LinesStarting = *l.Starting
+		}
+		if l.Terminated != nil {
+			ld.LinesTerminated = *l.Terminated
+		}
+	}
+	if ld.FieldsTerminated == "" || ld.LinesTerminated == "" {
+		return nil, unsupported("fixed-width LOAD DATA (empty terminators) is not supported")
+	}
+	if node.IgnoreLines != nil {
+		ld.IgnoreLines = *node.IgnoreLines
+	}
+	return ld, nil
+}
+
+// CopySQL returns the PostgreSQL COPY statement the rows are streamed into
+func (ld *LoadData) CopySQL() string {
+	var b strings.Builder
+	b.WriteString("COPY ")
+	if ld.Schema != "" {
+		b.WriteString(quoteIdent(ld.Schema) + ".")
+	}
+	b.WriteString(quoteIdent(ld.Table))
+	if len(ld.Columns) > 0 {
+		quoted := make([]string, len(ld.Columns))
+		for i, c := range ld.Columns {
+			quoted[i] = quoteIdent(c)
+		}
+		b.WriteString(" (" + strings.Join(quoted, ", ") + ")")
+	}
+	b.WriteString(" FROM STDIN")
+	return b.String()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
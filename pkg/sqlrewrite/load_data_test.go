@@ -0,0 +1,64 @@
+package sqlrewrite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLoadDataStatement(t *testing.T) {
+	assert.True(t, IsLoadDataStatement("LOAD DATA LOCAL INFILE 'x' INTO TABLE t"))
+	assert.True(t, IsLoadDataStatement("load  data infile 'x' into table t"))
+	assert.False(t, IsLoadDataStatement("LOAD INDEX INTO CACHE t"))
+	assert.False(t, IsLoadDataStatement("SELECT 'LOAD DATA'"))
+}
+
+func TestParseLoadData(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		ld, err := ParseLoadData("LOAD DATA LOCAL INFILE 'users.tsv' INTO TABLE users")
+		require.NoError(t, err)
+		assert.Equal(t, &LoadData{
+			Local:            true,
+			Path:             "users.tsv",
+			Table:            "users",
+			FieldsTerminated: "\t",
+			FieldsEscaped:    "\\",
+			LinesTerminated:  "\n",
+		}, ld)
+		assert.Equal(t, `COPY "users" FROM STDIN`, ld.CopySQL())
+	})
+
+	t.Run("CSV options and column list", func(t *testing.T) {
+		ld, err := ParseLoadData(`LOAD DATA LOCAL INFILE '/tmp/u.csv' INTO TABLE shop.users
+			FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '"'
+			LINES TERMINATED BY '\r\n' IGNORE 1 LINES (id, name)`)
+		require.NoError(t, err)
+		assert.Equal(t, ",", ld.FieldsTerminated)
+		assert.Equal(t, `"`, ld.FieldsEnclosed)
+		assert.Equal(t, "\r\n", ld.LinesTerminated)
+		assert.Equal(t, uint64(1), ld.IgnoreLines)
+		assert.Equal(t, `COPY "shop"."users" ("id", "name") FROM STDIN`, ld.CopySQL())
+	})
+
+	t.Run("Server-side file", func(t *testing.T) {
+		ld, err := ParseLoadData("LOAD DATA INFILE '/etc/passwd' INTO TABLE t")
+		require.NoError(t, err)
+		assert.False(t, ld.Local)
+	})
+
+	for _, sql := range []string{
+		"LOAD DATA LOCAL INFILE 'x' REPLACE INTO TABLE t",
+		"LOAD DATA LOCAL INFILE 'x' INTO TABLE t (a, @b) SET c = @b",
+		"LOAD DATA LOCAL INFILE 'x' INTO TABLE t (a, @b)",
+		"LOAD DATA LOCAL INFILE 'x' INTO TABLE t FIELDS TERMINATED BY ''",
+	} {
+		t.Run("Unsupported: "+sql, func(t *testing.T) {
+			_, err := ParseLoadData(sql)
+			var rewriteErr *RewriteError
+			require.True(t, errors.As(err, &rewriteErr))
+			assert.Equal(t, ReasonUnsupported, rewriteErr.Reason)
+		})
+	}
+}
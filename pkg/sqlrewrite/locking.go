@@ -0,0 +1,58 @@
+package sqlrewrite
+
+import "strings"
+
+// normalizeShareLock turns LOCK IN SHARE MODE into FOR SHARE before parsing. The
+// parser reads LOCK IN SHARE MODE without options only, MySQL 8 also takes NOWAIT
+// and SKIP LOCKED after it. The lock stays on the query block it was written on,
+// subqueries and UNION arms keep their own
+//
+//	SELECT ... LOCK IN SHARE MODE NOWAIT -> SELECT ... FOR SHARE NOWAIT
+//
+// Quoted strings and identifiers are left alone
+func normalizeShareLock(sql string) string {
+	if !strings.Contains(strings.ToUpper(sql), "SHARE") {
+		return sql
+	}
+
+	var sb strings.Builder
+	last := 0
+	for i := 0; i < len(sql); i++ {
+		if c := sql[i]; c == '\'' || c == '"' || c == '`' {
+			i = skipQuoted(sql, i) - 1
+			continue
+		}
+		end, ok := matchKeywords(sql, i, "LOCK", "IN", "SHARE", "MODE")
+		if !ok {
+			continue
+		}
+		sb.WriteString(sql[last:i])
+		sb.WriteString("FOR SHARE")
+		last = end
+		i = end - 1
+	}
+	if last == 0 {
+		return sql
+	}
+	sb.WriteString(sql[last:])
+	return sb.String()
+}
+
+// matchKeywords reports whether the keywords start at i, separated by whitespace,
+// and returns the end of the last one
+func matchKeywords(sql string, i int, keywords ...string) (int, bool) {
+	for n, keyword := range keywords {
+		if n > 0 {
+			if j := skipSpaces(sql, i); j > i {
+				i = j
+			} else {
+				return 0, false
+			}
+		}
+		if !isKeywordAt(sql, i, keyword) {
+			return 0, false
+		}
+		i += len(keyword)
+	}
+	return i, true
+}
@@ -0,0 +1,90 @@
+package sqlrewrite
+
+import (
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// mixedArgumentVisitor unifies the argument types of COALESCE, IFNULL, GREATEST and
+// LEAST mixing numbers and strings. MySQL returns, and GREATEST and LEAST compare, a
+// string then, PostgreSQL reads the strings as the numeric type and fails on text that
+// is no number. Numeric columns and literals are cast to text when a string is no
+// number, strings that are numbers become numeric literals, and numbers beside a
+// string column become text. Columns of tables created outside the proxy are unknown
+// MySQL: COALESCE(qty, 'none'), IFNULL(qty, '2.5'), COALESCE(name, 0)
+// PostgreSQL: COALESCE(CAST("qty" AS TEXT), 'none'), COALESCE("qty", CAST('2.5' AS NUMERIC)), COALESCE("name", CAST(0 AS TEXT))
+type mixedArgumentVisitor struct {
+	*columnScope
+}
+
+// Leave implements ast.Visitor interface
+func (v *mixedArgumentVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	node, ok := n.(*ast.FuncCallExpr)
+	if !ok {
+		return n, true
+	}
+	switch node.FnName.L {
+	case "coalesce", "ifnull", "greatest", "least":
+	default:
+		return n, true
+	}
+
+	numbers, strs := false, false
+	for _, arg := range node.Args {
+		switch v.argumentKind(arg) {
+		case argumentNumber:
+			numbers = true
+		case argumentString:
+			strs = true
+		}
+	}
+	if !numbers {
+		return n, true
+	}
+	for i, arg := range node.Args {
+		switch kind := v.argumentKind(arg); {
+		case strs && kind == argumentNumber:
+			node.Args[i] = textColumn(arg)
+		case !strs && kind == argumentNumericString:
+			text, _ := stringLiteral(arg)
+			node.Args[i], _ = numericLiteral(text)
+		}
+	}
+	return n, true
+}
+
+// argumentKind is what an argument of mixedArgumentVisitor is known to be
+type argumentKind int
+
+const (
+	argumentUnknown       argumentKind = iota
+	argumentNumber                     // A numeric column or literal
+	argumentString                     // A string column or a string literal that is no number
+	argumentNumericString              // A string literal MySQL reads as a number in full
+)
+
+// argumentKind classifies a function argument. NULL and placeholders are unknown,
+// PostgreSQL gives them the type of the other arguments
+func (v *mixedArgumentVisitor) argumentKind(arg ast.ExprNode) argumentKind {
+	if value, ok := arg.(*driver.ValueExpr); ok {
+		switch value.Kind() {
+		case driver.KindString:
+			if numericStringPattern.MatchString(value.GetString()) {
+				return argumentNumericString
+			}
+			return argumentString
+		case driver.KindInt64, driver.KindUint64, driver.KindFloat32, driver.KindFloat64, driver.KindMysqlDecimal:
+			return argumentNumber
+		}
+		return argumentUnknown
+	}
+	if tp, ok := v.columnType(arg); ok {
+		switch {
+		case isNumericType(tp):
+			return argumentNumber
+		case isStringType(tp):
+			return argumentString
+		}
+	}
+	return argumentUnknown
+}
@@ -0,0 +1,60 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMixedArguments(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE stock (id INT, qty INT, price DECIMAL(10,2), name VARCHAR(20))")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Integer column with a string default",
+			mysql:    "SELECT COALESCE(qty, 'none') AS qty FROM stock",
+			expected: `SELECT COALESCE(CAST("qty" AS TEXT), 'none') AS "qty" FROM "stock"`,
+		},
+		{
+			name:     "IFNULL",
+			mysql:    "SELECT IFNULL(s.qty, 'n/a') FROM stock s",
+			expected: `SELECT COALESCE(CAST("s"."qty" AS TEXT), 'n/a') FROM "stock" AS "s"`,
+		},
+		{
+			name:     "Numeric strings become numbers",
+			mysql:    "SELECT COALESCE(qty, '0'), COALESCE(qty, '2.5') FROM stock",
+			expected: `SELECT COALESCE("qty", 0),COALESCE("qty", CAST('2.5' AS NUMERIC)) FROM "stock"`,
+		},
+		{
+			name:  "GREATEST and LEAST compare as strings",
+			mysql: "SELECT GREATEST(qty, price, 'b'), LEAST(qty, 10) FROM stock",
+			expected: `SELECT CASE WHEN CAST("qty" AS TEXT) IS NOT NULL AND CAST("price" AS TEXT) IS NOT NULL THEN GREATEST(CAST("qty" AS TEXT), CAST("price" AS TEXT), 'b') END,` +
+				`CASE WHEN "qty" IS NOT NULL THEN LEAST("qty", 10) END FROM "stock"`,
+		},
+		{
+			name:     "Number beside a string column",
+			mysql:    "SELECT COALESCE(name, 0), COALESCE(name, qty) FROM stock",
+			expected: `SELECT COALESCE("name", CAST(0 AS TEXT)),COALESCE("name", CAST("qty" AS TEXT)) FROM "stock"`,
+		},
+		{
+			name:     "NULL, placeholders and unknown columns are kept",
+			mysql:    "SELECT COALESCE(qty, NULL, ?), COALESCE(other, 'x') FROM stock",
+			expected: `SELECT COALESCE("qty", NULL, $1),COALESCE("other", 'x') FROM "stock"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err, "Rewrite should not error")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package sqlrewrite
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// numericStringPattern matches the strings MySQL reads as a number in full
+var numericStringPattern = regexp.MustCompile(`^\s*[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?\s*$`)
+
+// numericLiteralVisitor adapts string literals compared with known numeric columns.
+// MySQL converts the string to a number, PostgreSQL reads it as the column type and
+// fails on fractions of integer columns and on text that is no number at all. Numbers
+// become numeric literals, other text is compared with the column as text
+// MySQL: WHERE id = '5' AND qty > '2.5' AND code = 'abc'
+// PostgreSQL: WHERE "id"=5 AND "qty">CAST('2.5' AS NUMERIC) AND CAST("code" AS TEXT)='abc'
+type numericLiteralVisitor struct {
+	*columnScope
+}
+
+// Leave implements ast.Visitor interface
+func (v *numericLiteralVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	switch node := n.(type) {
+	case *ast.BinaryOperationExpr:
+		if !isComparisonOp(node.Op) {
+			break
+		}
+		if v.isNumericColumn(node.L) {
+			node.L, node.R = numericOperands(node.L, node.R)
+		} else if v.isNumericColumn(node.R) {
+			node.R, node.L = numericOperands(node.R, node.L)
+		}
+
+	case *ast.BetweenExpr:
+		if v.isNumericColumn(node.Expr) {
+			bounds := []ast.ExprNode{node.Left, node.Right}
+			node.Expr = numericListOperands(node.Expr, bounds)
+			node.Left, node.Right = bounds[0], bounds[1]
+		}
+
+	case *ast.PatternInExpr:
+		if v.isNumericColumn(node.Expr) && node.Sel == nil {
+			node.Expr = numericListOperands(node.Expr, node.List)
+		}
+	}
+	return n, true
+}
+
+// isNumericColumn reports whether expr is a known integer, decimal or floating-point column
+func (v *numericLiteralVisitor) isNumericColumn(expr ast.ExprNode) bool {
+	tp, ok := v.columnType(expr)
+	return ok && isNumericType(tp)
+}
+
+// isNumericType reports whether tp is an integer, decimal or floating-point type
+func isNumericType(tp byte) bool {
+	switch tp {
+	case mysql.TypeNewDecimal, mysql.TypeFloat, mysql.TypeDouble:
+		return true
+	}
+	return isIntegerType(tp)
+}
+
+// numericOperands returns the column and the operand compared with it
+func numericOperands(column, operand ast.ExprNode) (ast.ExprNode, ast.ExprNode) {
+	text, ok := stringLiteral(operand)
+	if !ok {
+		return column, operand
+	}
+	if number, ok := numericLiteral(text); ok {
+		return column, number
+	}
+	return textColumn(column), operand
+}
+
+// numericListOperands converts the operands of BETWEEN and IN in place and returns the
+// column. A single string that is no number compares the whole list as text
+func numericListOperands(column ast.ExprNode, operands []ast.ExprNode) ast.ExprNode {
+	numbers := make([]ast.ExprNode, len(operands))
+	for i, operand := range operands {
+		numbers[i] = operand
+		text, ok := stringLiteral(operand)
+		if !ok {
+			continue
+		}
+		if numbers[i], ok = numericLiteral(text); !ok {
+			return textColumn(column)
+		}
+	}
+	copy(operands, numbers)
+	return column
+}
+
+// stringLiteral returns the text of a string literal
+func stringLiteral(expr ast.ExprNode) (string, bool) {
+	value, ok := expr.(*driver.ValueExpr)
+	if !ok || value.Kind() != driver.KindString {
+		return "", false
+	}
+	return value.GetString(), true
+}
+
+// numericLiteral returns the number a string reads as, integers as integer literals
+func numericLiteral(text string) (ast.ExprNode, bool) {
+	if !numericStringPattern.MatchString(text) {
+		return nil, false
+	}
+	text = strings.TrimSpace(text)
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return ast.NewValueExpr(n, "", ""), true
+	}
+	return &pgCastExpr{ExprNode: ast.NewValueExpr(text, "", ""), Type: "NUMERIC"}, true
+}
+
+// textColumn casts a column to text for comparing it with a string that is no number
+func textColumn(column ast.ExprNode) ast.ExprNode {
+	return &pgCastExpr{ExprNode: column, Type: "TEXT"}
+}
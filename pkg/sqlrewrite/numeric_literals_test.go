@@ -0,0 +1,64 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumericLiterals(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE items (id INT, qty DECIMAL(10,2), price DOUBLE, code VARCHAR(10))")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Integer column and numeric string",
+			mysql:    "SELECT code FROM items WHERE id = '5'",
+			expected: `SELECT "code" FROM "items" WHERE "id"=5`,
+		},
+		{
+			name:     "Integer column and non-numeric string",
+			mysql:    "SELECT code FROM items WHERE id = 'abc'",
+			expected: `SELECT "code" FROM "items" WHERE CAST("id" AS TEXT)='abc'`,
+		},
+		{
+			name:     "Fractions and exponents",
+			mysql:    "SELECT code FROM items i WHERE i.id < ' 2.5 ' AND '1e3' > price",
+			expected: `SELECT "code" FROM "items" AS "i" WHERE "i"."id"<CAST('2.5' AS NUMERIC) AND CAST('1e3' AS NUMERIC)>"price"`,
+		},
+		{
+			name:     "IN and BETWEEN",
+			mysql:    "SELECT code FROM items WHERE id IN ('1', '2', 3) AND qty BETWEEN '1' AND '9.99'",
+			expected: `SELECT "code" FROM "items" WHERE "id" IN (1,2,3) AND "qty" BETWEEN 1 AND CAST('9.99' AS NUMERIC)`,
+		},
+		{
+			name:     "IN with a non-numeric string",
+			mysql:    "SELECT code FROM items WHERE id NOT IN ('1', 'x')",
+			expected: `SELECT "code" FROM "items" WHERE CAST("id" AS TEXT) NOT IN ('1','x')`,
+		},
+		{
+			name:     "String columns and placeholders are kept",
+			mysql:    "SELECT id FROM items WHERE code = '5' AND id = ?",
+			expected: `SELECT "id" FROM "items" WHERE LOWER("code")=LOWER('5') AND "id"=$1`,
+		},
+		{
+			name:     "UPDATE",
+			mysql:    "UPDATE items SET code = '7' WHERE id = '7'",
+			expected: `UPDATE "items" SET "code"='7' WHERE "id"=7`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
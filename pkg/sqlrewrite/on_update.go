@@ -0,0 +1,83 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// rewriteOnUpdateColumns moves ON UPDATE CURRENT_TIMESTAMP out of a CREATE TABLE,
+// PostgreSQL has no such column option. The DEFAULT of the column stays in place and
+// a BEFORE UPDATE trigger, created right after the table, touches the columns
+// MySQL: CREATE TABLE t (id INT, updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP)
+// PostgreSQL: CREATE TABLE "t" ("id" INT,"updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP);
+//
+//	CREATE OR REPLACE FUNCTION "t_on_update"() ... ;
+//	DROP TRIGGER IF EXISTS "t_on_update" ON "t"; CREATE TRIGGER "t_on_update" ...
+func (v *ASTVisitor) rewriteOnUpdateColumns(node *ast.CreateTableStmt) {
+	var columns []string
+	for _, col := range node.Cols {
+		options := col.Options[:0]
+		for _, opt := range col.Options {
+			if opt.Tp == ast.ColumnOptionOnUpdate {
+				columns = append(columns, col.Name.Name.O)
+				continue
+			}
+			options = append(options, opt)
+		}
+		col.Options = options
+	}
+	if len(columns) > 0 {
+		v.trailingSQL = onUpdateTrigger(node.Table, columns)
+		v.columnTypes.RegisterOnUpdateTrigger(node.Table.Schema.O, node.Table.Name.O)
+	}
+}
+
+// onUpdateTrigger builds the trigger keeping ON UPDATE columns current. As in MySQL a
+// column is only touched when the row actually changes and the UPDATE did not set
+// the column itself. The trigger is replaced, CREATE TABLE IF NOT EXISTS may run
+// again for a table that has it
+func onUpdateTrigger(table *ast.TableName, columns []string) string {
+	name := table.Name.O + "_on_update"
+	qualified := func(name string) string { return qualifiedName(table, name) }
+
+	var body strings.Builder
+	for _, col := range columns {
+		column := quoteIdent(col)
+		fmt.Fprintf(&body, "IF NEW.%[1]s IS NOT DISTINCT FROM OLD.%[1]s THEN NEW.%[1]s = CURRENT_TIMESTAMP; END IF; ", column)
+	}
+
+	return fmt.Sprintf("CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger AS $$ "+
+		"BEGIN IF ROW(NEW.*) IS DISTINCT FROM ROW(OLD.*) THEN %[3]sEND IF; RETURN NEW; END $$ LANGUAGE plpgsql; "+
+		"DROP TRIGGER IF EXISTS %[4]s ON %[2]s; "+
+		"CREATE TRIGGER %[4]s BEFORE UPDATE ON %[2]s FOR EACH ROW EXECUTE FUNCTION %[1]s()",
+		qualified(name), qualified(table.Name.O), body.String(), quoteIdent(name))
+}
+
+// dropOnUpdateFunctions drops the trigger functions onUpdateTrigger created for the
+// tables. DROP TABLE takes the triggers along but not their functions. Tables the proxy
+// created no trigger for are left alone, a function of that name is the user's own.
+// It returns "" when none of the tables has a trigger
+// MySQL: CREATE TABLE a (..., t TIMESTAMP ON UPDATE CURRENT_TIMESTAMP); ...; DROP TABLE a, b
+// PostgreSQL: DROP TABLE "a", "b"; DROP FUNCTION IF EXISTS "a_on_update"()
+func (v *ASTVisitor) dropOnUpdateFunctions(tables []*ast.TableName) string {
+	var functions []string
+	for _, table := range tables {
+		if v.columnTypes.DropOnUpdateTrigger(table.Schema.O, table.Name.O) {
+			functions = append(functions, qualifiedName(table, table.Name.O+"_on_update")+"()")
+		}
+	}
+	if len(functions) == 0 {
+		return ""
+	}
+	return "DROP FUNCTION IF EXISTS " + strings.Join(functions, ", ")
+}
+
+// qualifiedName quotes name, qualified with the schema of table when it has one
+func qualifiedName(table *ast.TableName, name string) string {
+	if table.Schema.O != "" {
+		return quoteIdent(table.Schema.O) + "." + quoteIdent(name)
+	}
+	return quoteIdent(name)
+}
@@ -0,0 +1,69 @@
+package sqlrewrite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnUpdateCurrentTimestamp(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	result, err := rewriter.Rewrite("CREATE TABLE posts (id INT PRIMARY KEY, " +
+		"created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP(), " +
+		"updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, " +
+		"touched DATETIME(3) ON UPDATE CURRENT_TIMESTAMP(3))")
+	require.NoError(t, err)
+
+	statements := strings.SplitN(result, "; ", 2)
+	require.Len(t, statements, 2)
+	assert.Equal(t, `CREATE TABLE "posts" ("id" INT PRIMARY KEY,"created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,`+
+		`"updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,"touched" TIMESTAMP(3))`, statements[0])
+	assert.Equal(t, `CREATE OR REPLACE FUNCTION "posts_on_update"() RETURNS trigger AS $$ BEGIN `+
+		`IF ROW(NEW.*) IS DISTINCT FROM ROW(OLD.*) THEN `+
+		`IF NEW."updated_at" IS NOT DISTINCT FROM OLD."updated_at" THEN NEW."updated_at" = CURRENT_TIMESTAMP; END IF; `+
+		`IF NEW."touched" IS NOT DISTINCT FROM OLD."touched" THEN NEW."touched" = CURRENT_TIMESTAMP; END IF; `+
+		`END IF; RETURN NEW; END $$ LANGUAGE plpgsql; `+
+		`DROP TRIGGER IF EXISTS "posts_on_update" ON "posts"; `+
+		`CREATE TRIGGER "posts_on_update" BEFORE UPDATE ON "posts" FOR EACH ROW EXECUTE FUNCTION "posts_on_update"()`,
+		statements[1])
+
+	t.Run("Schema-qualified table", func(t *testing.T) {
+		result, err := rewriter.Rewrite("CREATE TABLE blog.posts (id INT, updated_at TIMESTAMP ON UPDATE NOW())")
+		require.NoError(t, err)
+		assert.Contains(t, result, `CREATE OR REPLACE FUNCTION "blog"."posts_on_update"()`)
+		assert.Contains(t, result, `DROP TRIGGER IF EXISTS "posts_on_update" ON "blog"."posts"; `+
+			`CREATE TRIGGER "posts_on_update" BEFORE UPDATE ON "blog"."posts" FOR EACH ROW EXECUTE FUNCTION "blog"."posts_on_update"()`)
+	})
+
+	t.Run("DROP TABLE drops the trigger functions", func(t *testing.T) {
+		result, err := rewriter.Rewrite("DROP TABLE IF EXISTS posts, blog.posts")
+		require.NoError(t, err)
+		assert.Equal(t, `DROP TABLE IF EXISTS "posts", "blog"."posts"; `+
+			`DROP FUNCTION IF EXISTS "posts_on_update"(), "blog"."posts_on_update"()`, result)
+
+		result, err = rewriter.Rewrite("DROP TABLE IF EXISTS posts")
+		require.NoError(t, err)
+		assert.Equal(t, `DROP TABLE IF EXISTS "posts"`, result, "the function went with the first DROP")
+	})
+
+	t.Run("DROP TABLE leaves functions the proxy did not create", func(t *testing.T) {
+		result, err := rewriter.Rewrite("DROP TABLE logs, blog.logs")
+		require.NoError(t, err)
+		assert.Equal(t, `DROP TABLE "logs", "blog"."logs"`, result)
+
+		_, err = rewriter.Rewrite("CREATE TABLE audit (id INT, changed TIMESTAMP ON UPDATE CURRENT_TIMESTAMP)")
+		require.NoError(t, err)
+		result, err = rewriter.Rewrite("DROP TABLE audit, logs")
+		require.NoError(t, err)
+		assert.Equal(t, `DROP TABLE "audit", "logs"; DROP FUNCTION IF EXISTS "audit_on_update"()`, result)
+	})
+
+	t.Run("No trigger without ON UPDATE", func(t *testing.T) {
+		result, err := rewriter.Rewrite("CREATE TABLE logs (id INT, created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)")
+		require.NoError(t, err)
+		assert.Equal(t, `CREATE TABLE "logs" ("id" INT,"created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP)`, result)
+	})
+}
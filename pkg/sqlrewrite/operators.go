@@ -0,0 +1,115 @@
+package sqlrewrite
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// parserSQLMode returns the parser mode for the session's @@sql_mode. MySQL spells
+// logical operators && and ||, which parse to LogicAnd/LogicOr and restore as AND/OR,
+// but under PIPES_AS_CONCAT (also part of ANSI) || concatenates strings as it does in
+// PostgreSQL and parses to CONCAT() instead. Other modes do not change the rewrite
+// MySQL: SELECT * FROM t WHERE a = 1 && b = 2 || c = 3
+// PostgreSQL: SELECT * FROM "t" WHERE "a"=1 AND "b"=2 OR "c"=3
+func parserSQLMode(vars map[string]interface{}) mysql.SQLMode {
+	value, ok := vars[systemVarPrefix+"sql_mode"].(string)
+	if !ok {
+		return mysql.ModeNone
+	}
+	var mode mysql.SQLMode
+	for _, name := range strings.Split(strings.ToUpper(value), ",") {
+		switch strings.TrimSpace(name) {
+		case "PIPES_AS_CONCAT", "ANSI":
+			mode |= mysql.ModePipesAsConcat
+		}
+	}
+	return mode
+}
+
+// divisionByZeroErrors reports whether MySQL fails stmt when it divides by zero. It
+// only does for INSERT and UPDATE without IGNORE when @@sql_mode is strict and has
+// ERROR_FOR_DIVISION_BY_ZERO, as the default TRADITIONAL does. Everywhere else the
+// result is NULL, see rewriteDivision
+func divisionByZeroErrors(stmt ast.StmtNode, vars map[string]interface{}) bool {
+	switch node := stmt.(type) {
+	case *ast.InsertStmt:
+		if node.IgnoreErr {
+			return false
+		}
+	case *ast.UpdateStmt:
+		if node.IgnoreErr {
+			return false
+		}
+	default:
+		return false
+	}
+
+	value, ok := vars[systemVarPrefix+"sql_mode"].(string)
+	if !ok {
+		value = systemVarDefaults["sql_mode"].(string)
+	}
+	var strict, errors bool
+	for _, name := range strings.Split(strings.ToUpper(value), ",") {
+		switch strings.TrimSpace(name) {
+		case "STRICT_TRANS_TABLES", "STRICT_ALL_TABLES":
+			strict = true
+		case "ERROR_FOR_DIVISION_BY_ZERO":
+			errors = true
+		case "TRADITIONAL":
+			strict, errors = true, true
+		}
+	}
+	return strict && errors
+}
+
+// isDivisionOp reports whether op divides, failing in PostgreSQL on a zero divisor
+func isDivisionOp(op opcode.Op) bool {
+	return op == opcode.Div || op == opcode.IntDiv || op == opcode.Mod
+}
+
+// rewriteDivision makes a division by zero NULL as in MySQL, PostgreSQL always fails.
+// Divisors other than non-zero numbers go through NULLIF, unless the statement fails on
+// zero anyway. PostgreSQL has no DIV operator but a function truncating the same way
+// MySQL: SELECT a / b, a DIV 2, a % b, MOD(a, ?)
+// PostgreSQL: SELECT "a"/NULLIF("b", 0),DIV("a", 2),"a"%NULLIF("b", 0),"a"%NULLIF($1, 0.0)
+func (v *ASTVisitor) rewriteDivision(node *ast.BinaryOperationExpr) ast.ExprNode {
+	if !v.divisionErrors && !isNonZeroNumber(node.R) {
+		var zero interface{} = int64(0)
+		if _, ok := node.R.(*driver.ParamMarkerExpr); ok {
+			// NULLIF($1, 0) would type the parameter INT and refuse fractions
+			decimal := new(driver.MyDecimal)
+			_ = decimal.FromString([]byte("0.0"))
+			zero = decimal
+		}
+		node.R = &ast.FuncCallExpr{FnName: ast.NewCIStr("NULLIF"), Args: []ast.ExprNode{node.R, ast.NewValueExpr(zero, "", "")}}
+	}
+	if node.Op == opcode.IntDiv {
+		return &ast.FuncCallExpr{FnName: ast.NewCIStr("DIV"), Args: []ast.ExprNode{node.L, node.R}}
+	}
+	return node
+}
+
+// isNonZeroNumber reports whether expr is a numeric literal other than zero
+func isNonZeroNumber(expr ast.ExprNode) bool {
+	value, ok := unwrapParentheses(expr).(*driver.ValueExpr)
+	if !ok {
+		return false
+	}
+	switch value.Kind() {
+	case driver.KindInt64:
+		return value.GetInt64() != 0
+	case driver.KindUint64:
+		return value.GetUint64() != 0
+	case driver.KindFloat64:
+		return value.GetFloat64() != 0
+	case driver.KindMysqlDecimal:
+		number, err := strconv.ParseFloat(value.GetMysqlDecimal().String(), 64)
+		return err == nil && number != 0
+	}
+	return false
+}
@@ -0,0 +1,617 @@
+// Copyright (c) 2025 axfor
+
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// PostgreSQL-only expression nodes
+// TiDB's AST has no way to express some PostgreSQL syntax (interval literals,
+// casts to PostgreSQL-only types, ...). These nodes wrap the original MySQL
+// expression and implement Restore() to emit the PostgreSQL form directly,
+// so they can be spliced into the AST by the visitor like any other ExprNode.
+
+// pgIntervalExpr renders a MySQL "INTERVAL expr unit" operand as a PostgreSQL interval
+//
+//	INTERVAL 7 DAY            -> INTERVAL '7 DAY'
+//	INTERVAL 1.5 HOUR         -> INTERVAL '1.5 HOUR'
+//	INTERVAL ? DAY            -> ($1) * INTERVAL '1 DAY'
+//	INTERVAL '1:30' HOUR_MINUTE -> CAST('1:30' AS INTERVAL HOUR TO MINUTE)
+type pgIntervalExpr struct {
+	ast.ExprNode // Interval value
+	Unit         ast.TimeUnitType
+}
+
+// pgCompoundIntervalFields maps MySQL compound units to PostgreSQL interval field qualifiers
+// PostgreSQL has no separate microsecond field, fractional seconds are part of SECOND
+var pgCompoundIntervalFields = map[ast.TimeUnitType]string{
+	ast.TimeUnitSecondMicrosecond: "SECOND",
+	ast.TimeUnitMinuteMicrosecond: "MINUTE TO SECOND",
+	ast.TimeUnitMinuteSecond:      "MINUTE TO SECOND",
+	ast.TimeUnitHourMicrosecond:   "HOUR TO SECOND",
+	ast.TimeUnitHourSecond:        "HOUR TO SECOND",
+	ast.TimeUnitHourMinute:        "HOUR TO MINUTE",
+	ast.TimeUnitDayMicrosecond:    "DAY TO SECOND",
+	ast.TimeUnitDaySecond:         "DAY TO SECOND",
+	ast.TimeUnitDayMinute:         "DAY TO MINUTE",
+	ast.TimeUnitDayHour:           "DAY TO HOUR",
+	ast.TimeUnitYearMonth:         "YEAR TO MONTH",
+}
+
+// Restore implements ast.Node interface
+func (n *pgIntervalExpr) Restore(ctx *format.RestoreCtx) error {
+	if fields, ok := pgCompoundIntervalFields[n.Unit]; ok {
+		// Compound units carry a formatted string ('1:30', '1 2', '1-6') which
+		// PostgreSQL parses the same way when given the matching field qualifier
+		ctx.WriteKeyWord("CAST")
+		ctx.WritePlain("(")
+		if literal, ok := n.literalText(ctx); ok {
+			ctx.WriteString(literal)
+		} else {
+			ctx.WriteKeyWord("CAST")
+			ctx.WritePlain("(")
+			if err := n.ExprNode.Restore(ctx); err != nil {
+				return err
+			}
+			ctx.WriteKeyWord(" AS TEXT")
+			ctx.WritePlain(")")
+		}
+		ctx.WriteKeyWord(" AS INTERVAL " + fields)
+		ctx.WritePlain(")")
+		return nil
+	}
+
+	unit := n.Unit.String()
+	if unit == "" {
+		return fmt.Errorf("unsupported INTERVAL unit: %d", n.Unit)
+	}
+
+	// PostgreSQL has no QUARTER interval unit
+	step := "1 " + unit
+	if n.Unit == ast.TimeUnitQuarter {
+		step = "3 MONTH"
+	}
+
+	// Numeric literals fold into a single interval literal, fractions included
+	if literal, ok := n.numericText(ctx); ok && n.Unit != ast.TimeUnitQuarter {
+		ctx.WriteKeyWord("INTERVAL ")
+		ctx.WriteString(literal + " " + unit)
+		return nil
+	}
+
+	// Anything else (placeholders, columns, expressions) scales a unit interval
+	ctx.WritePlain("(")
+	if err := n.ExprNode.Restore(ctx); err != nil {
+		return err
+	}
+	ctx.WritePlain(") * ")
+	ctx.WriteKeyWord("INTERVAL ")
+	ctx.WriteString(step)
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgIntervalExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgIntervalExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	return v.Leave(n)
+}
+
+// numericText returns the text of a numeric literal interval value
+func (n *pgIntervalExpr) numericText(ctx *format.RestoreCtx) (string, bool) {
+	value, ok := n.ExprNode.(*driver.ValueExpr)
+	if !ok {
+		return "", false
+	}
+	// Floats restore in exponent form which PostgreSQL interval literals reject
+	switch value.Kind() {
+	case driver.KindInt64, driver.KindUint64, driver.KindMysqlDecimal:
+	default:
+		return "", false
+	}
+
+	var sb strings.Builder
+	if err := value.Restore(format.NewRestoreCtx(ctx.Flags, &sb)); err != nil {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+// literalText returns the text of a string or numeric literal interval value
+func (n *pgIntervalExpr) literalText(ctx *format.RestoreCtx) (string, bool) {
+	if text, ok := n.numericText(ctx); ok {
+		return text, true
+	}
+	value, ok := n.ExprNode.(*driver.ValueExpr)
+	if !ok || value.Kind() != driver.KindString {
+		return "", false
+	}
+	return value.Datum.GetString(), true
+}
+
+// pgLimitExpr replaces the count of a LIMIT clause and renders the offset after it,
+// since TiDB restores an offset as MySQL's "LIMIT offset,count"
+//
+//	LIMIT 5, 10                      -> LIMIT 10 OFFSET 5
+//	LIMIT 5, 18446744073709551615    -> LIMIT ALL OFFSET 5
+//	LIMIT ?, ?                       -> LIMIT $2 OFFSET $1
+type pgLimitExpr struct {
+	ast.ExprNode // Row count
+	Offset       ast.ExprNode
+	offsetFirst  bool // Placeholders were written offset first, keep their numbering
+}
+
+// Restore implements ast.Node interface
+func (n *pgLimitExpr) Restore(ctx *format.RestoreCtx) error {
+	if n.offsetFirst {
+		ctx.WritePlain(limitOffsetFirstMarker)
+	}
+	if isLimitAll(n.ExprNode) {
+		ctx.WriteKeyWord("ALL")
+	} else if err := n.ExprNode.Restore(ctx); err != nil {
+		return err
+	}
+	if n.Offset != nil {
+		ctx.WriteKeyWord(" OFFSET ")
+		if err := n.Offset.Restore(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgLimitExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgLimitExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	if n.Offset != nil {
+		node, ok = n.Offset.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Offset = node.(ast.ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// pgDistinctExpr renders MySQL's NULL-safe equality as PostgreSQL's DISTINCT predicate
+//
+//	a <=> b        -> a IS NOT DISTINCT FROM b
+//	NOT (a <=> b)  -> a IS DISTINCT FROM b
+type pgDistinctExpr struct {
+	ast.ExprNode // Left operand
+	R            ast.ExprNode
+	Not          bool // The negation of <=>
+}
+
+// Restore implements ast.Node interface
+func (n *pgDistinctExpr) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreOperand(ctx, n.ExprNode); err != nil {
+		return err
+	}
+	if n.Not {
+		ctx.WriteKeyWord(" IS DISTINCT FROM ")
+	} else {
+		ctx.WriteKeyWord(" IS NOT DISTINCT FROM ")
+	}
+	return restoreOperand(ctx, n.R)
+}
+
+// restoreOperand parenthesizes operands that could bind looser than the PostgreSQL operator around them
+func restoreOperand(ctx *format.RestoreCtx, expr ast.ExprNode) error {
+	switch expr.(type) {
+	case *ast.BinaryOperationExpr, *ast.UnaryOperationExpr, *ast.IsNullExpr, *ast.IsTruthExpr,
+		*ast.PatternLikeOrIlikeExpr, *ast.PatternInExpr, *ast.PatternRegexpExpr, *ast.BetweenExpr,
+		*pgDistinctExpr, *pgRegexpMatchExpr:
+		ctx.WritePlain("(")
+		if err := expr.Restore(ctx); err != nil {
+			return err
+		}
+		ctx.WritePlain(")")
+		return nil
+	}
+	return expr.Restore(ctx)
+}
+
+// Accept implements ast.Node interface
+func (n *pgDistinctExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgDistinctExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	node, ok = n.R.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.R = node.(ast.ExprNode)
+	return v.Leave(n)
+}
+
+// unwrapParentheses strips redundant parentheses around an expression
+func unwrapParentheses(expr ast.ExprNode) ast.ExprNode {
+	for {
+		paren, ok := expr.(*ast.ParenthesesExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.Expr
+	}
+}
+
+// pgRegexpMatchExpr renders REGEXP, RLIKE and REGEXP_LIKE as PostgreSQL's match operators
+// Flags other than the case go into the pattern as embedded options
+//
+//	a REGEXP 'x'                 -> a ~* 'x'
+//	a NOT RLIKE 'x'              -> a !~* 'x'
+//	REGEXP_LIKE(a, '^x', 'cm')   -> a ~ '(?cw)^x'
+type pgRegexpMatchExpr struct {
+	ast.ExprNode // Subject
+	Pattern      ast.ExprNode
+	Flags        string // PostgreSQL regex flags
+	Not          bool
+}
+
+// Restore implements ast.Node interface
+func (n *pgRegexpMatchExpr) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreOperand(ctx, n.ExprNode); err != nil {
+		return err
+	}
+
+	op, options := "~", "(?"+n.Flags+")"
+	switch n.Flags {
+	case "i":
+		op, options = "~*", ""
+	case "c":
+		options = ""
+	}
+	if n.Not {
+		op = "!" + op
+	}
+	ctx.WritePlain(" " + op + " ")
+	return restoreRegexpPattern(ctx, n.Pattern, options, "")
+}
+
+// Accept implements ast.Node interface
+func (n *pgRegexpMatchExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgRegexpMatchExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	node, ok = n.Pattern.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Pattern = node.(ast.ExprNode)
+	return v.Leave(n)
+}
+
+// pgRegexpFuncExpr renders MySQL's REGEXP_REPLACE, REGEXP_SUBSTR and REGEXP_INSTR with the
+// regex functions PostgreSQL 12 has. A position past 1 searches the rest of the subject
+//
+//	REGEXP_REPLACE(a, 'x', 'y')        -> regexp_replace(a, 'x', 'y', 'gi')
+//	REGEXP_SUBSTR(a, 'x', 1, 2)        -> (SELECT m[1] FROM regexp_matches(a, '(x)', 'gi') AS r(m) LIMIT 1 OFFSET 1)
+//	REGEXP_INSTR(a, 'x')               -> COALESCE(LENGTH(SUBSTRING(a FROM '(?i)^(.*?)(?:x)')) + 1, 0)
+type pgRegexpFuncExpr struct {
+	ast.ExprNode        // Subject
+	Fn           string // replace, substr or instr
+	Pattern      ast.ExprNode
+	Replacement  ast.ExprNode // REGEXP_REPLACE only
+	Position     int64        // 1-based
+	Occurrence   int64        // 0 replaces every match
+	Flags        string       // PostgreSQL regex flags
+}
+
+// Restore implements ast.Node interface
+func (n *pgRegexpFuncExpr) Restore(ctx *format.RestoreCtx) error {
+	switch n.Fn {
+	case "replace":
+		// The part before the position is kept as is
+		if n.Position > 1 {
+			ctx.WritePlain("(")
+			ctx.WriteKeyWord("SUBSTRING")
+			ctx.WritePlain("(")
+			if err := n.ExprNode.Restore(ctx); err != nil {
+				return err
+			}
+			ctx.WriteKeyWord(" FROM ")
+			ctx.WritePlainf("1")
+			ctx.WriteKeyWord(" FOR ")
+			ctx.WritePlainf("%d", n.Position-1)
+			ctx.WritePlain(") || ")
+		}
+		ctx.WritePlain("regexp_replace(")
+		if err := n.restoreSubject(ctx); err != nil {
+			return err
+		}
+		ctx.WritePlain(", ")
+		if err := n.Pattern.Restore(ctx); err != nil {
+			return err
+		}
+		ctx.WritePlain(", ")
+		if err := n.Replacement.Restore(ctx); err != nil {
+			return err
+		}
+		flags := n.Flags
+		if n.Occurrence == 0 {
+			flags = "g" + flags
+		}
+		ctx.WritePlain(", ")
+		ctx.WriteString(flags)
+		ctx.WritePlain(")")
+		if n.Position > 1 {
+			ctx.WritePlain(")")
+		}
+
+	case "substr":
+		// Wrapping the pattern in a group makes m[1] the whole match, as MySQL returns
+		ctx.WritePlain("(")
+		ctx.WriteKeyWord("SELECT ")
+		ctx.WritePlain("r.m[1] ")
+		ctx.WriteKeyWord("FROM ")
+		ctx.WritePlain("regexp_matches(")
+		if err := n.restoreSubject(ctx); err != nil {
+			return err
+		}
+		ctx.WritePlain(", ")
+		if err := restoreRegexpPattern(ctx, n.Pattern, "(", ")"); err != nil {
+			return err
+		}
+		ctx.WritePlain(", ")
+		ctx.WriteString("g" + n.Flags)
+		ctx.WritePlain(") ")
+		ctx.WriteKeyWord("AS ")
+		ctx.WritePlain("r(m) ")
+		ctx.WriteKeyWord("LIMIT ")
+		ctx.WritePlain("1")
+		if n.Occurrence > 1 {
+			ctx.WriteKeyWord(" OFFSET ")
+			ctx.WritePlainf("%d", n.Occurrence-1)
+		}
+		ctx.WritePlain(")")
+
+	case "instr":
+		// The shortest prefix before a match ends where the leftmost match starts
+		ctx.WriteKeyWord("COALESCE")
+		ctx.WritePlain("(")
+		ctx.WriteKeyWord("LENGTH")
+		ctx.WritePlain("(")
+		ctx.WriteKeyWord("SUBSTRING")
+		ctx.WritePlain("(")
+		if err := n.restoreSubject(ctx); err != nil {
+			return err
+		}
+		ctx.WriteKeyWord(" FROM ")
+		if err := restoreRegexpPattern(ctx, n.Pattern, "(?"+n.Flags+")^(.*?)(?:", ")"); err != nil {
+			return err
+		}
+		ctx.WritePlainf(")) + %d, 0)", n.Position)
+
+	default:
+		return fmt.Errorf("unsupported regexp function: %s", n.Fn)
+	}
+	return nil
+}
+
+// restoreSubject writes the subject, cut at the start position
+func (n *pgRegexpFuncExpr) restoreSubject(ctx *format.RestoreCtx) error {
+	if n.Position <= 1 {
+		return n.ExprNode.Restore(ctx)
+	}
+	ctx.WriteKeyWord("SUBSTRING")
+	ctx.WritePlain("(")
+	if err := n.ExprNode.Restore(ctx); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord(" FROM ")
+	ctx.WritePlainf("%d", n.Position)
+	ctx.WritePlain(")")
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgRegexpFuncExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgRegexpFuncExpr)
+	for _, expr := range []*ast.ExprNode{&n.ExprNode, &n.Pattern, &n.Replacement} {
+		if *expr == nil {
+			continue
+		}
+		node, ok := (*expr).Accept(v)
+		if !ok {
+			return n, false
+		}
+		*expr = node.(ast.ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// restoreRegexpPattern writes a pattern between a prefix and a suffix, folded into the
+// literal when the pattern is a string and concatenated otherwise
+func restoreRegexpPattern(ctx *format.RestoreCtx, pattern ast.ExprNode, prefix, suffix string) error {
+	if value, ok := pattern.(*driver.ValueExpr); ok && value.Kind() == driver.KindString {
+		ctx.WriteString(prefix + value.GetString() + suffix)
+		return nil
+	}
+	if prefix == "" && suffix == "" {
+		return pattern.Restore(ctx)
+	}
+
+	ctx.WritePlain("(")
+	if prefix != "" {
+		ctx.WriteString(prefix)
+		ctx.WritePlain(" || ")
+	}
+	if err := restoreOperand(ctx, pattern); err != nil {
+		return err
+	}
+	if suffix != "" {
+		ctx.WritePlain(" || ")
+		ctx.WriteString(suffix)
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// pgCastExpr renders a cast to a PostgreSQL type name, which MySQL's CAST cannot express
+//
+//	'2024-01-01' compared with a DATETIME column -> CAST('2024-01-01' AS TIMESTAMP)
+type pgCastExpr struct {
+	ast.ExprNode // Operand
+	Type         string
+}
+
+// Restore implements ast.Node interface
+func (n *pgCastExpr) Restore(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord("CAST")
+	ctx.WritePlain("(")
+	if err := n.ExprNode.Restore(ctx); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord(" AS ")
+	ctx.WritePlain(n.Type)
+	ctx.WritePlain(")")
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgCastExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgCastExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	return v.Leave(n)
+}
+
+// pgRollupExpr renders the grouping columns of MySQL's WITH ROLLUP as a PostgreSQL grouping set
+//
+//	GROUP BY a, b WITH ROLLUP -> GROUP BY ROLLUP(a, b)
+type pgRollupExpr struct {
+	ast.ExprNode // First grouping column
+	Rest         []ast.ExprNode
+}
+
+// Restore implements ast.Node interface
+func (n *pgRollupExpr) Restore(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord("ROLLUP")
+	ctx.WritePlain("(")
+	if err := n.ExprNode.Restore(ctx); err != nil {
+		return err
+	}
+	for _, expr := range n.Rest {
+		ctx.WritePlain(", ")
+		if err := expr.Restore(ctx); err != nil {
+			return err
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgRollupExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgRollupExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	for i, expr := range n.Rest {
+		node, ok = expr.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Rest[i] = node.(ast.ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// pgCollateExpr renders an operand with a PostgreSQL collation, MySQL's COLLATE only
+// takes its own collation names
+//
+//	BINARY name -> "name" COLLATE "C"
+type pgCollateExpr struct {
+	ast.ExprNode // Operand
+	Collation    string
+}
+
+// Restore implements ast.Node interface
+func (n *pgCollateExpr) Restore(ctx *format.RestoreCtx) error {
+	// COLLATE binds tighter than any operator, keep compound operands together
+	switch n.ExprNode.(type) {
+	case *ast.ColumnNameExpr, *driver.ValueExpr, *driver.ParamMarkerExpr, *ast.FuncCallExpr, *ast.ParenthesesExpr:
+		if err := n.ExprNode.Restore(ctx); err != nil {
+			return err
+		}
+	default:
+		ctx.WritePlain("(")
+		if err := n.ExprNode.Restore(ctx); err != nil {
+			return err
+		}
+		ctx.WritePlain(")")
+	}
+	ctx.WriteKeyWord(" COLLATE ")
+	ctx.WriteName(n.Collation)
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgCollateExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgCollateExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	return v.Leave(n)
+}
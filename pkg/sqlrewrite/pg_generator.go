@@ -13,23 +13,18 @@ import (
 // PGGenerator generates PostgreSQL SQL
 // Generates PostgreSQL-compatible SQL statements based on converted AST
 type PGGenerator struct {
-	typeMapper       *TypeMapper
-	placeholderIndex int
+	typeMapper *TypeMapper
 }
 
 // NewPGGenerator creates a new PostgreSQL SQL generator
 func NewPGGenerator() *PGGenerator {
 	return &PGGenerator{
-		typeMapper:       NewTypeMapper(),
-		placeholderIndex: 0,
+		typeMapper: NewTypeMapper(),
 	}
 }
 
 // Generate generates PostgreSQL SQL from AST
 func (g *PGGenerator) Generate(node ast.StmtNode) (string, error) {
-	// Reset placeholder counter
-	g.placeholderIndex = 0
-
 	// Use custom RestoreCtx to generate SQL
 	var sb strings.Builder
 	ctx := format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)
@@ -49,11 +44,12 @@ func (g *PGGenerator) Generate(node ast.StmtNode) (string, error) {
 func (g *PGGenerator) createPGRestoreCtx(sb *strings.Builder) *format.RestoreCtx {
 	// Use PostgreSQL compatible flags
 	// RestoreTiDBSpecialComment - Remove TiDB special comments
-	// RestoreStringWithoutCharset - Remove charset declarations
+	// RestoreStringWithoutCharset - Remove charset introducers (_utf8mb4'x', N'x'), wherever the literal appears
 	// RestoreNameBackQuotes - Use double quotes instead of backticks
 	flags := format.RestoreStringSingleQuotes |
 		format.RestoreKeyWordUppercase |
-		format.RestoreNameBackQuotes
+		format.RestoreNameBackQuotes |
+		format.RestoreStringWithoutCharset
 
 	ctx := format.NewRestoreCtx(flags, sb)
 
@@ -83,6 +79,7 @@ func (g *PGGenerator) convertPlaceholders(sql string) (string, int) {
 	inString := false
 	stringChar := byte(0)
 	escaped := false
+	swapNext, swapped := false, false
 
 	for i := 0; i < len(sql); i++ {
 		ch := sql[i]
@@ -100,8 +97,8 @@ func (g *PGGenerator) convertPlaceholders(sql string) (string, int) {
 			continue
 		}
 
-		// Handle string literals
-		if ch == '\'' || ch == '"' {
+		// Handle string literals and quoted names, aliases are still in backticks here
+		if ch == '\'' || ch == '"' || ch == '`' {
 			if !inString {
 				inString = true
 				stringChar = ch
@@ -113,10 +110,26 @@ func (g *PGGenerator) convertPlaceholders(sql string) (string, int) {
 			continue
 		}
 
+		// LIMIT ?, ? binds the offset first, number the count after it
+		if !inString && strings.HasPrefix(sql[i:], limitOffsetFirstMarker) {
+			swapNext = true
+			i += len(limitOffsetFirstMarker) - 1
+			continue
+		}
+
 		// Only convert placeholders outside of strings
 		if !inString && ch == '?' {
 			paramIndex++
-			result.WriteString(fmt.Sprintf("$%d", paramIndex))
+			switch {
+			case swapNext:
+				result.WriteString(fmt.Sprintf("$%d", paramIndex+1))
+				swapNext, swapped = false, true
+			case swapped:
+				result.WriteString(fmt.Sprintf("$%d", paramIndex-1))
+				swapped = false
+			default:
+				result.WriteString(fmt.Sprintf("$%d", paramIndex))
+			}
 		} else {
 			result.WriteByte(ch)
 		}
@@ -145,33 +158,6 @@ func (g *PGGenerator) ConvertCreateTable(node *ast.CreateTableStmt) (string, err
 	return sql, nil
 }
 
-// ConvertFunctionCall converts function calls
-// This method can be used for additional function conversions when needed
-func (g *PGGenerator) ConvertFunctionCall(funcName string, args []string) string {
-	funcName = strings.ToLower(funcName)
-
-	// Special function handling
-	switch funcName {
-	case "now":
-		return "CURRENT_TIMESTAMP"
-	case "curdate":
-		return "CURRENT_DATE"
-	case "curtime":
-		return "CURRENT_TIME"
-	case "ifnull":
-		if len(args) == 2 {
-			return fmt.Sprintf("COALESCE(%s, %s)", args[0], args[1])
-		}
-	case "if":
-		if len(args) == 3 {
-			return fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", args[0], args[1], args[2])
-		}
-	}
-
-	// Default: keep as is
-	return fmt.Sprintf("%s(%s)", strings.ToUpper(funcName), strings.Join(args, ", "))
-}
-
 // PostProcess post-processes the generated SQL
 // Used to handle details that cannot be converted through AST
 func (g *PGGenerator) PostProcess(sql string) string {
@@ -189,10 +175,6 @@ func (g *PGGenerator) PostProcess(sql string) string {
 	sql = strings.ReplaceAll(sql, "UNIQUE KEY", "UNIQUE")
 	sql = strings.ReplaceAll(sql, "UNIQUE INDEX", "UNIQUE")
 
-	// Remove MySQL character set prefixes from string literals
-	// Examples: _UTF8MB4'text', _UTF8'text', _LATIN1'text', etc.
-	sql = g.removeCharsetPrefixes(sql)
-
 	// Fix PostgreSQL special keywords that should not have parentheses
 	sql = strings.ReplaceAll(sql, "CURRENT_TIMESTAMP()", "CURRENT_TIMESTAMP")
 	sql = strings.ReplaceAll(sql, "CURRENT_DATE()", "CURRENT_DATE")
@@ -203,8 +185,7 @@ func (g *PGGenerator) PostProcess(sql string) string {
 	// This is kept for ALTER TABLE and other edge cases
 	sql = g.convertAutoIncrement(sql)
 
-	// Convert NULL to DEFAULT in INSERT VALUES (for AUTO_INCREMENT/SERIAL compatibility)
-	sql = g.convertInsertNullToDefault(sql)
+	// NULL inserted into AUTO_INCREMENT columns is converted at AST level, see rewriteAutoIncrementValues
 
 	// Convert ENUM types to VARCHAR
 	// NOTE: For CREATE TABLE, ENUM conversion is handled at AST level in visitCreateTable()
@@ -226,14 +207,6 @@ func (g *PGGenerator) PostProcess(sql string) string {
 	// Convert @@(arg1, arg2) to arg1 @@ arg2
 	sql = g.convertMatchOperator(sql)
 
-	// Convert MySQL LIMIT syntax to PostgreSQL syntax
-	// MySQL: LIMIT offset, count → PostgreSQL: LIMIT count OFFSET offset
-	sql = g.convertLimitSyntax(sql)
-
-	// Convert MySQL lock syntax to PostgreSQL syntax
-	// MySQL: LOCK IN SHARE MODE → PostgreSQL: FOR SHARE
-	sql = strings.ReplaceAll(sql, "LOCK IN SHARE MODE", "FOR SHARE")
-
 	// Convert MySQL LAST_INSERT_ID() to PostgreSQL lastval()
 	// MySQL: LAST_INSERT_ID() → PostgreSQL: lastval()
 	sql = strings.ReplaceAll(sql, "LAST_INSERT_ID()", "lastval()")
@@ -254,89 +227,6 @@ func (g *PGGenerator) PostProcess(sql string) string {
 	return sql
 }
 
-// convertLimitSyntax converts MySQL LIMIT offset, count to PostgreSQL LIMIT count OFFSET offset
-func (g *PGGenerator) convertLimitSyntax(sql string) string {
-	result := sql
-
-	// Pattern: LIMIT number, number
-	// Use regex to find and replace
-	// Look for LIMIT followed by digits, comma, digits
-	searchPos := 0
-	for {
-		upperPart := strings.ToUpper(result[searchPos:])
-		limitIdx := strings.Index(upperPart, "LIMIT")
-		if limitIdx == -1 {
-			break
-		}
-
-		limitIdx = searchPos + limitIdx
-
-		// Skip past LIMIT keyword
-		i := limitIdx + 5
-
-		// Skip whitespace
-		for i < len(result) && (result[i] == ' ' || result[i] == '\t' || result[i] == '\n') {
-			i++
-		}
-
-		// Parse first number (offset)
-		start1 := i
-		for i < len(result) && result[i] >= '0' && result[i] <= '9' {
-			i++
-		}
-
-		if i == start1 {
-			// No number found
-			searchPos = limitIdx + 5
-			continue
-		}
-
-		offset := result[start1:i]
-
-		// Skip whitespace
-		for i < len(result) && (result[i] == ' ' || result[i] == '\t' || result[i] == '\n') {
-			i++
-		}
-
-		// Check for comma
-		if i >= len(result) || result[i] != ',' {
-			// No comma, not the MySQL syntax we're looking for
-			searchPos = limitIdx + 5
-			continue
-		}
-
-		i++ // Skip comma
-
-		// Skip whitespace
-		for i < len(result) && (result[i] == ' ' || result[i] == '\t' || result[i] == '\n') {
-			i++
-		}
-
-		// Parse second number (count)
-		start2 := i
-		for i < len(result) && result[i] >= '0' && result[i] <= '9' {
-			i++
-		}
-
-		if i == start2 {
-			// No second number found
-			searchPos = limitIdx + 5
-			continue
-		}
-
-		count := result[start2:i]
-
-		// Replace LIMIT offset, count with LIMIT count OFFSET offset
-		newLimit := "LIMIT " + count + " OFFSET " + offset
-		result = result[:limitIdx] + newLimit + result[i:]
-
-		// Continue searching from after the replacement
-		searchPos = limitIdx + len(newLimit)
-	}
-
-	return result
-}
-
 // convertGroupConcat converts MySQL GROUP_CONCAT to PostgreSQL string_agg
 // MySQL: GROUP_CONCAT(col SEPARATOR 'sep') → PostgreSQL: string_agg(col, 'sep')
 // MySQL: GROUP_CONCAT(col) → PostgreSQL: string_agg(col, ',')
@@ -421,17 +311,18 @@ func (g *PGGenerator) convertMatchAgainst(sql string) string {
 	resultUpper := strings.ToUpper(result)
 
 	// Find all MATCH(...) AGAINST(...) patterns
+	searchPos := 0
 	for {
-		matchIdx := strings.Index(resultUpper, "MATCH")
+		matchIdx := strings.Index(resultUpper[searchPos:], "MATCH")
 		if matchIdx == -1 {
 			break
 		}
+		matchIdx += searchPos
 
 		// Check if inside a string
 		if g.isInString(result, matchIdx) {
-			// Inside string, skip - must update both result and resultUpper
-			result = result[:matchIdx] + "xMATCH" + result[matchIdx+5:]
-			resultUpper = resultUpper[:matchIdx] + "xMATCH" + resultUpper[matchIdx+5:]
+			// Inside string, skip
+			searchPos = matchIdx + 5
 			continue
 		}
 
@@ -441,9 +332,8 @@ func (g *PGGenerator) convertMatchAgainst(sql string) string {
 			searchStart++
 		}
 		if searchStart >= len(result) || result[searchStart] != '(' {
-			// No left paren found, might not be MATCH...AGAINST expression - must update both
-			result = result[:matchIdx] + "xMATCH" + result[matchIdx+5:]
-			resultUpper = resultUpper[:matchIdx] + "xMATCH" + resultUpper[matchIdx+5:]
+			// No left paren found, might not be MATCH...AGAINST expression (e.g. regexp_matches)
+			searchPos = matchIdx + 5
 			continue
 		}
 		matchParenStart := searchStart
@@ -460,9 +350,8 @@ func (g *PGGenerator) convertMatchAgainst(sql string) string {
 		// Find AGAINST
 		againstIdx := strings.Index(resultUpper[matchParenEnd:], "AGAINST")
 		if againstIdx == -1 {
-			// No AGAINST, not a MATCH...AGAINST expression, skip - must update both
-			result = result[:matchIdx] + "xMATCH" + result[matchIdx+5:]
-			resultUpper = resultUpper[:matchIdx] + "xMATCH" + resultUpper[matchIdx+5:]
+			// No AGAINST, not a MATCH...AGAINST expression, skip
+			searchPos = matchIdx + 5
 			continue
 		}
 		againstIdx += matchParenEnd
@@ -473,9 +362,8 @@ func (g *PGGenerator) convertMatchAgainst(sql string) string {
 			searchAgainst++
 		}
 		if searchAgainst >= len(result) || result[searchAgainst] != '(' {
-			// No paren found - must update both
-			result = result[:matchIdx] + "xMATCH" + result[matchIdx+5:]
-			resultUpper = resultUpper[:matchIdx] + "xMATCH" + resultUpper[matchIdx+5:]
+			// No paren found
+			searchPos = matchIdx + 5
 			continue
 		}
 		againstParenStart := searchAgainst
@@ -483,9 +371,8 @@ func (g *PGGenerator) convertMatchAgainst(sql string) string {
 		// Find matching )
 		againstParenEnd := g.findMatchingParen(result, againstParenStart)
 		if againstParenEnd == -1 {
-			// No matching right paren found - must update both
-			result = result[:matchIdx] + "xMATCH" + result[matchIdx+5:]
-			resultUpper = resultUpper[:matchIdx] + "xMATCH" + resultUpper[matchIdx+5:]
+			// No matching right paren found
+			searchPos = matchIdx + 5
 			continue
 		}
 
@@ -508,11 +395,9 @@ func (g *PGGenerator) convertMatchAgainst(sql string) string {
 		// Replace
 		result = result[:matchIdx] + pgExpr + result[againstParenEnd+1:]
 		resultUpper = strings.ToUpper(result)
+		searchPos = matchIdx + len(pgExpr)
 	}
 
-	// Restore previously marked MATCH
-	result = strings.ReplaceAll(result, "xMATCH", "MATCH")
-
 	return result
 }
 
@@ -642,33 +527,6 @@ func (g *PGGenerator) convertMatchOperator(sql string) string {
 	return result
 }
 
-// removeCharsetPrefixes removes MySQL character set prefixes from string literals
-// MySQL: _UTF8MB4'text', _UTF8'text', _LATIN1'text'
-// PostgreSQL: 'text'
-func (g *PGGenerator) removeCharsetPrefixes(sql string) string {
-	result := sql
-
-	// Common MySQL character set prefixes
-	charsets := []string{
-		"_UTF8MB4", "_utf8mb4",
-		"_UTF8", "_utf8",
-		"_LATIN1", "_latin1",
-		"_ASCII", "_ascii",
-		"_BINARY", "_binary",
-		"_UCS2", "_ucs2",
-		"_UTF16", "_utf16",
-		"_UTF32", "_utf32",
-	}
-
-	for _, charset := range charsets {
-		// Pattern: _CHARSET'...' or _CHARSET"..."
-		result = strings.ReplaceAll(result, charset+"'", "'")
-		result = strings.ReplaceAll(result, charset+"\"", "\"")
-	}
-
-	return result
-}
-
 // convertAutoIncrement converts MySQL AUTO_INCREMENT to PostgreSQL SERIAL
 // MySQL: INT AUTO_INCREMENT PRIMARY KEY
 // PostgreSQL: SERIAL PRIMARY KEY
@@ -689,85 +547,6 @@ func (g *PGGenerator) convertAutoIncrement(sql string) string {
 	return result
 }
 
-// convertInsertNullToDefault converts NULL to DEFAULT in INSERT VALUES clauses
-// MySQL's AUTO_INCREMENT accepts NULL and auto-generates the next value
-// PostgreSQL's SERIAL requires DEFAULT instead of NULL
-// Pattern: INSERT INTO table (...) VALUES (NULL, ...) -> INSERT INTO table (...) VALUES (DEFAULT, ...)
-func (g *PGGenerator) convertInsertNullToDefault(sql string) string {
-	result := sql
-	resultUpper := strings.ToUpper(result)
-
-	// Only process if this is an INSERT statement
-	if !strings.HasPrefix(resultUpper, "INSERT") {
-		return result
-	}
-
-	// Find VALUES keyword
-	valuesIdx := strings.Index(resultUpper, "VALUES")
-	if valuesIdx == -1 {
-		return result
-	}
-
-	// Find opening parenthesis after VALUES
-	searchPos := valuesIdx + 6 // Skip "VALUES"
-	for searchPos < len(result) && (result[searchPos] == ' ' || result[searchPos] == '\n' || result[searchPos] == '\t') {
-		searchPos++
-	}
-
-	if searchPos >= len(result) || result[searchPos] != '(' {
-		return result
-	}
-
-	// Process each VALUES clause (for multi-row INSERT)
-	for searchPos < len(result) {
-		// Find the opening parenthesis
-		parenStart := strings.IndexByte(result[searchPos:], '(')
-		if parenStart == -1 {
-			break
-		}
-		parenStart += searchPos
-
-		// Find the matching closing parenthesis
-		parenEnd := g.findMatchingParen(result, parenStart)
-		if parenEnd == -1 {
-			break
-		}
-
-		// Check if the VALUES clause starts with NULL (possibly with spaces)
-		valueStart := parenStart + 1
-		for valueStart < parenEnd && (result[valueStart] == ' ' || result[valueStart] == '\n' || result[valueStart] == '\t') {
-			valueStart++
-		}
-
-		// Check if it's NULL (case-insensitive)
-		if valueStart+4 <= parenEnd {
-			nullCheck := strings.ToUpper(result[valueStart : valueStart+4])
-			if nullCheck == "NULL" {
-				// Make sure it's a complete word (followed by space, comma, or paren)
-				if valueStart+4 == parenEnd || result[valueStart+4] == ',' || result[valueStart+4] == ' ' || result[valueStart+4] == '\n' || result[valueStart+4] == '\t' || result[valueStart+4] == ')' {
-					// Replace NULL with DEFAULT
-					result = result[:valueStart] + "DEFAULT" + result[valueStart+4:]
-					// Adjust parenEnd since we changed the length
-					parenEnd = parenEnd - 4 + 7 // -4 for NULL, +7 for DEFAULT
-				}
-			}
-		}
-
-		// Move to next VALUES clause
-		searchPos = parenEnd + 1
-		// Skip to next opening paren (if exists)
-		for searchPos < len(result) && result[searchPos] != '(' {
-			if result[searchPos] == ';' {
-				// End of statement
-				break
-			}
-			searchPos++
-		}
-	}
-
-	return result
-}
-
 // convertEnum converts MySQL ENUM types to PostgreSQL VARCHAR
 // MySQL: ENUM('value1', 'value2', ...)
 // PostgreSQL: VARCHAR(50)
@@ -1091,11 +870,12 @@ func (g *PGGenerator) removeTableOptions(sql string) string {
 
 		// Should have = after keyword
 		if i >= len(result) || result[i] != '=' {
-			// Not a charset definition, mark and skip
+			// Not a charset definition, mark and skip. Markers must not contain the keyword
+			// itself, or the search would find it again
 			if isCharacterSet {
 				result = result[:idx] + "xCHARACTERxSETx" + result[idx+keywordLen:]
 			} else {
-				result = result[:idx] + "xCHARSET" + result[idx+keywordLen:]
+				result = result[:idx] + "xCHARxSET" + result[idx+keywordLen:]
 			}
 			continue
 		}
@@ -1117,7 +897,7 @@ func (g *PGGenerator) removeTableOptions(sql string) string {
 	}
 
 	// Restore previously marked keywords
-	result = strings.ReplaceAll(result, "xCHARSET", "CHARSET")
+	result = strings.ReplaceAll(result, "xCHARxSET", "CHARSET")
 	result = strings.ReplaceAll(result, "xCHARACTERxSETx", "CHARACTER SET")
 
 	// Remove COLLATE=xxx
@@ -1135,7 +915,7 @@ func (g *PGGenerator) removeTableOptions(sql string) string {
 
 		// Should have = after COLLATE
 		if i >= len(result) || result[i] != '=' {
-			result = result[:idx] + "xCOLLATE" + result[idx+7:]
+			result = result[:idx] + "xCOLLxATE" + result[idx+7:]
 			continue
 		}
 
@@ -1156,7 +936,7 @@ func (g *PGGenerator) removeTableOptions(sql string) string {
 	}
 
 	// Restore previously marked COLLATE
-	result = strings.ReplaceAll(result, "xCOLLATE", "COLLATE")
+	result = strings.ReplaceAll(result, "xCOLLxATE", "COLLATE")
 
 	// Clean up trailing spaces and commas before )
 	// Replace pattern: space/comma before ) with just )
@@ -0,0 +1,124 @@
+package sqlrewrite
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RawStatement classifies PostgreSQL SQL sent as written, see the /*aproxy:raw*/
+// directive. The text protocol has no placeholders, ? is left to PostgreSQL's
+// jsonb operators
+func RawStatement(sql string) *Statement {
+	sql = strings.TrimSpace(sql)
+	stmt := &Statement{SQL: sql, Type: statementTypeOfKeyword(sql)}
+	if _, returning := splitReturning(sql); returning != "" {
+		stmt.Returning = true
+	}
+	return stmt
+}
+
+// RawPrepared is RawStatement for a prepared statement. MySQL ? placeholders become
+// $1, $2, ... unless the statement numbers its own, which keeps the jsonb ? operators
+// usable. It returns the number of parameters
+//
+//	SELECT data->>'name' FROM docs WHERE id = ?         -> ... WHERE id = $1
+//	SELECT id FROM docs WHERE data ? 'tag' AND id > $1  -> unchanged
+//
+// Quoted strings and identifiers, dollar-quoted bodies and comments are left alone
+func RawPrepared(sql string) (*Statement, int) {
+	stmt := RawStatement(sql)
+	sql = stmt.SQL
+
+	numbered := 0
+	var questions []int
+	for i := 0; i < len(sql); {
+		end := skipPGToken(sql, i)
+		if end > i {
+			i = end
+			continue
+		}
+		switch c := sql[i]; {
+		case c == '?':
+			questions = append(questions, i)
+		case c == '$' && i+1 < len(sql) && isDigit(sql[i+1]) && (i == 0 || !isVariableChar(sql[i-1])):
+			j := i + 1
+			for j < len(sql) && isDigit(sql[j]) {
+				j++
+			}
+			if n, err := strconv.Atoi(sql[i+1 : j]); err == nil && n > numbered {
+				numbered = n
+			}
+			i = j
+			continue
+		}
+		i++
+	}
+	if numbered > 0 || len(questions) == 0 {
+		return stmt, numbered
+	}
+
+	var b strings.Builder
+	last := 0
+	for n, pos := range questions {
+		b.WriteString(sql[last:pos])
+		b.WriteString("$" + strconv.Itoa(n+1))
+		last = pos + 1
+	}
+	b.WriteString(sql[last:])
+	stmt.SQL = b.String()
+	return stmt, len(questions)
+}
+
+// skipPGToken returns the end of the quoted string, quoted identifier, dollar-quoted
+// body or comment starting at i in PostgreSQL SQL, i when there is none
+func skipPGToken(sql string, i int) int {
+	switch c := sql[i]; {
+	case c == '\'':
+		// E'...' strings take backslash escapes, standard strings do not
+		escapes := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i == 1 || !isVariableChar(sql[i-2]))
+		for j := i + 1; j < len(sql); j++ {
+			switch {
+			case sql[j] == '\\' && escapes:
+				j++
+			case sql[j] == '\'' && j+1 < len(sql) && sql[j+1] == '\'':
+				j++
+			case sql[j] == '\'':
+				return j + 1
+			}
+		}
+		return len(sql)
+	case c == '"':
+		return skipQuoted(sql, i)
+	case c == '-' && strings.HasPrefix(sql[i:], "--"):
+		if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+			return i + end + 1
+		}
+		return len(sql)
+	case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+		if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 2
+		}
+		return len(sql)
+	case c == '$' && (i == 0 || !isVariableChar(sql[i-1])):
+		// $tag$ ... $tag$, the tag is empty or an identifier
+		end := strings.IndexByte(sql[i+1:], '$')
+		if end < 0 {
+			return i
+		}
+		tag := sql[i : i+1+end+1]
+		for k := 1; k < len(tag)-1; k++ {
+			if !isVariableChar(tag[k]) || tag[k] == '$' || tag[k] == '.' || k == 1 && isDigit(tag[k]) {
+				return i
+			}
+		}
+		if close := strings.Index(sql[i+len(tag):], tag); close >= 0 {
+			return i + len(tag) + close + len(tag)
+		}
+		return len(sql)
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
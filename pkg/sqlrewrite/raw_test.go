@@ -0,0 +1,54 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawStatement(t *testing.T) {
+	tests := []struct {
+		sql       string
+		typ       StatementType
+		returning bool
+	}{
+		{"SELECT '{\"a\":1}'::jsonb ? 'a'", StatementSelect, false},
+		{"INSERT INTO t (a) VALUES (1) RETURNING id", StatementInsert, true},
+		{"INSERT INTO t (a) VALUES ('RETURNING')", StatementInsert, false},
+		{"CREATE INDEX CONCURRENTLY i ON t USING gin (data)", StatementDDL, false},
+		{"DO $$ BEGIN PERFORM 1; END $$", StatementOther, false},
+	}
+	for _, tt := range tests {
+		stmt := RawStatement("  " + tt.sql + " ")
+		assert.Equal(t, tt.sql, stmt.SQL, "sent as written")
+		assert.Equal(t, tt.typ, stmt.Type, tt.sql)
+		assert.Equal(t, tt.returning, stmt.Returning, tt.sql)
+	}
+}
+
+func TestRawPrepared(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+		params   int
+	}{
+		{"MySQL placeholders", "SELECT data->>'name' FROM docs WHERE id = ? AND kind = ?::text",
+			"SELECT data->>'name' FROM docs WHERE id = $1 AND kind = $2::text", 2},
+		{"PostgreSQL placeholders", "SELECT id FROM docs WHERE data ? 'tag' AND id > $2 AND id < $1",
+			"SELECT id FROM docs WHERE data ? 'tag' AND id > $2 AND id < $1", 2},
+		{"Strings", "SELECT 'a?', E'\\'?', 'it''s ?', ?", "SELECT 'a?', E'\\'?', 'it''s ?', $1", 1},
+		{"Standard strings take no backslash escapes", `SELECT 'a\', ?`, `SELECT 'a\', $1`, 1},
+		{"Identifiers", `SELECT "a?" FROM t WHERE b = ?`, `SELECT "a?" FROM t WHERE b = $1`, 1},
+		{"Dollar quoting", "SELECT $$ ? $1 $$, $fn$ ? $fn$, ?", "SELECT $$ ? $1 $$, $fn$ ? $fn$, $1", 1},
+		{"Comments", "SELECT ? -- ?\n/* $1 ? */ FROM t", "SELECT $1 -- ?\n/* $1 ? */ FROM t", 1},
+		{"No parameters", "SELECT now()::date", "SELECT now()::date", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, params := RawPrepared(tt.sql)
+			assert.Equal(t, tt.expected, stmt.SQL)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
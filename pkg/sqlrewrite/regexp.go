@@ -0,0 +1,227 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// MySQL's regex functions take their optional arguments in this order after the pattern
+// (and the replacement for REGEXP_REPLACE)
+//
+//	REGEXP_LIKE(expr, pat[, match_type])
+//	REGEXP_REPLACE(expr, pat, repl[, pos[, occurrence[, match_type]]])
+//	REGEXP_SUBSTR(expr, pat[, pos[, occurrence[, match_type]]])
+//	REGEXP_INSTR(expr, pat[, pos[, occurrence[, return_option[, match_type]]]])
+
+// transformRegexpLike converts REGEXP_LIKE to PostgreSQL's match operators
+func (v *ASTVisitor) transformRegexpLike(node *ast.FuncCallExpr) ast.Node {
+	if len(node.Args) < 2 || len(node.Args) > 3 {
+		v.err = fmt.Errorf("REGEXP_LIKE requires 2 or 3 arguments, got %d", len(node.Args))
+		return node
+	}
+	flags, err := regexpFlags(node.Args[2:])
+	if err != nil {
+		v.err = err
+		return node
+	}
+	return &pgRegexpMatchExpr{ExprNode: node.Args[0], Pattern: node.Args[1], Flags: flags}
+}
+
+// transformRegexpFunc converts REGEXP_REPLACE, REGEXP_SUBSTR and REGEXP_INSTR. Position,
+// occurrence, return option and match type must be literals, as they shape the generated SQL
+func (v *ASTVisitor) transformRegexpFunc(node *ast.FuncCallExpr) ast.Node {
+	fn := strings.TrimPrefix(node.FnName.L, "regexp_")
+	name := strings.ToUpper(node.FnName.O)
+
+	// Arguments before the optional ones, and how many optional ones there can be
+	required, optional := 2, 3
+	switch fn {
+	case "replace":
+		required = 3
+	case "instr":
+		optional = 4
+	}
+	if len(node.Args) < required || len(node.Args) > required+optional {
+		v.err = fmt.Errorf("%s requires %d to %d arguments, got %d", name, required, required+optional, len(node.Args))
+		return node
+	}
+
+	expr := &pgRegexpFuncExpr{
+		ExprNode:   node.Args[0],
+		Fn:         fn,
+		Pattern:    node.Args[1],
+		Position:   1,
+		Occurrence: 1,
+	}
+	if fn == "replace" {
+		expr.Replacement = regexpReplacement(node.Args[2])
+		expr.Occurrence = 0
+	}
+
+	// Numeric options up to the match type
+	rest := node.Args[required:]
+	numbers := []*int64{&expr.Position, &expr.Occurrence}
+	returnOption := int64(0)
+	if fn == "instr" {
+		numbers = append(numbers, &returnOption)
+	}
+	for i, target := range numbers {
+		if i >= len(rest) {
+			break
+		}
+		value, ok := regexpIntArg(rest[i])
+		if !ok {
+			v.err = fmt.Errorf("%s supports only literal position, occurrence and return option arguments", name)
+			return node
+		}
+		*target = value
+	}
+
+	flags, err := regexpFlags(rest[min(len(numbers), len(rest)):])
+	if err != nil {
+		v.err = err
+		return node
+	}
+	expr.Flags = flags
+
+	switch {
+	case expr.Position < 1:
+		v.err = fmt.Errorf("%s position must be at least 1, got %d", name, expr.Position)
+	case fn == "replace" && expr.Occurrence > 1:
+		v.err = fmt.Errorf("REGEXP_REPLACE supports replacing all matches (0) or the first (1), got occurrence %d", expr.Occurrence)
+	case fn == "replace" && expr.Position > 1 && containsParamMarker(expr.ExprNode):
+		v.err = fmt.Errorf("REGEXP_REPLACE with a position needs the subject twice, which a placeholder cannot provide")
+	case fn == "instr" && expr.Occurrence != 1:
+		v.err = fmt.Errorf("REGEXP_INSTR supports only the first occurrence, got %d", expr.Occurrence)
+	case fn == "instr" && returnOption != 0:
+		v.err = fmt.Errorf("REGEXP_INSTR supports only return option 0 (match start), got %d", returnOption)
+	}
+	if v.err != nil {
+		return node
+	}
+	return expr
+}
+
+// transformRegexpOperator converts expr [NOT] REGEXP/RLIKE pat. Without a match type MySQL
+// follows the collation, which is case-insensitive for the proxy's default
+func (v *ASTVisitor) transformRegexpOperator(node *ast.PatternRegexpExpr) ast.Node {
+	return &pgRegexpMatchExpr{ExprNode: node.Expr, Pattern: node.Pattern, Flags: "i", Not: node.Not}
+}
+
+// regexpFlags translates an optional MySQL match type literal to PostgreSQL regex flags
+//
+//	c -> c (case-sensitive)       i -> i (case-insensitive, the default)
+//	m -> w (^ and $ match at line terminators)
+//	n -> . matches line terminators, which PostgreSQL always does without n/p flags
+//	u -> Unix line endings only, PostgreSQL only knows \n
+//
+// The last of c and i wins, as in MySQL
+func regexpFlags(args []ast.ExprNode) (string, error) {
+	if len(args) == 0 {
+		return "i", nil
+	}
+	value, ok := args[0].(*driver.ValueExpr)
+	if !ok || value.Kind() != driver.KindString {
+		return "", fmt.Errorf("regexp match type must be a string literal")
+	}
+
+	caseFlag, multiline := "i", false
+	for _, c := range value.GetString() {
+		switch c {
+		case 'c', 'i':
+			caseFlag = string(c)
+		case 'm':
+			multiline = true
+		case 'n', 'u':
+		default:
+			return "", fmt.Errorf("invalid regexp match type: %q", value.GetString())
+		}
+	}
+	if multiline {
+		return caseFlag + "w", nil
+	}
+	return caseFlag, nil
+}
+
+// regexpReplacement translates a literal replacement string from ICU to PostgreSQL syntax:
+// group references $1 become \1 and $0 becomes \&. Backslash escapes the next character in
+// ICU, PostgreSQL only needs it for a literal backslash
+func regexpReplacement(expr ast.ExprNode) ast.ExprNode {
+	value, ok := expr.(*driver.ValueExpr)
+	if !ok || value.Kind() != driver.KindString {
+		return expr
+	}
+
+	in := value.GetString()
+	var sb strings.Builder
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		switch {
+		case c == '\\' && i+1 < len(in):
+			i++
+			if in[i] == '\\' {
+				sb.WriteString(`\\`)
+			} else {
+				sb.WriteByte(in[i])
+			}
+		case c == '$' && i+1 < len(in) && in[i+1] == '0':
+			i++
+			sb.WriteString(`\&`)
+		case c == '$' && i+1 < len(in) && in[i+1] >= '1' && in[i+1] <= '9':
+			i++
+			sb.WriteByte('\\')
+			sb.WriteByte(in[i])
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return ast.NewValueExpr(sb.String(), "", "")
+}
+
+// regexpIntArg returns the value of an integer literal argument
+func regexpIntArg(expr ast.ExprNode) (int64, bool) {
+	value, ok := expr.(*driver.ValueExpr)
+	if !ok {
+		return 0, false
+	}
+	switch value.Kind() {
+	case driver.KindInt64:
+		return value.GetInt64(), true
+	case driver.KindUint64:
+		return int64(value.GetUint64()), true
+	}
+	return 0, false
+}
+
+// containsParamMarker reports whether an expression contains a ? placeholder
+func containsParamMarker(expr ast.ExprNode) bool {
+	return countParamMarkers(expr) > 0
+}
+
+// countParamMarkers counts the ? placeholders of a node
+func countParamMarkers(node ast.Node) int {
+	finder := &paramMarkerFinder{}
+	node.Accept(finder)
+	return finder.count
+}
+
+// paramMarkerFinder counts ? placeholders
+type paramMarkerFinder struct {
+	count int
+}
+
+// Enter implements ast.Visitor interface
+func (f *paramMarkerFinder) Enter(n ast.Node) (ast.Node, bool) {
+	if _, ok := n.(*driver.ParamMarkerExpr); ok {
+		f.count++
+	}
+	return n, false
+}
+
+// Leave implements ast.Visitor interface
+func (f *paramMarkerFinder) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
@@ -0,0 +1,93 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteRegexp(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "REGEXP and RLIKE",
+			mysql:    "SELECT id FROM t WHERE a REGEXP '^x' AND b NOT RLIKE 'y$'",
+			expected: `SELECT "id" FROM "t" WHERE "a" ~* '^x' AND "b" !~* 'y$'`,
+		},
+		{
+			name:     "REGEXP_LIKE with flags",
+			mysql:    "SELECT REGEXP_LIKE(a, 'x'), REGEXP_LIKE(a, 'x', 'c'), REGEXP_LIKE(a, '^x', 'cm'), REGEXP_LIKE(a, 'x', 'ci')",
+			expected: `SELECT "a" ~* 'x',"a" ~ 'x',"a" ~ '(?cw)^x',"a" ~* 'x'`,
+		},
+		{
+			name:     "REGEXP_REPLACE replaces every match",
+			mysql:    "SELECT REGEXP_REPLACE(name, '(\\\\w+) (\\\\w+)', '$2, $1') FROM t",
+			expected: `SELECT regexp_replace("name", '(\w+) (\w+)', '\2, \1', 'gi') FROM "t"`,
+		},
+		{
+			name:     "REGEXP_REPLACE with position, occurrence and flags",
+			mysql:    "SELECT REGEXP_REPLACE(a, 'x', 'y', 3, 1, 'c') FROM t",
+			expected: `SELECT (SUBSTRING("a" FROM 1 FOR 2) || regexp_replace(SUBSTRING("a" FROM 3), 'x', 'y', 'c')) FROM "t"`,
+		},
+		{
+			name:     "REGEXP_SUBSTR",
+			mysql:    "SELECT REGEXP_SUBSTR(a, '[0-9]+') FROM t",
+			expected: `SELECT (SELECT r.m[1] FROM regexp_matches("a", '([0-9]+)', 'gi') AS r(m) LIMIT 1) FROM "t"`,
+		},
+		{
+			name:     "REGEXP_SUBSTR with position, occurrence and flags",
+			mysql:    "SELECT REGEXP_SUBSTR(a, 'ab', 2, 3, 'cm') FROM t",
+			expected: `SELECT (SELECT r.m[1] FROM regexp_matches(SUBSTRING("a" FROM 2), '(ab)', 'gcw') AS r(m) LIMIT 1 OFFSET 2) FROM "t"`,
+		},
+		{
+			name:     "REGEXP_INSTR",
+			mysql:    "SELECT REGEXP_INSTR(a, 'b+') FROM t",
+			expected: `SELECT COALESCE(LENGTH(SUBSTRING("a" FROM '(?i)^(.*?)(?:b+)')) + 1, 0) FROM "t"`,
+		},
+		{
+			name:     "REGEXP_INSTR with position and flags",
+			mysql:    "SELECT REGEXP_INSTR(a, 'B', 4, 1, 0, 'c') FROM t",
+			expected: `SELECT COALESCE(LENGTH(SUBSTRING(SUBSTRING("a" FROM 4) FROM '(?c)^(.*?)(?:B)')) + 4, 0) FROM "t"`,
+		},
+		{
+			name:     "Pattern from a placeholder",
+			mysql:    "SELECT REGEXP_SUBSTR(a, ?) FROM t WHERE b REGEXP ?",
+			expected: `SELECT (SELECT r.m[1] FROM regexp_matches("a", ('(' || $1 || ')'), 'gi') AS r(m) LIMIT 1) FROM "t" WHERE "b" ~* $2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	for _, sql := range []string{
+		"SELECT REGEXP_INSTR(a, 'x', 1, 2) FROM t",
+		"SELECT REGEXP_INSTR(a, 'x', 1, 1, 1) FROM t",
+		"SELECT REGEXP_REPLACE(a, 'x', 'y', 1, 2) FROM t",
+		"SELECT REGEXP_SUBSTR(a, 'x', ?) FROM t",
+		"SELECT REGEXP_LIKE(a, 'x', 'q') FROM t",
+	} {
+		t.Run("Unsupported: "+sql, func(t *testing.T) {
+			_, err := NewASTRewriter().Rewrite(sql)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("An unsupported call does not fail later statements", func(t *testing.T) {
+		_, err := rewriter.Rewrite("SELECT REGEXP_INSTR(a, 'x', 1, 2) FROM t")
+		require.Error(t, err)
+		result, err := rewriter.Rewrite("SELECT a FROM t WHERE a REGEXP 'x'")
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "a" FROM "t" WHERE "a" ~* 'x'`, result)
+	})
+}
@@ -4,13 +4,18 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
+// DefaultServerVersion is the MySQL version advertised to clients and returned by VERSION()
+const DefaultServerVersion = "8.0.11"
+
 // Rewriter is the main SQL rewriter using AST-based rewriting
 type Rewriter struct {
 	enabled            bool
 	astRewriter        *ASTRewriter
 	unsupportedDetector *UnsupportedDetector
+	versionTarget      int // Server version that /*!NNNNN ... */ comments are evaluated against
 }
 
 // NewRewriter creates a rewriter with AST rewriter
@@ -19,29 +24,159 @@ func NewRewriter(enabled bool) *Rewriter {
 		enabled:            enabled,
 		astRewriter:        NewASTRewriter(),
 		unsupportedDetector: NewUnsupportedDetector(),
+		versionTarget:      DefaultVersionCommentTarget,
+	}
+}
+
+// SetVersionCommentTarget sets the version /*!NNNNN ... */ comments are compared with
+func (r *Rewriter) SetVersionCommentTarget(version int) {
+	r.versionTarget = version
+}
+
+// SetServerVersion sets the version string VERSION() returns
+func (r *Rewriter) SetServerVersion(version string) {
+	if r.astRewriter != nil {
+		r.astRewriter.visitor.SetServerVersion(version)
+	}
+}
+
+// SetMaxAllowedPacket sets the value @@max_allowed_packet reads, 0 keeps the default
+func (r *Rewriter) SetMaxAllowedPacket(size int64) {
+	if r.astRewriter != nil {
+		r.astRewriter.visitor.SetMaxAllowedPacket(size)
+	}
+}
+
+// SetEnumOrderBy enables or disables sorting ENUM columns by declaration order
+func (r *Rewriter) SetEnumOrderBy(enabled bool) {
+	if r.astRewriter != nil {
+		r.astRewriter.visitor.SetEnumOrderBy(enabled)
+	}
+}
+
+// DeclaredColumnTypes returns the MySQL types of the ENUM and SET columns of a table
+// created through the proxy, keyed by lower-case column name. PostgreSQL stores them
+// as VARCHAR
+func (r *Rewriter) DeclaredColumnTypes(table string) map[string]string {
+	if r.astRewriter == nil {
+		return nil
+	}
+	return r.astRewriter.visitor.enums.Declarations(table)
+}
+
+// TablesToLoad returns the tables sql reads or writes that were not created through the
+// proxy. Their columns are read from the database and recorded with LoadTable before
+// the statement is rewritten
+func (r *Rewriter) TablesToLoad(sql string, userVars map[string]interface{}) []string {
+	if !r.enabled || r.astRewriter == nil {
+		return nil
+	}
+	body, _ := splitReturning(strings.TrimSpace(sql))
+	return r.astRewriter.tablesToLoad(body, userVars)
+}
+
+// LoadTable records the columns and unique keys of a table created outside the proxy,
+// read from the database at readAt, see ColumnTypeRegistry.LoadTable
+func (r *Rewriter) LoadTable(table string, columns []TableColumn, keys [][]string, readAt time.Time) {
+	if r.astRewriter != nil {
+		r.astRewriter.visitor.columnTypes.LoadTable(table, columns, keys, readAt)
+	}
+}
+
+// SetGreatestLeastNulls sets whether GREATEST and LEAST return NULL for a NULL
+// argument like MySQL, false keeps PostgreSQL's semantics of skipping NULLs
+func (r *Rewriter) SetGreatestLeastNulls(enabled bool) {
+	if r.astRewriter != nil {
+		r.astRewriter.visitor.SetGreatestLeastNulls(enabled)
+	}
+}
+
+// SetZeroDatePolicy sets what INSERT and UPDATE write for MySQL's zero dates
+func (r *Rewriter) SetZeroDatePolicy(policy ZeroDatePolicy) {
+	if r.astRewriter != nil {
+		r.astRewriter.visitor.SetZeroDatePolicy(policy)
 	}
 }
 
+// SetFunctionMappings adds custom MySQL -> PostgreSQL function renames
+func (r *Rewriter) SetFunctionMappings(mappings map[string]string) error {
+	if r.astRewriter == nil {
+		return nil
+	}
+	return r.astRewriter.visitor.AddFunctionMappings(mappings)
+}
+
+// StripComments resolves version comments and removes regular comments,
+// see the package-level StripComments
+func (r *Rewriter) StripComments(sql string) string {
+	return StripComments(sql, r.versionTarget)
+}
+
 // Rewrite rewrites a MySQL SQL statement to PostgreSQL using AST rewriter
 func (r *Rewriter) Rewrite(sql string) (string, error) {
+	return r.RewriteWithUserVars(sql, nil)
+}
+
+// RewriteWithUserVars rewrites a MySQL SQL statement, resolving @name references
+// against the session's user variables
+func (r *Rewriter) RewriteWithUserVars(sql string, userVars map[string]interface{}) (string, error) {
+	stmt, err := r.RewriteStatement(sql, userVars)
+	return stmt.SQL, err
+}
+
+// RewriteStatement rewrites a MySQL SQL statement like RewriteWithUserVars and
+// reports its type from the parsed AST. A trailing RETURNING clause is carried over
+func (r *Rewriter) RewriteStatement(sql string, userVars map[string]interface{}) (*Statement, error) {
 	if !r.enabled {
-		return sql, nil
+		return &Statement{SQL: sql, Type: statementTypeOfKeyword(sql)}, nil
 	}
 
 	sql = strings.TrimSpace(sql)
 
+	// Stored routines and triggers are passed through or refused, the parser cannot translate them
+	if routine, err := checkRoutine(sql); routine {
+		return &Statement{SQL: sql, Type: StatementDDL}, err
+	}
+
 	// Use AST rewriter
 	if r.astRewriter != nil {
-		rewritten, err := r.astRewriter.Rewrite(sql)
+		body, returning := splitReturning(sql)
+		body, checkOption := splitCheckOption(normalizeAlterView(body))
+		stmt, err := r.astRewriter.rewrite(body, userVars)
+		if err == nil && checkOption != "" {
+			stmt.SQL += " " + checkOption
+		}
+		if err == nil && returning != "" {
+			// Rewrite the column list as a select list to get the same quoting and functions
+			var list *Statement
+			if list, err = r.astRewriter.rewrite("SELECT "+returning, userVars); err == nil {
+				stmt.SQL += " RETURNING " + strings.TrimPrefix(list.SQL, "SELECT ")
+				stmt.Returning = true
+			}
+		}
 		if err == nil {
-			return rewritten, nil
+			return stmt, nil
 		}
 		// Log error and return original SQL
 		fmt.Fprintf(os.Stderr, "AST rewriter failed: %v\n", err)
-		return sql, err
+		return &Statement{SQL: sql, Type: statementTypeOfKeyword(sql)}, r.classifyFailure(sql, err)
 	}
 
-	return sql, nil
+	return &Statement{SQL: sql, Type: statementTypeOfKeyword(sql)}, nil
+}
+
+// classifyFailure attributes a rewrite failure to a known unsupported feature
+// when the detector recognizes one, so metrics point at what to implement next
+func (r *Rewriter) classifyFailure(sql string, err error) error {
+	if r.unsupportedDetector == nil {
+		return err
+	}
+	for _, feature := range r.unsupportedDetector.Detect(sql) {
+		if feature.Severity == "error" {
+			return &RewriteError{Reason: ReasonUnsupported, Feature: feature.Feature, Err: err}
+		}
+	}
+	return err
 }
 
 // DetectUnsupported detects unsupported MySQL features in SQL
@@ -54,10 +189,21 @@ func (r *Rewriter) DetectUnsupported(sql string) []UnsupportedFeature {
 
 // RewritePrepared rewrites a prepared statement and returns the parameter count
 func (r *Rewriter) RewritePrepared(sql string) (string, int, error) {
-	rewritten, err := r.Rewrite(sql)
+	stmt, paramCount, err := r.RewritePreparedStatement(sql, nil)
 	if err != nil {
 		return "", 0, err
 	}
+	return stmt.SQL, paramCount, nil
+}
+
+// RewritePreparedStatement is RewritePrepared that also reports the statement type,
+// @name references resolve against the session's user variables at prepare time
+func (r *Rewriter) RewritePreparedStatement(sql string, userVars map[string]interface{}) (*Statement, int, error) {
+	stmt, err := r.RewriteStatement(sql, userVars)
+	if err != nil {
+		return nil, 0, err
+	}
+	rewritten := stmt.SQL
 
 	// Count placeholders ($ followed by digits)
 	paramCount := 0
@@ -77,7 +223,7 @@ func (r *Rewriter) RewritePrepared(sql string) (string, int, error) {
 		}
 	}
 
-	return rewritten, paramCount, nil
+	return stmt, paramCount, nil
 }
 
 // Helper methods for statement type checking
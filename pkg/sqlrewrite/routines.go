@@ -0,0 +1,52 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// CREATE [OR REPLACE] [DEFINER = user] [AGGREGATE] {TRIGGER | FUNCTION | PROCEDURE}
+	routineStmtRe = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:AGGREGATE\s+)?(TRIGGER|FUNCTION|PROCEDURE)\s`)
+	// PostgreSQL bodies are dollar-quoted, which MySQL has no syntax for
+	dollarQuotedBodyRe = regexp.MustCompile(`\$[A-Za-z_]*\$`)
+	languageClauseRe   = regexp.MustCompile(`(?i)\bLANGUAGE\s+'?[a-z]+'?`)
+	// PostgreSQL triggers call a trigger function instead of containing a statement
+	executeFunctionRe = regexp.MustCompile(`(?i)\bEXECUTE\s+(?:FUNCTION|PROCEDURE)\s+[\w."]+\s*\(`)
+)
+
+// checkRoutine handles CREATE TRIGGER, FUNCTION and PROCEDURE, whose MySQL procedural
+// bodies cannot be translated. routine reports whether sql is one of them. Statements
+// already written for PostgreSQL (a dollar-quoted body with a LANGUAGE clause, or a
+// trigger executing a function) pass through with a nil error
+func checkRoutine(sql string) (routine bool, err error) {
+	m := routineStmtRe.FindStringSubmatch(sql)
+	if m == nil {
+		return false, nil
+	}
+	kind := strings.ToUpper(m[1])
+	feature := "CREATE " + kind
+
+	if kind == "TRIGGER" {
+		if executeFunctionRe.MatchString(sql) {
+			return true, nil
+		}
+		return true, &RewriteError{
+			Reason:  ReasonUnsupported,
+			Feature: feature,
+			Err: fmt.Errorf("CREATE TRIGGER with a MySQL trigger body is not supported: " +
+				"create a PL/pgSQL trigger function (RETURNS trigger ... LANGUAGE plpgsql) and CREATE TRIGGER ... EXECUTE FUNCTION it"),
+		}
+	}
+
+	if dollarQuotedBodyRe.MatchString(sql) && languageClauseRe.MatchString(sql) {
+		return true, nil
+	}
+	return true, &RewriteError{
+		Reason:  ReasonUnsupported,
+		Feature: feature,
+		Err: fmt.Errorf("%s with a MySQL routine body is not supported: "+
+			"write the body in PL/pgSQL, quoted as AS $$ ... $$ LANGUAGE plpgsql", feature),
+	}
+}
@@ -0,0 +1,61 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteRoutines(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	t.Run("MySQL trigger body is refused", func(t *testing.T) {
+		sql := "CREATE DEFINER=`root`@`%` TRIGGER orders_bi BEFORE INSERT ON orders FOR EACH ROW SET NEW.created_at = NOW()"
+		_, err := rewriter.Rewrite(sql)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CREATE TRIGGER with a MySQL trigger body is not supported")
+		assert.Contains(t, err.Error(), "EXECUTE FUNCTION")
+
+		reason, feature := RewriteFailureLabels(err)
+		assert.Equal(t, ReasonUnsupported, reason)
+		assert.Equal(t, "CREATE TRIGGER", feature)
+
+		features := rewriter.DetectUnsupported(sql)
+		require.Len(t, features, 1)
+		assert.Equal(t, "CREATE TRIGGER", features[0].Feature)
+		assert.Equal(t, "warning", features[0].Severity)
+	})
+
+	t.Run("MySQL routine bodies are refused", func(t *testing.T) {
+		for sql, feature := range map[string]string{
+			"CREATE FUNCTION add_tax(p DECIMAL(10,2)) RETURNS DECIMAL(10,2) DETERMINISTIC LANGUAGE SQL RETURN p * 1.2": "CREATE FUNCTION",
+			"CREATE PROCEDURE purge() BEGIN DELETE FROM logs WHERE created_at < NOW() - INTERVAL 30 DAY; END":          "CREATE PROCEDURE",
+		} {
+			_, err := rewriter.Rewrite(sql)
+			require.Error(t, err, sql)
+			assert.Contains(t, err.Error(), "PL/pgSQL")
+			_, label := RewriteFailureLabels(err)
+			assert.Equal(t, feature, label)
+		}
+	})
+
+	t.Run("PostgreSQL routines pass through", func(t *testing.T) {
+		for _, sql := range []string{
+			"CREATE OR REPLACE FUNCTION set_created() RETURNS trigger AS $$ BEGIN NEW.created_at := now(); RETURN NEW; END; $$ LANGUAGE plpgsql",
+			"CREATE PROCEDURE purge() LANGUAGE sql AS $body$ DELETE FROM logs $body$",
+			"CREATE TRIGGER orders_bi BEFORE INSERT ON orders FOR EACH ROW EXECUTE FUNCTION set_created()",
+		} {
+			stmt, err := rewriter.RewriteStatement(sql, nil)
+			require.NoError(t, err, sql)
+			assert.Equal(t, sql, stmt.SQL)
+			assert.Equal(t, StatementDDL, stmt.Type)
+		}
+	})
+
+	t.Run("Other statements are not routines", func(t *testing.T) {
+		routine, err := checkRoutine("CREATE TABLE triggers (function VARCHAR(10))")
+		assert.False(t, routine)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,345 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// UserVarCounter is a user variable a SELECT numbers its rows with, see
+// rewriteRowCounters. In MySQL the variable holds the last row's number afterwards
+type UserVarCounter struct {
+	Name  string      // Lowercased
+	Start interface{} // Value before the first row, nil when unset
+	Step  int64
+
+	// CountSQL counts the rows numbered when they are not the statement's result rows:
+	// the counter is in a derived table or a UNION member. "" for the result rows
+	CountSQL string
+}
+
+// After returns the value of the variable once rows rows are numbered, nil when the
+// start is no integer (MySQL's NULL + 1 stays NULL)
+func (c UserVarCounter) After(rows int64) interface{} {
+	var start int64
+	switch value := c.Start.(type) {
+	case int64:
+		start = value
+	case uint64:
+		start = int64(value)
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil
+		}
+		start = n
+	default:
+		return nil
+	}
+	return start + c.Step*rows
+}
+
+// rewriteRowCounters rewrites the ranking idiom, a user variable incremented in the
+// select list, to ROW_NUMBER(). MySQL evaluates the assignment row after row, which
+// PostgreSQL has no equivalent for. The variable starts at the literal an initializer
+// joined in FROM assigns, which is dropped, or else at its session value. Rows are
+// numbered in the order of the statement's ORDER BY, without one in the order they
+// are read, as in MySQL
+// MySQL: SELECT @n := @n + 1 AS pos, name FROM players, (SELECT @n := 0) init ORDER BY score DESC
+// PostgreSQL: SELECT 0+ROW_NUMBER() OVER (ORDER BY "score" DESC) AS "pos","name" FROM "players" ORDER BY "score" DESC
+//
+// Each counter numbers the rows of its own SELECT, see UserVarCounter.CountSQL. Counters
+// in subquery expressions and CTEs are refused, their rows cannot be counted apart from
+// the statement. Other assignments inside expressions are refused, see Leave
+func (v *ASTVisitor) rewriteRowCounters(node *ast.SelectStmt) {
+	if node.Fields == nil {
+		return
+	}
+	steps := make(map[string]int64)
+	fields := make(map[string]*ast.SelectField)
+	for _, field := range node.Fields.Fields {
+		name, step, ok := rowCounter(field.Expr)
+		if !ok {
+			continue
+		}
+		if _, seen := steps[name]; seen {
+			// Numbered twice per row, left to fail
+			delete(fields, name)
+			continue
+		}
+		steps[name] = step
+		fields[name] = field
+	}
+	if len(fields) == 0 {
+		return
+	}
+	if v.exprSubqueries[node] {
+		v.err = fmt.Errorf("numbering rows with a user variable in a subquery expression or CTE is not supported; use ROW_NUMBER() OVER (...)")
+		return
+	}
+
+	// A lone initializer stays, the statement then has no rows to number
+	starts := make(map[string]interface{})
+	if node.From != nil && node.From.TableRefs.Right != nil {
+		node.From.TableRefs = dropInitializers(node.From.TableRefs, fields, starts)
+	}
+	for _, field := range node.Fields.Fields {
+		name, _, ok := rowCounter(field.Expr)
+		if !ok || fields[name] != field {
+			continue
+		}
+		start, ok := starts[name]
+		if !ok {
+			start = v.userVars[name]
+		}
+		if field.AsName.O == "" {
+			field.AsName = ast.NewCIStr(field.Text())
+		}
+		field.Expr = &pgRowNumberExpr{ExprNode: ast.NewValueExpr(start, "", ""), Step: steps[name], Select: node}
+		v.counters = append(v.counters, UserVarCounter{Name: name, Start: start, Step: steps[name]})
+		v.counterScopes = append(v.counterScopes, node)
+	}
+}
+
+// exprSubqueries returns the SELECTs of a statement inside subquery expressions,
+// including CTEs, at any depth
+func exprSubqueries(stmt ast.StmtNode) map[*ast.SelectStmt]bool {
+	collector := &exprSubqueryCollector{selects: make(map[*ast.SelectStmt]bool)}
+	stmt.Accept(collector)
+	return collector.selects
+}
+
+// exprSubqueryCollector collects the SELECTs for exprSubqueries
+type exprSubqueryCollector struct {
+	depth   int
+	selects map[*ast.SelectStmt]bool
+}
+
+// Enter implements ast.Visitor interface
+func (c *exprSubqueryCollector) Enter(n ast.Node) (ast.Node, bool) {
+	switch node := n.(type) {
+	case *ast.SubqueryExpr:
+		c.depth++
+	case *ast.SelectStmt:
+		if c.depth > 0 {
+			c.selects[node] = true
+		}
+	}
+	return n, false
+}
+
+// Leave implements ast.Visitor interface
+func (c *exprSubqueryCollector) Leave(n ast.Node) (ast.Node, bool) {
+	if _, ok := n.(*ast.SubqueryExpr); ok {
+		c.depth--
+	}
+	return n, true
+}
+
+// countRowCounters sets CountSQL on the counters of visitor numbering the rows of
+// another SELECT than stmt, from the rewritten SELECT
+// MySQL: SELECT * FROM (SELECT @n := @n + 1 AS pos, name FROM players) ranked WHERE pos <= 3
+// CountSQL: SELECT COUNT(*) FROM (SELECT 0+ROW_NUMBER() OVER () AS "pos","name" FROM "players") AS "numbered"
+func (r *ASTRewriter) countRowCounters(stmt ast.StmtNode, visitor *ASTVisitor) error {
+	for i, scope := range visitor.counterScopes {
+		if ast.Node(scope) == stmt {
+			continue
+		}
+		sql, err := r.generator.Generate(scope)
+		if err != nil {
+			return err
+		}
+		sql = expandInfoSchemaViews(r.generator.PostProcess(sql))
+		visitor.counters[i].CountSQL = `SELECT COUNT(*) FROM (` + sql + `) AS "numbered"`
+	}
+	return nil
+}
+
+// rowCounter matches @name := @name + step, step being an integer literal
+func rowCounter(expr ast.ExprNode) (string, int64, bool) {
+	assign, ok := unwrapParentheses(expr).(*ast.VariableExpr)
+	if !ok || assign.IsSystem || assign.Value == nil {
+		return "", 0, false
+	}
+	name := strings.ToLower(assign.Name)
+	sum, ok := unwrapParentheses(assign.Value).(*ast.BinaryOperationExpr)
+	if !ok || sum.Op != opcode.Plus && sum.Op != opcode.Minus {
+		return "", 0, false
+	}
+	variable, literal := sum.L, sum.R
+	if sum.Op == opcode.Plus && !isUserVar(variable, name) {
+		variable, literal = literal, variable
+	}
+	value, ok := literal.(*driver.ValueExpr)
+	if !ok || !isUserVar(variable, name) {
+		return "", 0, false
+	}
+	step, ok := value.GetValue().(int64)
+	if !ok {
+		return "", 0, false
+	}
+	if sum.Op == opcode.Minus {
+		step = -step
+	}
+	return name, step, true
+}
+
+// isUserVar reports whether expr reads the user variable name
+func isUserVar(expr ast.ExprNode, name string) bool {
+	ref, ok := unwrapParentheses(expr).(*ast.VariableExpr)
+	return ok && isUserVariableRef(ref) && strings.EqualFold(ref.Name, name)
+}
+
+// dropInitializers removes derived tables such as (SELECT @n := 0) AS init joined with
+// a comma or CROSS JOIN, when they only assign literals to counters, and records the
+// values assigned
+func dropInitializers(join *ast.Join, counters map[string]*ast.SelectField, starts map[string]interface{}) *ast.Join {
+	if join == nil || join.Right != nil && (join.Tp != ast.CrossJoin || join.On != nil || join.Using != nil) {
+		return join
+	}
+	sides := []*ast.ResultSetNode{&join.Left, &join.Right}
+	for _, side := range sides {
+		switch node := (*side).(type) {
+		case *ast.Join:
+			inner := dropInitializers(node, counters, starts)
+			if inner.Right == nil {
+				*side = inner.Left // A single table, PostgreSQL refuses it in parentheses
+			} else {
+				*side = inner
+			}
+		case *ast.TableSource:
+			if initializer(node, counters, starts) {
+				*side = nil
+			}
+		}
+	}
+	if join.Left == nil {
+		join.Left, join.Right = join.Right, nil
+	}
+	if inner, ok := join.Left.(*ast.Join); ok && join.Right == nil {
+		return inner
+	}
+	return join
+}
+
+// initializer reports whether source is (SELECT @a := literal, ...) assigning counters
+// only, and records the values
+func initializer(source *ast.TableSource, counters map[string]*ast.SelectField, starts map[string]interface{}) bool {
+	sel, ok := source.Source.(*ast.SelectStmt)
+	if !ok || sel.From != nil || sel.Where != nil || sel.Fields == nil {
+		return false
+	}
+	values := make(map[string]interface{})
+	for _, field := range sel.Fields.Fields {
+		assign, ok := field.Expr.(*ast.VariableExpr)
+		if !ok || assign.IsSystem || assign.Value == nil {
+			return false
+		}
+		name := strings.ToLower(assign.Name)
+		value, literal := assign.Value.(*driver.ValueExpr)
+		if _, counter := counters[name]; !literal || !counter {
+			return false
+		}
+		values[name] = value.GetValue()
+	}
+	for name, value := range values {
+		starts[name] = value
+	}
+	return true
+}
+
+// pgRowNumberExpr numbers the rows of a SELECT from a start value
+//
+//	@n := @n + 1 -> 0+ROW_NUMBER() OVER (ORDER BY "score" DESC)
+type pgRowNumberExpr struct {
+	ast.ExprNode // Start value
+	Step         int64
+	Select       *ast.SelectStmt // Ordered by its ORDER BY, restored as rewritten
+}
+
+// Restore implements ast.Node interface
+func (n *pgRowNumberExpr) Restore(ctx *format.RestoreCtx) error {
+	if err := n.ExprNode.Restore(ctx); err != nil {
+		return err
+	}
+	step := n.Step
+	if step < 0 {
+		ctx.WritePlain("-")
+		step = -step
+	} else {
+		ctx.WritePlain("+")
+	}
+	if step != 1 {
+		ctx.WritePlainf("%d*", step)
+	}
+	ctx.WriteKeyWord("ROW_NUMBER")
+	ctx.WritePlain("() ")
+	ctx.WriteKeyWord("OVER")
+	ctx.WritePlain(" (")
+	if n.Select.OrderBy != nil {
+		written := 0
+		for _, item := range n.Select.OrderBy.Items {
+			expr, ok := n.selectExpr(item.Expr)
+			if !ok {
+				continue
+			}
+			if written == 0 {
+				ctx.WriteKeyWord("ORDER BY ")
+			} else {
+				ctx.WritePlain(",")
+			}
+			written++
+			resolved := &ast.ByItem{Expr: expr, Desc: item.Desc, NullOrder: item.NullOrder}
+			if err := resolved.Restore(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// selectExpr resolves ORDER BY 2 and ORDER BY alias to the select list expression,
+// a window's ORDER BY cannot refer to the select list. Ordering by a counter is left
+// out: it follows the order rows are read in
+func (n *pgRowNumberExpr) selectExpr(expr ast.ExprNode) (ast.ExprNode, bool) {
+	fields := n.Select.Fields.Fields
+	switch node := expr.(type) {
+	case *ast.PositionExpr:
+		if node.P == nil && node.N >= 1 && node.N <= len(fields) {
+			expr = fields[node.N-1].Expr
+		}
+	case *ast.ColumnNameExpr:
+		if node.Name.Table.O != "" {
+			break
+		}
+		for _, field := range fields {
+			if field.AsName.L == node.Name.Name.L {
+				expr = field.Expr
+				break
+			}
+		}
+	}
+	_, counter := expr.(*pgRowNumberExpr)
+	return expr, !counter
+}
+
+// Accept implements ast.Node interface
+func (n *pgRowNumberExpr) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgRowNumberExpr)
+	node, ok := n.ExprNode.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ExprNode = node.(ast.ExprNode)
+	return v.Leave(n)
+}
@@ -0,0 +1,91 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteRowCounters(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		vars     map[string]interface{}
+		expected string
+		counters []UserVarCounter
+	}{
+		{
+			name:     "Initializer joined in FROM",
+			mysql:    "SELECT @rownum := @rownum + 1 AS pos, name FROM players, (SELECT @rownum := 0) init ORDER BY score DESC",
+			expected: `SELECT 0+ROW_NUMBER() OVER (ORDER BY "score" DESC) AS "pos","name" FROM "players" ORDER BY "score" DESC`,
+			counters: []UserVarCounter{{Name: "rownum", Start: int64(0), Step: 1}},
+		},
+		{
+			name:     "Session value set before",
+			mysql:    "SELECT (@n := @n + 1), name FROM players ORDER BY 2",
+			vars:     map[string]interface{}{"n": "10"},
+			expected: `SELECT '10'+ROW_NUMBER() OVER (ORDER BY "name") AS "(@n := @n + 1)","name" FROM "players" ORDER BY 2`,
+			counters: []UserVarCounter{{Name: "n", Start: "10", Step: 1}},
+		},
+		{
+			name:     "Ordered derived table and CROSS JOIN",
+			mysql:    "SELECT @n := 2 + @n AS pos, p.* FROM (SELECT @n := 0) AS init CROSS JOIN (SELECT * FROM players ORDER BY score DESC) p",
+			expected: `SELECT 0+2*ROW_NUMBER() OVER () AS "pos","p".* FROM (SELECT * FROM "players" ORDER BY "score" DESC) AS "p"`,
+			counters: []UserVarCounter{{Name: "n", Start: int64(0), Step: 2}},
+		},
+		{
+			name:     "Ordering by the counter follows the read order",
+			mysql:    "SELECT @n := @n - 1 AS pos FROM players JOIN teams ON players.team = teams.id, (SELECT @n := 100) init ORDER BY pos DESC",
+			expected: `SELECT 100-ROW_NUMBER() OVER () AS "pos" FROM "players" JOIN "teams" ON "players"."team"="teams"."id" ORDER BY "pos" DESC`,
+			counters: []UserVarCounter{{Name: "n", Start: int64(100), Step: -1}},
+		},
+		{
+			name:     "Derived table counts its own rows",
+			mysql:    "SELECT name FROM (SELECT @n := @n + 1 AS pos, name FROM players, (SELECT @n := 0) init ORDER BY score DESC) ranked WHERE pos <= 3",
+			expected: `SELECT "name" FROM (SELECT 0+ROW_NUMBER() OVER (ORDER BY "score" DESC) AS "pos","name" FROM "players" ORDER BY "score" DESC) AS "ranked" WHERE "pos"<=3`,
+			counters: []UserVarCounter{{Name: "n", Start: int64(0), Step: 1,
+				CountSQL: `SELECT COUNT(*) FROM (SELECT 0+ROW_NUMBER() OVER (ORDER BY "score" DESC) AS "pos","name" FROM "players" ORDER BY "score" DESC) AS "numbered"`}},
+		},
+		{
+			name:     "UNION members count their own rows",
+			mysql:    "SELECT @n := @n + 1, name FROM players UNION ALL SELECT 0, name FROM coaches",
+			vars:     map[string]interface{}{"n": int64(5)},
+			expected: `SELECT 5+ROW_NUMBER() OVER () AS "@n := @n + 1","name" FROM "players" UNION ALL SELECT 0,"name" FROM "coaches"`,
+			counters: []UserVarCounter{{Name: "n", Start: int64(5), Step: 1,
+				CountSQL: `SELECT COUNT(*) FROM (SELECT 5+ROW_NUMBER() OVER () AS "@n := @n + 1","name" FROM "players") AS "numbered"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := rewriter.RewriteStatement(tt.mysql, tt.vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stmt.SQL)
+			assert.Equal(t, tt.counters, stmt.Counters)
+		})
+	}
+
+	t.Run("Other assignments", func(t *testing.T) {
+		for _, sql := range []string{
+			"SELECT @x := 5",
+			"SELECT @n := @n * 2 FROM t",
+			"SELECT @rn := IF(@g = grp, @rn + 1, 1), @g := grp FROM t, (SELECT @rn := 0, @g := '') init",
+			"SELECT a FROM t WHERE (@n := @n + 1) < 10",
+			"SELECT a FROM t WHERE a IN (SELECT @n := @n + 1 FROM u)",
+			"WITH ranked AS (SELECT @n := @n + 1 AS pos FROM t) SELECT pos FROM ranked",
+		} {
+			_, err := rewriter.RewriteStatement(sql, nil)
+			assert.Error(t, err, sql)
+		}
+	})
+}
+
+func TestUserVarCounterAfter(t *testing.T) {
+	assert.Equal(t, int64(5), UserVarCounter{Start: int64(0), Step: 1}.After(5))
+	assert.Equal(t, int64(4), UserVarCounter{Start: "10", Step: -2}.After(3))
+	assert.Equal(t, int64(7), UserVarCounter{Start: int64(7), Step: 1}.After(0))
+	assert.Nil(t, UserVarCounter{Start: nil, Step: 1}.After(5))
+}
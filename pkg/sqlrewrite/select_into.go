@@ -0,0 +1,114 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitSelectInto handles the MySQL INTO clause, which the TiDB parser does not
+// accept in the SELECT ... INTO ... FROM position
+//
+//	SELECT COUNT(*) INTO @c FROM t  -> ("SELECT COUNT(*)  FROM t", ["c"], nil)
+//	SELECT a INTO OUTFILE '/tmp/x'  -> unsupported error, the proxy never writes files
+//	SELECT a FROM t                 -> (sql, nil, nil)
+//
+// Variable names are returned lowercased, user variables are case-insensitive
+func SplitSelectInto(sql string) (string, []string, error) {
+	upper := strings.ToUpper(strings.TrimSpace(sql))
+	if !strings.Contains(upper, "INTO") ||
+		!(strings.HasPrefix(upper, "SELECT") || strings.HasPrefix(upper, "WITH") || strings.HasPrefix(upper, "(")) {
+		return sql, nil, nil
+	}
+
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i) - 1
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && isKeywordAt(sql, i, "INTO"):
+			j := skipSpaces(sql, i+4)
+			if isKeywordAt(sql, j, "OUTFILE") || isKeywordAt(sql, j, "DUMPFILE") {
+				return "", nil, &RewriteError{
+					Reason:  ReasonUnsupported,
+					Feature: "SELECT ... INTO OUTFILE",
+					Err:     fmt.Errorf("SELECT ... INTO OUTFILE/DUMPFILE is not supported: the proxy does not write server-side files"),
+				}
+			}
+			if j >= len(sql) || sql[j] != '@' {
+				return sql, nil, nil
+			}
+
+			names, end, err := parseIntoVariables(sql, j)
+			if err != nil {
+				return "", nil, err
+			}
+			return strings.TrimSpace(strings.TrimRight(sql[:i], " \t\r\n") + " " + strings.TrimLeft(sql[end:], " \t\r\n")), names, nil
+		}
+	}
+
+	return sql, nil, nil
+}
+
+// parseIntoVariables reads "@a, @`b`, @'c'" starting at pos and returns the
+// names and the index just past the list
+func parseIntoVariables(sql string, pos int) ([]string, int, error) {
+	var names []string
+	for {
+		if pos >= len(sql) || sql[pos] != '@' {
+			return nil, 0, fmt.Errorf("expected user variable at position %d in INTO clause", pos)
+		}
+		pos++
+
+		var name string
+		if pos < len(sql) && (sql[pos] == '`' || sql[pos] == '\'' || sql[pos] == '"') {
+			end := skipQuoted(sql, pos)
+			name = sql[pos+1 : end-1]
+			pos = end
+		} else {
+			start := pos
+			for pos < len(sql) && isVariableChar(sql[pos]) {
+				pos++
+			}
+			name = sql[start:pos]
+		}
+		if name == "" {
+			return nil, 0, fmt.Errorf("empty user variable name in INTO clause")
+		}
+		names = append(names, strings.ToLower(name))
+
+		next := skipSpaces(sql, pos)
+		if next < len(sql) && sql[next] == ',' {
+			pos = skipSpaces(sql, next+1)
+			continue
+		}
+		return names, pos, nil
+	}
+}
+
+func isKeywordAt(sql string, i int, keyword string) bool {
+	if i+len(keyword) > len(sql) || !strings.EqualFold(sql[i:i+len(keyword)], keyword) {
+		return false
+	}
+	if i > 0 && isVariableChar(sql[i-1]) {
+		return false
+	}
+	end := i + len(keyword)
+	return end == len(sql) || !isVariableChar(sql[end])
+}
+
+func isVariableChar(c byte) bool {
+	return c == '_' || c == '$' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func skipSpaces(sql string, i int) int {
+	for i < len(sql) && (sql[i] == ' ' || sql[i] == '\t' || sql[i] == '\n' || sql[i] == '\r') {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,67 @@
+package sqlrewrite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSelectInto(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+		vars     []string
+	}{
+		{
+			name:     "INTO before FROM",
+			sql:      "SELECT COUNT(*) INTO @c FROM t",
+			expected: "SELECT COUNT(*) FROM t",
+			vars:     []string{"c"},
+		},
+		{
+			name:     "Trailing INTO with several variables",
+			sql:      "SELECT a, b FROM t WHERE id = 1 INTO @x, @`Y`",
+			expected: "SELECT a, b FROM t WHERE id = 1",
+			vars:     []string{"x", "y"},
+		},
+		{
+			name:     "INTO inside a string literal",
+			sql:      "SELECT 'into @z' FROM t",
+			expected: "SELECT 'into @z' FROM t",
+		},
+		{
+			name:     "INTO in a subquery is left alone",
+			sql:      "SELECT (SELECT 1 INTO @q) FROM t",
+			expected: "SELECT (SELECT 1 INTO @q) FROM t",
+		},
+		{
+			name:     "Not a SELECT",
+			sql:      "INSERT INTO t SELECT 1",
+			expected: "INSERT INTO t SELECT 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped, vars, err := SplitSelectInto(tt.sql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stripped)
+			assert.Equal(t, tt.vars, vars)
+		})
+	}
+}
+
+func TestSplitSelectInto_RejectsFiles(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT a INTO OUTFILE '/tmp/x' FROM t",
+		"SELECT a FROM t INTO DUMPFILE '/tmp/x'",
+	} {
+		_, _, err := SplitSelectInto(sql)
+		var rerr *RewriteError
+		require.True(t, errors.As(err, &rerr), sql)
+		assert.Equal(t, ReasonUnsupported, rerr.Reason)
+	}
+}
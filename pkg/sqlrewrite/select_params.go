@@ -0,0 +1,69 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"sort"
+)
+
+// selectParamNumbers numbers the placeholders that are whole select-list columns. The
+// client binds placeholders in the order they appear, offsets are their positions
+// in the statement
+func selectParamNumbers(selectOffsets, offsets []int) []int {
+	if len(selectOffsets) == 0 {
+		return nil
+	}
+	sorted := append([]int(nil), offsets...)
+	sort.Ints(sorted)
+	numbers := make([]int, 0, len(selectOffsets))
+	for _, offset := range selectOffsets {
+		numbers = append(numbers, sort.SearchInts(sorted, offset)+1)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// CastSelectParams casts the placeholders of sql that are whole select-list columns,
+// params, to the type of the value bound to them. Values reach PostgreSQL as quoted
+// literals, so SELECT ? would return text where MySQL returns the type of the value
+//
+//	SELECT $1, [7] -> SELECT $1::BIGINT
+func CastSelectParams(sql string, params []int, args []interface{}) string {
+	if len(params) == 0 {
+		return sql
+	}
+	literals := make([]string, len(args))
+	for i := range literals {
+		literals[i] = fmt.Sprintf("$%d", i+1)
+	}
+	cast := false
+	for _, n := range params {
+		if n > len(args) {
+			continue
+		}
+		if pgType := paramType(args[n-1]); pgType != "" {
+			literals[n-1] += "::" + pgType
+			cast = true
+		}
+	}
+	if !cast {
+		return sql
+	}
+	return bindPlaceholders(sql, literals)
+}
+
+// paramType returns the PostgreSQL type of a bound value, "" for strings and NULL
+// that are fine as text
+func paramType(arg interface{}) string {
+	switch arg.(type) {
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		return "BIGINT"
+	case uint, uint64:
+		// BIGINT UNSIGNED values may not fit BIGINT
+		return "NUMERIC"
+	case float32:
+		return "REAL"
+	case float64:
+		return "DOUBLE PRECISION"
+	}
+	return ""
+}
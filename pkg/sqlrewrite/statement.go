@@ -0,0 +1,146 @@
+package sqlrewrite
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// StatementType is the kind of statement, taken from the parsed AST so that
+// leading comments and WITH clauses do not hide it
+type StatementType int
+
+const (
+	StatementOther  StatementType = iota // Anything else (EXPLAIN, CALL, GRANT, ...), sent as a query
+	StatementSelect                      // SELECT, UNION, TABLE, VALUES, including WITH ... SELECT
+	StatementInsert                      // INSERT and REPLACE
+	StatementUpdate
+	StatementDelete
+	StatementDDL // CREATE, ALTER, DROP, TRUNCATE, RENAME
+)
+
+// IsWrite reports whether the statement modifies data or schema rather than returning rows
+func (t StatementType) IsWrite() bool {
+	switch t {
+	case StatementInsert, StatementUpdate, StatementDelete, StatementDDL:
+		return true
+	}
+	return false
+}
+
+// CanExplain reports whether PostgreSQL's EXPLAIN takes the statement, DDL and
+// statements the proxy answers itself have no plan
+func (t StatementType) CanExplain() bool {
+	switch t {
+	case StatementSelect, StatementInsert, StatementUpdate, StatementDelete:
+		return true
+	}
+	return false
+}
+
+// Statement is a rewritten statement and what kind it is
+type Statement struct {
+	SQL       string // PostgreSQL SQL
+	Type      StatementType
+	Returning bool // INSERT/UPDATE/DELETE ... RETURNING, which produces rows
+
+	// FirstGeneratedRow is the INSERT row whose AUTO_INCREMENT value is generated first,
+	// its id is the last insert id MySQL reports
+	FirstGeneratedRow int
+
+	// SingleRowInsert is a plain INSERT ... VALUES of one row, see RewriteInsertRows
+	SingleRowInsert bool
+
+	// Counters are the user variables the SELECT numbers its rows with, the caller
+	// sets them to their value after the last row
+	Counters []UserVarCounter
+
+	// Explain is set for EXPLAIN, whose result the caller builds from the plan
+	Explain *Explain
+
+	// SelectParams are the placeholders, numbered from 1, that are whole select-list
+	// columns, see CastSelectParams
+	SelectParams []int
+}
+
+// statementTypeOf classifies a parsed statement
+func statementTypeOf(stmt ast.StmtNode) StatementType {
+	switch stmt.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		return StatementSelect
+	case *ast.InsertStmt, *pgUpsertStmt:
+		return StatementInsert
+	case *ast.UpdateStmt:
+		return StatementUpdate
+	case *ast.DeleteStmt:
+		return StatementDelete
+	case ast.DDLNode:
+		return StatementDDL
+	}
+	return StatementOther
+}
+
+// statementTypeOfKeyword classifies sql by its leading keyword, for statements
+// that are not rewritten through the AST
+func statementTypeOfKeyword(sql string) StatementType {
+	switch statementKeyword(sql) {
+	case "SELECT":
+		return StatementSelect
+	case "INSERT", "REPLACE":
+		return StatementInsert
+	case "UPDATE":
+		return StatementUpdate
+	case "DELETE":
+		return StatementDelete
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME":
+		return StatementDDL
+	}
+	return StatementOther
+}
+
+// splitReturning splits a trailing RETURNING clause (MariaDB and PostgreSQL syntax)
+// off a DML statement, the MySQL parser does not accept it
+//
+//	DELETE FROM t WHERE id = 1 RETURNING id, name -> ("DELETE FROM t WHERE id = 1", "id, name")
+func splitReturning(sql string) (string, string) {
+	switch statementKeyword(sql) {
+	case "INSERT", "REPLACE", "UPDATE", "DELETE", "WITH":
+	default:
+		return sql, ""
+	}
+	if !strings.Contains(strings.ToUpper(sql), "RETURNING") {
+		return sql, ""
+	}
+
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i) - 1
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && isKeywordAt(sql, i, "RETURNING"):
+			return strings.TrimSpace(sql[:i]), strings.TrimSpace(sql[i+len("RETURNING"):])
+		}
+	}
+	return sql, ""
+}
+
+// String returns the name of the statement type
+func (t StatementType) String() string {
+	switch t {
+	case StatementSelect:
+		return "SELECT"
+	case StatementInsert:
+		return "INSERT"
+	case StatementUpdate:
+		return "UPDATE"
+	case StatementDelete:
+		return "DELETE"
+	case StatementDDL:
+		return "DDL"
+	}
+	return "OTHER"
+}
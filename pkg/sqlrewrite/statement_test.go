@@ -0,0 +1,120 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteStatement_Type(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected StatementType
+		write    bool
+	}{
+		{"select", "SELECT 1", StatementSelect, false},
+		{"CTE select", "WITH x AS (SELECT 1 AS id) SELECT * FROM x", StatementSelect, false},
+		{"union", "(SELECT 1) UNION (SELECT 2)", StatementSelect, false},
+		{"comment-prefixed insert", "/* app:orders */ INSERT INTO t (id) VALUES (1)", StatementInsert, true},
+		{"CTE delete", "WITH x AS (SELECT 1 AS id) DELETE FROM t WHERE id IN (SELECT id FROM x)", StatementDelete, true},
+		{"CTE update", "WITH x AS (SELECT 1 AS id) UPDATE t SET a = 1 WHERE id IN (SELECT id FROM x)", StatementUpdate, true},
+		{"create index", "CREATE INDEX i ON t (a)", StatementDDL, true},
+		{"truncate", "TRUNCATE TABLE t", StatementDDL, true},
+		{"explain", "EXPLAIN SELECT 1", StatementOther, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := rewriter.RewriteStatement(tt.sql, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stmt.Type)
+			assert.Equal(t, tt.write, stmt.Type.IsWrite())
+			assert.False(t, stmt.Returning)
+		})
+	}
+}
+
+func TestRewriteStatement_Returning(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	stmt, err := rewriter.RewriteStatement(
+		"WITH old AS (SELECT id FROM t WHERE id < 10) DELETE FROM t WHERE id IN (SELECT id FROM old) RETURNING id, UPPER(name) AS n", nil)
+	require.NoError(t, err)
+	assert.Equal(t, StatementDelete, stmt.Type)
+	assert.True(t, stmt.Returning)
+	assert.Equal(t, `WITH "old" AS (SELECT "id" FROM "t" WHERE "id"<10) DELETE FROM "t" WHERE "id" IN (SELECT "id" FROM "old") RETURNING "id",UPPER("name") AS "n"`, stmt.SQL)
+
+	stmt, err = rewriter.RewriteStatement("INSERT INTO t (name) VALUES ('returning') RETURNING *", nil)
+	require.NoError(t, err)
+	assert.Equal(t, StatementInsert, stmt.Type)
+	assert.True(t, stmt.Returning)
+	assert.Equal(t, `INSERT INTO "t" ("name") VALUES ('returning') RETURNING *`, stmt.SQL)
+}
+
+func TestRewritePreparedStatement_Returning(t *testing.T) {
+	stmt, paramCount, err := NewRewriter(true).RewritePreparedStatement("INSERT INTO t (name, qty) VALUES (?, ?) RETURNING id, name", nil)
+	require.NoError(t, err)
+	assert.True(t, stmt.Returning)
+	assert.Equal(t, 2, paramCount)
+	assert.Equal(t, `INSERT INTO "t" ("name","qty") VALUES ($1,$2) RETURNING "id","name"`, stmt.SQL)
+}
+
+func TestRewritePreparedStatement_PlaceholderInAlias(t *testing.T) {
+	// The unaliased column is named after the expression, its ? is not a placeholder
+	stmt, paramCount, err := NewRewriter(true).RewritePreparedStatement("SELECT DATE_ADD(?, INTERVAL ? DAY)", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, paramCount)
+	assert.Equal(t, `SELECT ($1+($2) * INTERVAL '1 DAY') AS "DATE_ADD(?, INTERVAL ? DAY)"`, stmt.SQL)
+}
+
+func TestRewriteStatement_Disabled(t *testing.T) {
+	stmt, err := NewRewriter(false).RewriteStatement("UPDATE t SET a = 1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE t SET a = 1", stmt.SQL)
+	assert.Equal(t, StatementUpdate, stmt.Type)
+}
+
+func TestSplitReturning(t *testing.T) {
+	body, returning := splitReturning("DELETE FROM t WHERE name = 'x RETURNING y' RETURNING id")
+	assert.Equal(t, "DELETE FROM t WHERE name = 'x RETURNING y'", body)
+	assert.Equal(t, "id", returning)
+
+	body, returning = splitReturning("SELECT returning FROM t")
+	assert.Equal(t, "SELECT returning FROM t", body)
+	assert.Empty(t, returning)
+}
+
+func TestSelectParams(t *testing.T) {
+	r := NewRewriter(true)
+	tests := []struct {
+		mysql  string
+		params []int
+	}{
+		{"SELECT ?", []int{1}},
+		{"SELECT ?, ? AS b, name FROM t WHERE id = ?", []int{1, 2}},
+		{"SELECT name, ? FROM t WHERE id = ? LIMIT ?", []int{1}},
+		{"SELECT ? + 1 FROM t", nil},
+		{"UPDATE t SET a = ? WHERE id = ?", nil},
+		{"SELECT ? UNION SELECT ?", []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mysql, func(t *testing.T) {
+			stmt, _, err := r.RewritePreparedStatement(tt.mysql, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.params, stmt.SelectParams)
+		})
+	}
+}
+
+func TestCastSelectParams(t *testing.T) {
+	sql := `SELECT $1,$2,$3,$4,$5 FROM "t" WHERE "id"=$6`
+	args := []interface{}{int64(7), uint64(1 << 63), 1.5, "x", nil, int64(1)}
+	assert.Equal(t, `SELECT $1::BIGINT,$2::NUMERIC,$3::DOUBLE PRECISION,$4,$5 FROM "t" WHERE "id"=$6`,
+		CastSelectParams(sql, []int{1, 2, 3, 4, 5}, args))
+	assert.Equal(t, `SELECT '$1', $1`, CastSelectParams(`SELECT '$1', $1`, nil, args))
+	assert.Equal(t, `SELECT '$1', $1::BIGINT`, CastSelectParams(`SELECT '$1', $1`, []int{1}, args))
+}
@@ -0,0 +1,108 @@
+package sqlrewrite
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// rewriteNulString writes a string literal holding NUL (MySQL's \0 escape) as a bytea
+// in hex format. PostgreSQL text cannot hold NUL, and the byte would end the query on
+// the wire, so only literals known to be binary are accepted: _binary'...' and values
+// written to or compared with BYTEA columns (MySQL's BLOB and BINARY types). The other
+// escapes MySQL accepts are expanded by the parser already and written as plain
+// characters, PostgreSQL reads '...' without escapes
+// MySQL: INSERT INTO files (data) VALUES ('ab\0c')
+// PostgreSQL: INSERT INTO "files" ("data") VALUES (CAST('\x61620063' AS BYTEA))
+func (v *ASTVisitor) rewriteNulString(node *driver.ValueExpr) ast.ExprNode {
+	if node.Kind() != driver.KindString && node.Kind() != driver.KindBytes {
+		return node
+	}
+	value := node.GetString()
+	if strings.IndexByte(value, 0) < 0 {
+		return node
+	}
+	if !v.binaryLiterals[node] && node.Type.GetCharset() != charset.CharsetBin {
+		v.err = fmt.Errorf("string literal with a NUL byte can only be written to a BLOB or BINARY column, PostgreSQL text cannot hold NUL; use _binary'...' or a placeholder")
+		return node
+	}
+	return &pgCastExpr{ExprNode: ast.NewValueExpr(`\x`+hex.EncodeToString([]byte(value)), "", ""), Type: "BYTEA"}
+}
+
+// markBinaryValues records the literals an INSERT writes to BYTEA columns. The columns
+// come from the column list, or from CREATE TABLE when there is none
+func (v *ASTVisitor) markBinaryValues(node *ast.InsertStmt) {
+	table := insertTableName(node)
+	columns := v.columnTypes.Columns(table)
+	if len(node.Columns) > 0 {
+		columns = make([]string, len(node.Columns))
+		for i, col := range node.Columns {
+			columns[i] = col.Name.L
+		}
+	}
+
+	for _, row := range node.Lists {
+		for i, expr := range row {
+			if i < len(columns) && v.columnTypes.IsBinary(table, columns[i]) {
+				v.markBinaryLiteral(expr)
+			}
+		}
+	}
+	for _, assignment := range node.OnDuplicate {
+		if v.columnTypes.IsBinary(table, assignment.Column.Name.L) {
+			v.markBinaryLiteral(assignment.Expr)
+		}
+	}
+}
+
+// markBinaryLiteral records expr when it is a string literal
+func (v *ASTVisitor) markBinaryLiteral(expr ast.ExprNode) {
+	value, ok := expr.(*driver.ValueExpr)
+	if !ok {
+		return
+	}
+	if v.binaryLiterals == nil {
+		v.binaryLiterals = make(map[*driver.ValueExpr]bool)
+	}
+	v.binaryLiterals[value] = true
+}
+
+// binaryLiteralVisitor records the literals compared with or assigned to BYTEA columns,
+// see rewriteNulString
+// MySQL: UPDATE files SET data = 'a\0b' WHERE data = 'c\0d'
+// PostgreSQL: UPDATE "files" SET "data"=CAST('\x610062' AS BYTEA) WHERE "data"=CAST('\x630064' AS BYTEA)
+type binaryLiteralVisitor struct {
+	*columnScope
+	visitor *ASTVisitor
+}
+
+// Leave implements ast.Visitor interface
+func (b *binaryLiteralVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	switch node := n.(type) {
+	case *ast.BinaryOperationExpr:
+		if !isComparisonOp(node.Op) {
+			break
+		}
+		if b.isBinaryColumn(node.L) {
+			b.visitor.markBinaryLiteral(node.R)
+		} else if b.isBinaryColumn(node.R) {
+			b.visitor.markBinaryLiteral(node.L)
+		}
+
+	case *ast.Assignment:
+		if b.isBinaryColumn(&ast.ColumnNameExpr{Name: node.Column}) {
+			b.visitor.markBinaryLiteral(node.Expr)
+		}
+	}
+	return n, true
+}
+
+// isBinaryColumn reports whether expr is a known column of a binary type
+func (b *binaryLiteralVisitor) isBinaryColumn(expr ast.ExprNode) bool {
+	table, column, ok := b.columnTable(expr)
+	return ok && b.columns.IsBinary(table, column)
+}
@@ -0,0 +1,93 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteStringEscapes(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Escapes become the characters",
+			mysql:    `INSERT INTO t (a, b, c) VALUES ('line\nnext\ttab', 'it\'s \"quoted\"', 'back\\slash\Zend\b')`,
+			expected: "INSERT INTO \"t\" (\"a\",\"b\",\"c\") VALUES ('line\nnext\ttab','it''s \"quoted\"','back\\slash\x1aend\b')",
+		},
+		{
+			name:     "NUL in a _binary literal is written in bytea hex format",
+			mysql:    `SELECT _binary'a\0'`,
+			expected: `SELECT CAST('\x6100' AS BYTEA)`,
+		},
+		{
+			name:     "Wildcard escapes in LIKE",
+			mysql:    `SELECT * FROM t WHERE a LIKE '100\%' AND b LIKE 'x\_y%' AND c LIKE 'dir\\\\%'`,
+			expected: `SELECT * FROM "t" WHERE "a" ILIKE '100\%' AND "b" ILIKE 'x\_y%' AND "c" ILIKE 'dir\\%'`,
+		},
+		{
+			name:     "Wildcard escapes elsewhere keep the backslash",
+			mysql:    `SELECT '5\%', 'a\_b'`,
+			expected: `SELECT '5\%','a\_b'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRewriteNulStrings(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE files (id INT, name TEXT, data BLOB, tag VARBINARY(8))")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "INSERT into a BLOB column",
+			mysql:    `INSERT INTO files (id, data) VALUES (1, 'ab\0c'), (2, _binary'\0')`,
+			expected: `INSERT INTO "files" ("id","data") VALUES (1,CAST('\x61620063' AS BYTEA)),(2,CAST('\x00' AS BYTEA))`,
+		},
+		{
+			name:     "INSERT without a column list",
+			mysql:    `INSERT INTO files VALUES (1, 'a', 'b\0', 't\0')`,
+			expected: `INSERT INTO "files" VALUES (1,'a',CAST('\x6200' AS BYTEA),CAST('\x7400' AS BYTEA))`,
+		},
+		{
+			name:     "UPDATE and comparison",
+			mysql:    `UPDATE files SET data = 'a\0b' WHERE tag = 't\0'`,
+			expected: `UPDATE "files" SET "data"=CAST('\x610062' AS BYTEA) WHERE "tag"=CAST('\x7400' AS BYTEA)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	// TEXT cannot hold NUL, the backslash text must not be stored instead
+	for _, sql := range []string{
+		`INSERT INTO files (id, name) VALUES (1, 'ab\0c')`,
+		`UPDATE files SET name = 'a\0' WHERE id = 1`,
+		`INSERT INTO unknown_table (v) VALUES ('a\0')`,
+	} {
+		_, err := rewriter.Rewrite(sql)
+		assert.ErrorContains(t, err, "NUL", sql)
+	}
+}
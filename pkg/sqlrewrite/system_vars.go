@@ -0,0 +1,80 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// systemVarPrefix marks system variables among the session variables passed to the
+// rewriter: "@@time_zone" next to user variables, which are keyed by their bare name
+const systemVarPrefix = "@@"
+
+// systemVarDefaults are the values @@name reads when the session has not SET the
+// variable, matching what SHOW VARIABLES reports. Connectors read many of them at
+// startup. @@version is the advertised server version, @@max_allowed_packet the
+// limit the proxy enforces when one is configured
+var systemVarDefaults = map[string]interface{}{
+	"auto_increment_increment": int64(1),
+	"auto_increment_offset":    int64(1),
+	"autocommit":               int64(1),
+	"character_set_client":     "utf8mb4",
+	"character_set_connection": "utf8mb4",
+	"character_set_database":   "utf8mb4",
+	"character_set_results":    "utf8mb4",
+	"character_set_server":     "utf8mb4",
+	"collation_connection":     "utf8mb4_general_ci",
+	"collation_database":       "utf8mb4_general_ci",
+	"collation_server":         "utf8mb4_general_ci",
+	"init_connect":             "",
+	"interactive_timeout":      int64(28800),
+	"license":                  "GPL",
+	"lower_case_table_names":   int64(0),
+	"max_allowed_packet":       int64(67108864),
+	"net_buffer_length":        int64(16384),
+	"net_write_timeout":        int64(60),
+	"performance_schema":       int64(0),
+	"query_cache_size":         int64(0),
+	"query_cache_type":         "OFF",
+	"sql_mode":                 "TRADITIONAL",
+	"sql_select_limit":         uint64(18446744073709551615),
+	"system_time_zone":         "UTC",
+	"time_zone":                "SYSTEM",
+	"transaction_isolation":    "READ-COMMITTED", // PostgreSQL's default
+	"transaction_read_only":    int64(0),
+	"tx_isolation":             "READ-COMMITTED",
+	"tx_read_only":             int64(0),
+	"version_comment":          "aproxy",
+	"wait_timeout":             int64(28800),
+}
+
+// isSystemVariableRef reports whether node reads a system variable (@@name)
+func isSystemVariableRef(node *ast.VariableExpr) bool {
+	return node.IsSystem && node.Value == nil
+}
+
+// substituteSystemVar replaces @@name with its value, PostgreSQL has no such references.
+// Session values set with SET come first, @@GLOBAL.name reads the default
+// MySQL: SELECT @@version, @@session.time_zone
+// PostgreSQL: SELECT '8.0.11' AS "@@version",'SYSTEM' AS "@@session.time_zone"
+func (v *ASTVisitor) substituteSystemVar(node *ast.VariableExpr) ast.ExprNode {
+	name := strings.ToLower(node.Name)
+	if !node.IsGlobal {
+		if value, ok := v.userVars[systemVarPrefix+name]; ok {
+			return ast.NewValueExpr(value, "", "")
+		}
+	}
+	if name == "version" {
+		return ast.NewValueExpr(v.serverVersion, "", "")
+	}
+	if name == "max_allowed_packet" && v.maxAllowedPacket > 0 {
+		return ast.NewValueExpr(v.maxAllowedPacket, "", "")
+	}
+	value, ok := systemVarDefaults[name]
+	if !ok {
+		v.err = fmt.Errorf("Unknown system variable '%s'", node.Name)
+		return node
+	}
+	return ast.NewValueExpr(value, "", "")
+}
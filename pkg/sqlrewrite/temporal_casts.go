@@ -0,0 +1,250 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+	"github.com/pingcap/tidb/pkg/parser/types"
+)
+
+// rewriteTemporalCast casts to the PostgreSQL type of DATE, DATETIME and TIME. MySQL
+// reads strings leniently, literals are brought into the form PostgreSQL reads and
+// become NULL when MySQL cannot read them either, as its CAST returns NULL for them
+// MySQL: CAST(ts AS DATE), CAST('2024.1.2 3:4:5' AS DATETIME(3)), CONVERT(20240102, DATE), CAST(t AS TIME)
+// PostgreSQL: CAST("ts" AS DATE), CAST('2024-01-02 03:04:05' AS TIMESTAMP(3)), CAST('2024-01-02' AS DATE), CAST("t" AS TIME(0))
+//
+// Other operands are cast as they are, PostgreSQL then rejects what it cannot read
+func rewriteTemporalCast(node *ast.FuncCastExpr) (ast.ExprNode, bool) {
+	tp := node.Tp.GetType()
+	pgType, ok := pgTemporalTypes[tp]
+	if !ok || tp == mysql.TypeTimestamp {
+		return node, false
+	}
+	if tp != mysql.TypeDate {
+		// Without a precision MySQL rounds to whole seconds, PostgreSQL would keep microseconds
+		decimal := node.Tp.GetDecimal()
+		if decimal == types.UnspecifiedLength {
+			decimal = 0
+		}
+		pgType += fmt.Sprintf("(%d)", decimal)
+	}
+
+	expr := node.Expr
+	if value, ok := unwrapParentheses(expr).(*driver.ValueExpr); ok {
+		var literal string
+		switch value.Kind() {
+		case driver.KindString:
+			literal = value.GetString()
+		case driver.KindInt64:
+			literal = strconv.FormatInt(value.GetInt64(), 10)
+		case driver.KindUint64:
+			literal = strconv.FormatUint(value.GetUint64(), 10)
+		default:
+			return &pgCastExpr{ExprNode: expr, Type: pgType}, true
+		}
+		normalized, ok := normalizeTemporalLiteral(literal, tp)
+		if !ok {
+			return ast.NewValueExpr(nil, "", ""), true
+		}
+		expr = ast.NewValueExpr(normalized, "", "")
+	}
+	return &pgCastExpr{ExprNode: expr, Type: pgType}, true
+}
+
+// normalizeTemporalLiteral reads s the way MySQL reads a DATE, DATETIME or TIME
+// string and writes it as YYYY-MM-DD, YYYY-MM-DD hh:mm:ss[.frac] or hh:mm:ss[.frac]
+//
+//	'2024-1-2', '2024/01/02', '24-01-02', '20240102' -> '2024-01-02'
+//	'2024-01-02T03:04:05.5', '20240102030405'       -> '2024-01-02 03:04:05.5'
+//	'3:4', '030405', '2024-01-02 03:04:05' (TIME)    -> '03:04:00', '03:04:05', '03:04:05'
+func normalizeTemporalLiteral(s string, tp byte) (string, bool) {
+	s = strings.TrimSpace(s)
+	if tp == mysql.TypeDuration {
+		if _, clock, ok := parseMySQLDatetime(s); ok && clock != "" {
+			return clock, true
+		}
+		return parseMySQLTime(s)
+	}
+
+	date, clock, ok := parseMySQLDatetime(s)
+	if !ok {
+		return "", false
+	}
+	if tp == mysql.TypeDate {
+		return date, true
+	}
+	if clock == "" {
+		clock = "00:00:00"
+	}
+	return date + " " + clock, true
+}
+
+// parseMySQLDatetime reads a date with an optional time of day. Parts are separated
+// by any punctuation, the time by a space or T, or the string is digits only:
+// YYMMDD, YYYYMMDD, YYMMDDhhmmss or YYYYMMDDhhmmss. Two digit years are 1970-2069
+func parseMySQLDatetime(s string) (date, clock string, ok bool) {
+	var parts []string
+	var frac string
+	if digits, rest, _ := strings.Cut(s, "."); isAllDigits(digits) && (rest == "" || isAllDigits(rest)) {
+		switch len(digits) {
+		case 6, 12:
+			parts = splitFixed(digits, 2)
+		case 8, 14:
+			parts = append([]string{digits[:4]}, splitFixed(digits[4:], 2)...)
+		default:
+			return "", "", false
+		}
+		if len(parts) == 3 && rest != "" {
+			return "", "", false
+		}
+		frac = rest
+	} else {
+		var seps []byte
+		parts, seps = splitTemporalParts(s)
+		if parts == nil || len(parts) != 3 && len(parts) != 5 && len(parts) != 6 && len(parts) != 7 {
+			return "", "", false
+		}
+		if len(parts) == 7 {
+			if seps[5] != '.' {
+				return "", "", false
+			}
+			frac, parts = parts[6], parts[:6]
+		}
+		if len(parts) > 3 && seps[2] != ' ' && seps[2] != 'T' && seps[2] != 't' {
+			return "", "", false
+		}
+		if len(parts) == 5 {
+			parts = append(parts, "0")
+		}
+	}
+
+	year, _ := strconv.Atoi(parts[0])
+	switch len(parts[0]) {
+	case 2:
+		if year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	case 4:
+	default:
+		return "", "", false
+	}
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[2])
+	if month < 1 || month > 12 || day < 1 || day > time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day() {
+		return "", "", false
+	}
+	date = fmt.Sprintf("%04d-%02d-%02d", year, month, day)
+	if len(parts) == 3 {
+		return date, "", true
+	}
+
+	clock, ok = formatClock(parts[3:], frac, 23)
+	return date, clock, ok
+}
+
+// parseMySQLTime reads a time: h:m[:s][.frac] or digits read from the right,
+// [[hh]mm]ss[.frac]. MySQL's TIME goes up to 838 hours, PostgreSQL then reports
+// the hours it has no TIME for
+func parseMySQLTime(s string) (string, bool) {
+	digits, frac, _ := strings.Cut(s, ".")
+	if frac != "" && !isAllDigits(frac) {
+		return "", false
+	}
+	var parts []string
+	if isAllDigits(digits) {
+		if len(digits) > 6 {
+			return "", false
+		}
+		digits = strings.Repeat("0", 6-len(digits)) + digits
+		parts = splitFixed(digits, 2)
+	} else {
+		var seps []byte
+		parts, seps = splitTemporalParts(digits)
+		if len(parts) < 2 || len(parts) > 3 {
+			return "", false
+		}
+		for _, sep := range seps {
+			if sep != ':' {
+				return "", false
+			}
+		}
+		if len(parts) == 2 {
+			parts = append(parts, "0")
+		}
+	}
+	return formatClock(parts, frac, 838)
+}
+
+// formatClock writes hour, minute and second as hh:mm:ss[.frac]
+func formatClock(parts []string, frac string, maxHour int) (string, bool) {
+	if len(parts) != 3 {
+		return "", false
+	}
+	hour, _ := strconv.Atoi(parts[0])
+	minute, _ := strconv.Atoi(parts[1])
+	second, _ := strconv.Atoi(parts[2])
+	if len(parts[0]) > 3 || hour > maxHour || minute > 59 || second > 59 {
+		return "", false
+	}
+	clock := fmt.Sprintf("%02d:%02d:%02d", hour, minute, second)
+	if frac != "" {
+		clock += "." + frac
+	}
+	return clock, true
+}
+
+// splitTemporalParts splits s into its runs of digits and returns the single
+// character between each two of them. A string not made of digit runs separated by
+// one punctuation, space or T character gives nil
+func splitTemporalParts(s string) ([]string, []byte) {
+	var parts []string
+	var seps []byte
+	for i := 0; i < len(s); {
+		j := i
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		if j == i {
+			return nil, nil
+		}
+		parts = append(parts, s[i:j])
+		if j == len(s) {
+			break
+		}
+		sep := s[j]
+		if sep != 'T' && sep != 't' && sep != ' ' && !strings.ContainsRune("-./:_,;", rune(sep)) || j+1 == len(s) {
+			return nil, nil
+		}
+		seps = append(seps, sep)
+		i = j + 1
+	}
+	return parts, seps
+}
+
+// splitFixed splits s into pieces of n bytes
+func splitFixed(s string, n int) []string {
+	var parts []string
+	for i := 0; i < len(s); i += n {
+		parts = append(parts, s[i:i+n])
+	}
+	return parts
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
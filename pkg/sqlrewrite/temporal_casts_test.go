@@ -0,0 +1,120 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteTemporalCasts(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Columns",
+			mysql:    "SELECT CAST(a AS DATETIME), CAST(b AS DATE), CAST(c AS TIME) FROM t",
+			expected: `SELECT CAST("a" AS TIMESTAMP(0)),CAST("b" AS DATE),CAST("c" AS TIME(0)) FROM "t"`,
+		},
+		{
+			name:     "Fractional seconds",
+			mysql:    "SELECT CAST(a AS DATETIME(3)), CONVERT(b, TIME(6)) FROM t",
+			expected: `SELECT CAST("a" AS TIMESTAMP(3)),CAST("b" AS TIME(6)) FROM "t"`,
+		},
+		{
+			name:     "Fractional input is rounded to whole seconds",
+			mysql:    "SELECT CAST('2024-01-02 03:04:05.678' AS DATETIME), CAST('03:04:05.5' AS TIME), CAST('2024-01-02 03:04:05.678' AS DATETIME(2))",
+			expected: `SELECT CAST('2024-01-02 03:04:05.678' AS TIMESTAMP(0)),CAST('03:04:05.5' AS TIME(0)),CAST('2024-01-02 03:04:05.678' AS TIMESTAMP(2))`,
+		},
+		{
+			name:     "Lenient date strings",
+			mysql:    "SELECT CAST('2024-1-2' AS DATE), CAST('24/01/02' AS DATE), CAST('2024.1.2 3:4:5' AS DATETIME)",
+			expected: `SELECT CAST('2024-01-02' AS DATE),CAST('2024-01-02' AS DATE),CAST('2024-01-02 03:04:05' AS TIMESTAMP(0))`,
+		},
+		{
+			name:     "Numbers",
+			mysql:    "SELECT CAST(20240102 AS DATE), CONVERT(20240102030405, DATETIME)",
+			expected: `SELECT CAST('2024-01-02' AS DATE),CAST('2024-01-02 03:04:05' AS TIMESTAMP(0))`,
+		},
+		{
+			name:     "Strings MySQL cannot read are NULL",
+			mysql:    "SELECT CAST('not a date' AS DATE), CAST('2023-02-29' AS DATETIME)",
+			expected: `SELECT NULL,NULL`,
+		},
+		{
+			name:     "Placeholders and expressions",
+			mysql:    "SELECT id FROM t WHERE CAST(? AS DATE) = CAST(CONCAT(y, '-01-01') AS DATE)",
+			expected: `SELECT "id" FROM "t" WHERE CAST($1 AS DATE)=CAST(CONCAT("y", '-01-01') AS DATE)`,
+		},
+		{
+			name:     "BINARY casts are not temporal",
+			mysql:    "SELECT CAST(name AS BINARY) FROM t WHERE CAST(created AS DATE) = '2024-01-02'",
+			expected: `SELECT "name" COLLATE "C" FROM "t" WHERE CAST("created" AS DATE)='2024-01-02'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNormalizeTemporalLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		tp       byte
+		expected string
+		ok       bool
+	}{
+		{"2024-01-02", mysql.TypeDate, "2024-01-02", true},
+		{"2024-1-2", mysql.TypeDate, "2024-01-02", true},
+		{"2024/1/2", mysql.TypeDate, "2024-01-02", true},
+		{"69-12-31", mysql.TypeDate, "2069-12-31", true},
+		{"70-01-01", mysql.TypeDate, "1970-01-01", true},
+		{"20240102", mysql.TypeDate, "2024-01-02", true},
+		{"240102", mysql.TypeDate, "2024-01-02", true},
+		{"2024-01-02 10:30:00", mysql.TypeDate, "2024-01-02", true},
+		{" 2024-01-02 ", mysql.TypeDate, "2024-01-02", true},
+		{"2024-02-29", mysql.TypeDate, "2024-02-29", true},
+		{"2023-02-29", mysql.TypeDate, "", false},
+		{"2024-13-01", mysql.TypeDate, "", false},
+		{"0000-00-00", mysql.TypeDate, "", false},
+		{"2024-01", mysql.TypeDate, "", false},
+		{"2024-01-02x", mysql.TypeDate, "", false},
+		{"yesterday", mysql.TypeDate, "", false},
+
+		{"2024-01-02", mysql.TypeDatetime, "2024-01-02 00:00:00", true},
+		{"2024-01-02T03:04:05", mysql.TypeDatetime, "2024-01-02 03:04:05", true},
+		{"2024-1-2 3:4", mysql.TypeDatetime, "2024-01-02 03:04:00", true},
+		{"2024-01-02 03:04:05.123", mysql.TypeDatetime, "2024-01-02 03:04:05.123", true},
+		{"20240102030405", mysql.TypeDatetime, "2024-01-02 03:04:05", true},
+		{"20240102030405.5", mysql.TypeDatetime, "2024-01-02 03:04:05.5", true},
+		{"2024-01-02 24:00:00", mysql.TypeDatetime, "", false},
+		{"2024-01-02-03:04:05", mysql.TypeDatetime, "", false},
+
+		{"3:4", mysql.TypeDuration, "03:04:00", true},
+		{"10:30:15.25", mysql.TypeDuration, "10:30:15.25", true},
+		{"030405", mysql.TypeDuration, "03:04:05", true},
+		{"405", mysql.TypeDuration, "00:04:05", true},
+		{"2024-01-02 03:04:05", mysql.TypeDuration, "03:04:05", true},
+		{"100:00:00", mysql.TypeDuration, "100:00:00", true},
+		{"10:61:00", mysql.TypeDuration, "", false},
+		{"noon", mysql.TypeDuration, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, ok := normalizeTemporalLiteral(tt.input, tt.tp)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
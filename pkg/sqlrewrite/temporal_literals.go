@@ -0,0 +1,95 @@
+package sqlrewrite
+
+import (
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// pgTemporalTypes maps MySQL date/time column types to the PostgreSQL types they are stored as
+var pgTemporalTypes = map[byte]string{
+	mysql.TypeDate:      "DATE",
+	mysql.TypeDatetime:  "TIMESTAMP",
+	mysql.TypeTimestamp: "TIMESTAMP",
+	mysql.TypeDuration:  "TIME",
+}
+
+// textFunctions return text in PostgreSQL, which does not compare text with dates
+var textFunctions = map[string]bool{
+	"concat":      true,
+	"concat_ws":   true,
+	"date_format": true,
+	"left":        true,
+	"lpad":        true,
+	"replace":     true,
+	"right":       true,
+	"rpad":        true,
+	"substr":      true,
+	"substring":   true,
+	"trim":        true,
+}
+
+// temporalLiteralVisitor casts strings compared with known date/time columns to the column type
+// MySQL converts the string implicitly. PostgreSQL does so for bare literals, but not for
+// text expressions, and the explicit cast keeps both forms consistent
+// MySQL: WHERE d = CONCAT(@y, '-01-01') AND ts BETWEEN '2024-01-01' AND '2024-01-31'
+// PostgreSQL: WHERE "d"=CAST(CONCAT(...) AS DATE) AND "ts" BETWEEN CAST('2024-01-01' AS TIMESTAMP) AND ...
+type temporalLiteralVisitor struct {
+	*columnScope
+}
+
+// Leave implements ast.Visitor interface
+func (v *temporalLiteralVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	switch node := n.(type) {
+	case *ast.BinaryOperationExpr:
+		if !isComparisonOp(node.Op) {
+			break
+		}
+		if pgType, ok := v.temporalType(node.L); ok {
+			node.R = castTextOperand(node.R, pgType)
+		} else if pgType, ok := v.temporalType(node.R); ok {
+			node.L = castTextOperand(node.L, pgType)
+		}
+
+	case *ast.BetweenExpr:
+		if pgType, ok := v.temporalType(node.Expr); ok {
+			node.Left = castTextOperand(node.Left, pgType)
+			node.Right = castTextOperand(node.Right, pgType)
+		}
+
+	case *ast.PatternInExpr:
+		if pgType, ok := v.temporalType(node.Expr); ok {
+			for i, item := range node.List {
+				node.List[i] = castTextOperand(item, pgType)
+			}
+		}
+	}
+	return n, true
+}
+
+// temporalType returns the PostgreSQL type of a known date/time column
+func (v *temporalLiteralVisitor) temporalType(expr ast.ExprNode) (string, bool) {
+	tp, ok := v.columnType(expr)
+	if !ok {
+		return "", false
+	}
+	pgType, ok := pgTemporalTypes[tp]
+	return pgType, ok
+}
+
+// castTextOperand casts string literals and text expressions to pgType, other operands are kept
+func castTextOperand(expr ast.ExprNode, pgType string) ast.ExprNode {
+	switch e := expr.(type) {
+	case *driver.ValueExpr:
+		if e.Kind() != driver.KindString {
+			return expr
+		}
+	case *ast.FuncCallExpr:
+		if !textFunctions[e.FnName.L] {
+			return expr
+		}
+	default:
+		return expr
+	}
+	return &pgCastExpr{ExprNode: expr, Type: pgType}
+}
@@ -0,0 +1,74 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemporalLiterals(t *testing.T) {
+	rewriter := NewASTRewriter()
+	_, err := rewriter.Rewrite("CREATE TABLE events (id INT, day DATE, created_at DATETIME, starts TIME, note VARCHAR(20))")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Date column and string literal",
+			mysql:    "SELECT id FROM events WHERE day = '2024-01-01'",
+			expected: `SELECT "id" FROM "events" WHERE "day"=CAST('2024-01-01' AS DATE)`,
+		},
+		{
+			name:     "Literal on the left",
+			mysql:    "SELECT id FROM events e WHERE '2024-01-01 12:00:00' <= e.created_at",
+			expected: `SELECT "id" FROM "events" AS "e" WHERE CAST('2024-01-01 12:00:00' AS TIMESTAMP)<="e"."created_at"`,
+		},
+		{
+			name:     "BETWEEN range",
+			mysql:    "SELECT id FROM events WHERE created_at BETWEEN '2024-01-01' AND '2024-01-31 23:59:59'",
+			expected: `SELECT "id" FROM "events" WHERE "created_at" BETWEEN CAST('2024-01-01' AS TIMESTAMP) AND CAST('2024-01-31 23:59:59' AS TIMESTAMP)`,
+		},
+		{
+			name:     "IN list of times",
+			mysql:    "SELECT id FROM events WHERE starts NOT IN ('09:00', '17:30')",
+			expected: `SELECT "id" FROM "events" WHERE "starts" NOT IN (CAST('09:00' AS TIME),CAST('17:30' AS TIME))`,
+		},
+		{
+			name:     "Text expression",
+			mysql:    "SELECT id FROM events WHERE day >= CONCAT('2024', '-06-01')",
+			expected: `SELECT "id" FROM "events" WHERE "day">=CAST(CONCAT('2024', '-06-01') AS DATE)`,
+		},
+		{
+			name:     "Non-text operands are kept",
+			mysql:    "SELECT id FROM events WHERE day = CURDATE() AND created_at > ?",
+			expected: `SELECT "id" FROM "events" WHERE "day"=CURRENT_DATE AND "created_at">$1`,
+		},
+		{
+			name:     "Other columns are kept",
+			mysql:    "SELECT id FROM events WHERE note = '2024-01-01'",
+			expected: `SELECT "id" FROM "events" WHERE LOWER("note")=LOWER('2024-01-01')`,
+		},
+		{
+			name:     "Unknown tables are kept",
+			mysql:    "SELECT id FROM logs WHERE day = '2024-01-01'",
+			expected: `SELECT "id" FROM "logs" WHERE "day"='2024-01-01'`,
+		},
+		{
+			name:     "DELETE",
+			mysql:    "DELETE FROM events WHERE day < '2020-01-01'",
+			expected: `DELETE FROM "events" WHERE "day"<CAST('2020-01-01' AS DATE)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
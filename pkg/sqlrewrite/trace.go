@@ -0,0 +1,83 @@
+package sqlrewrite
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// ExplainRewriteFunction is the diagnostic function returning the rewrite trace of
+// the statement it is given, see ParseExplainRewrite
+const ExplainRewriteFunction = "aproxy_explain_rewrite"
+
+// RewriteStep is one row of a rewrite trace
+type RewriteStep struct {
+	Step   string // original, statement_type, function, rewrite, rewritten or error
+	Detail string
+}
+
+// ExplainRewrite rewrites sql like RewriteStatement and reports the steps taken: the
+// original SQL, the statement type, every function renamed and node replaced on the
+// way, and the PostgreSQL SQL or the error the rewrite ended with
+func (r *Rewriter) ExplainRewrite(sql string, userVars map[string]interface{}) []RewriteStep {
+	var transforms []RewriteStep
+	if r.astRewriter != nil {
+		r.astRewriter.visitor.trace = &transforms
+		defer func() { r.astRewriter.visitor.trace = nil }()
+	}
+	stmt, err := r.RewriteStatement(sql, userVars)
+
+	steps := append([]RewriteStep{
+		{Step: "original", Detail: sql},
+		{Step: "statement_type", Detail: stmt.Type.String()},
+	}, transforms...)
+	if err != nil {
+		return append(steps, RewriteStep{Step: "error", Detail: err.Error()})
+	}
+	return append(steps, RewriteStep{Step: "rewritten", Detail: stmt.SQL})
+}
+
+// ParseExplainRewrite returns the statement of SELECT aproxy_explain_rewrite('<sql>')
+func ParseExplainRewrite(query string) (string, bool) {
+	if !strings.Contains(strings.ToLower(query), ExplainRewriteFunction) {
+		return "", false
+	}
+	stmt, err := parser.New().ParseOneStmt(query, "", "")
+	if err != nil {
+		return "", false
+	}
+	sel, ok := stmt.(*ast.SelectStmt)
+	if !ok || sel.From != nil || sel.Fields == nil || len(sel.Fields.Fields) != 1 {
+		return "", false
+	}
+	call, ok := sel.Fields.Fields[0].Expr.(*ast.FuncCallExpr)
+	if !ok || call.FnName.L != ExplainRewriteFunction || len(call.Args) != 1 {
+		return "", false
+	}
+	value, ok := call.Args[0].(*driver.ValueExpr)
+	if !ok || value.Kind() != driver.KindString {
+		return "", false
+	}
+	return value.GetString(), true
+}
+
+// traceStep records a transformation while ExplainRewrite traces the rewrite
+func (v *ASTVisitor) traceStep(step, detail string) {
+	if v.trace != nil {
+		*v.trace = append(*v.trace, RewriteStep{Step: step, Detail: detail})
+	}
+}
+
+// restoreTrace renders a node for the trace
+func restoreTrace(node ast.Node) string {
+	var sb strings.Builder
+	flags := format.RestoreStringSingleQuotes | format.RestoreKeyWordUppercase | format.RestoreNameDoubleQuotes |
+		format.RestoreStringWithoutCharset
+	if err := node.Restore(format.NewRestoreCtx(flags, &sb)); err != nil {
+		return "?"
+	}
+	return sb.String()
+}
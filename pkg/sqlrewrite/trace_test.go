@@ -0,0 +1,48 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainRewrite(t *testing.T) {
+	r := NewRewriter(true)
+
+	steps := r.ExplainRewrite("SELECT IFNULL(name, 'x') FROM users", nil)
+	require.Len(t, steps, 4)
+	assert.Equal(t, RewriteStep{Step: "original", Detail: "SELECT IFNULL(name, 'x') FROM users"}, steps[0])
+	assert.Equal(t, RewriteStep{Step: "statement_type", Detail: "SELECT"}, steps[1])
+	assert.Equal(t, RewriteStep{Step: "function", Detail: "IFNULL() -> COALESCE()"}, steps[2])
+	assert.Equal(t, "rewritten", steps[3].Step)
+	assert.Contains(t, steps[3].Detail, "COALESCE")
+
+	steps = r.ExplainRewrite("SELECT FROM", nil)
+	assert.Equal(t, "error", steps[len(steps)-1].Step)
+
+	// The trace is only collected for ExplainRewrite
+	_, err := r.RewriteStatement("SELECT IFNULL(name, 'x') FROM users", nil)
+	require.NoError(t, err)
+	assert.Nil(t, r.astRewriter.visitor.trace)
+}
+
+func TestParseExplainRewrite(t *testing.T) {
+	tests := []struct {
+		query string
+		sql   string
+		ok    bool
+	}{
+		{"SELECT aproxy_explain_rewrite('SELECT 1')", "SELECT 1", true},
+		{"select APROXY_EXPLAIN_REWRITE('SELECT ''a''')", "SELECT 'a'", true},
+		{"SELECT aproxy_explain_rewrite('SELECT 1') FROM t", "", false},
+		{"SELECT aproxy_explain_rewrite('SELECT 1'), 2", "", false},
+		{"SELECT aproxy_explain_rewrite(1)", "", false},
+		{"SELECT 1", "", false},
+	}
+	for _, tt := range tests {
+		sql, ok := ParseExplainRewrite(tt.query)
+		assert.Equal(t, tt.ok, ok, tt.query)
+		assert.Equal(t, tt.sql, sql, tt.query)
+	}
+}
@@ -0,0 +1,103 @@
+package sqlrewrite
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+)
+
+// splitTruncateTables makes a TRUNCATE of several tables parseable. MySQL's grammar takes
+// one table, so the statement becomes one TRUNCATE per table, which truncateOf merges
+// into a single PostgreSQL TRUNCATE again
+//
+//	TRUNCATE TABLE a, b -> TRUNCATE TABLE a; TRUNCATE TABLE b
+func splitTruncateTables(sql string) string {
+	start, ok := matchKeywords(sql, skipSpaces(sql, 0), "TRUNCATE")
+	if !ok {
+		return sql
+	}
+	if end, ok := matchKeywords(sql, skipSpaces(sql, start), "TABLE"); ok {
+		start = end
+	}
+
+	var tables []string
+	last := start
+	for i := start; i < len(sql); i++ {
+		switch sql[i] {
+		case '\'', '"', '`':
+			i = skipQuoted(sql, i) - 1
+		case ',':
+			tables = append(tables, sql[last:i])
+			last = i + 1
+		}
+	}
+	if len(tables) == 0 {
+		return sql
+	}
+	tables = append(tables, sql[last:])
+
+	statements := make([]string, len(tables))
+	for i, table := range tables {
+		statements[i] = "TRUNCATE TABLE " + strings.TrimSpace(table)
+	}
+	return strings.Join(statements, "; ")
+}
+
+// truncateOf returns the PostgreSQL TRUNCATE of stmts when they are TRUNCATEs, more than
+// one for a TRUNCATE of several tables, see splitTruncateTables
+func truncateOf(stmts []ast.StmtNode) (*pgTruncateStmt, bool) {
+	truncate := &pgTruncateStmt{}
+	for _, stmt := range stmts {
+		node, ok := stmt.(*ast.TruncateTableStmt)
+		if !ok {
+			return nil, false
+		}
+		if truncate.TruncateTableStmt == nil {
+			truncate.TruncateTableStmt = node
+		}
+		truncate.Tables = append(truncate.Tables, node.Table)
+	}
+	return truncate, true
+}
+
+// pgTruncateStmt renders TRUNCATE of one or more tables. MySQL's TRUNCATE resets the
+// AUTO_INCREMENT counter, PostgreSQL keeps sequences unless told to restart them
+//
+//	TRUNCATE TABLE a, b -> TRUNCATE TABLE "a", "b" RESTART IDENTITY
+type pgTruncateStmt struct {
+	*ast.TruncateTableStmt
+	Tables []*ast.TableName
+}
+
+// Restore implements ast.Node interface
+func (n *pgTruncateStmt) Restore(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord("TRUNCATE TABLE ")
+	for i, table := range n.Tables {
+		if i > 0 {
+			ctx.WritePlain(", ")
+		}
+		if err := table.Restore(ctx); err != nil {
+			return err
+		}
+	}
+	ctx.WriteKeyWord(" RESTART IDENTITY")
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgTruncateStmt) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgTruncateStmt)
+	for i, table := range n.Tables {
+		node, ok := table.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Tables[i] = node.(*ast.TableName)
+	}
+	return v.Leave(n)
+}
@@ -0,0 +1,72 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateAndDropTables(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "TRUNCATE restarts identity",
+			mysql:    "TRUNCATE TABLE users",
+			expected: `TRUNCATE TABLE "users" RESTART IDENTITY`,
+		},
+		{
+			name:     "TRUNCATE without TABLE",
+			mysql:    "TRUNCATE users",
+			expected: `TRUNCATE TABLE "users" RESTART IDENTITY`,
+		},
+		{
+			name:     "TRUNCATE several tables",
+			mysql:    "TRUNCATE TABLE users, `order items`, shop.orders",
+			expected: `TRUNCATE TABLE "users", "order items", "shop"."orders" RESTART IDENTITY`,
+		},
+		{
+			name:     "DROP several tables",
+			mysql:    "DROP TABLE IF EXISTS a, b, c",
+			expected: `DROP TABLE IF EXISTS "a", "b", "c"`,
+		},
+		{
+			name:     "DROP TEMPORARY TABLE",
+			mysql:    "DROP TEMPORARY TABLE IF EXISTS tmp_a, tmp_b",
+			expected: `DROP TABLE IF EXISTS "pg_temp"."tmp_a", "pg_temp"."tmp_b"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestDropTemporaryTableKeepsRegistries(t *testing.T) {
+	rewriter := NewASTRewriter()
+
+	_, err := rewriter.Rewrite("CREATE TABLE orders (id INT, status ENUM('new','paid'))")
+	require.NoError(t, err)
+	_, err = rewriter.Rewrite("DROP TEMPORARY TABLE IF EXISTS orders")
+	require.NoError(t, err)
+
+	// The permanent table's enum is still known
+	result, err := rewriter.Rewrite("SELECT id FROM orders ORDER BY status")
+	require.NoError(t, err)
+	assert.Contains(t, result, "CASE")
+}
+
+func TestSplitTruncateTables(t *testing.T) {
+	assert.Equal(t, "TRUNCATE TABLE a; TRUNCATE TABLE `b,c`", splitTruncateTables("TRUNCATE a, `b,c`"))
+	assert.Equal(t, "TRUNCATE TABLE a", splitTruncateTables("TRUNCATE TABLE a"))
+	assert.Equal(t, "SELECT a, b FROM t", splitTruncateTables("SELECT a, b FROM t"))
+}
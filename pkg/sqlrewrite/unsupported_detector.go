@@ -26,6 +26,7 @@ type UnsupportedPattern struct {
 	Suggestion string
 	Severity   string
 	Category   string
+	Unless     *regexp.Regexp // The feature is supported when this matches as well
 }
 
 // NewUnsupportedDetector creates a new unsupported feature detector
@@ -41,7 +42,7 @@ func (d *UnsupportedDetector) Detect(sql string) []UnsupportedFeature {
 	upperSQL := strings.ToUpper(sql)
 
 	for _, pattern := range d.patterns {
-		if pattern.Pattern.MatchString(upperSQL) {
+		if pattern.Pattern.MatchString(upperSQL) && (pattern.Unless == nil || !pattern.Unless.MatchString(upperSQL)) {
 			features = append(features, UnsupportedFeature{
 				Feature:    pattern.Name,
 				SQL:        sql,
@@ -60,10 +61,11 @@ func buildUnsupportedPatterns() []UnsupportedPattern {
 	return []UnsupportedPattern{
 		// SQL Syntax
 		{
-			Name:       "UPDATE ... LIMIT",
-			Pattern:    regexp.MustCompile(`(?i)UPDATE\s+.*\s+LIMIT\s+\d+`),
-			Suggestion: "Use subquery: UPDATE ... WHERE id IN (SELECT id ... LIMIT n)",
-			Severity:   "error",
+			Name:       "UPDATE ... ORDER BY without LIMIT",
+			Pattern:    regexp.MustCompile(`(?i)^\s*UPDATE\s.*\sORDER\s+BY\s`),
+			Unless:     regexp.MustCompile(`(?i)\sLIMIT\s`),
+			Suggestion: "ORDER BY is dropped, without LIMIT it only decides the order rows are written in",
+			Severity:   "warning",
 			Category:   "syntax",
 		},
 		{
@@ -109,16 +111,16 @@ func buildUnsupportedPatterns() []UnsupportedPattern {
 			Category:   "syntax",
 		},
 		{
-			Name:       "PARTITION BY in CREATE TABLE",
-			Pattern:    regexp.MustCompile(`(?i)PARTITION\s+BY`),
-			Suggestion: "Rewrite using PostgreSQL declarative partitioning syntax",
-			Severity:   "error",
+			Name:       "CREATE TABLE ... IGNORE/REPLACE SELECT",
+			Pattern:    regexp.MustCompile(`(?i)CREATE\s+TABLE\s+.*\s(IGNORE|REPLACE)\s+(AS\s+)?\(?\s*SELECT\b`),
+			Suggestion: "Runs as plain CREATE TABLE AS; deduplicate in the SELECT (DISTINCT ON) if rows may collide",
+			Severity:   "warning",
 			Category:   "syntax",
 		},
 		{
-			Name:       "VALUES() function in INSERT ... ON DUPLICATE KEY UPDATE",
-			Pattern:    regexp.MustCompile(`(?i)ON\s+DUPLICATE\s+KEY\s+UPDATE.*VALUES\s*\(`),
-			Suggestion: "Use EXCLUDED table reference instead of VALUES()",
+			Name:       "PARTITION BY in CREATE TABLE",
+			Pattern:    regexp.MustCompile(`(?i)PARTITION\s+BY`),
+			Suggestion: "Rewrite using PostgreSQL declarative partitioning syntax",
 			Severity:   "error",
 			Category:   "syntax",
 		},
@@ -235,11 +237,25 @@ func buildUnsupportedPatterns() []UnsupportedPattern {
 		// Other
 		{
 			Name:       "LOAD DATA INFILE",
-			Pattern:    regexp.MustCompile(`(?i)LOAD\s+DATA\s+(LOCAL\s+)?INFILE`),
-			Suggestion: "Use PostgreSQL COPY FROM command",
+			Pattern:    regexp.MustCompile(`(?i)LOAD\s+DATA\s+((LOW_PRIORITY|CONCURRENT)\s+)?INFILE`),
+			Suggestion: "Use LOAD DATA LOCAL INFILE (streamed into COPY FROM STDIN) or PostgreSQL COPY FROM",
 			Severity:   "error",
 			Category:   "other",
 		},
+		{
+			Name:       "CREATE TRIGGER",
+			Pattern:    regexp.MustCompile(`(?i)^\s*CREATE\s+(OR\s+REPLACE\s+)?(DEFINER\s*=\s*\S+\s+)?TRIGGER\s`),
+			Suggestion: "Only PostgreSQL triggers (CREATE TRIGGER ... EXECUTE FUNCTION f()) are passed through, MySQL trigger bodies must be rewritten as PL/pgSQL trigger functions",
+			Severity:   "warning",
+			Category:   "other",
+		},
+		{
+			Name:       "CREATE FUNCTION/PROCEDURE",
+			Pattern:    regexp.MustCompile(`(?i)^\s*CREATE\s+(OR\s+REPLACE\s+)?(DEFINER\s*=\s*\S+\s+)?(AGGREGATE\s+)?(FUNCTION|PROCEDURE)\s`),
+			Suggestion: "Only PL/pgSQL routines (AS $$ ... $$ LANGUAGE plpgsql) are passed through, MySQL routine bodies must be rewritten",
+			Severity:   "warning",
+			Category:   "other",
+		},
 		{
 			Name:       "LOCK TABLES",
 			Pattern:    regexp.MustCompile(`(?i)LOCK\s+TABLES`),
@@ -257,8 +273,8 @@ func buildUnsupportedPatterns() []UnsupportedPattern {
 		{
 			Name:       "User variable (@variable)",
 			Pattern:    regexp.MustCompile(`@\w+`),
-			Suggestion: "Use temporary tables or session variables",
-			Severity:   "warning",
+			Suggestion: "SET @var, SELECT ... INTO @var and row numbering with SELECT @var := @var + 1 are emulated by the proxy, other assignments inside expressions (@var := ...) are not",
+			Severity:   "info",
 			Category:   "other",
 		},
 	}
@@ -0,0 +1,42 @@
+package sqlrewrite
+
+import (
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// rewriteUpdateOrderLimit moves ORDER BY and LIMIT of a single-table UPDATE into a
+// subquery picking the rows by ctid, PostgreSQL's UPDATE has neither clause. The rows
+// are locked as they are picked, as MySQL does while scanning in that order
+// MySQL: UPDATE jobs SET state = 'run' WHERE state = 'new' ORDER BY created LIMIT 10
+// PostgreSQL: UPDATE "jobs" SET "state"='run' WHERE "ctid" IN (SELECT "ctid" FROM "jobs" WHERE "state"='new' ORDER BY "created" LIMIT 10 FOR UPDATE)
+//
+// Without LIMIT the order only decides which rows are written first, the ORDER BY is
+// dropped and the unsupported-feature detector logs a warning
+func rewriteUpdateOrderLimit(node *ast.UpdateStmt) *ast.UpdateStmt {
+	if node.MultipleTable {
+		return node // MySQL refuses ORDER BY and LIMIT in multiple-table UPDATE
+	}
+	if node.Limit == nil {
+		node.Order = nil
+		return node
+	}
+
+	sel := &ast.SelectStmt{
+		SelectStmtOpts: &ast.SelectStmtOpts{SQLCache: true},
+		Kind:           ast.SelectStmtKindSelect,
+		Fields:         &ast.FieldList{Fields: []*ast.SelectField{{Expr: ctidColumn()}}},
+		From:           node.TableRefs,
+		Where:          node.Where,
+		OrderBy:        node.Order,
+		Limit:          node.Limit,
+		LockInfo:       &ast.SelectLockInfo{LockType: ast.SelectLockForUpdate},
+	}
+	node.Where = &ast.PatternInExpr{Expr: ctidColumn(), Sel: &ast.SubqueryExpr{Query: sel}}
+	node.Order, node.Limit = nil, nil
+	return node
+}
+
+// ctidColumn is PostgreSQL's physical row address, unique within a table
+func ctidColumn() *ast.ColumnNameExpr {
+	return &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: ast.NewCIStr("ctid")}}
+}
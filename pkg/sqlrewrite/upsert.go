@@ -0,0 +1,208 @@
+package sqlrewrite
+
+import (
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+)
+
+// rewriteOnDuplicateKeyUpdate turns ON DUPLICATE KEY UPDATE into PostgreSQL's ON CONFLICT.
+// The assignments went through the visitor like any other expression, VALUES(col) now
+// reads EXCLUDED.col and other columns the existing row of the table
+// MySQL: INSERT INTO t (id, n) VALUES (1, 1) ON DUPLICATE KEY UPDATE n = n + VALUES(n), at = NOW()
+// PostgreSQL: INSERT INTO "t" ("id","n") VALUES (1,1) ON CONFLICT ("id") DO UPDATE SET "n"="t"."n"+"excluded"."n","at"=CURRENT_TIMESTAMP
+//
+// PostgreSQL needs the key the conflict is checked on. It is the first PRIMARY KEY or
+// UNIQUE key of CREATE TABLE whose columns the INSERT all writes, as only those can
+// collide, and the primary key constraint for tables created outside the proxy
+func (v *ASTVisitor) rewriteOnDuplicateKeyUpdate(node *ast.InsertStmt) ast.Node {
+	table := insertTable(node)
+	if table == nil {
+		return node
+	}
+
+	upsert := &pgUpsertStmt{InsertStmt: node, Assignments: node.OnDuplicate}
+	node.OnDuplicate = nil
+	target := &targetColumnVisitor{table: table.Name}
+	for _, assignment := range upsert.Assignments {
+		// SET t.col = ... names the target column, PostgreSQL does not qualify it
+		assignment.Column = &ast.ColumnName{Name: assignment.Column.Name}
+		assignment.Expr.Accept(target)
+	}
+
+	if !v.columnTypes.HasTable(table.Name.L) {
+		upsert.Constraint = table.Name.O + "_pkey"
+		return upsert
+	}
+	keys := v.columnTypes.Keys(table.Name.L)
+	if len(keys) == 0 {
+		return node // Nothing can collide, as in MySQL the rows are just inserted
+	}
+	upsert.Target = keys[0]
+	if len(node.Columns) > 0 {
+		written := make(map[string]bool, len(node.Columns))
+		for _, col := range node.Columns {
+			written[col.Name.L] = true
+		}
+		for _, key := range keys {
+			if writesAll(written, key) {
+				upsert.Target = key
+				break
+			}
+		}
+	}
+	return upsert
+}
+
+// targetColumnVisitor qualifies the columns of ON CONFLICT DO UPDATE with the target
+// table, PostgreSQL finds a bare name in both the existing row and EXCLUDED and
+// refuses it as ambiguous
+type targetColumnVisitor struct {
+	table ast.CIStr
+}
+
+// Enter implements ast.Visitor interface
+func (v *targetColumnVisitor) Enter(n ast.Node) (ast.Node, bool) {
+	_, subquery := n.(*ast.SubqueryExpr) // Columns of a subquery belong to its own tables
+	return n, subquery
+}
+
+// Leave implements ast.Visitor interface
+func (v *targetColumnVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	if col, ok := n.(*ast.ColumnNameExpr); ok && col.Name.Table.L == "" {
+		col.Name.Table = v.table
+	}
+	return n, true
+}
+
+// writesAll reports whether every column of key is written
+func writesAll(written map[string]bool, key []string) bool {
+	for _, col := range key {
+		if !written[col] {
+			return false
+		}
+	}
+	return true
+}
+
+// insertTable returns the table an INSERT writes
+func insertTable(node *ast.InsertStmt) *ast.TableName {
+	if node.Table == nil || node.Table.TableRefs == nil {
+		return nil
+	}
+	source, ok := node.Table.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return nil
+	}
+	name, _ := source.Source.(*ast.TableName)
+	return name
+}
+
+// uniqueKeys returns the columns of the PRIMARY KEY and UNIQUE keys a CREATE TABLE
+// declares, the primary key first
+func uniqueKeys(node *ast.CreateTableStmt) [][]string {
+	var primary []string
+	var unique [][]string
+	for _, col := range node.Cols {
+		for _, opt := range col.Options {
+			switch opt.Tp {
+			case ast.ColumnOptionPrimaryKey:
+				primary = []string{col.Name.Name.L}
+			case ast.ColumnOptionUniqKey:
+				unique = append(unique, []string{col.Name.Name.L})
+			}
+		}
+	}
+	for _, constraint := range node.Constraints {
+		var columns []string
+		for _, key := range constraint.Keys {
+			if key.Column != nil {
+				columns = append(columns, key.Column.Name.L)
+			}
+		}
+		if len(columns) == 0 || len(columns) != len(constraint.Keys) {
+			continue // Expression keys cannot be named as a conflict target
+		}
+		switch constraint.Tp {
+		case ast.ConstraintPrimaryKey:
+			primary = columns
+		case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+			unique = append(unique, columns)
+		}
+	}
+
+	if primary == nil {
+		return unique
+	}
+	return append([][]string{primary}, unique...)
+}
+
+// pgUpsertStmt renders an INSERT with PostgreSQL's ON CONFLICT ... DO UPDATE, which
+// TiDB's InsertStmt cannot express
+//
+//	ON DUPLICATE KEY UPDATE n = n + 1 -> ON CONFLICT ("id") DO UPDATE SET "n"="t"."n"+1
+//	                                  -> ON CONFLICT ON CONSTRAINT "t_pkey" DO UPDATE SET "n"="t"."n"+1
+type pgUpsertStmt struct {
+	*ast.InsertStmt
+	Target      []string // Key columns the conflict is checked on
+	Constraint  string   // Or the name of the key constraint
+	Assignments []*ast.Assignment
+}
+
+// Restore implements ast.Node interface
+func (n *pgUpsertStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := n.InsertStmt.Restore(ctx); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord(" ON CONFLICT ")
+	if n.Constraint != "" {
+		ctx.WriteKeyWord("ON CONSTRAINT ")
+		ctx.WriteName(n.Constraint)
+	} else {
+		ctx.WritePlain("(")
+		for i, col := range n.Target {
+			if i > 0 {
+				ctx.WritePlain(",")
+			}
+			ctx.WriteName(col)
+		}
+		ctx.WritePlain(")")
+	}
+	ctx.WriteKeyWord(" DO UPDATE SET ")
+	for i, assignment := range n.Assignments {
+		if i > 0 {
+			ctx.WritePlain(",")
+		}
+		if err := assignment.Restore(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Accept implements ast.Node interface
+func (n *pgUpsertStmt) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgUpsertStmt)
+	node, ok := n.InsertStmt.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.InsertStmt = node.(*ast.InsertStmt)
+	for i, assignment := range n.Assignments {
+		node, ok = assignment.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Assignments[i] = node.(*ast.Assignment)
+	}
+	return v.Leave(n)
+}
+
+// excludedColumn turns VALUES(col) in ON DUPLICATE KEY UPDATE into the row proposed
+// for insertion, which PostgreSQL calls EXCLUDED
+func excludedColumn(node *ast.ValuesExpr) ast.ExprNode {
+	return &ast.ColumnNameExpr{Name: &ast.ColumnName{Table: ast.NewCIStr("excluded"), Name: node.Column.Name.Name}}
+}
@@ -0,0 +1,75 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteOnDuplicateKeyUpdate(t *testing.T) {
+	rewriter := NewASTRewriter()
+	for _, ddl := range []string{
+		"CREATE TABLE counters (id INT PRIMARY KEY, email VARCHAR(50) UNIQUE, count INT, updated_at DATETIME)",
+		"CREATE TABLE pairs (a INT, b INT, n INT, UNIQUE KEY (a, b))",
+		"CREATE TABLE logs (a INT, n INT)",
+	} {
+		_, err := rewriter.Rewrite(ddl)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Mixed VALUES(), columns, arithmetic and NOW()",
+			mysql:    "INSERT INTO counters (id, count) VALUES (1, 5) ON DUPLICATE KEY UPDATE count = count + VALUES(count), updated_at = NOW()",
+			expected: `INSERT INTO "counters" ("id","count") VALUES (1,5) ON CONFLICT ("id") DO UPDATE SET "count"="counters"."count"+"excluded"."count","updated_at"=CURRENT_TIMESTAMP`,
+		},
+		{
+			name:     "Functions and literals",
+			mysql:    "INSERT INTO counters (id, count) VALUES (1, 5) ON DUPLICATE KEY UPDATE count = IFNULL(count, 0) + 1, email = 'x'",
+			expected: `INSERT INTO "counters" ("id","count") VALUES (1,5) ON CONFLICT ("id") DO UPDATE SET "count"=COALESCE("counters"."count", 0)+1,"email"='x'`,
+		},
+		{
+			name:     "Qualified columns",
+			mysql:    "INSERT INTO counters (id, count) VALUES (1, 5) ON DUPLICATE KEY UPDATE counters.count = counters.count + 1",
+			expected: `INSERT INTO "counters" ("id","count") VALUES (1,5) ON CONFLICT ("id") DO UPDATE SET "count"="counters"."count"+1`,
+		},
+		{
+			name:     "Placeholders keep their order",
+			mysql:    "INSERT INTO counters (id, count) VALUES (?, ?) ON DUPLICATE KEY UPDATE count = count + ?",
+			expected: `INSERT INTO "counters" ("id","count") VALUES ($1,$2) ON CONFLICT ("id") DO UPDATE SET "count"="counters"."count"+$3`,
+		},
+		{
+			name:     "Unique key written instead of the primary key",
+			mysql:    "INSERT INTO counters (email, count) VALUES ('a@b.c', 1) ON DUPLICATE KEY UPDATE count = VALUES(count)",
+			expected: `INSERT INTO "counters" ("email","count") VALUES ('a@b.c',1) ON CONFLICT ("email") DO UPDATE SET "count"="excluded"."count"`,
+		},
+		{
+			name:     "Composite unique key",
+			mysql:    "INSERT INTO pairs (a, b, n) VALUES (1, 2, 3) ON DUPLICATE KEY UPDATE n = n + VALUES(n)",
+			expected: `INSERT INTO "pairs" ("a","b","n") VALUES (1,2,3) ON CONFLICT ("a","b") DO UPDATE SET "n"="pairs"."n"+"excluded"."n"`,
+		},
+		{
+			name:     "Table without keys never conflicts",
+			mysql:    "INSERT INTO logs (a, n) VALUES (1, 2) ON DUPLICATE KEY UPDATE n = VALUES(n)",
+			expected: `INSERT INTO "logs" ("a","n") VALUES (1,2)`,
+		},
+		{
+			name:     "Unknown table falls back to the primary key",
+			mysql:    "INSERT INTO users (id, name) VALUES (1, 'John') ON DUPLICATE KEY UPDATE name = VALUES(name)",
+			expected: `INSERT INTO "users" ("id","name") VALUES (1,'John') ON CONFLICT ON CONSTRAINT "users_pkey" DO UPDATE SET "name"="excluded"."name"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package sqlrewrite
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+)
+
+var (
+	alterViewRe   = regexp.MustCompile(`(?is)^\s*ALTER\s+((?:ALGORITHM\s*=\s*\w+\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:SQL\s+SECURITY\s+\w+\s+)?)VIEW\s`)
+	createViewRe  = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(?:ALGORITHM\s*=\s*\w+\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:SQL\s+SECURITY\s+\w+\s+)?VIEW\s`)
+	checkOptionRe = regexp.MustCompile(`(?is)\s+WITH\s+(?:(CASCADED|LOCAL)\s+)?CHECK\s+OPTION$`)
+)
+
+// normalizeAlterView turns ALTER VIEW, which the parser does not accept, into the
+// CREATE OR REPLACE VIEW MySQL documents it as. PostgreSQL then only accepts the
+// new definition if it keeps the existing columns, new ones going last
+//
+//	ALTER ALGORITHM=MERGE VIEW v AS SELECT ... -> CREATE OR REPLACE ALGORITHM=MERGE VIEW v AS SELECT ...
+func normalizeAlterView(sql string) string {
+	if m := alterViewRe.FindStringSubmatchIndex(sql); m != nil {
+		return "CREATE OR REPLACE " + sql[m[2]:]
+	}
+	return sql
+}
+
+// splitCheckOption splits WITH [CASCADED | LOCAL] CHECK OPTION off CREATE VIEW. The
+// parser reads no option and CASCADED alike, so it is put back after rewriting
+//
+//	CREATE VIEW v AS SELECT ... WITH CHECK OPTION -> ("CREATE VIEW v AS SELECT ...", "WITH CASCADED CHECK OPTION")
+func splitCheckOption(sql string) (string, string) {
+	if !createViewRe.MatchString(sql) {
+		return sql, ""
+	}
+	m := checkOptionRe.FindStringSubmatchIndex(sql)
+	if m == nil {
+		return sql, ""
+	}
+	option := "CASCADED"
+	if m[2] >= 0 {
+		option = strings.ToUpper(sql[m[2]:m[3]])
+	}
+	return sql[:m[0]], "WITH " + option + " CHECK OPTION"
+}
+
+// pgCreateViewStmt is CREATE VIEW without the clauses PostgreSQL has no syntax for.
+// ALGORITHM is up to the planner and PostgreSQL views always run with their owner's
+// rights, what DEFINER and SQL SECURITY DEFINER ask for
+//
+//	CREATE ALGORITHM=MERGE DEFINER=`app`@`%` SQL SECURITY DEFINER VIEW v AS SELECT ... -> CREATE VIEW "v" AS SELECT ...
+type pgCreateViewStmt struct {
+	*ast.CreateViewStmt
+}
+
+// Restore implements ast.Node interface
+func (n *pgCreateViewStmt) Restore(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord("CREATE ")
+	if n.OrReplace {
+		ctx.WriteKeyWord("OR REPLACE ")
+	}
+	ctx.WriteKeyWord("VIEW ")
+	if err := n.ViewName.Restore(ctx); err != nil {
+		return err
+	}
+	if len(n.Cols) > 0 {
+		ctx.WritePlain(" (")
+		for i, col := range n.Cols {
+			if i > 0 {
+				ctx.WritePlain(",")
+			}
+			ctx.WriteName(col.O)
+		}
+		ctx.WritePlain(")")
+	}
+	ctx.WriteKeyWord(" AS ")
+	return n.Select.Restore(ctx)
+}
+
+// Accept implements ast.Node interface
+func (n *pgCreateViewStmt) Accept(v ast.Visitor) (ast.Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*pgCreateViewStmt)
+	// Not through the CreateViewStmt, Leave would wrap it again
+	node, ok := n.Select.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Select = node.(ast.StmtNode)
+	return v.Leave(n)
+}
@@ -0,0 +1,70 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteViews(t *testing.T) {
+	rewriter := NewRewriter(true)
+
+	tests := []struct {
+		name     string
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "Functions and LIMIT in the definition",
+			mysql:    "CREATE VIEW recent AS SELECT id, CONCAT(first, ' ', last) AS name, NOW() AS seen FROM users LIMIT 2, 5",
+			expected: `CREATE VIEW "recent" AS SELECT "id",CONCAT("first", ' ', "last") AS "name",CURRENT_TIMESTAMP AS "seen" FROM "users" LIMIT 5 OFFSET 2`,
+		},
+		{
+			name:     "ALGORITHM, DEFINER and SQL SECURITY are dropped",
+			mysql:    "CREATE OR REPLACE ALGORITHM=MERGE DEFINER=`app`@`%` SQL SECURITY DEFINER VIEW `v` (a, b) AS SELECT IFNULL(x, 1), `y` FROM t",
+			expected: `CREATE OR REPLACE VIEW "v" ("a","b") AS SELECT COALESCE("x", 1),"y" FROM "t"`,
+		},
+		{
+			name:     "Check options",
+			mysql:    "CREATE VIEW v AS SELECT a FROM t WHERE a > 0 WITH CHECK OPTION",
+			expected: `CREATE VIEW "v" AS SELECT "a" FROM "t" WHERE "a">0 WITH CASCADED CHECK OPTION`,
+		},
+		{
+			name:     "Local check option",
+			mysql:    "CREATE VIEW v AS SELECT a FROM t WHERE a > 0 with local check option;",
+			expected: `CREATE VIEW "v" AS SELECT "a" FROM "t" WHERE "a">0 WITH LOCAL CHECK OPTION`,
+		},
+		{
+			name:     "ALTER VIEW",
+			mysql:    "ALTER ALGORITHM=UNDEFINED SQL SECURITY INVOKER VIEW v AS SELECT IF(a > 0, 'y', 'n') AS pos FROM t",
+			expected: `CREATE OR REPLACE VIEW "v" AS SELECT CASE WHEN "a">0 THEN 'y' ELSE 'n' END AS "pos" FROM "t"`,
+		},
+		{
+			name:     "DROP VIEW IF EXISTS",
+			mysql:    "DROP VIEW IF EXISTS v, `w`",
+			expected: `DROP VIEW IF EXISTS "v", "w"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := rewriter.RewriteStatement(TrimStatement(tt.mysql), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stmt.SQL)
+			assert.Equal(t, StatementDDL, stmt.Type)
+		})
+	}
+}
+
+func TestSplitCheckOption(t *testing.T) {
+	// Only off CREATE VIEW
+	sql := "SELECT a FROM t WITH CHECK OPTION"
+	body, option := splitCheckOption(sql)
+	assert.Equal(t, sql, body)
+	assert.Empty(t, option)
+
+	body, option = splitCheckOption("CREATE DEFINER=CURRENT_USER VIEW v AS SELECT 1 WITH CASCADED CHECK OPTION")
+	assert.Equal(t, "CREATE DEFINER=CURRENT_USER VIEW v AS SELECT 1", body)
+	assert.Equal(t, "WITH CASCADED CHECK OPTION", option)
+}
@@ -0,0 +1,75 @@
+package sqlrewrite
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	driver "github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// ZeroDatePolicy decides what INSERT and UPDATE write for MySQL's zero dates
+// '0000-00-00' and '0000-00-00 00:00:00', which PostgreSQL rejects
+type ZeroDatePolicy string
+
+const (
+	// ZeroDateNull writes NULL
+	ZeroDateNull ZeroDatePolicy = "null"
+	// ZeroDateError fails the statement, as MySQL does under NO_ZERO_DATE
+	ZeroDateError ZeroDatePolicy = "error"
+	// ZeroDateEpoch writes 1970-01-01 (00:00:00)
+	ZeroDateEpoch ZeroDatePolicy = "epoch"
+)
+
+// zeroDatePattern matches the zero date and datetime literals, with optional fraction
+var zeroDatePattern = regexp.MustCompile(`^0000-00-00( 00:00:00(\.0+)?)?$`)
+
+// rewriteZeroDates replaces the zero date literals of a value an INSERT or UPDATE
+// writes according to the ZeroDatePolicy. Conditions keep them, they only compare
+// MySQL: INSERT INTO t (d, ts) VALUES ('0000-00-00', '0000-00-00 00:00:00')
+// PostgreSQL: INSERT INTO "t" ("d","ts") VALUES (NULL,NULL)
+//
+//	epoch: VALUES ('1970-01-01','1970-01-01 00:00:00')
+func (v *ASTVisitor) rewriteZeroDates(expr ast.ExprNode) ast.ExprNode {
+	if v.err != nil {
+		return expr
+	}
+	node, _ := expr.Accept(&zeroDateVisitor{visitor: v})
+	return node.(ast.ExprNode)
+}
+
+// zeroDateVisitor replaces the zero date literals of an expression
+type zeroDateVisitor struct {
+	visitor *ASTVisitor
+}
+
+// Enter implements ast.Visitor interface
+func (z *zeroDateVisitor) Enter(n ast.Node) (ast.Node, bool) {
+	_, subquery := n.(*ast.SubqueryExpr) // INSERT ... VALUES ((SELECT ...)) only reads
+	return n, subquery
+}
+
+// Leave implements ast.Visitor interface
+func (z *zeroDateVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	value, ok := n.(*driver.ValueExpr)
+	if !ok || value.Kind() != driver.KindString || !zeroDatePattern.MatchString(value.GetString()) {
+		return n, true
+	}
+
+	switch z.visitor.zeroDates {
+	case ZeroDateError:
+		z.visitor.err = fmt.Errorf("incorrect date value '%s': PostgreSQL has no zero dates", value.GetString())
+		return n, false
+	case ZeroDateEpoch:
+		if len(value.GetString()) == len("0000-00-00") {
+			return ast.NewValueExpr("1970-01-01", "", ""), true
+		}
+		return ast.NewValueExpr("1970-01-01 00:00:00", "", ""), true
+	}
+	return ast.NewValueExpr(nil, "", ""), true
+}
+
+// SetZeroDatePolicy sets what INSERT and UPDATE write for zero dates
+func (v *ASTVisitor) SetZeroDatePolicy(policy ZeroDatePolicy) {
+	v.zeroDates = policy
+}
@@ -0,0 +1,71 @@
+package sqlrewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteZeroDates(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   ZeroDatePolicy
+		mysql    string
+		expected string
+	}{
+		{
+			name:     "INSERT writes NULL",
+			policy:   ZeroDateNull,
+			mysql:    "INSERT INTO t (d, ts) VALUES ('0000-00-00', '0000-00-00 00:00:00'), (?, IFNULL(?, '0000-00-00 00:00:00.000'))",
+			expected: `INSERT INTO "t" ("d","ts") VALUES (NULL,NULL),($1,COALESCE($2, NULL))`,
+		},
+		{
+			name:     "UPDATE keeps its condition",
+			policy:   ZeroDateNull,
+			mysql:    "UPDATE t SET d = '0000-00-00', note = '0000-00-01' WHERE id = 1",
+			expected: `UPDATE "t" SET "d"=NULL, "note"='0000-00-01' WHERE "id"=1`,
+		},
+		{
+			name:     "INSERT writes the epoch",
+			policy:   ZeroDateEpoch,
+			mysql:    "INSERT INTO t (d, ts) VALUES ('0000-00-00', '0000-00-00 00:00:00')",
+			expected: `INSERT INTO "t" ("d","ts") VALUES ('1970-01-01','1970-01-01 00:00:00')`,
+		},
+		{
+			name:     "ON DUPLICATE KEY UPDATE writes the epoch",
+			policy:   ZeroDateEpoch,
+			mysql:    "INSERT INTO t (id, d) VALUES (1, '2024-01-01') ON DUPLICATE KEY UPDATE d = '0000-00-00'",
+			expected: `INSERT INTO "t" ("id","d") VALUES (1,'2024-01-01') ON CONFLICT ON CONSTRAINT "t_pkey" DO UPDATE SET "d"='1970-01-01'`,
+		},
+		{
+			name:     "SELECT is untouched",
+			policy:   ZeroDateError,
+			mysql:    "SELECT id FROM t WHERE d = '0000-00-00'",
+			expected: `SELECT "id" FROM "t" WHERE "d"='0000-00-00'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewriter := NewASTRewriter()
+			rewriter.visitor.SetZeroDatePolicy(tt.policy)
+			result, err := rewriter.Rewrite(tt.mysql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("error policy rejects the statement", func(t *testing.T) {
+		rewriter := NewASTRewriter()
+		rewriter.visitor.SetZeroDatePolicy(ZeroDateError)
+		_, err := rewriter.Rewrite("INSERT INTO t (d) VALUES ('0000-00-00')")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "incorrect date value '0000-00-00'")
+
+		// The failure does not stick to the next statement
+		result, err := rewriter.Rewrite("INSERT INTO t (d) VALUES ('2024-01-01')")
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "t" ("d") VALUES ('2024-01-01')`, result)
+	})
+}
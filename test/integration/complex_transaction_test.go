@@ -448,6 +448,72 @@ func TestDeadlockHandling(t *testing.T) {
 	})
 }
 
+// TestConcurrentAtomicIncrements tests that self-referential updates do not lose
+// writes when many transactions run concurrently. Run the proxy with
+// connection_mode: pooled to cover pooled backend connections
+func TestConcurrentAtomicIncrements(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip concurrency test in short mode")
+	}
+
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	defer cleanupPostgreSQL(t, "atomic_accounts")
+
+	_, err := db.Exec(`
+		CREATE TABLE atomic_accounts (
+			id INT PRIMARY KEY,
+			balance INT NOT NULL
+		)
+	`)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO atomic_accounts (id, balance) VALUES (1, 10000), (2, 0)")
+	require.NoError(t, err)
+
+	const workers, transfers = 20, 25
+	db.SetMaxOpenConns(workers)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	committed := 0
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < transfers; i++ {
+				tx, err := db.Begin()
+				if err != nil {
+					t.Errorf("begin: %v", err)
+					return
+				}
+				_, err = tx.Exec("UPDATE atomic_accounts SET balance = balance - 1 WHERE id = 1")
+				if err == nil {
+					_, err = tx.Exec("UPDATE atomic_accounts SET balance = balance + 1 WHERE id = 2")
+				}
+				// Every fifth transfer is rolled back and must leave no trace
+				if err != nil || (w+i)%5 == 0 {
+					tx.Rollback()
+					continue
+				}
+				if err := tx.Commit(); err != nil {
+					continue
+				}
+				mu.Lock()
+				committed++
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var from, to int
+	require.NoError(t, db.QueryRow("SELECT balance FROM atomic_accounts WHERE id = 1").Scan(&from))
+	require.NoError(t, db.QueryRow("SELECT balance FROM atomic_accounts WHERE id = 2").Scan(&to))
+	assert.Greater(t, committed, 0)
+	assert.Equal(t, 10000-committed, from)
+	assert.Equal(t, committed, to)
+}
+
 // TestLongRunningTransaction tests long-running transactions
 func TestLongRunningTransaction(t *testing.T) {
 	if testing.Short() {
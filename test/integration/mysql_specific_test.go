@@ -3,12 +3,17 @@
 package integration
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -288,6 +293,7 @@ func TestMySQLSpecific_Summary(t *testing.T) {
 	t.Log("📚 Reference: prompt/mysql_to_MATCH_AGAINST.md")
 	t.Log("=" + fmt.Sprintf("%80s", "="))
 }
+
 // TestLastInsertID tests LAST_INSERT_ID() function support
 // Verifies that AProxy correctly converts LAST_INSERT_ID() to PostgreSQL's lastval()
 func TestLastInsertID(t *testing.T) {
@@ -508,6 +514,52 @@ func TestTinyIntOne(t *testing.T) {
 	assert.Equal(t, 1, flag)
 }
 
+// TestTinyIntBooleanLiterals tests comparing TINYINT columns with TRUE/FALSE
+// PostgreSQL stores them as SMALLINT, which has no boolean comparison
+func TestTinyIntBooleanLiterals(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS test_tinyint_bool")
+	_, err = db.Exec(`CREATE TABLE test_tinyint_bool (
+		id INT PRIMARY KEY,
+		is_active TINYINT(1)
+	)`)
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS test_tinyint_bool")
+
+	_, err = db.Exec("INSERT INTO test_tinyint_bool (id, is_active) VALUES (1, 1), (2, 0), (3, NULL)")
+	require.NoError(t, err)
+
+	ids := func(query string) []int {
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		defer rows.Close()
+		var result []int
+		for rows.Next() {
+			var id int
+			require.NoError(t, rows.Scan(&id))
+			result = append(result, id)
+		}
+		require.NoError(t, rows.Err())
+		return result
+	}
+
+	assert.Equal(t, []int{1}, ids("SELECT id FROM test_tinyint_bool WHERE is_active = TRUE ORDER BY id"))
+	assert.Equal(t, []int{2}, ids("SELECT id FROM test_tinyint_bool WHERE is_active = FALSE ORDER BY id"))
+	assert.Equal(t, []int{1}, ids("SELECT id FROM test_tinyint_bool WHERE is_active IS TRUE ORDER BY id"))
+	assert.Equal(t, []int{2}, ids("SELECT id FROM test_tinyint_bool WHERE is_active IS FALSE ORDER BY id"))
+	assert.Equal(t, []int{2, 3}, ids("SELECT id FROM test_tinyint_bool WHERE is_active IS NOT TRUE ORDER BY id"))
+	assert.Equal(t, []int{3}, ids("SELECT id FROM test_tinyint_bool WHERE (is_active > 0) IS UNKNOWN ORDER BY id"))
+	assert.Equal(t, []int{1, 2}, ids("SELECT id FROM test_tinyint_bool WHERE is_active IS NOT UNKNOWN ORDER BY id"))
+	assert.Equal(t, []int{3}, ids("SELECT id FROM test_tinyint_bool WHERE CASE WHEN is_active IS UNKNOWN THEN 1 ELSE 0 END = 1 ORDER BY id"))
+
+	_, err = db.Exec("UPDATE test_tinyint_bool SET is_active = TRUE WHERE id = 2")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids("SELECT id FROM test_tinyint_bool WHERE is_active = TRUE ORDER BY id"))
+}
+
 // TestMediumInt tests MEDIUMINT type conversion
 // MySQL MEDIUMINT is converted to PostgreSQL INTEGER
 func TestMediumInt(t *testing.T) {
@@ -561,3 +613,2366 @@ func TestDisplayWidth(t *testing.T) {
 	assert.Equal(t, 123, val)
 	assert.Equal(t, 456, zeropad)
 }
+
+// TestAnyValue tests ANY_VALUE() in grouped queries
+// PostgreSQL has no ANY_VALUE before 16, so AProxy maps it to MIN() which picks
+// a deterministic value from the group
+func TestAnyValue(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS test_any_value")
+	_, err = db.Exec("CREATE TABLE test_any_value (id INT PRIMARY KEY, dept VARCHAR(50), name VARCHAR(50))")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO test_any_value VALUES (1, 'eng', 'Bob'), (2, 'eng', 'Alice'), (3, 'ops', 'Carol')")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS test_any_value")
+
+	rows, err := db.Query("SELECT dept, ANY_VALUE(name) FROM test_any_value GROUP BY dept HAVING ANY_VALUE(name) IS NOT NULL ORDER BY dept")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	got := map[string]string{}
+	for rows.Next() {
+		var dept, name string
+		require.NoError(t, rows.Scan(&dept, &name))
+		got[dept] = name
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, map[string]string{"eng": "Alice", "ops": "Carol"}, got)
+}
+
+// TestIntervalArithmetic tests INTERVAL expressions outside DATE_ADD/DATE_SUB
+// MySQL: created_at > NOW() - INTERVAL 7 DAY
+// PostgreSQL: created_at > (CURRENT_TIMESTAMP - INTERVAL '7 DAY')
+func TestIntervalArithmetic(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS test_interval")
+	_, err = db.Exec("CREATE TABLE test_interval (id INT PRIMARY KEY, created_at DATETIME)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS test_interval")
+
+	_, err = db.Exec("INSERT INTO test_interval VALUES (1, NOW() - INTERVAL 1 DAY), (2, NOW() - INTERVAL 30 DAY)")
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM test_interval WHERE created_at > NOW() - INTERVAL 7 DAY").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Fractional and compound units
+	err = db.QueryRow("SELECT COUNT(*) FROM test_interval WHERE created_at > NOW() - INTERVAL 1.5 DAY").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	err = db.QueryRow("SELECT COUNT(*) FROM test_interval WHERE created_at < NOW() - INTERVAL '2 12' DAY_HOUR").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+// TestVersionComments tests mysqldump-style /*!NNNNN ... */ comments
+// Versions up to the configured target are executed, newer ones are dropped
+func TestVersionComments(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS test_version_comment")
+	defer db.Exec("DROP TABLE IF EXISTS test_version_comment")
+
+	// Whole statement wrapped in a version comment, as mysqldump emits it
+	_, err = db.Exec("/*!40101 CREATE TABLE test_version_comment (id INT PRIMARY KEY, name VARCHAR(50)) */")
+	require.NoError(t, err)
+
+	_, err = db.Exec("/* dump data */ INSERT INTO test_version_comment VALUES (1, 'a') /*!40000 , (2, 'b') */")
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM test_version_comment").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Newer than the target version: dropped
+	var value int
+	err = db.QueryRow("SELECT 1 /*!99999 + 1 */").Scan(&value)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	// A statement that is entirely a skipped version comment is a no-op
+	_, err = db.Exec("/*!99999 DROP TABLE test_version_comment */")
+	assert.NoError(t, err)
+}
+
+// TestShowTablesLike tests SHOW TABLES LIKE / WHERE filtering
+func TestShowTablesLike(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	tables := []string{"stl_order_items", "stl_orders", "stl_users"}
+	for _, table := range tables {
+		_, _ = db.Exec("DROP TABLE IF EXISTS " + table)
+		_, err = db.Exec("CREATE TABLE " + table + " (id INT PRIMARY KEY)")
+		require.NoError(t, err)
+		defer db.Exec("DROP TABLE IF EXISTS " + table)
+	}
+
+	queryNames := func(query string) []string {
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var names []string
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"stl_order_items", "stl_orders"}, queryNames("SHOW TABLES LIKE 'stl_order%'"))
+	assert.Equal(t, []string{"stl_users"}, queryNames("SHOW TABLES LIKE 'stl\\_user_'"))
+	assert.Equal(t, []string{"stl_orders", "stl_users"},
+		queryNames("SHOW TABLES WHERE Tables_in_test IN ('stl_orders', 'stl_users')"))
+
+	// Quotes and backslashes in the pattern stay inside the literal, statements in
+	// WHERE are refused
+	assert.Empty(t, queryNames("SHOW TABLES LIKE 'stl''; DROP TABLE stl_users; --'"))
+	_, _ = db.Exec(`SHOW TABLES LIKE 'stl\\'; DROP TABLE stl_users; --'`)
+	_, err = db.Exec("SHOW TABLES WHERE 1=1) AS t; DROP TABLE stl_users; --")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"stl_users"}, queryNames("SHOW TABLES LIKE 'stl_users'"))
+}
+
+// TestShowFullTables tests the Table_type column of SHOW FULL TABLES
+func TestShowFullTables(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP VIEW IF EXISTS sft_active")
+	_, _ = db.Exec("DROP TABLE IF EXISTS sft_users")
+	_, err = db.Exec("CREATE TABLE sft_users (id INT PRIMARY KEY, active BOOLEAN)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS sft_users")
+	_, err = db.Exec("CREATE VIEW sft_active AS SELECT id FROM sft_users WHERE active")
+	require.NoError(t, err)
+	defer db.Exec("DROP VIEW IF EXISTS sft_active")
+
+	queryTypes := func(query string) []string {
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		require.NoError(t, err)
+		assert.Equal(t, "Table_type", columns[1])
+
+		var types []string
+		for rows.Next() {
+			var name, tableType string
+			require.NoError(t, rows.Scan(&name, &tableType))
+			types = append(types, name+":"+tableType)
+		}
+		return types
+	}
+
+	assert.Equal(t, []string{"sft_active:VIEW", "sft_users:BASE TABLE"}, queryTypes("SHOW FULL TABLES LIKE 'sft%'"))
+	assert.Equal(t, []string{"sft_active:VIEW"}, queryTypes("SHOW FULL TABLES WHERE Table_type = 'VIEW' AND Tables_in_test LIKE 'sft%'"))
+	assert.Equal(t, []string{"sft_active:VIEW"}, queryTypes("SHOW FULL TABLES LIKE 'sft\\_active'"))
+
+	_, err = db.Exec("SHOW FULL TABLES WHERE Table_type = 'VIEW') AS t; DROP VIEW sft_active; --")
+	assert.Error(t, err)
+}
+
+func TestSelectIntoUserVar(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// User variables live in the session, keep every statement on one connection
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, _ = conn.ExecContext(ctx, "DROP TABLE IF EXISTS siv_items")
+	_, err = conn.ExecContext(ctx, "CREATE TABLE siv_items (id INT PRIMARY KEY, name VARCHAR(50))")
+	require.NoError(t, err)
+	defer conn.ExecContext(ctx, "DROP TABLE IF EXISTS siv_items")
+
+	_, err = conn.ExecContext(ctx, "INSERT INTO siv_items (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c')")
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(ctx, "SELECT COUNT(*) INTO @c FROM siv_items")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT @c").Scan(&count))
+	assert.Equal(t, 3, count)
+
+	var name string
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT name FROM siv_items WHERE id = @c").Scan(&name))
+	assert.Equal(t, "c", name)
+
+	_, err = conn.ExecContext(ctx, "SELECT id INTO @id FROM siv_items")
+	assert.Error(t, err, "more than one row must be rejected")
+
+	_, err = conn.ExecContext(ctx, "SELECT id INTO OUTFILE '/tmp/siv_items.txt' FROM siv_items")
+	assert.Error(t, err, "INTO OUTFILE must be rejected")
+}
+
+func TestPreparedStatementUserVariable(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+	// User variables live in the session
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("DROP TABLE IF EXISTS prepared_user_var_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE prepared_user_var_test (id INT PRIMARY KEY, score INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS prepared_user_var_test")
+	_, err = db.Exec("INSERT INTO prepared_user_var_test VALUES (1, 50), (2, 70), (3, 90)")
+	require.NoError(t, err)
+
+	_, err = db.Exec("SET @min_score = 60")
+	require.NoError(t, err)
+
+	// Arguments make the driver prepare the statement, @min_score is not NULL there
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM prepared_user_var_test WHERE score >= @min_score AND id <= ?", 3).Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestRowConstructorIn(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS rci_pairs")
+	_, err = db.Exec("CREATE TABLE rci_pairs (id INT PRIMARY KEY, a INT, b VARCHAR(10))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS rci_pairs")
+
+	_, err = db.Exec("INSERT INTO rci_pairs (id, a, b) VALUES (1, 1, 'x'), (2, 2, 'y'), (3, 3, 'z')")
+	require.NoError(t, err)
+
+	queryIDs := func(query string, args ...interface{}) []int {
+		rows, err := db.Query(query, args...)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var ids []int
+		for rows.Next() {
+			var id int
+			require.NoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int{1, 3},
+		queryIDs("SELECT id FROM rci_pairs WHERE (a, b) IN ((1, 'x'), (3, 'z'), (3, 'x')) ORDER BY id"))
+
+	// With arguments the driver uses a server-side prepared statement
+	assert.Equal(t, []int{2, 3},
+		queryIDs("SELECT id FROM rci_pairs WHERE (a, b) IN ((?, ?), (?, ?)) ORDER BY id", 2, "y", 3, "z"))
+
+	assert.Equal(t, []int{1},
+		queryIDs("SELECT id FROM rci_pairs WHERE (a, b) NOT IN ((?, ?), (?, ?)) ORDER BY id", 2, "y", 3, "z"))
+}
+
+func TestDataTooLong(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS dtl_users")
+	_, err = db.Exec("CREATE TABLE dtl_users (id INT PRIMARY KEY, name VARCHAR(5))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS dtl_users")
+
+	_, err = db.Exec("INSERT INTO dtl_users (id, name) VALUES (1, 'abcdefgh')")
+	require.Error(t, err)
+
+	var mysqlErr *mysqldriver.MySQLError
+	require.True(t, errors.As(err, &mysqlErr), "expected a MySQL error, got %v", err)
+	assert.Equal(t, uint16(1406), mysqlErr.Number)
+	assert.Contains(t, mysqlErr.Message, "Data too long")
+}
+
+func TestErrorSQLState(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS sqlstate_users")
+	_, err = db.Exec("CREATE TABLE sqlstate_users (id INT PRIMARY KEY, email VARCHAR(50) UNIQUE)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS sqlstate_users")
+	_, err = db.Exec("INSERT INTO sqlstate_users VALUES (1, 'a@example.com')")
+	require.NoError(t, err)
+
+	var mysqlErr *mysqldriver.MySQLError
+	_, err = db.Exec("INSERT INTO sqlstate_users VALUES (2, 'a@example.com')")
+	require.True(t, errors.As(err, &mysqlErr), "expected a MySQL error, got %v", err)
+	assert.Equal(t, uint16(1062), mysqlErr.Number)
+	assert.Equal(t, "23000", string(mysqlErr.SQLState[:]))
+
+	// Prepared statements report it as well
+	_, err = db.Exec("INSERT INTO sqlstate_users VALUES (?, ?)", 3, "a@example.com")
+	require.True(t, errors.As(err, &mysqlErr), "expected a MySQL error, got %v", err)
+	assert.Equal(t, "23000", string(mysqlErr.SQLState[:]))
+
+	_, err = db.Exec("SELECT * FROM sqlstate_missing")
+	require.True(t, errors.As(err, &mysqlErr), "expected a MySQL error, got %v", err)
+	assert.Equal(t, uint16(1146), mysqlErr.Number)
+	assert.Equal(t, "42S02", string(mysqlErr.SQLState[:]))
+}
+
+func TestEnumOrderBy(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS eo_orders")
+	_, err = db.Exec("CREATE TABLE eo_orders (id INT PRIMARY KEY, status ENUM('new', 'paid', 'shipped', 'cancelled'))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS eo_orders")
+
+	_, err = db.Exec("INSERT INTO eo_orders (id, status) VALUES (1, 'shipped'), (2, 'new'), (3, 'cancelled'), (4, 'paid')")
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT status FROM eo_orders ORDER BY status")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var statuses []string
+	for rows.Next() {
+		var status string
+		require.NoError(t, rows.Scan(&status))
+		statuses = append(statuses, status)
+	}
+
+	// MySQL sorts ENUM by declaration order, not alphabetically
+	assert.Equal(t, []string{"new", "paid", "shipped", "cancelled"}, statuses)
+}
+
+func TestBigintUnsignedMax(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS bu_counters")
+	_, err = db.Exec("CREATE TABLE bu_counters (id INT PRIMARY KEY, hits BIGINT UNSIGNED)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS bu_counters")
+
+	_, err = db.Exec("INSERT INTO bu_counters (id, hits) VALUES (1, 18446744073709551615)")
+	require.NoError(t, err)
+
+	// Text protocol
+	var hits uint64
+	require.NoError(t, db.QueryRow("SELECT hits FROM bu_counters WHERE id = 1").Scan(&hits))
+	assert.Equal(t, uint64(18446744073709551615), hits)
+
+	// Binary protocol (server-side prepared statement)
+	hits = 0
+	require.NoError(t, db.QueryRow("SELECT hits FROM bu_counters WHERE id = ?", 1).Scan(&hits))
+	assert.Equal(t, uint64(18446744073709551615), hits)
+}
+
+func TestTrailingSemicolonAndWhitespace(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS tsw_items;")
+	_, err = db.Exec("\n  CREATE TABLE tsw_items (id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(20));  \n")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS tsw_items")
+
+	result, err := db.Exec("  INSERT INTO tsw_items (name) VALUES ('a') ;\n")
+	require.NoError(t, err)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	var name string
+	require.NoError(t, db.QueryRow("\t SELECT name FROM tsw_items WHERE id = 1;").Scan(&name))
+	assert.Equal(t, "a", name)
+
+	// Transaction control is matched exactly, a trailing semicolon must not change that
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("UPDATE tsw_items SET name = 'b' WHERE id = 1;")
+	require.NoError(t, err)
+	_, err = tx.Exec("ROLLBACK;")
+	require.NoError(t, err)
+	_ = tx.Rollback()
+
+	require.NoError(t, db.QueryRow("SELECT name FROM tsw_items WHERE id = 1").Scan(&name))
+	assert.Equal(t, "a", name)
+}
+
+func TestStatementClassification(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS sc_jobs")
+	_, err = db.Exec("CREATE TABLE sc_jobs (id INT PRIMARY KEY, state VARCHAR(20))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS sc_jobs")
+
+	// A leading comment must not hide the INSERT
+	result, err := db.Exec("/* app:scheduler */ INSERT INTO sc_jobs (id, state) VALUES (1, 'done'), (2, 'done'), (3, 'queued')")
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	// CTE-wrapped write without RETURNING reports affected rows
+	result, err = db.Exec("WITH queued AS (SELECT id FROM sc_jobs WHERE state = 'queued') UPDATE sc_jobs SET state = 'running' WHERE id IN (SELECT id FROM queued)")
+	require.NoError(t, err)
+	affected, err = result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	// With RETURNING the deleted rows come back as a result set
+	rows, err := db.Query("WITH finished AS (SELECT id FROM sc_jobs WHERE state = 'done') DELETE FROM sc_jobs WHERE id IN (SELECT id FROM finished) RETURNING id")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var deleted []int
+	for rows.Next() {
+		var id int
+		require.NoError(t, rows.Scan(&id))
+		deleted = append(deleted, id)
+	}
+	assert.ElementsMatch(t, []int{1, 2}, deleted)
+
+	var remaining int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sc_jobs").Scan(&remaining))
+	assert.Equal(t, 1, remaining)
+}
+
+func TestInsertReturning(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS ir_users")
+	_, err = db.Exec("CREATE TABLE ir_users (id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(50))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS ir_users")
+
+	var id int
+	var name string
+
+	// Text protocol
+	require.NoError(t, db.QueryRow("INSERT INTO ir_users (name) VALUES ('alice') RETURNING id, name").Scan(&id, &name))
+	assert.Equal(t, 1, id)
+	assert.Equal(t, "alice", name)
+
+	// Binary protocol (server-side prepared statement)
+	require.NoError(t, db.QueryRow("INSERT INTO ir_users (name) VALUES (?) RETURNING id, name", "bob").Scan(&id, &name))
+	assert.Equal(t, 2, id)
+	assert.Equal(t, "bob", name)
+
+	// Without RETURNING the AUTO_INCREMENT id still comes back as the last insert id
+	result, err := db.Exec("INSERT INTO ir_users (name) VALUES ('returning carol')")
+	require.NoError(t, err)
+	lastID, err := result.LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), lastID)
+}
+
+// TestServerVersion checks that VERSION() reports the version advertised in the handshake
+func TestServerVersion(t *testing.T) {
+	conn, err := net.Dial("tcp", "localhost:3306")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Handshake V10: 4 byte header, protocol version, NUL-terminated server version
+	header := make([]byte, 4)
+	_, err = io.ReadFull(conn, header)
+	require.NoError(t, err)
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	_, err = io.ReadFull(conn, payload)
+	require.NoError(t, err)
+	require.Equal(t, byte(10), payload[0])
+	handshakeVersion := string(payload[1 : 1+strings.IndexByte(string(payload[1:]), 0)])
+
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var version string
+	require.NoError(t, db.QueryRow("SELECT VERSION()").Scan(&version))
+	assert.Equal(t, handshakeVersion, version)
+	assert.NotContains(t, version, "PostgreSQL")
+}
+
+// TestLoadDataLocalInfile streams a client-side CSV into the table through COPY
+func TestLoadDataLocalInfile(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS ld_users")
+	_, err = db.Exec("CREATE TABLE ld_users (id INT PRIMARY KEY, name VARCHAR(50), note VARCHAR(50))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS ld_users")
+
+	csv := "id,name,note\n1,alice,\"likes, commas\"\n2,bob,\\N\n3,\"carol \"\"c\"\"\",plain\n"
+	mysqldriver.RegisterReaderHandler("ld_users", func() io.Reader { return strings.NewReader(csv) })
+	defer mysqldriver.DeregisterReaderHandler("ld_users")
+
+	result, err := db.Exec(`LOAD DATA LOCAL INFILE 'Reader::ld_users' INTO TABLE ld_users
+		FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '"'
+		IGNORE 1 LINES (id, name, note)`)
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM ld_users").Scan(&count))
+	assert.Equal(t, 3, count)
+
+	var name string
+	var note sql.NullString
+	require.NoError(t, db.QueryRow("SELECT name, note FROM ld_users WHERE id = 3").Scan(&name, &note))
+	assert.Equal(t, `carol "c"`, name)
+	require.NoError(t, db.QueryRow("SELECT note FROM ld_users WHERE id = 2").Scan(&note))
+	assert.False(t, note.Valid)
+
+	// Files on the proxy host are never read
+	_, err = db.Exec("LOAD DATA INFILE '/etc/passwd' INTO TABLE ld_users")
+	var myErr *mysqldriver.MySQLError
+	require.True(t, errors.As(err, &myErr))
+	assert.Equal(t, uint16(1148), myErr.Number)
+}
+
+// TestInformationSchemaColumns introspects a table through the MySQL-shaped information_schema
+func TestInformationSchemaColumns(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS isc_users")
+	_, err = db.Exec("CREATE TABLE isc_users (id INT AUTO_INCREMENT PRIMARY KEY, email VARCHAR(100) NOT NULL UNIQUE, balance DECIMAL(10,2))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS isc_users")
+
+	rows, err := db.Query(`SELECT column_name, data_type, column_type, is_nullable, column_key, extra
+		FROM information_schema.COLUMNS WHERE table_name = 'isc_users' ORDER BY ordinal_position`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"COLUMN_NAME", "DATA_TYPE", "COLUMN_TYPE", "IS_NULLABLE", "COLUMN_KEY", "EXTRA"}, columns)
+
+	var got [][]string
+	for rows.Next() {
+		var name, dataType, columnType, nullable, key, extra string
+		require.NoError(t, rows.Scan(&name, &dataType, &columnType, &nullable, &key, &extra))
+		got = append(got, []string{name, dataType, columnType, nullable, key, extra})
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][]string{
+		{"id", "int", "int", "NO", "PRI", "auto_increment"},
+		{"email", "varchar", "varchar(100)", "NO", "UNI", ""},
+		{"balance", "decimal", "decimal(10,2)", "YES", "", ""},
+	}, got)
+
+	var indexName string
+	require.NoError(t, db.QueryRow(`SELECT index_name FROM information_schema.STATISTICS
+		WHERE table_name = 'isc_users' AND column_name = 'id'`).Scan(&indexName))
+	assert.Equal(t, "PRIMARY", indexName)
+
+	var engine string
+	require.NoError(t, db.QueryRow("SELECT engine FROM information_schema.TABLES WHERE table_name = 'isc_users'").Scan(&engine))
+	assert.Equal(t, "InnoDB", engine)
+}
+
+// TestOrdinalGroupByOrderBy groups and sorts by select-list positions around rewritten functions
+func TestOrdinalGroupByOrderBy(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS ogb_orders")
+	_, err = db.Exec("CREATE TABLE ogb_orders (id INT PRIMARY KEY, amount INT, status VARCHAR(10))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS ogb_orders")
+
+	_, err = db.Exec(`INSERT INTO ogb_orders (id, amount, status) VALUES
+		(1, 50, 'new'), (2, 150, 'new'), (3, 200, 'paid'), (4, 20, 'paid'), (5, 300, 'paid')`)
+	require.NoError(t, err)
+
+	rows, err := db.Query(`SELECT IF(amount > 100, 'large', 'small'), status, COUNT(*)
+		FROM ogb_orders GROUP BY 1, 2 ORDER BY 3 DESC, 1, 2`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var size, status string
+		var count int
+		require.NoError(t, rows.Scan(&size, &status, &count))
+		got = append(got, fmt.Sprintf("%s/%s/%d", size, status, count))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"large/paid/2", "large/new/1", "small/new/1", "small/paid/1"}, got)
+}
+
+// TestCreateTableSelect creates a table from a SELECT and reads the copied rows back
+func TestCreateTableSelect(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS ctas_orders")
+	_, _ = db.Exec("DROP TABLE IF EXISTS ctas_totals")
+	_, err = db.Exec("CREATE TABLE ctas_orders (id INT PRIMARY KEY, customer VARCHAR(20), amount INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS ctas_orders")
+
+	_, err = db.Exec(`INSERT INTO ctas_orders (id, customer, amount) VALUES
+		(1, 'alice', 10), (2, 'bob', 20), (3, 'alice', 30)`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`CREATE TABLE ctas_totals AS
+		SELECT customer, COUNT(*), SUM(amount) + 0 FROM ctas_orders GROUP BY customer`)
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS ctas_totals")
+
+	rows, err := db.Query("SELECT * FROM ctas_totals ORDER BY customer")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customer", "COUNT(*)", "SUM(amount) + 0"}, columns)
+
+	var got []string
+	for rows.Next() {
+		var customer string
+		var count, total int
+		require.NoError(t, rows.Scan(&customer, &count, &total))
+		got = append(got, fmt.Sprintf("%s/%d/%d", customer, count, total))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"alice/2/40", "bob/1/20"}, got)
+
+	// IGNORE runs as a plain CREATE TABLE AS
+	_, err = db.Exec("DROP TABLE ctas_totals")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE ctas_totals IGNORE SELECT id FROM ctas_orders WHERE amount > 15")
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM ctas_totals").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+// TestNullSafeEqual tests the <=> operator where one or both sides are NULL
+func TestNullSafeEqual(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS nse_left")
+	_, _ = db.Exec("DROP TABLE IF EXISTS nse_right")
+	_, err = db.Exec("CREATE TABLE nse_left (id INT PRIMARY KEY, code VARCHAR(10))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS nse_left")
+	_, err = db.Exec("CREATE TABLE nse_right (id INT PRIMARY KEY, code VARCHAR(10))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS nse_right")
+
+	_, err = db.Exec("INSERT INTO nse_left (id, code) VALUES (1, 'a'), (2, NULL), (3, 'c')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO nse_right (id, code) VALUES (10, 'a'), (20, NULL), (30, 'x')")
+	require.NoError(t, err)
+
+	ids := func(query string, args ...interface{}) []int {
+		rows, err := db.Query(query, args...)
+		require.NoError(t, err)
+		defer rows.Close()
+		var result []int
+		for rows.Next() {
+			var id int
+			require.NoError(t, rows.Scan(&id))
+			result = append(result, id)
+		}
+		require.NoError(t, rows.Err())
+		return result
+	}
+
+	// NULL <=> NULL is true, where NULL = NULL matches nothing
+	assert.Equal(t, []int{2}, ids("SELECT id FROM nse_left WHERE code <=> NULL"))
+	assert.Empty(t, ids("SELECT id FROM nse_left WHERE code = NULL"))
+	assert.Equal(t, []int{1, 3}, ids("SELECT id FROM nse_left WHERE NOT (code <=> NULL) ORDER BY id"))
+	assert.Equal(t, []int{2, 3}, ids("SELECT id FROM nse_left WHERE NOT (code <=> ?) ORDER BY id", "a"))
+
+	// Joining on <=> pairs the NULL codes
+	assert.Equal(t, []int{10, 20}, ids(`SELECT r.id FROM nse_left l JOIN nse_right r ON l.code <=> r.code ORDER BY r.id`))
+}
+
+// TestRegexpFunctions tests REGEXP_REPLACE, REGEXP_SUBSTR and REGEXP_INSTR with match types
+func TestRegexpFunctions(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var replaced, substr string
+	var instr, like int
+	err = db.QueryRow(`SELECT
+		REGEXP_REPLACE('John Smith', '(\\w+) (\\w+)', '$2, $1'),
+		REGEXP_SUBSTR('a1 B22 c333', '[a-z][0-9]+', 1, 2, 'i'),
+		REGEXP_INSTR('xxABCabc', 'abc', 1, 1, 0, 'c'),
+		REGEXP_LIKE('Hello', '^hello$')`).Scan(&replaced, &substr, &instr, &like)
+	require.NoError(t, err)
+	assert.Equal(t, "Smith, John", replaced)
+	assert.Equal(t, "B22", substr)
+	assert.Equal(t, 6, instr)
+	assert.Equal(t, 1, like)
+
+	// Case-sensitive match type and a start position
+	err = db.QueryRow(`SELECT
+		REGEXP_REPLACE('aAaA', 'a', '-', 2, 0, 'c'),
+		REGEXP_INSTR('abcabc', 'A', 2, 1, 0, 'i')`).Scan(&replaced, &instr)
+	require.NoError(t, err)
+	assert.Equal(t, "aA-A", replaced)
+	assert.Equal(t, 4, instr)
+
+	var missing sql.NullString
+	require.NoError(t, db.QueryRow("SELECT REGEXP_SUBSTR('abc', '[0-9]+')").Scan(&missing))
+	assert.False(t, missing.Valid)
+}
+
+func TestShowScopedVariablesAndStatus(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var name, value string
+	for _, query := range []string{
+		"SHOW GLOBAL VARIABLES LIKE 'max_connections'",
+		"SHOW SESSION VARIABLES LIKE 'max_connections'",
+	} {
+		require.NoError(t, db.QueryRow(query).Scan(&name, &value), query)
+		assert.Equal(t, "max_connections", name)
+		assert.NotEmpty(t, value)
+	}
+
+	for _, query := range []string{
+		"SHOW GLOBAL STATUS LIKE 'Threads_connected'",
+		"SHOW SESSION STATUS LIKE 'threads_connected'",
+	} {
+		require.NoError(t, db.QueryRow(query).Scan(&name, &value), query)
+		assert.Equal(t, "Threads_connected", name)
+		assert.NotEqual(t, "0", value)
+	}
+}
+
+func TestDateStringComparisons(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS date_compare_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE date_compare_test (id INT PRIMARY KEY, day DATE, created_at DATETIME)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS date_compare_test")
+
+	_, err = db.Exec(`INSERT INTO date_compare_test VALUES
+		(1, '2024-01-01', '2024-01-01 08:00:00'),
+		(2, '2024-01-15', '2024-01-15 12:30:00'),
+		(3, '2024-02-01', '2024-02-01 00:00:00')`)
+	require.NoError(t, err)
+
+	var id int
+	require.NoError(t, db.QueryRow("SELECT id FROM date_compare_test WHERE day = '2024-01-15'").Scan(&id))
+	assert.Equal(t, 2, id)
+
+	require.NoError(t, db.QueryRow("SELECT id FROM date_compare_test WHERE day = CONCAT('2024-02', '-01')").Scan(&id))
+	assert.Equal(t, 3, id)
+
+	var count int
+	require.NoError(t, db.QueryRow(
+		"SELECT COUNT(*) FROM date_compare_test WHERE created_at BETWEEN '2024-01-01' AND '2024-01-31 23:59:59'").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestCharsetIntroducers(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS charset_introducer_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE charset_introducer_test (id INT PRIMARY KEY, `_utf8col` VARCHAR(20))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS charset_introducer_test")
+
+	_, err = db.Exec("INSERT INTO charset_introducer_test (id, `_utf8col`) VALUES (1, N'national'), (2, _utf8mb4'plain')")
+	require.NoError(t, err)
+
+	var value string
+	require.NoError(t, db.QueryRow("SELECT `_utf8col` FROM charset_introducer_test WHERE `_utf8col` = N'national'").Scan(&value))
+	assert.Equal(t, "national", value)
+
+	require.NoError(t, db.QueryRow("SELECT CONCAT(_latin1'a', N'b', `_utf8col`) FROM charset_introducer_test WHERE id = 2").Scan(&value))
+	assert.Equal(t, "abplain", value)
+}
+
+func TestMultiRowAutoIncrementInsert(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS multi_row_auto_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE multi_row_auto_test (id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(20))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS multi_row_auto_test")
+
+	result, err := db.Exec("INSERT INTO multi_row_auto_test VALUES (100, 'x'), (NULL, 'a'), (DEFAULT, 'b'), (500, 'c')")
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), affected)
+	lastID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT id FROM multi_row_auto_test ORDER BY name")
+	require.NoError(t, err)
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	require.Len(t, ids, 4)
+
+	// a and b are generated, c and x keep their explicit ids
+	assert.Equal(t, lastID, ids[0])
+	assert.Equal(t, ids[0]+1, ids[1])
+	assert.Equal(t, int64(500), ids[2])
+	assert.Equal(t, int64(100), ids[3])
+}
+
+func TestShowIndexStatistics(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS show_index_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE show_index_test (id INT PRIMARY KEY, a INT, b INT, c INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS show_index_test")
+	_, err = db.Exec("CREATE INDEX idx_show_index_cab ON show_index_test (c, a, b)")
+	require.NoError(t, err)
+
+	rows, err := db.Query("SHOW INDEX FROM show_index_test")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type indexColumn struct {
+		nonUnique   int
+		key         string
+		seq         int
+		column      string
+		cardinality sql.NullInt64
+	}
+	var got []indexColumn
+	for rows.Next() {
+		var c indexColumn
+		var table, collation, null, indexType, comment string
+		var subPart, packed sql.NullString
+		require.NoError(t, rows.Scan(&table, &c.nonUnique, &c.key, &c.seq, &c.column, &collation,
+			&c.cardinality, &subPart, &packed, &null, &indexType, &comment))
+		got = append(got, c)
+	}
+	require.NoError(t, rows.Err())
+	require.Len(t, got, 4)
+
+	assert.Equal(t, "PRIMARY", got[0].key)
+	assert.Equal(t, 0, got[0].nonUnique)
+	for i, column := range []string{"c", "a", "b"} {
+		assert.Equal(t, "idx_show_index_cab", got[i+1].key)
+		assert.Equal(t, 1, got[i+1].nonUnique)
+		assert.Equal(t, i+1, got[i+1].seq)
+		assert.Equal(t, column, got[i+1].column)
+	}
+	for _, c := range got {
+		assert.True(t, c.cardinality.Valid, "cardinality of %s.%s", c.key, c.column)
+	}
+}
+
+func TestShowIndexSpellings(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS show_keys_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE show_keys_test (id INT PRIMARY KEY, name VARCHAR(20))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS show_keys_test")
+
+	var schema string
+	require.NoError(t, db.QueryRow("SELECT DATABASE()").Scan(&schema))
+
+	for _, query := range []string{
+		"SHOW INDEX FROM show_keys_test",
+		"SHOW INDEXES FROM show_keys_test",
+		"SHOW KEYS FROM show_keys_test",
+		"SHOW INDEX IN show_keys_test FROM " + schema,
+	} {
+		t.Run(query, func(t *testing.T) {
+			rows, err := db.Query(query)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			columns, err := rows.Columns()
+			require.NoError(t, err)
+			require.Contains(t, columns, "Key_name")
+
+			count := 0
+			for rows.Next() {
+				count++
+			}
+			require.NoError(t, rows.Err())
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestPreparedBlobRoundTrip(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS blob_binary_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE blob_binary_test (id INT PRIMARY KEY, data BLOB)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS blob_binary_test")
+
+	blob := make([]byte, 256)
+	for i := range blob {
+		blob[i] = byte(i)
+	}
+	_, err = db.Exec("INSERT INTO blob_binary_test (id, data) VALUES (?, ?), (?, ?)", 1, nil, 2, blob)
+	require.NoError(t, err)
+
+	// Query arguments make the driver use a prepared statement and the binary protocol
+	stmt, err := db.Prepare("SELECT data FROM blob_binary_test WHERE id >= ? ORDER BY id")
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	rows, err := stmt.Query(1)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var got [][]byte
+	for rows.Next() {
+		var data []byte
+		require.NoError(t, rows.Scan(&data))
+		got = append(got, data)
+	}
+	require.NoError(t, rows.Err())
+	require.Len(t, got, 2)
+	assert.Nil(t, got[0])
+	assert.Equal(t, blob, got[1])
+}
+
+func TestSelectSystemVariables(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(context.Background(), "SET time_zone = '+00:00'")
+	require.NoError(t, err)
+
+	rows, err := conn.QueryContext(context.Background(), "SELECT @@version, @@autocommit, @@session.time_zone AS tz")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@@version", "@@autocommit", "tz"}, columns)
+
+	require.True(t, rows.Next())
+	var version, timeZone string
+	var autocommit int
+	require.NoError(t, rows.Scan(&version, &autocommit, &timeZone))
+	assert.NotEmpty(t, version)
+	assert.Equal(t, 1, autocommit)
+	assert.Equal(t, "+00:00", timeZone)
+}
+
+func TestOnUpdateCurrentTimestamp(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test?parseTime=true")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS on_update_test")
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE on_update_test (
+		id INT PRIMARY KEY,
+		name VARCHAR(20),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP(),
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS on_update_test")
+
+	_, err = db.Exec("INSERT INTO on_update_test (id, name) VALUES (1, 'a')")
+	require.NoError(t, err)
+
+	var created, updated time.Time
+	require.NoError(t, db.QueryRow("SELECT created_at, updated_at FROM on_update_test WHERE id = 1").Scan(&created, &updated))
+	assert.False(t, created.IsZero(), "insert fills the DEFAULT")
+	assert.False(t, updated.IsZero(), "insert fills the DEFAULT")
+
+	time.Sleep(1100 * time.Millisecond)
+	_, err = db.Exec("UPDATE on_update_test SET name = 'b' WHERE id = 1")
+	require.NoError(t, err)
+
+	var touched, createdAfter time.Time
+	require.NoError(t, db.QueryRow("SELECT created_at, updated_at FROM on_update_test WHERE id = 1").Scan(&createdAfter, &touched))
+	assert.True(t, touched.After(updated), "update touches updated_at")
+	assert.Equal(t, created, createdAfter, "columns without ON UPDATE keep their value")
+
+	// An explicit value wins over the touch
+	_, err = db.Exec("UPDATE on_update_test SET name = 'c', updated_at = '2020-01-01 00:00:00' WHERE id = 1")
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRow("SELECT updated_at FROM on_update_test WHERE id = 1").Scan(&touched))
+	assert.Equal(t, 2020, touched.Year())
+
+	// Running the CREATE again replaces the trigger
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS on_update_test (
+		id INT PRIMARY KEY,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err)
+
+	// DROP TABLE drops the trigger function as well
+	_, err = db.Exec("DROP TABLE on_update_test")
+	require.NoError(t, err)
+	var functions int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM pg_proc WHERE proname = 'on_update_test_on_update'").Scan(&functions))
+	assert.Equal(t, 0, functions)
+}
+
+func TestShowCreateDatabase(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var name, create string
+	require.NoError(t, db.QueryRow("SHOW CREATE DATABASE public").Scan(&name, &create))
+	assert.Equal(t, "public", name)
+	assert.Equal(t, "CREATE DATABASE `public` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci */", create)
+
+	err = db.QueryRow("SHOW CREATE DATABASE no_such_database").Scan(&name, &create)
+	var myErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &myErr)
+	assert.Equal(t, uint16(1049), myErr.Number)
+}
+
+func TestLogicalOperators(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS logical_ops_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE logical_ops_test (id INT PRIMARY KEY, name VARCHAR(20), age INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS logical_ops_test")
+
+	_, err = db.Exec("INSERT INTO logical_ops_test VALUES (1, 'a', 10), (2, 'b', 20), (3, 'c', 30)")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM logical_ops_test WHERE age > 15 && name != 'c' || id <> id").Scan(&count))
+	assert.Equal(t, 1, count)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM logical_ops_test WHERE id = 1 || (age >= 20 && !(name = 'b'))").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// PIPES_AS_CONCAT turns || into string concatenation for the session
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(context.Background(), "SET sql_mode = 'PIPES_AS_CONCAT'")
+	require.NoError(t, err)
+
+	var joined string
+	require.NoError(t, conn.QueryRowContext(context.Background(), "SELECT name || '-' || id FROM logical_ops_test WHERE id = 2").Scan(&joined))
+	assert.Equal(t, "b-2", joined)
+}
+
+func TestGroupByWithRollup(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS rollup_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE rollup_test (region VARCHAR(10), category VARCHAR(10), amount INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS rollup_test")
+
+	_, err = db.Exec(`INSERT INTO rollup_test VALUES
+		('east', 'a', 10), ('east', 'b', 20), ('west', 'a', 30), ('west', 'a', 40)`)
+	require.NoError(t, err)
+
+	rows, err := db.Query(`SELECT region, category, SUM(amount) FROM rollup_test
+		GROUP BY region, category WITH ROLLUP ORDER BY region, category`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var region, category sql.NullString
+		var total int
+		require.NoError(t, rows.Scan(&region, &category, &total))
+		got = append(got, fmt.Sprintf("%s/%s=%d", region.String, category.String, total))
+	}
+	require.NoError(t, rows.Err())
+
+	// Subtotal rows have NULL in the rolled up columns, the grand total in all of them
+	assert.Equal(t, []string{
+		"east/a=10", "east/b=20", "east/=30",
+		"west/a=70", "west/=70",
+		"/=100",
+	}, got)
+}
+
+func TestCaseInsensitiveSearch(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS ci_search_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE ci_search_test (id INT PRIMARY KEY, title VARCHAR(50))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS ci_search_test")
+
+	_, err = db.Exec("INSERT INTO ci_search_test VALUES (1, 'Hello World'), (2, 'hello mysql'), (3, 'Goodbye')")
+	require.NoError(t, err)
+
+	var locate, position, instr int
+	require.NoError(t, db.QueryRow("SELECT LOCATE('WORLD', title), POSITION('world' IN title), INSTR(title, 'wOrLd') FROM ci_search_test WHERE id = 1").
+		Scan(&locate, &position, &instr))
+	assert.Equal(t, []int{7, 7, 7}, []int{locate, position, instr})
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM ci_search_test WHERE title LIKE 'HELLO%'").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// A case-sensitive collation keeps the search exact
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM ci_search_test WHERE title COLLATE utf8mb4_bin LIKE 'Hello%'").Scan(&count))
+	assert.Equal(t, 1, count)
+	require.NoError(t, db.QueryRow("SELECT LOCATE(BINARY 'WORLD', title) FROM ci_search_test WHERE id = 1").Scan(&locate))
+	assert.Equal(t, 0, locate)
+}
+
+func TestLikeFollowsCollation(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS like_collation_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE like_collation_test (id INT PRIMARY KEY, name VARCHAR(20), code VARCHAR(20) COLLATE utf8mb4_bin)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS like_collation_test")
+
+	_, err = db.Exec("INSERT INTO like_collation_test VALUES (1, 'Alice', 'AB-1'), (2, 'alfred', 'ab-2'), (3, 'ALBERT', 'Ab_3'), (4, 'Bob', 'x')")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM like_collation_test WHERE name LIKE 'al%'").Scan(&count))
+	assert.Equal(t, 3, count, "_ci matches every case")
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM like_collation_test WHERE code LIKE 'AB%'").Scan(&count))
+	assert.Equal(t, 1, count, "a _bin column stays case-sensitive")
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM like_collation_test WHERE code LIKE 'ab|_%' COLLATE utf8mb4_general_ci ESCAPE '|'").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// SET NAMES binary makes comparisons of columns without a collation case-sensitive
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(context.Background(), "SET NAMES binary")
+	require.NoError(t, err)
+	require.NoError(t, conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM like_collation_test WHERE name LIKE 'al%'").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestOnDuplicateKeyUpdateMixedAssignments(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS odku_mixed_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE odku_mixed_test (id INT PRIMARY KEY, count INT, note VARCHAR(20), updated_at DATETIME)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS odku_mixed_test")
+
+	upsert := "INSERT INTO odku_mixed_test (id, count, note) VALUES (?, ?, 'new') " +
+		"ON DUPLICATE KEY UPDATE count = count + VALUES(count), note = 'seen', updated_at = NOW()"
+	_, err = db.Exec(upsert, 1, 10)
+	require.NoError(t, err)
+	_, err = db.Exec(upsert, 1, 5)
+	require.NoError(t, err)
+
+	var count int
+	var note string
+	var updatedAt sql.NullString
+	require.NoError(t, db.QueryRow("SELECT count, note, updated_at FROM odku_mixed_test WHERE id = 1").Scan(&count, &note, &updatedAt))
+	assert.Equal(t, 15, count)
+	assert.Equal(t, "seen", note)
+	assert.True(t, updatedAt.Valid)
+}
+
+func TestDivisionByZero(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS division_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE division_test (id INT PRIMARY KEY, a INT, b INT, q INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS division_test")
+
+	_, err = db.Exec("INSERT INTO division_test (id, a, b) VALUES (1, 7, 0), (2, 7, 2)")
+	require.NoError(t, err)
+
+	// Queries yield NULL in every sql_mode
+	var quotient, intQuotient, remainder, mod sql.NullInt64
+	require.NoError(t, db.QueryRow("SELECT a / b, a DIV b, a % b, MOD(a, b) FROM division_test WHERE id = 1").
+		Scan(&quotient, &intQuotient, &remainder, &mod))
+	assert.False(t, quotient.Valid || intQuotient.Valid || remainder.Valid || mod.Valid)
+	require.NoError(t, db.QueryRow("SELECT a DIV b, a % b FROM division_test WHERE id = 2").Scan(&intQuotient, &remainder))
+	assert.Equal(t, int64(3), intQuotient.Int64)
+	assert.Equal(t, int64(1), remainder.Int64)
+
+	// The default strict sql_mode fails data changes
+	_, err = db.Exec("UPDATE division_test SET q = a DIV b WHERE id = 1")
+	assert.Error(t, err)
+
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(context.Background(), "SET sql_mode = ''")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(context.Background(), "UPDATE division_test SET q = a DIV b WHERE id = 1")
+	require.NoError(t, err)
+	var q sql.NullInt64
+	require.NoError(t, conn.QueryRowContext(context.Background(), "SELECT q FROM division_test WHERE id = 1").Scan(&q))
+	assert.False(t, q.Valid)
+}
+
+func TestBinaryComparison(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS binary_cmp_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE binary_cmp_test (id INT PRIMARY KEY, name VARCHAR(20))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS binary_cmp_test")
+
+	_, err = db.Exec("INSERT INTO binary_cmp_test VALUES (1, 'Alice'), (2, 'alice'), (3, 'ALICE')")
+	require.NoError(t, err)
+
+	var id int
+	require.NoError(t, db.QueryRow("SELECT id FROM binary_cmp_test WHERE BINARY name = 'Alice'").Scan(&id))
+	assert.Equal(t, 1, id)
+	require.NoError(t, db.QueryRow("SELECT id FROM binary_cmp_test WHERE CAST(name AS BINARY) = ?", "alice").Scan(&id))
+	assert.Equal(t, 2, id)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM binary_cmp_test WHERE name LIKE BINARY 'A%'").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// Bytes sort upper case first
+	rows, err := db.Query("SELECT id FROM binary_cmp_test ORDER BY BINARY name")
+	require.NoError(t, err)
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []int{3, 1, 2}, ids)
+}
+
+func TestZeroDates(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS zero_date_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE zero_date_test (id INT PRIMARY KEY, d DATE, ts DATETIME)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS zero_date_test")
+
+	// The default policy stores zero dates as NULL
+	_, err = db.Exec("INSERT INTO zero_date_test VALUES (1, '0000-00-00', '0000-00-00 00:00:00')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO zero_date_test VALUES (2, '2024-01-02', ?)", "2024-01-02 03:04:05")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE zero_date_test SET ts = '0000-00-00 00:00:00' WHERE id = 2")
+	require.NoError(t, err)
+
+	var d, ts sql.NullString
+	require.NoError(t, db.QueryRow("SELECT d, ts FROM zero_date_test WHERE id = 1").Scan(&d, &ts))
+	assert.False(t, d.Valid)
+	assert.False(t, ts.Valid)
+	require.NoError(t, db.QueryRow("SELECT d, ts FROM zero_date_test WHERE id = 2").Scan(&d, &ts))
+	assert.True(t, d.Valid)
+	assert.False(t, ts.Valid)
+}
+
+func TestNumericStringComparison(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS numeric_cmp_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE numeric_cmp_test (id INT PRIMARY KEY, qty INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS numeric_cmp_test")
+
+	_, err = db.Exec("INSERT INTO numeric_cmp_test VALUES (1, 5), (2, 10)")
+	require.NoError(t, err)
+
+	var id int
+	require.NoError(t, db.QueryRow("SELECT id FROM numeric_cmp_test WHERE qty = '5'").Scan(&id))
+	assert.Equal(t, 1, id)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM numeric_cmp_test WHERE qty > '7.5'").Scan(&count))
+	assert.Equal(t, 1, count)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM numeric_cmp_test WHERE qty = 'abc'").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestCollationNegotiation(t *testing.T) {
+	// The driver sends SET NAMES utf8mb4 COLLATE utf8mb4_0900_ai_ci right after connecting
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test?charset=utf8mb4&collation=utf8mb4_0900_ai_ci")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	rows, err := db.Query("SHOW COLLATION")
+	require.NoError(t, err)
+	ids := map[string]int{}
+	for rows.Next() {
+		var name, charset, isDefault, compiled, padAttribute string
+		var id, sortlen int
+		require.NoError(t, rows.Scan(&name, &charset, &id, &isDefault, &compiled, &sortlen, &padAttribute))
+		ids[name] = id
+	}
+	require.NoError(t, rows.Err())
+	rows.Close()
+	assert.Equal(t, 45, ids["utf8mb4_general_ci"])
+	assert.Equal(t, 224, ids["utf8mb4_unicode_ci"])
+	assert.Equal(t, 255, ids["utf8mb4_0900_ai_ci"])
+	assert.Equal(t, 8, ids["latin1_swedish_ci"])
+
+	var collation string
+	require.NoError(t, db.QueryRow("SELECT @@collation_connection").Scan(&collation))
+	assert.Equal(t, "utf8mb4_0900_ai_ci", collation)
+
+	_, err = db.Exec("SET NAMES latin1 COLLATE latin1_swedish_ci")
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRow("SELECT @@collation_connection").Scan(&collation))
+	assert.Equal(t, "latin1_swedish_ci", collation)
+	_, err = db.Exec("SET NAMES utf8mb4")
+	require.NoError(t, err)
+}
+
+func TestForUpdateNowait(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS nowait_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE nowait_test (id INT PRIMARY KEY, balance INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS nowait_test")
+	_, err = db.Exec("INSERT INTO nowait_test VALUES (1, 100), (2, 200)")
+	require.NoError(t, err)
+
+	holder, err := db.Begin()
+	require.NoError(t, err)
+	defer holder.Rollback()
+	var balance int
+	require.NoError(t, holder.QueryRow("SELECT balance FROM nowait_test WHERE id = 1 FOR UPDATE").Scan(&balance))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.QueryRow("SELECT balance FROM nowait_test WHERE id = 1 FOR UPDATE NOWAIT").Scan(&balance)
+	var myErr *mysqldriver.MySQLError
+	require.True(t, errors.As(err, &myErr), "expected a MySQL error, got %v", err)
+	assert.Equal(t, uint16(3572), myErr.Number)
+	require.NoError(t, tx.Rollback())
+
+	// Other rows and SKIP LOCKED are not blocked
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.NoError(t, tx.QueryRow("SELECT balance FROM nowait_test t WHERE id = 2 FOR SHARE OF t NOWAIT").Scan(&balance))
+	assert.Equal(t, 200, balance)
+	var id int
+	require.NoError(t, tx.QueryRow("SELECT id FROM nowait_test ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED").Scan(&id))
+	assert.Equal(t, 2, id)
+}
+
+func TestUpdateOrderByLimit(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS update_top_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE update_top_test (id INT PRIMARY KEY, score INT, picked TINYINT DEFAULT 0)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS update_top_test")
+	_, err = db.Exec("INSERT INTO update_top_test (id, score) VALUES (1, 50), (2, 90), (3, 10), (4, 70), (5, 30)")
+	require.NoError(t, err)
+
+	// Pick the three highest scores
+	result, err := db.Exec("UPDATE update_top_test SET picked = 1 WHERE picked = 0 ORDER BY score DESC LIMIT ?", 3)
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	rows, err := db.Query("SELECT id FROM update_top_test WHERE picked = 1 ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []int{1, 2, 4}, ids)
+}
+
+func TestShowWarningsFromNotices(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1) // SHOW WARNINGS reads the diagnostics of the same connection
+
+	_, err = db.Exec("DROP TABLE IF EXISTS no_such_table_for_warnings")
+	require.NoError(t, err)
+
+	rows, err := db.Query("SHOW WARNINGS")
+	require.NoError(t, err)
+	defer rows.Close()
+	require.True(t, rows.Next(), "expected the notice of DROP TABLE IF EXISTS")
+	var level, message string
+	var code int
+	require.NoError(t, rows.Scan(&level, &code, &message))
+	assert.Equal(t, "Note", level)
+	assert.Contains(t, message, "does not exist")
+	require.NoError(t, rows.Close())
+
+	// The next statement clears them
+	var one int
+	require.NoError(t, db.QueryRow("SELECT 1").Scan(&one))
+	var count int
+	require.NoError(t, db.QueryRow("SHOW COUNT(*) WARNINGS").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestColumnCollationOrderBy(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS collation_order_test")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS collation_order_test")
+
+	_, err = db.Exec(`CREATE TABLE collation_order_test (
+		id INT PRIMARY KEY,
+		code VARCHAR(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+		name VARCHAR(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci
+	) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO collation_order_test (id, code, name) VALUES (1, 'b', 'b'), (2, 'B', 'B'), (3, 'a', 'a'), (4, 'A', 'A')")
+	require.NoError(t, err)
+
+	// utf8mb4_bin sorts by code point: upper case before lower case
+	rows, err := db.Query("SELECT code FROM collation_order_test ORDER BY code")
+	require.NoError(t, err)
+	defer rows.Close()
+	var codes []string
+	for rows.Next() {
+		var code string
+		require.NoError(t, rows.Scan(&code))
+		codes = append(codes, code)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"A", "B", "a", "b"}, codes)
+
+	// The _ci column still compares case-insensitively
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM collation_order_test WHERE name LIKE 'a%'").Scan(&count))
+	assert.Equal(t, 2, count)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM collation_order_test WHERE code LIKE 'a%'").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestCountDistinctMultipleColumns(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS count_distinct_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE count_distinct_test (id INT PRIMARY KEY, a INT, b VARCHAR(10))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS count_distinct_test")
+	_, err = db.Exec(`INSERT INTO count_distinct_test VALUES
+		(1, 1, 'x'), (2, 1, 'x'), (3, 1, 'y'), (4, 2, 'x'), (5, NULL, 'x'), (6, 2, NULL)`)
+	require.NoError(t, err)
+
+	// (1,x), (1,y), (2,x); rows with a NULL in either column are not counted
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(DISTINCT a, b) FROM count_distinct_test").Scan(&count))
+	assert.Equal(t, 3, count)
+
+	var pairs string
+	require.NoError(t, db.QueryRow(
+		"SELECT GROUP_CONCAT(DISTINCT a, ':', b) FROM count_distinct_test WHERE id < 5").Scan(&pairs))
+	assert.ElementsMatch(t, []string{"1:x", "1:y", "2:x"}, strings.Split(pairs, ","))
+}
+
+func TestStringLiteralEscapes(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS string_escape_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE string_escape_test (id INT PRIMARY KEY, s VARCHAR(50), b BLOB)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS string_escape_test")
+
+	_, err = db.Exec(`INSERT INTO string_escape_test VALUES
+		(1, 'first\nsecond', NULL), (2, 'it\'s', NULL), (3, 'tab\there\\', 'a\0b'), (4, '100%', NULL)`)
+	require.NoError(t, err)
+
+	var s string
+	require.NoError(t, db.QueryRow("SELECT s FROM string_escape_test WHERE id = 1").Scan(&s))
+	assert.Equal(t, "first\nsecond", s)
+	require.NoError(t, db.QueryRow("SELECT s FROM string_escape_test WHERE id = 2").Scan(&s))
+	assert.Equal(t, "it's", s)
+	require.NoError(t, db.QueryRow(`SELECT s FROM string_escape_test WHERE s = 'it\'s'`).Scan(&s))
+	assert.Equal(t, "it's", s)
+
+	var b []byte
+	require.NoError(t, db.QueryRow("SELECT s, b FROM string_escape_test WHERE id = 3").Scan(&s, &b))
+	assert.Equal(t, "tab\there\\", s)
+	assert.Equal(t, []byte("a\x00b"), b)
+
+	// Text columns cannot hold NUL, the statement fails instead of storing other text
+	_, err = db.Exec(`INSERT INTO string_escape_test VALUES (5, 'a\0b', NULL)`)
+	assert.ErrorContains(t, err, "NUL")
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM string_escape_test WHERE id = 5").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	// \% matches a literal %
+	var id int
+	require.NoError(t, db.QueryRow(`SELECT id FROM string_escape_test WHERE s LIKE '%\%'`).Scan(&id))
+	assert.Equal(t, 4, id)
+}
+
+func TestUserVariableRowNumber(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+	// User variables live in the session
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("DROP TABLE IF EXISTS row_number_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE row_number_test (name VARCHAR(20) PRIMARY KEY, score INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS row_number_test")
+	_, err = db.Exec("INSERT INTO row_number_test VALUES ('ann', 70), ('bob', 95), ('cid', 80), ('dee', 60)")
+	require.NoError(t, err)
+
+	ranking := func(query string) []string {
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		defer rows.Close()
+		var ranks []string
+		for rows.Next() {
+			var pos int
+			var name string
+			require.NoError(t, rows.Scan(&pos, &name))
+			ranks = append(ranks, fmt.Sprintf("%d:%s", pos, name))
+		}
+		require.NoError(t, rows.Err())
+		return ranks
+	}
+
+	assert.Equal(t, []string{"1:bob", "2:cid", "3:ann", "4:dee"}, ranking(
+		"SELECT @rownum := @rownum + 1 AS pos, name FROM row_number_test, (SELECT @rownum := 0) init ORDER BY score DESC"))
+
+	// The variable holds the last number afterwards
+	var last int
+	require.NoError(t, db.QueryRow("SELECT @rownum").Scan(&last))
+	assert.Equal(t, 4, last)
+
+	_, err = db.Exec("SET @rownum = 10")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"11:ann", "12:bob"}, ranking(
+		"SELECT @rownum := @rownum + 1 AS pos, name FROM row_number_test ORDER BY name LIMIT 2"))
+
+	// A derived table numbers all of its rows, whatever the outer query keeps
+	assert.Equal(t, []string{"1:bob", "2:cid"}, ranking(
+		"SELECT pos, name FROM (SELECT @rownum := @rownum + 1 AS pos, name FROM row_number_test, (SELECT @rownum := 0) init ORDER BY score DESC) ranked WHERE pos <= 2 ORDER BY pos"))
+	require.NoError(t, db.QueryRow("SELECT @rownum").Scan(&last))
+	assert.Equal(t, 4, last)
+}
+
+func TestRawPostgreSQLStatement(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// :: casts and jsonb operators are not MySQL
+	var b string
+	require.NoError(t, db.QueryRow(`/*aproxy:raw*/ SELECT '{"a":1,"b":2}'::jsonb ->> 'b'`).Scan(&b))
+	assert.Equal(t, "2", b)
+
+	_, err = db.Exec("/*aproxy:raw*/ DROP TABLE IF EXISTS raw_test")
+	require.NoError(t, err)
+	_, err = db.Exec("/*aproxy:raw*/ CREATE TABLE raw_test (id serial PRIMARY KEY, data jsonb)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS raw_test")
+
+	var id int
+	require.NoError(t, db.QueryRow(
+		`/*aproxy:raw*/ INSERT INTO raw_test (data) VALUES ($$ {"tag": "it's"} $$::jsonb) RETURNING id`).Scan(&id))
+	assert.Equal(t, 1, id)
+
+	// Placeholders of prepared statements still work, numbered ones leave ? to jsonb
+	var tag string
+	require.NoError(t, db.QueryRow("/*aproxy:raw*/ SELECT data->>'tag' FROM raw_test WHERE id = ?", id).Scan(&tag))
+	assert.Equal(t, "it's", tag)
+	require.NoError(t, db.QueryRow("/*aproxy:raw*/ SELECT data->>'tag' FROM raw_test WHERE id = $1 AND data ? 'tag'", id).Scan(&tag))
+	assert.Equal(t, "it's", tag)
+}
+
+func TestCreateViewWithFunctions(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP VIEW IF EXISTS view_test_names")
+	_, _ = db.Exec("DROP TABLE IF EXISTS view_test_users")
+	_, err = db.Exec("CREATE TABLE view_test_users (id INT PRIMARY KEY, first VARCHAR(20), last VARCHAR(20))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS view_test_users")
+	_, err = db.Exec("INSERT INTO view_test_users VALUES (1, 'Ada', 'Lovelace'), (2, 'Alan', 'Turing')")
+	require.NoError(t, err)
+
+	_, err = db.Exec("CREATE ALGORITHM=MERGE DEFINER=CURRENT_USER SQL SECURITY DEFINER VIEW view_test_names AS " +
+		"SELECT id, CONCAT(first, ' ', last) AS name, NOW() AS seen FROM view_test_users")
+	require.NoError(t, err)
+	defer db.Exec("DROP VIEW IF EXISTS view_test_names")
+
+	var name, seen string
+	require.NoError(t, db.QueryRow("SELECT name, seen FROM view_test_names WHERE id = 2").Scan(&name, &seen))
+	assert.Equal(t, "Alan Turing", name)
+	assert.NotEmpty(t, seen)
+
+	// ALTER VIEW replaces the definition, keeping the columns
+	_, err = db.Exec("ALTER VIEW view_test_names AS SELECT id, CONCAT(last, ', ', first) AS name, NOW() AS seen FROM view_test_users")
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRow("SELECT name FROM view_test_names WHERE id = 1").Scan(&name))
+	assert.Equal(t, "Lovelace, Ada", name)
+
+	_, err = db.Exec("DROP VIEW IF EXISTS view_test_names")
+	require.NoError(t, err)
+	_, err = db.Exec("DROP VIEW IF EXISTS view_test_names")
+	assert.NoError(t, err)
+}
+
+func TestSetTransactionReadOnly(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, _ = conn.ExecContext(ctx, "DROP TABLE IF EXISTS tx_mode_test")
+	_, err = conn.ExecContext(ctx, "CREATE TABLE tx_mode_test (id INT PRIMARY KEY)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS tx_mode_test")
+
+	assertReadOnly := func(err error) {
+		t.Helper()
+		var myErr *mysqldriver.MySQLError
+		require.ErrorAs(t, err, &myErr)
+		assert.Equal(t, uint16(1792), myErr.Number)
+		assert.Equal(t, "25006", string(myErr.SQLState[:]))
+	}
+
+	// The next transaction only
+	_, err = conn.ExecContext(ctx, "SET TRANSACTION READ ONLY")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "BEGIN")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "INSERT INTO tx_mode_test VALUES (1)")
+	assertReadOnly(err)
+	_, err = conn.ExecContext(ctx, "ROLLBACK")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "INSERT INTO tx_mode_test VALUES (1)")
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(ctx, "START TRANSACTION READ ONLY")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "INSERT INTO tx_mode_test VALUES (2)")
+	assertReadOnly(err)
+	_, err = conn.ExecContext(ctx, "ROLLBACK")
+	require.NoError(t, err)
+
+	// Inside a transaction START TRANSACTION READ ONLY is not kept for the next one
+	_, err = conn.ExecContext(ctx, "BEGIN")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "START TRANSACTION READ ONLY")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "BEGIN")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "INSERT INTO tx_mode_test VALUES (2)")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "ROLLBACK")
+	require.NoError(t, err)
+
+	// Every later transaction of the session
+	_, err = conn.ExecContext(ctx, "SET SESSION TRANSACTION READ ONLY")
+	require.NoError(t, err)
+	var readOnly string
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT @@transaction_read_only").Scan(&readOnly))
+	assert.Contains(t, []string{"1", "ON"}, readOnly)
+	_, err = conn.ExecContext(ctx, "INSERT INTO tx_mode_test VALUES (3)")
+	assertReadOnly(err)
+
+	_, err = conn.ExecContext(ctx, "SET SESSION TRANSACTION READ WRITE")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "INSERT INTO tx_mode_test VALUES (3)")
+	require.NoError(t, err)
+}
+
+func TestGreatestLeastWithNull(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _ = db.Exec("DROP TABLE IF EXISTS greatest_test")
+	_, err = db.Exec("CREATE TABLE greatest_test (id INT PRIMARY KEY, a INT, b INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS greatest_test")
+	_, err = db.Exec("INSERT INTO greatest_test VALUES (1, 3, 7), (2, 5, NULL)")
+	require.NoError(t, err)
+
+	var greatest, least sql.NullInt64
+	require.NoError(t, db.QueryRow("SELECT GREATEST(a, b), LEAST(a, b) FROM greatest_test WHERE id = 1").Scan(&greatest, &least))
+	assert.Equal(t, int64(7), greatest.Int64)
+	assert.Equal(t, int64(3), least.Int64)
+
+	// Any NULL argument makes the result NULL, as in MySQL
+	require.NoError(t, db.QueryRow("SELECT GREATEST(a, b), LEAST(a, b, 0) FROM greatest_test WHERE id = 2").Scan(&greatest, &least))
+	assert.False(t, greatest.Valid)
+	assert.False(t, least.Valid)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM greatest_test WHERE GREATEST(a, b) > 4").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestCastTemporalStrings(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT CAST('2024-1-2' AS DATE)", "2024-01-02"},
+		{"SELECT CAST('2024/01/02' AS DATE)", "2024-01-02"},
+		{"SELECT CAST(20240102 AS DATE)", "2024-01-02"},
+		{"SELECT CAST('24-1-2 3:4:5' AS DATETIME)", "2024-01-02 03:04:05"},
+		{"SELECT CAST('2024-01-02' AS DATETIME)", "2024-01-02 00:00:00"},
+		{"SELECT CAST('3:4:5' AS TIME)", "03:04:05"},
+		{"SELECT CAST('2024-01-02 03:04:05.678' AS DATETIME)", "2024-01-02 03:04:06"},
+		{"SELECT CAST('03:04:05.4' AS TIME)", "03:04:05"},
+	}
+	for _, tt := range tests {
+		var value string
+		require.NoError(t, db.QueryRow(tt.query).Scan(&value), tt.query)
+		assert.Equal(t, tt.expected, value, tt.query)
+	}
+
+	var invalid sql.NullString
+	require.NoError(t, db.QueryRow("SELECT CAST('2023-02-29' AS DATE)").Scan(&invalid))
+	assert.False(t, invalid.Valid)
+
+	var value string
+	require.NoError(t, db.QueryRow("SELECT CAST(? AS DATE)", "2024-01-02").Scan(&value))
+	assert.Equal(t, "2024-01-02", value)
+}
+
+func TestLockClausesPerQueryBlock(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS lock_parent, lock_child")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE lock_parent (id INT PRIMARY KEY, note VARCHAR(50))")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE lock_child (id INT PRIMARY KEY, parent_id INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS lock_parent, lock_child")
+
+	_, err = db.Exec("INSERT INTO lock_parent VALUES (1, 'LOCK IN SHARE MODE'), (2, 'b')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO lock_child VALUES (10, 1), (11, 2)")
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// PostgreSQL takes no lock clause next to an aggregate, rows are counted here
+	queries := []string{
+		"SELECT p.id FROM lock_parent p JOIN (SELECT parent_id FROM lock_child LOCK IN SHARE MODE) AS c ON p.id = c.parent_id",
+		"SELECT id FROM lock_parent WHERE id IN (SELECT parent_id FROM lock_child FOR UPDATE) LOCK IN SHARE MODE NOWAIT",
+	}
+	for _, query := range queries {
+		rows, err := tx.Query(query)
+		require.NoError(t, err, query)
+		count := 0
+		for rows.Next() {
+			count++
+		}
+		require.NoError(t, rows.Err())
+		rows.Close()
+		assert.Equal(t, 2, count, query)
+	}
+
+	// The phrase inside a string is data, not a lock clause
+	var note string
+	require.NoError(t, tx.QueryRow("SELECT note FROM lock_parent WHERE note = 'LOCK IN SHARE MODE'").Scan(&note))
+	assert.Equal(t, "LOCK IN SHARE MODE", note)
+}
+
+func TestCaseMatchesCaseInsensitively(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS ci_case_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE ci_case_test (id INT PRIMARY KEY, status VARCHAR(20), code VARCHAR(20) COLLATE utf8mb4_bin)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS ci_case_test")
+
+	_, err = db.Exec("INSERT INTO ci_case_test VALUES (1, 'ACTIVE', 'ACTIVE')")
+	require.NoError(t, err)
+
+	var byStatus, byCode, byParam string
+	require.NoError(t, db.QueryRow(
+		"SELECT CASE status WHEN 'active' THEN 'on' ELSE 'off' END, CASE code WHEN 'active' THEN 'on' ELSE 'off' END, "+
+			"CASE status WHEN ? THEN 'on' ELSE 'off' END FROM ci_case_test WHERE id = 1", "Active").Scan(&byStatus, &byCode, &byParam))
+	assert.Equal(t, "on", byStatus, "_ci matches every case")
+	assert.Equal(t, "off", byCode, "a _bin column stays case-sensitive")
+	assert.Equal(t, "on", byParam)
+
+	// Comparisons match the same rows as CASE
+	for query, want := range map[string]int{
+		"SELECT COUNT(*) FROM ci_case_test WHERE status = 'active'":         1,
+		"SELECT COUNT(*) FROM ci_case_test WHERE status IN ('Active', 'x')": 1,
+		"SELECT COUNT(*) FROM ci_case_test WHERE code = 'active'":           0,
+	} {
+		var count int
+		require.NoError(t, db.QueryRow(query).Scan(&count), query)
+		assert.Equal(t, want, count, query)
+	}
+}
+
+func TestTablesCreatedOutsideTheProxy(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("/*aproxy:raw*/ DROP TABLE IF EXISTS pg_created_test")
+	require.NoError(t, err)
+	_, err = db.Exec("/*aproxy:raw*/ CREATE TABLE pg_created_test (id serial PRIMARY KEY, email varchar(50) UNIQUE, " +
+		`code varchar(20) COLLATE "C", visits integer, seen timestamp)`)
+	require.NoError(t, err)
+	defer db.Exec("/*aproxy:raw*/ DROP TABLE IF EXISTS pg_created_test")
+
+	_, err = db.Exec("INSERT INTO pg_created_test VALUES (NULL, 'Ann@Example.com', 'ABC', 1, '2024-01-02 03:04:05')")
+	require.NoError(t, err)
+
+	for query, want := range map[string]int{
+		"SELECT COUNT(*) FROM pg_created_test WHERE email = 'ann@example.com'":              1,
+		"SELECT COUNT(*) FROM pg_created_test WHERE code = 'abc'":                           0,
+		"SELECT COUNT(*) FROM pg_created_test WHERE visits = '1'":                           1,
+		"SELECT COUNT(*) FROM pg_created_test WHERE seen >= '2024-01-02'":                   1,
+		"SELECT COUNT(*) FROM pg_created_test WHERE seen BETWEEN '2024-1-2' AND '2024-1-3'": 1,
+	} {
+		var count int
+		require.NoError(t, db.QueryRow(query).Scan(&count), query)
+		assert.Equal(t, want, count, query)
+	}
+
+	// ON DUPLICATE KEY UPDATE conflicts on the UNIQUE column the INSERT writes
+	_, err = db.Exec("INSERT INTO pg_created_test (email, visits) VALUES ('Ann@Example.com', 1) ON DUPLICATE KEY UPDATE visits = visits + 1")
+	require.NoError(t, err)
+	var visits int
+	require.NoError(t, db.QueryRow("SELECT visits FROM pg_created_test WHERE id = 1").Scan(&visits))
+	assert.Equal(t, 2, visits)
+}
+
+func TestShowReplicationStatusStubs(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	tests := []struct {
+		query   string
+		columns int
+		first   string
+	}{
+		{"SHOW MASTER STATUS", 5, "File"},
+		{"SHOW BINARY LOG STATUS", 5, "File"},
+		{"SHOW SLAVE STATUS", 60, "Slave_IO_State"},
+		{"SHOW REPLICA STATUS", 60, "Replica_IO_State"},
+	}
+	for _, tt := range tests {
+		rows, err := db.Query(tt.query)
+		require.NoError(t, err, tt.query)
+		columns, err := rows.Columns()
+		require.NoError(t, err)
+		assert.Len(t, columns, tt.columns, tt.query)
+		assert.Equal(t, tt.first, columns[0], tt.query)
+		assert.False(t, rows.Next(), "%s returns no row", tt.query)
+		rows.Close()
+	}
+}
+
+func TestShowEngineInnodbStatus(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query("SHOW ENGINE INNODB STATUS")
+	require.NoError(t, err)
+	defer rows.Close()
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Type", "Name", "Status"}, columns)
+
+	require.True(t, rows.Next())
+	var engine, name, status string
+	require.NoError(t, rows.Scan(&engine, &name, &status))
+	assert.Equal(t, "InnoDB", engine)
+	assert.Equal(t, "", name)
+	assert.Contains(t, status, "TRANSACTIONS")
+	assert.Contains(t, status, "END OF INNODB MONITOR OUTPUT")
+	assert.False(t, rows.Next())
+}
+
+func TestIntervalArithmeticInSelectList(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test?parseTime=true")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS interval_select_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE interval_select_test (id INT PRIMARY KEY, created_at DATETIME)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS interval_select_test")
+
+	_, err = db.Exec("INSERT INTO interval_select_test VALUES (1, '2024-01-01 10:00:00'), (2, '2024-01-01 23:30:00')")
+	require.NoError(t, err)
+
+	var next time.Time
+	require.NoError(t, db.QueryRow("SELECT created_at + INTERVAL 1 HOUR AS next FROM interval_select_test WHERE id = 1").Scan(&next))
+	assert.Equal(t, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), next.UTC())
+
+	rows, err := db.Query("SELECT created_at + INTERVAL 1 HOUR FROM interval_select_test WHERE id = 1")
+	require.NoError(t, err)
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	rows.Close()
+	assert.Equal(t, []string{"created_at + INTERVAL 1 HOUR"}, columns)
+
+	// Grouped and ordered by the shifted day
+	rows, err = db.Query("SELECT DATE(created_at + INTERVAL 1 HOUR) AS day, COUNT(*) FROM interval_select_test " +
+		"GROUP BY DATE(created_at + INTERVAL 1 HOUR) ORDER BY DATE(created_at + INTERVAL 1 HOUR)")
+	require.NoError(t, err)
+	defer rows.Close()
+	var days []string
+	for rows.Next() {
+		var day time.Time
+		var count int
+		require.NoError(t, rows.Scan(&day, &count))
+		days = append(days, day.Format("2006-01-02"))
+		assert.Equal(t, 1, count)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"2024-01-01", "2024-01-02"}, days)
+}
+
+func TestCoalesceMixedTypes(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS mixed_coalesce_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE mixed_coalesce_test (id INT PRIMARY KEY, qty INT, name VARCHAR(20))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS mixed_coalesce_test")
+
+	_, err = db.Exec("INSERT INTO mixed_coalesce_test VALUES (1, 5, 'a'), (2, NULL, NULL)")
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT COALESCE(qty, 'none'), IFNULL(qty, '0'), COALESCE(name, 0) FROM mixed_coalesce_test ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var got [][]string
+	for rows.Next() {
+		var qty, zero, name string
+		require.NoError(t, rows.Scan(&qty, &zero, &name))
+		got = append(got, []string{qty, zero, name})
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][]string{{"5", "5", "a"}, {"none", "0", "0"}}, got)
+}
+
+func TestExpressionDefaults(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS expr_default_test")
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE expr_default_test (
+		id INT PRIMARY KEY,
+		token CHAR(36) DEFAULT (UUID()),
+		qty INT DEFAULT (1 + 2),
+		created DATETIME DEFAULT (CURRENT_TIMESTAMP),
+		expires DATETIME DEFAULT (NOW() + INTERVAL 1 DAY)
+	)`)
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS expr_default_test")
+
+	_, err = db.Exec("INSERT INTO expr_default_test (id) VALUES (1)")
+	require.NoError(t, err)
+
+	var token string
+	var qty int
+	var later bool
+	err = db.QueryRow("SELECT token, qty, expires > created FROM expr_default_test WHERE id = 1").Scan(&token, &qty, &later)
+	require.NoError(t, err)
+	assert.Len(t, token, 36)
+	assert.Equal(t, 3, qty)
+	assert.True(t, later)
+}
+
+func TestPreparedSelectParamTypes(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	stmt, err := db.Prepare("SELECT ?, ?, ?")
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	rows, err := stmt.Query(42, 1.5, "x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, "BIGINT", columns[0].DatabaseTypeName())
+	assert.Equal(t, "DOUBLE", columns[1].DatabaseTypeName())
+
+	require.True(t, rows.Next())
+	var n int
+	var f float64
+	var s string
+	require.NoError(t, rows.Scan(&n, &f, &s))
+	assert.Equal(t, 42, n)
+	assert.Equal(t, 1.5, f)
+	assert.Equal(t, "x", s)
+}
+
+func TestTruncateAndDropMultipleTables(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, table := range []string{"multi_table_a", "multi_table_b"} {
+		_, err = db.Exec("DROP TABLE IF EXISTS " + table)
+		require.NoError(t, err)
+		_, err = db.Exec("CREATE TABLE " + table + " (id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(20))")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO " + table + " (name) VALUES ('x'), ('y')")
+		require.NoError(t, err)
+	}
+	defer db.Exec("DROP TABLE IF EXISTS multi_table_a, multi_table_b")
+
+	_, err = db.Exec("TRUNCATE TABLE multi_table_a, multi_table_b")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT (SELECT COUNT(*) FROM multi_table_a) + (SELECT COUNT(*) FROM multi_table_b)").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	// TRUNCATE resets AUTO_INCREMENT, as in MySQL
+	result, err := db.Exec("INSERT INTO multi_table_a (name) VALUES ('z')")
+	require.NoError(t, err)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	_, err = db.Exec("DROP TABLE IF EXISTS multi_table_a, multi_table_b, multi_table_missing")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT 1 FROM multi_table_b")
+	assert.Error(t, err)
+}
+
+func TestDropTemporaryTableKeepsPermanentTable(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "DROP TABLE IF EXISTS temp_drop_orders")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "CREATE TABLE temp_drop_orders (id INT PRIMARY KEY)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS temp_drop_orders")
+	_, err = conn.ExecContext(ctx, "INSERT INTO temp_drop_orders VALUES (1)")
+	require.NoError(t, err)
+
+	// No temporary table of that name exists, the permanent one must not be dropped
+	_, err = conn.ExecContext(ctx, "DROP TEMPORARY TABLE IF EXISTS temp_drop_orders")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM temp_drop_orders").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// A temporary table shadowing it is dropped, the permanent one is visible again
+	_, err = conn.ExecContext(ctx, "CREATE TEMPORARY TABLE temp_drop_orders (id INT)")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "DROP TEMPORARY TABLE temp_drop_orders")
+	require.NoError(t, err)
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM temp_drop_orders").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestShowColumnsEnumType(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS enum_type_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE enum_type_test (id INT PRIMARY KEY, status ENUM('new','paid'), tags SET('a','b'))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS enum_type_test")
+
+	for _, query := range []string{"SHOW COLUMNS FROM enum_type_test", "DESCRIBE enum_type_test"} {
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+
+		types := map[string]string{}
+		for rows.Next() {
+			var field, tp string
+			var null, key, extra sql.NullString
+			var def sql.NullString
+			require.NoError(t, rows.Scan(&field, &tp, &null, &def, &key, &extra))
+			types[field] = tp
+		}
+		require.NoError(t, rows.Err())
+		rows.Close()
+
+		assert.Equal(t, "enum('new','paid')", types["status"], query)
+		assert.Equal(t, "set('a','b')", types["tags"], query)
+	}
+}
+
+func TestInsertSet(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS insert_set_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE insert_set_test (id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(20), qty INT DEFAULT 5)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS insert_set_test")
+
+	result, err := db.Exec("INSERT INTO insert_set_test SET id = NULL, name = CONCAT('wid', 'get'), qty = DEFAULT")
+	require.NoError(t, err)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	var name string
+	var qty int
+	require.NoError(t, db.QueryRow("SELECT name, qty FROM insert_set_test WHERE id = ?", id).Scan(&name, &qty))
+	assert.Equal(t, "widget", name)
+	assert.Equal(t, 5, qty)
+
+	_, err = db.Exec("INSERT INTO insert_set_test SET id = ?, name = ?, qty = 1 ON DUPLICATE KEY UPDATE qty = qty + VALUES(qty)", id, "widget")
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRow("SELECT qty FROM insert_set_test WHERE id = ?", id).Scan(&qty))
+	assert.Equal(t, 6, qty)
+}
+
+func TestDecimalPrecision(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS decimal_precision_test")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE decimal_precision_test (id INT PRIMARY KEY, amount DECIMAL(30,10))")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS decimal_precision_test")
+
+	// A float64 holds 15-17 significant digits, this value has 30
+	const exact = "12345678901234567890.1234567890"
+	_, err = db.Exec("INSERT INTO decimal_precision_test VALUES (1, "+exact+"), (2, ?)", exact)
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM decimal_precision_test WHERE amount = "+exact).Scan(&count))
+	assert.Equal(t, 2, count)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM decimal_precision_test WHERE amount > ?", "12345678901234567890.1234567889").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// Text protocol
+	var amount, sum string
+	require.NoError(t, db.QueryRow("SELECT amount FROM decimal_precision_test WHERE id = 1").Scan(&amount))
+	assert.Equal(t, exact, amount)
+	require.NoError(t, db.QueryRow("SELECT amount + 0.0000000001, SUM(amount) FROM decimal_precision_test WHERE id = 1 GROUP BY amount").Scan(&amount, &sum))
+	assert.Equal(t, "12345678901234567890.1234567891", amount)
+	assert.Equal(t, exact, sum)
+
+	// Binary protocol
+	require.NoError(t, db.QueryRow("SELECT amount FROM decimal_precision_test WHERE id = ?", 2).Scan(&amount))
+	assert.Equal(t, exact, amount)
+}
+
+func TestScalarSubqueryLimit(t *testing.T) {
+	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, table := range []string{"scalar_limit_users", "scalar_limit_orders"} {
+		_, err = db.Exec("DROP TABLE IF EXISTS " + table)
+		require.NoError(t, err)
+	}
+	_, err = db.Exec("CREATE TABLE scalar_limit_users (id INT PRIMARY KEY, name VARCHAR(20))")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE scalar_limit_orders (id INT PRIMARY KEY, user_id INT, total INT)")
+	require.NoError(t, err)
+	defer db.Exec("DROP TABLE IF EXISTS scalar_limit_users, scalar_limit_orders")
+
+	_, err = db.Exec("INSERT INTO scalar_limit_users VALUES (1, 'ann'), (2, 'bob'), (3, 'cid')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO scalar_limit_orders VALUES (1, 1, 10), (2, 1, 30), (3, 1, 20), (4, 2, 5)")
+	require.NoError(t, err)
+
+	// Latest order of each user, NULL for users without orders
+	rows, err := db.Query("SELECT u.id, (SELECT o.total FROM scalar_limit_orders o WHERE o.user_id = u.id " +
+		"ORDER BY o.id DESC LIMIT 1) AS last_total FROM scalar_limit_users u ORDER BY u.id")
+	require.NoError(t, err)
+	totals := map[int]sql.NullInt64{}
+	for rows.Next() {
+		var id int
+		var total sql.NullInt64
+		require.NoError(t, rows.Scan(&id, &total))
+		totals[id] = total
+	}
+	require.NoError(t, rows.Err())
+	rows.Close()
+	assert.Equal(t, map[int]sql.NullInt64{
+		1: {Int64: 20, Valid: true},
+		2: {Int64: 5, Valid: true},
+		3: {},
+	}, totals)
+
+	// Second largest order, with the comma form and placeholders
+	var second int
+	require.NoError(t, db.QueryRow("SELECT (SELECT o.total FROM scalar_limit_orders o WHERE o.user_id = u.id "+
+		"ORDER BY o.total DESC LIMIT ?, ?) FROM scalar_limit_users u WHERE u.id = ?", 1, 1, 1).Scan(&second))
+	assert.Equal(t, 20, second)
+}